@@ -0,0 +1,94 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/scheduler"
+)
+
+func printAudit(cmd *cobra.Command, args []string) {
+	outputFormat, err := cmd.Flags().GetString("output")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if outputFormat != "table" && outputFormat != "json" {
+		log.Fatal("Unknown output format. Expected one of: table, json")
+	}
+	name, err := cmd.Flags().GetString("audit-configmap")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if name == "" {
+		log.Fatal("Name of audit log must be set with --audit-configmap")
+	}
+	runID, err := cmd.Flags().GetString("run")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	c, err := newClient(cmd, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	entries, err := client.NewAuditLog(c.ConfigMaps(), name).Entries()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if runID != "" {
+		var filtered []client.AuditEntry
+		for _, entry := range entries {
+			if entry.RunID == runID {
+				filtered = append(filtered, entry)
+			}
+		}
+		entries = filtered
+	}
+
+	if outputFormat == "json" {
+		data, err := json.Marshal(entries)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	for _, line := range scheduler.AuditAsTable(entries) {
+		fmt.Println(line)
+	}
+}
+
+// InitAuditCommand returns cobra command for printing audit log of mutations made by AppController
+func InitAuditCommand() *cobra.Command {
+	audit := &cobra.Command{
+		Use:   "audit [URL]",
+		Short: "Print audit log of changes made to the cluster",
+		Long: "Print every create, update, patch and delete request sent by AppController commands run with " +
+			"--audit-configmap flag, with its time, run ID, object, outcome and change",
+		Run: printAudit,
+	}
+
+	var outputFormat string
+	audit.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format: table or json. JSON includes diffs of the changes")
+	var runID string
+	audit.Flags().StringVar(&runID, "run", "", "ID of the graph run to print changes of")
+	return audit
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api"
 	"k8s.io/client-go/pkg/api/errors"
 	"k8s.io/client-go/pkg/apis/extensions/v1beta1"
 
@@ -105,8 +106,11 @@ func bootstrap(cmd *cobra.Command, args []string) {
 	dependencyTPR := getDependencyFromPath(thirdPartyResourcesPath + "/dependencies.json")
 	definitionTPR := getDependencyFromPath(thirdPartyResourcesPath + "/resdefs.json")
 
-	url := os.Getenv("KUBERNETES_CLUSTER_URL")
-	config, err := client.GetConfig(url)
+	opts, err := clientOptions(cmd, os.Getenv("KUBERNETES_CLUSTER_URL"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	config, err := opts.RESTConfig()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -118,6 +122,21 @@ func bootstrap(cmd *cobra.Command, args []string) {
 
 	checkVersion(c)
 
+	groups, err := c.Discovery().ServerGroups()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if (client.Client{APIVersions: groups}).IsEnabled(client.CRDGroupVersion) {
+		namespace := os.Getenv("KUBERNETES_AC_POD_NAMESPACE")
+		if namespace == "" {
+			namespace = api.NamespaceDefault
+		}
+		if err = client.EnsureCustomResourceDefinitions(config, namespace); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	createTPRIfNotExists(dependencyTPR, c)
 	createTPRIfNotExists(definitionTPR, c)
 }
@@ -126,6 +145,6 @@ func bootstrap(cmd *cobra.Command, args []string) {
 var Bootstrap = &cobra.Command{
 	Use:   "bootstrap",
 	Short: "Bootstrap AppController",
-	Long:  "Create ThirdPartyResources required for AppController pod to function properly",
+	Long:  "Create CustomResourceDefinitions (or ThirdPartyResources on clusters without them) required for AppController pod to function properly, migrating objects of existing ThirdPartyResources",
 	Run:   bootstrap,
 }
@@ -0,0 +1,83 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// setCheckpointApproval returns command function setting approval of checkpoint given as argument
+func setCheckpointApproval(approval string) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			log.Fatal("Checkpoint name is required")
+		}
+		// checkpoint name is the only argument, so cluster URL is taken from environment
+		c, err := newClient(cmd, nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err = resources.SetCheckpointApproval(c.ConfigMaps(), args[0], approval); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Checkpoint %s is %s", args[0], approval)
+	}
+}
+
+func listCheckpoints(cmd *cobra.Command, args []string) {
+	c, err := newClient(cmd, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	names, err := resources.PendingCheckpoints(c.ConfigMaps())
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, name := range names {
+		fmt.Println(name)
+	}
+}
+
+// InitCheckpointCommand returns cobra command for listing, approving and rejecting checkpoints
+func InitCheckpointCommand() *cobra.Command {
+	checkpoint := &cobra.Command{
+		Use:   "checkpoint",
+		Short: "Manage checkpoints of AppController graph",
+		Long:  "List checkpoints waiting for approval, approve or reject them. Dependents of a checkpoint are created once it is approved, rejected checkpoint fails",
+	}
+	checkpoint.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List checkpoints waiting for approval",
+			Run:   listCheckpoints,
+		},
+		&cobra.Command{
+			Use:   "approve NAME",
+			Short: "Approve checkpoint",
+			Run:   setCheckpointApproval(resources.CheckpointApproved),
+		},
+		&cobra.Command{
+			Use:   "reject NAME",
+			Short: "Reject checkpoint",
+			Run:   setCheckpointApproval(resources.CheckpointRejected),
+		},
+	)
+	return checkpoint
+}
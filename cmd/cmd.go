@@ -16,8 +16,16 @@ package cmd
 
 import (
 	"log"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+	"github.com/Mirantis/k8s-AppController/pkg/scheduler"
 )
 
 // RootCmd is top-level AppController command. It is not executable, but it has sub-commands attached
@@ -34,9 +42,287 @@ func Init() {
 		log.Fatal(err)
 	}
 
+	addWrapFlags()
+
+	RootCmd = newRootCommand("kubeac")
+	RootCmd.AddCommand(Bootstrap, run, Wrap, status, InitDestroyCommand(), InitValidateCommand(), InitGraphCommand(), InitImportGraphCommand(), InitStatusCommand(), InitCheckpointCommand(), InitRunsCommand(), InitRevisionsCommand(), InitDiffCommand(), InitMigratePetSetsCommand(), InitAuditCommand(), InitEncryptCommand())
+}
+
+// newRootCommand returns top-level command with persistent logging and client flags
+func newRootCommand(use string) *cobra.Command {
+	root := &cobra.Command{Use: use, PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		setupLogging(cmd, args)
+		loadPlugins(cmd)
+	}}
+	root.PersistentFlags().String("log-level", "info", "Minimal level of log messages: debug, info, warning or error")
+	root.PersistentFlags().String("log-format", "text", "Format of log messages: text or json")
+	root.PersistentFlags().StringSlice("plugin", nil, "Path to Go plugin registering additional resource kinds. May be repeated")
+	addClientFlags(root)
+	return root
+}
+
+// addWrapFlags adds flags of wrap command, unless they were added already
+func addWrapFlags() {
+	if Wrap.Flags().Lookup("format") != nil {
+		return
+	}
 	var format string
 	Wrap.Flags().StringVarP(&format, "format", "f", "yaml", "file format")
+	Wrap.Flags().Bool("yaml-stream", false, "Emit all definitions as a single YAML stream, regardless of input formats")
+	Wrap.Flags().Bool("emit-deps", false, "Emit Dependencies inferred from references between wrapped objects")
+	Wrap.Flags().String("helm", "", "Helm chart to render and wrap instead of reading files")
+	Wrap.Flags().StringSlice("helm-values", nil, "Values file of Helm chart. May be repeated")
+	Wrap.Flags().StringSlice("helm-set", nil, "Value of Helm chart, e.g. --helm-set image.tag=1.11. May be repeated")
+	Wrap.Flags().String("kustomize", "", "Kustomize overlay directory to render and wrap instead of reading files")
+}
+
+// setupLogging configures logger according to persistent root command flags
+func setupLogging(cmd *cobra.Command, args []string) {
+	levelName, err := cmd.Flags().GetString("log-level")
+	if err != nil {
+		log.Fatal(err)
+	}
+	level, err := logging.ParseLevel(levelName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	logging.SetLevel(level)
+
+	format, err := cmd.Flags().GetString("log-format")
+	if err != nil {
+		log.Fatal(err)
+	}
+	switch format {
+	case "text":
+		logging.SetJSON(false)
+	case "json":
+		logging.SetJSON(true)
+	default:
+		log.Fatalf("Unknown log format %s. Expected one of: text, json", format)
+	}
+}
+
+// loadPlugins loads Go plugins given by persistent root command flag, so that their kinds are registered
+// before any command is run
+func loadPlugins(cmd *cobra.Command) {
+	paths, err := cmd.Flags().GetStringSlice("plugin")
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, path := range paths {
+		if err = resources.LoadPlugin(path); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// addClientFlags adds persistent flags describing how to connect and authenticate to the cluster
+func addClientFlags(cmd *cobra.Command) {
+	flags := cmd.PersistentFlags()
+	flags.String("kubeconfig", "", "Path to kubeconfig file. Defaults to KUBECONFIG env variable")
+	flags.String("context", "", "Name of kubeconfig context to use instead of the current one")
+	flags.StringP("namespace", "n", "", "Namespace of AppController objects. Overrides KUBERNETES_AC_POD_NAMESPACE env variable and namespace of kubeconfig context")
+	flags.String("token", "", "Bearer token for authentication to the API server")
+	flags.String("token-file", "", "Path to file with bearer token for authentication to the API server")
+	flags.String("client-certificate", "", "Path to client certificate file for TLS authentication")
+	flags.String("client-key", "", "Path to client key file for TLS authentication")
+	flags.String("certificate-authority", "", "Path to certificate authority file of the API server")
+	flags.Bool("insecure-skip-tls-verify", false, "Do not verify certificate of the API server")
+	flags.String("auth-exec", "", "Command printing bearer token or ExecCredential JSON used for authentication")
+	flags.Float32("qps", 0, "Maximum number of requests per second to the API server, 0 means client default (5)")
+	flags.Int("burst", 0, "Maximum burst of requests to the API server above --qps, 0 means client default (10)")
+	flags.String("content-type", "protobuf", "Serialization of built-in kinds in requests to the API server, one of: protobuf, json")
+	flags.String("audit-configmap", "", "Name of audit log kept in config maps, every create, update, patch and delete request is recorded in it. Built-in kinds are sent as JSON while auditing")
+	flags.Int("request-timeout", int(scheduler.RequestTimeout/time.Second), "Time in seconds within which a single status check, creation or deletion of a resource must complete, 0 means no limit. Status checks which time out are retried")
+	flags.Int("status-workers", scheduler.StatusWorkers, "Number of resources whose status is checked in parallel when collecting reports and progress of the graph")
+	flags.StringSlice("set", nil, "Parameter substituted into placeholders of resource definitions, e.g. --set tag=1.11. May be repeated, values cannot contain commas")
+	flags.String("parameters-configmap", "", "Name of config map with parameters of resource definitions")
+	flags.String("parameters-secret", "", "Name of secret with parameters of resource definitions")
+	flags.String("encryption-key", "", "Path to 32 byte key, raw or base64 encoded, decrypting resource definitions encrypted by local key provider")
+	flags.String("encryption-kms-command", "", "Command decrypting data keys of resource definitions encrypted by kms key provider. It is run with encrypt or decrypt argument, reading and printing base64")
+	flags.String("meta-defaults-configmap", "", "Name of config map with default meta of resource definitions and dependencies in its resources and dependencies keys")
+	flags.String("default-service-account", "", "Service account in the namespace of the object which objects of resource definitions without serviceAccount meta are managed on behalf of. If it is not set, they are managed with permissions of AppController")
+	flags.String("exec-checks-dir", "", "Directory with executables exec checks may run in AppController pod. Exec checks are disabled unless it is set")
+	flags.Bool("enable-petsets", false, "Support PetSets in graphs, for clusters older than Kubernetes 1.5. Use 'migrate-petsets' command to replace them by StatefulSets")
+	flags.String("source", "", "File, directory, http(s) URL or git+<repository>[#<ref>][:<path>] to read resource definitions and dependencies from instead of the cluster")
+}
+
+// newClient returns client for the cluster given by the first argument or KUBERNETES_CLUSTER_URL env variable,
+// authenticated according to persistent root command flags
+func newClient(cmd *cobra.Command, args []string) (client.Interface, error) {
+	var url string
+	if len(args) > 0 {
+		url = args[0]
+	}
+	if url == "" {
+		url = os.Getenv("KUBERNETES_CLUSTER_URL")
+	}
+	opts, err := clientOptions(cmd, url)
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.NewFromOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	// status checks are limited together with the rate of requests of the client
+	if scheduler.StatusWorkers, err = cmd.Flags().GetInt("status-workers"); err != nil {
+		return nil, err
+	}
+	requestTimeout, err := cmd.Flags().GetInt("request-timeout")
+	if err != nil {
+		return nil, err
+	}
+	scheduler.RequestTimeout = time.Duration(requestTimeout) * time.Second
+	if resources.PetSetsEnabled, err = cmd.Flags().GetBool("enable-petsets"); err != nil {
+		return nil, err
+	}
+	if scheduler.DefaultServiceAccount, err = cmd.Flags().GetString("default-service-account"); err != nil {
+		return nil, err
+	}
+	if resources.ExecChecksDir, err = cmd.Flags().GetString("exec-checks-dir"); err != nil {
+		return nil, err
+	}
+	source, err := cmd.Flags().GetString("source")
+	if err != nil {
+		return nil, err
+	}
+	if source != "" {
+		if err = client.WithSource(c, source); err != nil {
+			return nil, err
+		}
+	}
+	if err = setParameters(cmd, c); err != nil {
+		return nil, err
+	}
+	if err = setDecryption(cmd, c); err != nil {
+		return nil, err
+	}
+	return c, setMetaDefaults(cmd, c)
+}
+
+// keyProviders returns key providers given by persistent root command flags
+func keyProviders(cmd *cobra.Command) ([]client.KeyProvider, error) {
+	var providers []client.KeyProvider
+	keyFile, err := cmd.Flags().GetString("encryption-key")
+	if err != nil {
+		return nil, err
+	}
+	if keyFile != "" {
+		provider, err := client.NewLocalKeyProvider(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	kmsCommand, err := cmd.Flags().GetString("encryption-kms-command")
+	if err != nil {
+		return nil, err
+	}
+	if kmsCommand != "" {
+		provider, err := client.NewKMSKeyProvider(strings.Fields(kmsCommand))
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// setDecryption makes the client decrypt resource definitions with key providers given by persistent
+// root command flags, if any of them is set. It must be called after setParameters, as encrypted objects
+// are not rendered with parameters
+func setDecryption(cmd *cobra.Command, c client.Interface) error {
+	providers, err := keyProviders(cmd)
+	if err != nil || len(providers) == 0 {
+		return err
+	}
+	return client.WithDecryption(c, providers...)
+}
+
+// setMetaDefaults makes the client apply default meta from the config map given by persistent root
+// command flag, if it is set
+func setMetaDefaults(cmd *cobra.Command, c client.Interface) error {
+	configMap, err := cmd.Flags().GetString("meta-defaults-configmap")
+	if err != nil || configMap == "" {
+		return err
+	}
+	defaults, err := client.LoadMetaDefaults(c, configMap)
+	if err != nil {
+		return err
+	}
+	return client.WithMetaDefaults(c, defaults)
+}
 
-	RootCmd = &cobra.Command{Use: "kubeac"}
-	RootCmd.AddCommand(Bootstrap, run, Wrap, status)
+// setParameters makes the client render resource definitions with parameters given by persistent
+// root command flags, if any of them is set. Parameters set by --set override ones from config map
+// and secret
+func setParameters(cmd *cobra.Command, c client.Interface) error {
+	flags := cmd.Flags()
+	set, err := flags.GetStringSlice("set")
+	if err != nil {
+		return err
+	}
+	configMap, err := flags.GetString("parameters-configmap")
+	if err != nil {
+		return err
+	}
+	secret, err := flags.GetString("parameters-secret")
+	if err != nil {
+		return err
+	}
+	if len(set) == 0 && configMap == "" && secret == "" {
+		return nil
+	}
+
+	params, err := client.LoadParameters(c, configMap, secret)
+	if err != nil {
+		return err
+	}
+	overrides, err := client.ParseParameters(set)
+	if err != nil {
+		return err
+	}
+	for key, value := range overrides {
+		params[key] = value
+	}
+	return client.WithParameters(c, params)
+}
+
+// clientOptions returns options of connection to the cluster with given URL taken from persistent root command flags
+func clientOptions(cmd *cobra.Command, url string) (client.ConfigOptions, error) {
+	opts := client.ConfigOptions{URL: url}
+	flags := cmd.Flags()
+	for flag, value := range map[string]*string{
+		"kubeconfig":            &opts.Kubeconfig,
+		"context":               &opts.Context,
+		"token":                 &opts.Token,
+		"token-file":            &opts.TokenFile,
+		"client-certificate":    &opts.CertFile,
+		"client-key":            &opts.KeyFile,
+		"certificate-authority": &opts.CAFile,
+		"auth-exec":             &opts.ExecCommand,
+		"content-type":          &opts.ContentType,
+		"namespace":             &opts.Namespace,
+		"audit-configmap":       &opts.AuditConfigMap,
+	} {
+		var err error
+		if *value, err = flags.GetString(flag); err != nil {
+			return opts, err
+		}
+	}
+	var err error
+	if opts.Insecure, err = flags.GetBool("insecure-skip-tls-verify"); err != nil {
+		return opts, err
+	}
+	if opts.QPS, err = flags.GetFloat32("qps"); err != nil {
+		return opts, err
+	}
+	if opts.Burst, err = flags.GetInt("burst"); err != nil {
+		return opts, err
+	}
+	if opts.Kubeconfig == "" {
+		opts.Kubeconfig = os.Getenv("KUBECONFIG")
+	}
+	return opts, nil
 }
@@ -15,17 +15,26 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/pkg/api"
 	"k8s.io/client-go/pkg/labels"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/election"
+	"github.com/Mirantis/k8s-AppController/pkg/metrics"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
 	"github.com/Mirantis/k8s-AppController/pkg/scheduler"
+	"github.com/Mirantis/k8s-AppController/pkg/server"
+	"github.com/Mirantis/k8s-AppController/pkg/trigger"
 )
 
 func deploy(cmd *cobra.Command, args []string) {
@@ -43,15 +52,19 @@ func deploy(cmd *cobra.Command, args []string) {
 
 	log.Println("Using concurrency:", concurrency)
 
-	var url string
-	if len(args) > 0 {
-		url = args[0]
+	metricsAddress, err := cmd.Flags().GetString("metrics-address")
+	if err != nil {
+		log.Fatal(err)
 	}
-	if url == "" {
-		url = os.Getenv("KUBERNETES_CLUSTER_URL")
+	if metricsAddress != "" {
+		metrics.Serve(metricsAddress)
+	}
+
+	if err = addWebhooks(cmd); err != nil {
+		log.Fatal(err)
 	}
 
-	c, err := client.New(url)
+	c, err := newClient(cmd, args)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -63,32 +76,563 @@ func deploy(cmd *cobra.Command, args []string) {
 
 	log.Println("Using label selector:", labelSelector)
 
-	depGraph, err := scheduler.BuildDependencyGraph(c, sel)
+	leaderElect, err := cmd.Flags().GetBool("leader-elect")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !leaderElect {
+		runGraph(cmd, c, sel, concurrency)
+		return
+	}
+	elector, err := newElector(cmd, c)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = elector.Run(func() { runGraph(cmd, c, sel, concurrency) }, func() {
+		log.Fatal("Lost leadership, exiting so that another replica takes over")
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
+}
+
+// runGraph deploys the graph once, or keeps reconciling it in watch mode. If API address is set,
+// API server is started and the process keeps running after the deployment
+func runGraph(cmd *cobra.Command, c client.Interface, sel labels.Selector, concurrency int) {
+	apiAddress, err := cmd.Flags().GetString("api-address")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if apiAddress != "" {
+		apiToken, err := cmd.Flags().GetString("api-token")
+		if err != nil {
+			log.Fatal(err)
+		}
+		server.New(c, sel, func() error { return deployOnce(cmd, c, sel, concurrency) }).WithToken(apiToken).Serve(apiAddress)
+	}
+
+	watch, err := cmd.Flags().GetBool("watch")
+	if err != nil {
+		log.Fatal(err)
+	}
+	triggers, err := getTriggers(cmd, c)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(triggers) > 0 {
+		if watch {
+			log.Fatal("Triggers cannot be used in watch mode")
+		}
+		trigger.Run(triggers, func(reason string) error {
+			if _, running := scheduler.LastRun(); running {
+				return errors.New("graph is being deployed already")
+			}
+			return deployOnce(cmd, c, sel, concurrency)
+		}, nil)
+		return
+	}
+	if watch {
+		interval, err := cmd.Flags().GetInt("watch-interval")
+		if err != nil {
+			log.Fatal(err)
+		}
+		scheduler.Reconcile(c, sel, concurrency, time.Duration(interval)*time.Second, nil)
+		return
+	}
+
+	if err = deployOnce(cmd, c, sel, concurrency); err != nil {
+		if outcomeErr, ok := err.(outcomeError); ok {
+			log.Println(outcomeErr)
+			os.Exit(outcomeErr.outcome.ExitCode())
+		}
+		log.Fatal(err)
+	}
+	if apiAddress != "" {
+		log.Println("Serving API until the process is stopped")
+		select {}
+	}
+}
+
+// getTriggers returns triggers of graph runs set by --schedule and --trigger-* flags
+func getTriggers(cmd *cobra.Command, c client.Interface) ([]trigger.Trigger, error) {
+	var triggers []trigger.Trigger
+	spec, err := cmd.Flags().GetString("schedule")
+	if err != nil {
+		return nil, err
+	}
+	if spec != "" {
+		schedule, err := trigger.ParseSchedule(spec)
+		if err != nil {
+			return nil, err
+		}
+		triggers = append(triggers, schedule)
+	}
+
+	onConfigMaps, err := cmd.Flags().GetBool("trigger-configmaps")
+	if err != nil {
+		return nil, err
+	}
+	if onConfigMaps {
+		interval, err := cmd.Flags().GetInt("watch-interval")
+		if err != nil {
+			return nil, err
+		}
+		triggers = append(triggers, trigger.ConfigMaps{Client: c.ConfigMaps(), Interval: time.Duration(interval) * time.Second})
+	}
+
+	webhookAddress, err := cmd.Flags().GetString("trigger-webhook-address")
+	if err != nil {
+		return nil, err
+	}
+	if webhookAddress != "" {
+		token, err := cmd.Flags().GetString("trigger-webhook-token")
+		if err != nil {
+			return nil, err
+		}
+		triggers = append(triggers, trigger.Webhook{Address: webhookAddress, Token: token})
+	}
+	return triggers, nil
+}
+
+// deployOnce builds the graph and deploys it
+func deployOnce(cmd *cobra.Command, c client.Interface, sel labels.Selector, concurrency int) error {
+	if err := checkMeta(cmd, c, sel); err != nil {
+		return err
+	}
+
+	depGraph, err := scheduler.BuildDependencyGraph(c, sel)
+	if err != nil {
+		return err
+	}
+
+	depGraph, err = selectTargets(cmd, c, depGraph)
+	if err != nil {
+		return err
+	}
 
 	log.Println("Checking for circular dependencies.")
 	cycles := scheduler.DetectCycles(depGraph)
 	if len(cycles) > 0 {
 		message := "Cycles detected, terminating:\n"
+		var problems []string
 		for _, cycle := range cycles {
 			keys := make([]string, 0, len(cycle))
 			for _, vertex := range cycle {
 				keys = append(keys, vertex.Key())
 			}
 			message = fmt.Sprintf("%sCycle: %s\n", message, strings.Join(keys, ", "))
+			problems = append(problems, "Cycle: "+strings.Join(keys, ", "))
+		}
+
+		graphName, err := cmd.Flags().GetString("graph-name")
+		if err != nil {
+			return err
+		}
+		if err = writeReport(cmd, scheduler.ValidationReport(graphName, problems)); err != nil {
+			return err
 		}
+		return outcomeError{report.OutcomeValidationError, message}
+	}
+	log.Println("No cycles detected.")
+
+	if err = checkCapacity(cmd, c, depGraph); err != nil {
+		return err
+	}
+
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		return printPlan(cmd, depGraph)
+	}
+
+	deadline, err := cmd.Flags().GetInt("deadline")
+	if err != nil {
+		return err
+	}
+	depGraph.WithDeadline(time.Duration(deadline) * time.Second)
+
+	window, err := cmd.Flags().GetString("window")
+	if err != nil {
+		return err
+	}
+	if window != "" {
+		windows, err := scheduler.ParseWindows(window)
+		if err != nil {
+			return err
+		}
+		depGraph.WithWindows(windows)
+	}
+
+	polling, err := pollingOptions(cmd)
+	if err != nil {
+		return err
+	}
+	depGraph.WithPolling(polling)
+
+	rollback, err := cmd.Flags().GetBool("rollback-on-abort")
+	if err != nil {
+		return err
+	}
+	depGraph.WithRollback(rollback)
+
+	graphName, err := cmd.Flags().GetString("graph-name")
+	if err != nil {
+		return err
+	}
+	depGraph.WithName(graphName)
 
-		log.Fatal(message)
+	historyConfigMap, err := cmd.Flags().GetString("history-configmap")
+	if err != nil {
+		return err
+	}
+	keepRuns, err := cmd.Flags().GetInt("keep-runs")
+	if err != nil {
+		return err
+	}
+	if keepRuns > 0 {
+		store := scheduler.NewConfigMapRunRecordStore(c.ConfigMaps(), graphName, keepRuns)
+		depGraph.WithRunRecords(store)
+		depGraph.WithHistory(scheduler.RunRecordHistory(store))
+	}
+	keepRevisions, err := cmd.Flags().GetInt("keep-revisions")
+	if err != nil {
+		return err
+	}
+	if keepRevisions > 0 {
+		depGraph.WithRevisions(scheduler.NewConfigMapRevisionStore(c.ConfigMaps(), keepRevisions))
+	}
+	if historyConfigMap != "" {
+		depGraph.WithHistory(scheduler.NewConfigMapDurationHistory(c.ConfigMaps(), historyConfigMap))
+	}
+	dependencyStatus, err := cmd.Flags().GetBool("dependency-status")
+	if err != nil {
+		return err
+	}
+	if dependencyStatus {
+		depGraph.WithDependencyStatus(c.Dependencies())
+	}
+
+	stateConfigMap, err := cmd.Flags().GetString("state-configmap")
+	if err != nil {
+		return err
+	}
+	showProgress, err := cmd.Flags().GetBool("progress")
+	if err != nil {
+		return err
+	}
+	if showProgress {
+		display := startProgress(depGraph)
+		defer display.Stop()
+	}
+	if stateConfigMap == "" {
+		scheduler.Create(depGraph, concurrency)
 	} else {
-		log.Println("No cycles detected.")
+		log.Println("Keeping deployment state in config map", stateConfigMap)
+		store := scheduler.NewConfigMapStateStore(c.ConfigMaps(), stateConfigMap)
+		if err = scheduler.CreateWithState(depGraph, concurrency, store); err != nil {
+			return err
+		}
 	}
 
-	scheduler.Create(depGraph, concurrency)
+	runReport := depGraph.RunReport()
+	if err = writeReport(cmd, runReport); err != nil {
+		return err
+	}
+	if failed := depGraph.Failed(); len(failed) > 0 {
+		log.Println("Deployment failed, failed resources:", strings.Join(failed, ", "))
+		if err = runFailureHook(cmd, c, concurrency); err != nil {
+			return err
+		}
+		return outcomeError{runReport.Outcome, "Deployment failed with outcome " + string(runReport.Outcome)}
+	}
+
+	if err = prune(cmd, c, depGraph); err != nil {
+		return err
+	}
 
 	log.Println("Done")
+	return nil
+}
+
+// outcomeError is returned when the graph was not deployed successfully, the process exits with the
+// code of the outcome
+type outcomeError struct {
+	outcome report.Outcome
+	message string
+}
 
+func (e outcomeError) Error() string {
+	return e.message
+}
+
+// writeReport writes JSON report of the run to the file set by --report, if it is set
+func writeReport(cmd *cobra.Command, runReport report.RunReport) error {
+	path, err := cmd.Flags().GetString("report")
+	if err != nil || path == "" {
+		return err
+	}
+	return runReport.WriteFile(path)
+}
+
+// prune deletes objects which do not belong to the graph anymore, if --prune is set. Nothing is
+// pruned when only targets are deployed, as objects of the rest of the graph would be deleted
+func prune(cmd *cobra.Command, c client.Interface, depGraph scheduler.DependencyGraph) error {
+	enabled, err := cmd.Flags().GetBool("prune")
+	if err != nil || !enabled {
+		return err
+	}
+	targets, err := cmd.Flags().GetStringSlice("target")
+	if err != nil {
+		return err
+	}
+	targetLabel, err := cmd.Flags().GetString("target-label")
+	if err != nil {
+		return err
+	}
+	if len(targets) > 0 || targetLabel != "" {
+		log.Println("Only targets were deployed, skipping pruning")
+		return nil
+	}
+	whitelist, err := cmd.Flags().GetStringSlice("prune-whitelist")
+	if err != nil {
+		return err
+	}
+	pruned, err := scheduler.Prune(c, depGraph, scheduler.PruneOptions{Whitelist: whitelist})
+	if len(pruned) > 0 {
+		log.Println("Pruned resources:", strings.Join(pruned, ", "))
+	}
+	return err
+}
+
+// runFailureHook deploys the graph of resources matching --on-failure-selector, if it is set
+func runFailureHook(cmd *cobra.Command, c client.Interface, concurrency int) error {
+	hookSelector, err := cmd.Flags().GetString("on-failure-selector")
+	if err != nil || hookSelector == "" {
+		return err
+	}
+	sel, err := labels.Parse(hookSelector)
+	if err != nil {
+		return err
+	}
+	log.Println("Deploying on-failure hook graph with label selector:", hookSelector)
+	hookGraph, err := scheduler.BuildDependencyGraph(c, sel)
+	if err != nil {
+		return err
+	}
+	scheduler.Create(hookGraph, concurrency)
+	if failed := hookGraph.Failed(); len(failed) > 0 {
+		return fmt.Errorf("On-failure hook graph failed, failed resources: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// newElector returns leader elector configured by --leader-elect-* flags. Identity defaults to
+// host name, which is the pod name in the cluster
+func newElector(cmd *cobra.Command, c client.Interface) (*election.Elector, error) {
+	lockName, err := cmd.Flags().GetString("leader-elect-configmap")
+	if err != nil {
+		return nil, err
+	}
+	identity, err := cmd.Flags().GetString("leader-elect-identity")
+	if err != nil {
+		return nil, err
+	}
+	if identity == "" {
+		if identity, err = os.Hostname(); err != nil {
+			return nil, err
+		}
+	}
+	leaseDuration, err := cmd.Flags().GetInt("leader-elect-lease")
+	if err != nil {
+		return nil, err
+	}
+	return election.New(election.Config{
+		Client:        c.ConfigMaps(),
+		Name:          lockName,
+		Identity:      identity,
+		LeaseDuration: time.Duration(leaseDuration) * time.Second,
+	}), nil
+}
+
+// pollingOptions returns intervals of status checks set by flags
+func pollingOptions(cmd *cobra.Command) (scheduler.PollingOptions, error) {
+	var options scheduler.PollingOptions
+	interval, err := cmd.Flags().GetFloat64("poll-interval")
+	if err != nil {
+		return options, err
+	}
+	delay, err := cmd.Flags().GetFloat64("initial-delay")
+	if err != nil {
+		return options, err
+	}
+	if interval <= 0 || delay < 0 {
+		return options, errors.New("--poll-interval must be positive and --initial-delay must not be negative")
+	}
+	options.Interval = time.Duration(interval * float64(time.Second))
+	options.InitialDelay = time.Duration(delay * float64(time.Second))
+
+	kindIntervals, err := cmd.Flags().GetStringSlice("kind-poll-interval")
+	if err != nil {
+		return options, err
+	}
+	if options.KindIntervals, err = scheduler.ParseKindDurations(kindIntervals); err != nil {
+		return options, fmt.Errorf("invalid --kind-poll-interval: %v", err)
+	}
+	kindDelays, err := cmd.Flags().GetStringSlice("kind-initial-delay")
+	if err != nil {
+		return options, err
+	}
+	if options.KindInitialDelays, err = scheduler.ParseKindDurations(kindDelays); err != nil {
+		return options, fmt.Errorf("invalid --kind-initial-delay: %v", err)
+	}
+	return options, nil
+}
+
+// checkCapacity compares requests of pods of the graph with capacity of nodes, if --check-capacity is
+// set, and aborts the run if they can never be scheduled
+func checkCapacity(cmd *cobra.Command, c client.Interface, depGraph scheduler.DependencyGraph) error {
+	enabled, err := cmd.Flags().GetBool("check-capacity")
+	if err != nil || !enabled {
+		return err
+	}
+	nodeSelector, err := cmd.Flags().GetString("capacity-node-selector")
+	if err != nil {
+		return err
+	}
+	sel, err := labels.Parse(nodeSelector)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Checking capacity of nodes.")
+	capacity, err := scheduler.CheckCapacity(c, depGraph, sel)
+	if err != nil {
+		return err
+	}
+	for _, line := range capacity.AsText() {
+		log.Println(line)
+	}
+	if capacity.Fits() {
+		return nil
+	}
+
+	graphName, err := cmd.Flags().GetString("graph-name")
+	if err != nil {
+		return err
+	}
+	if err = writeReport(cmd, scheduler.ValidationReport(graphName, capacity.Problems)); err != nil {
+		return err
+	}
+	return outcomeError{report.OutcomeValidationError, "Graph does not fit in the cluster, terminating:\n" + strings.Join(capacity.Problems, "\n")}
+}
+
+// checkMeta checks meta keys of resource definitions and dependencies, so that misspelled keys and values
+// of wrong types do not silently fall back to defaults. Warnings are logged, errors abort the run
+func checkMeta(cmd *cobra.Command, c client.Interface, sel labels.Selector) error {
+	resDefList, err := c.ResourceDefinitions().List(api.ListOptions{LabelSelector: sel})
+	if err != nil {
+		return err
+	}
+	depList, err := c.Dependencies().List(api.ListOptions{LabelSelector: sel})
+	if err != nil {
+		return err
+	}
+
+	var messages []string
+	for _, problem := range scheduler.ValidateMeta(resDefList.Items, depList.Items) {
+		if problem.Severity == scheduler.SeverityError {
+			messages = append(messages, problem.Message)
+			continue
+		}
+		log.Printf("Warning: %s", problem.Message)
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	graphName, err := cmd.Flags().GetString("graph-name")
+	if err != nil {
+		return err
+	}
+	if err = writeReport(cmd, scheduler.ValidationReport(graphName, messages)); err != nil {
+		return err
+	}
+	return outcomeError{report.OutcomeValidationError, "Invalid meta, terminating:\n" + strings.Join(messages, "\n")}
+}
+
+// printPlan prints batches of resources in order in which they would be created
+func printPlan(cmd *cobra.Command, depGraph scheduler.DependencyGraph) error {
+	getJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return err
+	}
+	plan, err := depGraph.Plan()
+	if err != nil {
+		return err
+	}
+	if getJSON {
+		data, err := json.Marshal(plan)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	for _, line := range plan.AsText() {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// selectTargets returns part of the graph needed for resources selected by --target and --target-label
+// flags, or the whole graph if none of them is set
+func selectTargets(cmd *cobra.Command, c client.Interface, depGraph scheduler.DependencyGraph) (scheduler.DependencyGraph, error) {
+	targets, err := cmd.Flags().GetStringSlice("target")
+	if err != nil {
+		return nil, err
+	}
+	targetLabel, err := cmd.Flags().GetString("target-label")
+	if err != nil {
+		return nil, err
+	}
+	if targetLabel != "" {
+		sel, err := labels.Parse(targetLabel)
+		if err != nil {
+			return nil, err
+		}
+		selected, err := scheduler.SelectTargets(c, sel)
+		if err != nil {
+			return nil, err
+		}
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("No resource definitions match target label selector %s", targetLabel)
+		}
+		targets = append(targets, selected...)
+	}
+	if len(targets) == 0 {
+		return depGraph, nil
+	}
+	log.Println("Deploying targets:", strings.Join(targets, ", "))
+	return depGraph.Subgraph(targets)
+}
+
+// addWebhooks registers webhooks set by --webhook and --slack-webhook flags
+func addWebhooks(cmd *cobra.Command) error {
+	formats := map[string]string{"webhook": scheduler.WebhookJSON, "slack-webhook": scheduler.WebhookSlack}
+	for flag, format := range formats {
+		urls, err := cmd.Flags().GetStringSlice(flag)
+		if err != nil {
+			return err
+		}
+		for _, url := range urls {
+			if err = scheduler.AddWebhook(scheduler.Webhook{URL: url, Format: format}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 func getLabelSelector(cmd *cobra.Command) (string, error) {
@@ -111,6 +655,95 @@ func InitRunCommand() (*cobra.Command, error) {
 	var labelSelector string
 	run.Flags().StringVarP(&labelSelector, "label", "l", "", "Label selector. Overrides KUBERNETES_AC_LABEL_SELECTOR env variable in AppController pod.")
 
+	var targets []string
+	run.Flags().StringSliceVar(&targets, "target", nil, "Keys of resources to deploy (e.g. deployment/frontend). Only these resources and resources they depend on are created.")
+	var targetLabel string
+	run.Flags().StringVar(&targetLabel, "target-label", "", "Label selector of resource definitions to deploy. Only matching resources and resources they depend on are created.")
+
+	var dryRun, getJSON bool
+	run.Flags().BoolVar(&dryRun, "dry-run", false, "Print batches of resources in order in which they would be created, without creating them")
+	run.Flags().BoolVarP(&getJSON, "json", "j", false, "Output JSON in dry run mode")
+
+	var metricsAddress string
+	run.Flags().StringVar(&metricsAddress, "metrics-address", "", "Address (e.g. :9090) to expose Prometheus metrics on /metrics path while the deployment runs")
+
+	var webhooks, slackWebhooks []string
+	run.Flags().StringSliceVar(&webhooks, "webhook", nil, "URLs to POST JSON summary to when the deployment starts, completes or fails")
+	run.Flags().StringSliceVar(&slackWebhooks, "slack-webhook", nil, "Slack incoming webhook URLs to notify when the deployment starts, completes or fails")
+
+	var watch bool
+	var watchInterval int
+	run.Flags().BoolVar(&watch, "watch", false, "Keep running and deploy the graph again whenever resource definitions or dependencies change, or managed objects are changed or deleted. Targets, dry run and state flags are ignored in this mode")
+	run.Flags().IntVar(&watchInterval, "watch-interval", int(scheduler.DefaultReconcileInterval/time.Second), "Interval in seconds between checks of the graph in watch mode")
+
+	var schedule, triggerWebhookAddress, triggerWebhookToken string
+	var triggerConfigMaps bool
+	run.Flags().StringVar(&schedule, "schedule", "", "Cron schedule (e.g. \"0 2 * * *\" or @hourly) on which the graph is deployed. The process keeps running and deploys the graph only when triggered")
+	run.Flags().BoolVar(&triggerConfigMaps, "trigger-configmaps", false, "Deploy the graph whenever config maps annotated with appcontroller.k8s/trigger=true change. They are checked every --watch-interval seconds")
+	run.Flags().StringVar(&triggerWebhookAddress, "trigger-webhook-address", "", "Address (e.g. :8090) to accept POST requests on /trigger path deploying the graph")
+	run.Flags().StringVar(&triggerWebhookToken, "trigger-webhook-token", "", "Token which requests to the trigger webhook must give in X-AppController-Token header or token query parameter")
+
+	var leaderElect bool
+	var leaderElectConfigMap, leaderElectIdentity string
+	var leaderElectLease int
+	run.Flags().BoolVar(&leaderElect, "leader-elect", false, "Wait until this replica becomes the leader before deploying, so that only one of several AppController replicas deploys the graph")
+	run.Flags().StringVar(&leaderElectConfigMap, "leader-elect-configmap", "appcontroller-leader", "Name of config map used as leader election lock")
+	run.Flags().StringVar(&leaderElectIdentity, "leader-elect-identity", "", "Unique name of this replica in leader election. Host name is used by default")
+	run.Flags().IntVar(&leaderElectLease, "leader-elect-lease", int(election.DefaultLeaseDuration/time.Second), "Time in seconds after which standby replica takes over if the leader stops renewing its lease")
+
+	var deadline int
+	var onFailureSelector string
+	run.Flags().IntVar(&deadline, "deadline", 0, "Time in seconds within which the whole graph must be deployed. Resources which are not ready by then fail, 0 means no deadline")
+	var window string
+	run.Flags().StringVar(&window, "window", "", "Deployment windows separated by semicolons, each a cron schedule of its start and its duration, e.g. \"0 22 * * 1-5 4h\". Creation of resources is deferred until a window is open, the window key in meta overrides it")
+	run.Flags().StringVar(&onFailureSelector, "on-failure-selector", "", "Label selector of the graph to deploy if deployment fails, e.g. because of exceeded deadline. Resources of this graph should not match the main selector")
+
+	var pollInterval, initialDelay float64
+	var kindPollIntervals, kindInitialDelays []string
+	run.Flags().Float64Var(&pollInterval, "poll-interval", scheduler.CheckInterval.Seconds(), "Interval in seconds between checks of resource status. Overridden by poll-interval key of resource definition meta")
+	run.Flags().Float64Var(&initialDelay, "initial-delay", 0, "Time in seconds to wait after a resource is created before its status is checked for the first time. Overridden by initial-delay key of resource definition meta")
+	run.Flags().StringSliceVar(&kindPollIntervals, "kind-poll-interval", nil, "Intervals between status checks of resources of given kinds, e.g. job=10,deployment=5")
+	run.Flags().StringSliceVar(&kindInitialDelays, "kind-initial-delay", nil, "Initial delays of resources of given kinds, e.g. persistentvolumeclaim=30")
+
+	var graphName string
+	run.Flags().StringVar(&graphName, "graph-name", scheduler.DefaultGraphName, "Name of the graph put in appcontroller.k8s/graph label of created objects")
+
+	var pruneObjects bool
+	var pruneWhitelist []string
+	run.Flags().BoolVar(&pruneObjects, "prune", false, "After successful deployment delete objects labeled with the graph name which do not belong to any resource of the graph anymore")
+	run.Flags().StringSliceVar(&pruneWhitelist, "prune-whitelist", nil, "Kinds of objects to prune, e.g. configmap. All supported kinds are pruned by default")
+
+	var capacityCheck bool
+	var capacityNodeSelector string
+	run.Flags().BoolVar(&capacityCheck, "check-capacity", false, "Before the run compare CPU and memory requested by pods of the graph with allocatable capacity of nodes, and abort if they can never be scheduled")
+	run.Flags().StringVar(&capacityNodeSelector, "capacity-node-selector", "", "Label selector of nodes whose capacity is checked by --check-capacity. All nodes are used by default")
+
+	var rollbackOnAbort bool
+	run.Flags().BoolVar(&rollbackOnAbort, "rollback-on-abort", false, "Delete resources created by the run, in reverse order, if it is aborted by a resource with on-failure set to abort. Resources which existed before the run are kept")
+
+	var showProgress bool
+	run.Flags().BoolVar(&showProgress, "progress", false, "Show live view of the run in the terminal, with resources in progress, counts of resources by state and most recent errors. Logs are printed above the view")
+
+	var reportPath string
+	run.Flags().StringVar(&reportPath, "report", "", "File to write JSON report of the run to, with state, duration and error of every resource. Use - to write it to stdout")
+
+	var apiAddress string
+	run.Flags().StringVar(&apiAddress, "api-address", "", "Address to serve HTTP API on, e.g. :8080. With API the process keeps running after deployment, so that runs can be triggered through the API")
+
+	var apiToken string
+	run.Flags().StringVar(&apiToken, "api-token", "", "Bearer token which requests triggering, cancelling or pausing runs and approving checkpoints through HTTP API must give in Authorization header. Without it such requests are only accepted from localhost")
+
+	var stateConfigMap string
+	run.Flags().StringVar(&stateConfigMap, "state-configmap", "", "Name of config map to keep deployment state in. Interrupted deployment started with the same config map resumes from the saved state.")
+	var historyConfigMap string
+	var keepRuns int
+	run.Flags().IntVar(&keepRuns, "keep-runs", 0, "Number of most recent runs to keep records of in config maps, with outcome and duration of every resource. Records are used to estimate time remaining if --history-configmap is not set")
+	var keepRevisions int
+	run.Flags().IntVar(&keepRevisions, "keep-revisions", scheduler.DefaultKeepRevisions, "Number of revisions of every resource definition to keep in config maps, recorded when the resource is deployed. 0 disables revision history")
+	run.Flags().StringVar(&historyConfigMap, "history-configmap", "", "Name of config map to record times resources take to become ready in, used to estimate time remaining until deployment is complete")
+	var dependencyStatus bool
+	run.Flags().BoolVar(&dependencyStatus, "dependency-status", false, "Publish reports of parents in status of Dependency objects during the run, so that it can be watched with kubectl get dependencies -w")
+
 	concurrencyString := os.Getenv("KUBERNETES_AC_CONCURRENCY")
 
 	var err error
@@ -124,6 +757,6 @@ func InitRunCommand() (*cobra.Command, error) {
 		}
 	}
 	var concurrency int
-	run.Flags().IntVarP(&concurrency, "concurrency", "c", concurrencyDefault, "concurrency")
+	run.Flags().IntVarP(&concurrency, "concurrency", "c", concurrencyDefault, "Maximum number of resources created or checked in parallel, 0 means no limit. Overrides KUBERNETES_AC_CONCURRENCY env variable in AppController pod.")
 	return run, err
 }
@@ -0,0 +1,117 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/pkg/labels"
+
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+	"github.com/Mirantis/k8s-AppController/pkg/scheduler"
+)
+
+func destroy(cmd *cobra.Command, args []string) {
+	labelSelector, err := getLabelSelector(cmd)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	options, err := getDestroyOptions(cmd)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	c, err := newClient(cmd, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sel, err := labels.Parse(labelSelector)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Println("Using label selector:", labelSelector)
+
+	depGraph, err := scheduler.BuildDependencyGraph(c, sel)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err = scheduler.Destroy(depGraph, options); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Println("Done")
+}
+
+func getDestroyOptions(cmd *cobra.Command) (scheduler.DestroyOptions, error) {
+	options := scheduler.DestroyOptions{}
+
+	keepPVCs, err := cmd.Flags().GetBool("keep-pvcs")
+	if err != nil {
+		return options, err
+	}
+	if keepPVCs {
+		options.KeepKinds = append(options.KeepKinds, "persistentvolumeclaim")
+	}
+	keepSecrets, err := cmd.Flags().GetBool("keep-secrets")
+	if err != nil {
+		return options, err
+	}
+	if keepSecrets {
+		options.KeepKinds = append(options.KeepKinds, "secret")
+	}
+
+	orphan, err := cmd.Flags().GetBool("orphan")
+	if err != nil {
+		return options, err
+	}
+	if orphan {
+		options.Policy = resources.DeletionOrphan
+	}
+
+	timeout, err := cmd.Flags().GetInt("timeout")
+	if err != nil {
+		return options, err
+	}
+	options.Timeout = time.Duration(timeout) * time.Second
+	return options, nil
+}
+
+// InitDestroyCommand returns cobra command for deleting resources of AppController graph
+func InitDestroyCommand() *cobra.Command {
+	run := &cobra.Command{
+		Use:   "destroy",
+		Short: "Delete resources of AppController graph",
+		Long:  "Delete resources of AppController graph in reverse order of their creation, waiting for each resource to be gone",
+		Run:   destroy,
+	}
+
+	var labelSelector string
+	run.Flags().StringVarP(&labelSelector, "label", "l", "", "Label selector. Overrides KUBERNETES_AC_LABEL_SELECTOR env variable in AppController pod.")
+
+	var keepPVCs, keepSecrets, orphan bool
+	run.Flags().BoolVar(&keepPVCs, "keep-pvcs", false, "Do not delete persistent volume claims")
+	run.Flags().BoolVar(&keepSecrets, "keep-secrets", false, "Do not delete secrets")
+	run.Flags().BoolVar(&orphan, "orphan", false, "Keep objects owned by deleted resources (e.g. pods of replica sets) in the cluster")
+
+	var timeout int
+	run.Flags().IntVarP(&timeout, "timeout", "t", 0, "Time in seconds to wait for deletion of each resource, 0 means default timeout")
+	return run
+}
@@ -0,0 +1,110 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/pkg/labels"
+
+	"github.com/Mirantis/k8s-AppController/pkg/scheduler"
+)
+
+func diff(cmd *cobra.Command, args []string) {
+	labelSelector, err := getLabelSelector(cmd)
+	if err != nil {
+		log.Fatal(err)
+	}
+	outputFormat, err := cmd.Flags().GetString("output")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if outputFormat != "text" && outputFormat != "json" {
+		log.Fatal("Unknown output format. Expected one of: text, json")
+	}
+	graphName, err := cmd.Flags().GetString("graph-name")
+	if err != nil {
+		log.Fatal(err)
+	}
+	prune, err := cmd.Flags().GetBool("prune")
+	if err != nil {
+		log.Fatal(err)
+	}
+	whitelist, err := cmd.Flags().GetStringSlice("prune-whitelist")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	c, err := newClient(cmd, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sel, err := labels.Parse(labelSelector)
+	if err != nil {
+		log.Fatal(err)
+	}
+	depGraph, err := scheduler.BuildDependencyGraph(c, sel)
+	if err != nil {
+		log.Fatal(err)
+	}
+	depGraph.WithName(graphName)
+
+	var pruneOptions *scheduler.PruneOptions
+	if prune {
+		pruneOptions = &scheduler.PruneOptions{Whitelist: whitelist}
+	}
+	graphDiff, err := scheduler.Diff(c, depGraph, pruneOptions)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if outputFormat == "json" {
+		data, err := json.Marshal(graphDiff)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	for _, line := range graphDiff.AsText() {
+		fmt.Println(line)
+	}
+}
+
+// InitDiffCommand returns cobra command for comparing resource definitions with objects in the cluster
+func InitDiffCommand() *cobra.Command {
+	run := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare resource definitions with the cluster",
+		Long: "Print unified diff between every resource definition and its object in the cluster, followed by " +
+			"resources which would be created, updated or deleted by the next run",
+		Run: diff,
+	}
+
+	var labelSelector string
+	run.Flags().StringVarP(&labelSelector, "label", "l", "", "Label selector. Overrides KUBERNETES_AC_LABEL_SELECTOR env variable in AppController pod.")
+	var outputFormat string
+	run.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+	var graphName string
+	run.Flags().StringVar(&graphName, "graph-name", scheduler.DefaultGraphName, "Name of the graph whose objects are looked for by --prune")
+	var prune bool
+	var pruneWhitelist []string
+	run.Flags().BoolVar(&prune, "prune", false, "Also report objects labeled with the graph name which would be deleted by run with --prune")
+	run.Flags().StringSliceVar(&pruneWhitelist, "prune-whitelist", nil, "Kinds of objects to look for with --prune, e.g. configmap. All supported kinds are used by default")
+	return run
+}
@@ -0,0 +1,122 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/pkg/util/yaml"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+)
+
+// readDefinitions reads resource definitions from YAML or JSON stream, either single definitions or
+// lists of them
+func readDefinitions(r io.Reader) ([]client.ResourceDefinition, error) {
+	var definitions []client.ResourceDefinition
+	decoder := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		var doc struct {
+			client.ResourceDefinition
+			Items []client.ResourceDefinition `json:"items"`
+		}
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			return definitions, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if doc.Items != nil {
+			definitions = append(definitions, doc.Items...)
+		} else if doc.Name != "" {
+			definitions = append(definitions, doc.ResourceDefinition)
+		}
+	}
+}
+
+func encrypt(cmd *cobra.Command, args []string) {
+	providers, err := keyProviders(cmd)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(providers) != 1 {
+		log.Fatal(errors.New("Exactly one of --encryption-key and --encryption-kms-command must be set"))
+	}
+
+	var definitions []client.ResourceDefinition
+	if len(args) == 0 {
+		if definitions, err = readDefinitions(os.Stdin); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, path := range args {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fileDefinitions, err := readDefinitions(file)
+		file.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+		definitions = append(definitions, fileDefinitions...)
+	}
+
+	namespace, err := cmd.Flags().GetString("namespace")
+	if err != nil {
+		log.Fatal(err)
+	}
+	for i := range definitions {
+		r := &definitions[i]
+		if r.Namespace == "" {
+			r.Namespace = namespace
+		}
+		if r.Namespace == "" {
+			log.Fatalf("Namespace of resource definition %s must be set in its metadata or with --namespace, as it is encrypted along with the object", r.Name)
+		}
+		if err = client.EncryptDefinition(r, providers[0]); err != nil {
+			log.Fatal(err)
+		}
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if i > 0 {
+			fmt.Println("---")
+		}
+		fmt.Println(string(data))
+	}
+}
+
+// InitEncryptCommand returns cobra command for encrypting objects of resource definitions
+func InitEncryptCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "encrypt [FILE...]",
+		Short: "Encrypt objects of resource definitions",
+		Long: "Encrypt objects of resource definitions read from files or stdin with a random data key, which is " +
+			"encrypted by the key given with --encryption-key or --encryption-kms-command. Encrypted definitions " +
+			"are printed to stdout, their metadata and meta are left in plain text, but namespace, name and meta " +
+			"are authenticated with the object, so definitions without namespace are put in the one given with " +
+			"--namespace. Objects are decrypted only in memory of commands given the same key",
+		Run: encrypt,
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// objectMeta is a type for extracting metadata of serialized k8s objects and their pod templates
+type objectMeta struct {
+	Name   string            "name"
+	Labels map[string]string "labels"
+}
+
+// podSpec is a type for extracting objects referenced by pods
+type podSpec struct {
+	ServiceAccountName string "serviceAccountName"
+	Volumes            []struct {
+		ConfigMap struct {
+			Name string "name"
+		} "configMap"
+		Secret struct {
+			SecretName string "secretName"
+		} "secret"
+		PersistentVolumeClaim struct {
+			ClaimName string "claimName"
+		} "persistentVolumeClaim"
+	} "volumes"
+	Containers []struct {
+		Env []struct {
+			ValueFrom struct {
+				ConfigMapKeyRef struct {
+					Name string "name"
+				} "configMapKeyRef"
+				SecretKeyRef struct {
+					Name string "name"
+				} "secretKeyRef"
+			} "valueFrom"
+		} "env"
+		EnvFrom []struct {
+			ConfigMapRef struct {
+				Name string "name"
+			} "configMapRef"
+			SecretRef struct {
+				Name string "name"
+			} "secretRef"
+		} "envFrom"
+	} "containers"
+}
+
+// ReferenceExtractor is a type for extracting data relevant for inferring dependencies from serialized
+// k8s objects
+type ReferenceExtractor struct {
+	Kind     string     "kind"
+	Metadata objectMeta "metadata"
+	Spec     struct {
+		podSpec ",inline"
+		// Selector is a label map for services, and a label selector for workloads
+		Selector interface{} "selector"
+		Template struct {
+			Metadata objectMeta "metadata"
+			Spec     podSpec    "spec"
+		} "template"
+	} "spec"
+}
+
+// Key returns AppController key of the object, e.g. pod/name
+func (r ReferenceExtractor) Key() string {
+	return strings.ToLower(r.Kind) + "/" + r.Metadata.Name
+}
+
+// pod returns labels and spec of pods created for the object, and false if it is not a workload
+func (r ReferenceExtractor) pod() (map[string]string, podSpec, bool) {
+	kind := strings.ToLower(r.Kind)
+	if kind == "pod" {
+		return r.Metadata.Labels, r.Spec.podSpec, true
+	}
+	if resources.WorkloadKinds[kind] {
+		return r.Spec.Template.Metadata.Labels, r.Spec.Template.Spec, true
+	}
+	return nil, podSpec{}, false
+}
+
+// serviceSelector returns selector of service, or nil if the object is not a service
+func (r ReferenceExtractor) serviceSelector() map[string]string {
+	if strings.ToLower(r.Kind) != "service" {
+		return nil
+	}
+	// YAML and JSON decode maps with different key types
+	selector := map[string]string{}
+	switch s := r.Spec.Selector.(type) {
+	case map[interface{}]interface{}:
+		for key, value := range s {
+			selector[fmt.Sprint(key)] = fmt.Sprint(value)
+		}
+	case map[string]interface{}:
+		for key, value := range s {
+			selector[key] = fmt.Sprint(value)
+		}
+	}
+	return selector
+}
+
+// references returns keys of objects referenced by pod spec
+func (s podSpec) references() []string {
+	var result []string
+	add := func(kind, name string) {
+		if name != "" {
+			result = append(result, kind+"/"+name)
+		}
+	}
+	add("serviceaccount", s.ServiceAccountName)
+	for _, v := range s.Volumes {
+		add("configmap", v.ConfigMap.Name)
+		add("secret", v.Secret.SecretName)
+		add("persistentvolumeclaim", v.PersistentVolumeClaim.ClaimName)
+	}
+	for _, c := range s.Containers {
+		for _, e := range c.Env {
+			add("configmap", e.ValueFrom.ConfigMapKeyRef.Name)
+			add("secret", e.ValueFrom.SecretKeyRef.Name)
+		}
+		for _, e := range c.EnvFrom {
+			add("configmap", e.ConfigMapRef.Name)
+			add("secret", e.SecretRef.Name)
+		}
+	}
+	return result
+}
+
+// matches returns true if labels have all key-value pairs of non-empty selector
+func matches(selector, labels map[string]string) bool {
+	if len(selector) == 0 {
+		return false
+	}
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// References returns data for inferring dependencies of every object of the stream
+func References(f Format, stream string) ([]ReferenceExtractor, error) {
+	objects, err := f.Objects(stream)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]ReferenceExtractor, 0, len(objects))
+	for _, o := range objects {
+		refs, err := f.ExtractReferences(o)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, refs)
+	}
+	return result, nil
+}
+
+// InferDependencies returns parent and child keys of dependencies between the objects: workloads are
+// parents of services selecting their pods, and config maps, secrets, persistent volume claims and
+// service accounts are parents of workloads whose pods use them. Only dependencies between given
+// objects are returned, in order of the objects
+func InferDependencies(objects []ReferenceExtractor) [][2]string {
+	present := map[string]bool{}
+	for _, o := range objects {
+		present[o.Key()] = true
+	}
+
+	var result [][2]string
+	seen := map[[2]string]bool{}
+	add := func(parent, child string) {
+		dep := [2]string{parent, child}
+		if present[parent] && parent != child && !seen[dep] {
+			seen[dep] = true
+			result = append(result, dep)
+		}
+	}
+	for _, o := range objects {
+		if selector := o.serviceSelector(); selector != nil {
+			for _, workload := range objects {
+				if labels, _, ok := workload.pod(); ok && matches(selector, labels) {
+					add(workload.Key(), o.Key())
+				}
+			}
+		}
+		if _, spec, ok := o.pod(); ok {
+			for _, parent := range spec.references() {
+				add(parent, o.Key())
+			}
+		}
+	}
+	return result
+}
+
+// Dependencies returns Dependency objects inferred from relationships between the objects, named
+// after their parents and children
+func Dependencies(f Format, objects []ReferenceExtractor, names Names) []string {
+	var result []string
+	for _, dep := range InferDependencies(objects) {
+		name := strings.Replace(dep[0]+"-"+dep[1], "/", "-", -1)
+		result = append(result, f.WrapDependency(names.Unique(name), dep[0], dep[1]))
+	}
+	return result
+}
@@ -0,0 +1,85 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"reflect"
+	"testing"
+)
+
+const referencingObjects = `{"kind": "Service", "metadata": {"name": "web"}, "spec": {"selector": {"app": "web"}}}
+{"kind": "Deployment", "metadata": {"name": "web"}, "spec": {
+	"selector": {"matchLabels": {"app": "web"}},
+	"template": {"metadata": {"labels": {"app": "web"}}, "spec": {
+		"serviceAccountName": "web",
+		"volumes": [{"configMap": {"name": "config"}}, {"persistentVolumeClaim": {"claimName": "data"}}],
+		"containers": [{"env": [{"valueFrom": {"secretKeyRef": {"name": "password"}}}]}]
+	}}
+}}
+{"kind": "Pod", "metadata": {"name": "other", "labels": {"app": "other"}}, "spec": {"serviceAccountName": "missing"}}
+{"kind": "ConfigMap", "metadata": {"name": "config"}}
+{"kind": "Secret", "metadata": {"name": "password"}}
+{"kind": "PersistentVolumeClaim", "metadata": {"name": "data"}}
+{"kind": "ServiceAccount", "metadata": {"name": "web"}}`
+
+// TestInferDependencies checks that dependencies are inferred only between given objects
+func TestInferDependencies(t *testing.T) {
+	refs, err := References(JSON{}, referencingObjects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][2]string{
+		{"deployment/web", "service/web"},
+		{"serviceaccount/web", "deployment/web"},
+		{"configmap/config", "deployment/web"},
+		{"persistentvolumeclaim/data", "deployment/web"},
+		{"secret/password", "deployment/web"},
+	}
+	if deps := InferDependencies(refs); !reflect.DeepEqual(deps, expected) {
+		t.Errorf("Expected dependencies %v, got %v", expected, deps)
+	}
+}
+
+// TestYamlDependencies checks that references are extracted from YAML and dependencies are wrapped
+func TestYamlDependencies(t *testing.T) {
+	f := Yaml{}
+	yaml := `  kind: Service
+  metadata:
+    name: web
+  spec:
+    selector:
+      app: web
+  ---
+  kind: Pod
+  metadata:
+    name: web
+    labels:
+      app: web`
+
+	refs, err := References(f, yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := Dependencies(f, refs, Names{})
+	expected := []string{`apiVersion: appcontroller.k8s/v1alpha1
+kind: Dependency
+metadata:
+  name: pod-web-service-web
+parent: pod/web
+child: service/web`}
+	if !reflect.DeepEqual(deps, expected) {
+		t.Errorf("Expected dependencies %v, got %v", expected, deps)
+	}
+}
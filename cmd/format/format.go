@@ -14,11 +14,28 @@
 
 package format
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
 // Format is an interface for data formats for wrapper
 type Format interface {
 	ExtractData(k8sObject string) (DataExtractor, error)
 	Wrap(k8sObject string) (string, error)
 	IndentLevel() int
+	// Objects splits stream into serialized k8s objects, replacing lists by their items
+	Objects(stream string) ([]string, error)
+	// WrapObject wraps single k8s object of given kind into Definition with given name
+	WrapObject(k8sObject, kind, name string) string
+	// Join joins wrapped definitions into a stream
+	Join(definitions []string) string
+	// ExtractReferences returns data relevant for inferring dependencies from serialized k8s object
+	ExtractReferences(k8sObject string) (ReferenceExtractor, error)
+	// WrapDependency returns Dependency with given name between parent and child keys
+	WrapDependency(name, parent, child string) string
 }
 
 // DataExtractor is a type for extracting data relevant for wrap tool from serialized k8s objects
@@ -28,3 +45,59 @@ type DataExtractor struct {
 		Name string "name"
 	} "metadata"
 }
+
+// listKind is lowercased kind of lists of k8s objects, e.g. output of kubectl get -o yaml
+const listKind = "list"
+
+// Names keeps track of Definition names given to wrapped objects, so that they stay unique across
+// several streams
+type Names map[string]int
+
+// Unique returns the name if it was not used yet, or the name with the lowest free numeric suffix
+func (n Names) Unique(name string) string {
+	n[name]++
+	if n[name] == 1 {
+		return name
+	}
+	for {
+		candidate := fmt.Sprintf("%s-%d", name, n[name])
+		if n[candidate] == 0 {
+			n[candidate]++
+			return candidate
+		}
+		n[name]++
+	}
+}
+
+// Definitions wraps every object of the stream into Definition named <kind>-<name>. Names already
+// given by earlier calls with the same names get numeric suffixes in order of appearance. Objects of
+// registered kinds which are not built in are wrapped into object field
+func Definitions(f Format, stream string, names Names) ([]string, error) {
+	objects, err := f.Objects(stream)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(objects))
+	for _, o := range objects {
+		data, err := f.ExtractData(o)
+		if err != nil {
+			return nil, err
+		}
+		if data.Kind == "" {
+			return nil, fmt.Errorf("Object has no kind:\n%s", o)
+		}
+		name := names.Unique(strings.ToLower(data.Kind + "-" + data.Metadata.Name))
+		result = append(result, f.WrapObject(o, resources.DefinitionField(data.Kind), name))
+	}
+	return result, nil
+}
+
+// YamlStream joins wrapped definitions of any format into a single YAML stream. JSON definitions
+// are valid YAML documents, so the result can be passed to kubectl create as is
+func YamlStream(definitions []string) string {
+	documents := make([]string, 0, len(definitions))
+	for _, d := range definitions {
+		documents = append(documents, strings.Trim(d, "\n"))
+	}
+	return strings.Join(documents, "\n---\n")
+}
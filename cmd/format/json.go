@@ -16,6 +16,7 @@ package format
 
 import (
 	"encoding/json"
+	"io"
 	"strings"
 )
 
@@ -31,21 +32,87 @@ func (f JSON) ExtractData(k8sObject string) (DataExtractor, error) {
 	return data, err
 }
 
-// Wrap wraps k8sObject into Definition ThirdPArtyResource
-func (f JSON) Wrap(k8sObject string) (string, error) {
-	data, err := f.ExtractData(k8sObject)
+// Objects splits stream of concatenated JSON objects, replacing lists by their items
+func (f JSON) Objects(stream string) ([]string, error) {
+	var result []string
+	decoder := json.NewDecoder(strings.NewReader(stream))
+	for {
+		var object json.RawMessage
+		err := decoder.Decode(&object)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := f.ExtractData(string(object))
+		if err != nil {
+			return nil, err
+		}
+		if data.Kind != listKind {
+			result = append(result, string(object))
+			continue
+		}
+		var list struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		if err = json.Unmarshal(object, &list); err != nil {
+			return nil, err
+		}
+		for _, item := range list.Items {
+			items, err := f.Objects(string(item))
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, items...)
+		}
+	}
+	return result, nil
+}
 
+// WrapObject wraps single k8s object into Definition ThirdPartyResource
+func (f JSON) WrapObject(k8sObject, kind, name string) string {
 	base := `{
     "apiVersion": "appcontroller.k8s/v1alpha1",
     "kind": "Definition",
     "metadata": {
-        "name": "` + data.Kind + "-" + data.Metadata.Name + `"
+        "name": "` + name + `"
     },` + "\n"
+	return base + `    "` + kind + `": ` + strings.TrimSpace(k8sObject) + "\n}\n"
+}
 
+// ExtractReferences returns data relevant for inferring dependencies from serialized k8s object
+func (f JSON) ExtractReferences(k8sObject string) (ReferenceExtractor, error) {
+	var refs ReferenceExtractor
+	err := json.Unmarshal([]byte(k8sObject), &refs)
+	return refs, err
+}
+
+// WrapDependency returns Dependency ThirdPartyResource between parent and child
+func (f JSON) WrapDependency(name, parent, child string) string {
+	return `{
+    "apiVersion": "appcontroller.k8s/v1alpha1",
+    "kind": "Dependency",
+    "metadata": {
+        "name": "` + name + `"
+    },
+    "parent": "` + parent + `",
+    "child": "` + child + `"
+}` + "\n"
+}
+
+// Join joins wrapped definitions into stream of concatenated JSON objects
+func (f JSON) Join(definitions []string) string {
+	return strings.Join(definitions, "")
+}
+
+// Wrap wraps every object of JSON stream into Definition ThirdPartyResource
+func (f JSON) Wrap(k8sObject string) (string, error) {
+	definitions, err := Definitions(f, k8sObject, Names{})
 	if err != nil {
 		return "", err
 	}
-	return base + `    "` + data.Kind + `": ` + strings.TrimLeft(k8sObject, " ") + "}\n", nil
+	return f.Join(definitions), nil
 }
 
 // IndentLevel returns indent level for JSON format
@@ -15,6 +15,7 @@
 package format
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -53,3 +54,53 @@ func TestWrapJSON(t *testing.T) {
 		t.Errorf("Wrapped doesn't match expected output\nExpected:\n%s\nAactual:\n%s", expected, wrapped)
 	}
 }
+
+// TestWrapJSONStream checks that every object of JSON stream is wrapped
+func TestWrapJSONStream(t *testing.T) {
+	f := JSON{}
+	text := `{"kind": "Pod", "metadata": {"name": "first"}}
+{"kind": "Service", "metadata": {"name": "second"}}`
+
+	wrapped, err := f.Wrap(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+    "apiVersion": "appcontroller.k8s/v1alpha1",
+    "kind": "Definition",
+    "metadata": {
+        "name": "pod-first"
+    },
+    "pod": {"kind": "Pod", "metadata": {"name": "first"}}
+}
+{
+    "apiVersion": "appcontroller.k8s/v1alpha1",
+    "kind": "Definition",
+    "metadata": {
+        "name": "service-second"
+    },
+    "service": {"kind": "Service", "metadata": {"name": "second"}}
+}` + "\n"
+	if wrapped != expected {
+		t.Errorf("Wrapped doesn't match expected output\nExpected:\n%s\nActual:\n%s", expected, wrapped)
+	}
+}
+
+// TestWrapJSONList checks that items of lists are wrapped into definitions with unique names
+func TestWrapJSONList(t *testing.T) {
+	f := JSON{}
+	text := `{"kind": "List", "items": [{"kind": "Pod", "metadata": {"name": "a"}}, {"kind": "Pod", "metadata": {"name": "a"}}]}`
+
+	definitions, err := Definitions(f, text, Names{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(definitions) != 2 {
+		t.Fatalf("Expected 2 definitions, got %d", len(definitions))
+	}
+	for i, name := range []string{`"name": "pod-a"`, `"name": "pod-a-2"`} {
+		if !strings.Contains(definitions[i], name) {
+			t.Errorf("Definition %d should contain %s:\n%s", i, name, definitions[i])
+		}
+	}
+}
@@ -15,7 +15,7 @@
 package format
 
 import (
-	"fmt"
+	"regexp"
 	"strings"
 
 	"gopkg.in/yaml.v2"
@@ -33,24 +33,115 @@ func (f Yaml) ExtractData(k8sObject string) (DataExtractor, error) {
 	return data, err
 }
 
-// Wrap wraps k8sObject into Definition ThirdPArtyResource
-func (f Yaml) Wrap(k8sObject string) (string, error) {
-	objects := strings.Split(k8sObject, fmt.Sprintf("%s---", strings.Repeat(" ", f.IndentLevel())))
+// documentSeparator matches lines separating documents of YAML stream, which may be followed by a comment
+var documentSeparator = regexp.MustCompile(`(?m)^[ \t]*---([ \t]+#.*)?[ \t]*$`)
+
+// splitDocuments splits YAML stream into documents. Documents which have no content, e.g. before the
+// leading separator, are skipped
+func splitDocuments(stream string) []string {
+	var result []string
+	for _, document := range documentSeparator.Split(stream, -1) {
+		if hasContent(document) {
+			result = append(result, document)
+		}
+	}
+	return result
+}
+
+// hasContent returns true if the YAML document has lines other than blank lines and comments
+func hasContent(document string) bool {
+	for _, line := range strings.Split(document, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return true
+		}
+	}
+	return false
+}
 
-	result := make([]string, 0, len(objects))
-	for _, o := range objects {
-		data, err := f.ExtractData(o)
+// Objects splits YAML stream into documents, replacing lists by their items
+func (f Yaml) Objects(stream string) ([]string, error) {
+	var result []string
+	for _, document := range splitDocuments(stream) {
+		data, err := f.ExtractData(document)
 		if err != nil {
-			return "", err
+			return nil, err
+		}
+		if data.Kind != listKind {
+			result = append(result, document)
+			continue
+		}
+		var list struct {
+			Items []interface{} "items"
+		}
+		if err = yaml.Unmarshal([]byte(document), &list); err != nil {
+			return nil, err
 		}
-		base := `apiVersion: appcontroller.k8s/v1alpha1
+		for _, item := range list.Items {
+			out, err := yaml.Marshal(item)
+			if err != nil {
+				return nil, err
+			}
+			items, err := f.Objects(indent(string(out), f.IndentLevel()))
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, items...)
+		}
+	}
+	return result, nil
+}
+
+// indent adds given number of spaces to every non-empty line
+func indent(text string, level int) string {
+	spaces := strings.Repeat(" ", level)
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = spaces + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WrapObject wraps single k8s object into Definition ThirdPartyResource
+func (f Yaml) WrapObject(k8sObject, kind, name string) string {
+	base := `apiVersion: appcontroller.k8s/v1alpha1
 kind: Definition
 metadata:
-  name: ` + data.Kind + "-" + data.Metadata.Name + "\n"
-		result = append(result, base+data.Kind+":\n"+strings.Trim(o, "\n"))
-	}
+  name: ` + name + "\n"
+	return base + kind + ":\n" + strings.Trim(k8sObject, "\n")
+}
+
+// ExtractReferences returns data relevant for inferring dependencies from serialized k8s object
+func (f Yaml) ExtractReferences(k8sObject string) (ReferenceExtractor, error) {
+	var refs ReferenceExtractor
+	err := yaml.Unmarshal([]byte(k8sObject), &refs)
+	return refs, err
+}
 
-	return strings.Join(result, "\n---\n"), nil
+// WrapDependency returns Dependency ThirdPartyResource between parent and child
+func (f Yaml) WrapDependency(name, parent, child string) string {
+	return `apiVersion: appcontroller.k8s/v1alpha1
+kind: Dependency
+metadata:
+  name: ` + name + `
+parent: ` + parent + `
+child: ` + child
+}
+
+// Join joins wrapped definitions into YAML stream
+func (f Yaml) Join(definitions []string) string {
+	return YamlStream(definitions)
+}
+
+// Wrap wraps every object of YAML stream into Definition ThirdPartyResource
+func (f Yaml) Wrap(k8sObject string) (string, error) {
+	definitions, err := Definitions(f, k8sObject, Names{})
+	if err != nil {
+		return "", err
+	}
+	return f.Join(definitions), nil
 }
 
 // IndentLevel returns indent level for Yaml format
@@ -165,3 +165,119 @@ job:
 		t.Errorf("Wrapped doesn't match expected output\nExpected:\n%s\nactual:\n%s", expected, wrapped)
 	}
 }
+
+// TestMultiDocSeparators checks that leading separators, separators with comments and documents
+// without content are handled
+func TestMultiDocSeparators(t *testing.T) {
+	f := Yaml{}
+	yaml := `  ---
+  apiVersion: v1
+  kind: Pod
+  metadata:
+    name: first
+  --- # second object
+  # only comments here
+  ---
+  apiVersion: v1
+  kind: Service
+  metadata:
+    name: second
+  ---
+`
+
+	wrapped, err := f.Wrap(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `apiVersion: appcontroller.k8s/v1alpha1
+kind: Definition
+metadata:
+  name: pod-first
+pod:
+  apiVersion: v1
+  kind: Pod
+  metadata:
+    name: first
+---
+apiVersion: appcontroller.k8s/v1alpha1
+kind: Definition
+metadata:
+  name: service-second
+service:
+  apiVersion: v1
+  kind: Service
+  metadata:
+    name: second`
+
+	if wrapped != expected {
+		t.Errorf("Wrapped doesn't match expected output\nExpected:\n%s\nactual:\n%s", expected, wrapped)
+	}
+}
+
+// TestWrapWithoutKind checks that documents which are not k8s objects are rejected
+func TestWrapWithoutKind(t *testing.T) {
+	f := Yaml{}
+	if _, err := f.Wrap("  metadata:\n    name: pi"); err == nil {
+		t.Error("Error expected for object without kind")
+	}
+}
+
+// TestWrapList checks that items of lists are wrapped into separate definitions
+func TestWrapList(t *testing.T) {
+	f := Yaml{}
+	yaml := `  apiVersion: v1
+  kind: List
+  items:
+  - apiVersion: v1
+    kind: Pod
+    metadata:
+      name: first
+  - apiVersion: v1
+    kind: Service
+    metadata:
+      name: second`
+
+	wrapped, err := f.Wrap(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `apiVersion: appcontroller.k8s/v1alpha1
+kind: Definition
+metadata:
+  name: pod-first
+pod:
+  apiVersion: v1
+  kind: Pod
+  metadata:
+    name: first
+---
+apiVersion: appcontroller.k8s/v1alpha1
+kind: Definition
+metadata:
+  name: service-second
+service:
+  apiVersion: v1
+  kind: Service
+  metadata:
+    name: second`
+
+	if wrapped != expected {
+		t.Errorf("Wrapped doesn't match expected output\nExpected:\n%s\nactual:\n%s", expected, wrapped)
+	}
+}
+
+// TestNames checks that definition names are made unique in order of appearance
+func TestNames(t *testing.T) {
+	names := Names{}
+	var result []string
+	for _, name := range []string{"pod-a", "pod-a", "pod-b", "pod-a", "pod-a-2"} {
+		result = append(result, names.Unique(name))
+	}
+	expected := []string{"pod-a", "pod-a-2", "pod-b", "pod-a-3", "pod-a-2-2"}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Errorf("Expected names %v, got %v", expected, result)
+			break
+		}
+	}
+}
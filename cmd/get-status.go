@@ -4,9 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"os"
 
-	"github.com/Mirantis/k8s-AppController/pkg/client"
 	"github.com/Mirantis/k8s-AppController/pkg/scheduler"
 
 	"github.com/spf13/cobra"
@@ -31,15 +29,7 @@ func getStatus(cmd *cobra.Command, args []string) {
 		log.Fatal(err)
 	}
 
-	var url string
-	if len(args) > 0 {
-		url = args[0]
-	}
-	if url == "" {
-		url = os.Getenv("KUBERNETES_CLUSTER_URL")
-	}
-
-	c, err := client.New(url)
+	c, err := newClient(cmd, args)
 	if err != nil {
 		log.Fatal(err)
 	}
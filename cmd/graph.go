@@ -0,0 +1,154 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/pkg/labels"
+
+	"github.com/Mirantis/k8s-AppController/pkg/scheduler"
+)
+
+func graph(cmd *cobra.Command, args []string) {
+	labelSelector, err := getLabelSelector(cmd)
+	if err != nil {
+		log.Fatal(err)
+	}
+	outputFormat, err := cmd.Flags().GetString("output")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if outputFormat != "dot" && outputFormat != "json" && outputFormat != "document" {
+		log.Fatal("Unknown output format. Expected one of: dot, json, document")
+	}
+	noStatus, err := cmd.Flags().GetBool("no-status")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if outputFormat == "document" {
+		resDefs, deps, err := loadCluster(cmd, args)
+		if err != nil {
+			log.Fatal(err)
+		}
+		document, err := scheduler.NewGraphDocument(resDefs, deps)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printJSON(document)
+		return
+	}
+
+	c, err := newClient(cmd, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sel, err := labels.Parse(labelSelector)
+	if err != nil {
+		log.Fatal(err)
+	}
+	depGraph, err := scheduler.BuildDependencyGraph(c, sel)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	export := depGraph.Export(!noStatus)
+	if outputFormat == "json" {
+		data, err := json.Marshal(export)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Print(export.AsDOT())
+	}
+}
+
+// printJSON prints the value as indented JSON
+func printJSON(value interface{}) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(data))
+}
+
+func importGraph(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		log.Fatal("Expected path to graph document, or - for stdin")
+	}
+	var data []byte
+	var err error
+	if args[0] == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(args[0])
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	document, err := scheduler.ParseGraphDocument(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	resDefs, deps, err := document.Materialize()
+	if err != nil {
+		log.Fatal(err)
+	}
+	items := make([]interface{}, 0, len(resDefs)+len(deps))
+	for _, r := range resDefs {
+		items = append(items, r)
+	}
+	for _, d := range deps {
+		items = append(items, d)
+	}
+	printJSON(map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": items})
+}
+
+// InitGraphCommand returns cobra command for exporting AppController graph
+func InitGraphCommand() *cobra.Command {
+	run := &cobra.Command{
+		Use:   "graph",
+		Short: "Export AppController graph",
+		Long:  "Print AppController dependency graph in Graphviz DOT or JSON format, with resources colored by their status, or as a graph document which can be imported with import-graph",
+		Run:   graph,
+	}
+
+	var labelSelector string
+	run.Flags().StringVarP(&labelSelector, "label", "l", "", "Label selector. Overrides KUBERNETES_AC_LABEL_SELECTOR env variable in AppController pod.")
+
+	var outputFormat string
+	run.Flags().StringVarP(&outputFormat, "output", "o", "dot", "Output format: dot, json or document")
+	var noStatus bool
+	run.Flags().BoolVar(&noStatus, "no-status", false, "Do not retrieve status of resources from the cluster")
+	return run
+}
+
+// InitImportGraphCommand returns cobra command for converting graph documents into AppController objects
+func InitImportGraphCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import-graph FILE",
+		Short: "Convert graph document into Definitions and Dependencies",
+		Long:  "Print Definitions and Dependencies described by a graph document in YAML, JSON or Graphviz DOT format as a list which can be passed to kubectl create. Nodes of DOT graphs have no objects, so only Dependencies are created for them",
+		Run:   importGraph,
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/pkg/labels"
+
+	"github.com/Mirantis/k8s-AppController/pkg/scheduler"
+)
+
+func migratePetSets(cmd *cobra.Command, args []string) {
+	labelSelector, err := getLabelSelector(cmd)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sel, err := labels.Parse(labelSelector)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		log.Fatal(err)
+	}
+	// dependencies are recreated as they are listed, so defaults would be stored in them
+	metaDefaults, err := cmd.Flags().GetString("meta-defaults-configmap")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if metaDefaults != "" {
+		log.Fatal(errors.New("--meta-defaults-configmap cannot be used with migrate-petsets, as defaults would be written into migrated dependencies"))
+	}
+
+	c, err := newClient(cmd, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	changes, err := scheduler.MigratePetSets(c, sel, dryRun)
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(changes) == 0 {
+		fmt.Println("No PetSets to migrate")
+	}
+}
+
+// InitMigratePetSetsCommand returns cobra command for converting PetSets of the graph to StatefulSets
+func InitMigratePetSetsCommand() *cobra.Command {
+	run := &cobra.Command{
+		Use:   "migrate-petsets",
+		Short: "Convert PetSets of AppController graph to StatefulSets",
+		Long: "Replace PetSet definitions by StatefulSet definitions with the same spec, replace PetSets in the cluster by StatefulSets " +
+			"adopting their pods and volume claims, and make dependencies refer to the StatefulSets",
+		Run: migratePetSets,
+	}
+
+	var labelSelector string
+	run.Flags().StringVarP(&labelSelector, "label", "l", "", "Label selector. Overrides KUBERNETES_AC_LABEL_SELECTOR env variable in AppController pod.")
+	var dryRun bool
+	run.Flags().BoolVar(&dryRun, "dry-run", false, "Print changes without making them")
+	return run
+}
@@ -0,0 +1,41 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+)
+
+// PluginName is a name of kubectl plugin binary, kubectl finds it in PATH and runs it as
+// "kubectl appcontroller"
+const PluginName = "kubectl-appcontroller"
+
+// InitPlugin initializes RootCmd as kubectl plugin. It has commands needed by operators of
+// AppController graphs, run command is called deploy, as kubectl run means a different thing
+func InitPlugin() {
+	run, err := InitRunCommand()
+	if err != nil {
+		log.Fatal(err)
+	}
+	run.Use = "deploy"
+	run.Aliases = []string{"run"}
+	run.Short = "Deploy AppController graph"
+
+	addWrapFlags()
+
+	RootCmd = newRootCommand("kubectl appcontroller")
+	RootCmd.Short = "Deploy and inspect AppController graphs"
+	RootCmd.AddCommand(run, InitStatusCommand(), InitGraphCommand(), InitValidateCommand(), InitDiffCommand(), Wrap)
+}
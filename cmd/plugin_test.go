@@ -0,0 +1,45 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+)
+
+// TestPluginCommands checks that kubectl plugin has operator commands and honors kubectl flags
+func TestPluginCommands(t *testing.T) {
+	InitPlugin()
+	for _, args := range [][]string{{"deploy"}, {"run"}, {"status"}, {"graph"}, {"validate"}, {"diff"}, {"wrap"}} {
+		command, _, err := RootCmd.Find(args)
+		if err != nil || command == RootCmd {
+			t.Errorf("Plugin should have %s command", args[0])
+		}
+	}
+
+	command, _, err := RootCmd.Find([]string{"status"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = command.ParseFlags([]string{"-n", "apps", "-o", "json"}); err != nil {
+		t.Fatal(err)
+	}
+	opts, err := clientOptions(command, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Namespace != "apps" {
+		t.Errorf("Expected namespace apps, got %q", opts.Namespace)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+	"github.com/Mirantis/k8s-AppController/pkg/scheduler"
+)
+
+// spinnerFrames are drawn in turn next to resources which are in progress
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// progressRefresh is an interval between redraws of the progress display
+const progressRefresh = 100 * time.Millisecond
+
+// maxErrorLength is a length errors are cut to in the progress display, so that each fits in one line
+const maxErrorLength = 120
+
+// maxProgressErrors is a number of most recent errors shown by the progress display
+const maxProgressErrors = 5
+
+// progressDisplay draws live view of the graph run at the bottom of the terminal. Log lines written
+// to it are printed above the view, which is redrawn after them
+type progressDisplay struct {
+	out      io.Writer
+	depGraph scheduler.DependencyGraph
+	start    time.Time
+
+	frame int
+	view  []string
+	// lines is a number of lines of the last drawn view, which are erased before drawing the next one
+	lines int
+	// errors are most recent errors of resources, seen keeps keys of resources whose errors were shown
+	errors []string
+	seen   map[string]bool
+
+	stop chan struct{}
+	done chan struct{}
+	sync.Mutex
+}
+
+// startProgress starts drawing progress of the graph run to stderr and redirects logs to the display
+func startProgress(depGraph scheduler.DependencyGraph) *progressDisplay {
+	d := &progressDisplay{
+		out:      os.Stderr,
+		depGraph: depGraph,
+		start:    time.Now(),
+		seen:     map[string]bool{},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	log.SetOutput(d)
+	logging.SetOutput(d)
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(progressRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				d.redraw()
+			case <-d.stop:
+				return
+			}
+		}
+	}()
+	return d
+}
+
+// Stop draws the final view of the run and gives the terminal back to logs
+func (d *progressDisplay) Stop() {
+	close(d.stop)
+	<-d.done
+	d.redraw()
+	log.SetOutput(os.Stderr)
+	logging.SetOutput(os.Stderr)
+}
+
+// Write prints log lines above the view
+func (d *progressDisplay) Write(p []byte) (int, error) {
+	d.Lock()
+	defer d.Unlock()
+	d.erase()
+	n, err := d.out.Write(p)
+	d.draw()
+	return n, err
+}
+
+// redraw draws the view with current state of resources. The state is read before locking the
+// display, as resources may log while holding their own locks
+func (d *progressDisplay) redraw() {
+	nodes := d.depGraph.NodeResults()
+	d.Lock()
+	defer d.Unlock()
+	for _, node := range nodes {
+		if node.Error != "" && !d.seen[node.Key] {
+			d.seen[node.Key] = true
+			d.errors = append(d.errors, shortLine(fmt.Sprintf("%s: %s", node.Key, node.Error)))
+		}
+	}
+	if len(d.errors) > maxProgressErrors {
+		d.errors = d.errors[len(d.errors)-maxProgressErrors:]
+	}
+	d.frame++
+	d.view = progressLines(nodes, d.errors, d.frame, time.Since(d.start))
+	d.erase()
+	d.draw()
+}
+
+// erase moves cursor to the first line of the view and clears the rest of the screen
+func (d *progressDisplay) erase() {
+	if d.lines > 0 {
+		fmt.Fprintf(d.out, "\033[%dA\033[J", d.lines)
+		d.lines = 0
+	}
+}
+
+func (d *progressDisplay) draw() {
+	for _, line := range d.view {
+		fmt.Fprintln(d.out, line)
+	}
+	d.lines = len(d.view)
+}
+
+// progressLines returns lines of the view: counts of resources by state, resources in progress with
+// spinners and most recent errors
+func progressLines(nodes []report.NodeResult, errors []string, frame int, elapsed time.Duration) []string {
+	counts := map[string]int{}
+	var inProgress, deferred []string
+	for _, node := range nodes {
+		counts[node.State]++
+		switch node.State {
+		case report.NodeInProgress:
+			inProgress = append(inProgress, node.Key)
+		case report.NodeDeferred:
+			deferred = append(deferred, fmt.Sprintf("  - %s until %s", node.Key, node.DeferredUntil.Format(time.RFC3339)))
+		}
+	}
+	summary := fmt.Sprintf(
+		"[%s] %d/%d done: %d created, %d skipped, %d failed, %d in progress",
+		elapsed/time.Second*time.Second, counts[report.NodeCreated]+counts[report.NodeSkipped]+counts[report.NodeFailed],
+		len(nodes), counts[report.NodeCreated], counts[report.NodeSkipped], counts[report.NodeFailed],
+		counts[report.NodeInProgress],
+	)
+	// deferred resources are counted only if there are any, as most graphs have no deployment windows
+	if len(deferred) > 0 {
+		summary += fmt.Sprintf(", %d deferred", len(deferred))
+	}
+	lines := []string{summary + fmt.Sprintf(", %d pending", counts[report.NodePending])}
+	spinner := spinnerFrames[frame%len(spinnerFrames)]
+	for _, key := range inProgress {
+		lines = append(lines, fmt.Sprintf("  %s %s", spinner, key))
+	}
+	lines = append(lines, deferred...)
+	if len(errors) > 0 {
+		lines = append(lines, "Recent errors:")
+		for _, err := range errors {
+			lines = append(lines, "  "+err)
+		}
+	}
+	return lines
+}
+
+// shortLine returns first line of the text cut to maxErrorLength
+func shortLine(text string) string {
+	if i := strings.Index(text, "\n"); i >= 0 {
+		text = text[:i]
+	}
+	if len(text) > maxErrorLength {
+		text = text[:maxErrorLength-3] + "..."
+	}
+	return text
+}
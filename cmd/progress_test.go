@@ -0,0 +1,43 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+// TestProgressLines checks counts, spinners of resources in progress and errors in progress view
+func TestProgressLines(t *testing.T) {
+	nodes := []report.NodeResult{
+		{Key: "job/migrate", State: report.NodeFailed, Error: "failed"},
+		{Key: "pod/db", State: report.NodeCreated},
+		{Key: "pod/web", State: report.NodeInProgress},
+		{Key: "service/web", State: report.NodePending},
+	}
+	lines := progressLines(nodes, []string{"job/migrate: failed"}, 1, 1500*time.Millisecond)
+	expected := []string{
+		"[1s] 2/4 done: 1 created, 0 skipped, 1 failed, 1 in progress, 1 pending",
+		"  / pod/web",
+		"Recent errors:",
+		"  job/migrate: failed",
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected lines %v, got %v", expected, lines)
+	}
+}
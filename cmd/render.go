@@ -0,0 +1,95 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// helmArgs returns arguments of helm command rendering the chart with given values files and values
+func helmArgs(chart string, valuesFiles, values []string) []string {
+	args := []string{"template"}
+	for _, file := range valuesFiles {
+		args = append(args, "--values", file)
+	}
+	for _, value := range values {
+		args = append(args, "--set", value)
+	}
+	return append(args, chart)
+}
+
+// kustomizeCommand returns command building kustomize overlay in the directory. Standalone kustomize
+// is used if it is installed, kubectl otherwise
+func kustomizeCommand(dir string) []string {
+	if _, err := exec.LookPath("kustomize"); err == nil {
+		return []string{"kustomize", "build", dir}
+	}
+	return []string{"kubectl", "kustomize", dir}
+}
+
+// runRenderer runs command printing manifests and returns its output
+func runRenderer(command []string) ([]byte, error) {
+	var stderr bytes.Buffer
+	c := exec.Command(command[0], command[1:]...)
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %v: %s", strings.Join(command, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// renderManifests renders Helm chart or kustomize overlay given by wrap command flags into YAML
+// stream. It returns false if neither is given
+func renderManifests(cmd *cobra.Command) ([]byte, bool, error) {
+	flags := cmd.Flags()
+	chart, err := flags.GetString("helm")
+	if err != nil {
+		return nil, false, err
+	}
+	overlay, err := flags.GetString("kustomize")
+	if err != nil {
+		return nil, false, err
+	}
+
+	var command []string
+	switch {
+	case chart != "" && overlay != "":
+		return nil, false, errors.New("Only one of --helm and --kustomize can be given")
+	case chart != "":
+		valuesFiles, err := flags.GetStringSlice("helm-values")
+		if err != nil {
+			return nil, false, err
+		}
+		values, err := flags.GetStringSlice("helm-set")
+		if err != nil {
+			return nil, false, err
+		}
+		command = append([]string{"helm"}, helmArgs(chart, valuesFiles, values)...)
+	case overlay != "":
+		command = kustomizeCommand(overlay)
+	default:
+		return nil, false, nil
+	}
+
+	out, err := runRenderer(command)
+	return out, err == nil, err
+}
@@ -0,0 +1,43 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestHelmArgs checks that values are passed to helm template
+func TestHelmArgs(t *testing.T) {
+	args := helmArgs("./chart", []string{"prod.yaml"}, []string{"image.tag=1.11", "replicas=3"})
+	expected := []string{"template", "--values", "prod.yaml", "--set", "image.tag=1.11", "--set", "replicas=3", "./chart"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected arguments %v, got %v", expected, args)
+	}
+}
+
+// TestRunRenderer checks that output of renderer is returned and its failures are reported
+func TestRunRenderer(t *testing.T) {
+	out, err := runRenderer([]string{"echo", "kind: Pod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "kind: Pod\n" {
+		t.Errorf("Unexpected output %q", out)
+	}
+	if _, err = runRenderer([]string{"sh", "-c", "echo broken chart >&2; exit 1"}); err == nil {
+		t.Error("Error expected for failed renderer")
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/scheduler"
+)
+
+func revisions(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		log.Fatal("Name of resource definition is required")
+	}
+	name := args[0]
+	outputFormat, err := cmd.Flags().GetString("output")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if outputFormat != "table" && outputFormat != "json" {
+		log.Fatal("Unknown output format. Expected one of: table, json")
+	}
+	diff, err := cmd.Flags().GetInt("diff")
+	if err != nil {
+		log.Fatal(err)
+	}
+	rollback, err := cmd.Flags().GetInt("rollback")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// the argument is definition name, so cluster URL is taken from environment
+	c, err := newClient(cmd, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	history, err := scheduler.NewConfigMapRevisionStore(c.ConfigMaps(), 0).History(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch {
+	case diff > 0:
+		revision, err := history.Get(diff)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err = decryptRevision(cmd, &revision); err != nil {
+			log.Fatal(err)
+		}
+		lines, err := scheduler.DefinitionDiff(c, revision.Definition, fmt.Sprintf("revision %d", revision.Number))
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, line := range lines {
+			fmt.Println(line)
+		}
+	case rollback > 0:
+		revision, err := history.Get(rollback)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err = rollbackDefinition(c, name, revision); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Definition %s is rolled back to revision %d, it is deployed by the next run", name, revision.Number)
+	case outputFormat == "json":
+		data, err := json.Marshal(history)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+	default:
+		for _, line := range scheduler.RevisionsAsTable(history) {
+			fmt.Println(line)
+		}
+	}
+}
+
+// decryptRevision decrypts definition of the revision which was stored encrypted with key providers
+// given by persistent root command flags
+func decryptRevision(cmd *cobra.Command, revision *scheduler.Revision) error {
+	if revision.Definition.Encrypted == nil {
+		return nil
+	}
+	providers, err := keyProviders(cmd)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]client.KeyProvider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+	return client.DecryptDefinition(&revision.Definition, byName)
+}
+
+// rollbackDefinition replaces content of resource definition in the cluster with content of the revision.
+// Secret data is not kept in revisions, so data of the current definition is kept
+func rollbackDefinition(c client.Interface, name string, revision scheduler.Revision) error {
+	current, err := c.ResourceDefinitions().Get(name)
+	if err != nil {
+		return err
+	}
+	restored := revision.Definition
+	if revision.Redacted {
+		if current.Secret == nil {
+			return fmt.Errorf("Secret data is not kept in revision %d and definition %s has no secret data to keep", revision.Number, name)
+		}
+		secret := *restored.Secret
+		secret.Data = current.Secret.Data
+		secret.StringData = current.Secret.StringData
+		restored.Secret = &secret
+	}
+	restored.TypeMeta = current.TypeMeta
+	restored.ObjectMeta = current.ObjectMeta
+	_, err = c.ResourceDefinitions().Update(&restored)
+	return err
+}
+
+// InitRevisionsCommand returns cobra command for listing, comparing and restoring revisions of resource definitions
+func InitRevisionsCommand() *cobra.Command {
+	run := &cobra.Command{
+		Use:   "revisions NAME",
+		Short: "List revisions of resource definition",
+		Long: "List revisions of resource definition recorded when it was deployed (see --keep-revisions of run command). " +
+			"With --diff the object in the cluster is compared with the given revision, with --rollback the definition is restored to it",
+		Run: revisions,
+	}
+
+	var outputFormat string
+	run.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format: table or json")
+	var diff, rollback int
+	run.Flags().IntVar(&diff, "diff", 0, "Number of revision to print unified diff of the object in the cluster against")
+	run.Flags().IntVar(&rollback, "rollback", 0, "Number of revision to restore content of the definition to")
+	return run
+}
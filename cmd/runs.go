@@ -0,0 +1,93 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Mirantis/k8s-AppController/pkg/scheduler"
+)
+
+func printRuns(cmd *cobra.Command, args []string) {
+	outputFormat, err := cmd.Flags().GetString("output")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if outputFormat != "table" && outputFormat != "json" {
+		log.Fatal("Unknown output format. Expected one of: table, json")
+	}
+	graphName, err := cmd.Flags().GetString("graph-name")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// arguments are run IDs, so cluster URL is taken from environment
+	c, err := newClient(cmd, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	store := scheduler.NewConfigMapRunRecordStore(c.ConfigMaps(), graphName, 0)
+
+	var records []scheduler.RunRecord
+	if len(args) == 0 {
+		if records, err = store.List(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, id := range args {
+		record, err := store.Get(id)
+		if err != nil {
+			log.Fatal(err)
+		}
+		records = append(records, record)
+	}
+
+	if outputFormat == "json" {
+		data, err := json.Marshal(records)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	lines := scheduler.RunsAsTable(records)
+	if len(args) > 0 {
+		lines = scheduler.RunDurationsAsTable(records)
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
+
+// InitRunsCommand returns cobra command for listing and comparing recorded runs of AppController graph
+func InitRunsCommand() *cobra.Command {
+	run := &cobra.Command{
+		Use:   "runs [RUN ID...]",
+		Short: "List recorded graph runs",
+		Long: "List graph runs recorded with --keep-runs flag of run command, with their outcomes and durations. " +
+			"If run IDs are given, print time each resource took to become ready in these runs, so that they can be compared",
+		Run: printRuns,
+	}
+
+	var outputFormat string
+	run.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format: table or json")
+	var graphName string
+	run.Flags().StringVar(&graphName, "graph-name", "", "Name of the graph to list runs of. Runs of all graphs are listed if not set")
+	return run
+}
@@ -0,0 +1,179 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/pkg/labels"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/scheduler"
+)
+
+func printProgress(cmd *cobra.Command, args []string) {
+	labelSelector, err := getLabelSelector(cmd)
+	if err != nil {
+		log.Fatal(err)
+	}
+	outputFormat, err := cmd.Flags().GetString("output")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if outputFormat != "table" && outputFormat != "json" {
+		log.Fatal("Unknown output format. Expected one of: table, json")
+	}
+	stateConfigMap, err := cmd.Flags().GetString("state-configmap")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	c, err := newClient(cmd, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sel, err := labels.Parse(labelSelector)
+	if err != nil {
+		log.Fatal(err)
+	}
+	depGraph, err := scheduler.BuildDependencyGraph(c, sel)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var states map[string]scheduler.NodeRecord
+	if stateConfigMap != "" {
+		states, err = scheduler.NewConfigMapStateStore(c.ConfigMaps(), stateConfigMap).Load()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	why, err := cmd.Flags().GetString("why")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if why != "" {
+		printBlocking(depGraph, why, outputFormat)
+		return
+	}
+
+	summary, err := cmd.Flags().GetBool("summary")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if summary {
+		printSummary(cmd, c, depGraph, states, outputFormat)
+		return
+	}
+
+	depGraph.WithRevisions(scheduler.NewConfigMapRevisionStore(c.ConfigMaps(), 0))
+	progress := depGraph.Progress(states)
+	if outputFormat == "json" {
+		data, err := json.Marshal(progress)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	for _, line := range scheduler.ProgressAsTable(progress, time.Now()) {
+		fmt.Println(line)
+	}
+}
+
+// printBlocking prints resources which block the resource with given key without being blocked themselves
+func printBlocking(depGraph scheduler.DependencyGraph, key, outputFormat string) {
+	_, deploymentReport := depGraph.GetStatus()
+	analysis, err := deploymentReport.AnalyzeBlocking(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if outputFormat == "json" {
+		data, err := json.Marshal(analysis)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	for _, line := range analysis.AsText(0) {
+		fmt.Println(line)
+	}
+}
+
+// printSummary prints overall progress of the graph with time remaining estimated from durations
+// recorded in the history config map, or in records of previous runs of the graph
+func printSummary(cmd *cobra.Command, c client.Interface, depGraph scheduler.DependencyGraph, states map[string]scheduler.NodeRecord, outputFormat string) {
+	historyConfigMap, err := cmd.Flags().GetString("history-configmap")
+	if err != nil {
+		log.Fatal(err)
+	}
+	graphName, err := cmd.Flags().GetString("graph-name")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if historyConfigMap != "" {
+		depGraph.WithHistory(scheduler.NewConfigMapDurationHistory(c.ConfigMaps(), historyConfigMap))
+	} else {
+		depGraph.WithHistory(scheduler.RunRecordHistory(scheduler.NewConfigMapRunRecordStore(c.ConfigMaps(), graphName, 0)))
+	}
+	progress, err := depGraph.EstimateProgress(states, time.Now())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if outputFormat == "json" {
+		data, err := json.Marshal(progress)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	for _, line := range progress.AsText(0) {
+		fmt.Println(line)
+	}
+}
+
+// InitStatusCommand returns cobra command for printing progress of every resource of AppController graph
+func InitStatusCommand() *cobra.Command {
+	run := &cobra.Command{
+		Use:   "status",
+		Short: "Print progress of every resource",
+		Long:  "Print status, readiness percentage and blocking dependencies of every resource of AppController graph",
+		Run:   printProgress,
+	}
+
+	var labelSelector string
+	run.Flags().StringVarP(&labelSelector, "label", "l", "", "Label selector. Overrides KUBERNETES_AC_LABEL_SELECTOR env variable in AppController pod.")
+
+	var outputFormat string
+	run.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format: table or json")
+	var stateConfigMap string
+	run.Flags().StringVar(&stateConfigMap, "state-configmap", "", "Name of config map with deployment state (see --state-configmap of run command), used to show time spent in current state")
+	var summary bool
+	var historyConfigMap string
+	run.Flags().BoolVar(&summary, "summary", false, "Print overall progress with estimated time remaining, for the whole graph and for each of its branches")
+	run.Flags().StringVar(&historyConfigMap, "history-configmap", "", "Name of config map with readiness durations recorded by previous runs (see --history-configmap of run command), used by --summary to estimate time remaining")
+	var why string
+	run.Flags().StringVar(&why, "why", "", "Key of a resource (e.g. deployment/frontend) to explain what exactly is blocking it: the deepest not ready resources on every path of blocking dependencies leading to it")
+	var graphName string
+	run.Flags().StringVar(&graphName, "graph-name", scheduler.DefaultGraphName, "Name of the graph whose run records (see --keep-runs of run command) are used by --summary to estimate time remaining if --history-configmap is not set")
+	return run
+}
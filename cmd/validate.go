@@ -0,0 +1,161 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/labels"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/scheduler"
+)
+
+// loadFiles reads resource definitions and dependencies from YAML or JSON files, directories or
+// URLs. Files may contain several documents, documents of other kinds are ignored
+func loadFiles(paths []string) ([]client.ResourceDefinition, []client.Dependency, error) {
+	var resDefs []client.ResourceDefinition
+	var deps []client.Dependency
+	for _, path := range paths {
+		pathDefs, pathDeps, err := client.LoadSource(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		resDefs = append(resDefs, pathDefs...)
+		deps = append(deps, pathDeps...)
+	}
+	return resDefs, deps, nil
+}
+
+// loadCluster reads resource definitions and dependencies matching the label selector from the cluster
+func loadCluster(cmd *cobra.Command, args []string) ([]client.ResourceDefinition, []client.Dependency, error) {
+	labelSelector, err := getLabelSelector(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	sel, err := labels.Parse(labelSelector)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c, err := newClient(cmd, args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resDefList, err := c.ResourceDefinitions().List(api.ListOptions{LabelSelector: sel})
+	if err != nil {
+		return nil, nil, err
+	}
+	depList, err := c.Dependencies().List(api.ListOptions{LabelSelector: sel})
+	if err != nil {
+		return nil, nil, err
+	}
+	return resDefList.Items, depList.Items, nil
+}
+
+func validate(cmd *cobra.Command, args []string) {
+	files, err := cmd.Flags().GetStringSlice("file")
+	if err != nil {
+		log.Fatal(err)
+	}
+	getJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	outputFormat, err := cmd.Flags().GetString("output")
+	if err != nil {
+		log.Fatal(err)
+	}
+	switch outputFormat {
+	case "text":
+	case "json":
+		getJSON = true
+	default:
+		log.Fatalf("Unknown output format %s. Expected one of: text, json", outputFormat)
+	}
+	strict, err := cmd.Flags().GetBool("strict")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var resDefs []client.ResourceDefinition
+	var deps []client.Dependency
+	if len(files) > 0 {
+		resDefs, deps, err = loadFiles(files)
+	} else {
+		resDefs, deps, err = loadCluster(cmd, args)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	problems := scheduler.ValidateGraph(resDefs, deps)
+	failed := false
+	for _, problem := range problems {
+		if problem.Severity == scheduler.SeverityError || strict {
+			failed = true
+		}
+	}
+
+	if getJSON {
+		if problems == nil {
+			problems = []scheduler.ValidationProblem{}
+		}
+		data, err := json.Marshal(problems)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, problem := range problems {
+			fmt.Printf("%s: %s: %s\n", problem.Severity, problem.Type, problem.Message)
+		}
+		if !failed {
+			fmt.Printf("Graph of %d resource definitions and %d dependencies is valid\n", len(resDefs), len(deps))
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// InitValidateCommand returns cobra command for validating AppController graph
+func InitValidateCommand() *cobra.Command {
+	run := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate AppController graph",
+		Long:  "Check resource definitions and dependencies from the cluster or local files for cycles, references to undefined resources, duplicates and resources which can never be created",
+		Run:   validate,
+	}
+
+	var labelSelector string
+	run.Flags().StringVarP(&labelSelector, "label", "l", "", "Label selector. Overrides KUBERNETES_AC_LABEL_SELECTOR env variable in AppController pod.")
+
+	var files []string
+	run.Flags().StringSliceVarP(&files, "file", "f", nil, "YAML or JSON files, directories or URLs with resource definitions and dependencies to validate instead of the ones in the cluster")
+
+	var getJSON, strict bool
+	run.Flags().BoolVarP(&getJSON, "json", "j", false, "Output JSON, same as --output json")
+	var outputFormat string
+	run.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or json")
+	run.Flags().BoolVar(&strict, "strict", false, "Treat warnings as errors")
+	return run
+}
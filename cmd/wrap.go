@@ -16,9 +16,12 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -26,7 +29,7 @@ import (
 	"github.com/Mirantis/k8s-AppController/cmd/format"
 )
 
-func getInput(stream *os.File, indent int) string {
+func getInput(stream io.Reader, indent int) string {
 	result := ""
 	spaces := strings.Repeat(" ", indent)
 
@@ -38,11 +41,107 @@ func getInput(stream *os.File, indent int) string {
 	return result
 }
 
+// manifestExtensions are extensions of files read from directories, with formats of their content
+var manifestExtensions = map[string]format.Format{
+	".yaml": format.Yaml{},
+	".yml":  format.Yaml{},
+	".json": format.JSON{},
+}
+
+// manifestFiles returns files given in paths, replacing directories by manifest files found in them
+// recursively, in lexical order
+func manifestFiles(paths []string) ([]string, error) {
+	var result []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			result = append(result, path)
+			continue
+		}
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if _, ok := manifestExtensions[filepath.Ext(file)]; ok && !info.IsDir() {
+				result = append(result, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// wrapper collects definitions wrapped from several streams
+type wrapper struct {
+	names       format.Names
+	definitions []string
+	// references are collected only if dependencies are inferred
+	references []format.ReferenceExtractor
+	emitDeps   bool
+}
+
+// add wraps objects of the stream
+func (w *wrapper) add(f format.Format, stream string) error {
+	definitions, err := format.Definitions(f, stream, w.names)
+	if err != nil {
+		return err
+	}
+	w.definitions = append(w.definitions, definitions...)
+	if w.emitDeps {
+		references, err := format.References(f, stream)
+		if err != nil {
+			return err
+		}
+		w.references = append(w.references, references...)
+	}
+	return nil
+}
+
+// addFile wraps objects in file and returns its format. Format of the file is chosen by its
+// extension, falling back to the given one
+func (w *wrapper) addFile(path string, f format.Format) (format.Format, error) {
+	if extFormat, ok := manifestExtensions[filepath.Ext(path)]; ok {
+		f = extFormat
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if err = w.add(f, getInput(file, f.IndentLevel())); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return f, nil
+}
+
+// result returns wrapped definitions followed by inferred dependencies in given format
+func (w *wrapper) result(f format.Format) []string {
+	if !w.emitDeps {
+		return w.definitions
+	}
+	return append(w.definitions, format.Dependencies(f, w.references, format.Names{})...)
+}
+
 func wrap(cmd *cobra.Command, args []string) {
 	fileFormat, err := cmd.Flags().GetString("format")
 	if err != nil {
 		log.Fatal(err)
 	}
+	yamlStream, err := cmd.Flags().GetBool("yaml-stream")
+	if err != nil {
+		log.Fatal(err)
+	}
+	emitDeps, err := cmd.Flags().GetBool("emit-deps")
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	var f format.Format
 	switch fileFormat {
@@ -54,19 +153,63 @@ func wrap(cmd *cobra.Command, args []string) {
 		log.Fatal("Unknonwn file format. Expected one of: yaml, json")
 	}
 
-	definition := getInput(os.Stdin, f.IndentLevel())
-
-	out, err := f.Wrap(definition)
+	rendered, ok, err := renderManifests(cmd)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
+	}
+
+	// dependencies are always inferred for rendered charts and overlays
+	w := &wrapper{names: format.Names{}, emitDeps: emitDeps || ok}
+	if ok {
+		if len(args) > 0 {
+			log.Fatal("Files cannot be given together with --helm or --kustomize")
+		}
+		f = format.Yaml{}
+		if err = w.add(f, getInput(bytes.NewReader(rendered), f.IndentLevel())); err != nil {
+			log.Fatal(err)
+		}
+	} else if len(args) == 0 {
+		if err = w.add(f, getInput(os.Stdin, f.IndentLevel())); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		files, err := manifestFiles(args)
+		if err != nil {
+			log.Fatal(err)
+		}
+		formats := map[format.Format]bool{}
+		for _, path := range files {
+			fileFormat, err := w.addFile(path, f)
+			if err != nil {
+				log.Fatal(err)
+			}
+			formats[fileFormat] = true
+		}
+		if len(formats) > 1 && !yamlStream {
+			log.Fatal("Files have different formats, use --yaml-stream to combine them")
+		}
+		for fileFormat := range formats {
+			f = fileFormat
+		}
+	}
+
+	if yamlStream {
+		fmt.Println(format.YamlStream(w.result(format.Yaml{})))
+	} else {
+		fmt.Print(f.Join(w.result(f)))
 	}
-	fmt.Print(out)
 }
 
 // Wrap is cobra command for wrapping K8s objects in AppController definitions
 var Wrap = &cobra.Command{
-	Use:   "wrap",
+	Use:   "wrap [file or directory...]",
 	Short: "Echo wrapped k8s object to stdout",
-	Long:  "Echo wrapped k8s object to stdout",
-	Run:   wrap,
+	Long: `Echo wrapped k8s object to stdout. Objects are read from stdin, or from given files and
+directories, which are searched recursively for .yaml, .yml and .json files. Lists are replaced
+by their items, and every object is wrapped into a separate Definition. With --emit-deps,
+Dependencies inferred from references between the objects are emitted too.
+
+With --helm or --kustomize, objects are rendered from Helm chart or kustomize overlay by helm or
+kustomize (or kubectl) binary, and inferred Dependencies are always emitted`,
+	Run: wrap,
 }
@@ -18,6 +18,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -60,3 +61,37 @@ func TestInput(t *testing.T) {
 		}
 	}
 }
+
+// TestManifestFiles checks that directories are searched for manifests recursively in lexical order
+func TestManifestFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"b.yaml", "a/c.json", "a/d.txt", "e.yml"} {
+		path := filepath.Join(dir, name)
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := manifestFiles([]string{dir, filepath.Join(dir, "a/d.txt")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"a/c.json", "b.yaml", "e.yml", "a/d.txt"}
+	if len(files) != len(expected) {
+		t.Fatalf("Expected files %v, got %v", expected, files)
+	}
+	for i, name := range expected {
+		if files[i] != filepath.Join(dir, name) {
+			t.Errorf("Expected files %v, got %v", expected, files)
+			break
+		}
+	}
+}
@@ -0,0 +1,499 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// AuditLabel marks config maps holding audit log, its value is the name of the log
+const AuditLabel = "appcontroller.k8s/audit"
+
+// auditEntriesPerConfigMap limits number of entries in a single config map of audit log, so that config
+// maps stay within their size limit. Full config maps are never modified, entries go to the next one
+const auditEntriesPerConfigMap = 200
+
+// auditDiffLimit limits size of recorded diffs
+const auditDiffLimit = 4096
+
+// auditConflictRetries is a number of attempts to append entries to config map modified concurrently
+const auditConflictRetries = 5
+
+// Outcomes of audited requests
+const (
+	AuditSucceeded = "succeeded"
+	AuditFailed    = "failed"
+)
+
+// auditVerbs are verbs of audited requests by HTTP method, requests with other methods do not modify objects
+var auditVerbs = map[string]string{
+	http.MethodPost:   "create",
+	http.MethodPut:    "update",
+	http.MethodPatch:  "patch",
+	http.MethodDelete: "delete",
+}
+
+// AuditEntry is a record of a single mutation of the cluster made by AppController
+type AuditEntry struct {
+	Time  time.Time `json:"time"`
+	RunID string    `json:"runID,omitempty"`
+	// Verb is one of create, update, patch or delete
+	Verb string `json:"verb"`
+	// Key is <resource>/<name> of the object, or <resource> for creations and requests to collections
+	Key         string `json:"key"`
+	Namespace   string `json:"namespace,omitempty"`
+	Subresource string `json:"subresource,omitempty"`
+	// Diff is a JSON merge patch from the previous object to the new one for updates, the patch itself
+	// for patches and the new object for creations
+	Diff    string `json:"diff,omitempty"`
+	Code    int    `json:"code,omitempty"`
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// AuditLog is an append-only log of mutations kept in config maps labeled with AuditLabel. Entries are
+// written as soon as they are recorded, entries which could not be written are kept and written with
+// the next one
+type AuditLog struct {
+	name       string
+	configMaps corev1.ConfigMapInterface
+
+	current *v1.ConfigMap
+	pending []AuditEntry
+	sync.Mutex
+}
+
+// NewAuditLog returns audit log with given name kept in config maps of the client
+func NewAuditLog(configMaps corev1.ConfigMapInterface, name string) *AuditLog {
+	return &AuditLog{name: name, configMaps: configMaps}
+}
+
+// Record appends entry to the log
+func (l *AuditLog) Record(entry AuditEntry) error {
+	l.Lock()
+	defer l.Unlock()
+	l.pending = append(l.pending, entry)
+	return l.flush()
+}
+
+// flush writes pending entries to the current config map of the log
+func (l *AuditLog) flush() error {
+	conflicts := 0
+	for len(l.pending) > 0 {
+		if l.current == nil || len(l.current.Data) >= auditEntriesPerConfigMap {
+			if err := l.nextConfigMap(); err != nil {
+				return err
+			}
+		}
+
+		configMap := *l.current
+		configMap.Data = make(map[string]string, len(l.current.Data)+len(l.pending))
+		for key, value := range l.current.Data {
+			configMap.Data[key] = value
+		}
+		n := 0
+		for ; n < len(l.pending) && len(configMap.Data) < auditEntriesPerConfigMap; n++ {
+			data, err := json.Marshal(l.pending[n])
+			if err != nil {
+				return err
+			}
+			configMap.Data[auditEntryKey(len(configMap.Data))] = string(data)
+		}
+
+		updated, err := l.configMaps.Update(&configMap)
+		if errors.IsConflict(err) && conflicts < auditConflictRetries {
+			// another process appended entries, they are reloaded before retrying
+			conflicts++
+			l.current = nil
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		l.current = updated
+		l.pending = l.pending[n:]
+	}
+	return nil
+}
+
+// nextConfigMap makes the latest config map of the log current, or creates the next one if the latest
+// one is full
+func (l *AuditLog) nextConfigMap() error {
+	configMaps, err := l.list()
+	if err != nil {
+		return err
+	}
+	index := 0
+	if len(configMaps) > 0 {
+		latest := configMaps[len(configMaps)-1]
+		if len(latest.configMap.Data) < auditEntriesPerConfigMap {
+			l.current = latest.configMap
+			return nil
+		}
+		index = latest.index + 1
+	}
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:   fmt.Sprintf("%s-%05d", l.name, index),
+			Labels: map[string]string{AuditLabel: l.name},
+		},
+		Data: map[string]string{},
+	}
+	created, err := l.configMaps.Create(configMap)
+	if errors.IsAlreadyExists(err) {
+		// created by another process in the meantime
+		created, err = l.configMaps.Get(configMap.Name)
+	}
+	if err != nil {
+		return err
+	}
+	l.current = created
+	return nil
+}
+
+// auditConfigMap is a config map of audit log with its index in the log
+type auditConfigMap struct {
+	index     int
+	configMap *v1.ConfigMap
+}
+
+type auditConfigMapsByIndex []auditConfigMap
+
+func (a auditConfigMapsByIndex) Len() int           { return len(a) }
+func (a auditConfigMapsByIndex) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a auditConfigMapsByIndex) Less(i, j int) bool { return a[i].index < a[j].index }
+
+// list returns config maps of the log in order of their indexes
+func (l *AuditLog) list() ([]auditConfigMap, error) {
+	list, err := l.configMaps.List(v1.ListOptions{LabelSelector: AuditLabel + "=" + l.name})
+	if err != nil {
+		return nil, err
+	}
+	var configMaps []auditConfigMap
+	for i := range list.Items {
+		configMap := &list.Items[i]
+		if configMap.Labels[AuditLabel] != l.name {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimPrefix(configMap.Name, l.name+"-"))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid name of audit log config map %s", configMap.Name)
+		}
+		configMaps = append(configMaps, auditConfigMap{index, configMap})
+	}
+	sort.Sort(auditConfigMapsByIndex(configMaps))
+	return configMaps, nil
+}
+
+// Entries returns all entries of the log in order they were recorded
+func (l *AuditLog) Entries() ([]AuditEntry, error) {
+	configMaps, err := l.list()
+	if err != nil {
+		return nil, err
+	}
+	entries := []AuditEntry{}
+	for _, c := range configMaps {
+		for i := 0; i < len(c.configMap.Data); i++ {
+			data, ok := c.configMap.Data[auditEntryKey(i)]
+			if !ok {
+				return nil, fmt.Errorf("Audit log config map %s is missing entry %d", c.configMap.Name, i)
+			}
+			var entry AuditEntry
+			if err = json.Unmarshal([]byte(data), &entry); err != nil {
+				return nil, fmt.Errorf("Invalid entry %d of audit log config map %s: %v", i, c.configMap.Name, err)
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// auditEntryKey returns config map data key of the entry with given index, keys sort in order of entries
+func auditEntryKey(i int) string {
+	return fmt.Sprintf("%04d", i)
+}
+
+// auditRedactedValue replaces redacted values in recorded changes
+const auditRedactedValue = "[redacted]"
+
+// auditSecretFields are fields of Secrets whose values are redacted in recorded changes
+var auditSecretFields = []string{"data", "stringData"}
+
+// auditObjectFields are fields of objects from encrypted definitions which are recorded as they are
+var auditObjectFields = map[string]bool{"apiVersion": true, "kind": true, "metadata": true}
+
+// auditRedactions are keys of objects created from encrypted definitions. Only metadata of such objects
+// is recorded in audit log, as the rest of them is kept encrypted in the cluster
+type auditRedactions struct {
+	keys map[string]bool
+	sync.RWMutex
+}
+
+func newAuditRedactions() *auditRedactions {
+	return &auditRedactions{keys: map[string]bool{}}
+}
+
+// auditResource returns API resource of lowercase kind
+func auditResource(kind string) string {
+	switch {
+	case strings.HasSuffix(kind, "s"):
+		return kind + "es"
+	case len(kind) > 1 && strings.HasSuffix(kind, "y") && !strings.ContainsRune("aeiou", rune(kind[len(kind)-2])):
+		return kind[:len(kind)-1] + "ies"
+	}
+	return kind + "s"
+}
+
+func (r *auditRedactions) add(kind, name string) {
+	r.Lock()
+	defer r.Unlock()
+	r.keys[auditResource(strings.ToLower(kind))+"/"+name] = true
+}
+
+// addDefinition makes object of decrypted resource definition redacted
+func (r *auditRedactions) addDefinition(def *ResourceDefinition) error {
+	if def.Object != nil {
+		r.add(def.Object.Kind, def.Object.Name)
+		return nil
+	}
+	data, err := json.Marshal(def)
+	if err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for field, value := range fields {
+		if definitionEnvelopeFields[field] {
+			continue
+		}
+		var header objectHeader
+		if err = json.Unmarshal(value, &header); err == nil && header.Metadata.Name != "" {
+			r.add(field, header.Metadata.Name)
+		}
+	}
+	return nil
+}
+
+func (r *auditRedactions) has(key string) bool {
+	r.RLock()
+	defer r.RUnlock()
+	return r.keys[key]
+}
+
+// redact replaces sensitive values of recorded object with auditRedactedValue. Values are replaced after
+// the diff is made, so that changed keys are still recorded
+func (r *auditRedactions) redact(key string, object interface{}) interface{} {
+	redacted := r != nil && r.has(key)
+	fields, ok := object.(map[string]interface{})
+	if !ok {
+		// e.g. JSON patches, which cannot be redacted field by field
+		if redacted || strings.HasPrefix(key, "secrets/") {
+			return auditRedactedValue
+		}
+		return object
+	}
+	if redacted {
+		for field, value := range fields {
+			if !auditObjectFields[field] && value != nil {
+				fields[field] = auditRedactedValue
+			}
+		}
+		return fields
+	}
+	if strings.HasPrefix(key, "secrets/") {
+		for _, field := range auditSecretFields {
+			values, ok := fields[field].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for name, value := range values {
+				if value != nil {
+					values[name] = auditRedactedValue
+				}
+			}
+		}
+	}
+	return fields
+}
+
+// auditRoundTripper records every request modifying objects in the audit log
+type auditRoundTripper struct {
+	base       http.RoundTripper
+	log        *AuditLog
+	redactions *auditRedactions
+}
+
+// RoundTrip sends the request and records it in the audit log with its outcome
+func (rt *auditRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	verb, ok := auditVerbs[req.Method]
+	if !ok {
+		return rt.base.RoundTrip(req)
+	}
+
+	entry := AuditEntry{Time: time.Now(), RunID: logging.RunID(), Verb: verb}
+	entry.Namespace, entry.Key, entry.Subresource = parseAuditPath(req.URL.Path)
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		// requests must not be modified by round trippers, so the body is read again from a copy
+		bodyReq := new(http.Request)
+		*bodyReq = *req
+		bodyReq.Body = ioutil.NopCloser(bytes.NewReader(body))
+		req = bodyReq
+		if verb != "delete" {
+			entry.Diff = rt.diff(req, verb, entry.Key, body)
+		}
+	}
+
+	resp, err := rt.base.RoundTrip(req)
+	switch {
+	case err != nil:
+		entry.Outcome = AuditFailed
+		entry.Error = err.Error()
+	case resp.StatusCode >= http.StatusBadRequest:
+		entry.Code = resp.StatusCode
+		entry.Outcome = AuditFailed
+		entry.Error = resp.Status
+	default:
+		entry.Code = resp.StatusCode
+		entry.Outcome = AuditSucceeded
+	}
+	if logErr := rt.log.Record(entry); logErr != nil {
+		logging.Warningf("Could not record %s of %s in audit log: %v", verb, entry.Key, logErr)
+	}
+	return resp, err
+}
+
+// diff returns recorded change made by the request. Updates are compared with the current object. Data of
+// Secrets and objects from encrypted definitions is redacted
+func (rt *auditRoundTripper) diff(req *http.Request, verb, key string, body []byte) string {
+	var object interface{}
+	if err := json.Unmarshal(body, &object); err != nil {
+		return fmt.Sprintf("<%d bytes of %s>", len(body), req.Header.Get("Content-Type"))
+	}
+	if verb == "create" {
+		var header objectHeader
+		if err := json.Unmarshal(body, &header); err == nil {
+			key += "/" + header.Metadata.Name
+		}
+	}
+	if verb == "update" {
+		if current, ok := rt.current(req); ok {
+			object, _ = mergePatch(current, object)
+		}
+	}
+	object = rt.redactions.redact(key, object)
+	data, err := json.Marshal(object)
+	if err != nil {
+		return ""
+	}
+	if len(data) > auditDiffLimit {
+		return string(data[:auditDiffLimit]) + "..."
+	}
+	return string(data)
+}
+
+// current returns JSON of the object the request is sent to
+func (rt *auditRoundTripper) current(req *http.Request) (interface{}, bool) {
+	getReq, err := http.NewRequest(http.MethodGet, req.URL.String(), nil)
+	if err != nil {
+		return nil, false
+	}
+	for key, values := range req.Header {
+		getReq.Header[key] = append([]string(nil), values...)
+	}
+	getReq.Header.Del("Content-Type")
+	getReq.Header.Set("Accept", contentTypeJSON)
+	resp, err := rt.base.RoundTrip(getReq)
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, false
+	}
+	var object interface{}
+	if err = json.NewDecoder(resp.Body).Decode(&object); err != nil {
+		return nil, false
+	}
+	return object, true
+}
+
+// mergePatch returns JSON merge patch turning from into to, and whether they differ at all
+func mergePatch(from, to interface{}) (interface{}, bool) {
+	fromMap, fromOk := from.(map[string]interface{})
+	toMap, toOk := to.(map[string]interface{})
+	if !fromOk || !toOk {
+		return to, !reflect.DeepEqual(from, to)
+	}
+	patch := map[string]interface{}{}
+	for key, value := range toMap {
+		if p, changed := mergePatch(fromMap[key], value); changed {
+			patch[key] = p
+		}
+	}
+	for key := range fromMap {
+		if _, ok := toMap[key]; !ok {
+			patch[key] = nil
+		}
+	}
+	return patch, len(patch) > 0
+}
+
+// parseAuditPath returns namespace, key and subresource of API path
+func parseAuditPath(path string) (namespace, key, subresource string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(parts) >= 2 && parts[0] == "api":
+		parts = parts[2:]
+	case len(parts) >= 3 && parts[0] == "apis":
+		parts = parts[3:]
+	default:
+		return "", path, ""
+	}
+	if len(parts) >= 3 && parts[0] == "namespaces" {
+		namespace = parts[1]
+		parts = parts[2:]
+	}
+	switch len(parts) {
+	case 0:
+		return namespace, "", ""
+	case 1, 2:
+		return namespace, strings.Join(parts, "/"), ""
+	}
+	return namespace, strings.Join(parts[:2], "/"), strings.Join(parts[2:], "/")
+}
@@ -0,0 +1,192 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// TestParseAuditPath checks that keys of objects are taken from API paths of core and group resources
+func TestParseAuditPath(t *testing.T) {
+	cases := []struct {
+		path, namespace, key, subresource string
+	}{
+		{"/api/v1/namespaces/testing/pods/pod1", "testing", "pods/pod1", ""},
+		{"/api/v1/namespaces/testing/pods", "testing", "pods", ""},
+		{"/apis/extensions/v1beta1/namespaces/testing/deployments/web/scale", "testing", "deployments/web", "scale"},
+		{"/api/v1/namespaces/testing", "", "namespaces/testing", ""},
+		{"/api/v1/nodes/node1", "", "nodes/node1", ""},
+	}
+	for _, tc := range cases {
+		namespace, key, subresource := parseAuditPath(tc.path)
+		if namespace != tc.namespace || key != tc.key || subresource != tc.subresource {
+			t.Errorf("Unexpected namespace %q, key %q and subresource %q of %s", namespace, key, subresource, tc.path)
+		}
+	}
+}
+
+// TestAuditRoundTripper checks that mutations are recorded with their outcomes and updates are recorded
+// as merge patches from current objects
+func TestAuditRoundTripper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"metadata": {"name": "pod1"}, "spec": {"a": 1, "b": 2}}`))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	log := NewAuditLog(fake.NewSimpleClientset().Core().ConfigMaps("testing"), "audit")
+	rt := &auditRoundTripper{base: http.DefaultTransport, log: log}
+	send := func(method, body string) {
+		req, err := http.NewRequest(method, server.URL+"/api/v1/namespaces/testing/pods/pod1", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	send(http.MethodGet, "")
+	send(http.MethodPut, `{"metadata": {"name": "pod1"}, "spec": {"a": 3}}`)
+	send(http.MethodDelete, "")
+
+	entries, err := log.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %v", entries)
+	}
+	if e := entries[0]; e.Verb != "update" || e.Key != "pods/pod1" || e.Namespace != "testing" || e.Outcome != AuditSucceeded {
+		t.Errorf("Unexpected update entry %+v", e)
+	}
+	if diff := entries[0].Diff; diff != `{"spec":{"a":3,"b":null}}` {
+		t.Errorf("Unexpected diff %s", diff)
+	}
+	if e := entries[1]; e.Verb != "delete" || e.Outcome != AuditFailed || e.Code != http.StatusNotFound {
+		t.Errorf("Unexpected delete entry %+v", e)
+	}
+}
+
+// TestAuditLogRollover checks that entries go to the next config map when the current one is full
+func TestAuditLogRollover(t *testing.T) {
+	configMaps := fake.NewSimpleClientset().Core().ConfigMaps("testing")
+	log := NewAuditLog(configMaps, "audit")
+	for i := 0; i <= auditEntriesPerConfigMap; i++ {
+		if err := log.Record(AuditEntry{Verb: "create", Key: "pods", Code: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := configMaps.List(v1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 2 {
+		t.Errorf("Expected 2 config maps, got %d", len(list.Items))
+	}
+	entries, err := NewAuditLog(configMaps, "audit").Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != auditEntriesPerConfigMap+1 {
+		t.Fatalf("Expected %d entries, got %d", auditEntriesPerConfigMap+1, len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Code != i {
+			t.Fatalf("Entry %d is out of order: %+v", i, entry)
+		}
+	}
+}
+
+// TestAuditRedaction checks that data of Secrets and objects of decrypted definitions is not recorded,
+// while changed keys still are
+func TestAuditRedaction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"metadata": {"name": "creds"}, "data": {"user": "YWRtaW4=", "password": "b2xk"}}`))
+		}
+	}))
+	defer server.Close()
+
+	log := NewAuditLog(fake.NewSimpleClientset().Core().ConfigMaps("testing"), "audit")
+	redactions := newAuditRedactions()
+	redactions.addDefinition(&ResourceDefinition{ConfigMap: &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: "settings"},
+		Data:       map[string]string{"token": "plain"},
+	}})
+	rt := &auditRoundTripper{base: http.DefaultTransport, log: log, redactions: redactions}
+	send := func(method, path, body string) {
+		req, err := http.NewRequest(method, server.URL+"/api/v1/namespaces/testing/"+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	send(http.MethodPost, "secrets", `{"kind": "Secret", "metadata": {"name": "creds"}, "stringData": {"password": "new"}}`)
+	send(http.MethodPut, "secrets/creds", `{"metadata": {"name": "creds"}, "data": {"user": "YWRtaW4=", "password": "bmV3"}}`)
+	send(http.MethodPatch, "secrets/creds", `[{"op": "replace", "path": "/data/password", "value": "bmV3"}]`)
+	send(http.MethodPost, "configmaps", `{"kind": "ConfigMap", "metadata": {"name": "settings"}, "data": {"token": "plain"}}`)
+	send(http.MethodPost, "configmaps", `{"kind": "ConfigMap", "metadata": {"name": "other"}, "data": {"key": "value"}}`)
+
+	entries, err := log.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`{"kind":"Secret","metadata":{"name":"creds"},"stringData":{"password":"[redacted]"}}`,
+		`{"data":{"password":"[redacted]"}}`,
+		`"[redacted]"`,
+		`{"data":"[redacted]","kind":"ConfigMap","metadata":{"name":"settings"}}`,
+		`{"data":{"key":"value"},"kind":"ConfigMap","metadata":{"name":"other"}}`,
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %v", len(expected), entries)
+	}
+	for i, entry := range entries {
+		if entry.Diff != expected[i] {
+			t.Errorf("Expected diff %s of %s, got %s", expected[i], entry.Key, entry.Diff)
+		}
+	}
+}
+
+// TestAuditResource checks API resources of kinds of encrypted definitions
+func TestAuditResource(t *testing.T) {
+	for kind, resource := range map[string]string{
+		"configmap":     "configmaps",
+		"storageclass":  "storageclasses",
+		"networkpolicy": "networkpolicies",
+		"gateway":       "gateways",
+	} {
+		if r := auditResource(kind); r != resource {
+			t.Errorf("Expected resource %s of %s, got %s", resource, kind, r)
+		}
+	}
+}
@@ -15,22 +15,27 @@
 package client
 
 import (
-	"log"
 	"os"
 
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
 	// install v1alpha1 petset api
 	_ "github.com/Mirantis/k8s-AppController/pkg/client/petsets/apis/apps/install"
 	"github.com/Mirantis/k8s-AppController/pkg/client/petsets/typed/apps/v1alpha1"
 
+	"golang.org/x/net/context"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
 	appsbeta1 "k8s.io/client-go/kubernetes/typed/apps/v1beta1"
 	batchv1 "k8s.io/client-go/kubernetes/typed/batch/v1"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/kubernetes/typed/extensions/v1beta1"
+	rbacalpha1 "k8s.io/client-go/kubernetes/typed/rbac/v1alpha1"
+	storagebeta1 "k8s.io/client-go/kubernetes/typed/storage/v1beta1"
 	"k8s.io/client-go/pkg/api"
 	"k8s.io/client-go/pkg/api/unversioned"
 	"k8s.io/client-go/pkg/apimachinery/announced"
 	"k8s.io/client-go/pkg/runtime"
+	"k8s.io/client-go/pkg/version"
 	"k8s.io/client-go/rest"
 )
 
@@ -86,11 +91,35 @@ type Interface interface {
 	DaemonSets() v1beta1.DaemonSetInterface
 	Deployments() v1beta1.DeploymentInterface
 	PersistentVolumeClaims() corev1.PersistentVolumeClaimInterface
+	Events() corev1.EventInterface
+	Nodes() corev1.NodeInterface
+	Namespaces() corev1.NamespaceInterface
+	PersistentVolumes() corev1.PersistentVolumeInterface
+	StorageClasses() storagebeta1.StorageClassInterface
+	ClusterRoles() rbacalpha1.ClusterRoleInterface
+
+	// WithNamespace returns client of the same cluster for objects in given namespace
+	WithNamespace(namespace string) Interface
+	// Impersonate returns client of the same cluster making requests on behalf of the service account
+	// of the namespace of the client
+	Impersonate(serviceAccount string) (Interface, error)
 
 	Dependencies() DependenciesInterface
 	ResourceDefinitions() ResourceDefinitionsInterface
 
 	IsEnabled(version unversioned.GroupVersion) bool
+
+	// Discovery returns discovery client of the API server
+	Discovery() discovery.DiscoveryInterface
+	// ServerAtLeast returns true if version of the API server is major.minor or newer
+	ServerAtLeast(major, minor int) bool
+	// ResourceExists returns true if the API server serves objects of given kind
+	ResourceExists(gvk unversioned.GroupVersionKind) bool
+
+	// Exec runs the command in the container of the pod and returns its output
+	Exec(ctx context.Context, pod, container string, command []string) (string, error)
+	// Logs returns last lines of logs of the container of the pod
+	Logs(ctx context.Context, pod, container string, tailLines int64) (string, error)
 }
 
 type Client struct {
@@ -100,16 +129,29 @@ type Client struct {
 	ResDefs     ResourceDefinitionsInterface
 	Namespace   string
 	APIVersions *unversioned.APIGroupList
+	// ServerVersion is a version of the API server, nil if it could not be discovered
+	ServerVersion *version.Info
+	// Storage is an API extension serving Definitions and Dependencies in the cluster
+	Storage Storage
+	// Executor runs commands in pods, Exec fails if it is not set
+	Executor PodExecutor
+	// LogReader reads logs of pods, Logs fails if it is not set
+	LogReader PodLogReader
+
+	// config is a config the client was created from, Impersonate fails if it is not set
+	config *rest.Config
+	// auditRedactions are objects whose data is not recorded in audit log, nil if the client is not audited
+	auditRedactions *auditRedactions
 }
 
 var _ Interface = &Client{}
 
-// Dependencies returns dependency client for ThirdPartyResource created by AppController
+// Dependencies returns dependency client for custom resource created by AppController
 func (c Client) Dependencies() DependenciesInterface {
 	return c.Deps
 }
 
-// ResourceDefinitions returns resource definition client for ThirdPartyResource created by AppController
+// ResourceDefinitions returns resource definition client for custom resource created by AppController
 func (c Client) ResourceDefinitions() ResourceDefinitionsInterface {
 	return c.ResDefs
 }
@@ -173,6 +215,43 @@ func (c Client) PersistentVolumeClaims() corev1.PersistentVolumeClaimInterface {
 	return c.Clientset.Core().PersistentVolumeClaims(c.Namespace)
 }
 
+// Events return K8s Event client for ac namespace
+func (c Client) Events() corev1.EventInterface {
+	return c.Clientset.Core().Events(c.Namespace)
+}
+
+// Nodes return K8s Node client
+func (c Client) Nodes() corev1.NodeInterface {
+	return c.Clientset.Core().Nodes()
+}
+
+// Namespaces return K8s Namespace client
+func (c Client) Namespaces() corev1.NamespaceInterface {
+	return c.Clientset.Core().Namespaces()
+}
+
+// PersistentVolumes return K8s PersistentVolume client. Persistent volumes are cluster-scoped
+func (c Client) PersistentVolumes() corev1.PersistentVolumeInterface {
+	return c.Clientset.Core().PersistentVolumes()
+}
+
+// StorageClasses return K8s StorageClass client. Storage classes are cluster-scoped
+func (c Client) StorageClasses() storagebeta1.StorageClassInterface {
+	return c.Clientset.Storage().StorageClasses()
+}
+
+// ClusterRoles return K8s ClusterRole client. Cluster roles are cluster-scoped
+func (c Client) ClusterRoles() rbacalpha1.ClusterRoleInterface {
+	return c.Clientset.Rbac().ClusterRoles()
+}
+
+// WithNamespace returns copy of the client for given namespace. AppController dependencies and
+// resource definitions are still read from ac namespace
+func (c Client) WithNamespace(namespace string) Interface {
+	c.Namespace = namespace
+	return &c
+}
+
 // IsEnabled verifies that required group name and group version is registered in API
 // particularly we need it to support both pet sets and stateful sets using same application
 func (c Client) IsEnabled(version unversioned.GroupVersion) bool {
@@ -183,7 +262,7 @@ func (c Client) IsEnabled(version unversioned.GroupVersion) bool {
 		}
 		for _, v := range group.Versions {
 			if v.Version == version.Version {
-				log.Printf("Found version %v and group %v", group.Name, v.Version)
+				logging.Debugf("Found version %v and group %v", group.Name, v.Version)
 				return true
 			}
 		}
@@ -200,7 +279,7 @@ func newForConfig(c rest.Config, namespace string) (Interface, error) {
 	if err != nil {
 		return nil, err
 	}
-	cl, err := kubernetes.NewForConfig(&c)
+	cl, err := kubernetes.NewForConfig(builtinKindsConfig(c))
 	if err != nil {
 		return nil, err
 	}
@@ -212,14 +291,33 @@ func newForConfig(c rest.Config, namespace string) (Interface, error) {
 	if err != nil {
 		return nil, err
 	}
+	serverVersion, err := cl.Discovery().ServerVersion()
+	if err != nil {
+		logging.Warningf("Could not get version of API server: %v", err)
+		serverVersion = nil
+	}
+	storage, err := detectStorage(c, cl, versions)
+	if err != nil {
+		return nil, err
+	}
+	if storage == StorageUnknown {
+		logging.Warningf("Neither CustomResourceDefinitions nor ThirdPartyResources of AppController are found. Run kubeac bootstrap to create them")
+	} else {
+		logging.Debugf("Using %s storage of AppController objects", storage)
+	}
 
 	return &Client{
-		Clientset:   cl,
-		AlphaApps:   apps,
-		Deps:        deps,
-		ResDefs:     resdefs,
-		Namespace:   namespace,
-		APIVersions: versions,
+		Clientset:     cl,
+		AlphaApps:     apps,
+		Deps:          deps,
+		ResDefs:       resdefs,
+		Namespace:     namespace,
+		APIVersions:   versions,
+		ServerVersion: serverVersion,
+		Storage:       storage,
+		Executor:      websocketExecutor{config: &c},
+		LogReader:     restLogReader{clientset: cl},
+		config:        &c,
 	}, nil
 }
 
@@ -239,7 +337,7 @@ func thirdPartyResourceRESTClient(c *rest.Config) (*rest.RESTClient, error) {
 // If url is empty, assume in-cluster config. Otherwise, return config for remote cluster.
 func GetConfig(url string) (*rest.Config, error) {
 	if url == "" {
-		log.Println("No Kubernetes cluster URL provided. Assume in-cluster.")
+		logging.Infof("No Kubernetes cluster URL provided. Assume in-cluster.")
 		return rest.InClusterConfig()
 
 	}
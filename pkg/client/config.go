@@ -0,0 +1,282 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// ConfigOptions describe how to connect and authenticate to the cluster. Cluster is taken from kubeconfig
+// file if it is set, from URL otherwise, and in-cluster config is used if neither is set. Credentials
+// override the ones from kubeconfig or in-cluster config
+type ConfigOptions struct {
+	URL string
+	// Kubeconfig is a path to kubeconfig file, Context is a name of its context to use instead of current one
+	Kubeconfig string
+	Context    string
+
+	// Token is a bearer token, TokenFile is a file to read it from
+	Token     string
+	TokenFile string
+	// CertFile and KeyFile are client certificate and its key, CAFile is a certificate authority of the server
+	CertFile string
+	KeyFile  string
+	CAFile   string
+	Insecure bool
+	// ExecCommand is a command printing credential to stdout, either ExecCredential JSON
+	// ({"status": {"token": "...", "expirationTimestamp": "..."}}) or the token itself
+	ExecCommand string
+	// QPS and Burst limit rate of requests to the API server, client defaults are used if they are not set
+	QPS   float32
+	Burst int
+	// ContentType is either "protobuf" or "json" serialization of built-in kinds, protobuf is used if it is
+	// not set. AppController objects are always sent as JSON
+	ContentType string
+	// Namespace of AppController objects, it overrides namespace from env variable and kubeconfig context
+	Namespace string
+	// AuditConfigMap is a name of audit log in config maps of AppController namespace, every mutation made
+	// by the client is recorded in it. Built-in kinds are sent as JSON, so that changes can be recorded
+	AuditConfigMap string
+}
+
+// config returns REST config and namespace of kubeconfig context, if kubeconfig is used
+func (o ConfigOptions) config() (*rest.Config, string, error) {
+	var config *rest.Config
+	var namespace string
+	var err error
+	switch {
+	case o.Kubeconfig != "":
+		rules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: o.Kubeconfig}
+		// KUBECONFIG env variable may hold a list of files to merge
+		if paths := filepath.SplitList(o.Kubeconfig); len(paths) > 1 {
+			rules = &clientcmd.ClientConfigLoadingRules{Precedence: paths}
+		}
+		loader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			rules,
+			&clientcmd.ConfigOverrides{CurrentContext: o.Context},
+		)
+		if config, err = loader.ClientConfig(); err != nil {
+			return nil, "", err
+		}
+		if namespace, _, err = loader.Namespace(); err != nil {
+			return nil, "", err
+		}
+		if o.URL != "" {
+			config.Host = o.URL
+		}
+	default:
+		if config, err = GetConfig(o.URL); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if o.TokenFile != "" {
+		data, err := ioutil.ReadFile(o.TokenFile)
+		if err != nil {
+			return nil, "", err
+		}
+		config.BearerToken = strings.TrimSpace(string(data))
+	}
+	if o.Token != "" {
+		config.BearerToken = o.Token
+	}
+	if o.CertFile != "" || o.KeyFile != "" {
+		config.TLSClientConfig.CertFile = o.CertFile
+		config.TLSClientConfig.KeyFile = o.KeyFile
+		config.TLSClientConfig.CertData = nil
+		config.TLSClientConfig.KeyData = nil
+	}
+	if o.CAFile != "" {
+		config.TLSClientConfig.CAFile = o.CAFile
+		config.TLSClientConfig.CAData = nil
+	}
+	if o.Insecure {
+		config.Insecure = true
+		config.TLSClientConfig.CAFile = ""
+		config.TLSClientConfig.CAData = nil
+	}
+	if o.ExecCommand != "" {
+		command := strings.Fields(o.ExecCommand)
+		config.BearerToken = ""
+		config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+			return &execAuthRoundTripper{command: command, base: rt}
+		}
+	}
+	if o.QPS > 0 {
+		config.QPS = o.QPS
+	}
+	if o.Burst > 0 {
+		config.Burst = o.Burst
+	}
+	switch o.ContentType {
+	case "", "protobuf":
+	case "json":
+		config.ContentType = contentTypeJSON
+	default:
+		return nil, "", fmt.Errorf("unknown content type %s, expected one of: protobuf, json", o.ContentType)
+	}
+	if o.AuditConfigMap != "" {
+		config.ContentType = contentTypeJSON
+	}
+	return config, namespace, nil
+}
+
+// RESTConfig returns REST config for the cluster described by options
+func (o ConfigOptions) RESTConfig() (*rest.Config, error) {
+	config, _, err := o.config()
+	return config, err
+}
+
+// NewFromOptions returns client for the cluster described by options. Namespace is taken from
+// options, KUBERNETES_AC_POD_NAMESPACE env variable, or from kubeconfig context if neither is set
+func NewFromOptions(o ConfigOptions) (Interface, error) {
+	config, namespace, err := o.config()
+	if err != nil {
+		return nil, err
+	}
+	if ns := os.Getenv("KUBERNETES_AC_POD_NAMESPACE"); ns != "" || namespace == "" {
+		namespace = getNamespace()
+	}
+	if o.Namespace != "" {
+		namespace = o.Namespace
+	}
+	var redactions *auditRedactions
+	if o.AuditConfigMap != "" {
+		if redactions, err = withAuditLog(config, namespace, o.AuditConfigMap); err != nil {
+			return nil, err
+		}
+	}
+	c, err := newForConfig(*config, namespace)
+	if err != nil {
+		return nil, err
+	}
+	c.(*Client).auditRedactions = redactions
+	return c, nil
+}
+
+// withAuditLog makes clients created from config record their mutations in audit log with given name.
+// The log itself is written by a client which is not audited. Objects added to returned redactions are
+// recorded without their data
+func withAuditLog(config *rest.Config, namespace, name string) (*auditRedactions, error) {
+	cl, err := kubernetes.NewForConfig(builtinKindsConfig(*config))
+	if err != nil {
+		return nil, err
+	}
+	log := NewAuditLog(cl.Core().ConfigMaps(namespace), name)
+	redactions := newAuditRedactions()
+	wrap := config.WrapTransport
+	config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		if wrap != nil {
+			rt = wrap(rt)
+		}
+		return &auditRoundTripper{base: rt, log: log, redactions: redactions}
+	}
+	return redactions, nil
+}
+
+// execCredential is an output of exec auth plugin
+type execCredential struct {
+	Status struct {
+		Token               string     `json:"token"`
+		ExpirationTimestamp *time.Time `json:"expirationTimestamp,omitempty"`
+	} `json:"status"`
+}
+
+// execAuthRoundTripper authenticates requests with a token printed by external command. The token is
+// cached until it expires or the server rejects it
+type execAuthRoundTripper struct {
+	command []string
+	base    http.RoundTripper
+
+	token   string
+	expires time.Time
+	sync.Mutex
+}
+
+// getToken returns cached token or runs the command to get a new one
+func (rt *execAuthRoundTripper) getToken() (string, error) {
+	rt.Lock()
+	defer rt.Unlock()
+	if rt.token != "" && (rt.expires.IsZero() || time.Now().Before(rt.expires)) {
+		return rt.token, nil
+	}
+
+	cmd := exec.Command(rt.command[0], rt.command[1:]...)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("auth command %s failed: %v", rt.command[0], err)
+	}
+
+	var credential execCredential
+	if err = json.Unmarshal(output, &credential); err != nil {
+		// plain token
+		rt.token = strings.TrimSpace(string(output))
+		rt.expires = time.Time{}
+	} else {
+		rt.token = credential.Status.Token
+		rt.expires = time.Time{}
+		if credential.Status.ExpirationTimestamp != nil {
+			rt.expires = *credential.Status.ExpirationTimestamp
+		}
+	}
+	if rt.token == "" {
+		return "", fmt.Errorf("auth command %s returned empty token", rt.command[0])
+	}
+	return rt.token, nil
+}
+
+// resetToken drops cached token, so that the command is run again for the next request
+func (rt *execAuthRoundTripper) resetToken() {
+	rt.Lock()
+	defer rt.Unlock()
+	rt.token = ""
+}
+
+// RoundTrip sends request with Authorization header set to the token
+func (rt *execAuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := rt.getToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// requests must not be modified by round trippers, so the header is set on a copy
+	authReq := new(http.Request)
+	*authReq = *req
+	authReq.Header = make(http.Header, len(req.Header)+1)
+	for key, values := range req.Header {
+		authReq.Header[key] = append([]string(nil), values...)
+	}
+	authReq.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := rt.base.RoundTrip(authReq)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		rt.resetToken()
+	}
+	return resp, err
+}
@@ -0,0 +1,203 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://test.example.com
+users:
+- name: test
+  user:
+    token: kubeconfig-token
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+    namespace: apps
+- name: other
+  context:
+    cluster: test
+    user: test
+current-context: test
+`
+
+// writeTempFile writes data to file in temporary directory and returns its path
+func writeTempFile(t *testing.T, dir, name, data string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestConfigFromKubeconfig checks that server, credentials and namespace are taken from kubeconfig context
+func TestConfigFromKubeconfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := writeTempFile(t, dir, "config", testKubeconfig)
+
+	config, namespace, err := ConfigOptions{Kubeconfig: path}.config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "https://test.example.com" {
+		t.Errorf("expected host from kubeconfig, got %s", config.Host)
+	}
+	if config.BearerToken != "kubeconfig-token" {
+		t.Errorf("expected token from kubeconfig, got %s", config.BearerToken)
+	}
+	if namespace != "apps" {
+		t.Errorf("expected namespace apps, got %s", namespace)
+	}
+
+	_, namespace, err = ConfigOptions{Kubeconfig: path, Context: "other"}.config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if namespace != "default" {
+		t.Errorf("expected default namespace for context without namespace, got %s", namespace)
+	}
+}
+
+// TestConfigOverrides checks that URL and credentials from options override kubeconfig
+func TestConfigOverrides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := writeTempFile(t, dir, "config", testKubeconfig)
+	tokenFile := writeTempFile(t, dir, "token", "file-token\n")
+
+	config, err := ConfigOptions{
+		URL:        "http://localhost:8080",
+		Kubeconfig: path,
+		TokenFile:  tokenFile,
+		CertFile:   "/tmp/cert.pem",
+		KeyFile:    "/tmp/key.pem",
+		Insecure:   true,
+	}.RESTConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "http://localhost:8080" {
+		t.Errorf("expected host from URL, got %s", config.Host)
+	}
+	if config.BearerToken != "file-token" {
+		t.Errorf("expected token from file, got %s", config.BearerToken)
+	}
+	if config.CertFile != "/tmp/cert.pem" || config.KeyFile != "/tmp/key.pem" {
+		t.Errorf("expected client certificate from options, got %s and %s", config.CertFile, config.KeyFile)
+	}
+	if !config.Insecure {
+		t.Error("expected insecure config")
+	}
+
+	config, err = ConfigOptions{URL: "http://localhost:8080", Token: "token", QPS: 50, Burst: 100}.RESTConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.BearerToken != "token" {
+		t.Errorf("expected token from options, got %s", config.BearerToken)
+	}
+	if config.QPS != 50 || config.Burst != 100 {
+		t.Errorf("expected rate limits from options, got QPS %v and burst %d", config.QPS, config.Burst)
+	}
+}
+
+// TestExecAuth checks that token printed by auth command is sent to the server, cached and refreshed
+// once the server rejects it
+func TestExecAuth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exec-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	counter := filepath.Join(dir, "counter")
+	script := writeTempFile(t, dir, "auth.sh",
+		"echo x >> "+counter+"\n"+
+			`echo '{"status": {"token": "exec-token"}}'`+"\n")
+
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		if len(tokens) == 2 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	config, err := ConfigOptions{URL: server.URL, ExecCommand: "sh " + script}.RESTConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt := config.WrapTransport(http.DefaultTransport)
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequest("GET", server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if req.Header.Get("Authorization") != "" {
+			t.Error("original request must not be modified")
+		}
+	}
+
+	for _, token := range tokens {
+		if token != "Bearer exec-token" {
+			t.Errorf("expected token from auth command, got %q", token)
+		}
+	}
+	runs, err := ioutil.ReadFile(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// once for the first request and once more after the token was rejected
+	if len(runs) != 4 {
+		t.Errorf("expected auth command to run 2 times, got %d", len(runs)/2)
+	}
+}
+
+// TestExecAuthPlainToken checks that auth command may print the token itself
+func TestExecAuthPlainToken(t *testing.T) {
+	rt := &execAuthRoundTripper{command: []string{"echo", "plain-token"}}
+	token, err := rt.getToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "plain-token" {
+		t.Errorf("expected plain-token, got %s", token)
+	}
+}
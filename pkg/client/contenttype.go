@@ -0,0 +1,33 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import "k8s.io/client-go/rest"
+
+// ContentTypeProtobuf is a content type of protobuf serialization of built-in kinds
+const ContentTypeProtobuf = "application/vnd.kubernetes.protobuf"
+
+// contentTypeJSON is a content type of JSON serialization, which is the only one available for
+// AppController objects and PetSets
+const contentTypeJSON = "application/json"
+
+// builtinKindsConfig returns config of clients of built-in kinds, which use protobuf unless content
+// type is set explicitly
+func builtinKindsConfig(c rest.Config) *rest.Config {
+	if c.ContentType == "" {
+		c.ContentType = ContentTypeProtobuf
+	}
+	return &c
+}
@@ -0,0 +1,43 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import "testing"
+
+// TestContentType checks that built-in kinds use protobuf unless JSON is requested
+func TestContentType(t *testing.T) {
+	config, err := ConfigOptions{URL: "http://localhost:8080"}.RESTConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType := builtinKindsConfig(*config).ContentType; contentType != ContentTypeProtobuf {
+		t.Errorf("expected protobuf content type, got %s", contentType)
+	}
+	if config.ContentType != "" {
+		t.Errorf("config of AppController objects must not be changed, got content type %s", config.ContentType)
+	}
+
+	config, err = ConfigOptions{URL: "http://localhost:8080", ContentType: "json"}.RESTConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType := builtinKindsConfig(*config).ContentType; contentType != contentTypeJSON {
+		t.Errorf("expected JSON content type, got %s", contentType)
+	}
+
+	if _, err = (ConfigOptions{URL: "http://localhost:8080", ContentType: "yaml"}).RESTConfig(); err == nil {
+		t.Error("unknown content type should result in error")
+	}
+}
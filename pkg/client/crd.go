@@ -0,0 +1,405 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/labels"
+	"k8s.io/client-go/rest"
+
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// Storage is a kind of API extension serving Definitions and Dependencies
+type Storage string
+
+// Storages of AppController objects. Both serve the same group, version and resources, so objects are
+// accessed in the same way, but ThirdPartyResources are removed since Kubernetes 1.8
+const (
+	StorageUnknown                   Storage = ""
+	StorageThirdPartyResources       Storage = "ThirdPartyResource"
+	StorageCustomResourceDefinitions Storage = "CustomResourceDefinition"
+)
+
+// CRDGroupVersion is a group version of CustomResourceDefinition API
+var CRDGroupVersion = unversioned.GroupVersion{Group: "apiextensions.k8s.io", Version: "v1beta1"}
+
+// MigrationBackupName is a name of config map holding objects of ThirdPartyResources while they are
+// migrated to CustomResourceDefinitions. If migration is interrupted, it is resumed from the backup
+const MigrationBackupName = "appcontroller-tpr-backup"
+
+// crdEstablishTimeout is a time to wait for the CRD to be served by the API server, and for removed TPR
+// to stop being served
+var crdEstablishTimeout = 30 * time.Second
+
+// customResource describes one of AppController resources
+type customResource struct {
+	plural   string
+	singular string
+	kind     string
+	// status is true if status of objects is served as a subresource
+	status bool
+}
+
+// crdName returns name of CustomResourceDefinition of the resource
+func (r customResource) crdName() string {
+	return r.plural + "." + GroupName
+}
+
+// tprName returns name of ThirdPartyResource of the resource
+func (r customResource) tprName() string {
+	return r.singular + "." + GroupName
+}
+
+var customResources = []customResource{
+	{plural: "definitions", singular: "definition", kind: "Definition"},
+	{plural: "dependencies", singular: "dependency", kind: "Dependency", status: true},
+}
+
+// customResourceDefinition is a subset of apiextensions.k8s.io/v1beta1 CustomResourceDefinition used by AppController
+type customResourceDefinition struct {
+	unversioned.TypeMeta `json:",inline"`
+	Metadata             struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Group   string `json:"group"`
+		Version string `json:"version"`
+		Scope   string `json:"scope"`
+		Names   struct {
+			Plural   string `json:"plural"`
+			Singular string `json:"singular"`
+			Kind     string `json:"kind"`
+		} `json:"names"`
+		Subresources *crdSubresources `json:"subresources,omitempty"`
+	} `json:"spec"`
+	Status struct {
+		Conditions []struct {
+			Type   string `json:"type"`
+			Status string `json:"status"`
+		} `json:"conditions,omitempty"`
+	} `json:"status,omitempty"`
+}
+
+// crdSubresources are subresources served for objects of CRD
+type crdSubresources struct {
+	Status *struct{} `json:"status,omitempty"`
+}
+
+// newCustomResourceDefinition returns CRD of the resource
+func newCustomResourceDefinition(r customResource) *customResourceDefinition {
+	crd := &customResourceDefinition{}
+	crd.APIVersion = CRDGroupVersion.String()
+	crd.Kind = "CustomResourceDefinition"
+	crd.Metadata.Name = r.crdName()
+	crd.Spec.Group = GroupName
+	crd.Spec.Version = Version
+	crd.Spec.Scope = "Namespaced"
+	crd.Spec.Names.Plural = r.plural
+	crd.Spec.Names.Singular = r.singular
+	crd.Spec.Names.Kind = r.kind
+	if r.status {
+		crd.Spec.Subresources = &crdSubresources{Status: &struct{}{}}
+	}
+	return crd
+}
+
+// established returns true if the CRD is served by the API server
+func (crd *customResourceDefinition) established() bool {
+	for _, condition := range crd.Status.Conditions {
+		if condition.Type == "Established" && condition.Status == "True" {
+			return true
+		}
+	}
+	return false
+}
+
+// crdClient manages CustomResourceDefinitions. client-go of the used version has no client for them,
+// so requests and responses are encoded manually
+type crdClient struct {
+	rc *rest.RESTClient
+}
+
+func newCRDClient(c rest.Config) (*crdClient, error) {
+	c.APIPath = "/apis"
+	c.ContentConfig = rest.ContentConfig{
+		GroupVersion:         &CRDGroupVersion,
+		NegotiatedSerializer: api.Codecs,
+	}
+	rc, err := rest.RESTClientFor(&c)
+	if err != nil {
+		return nil, err
+	}
+	return &crdClient{rc}, nil
+}
+
+func (c *crdClient) get(name string) (*customResourceDefinition, error) {
+	resp, err := c.rc.Get().Resource("customresourcedefinitions").Name(name).DoRaw()
+	if err != nil {
+		return nil, err
+	}
+	crd := &customResourceDefinition{}
+	return crd, json.Unmarshal(resp, crd)
+}
+
+func (c *crdClient) create(crd *customResourceDefinition) error {
+	body, err := json.Marshal(crd)
+	if err != nil {
+		return err
+	}
+	return c.rc.Post().Resource("customresourcedefinitions").Body(body).Do().Error()
+}
+
+// detectStorage returns storage of AppController objects in the cluster. CRDs are preferred, as TPRs
+// are not migrated yet if both are present
+func detectStorage(c rest.Config, clientset kubernetes.Interface, versions *unversioned.APIGroupList) (Storage, error) {
+	if (Client{APIVersions: versions}).IsEnabled(CRDGroupVersion) {
+		crds, err := newCRDClient(c)
+		if err != nil {
+			return StorageUnknown, err
+		}
+		_, err = crds.get(customResources[0].crdName())
+		if err == nil {
+			return StorageCustomResourceDefinitions, nil
+		}
+		if !errors.IsNotFound(err) {
+			return StorageUnknown, err
+		}
+	}
+	exists, err := tprExists(clientset, customResources[0])
+	if err != nil || !exists {
+		return StorageUnknown, err
+	}
+	return StorageThirdPartyResources, nil
+}
+
+// tprExists returns true if ThirdPartyResource of the resource exists
+func tprExists(clientset kubernetes.Interface, r customResource) (bool, error) {
+	_, err := clientset.Extensions().ThirdPartyResources().Get(r.tprName())
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// migrationBackup holds AppController objects of all namespaces
+type migrationBackup struct {
+	Definitions  []ResourceDefinition
+	Dependencies []Dependency
+}
+
+// loadBackup returns backup of interrupted migration, or nil if there is none
+func loadBackup(clientset kubernetes.Interface, namespace string) (*migrationBackup, error) {
+	configMap, err := clientset.Core().ConfigMaps(namespace).Get(MigrationBackupName)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	backup := &migrationBackup{}
+	if err = json.Unmarshal([]byte(configMap.Data["definitions"]), &backup.Definitions); err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal([]byte(configMap.Data["dependencies"]), &backup.Dependencies); err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
+
+// saveBackup stores backup in config map, so that objects are not lost if migration is interrupted
+// after TPRs are deleted
+func saveBackup(clientset kubernetes.Interface, namespace string, backup *migrationBackup) error {
+	definitions, err := json.Marshal(backup.Definitions)
+	if err != nil {
+		return err
+	}
+	dependencies, err := json.Marshal(backup.Dependencies)
+	if err != nil {
+		return err
+	}
+	_, err = clientset.Core().ConfigMaps(namespace).Create(&v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: MigrationBackupName, Namespace: namespace},
+		Data: map[string]string{
+			"definitions":  string(definitions),
+			"dependencies": string(dependencies),
+		},
+	})
+	return err
+}
+
+// listAll returns AppController objects of all namespaces
+func listAll(c rest.Config) (*migrationBackup, error) {
+	resDefs, err := newResourceDefinitions(c, api.NamespaceAll)
+	if err != nil {
+		return nil, err
+	}
+	deps, err := newDependencies(c, api.NamespaceAll)
+	if err != nil {
+		return nil, err
+	}
+	resDefList, err := resDefs.List(api.ListOptions{LabelSelector: labels.Everything()})
+	if err != nil {
+		return nil, err
+	}
+	depList, err := deps.List(api.ListOptions{LabelSelector: labels.Everything()})
+	if err != nil {
+		return nil, err
+	}
+	return &migrationBackup{Definitions: resDefList.Items, Dependencies: depList.Items}, nil
+}
+
+// migratedMeta returns metadata of the object to create it in new storage. Metadata assigned by
+// the server is dropped
+func migratedMeta(meta api.ObjectMeta) api.ObjectMeta {
+	return api.ObjectMeta{
+		Name:        meta.Name,
+		Namespace:   meta.Namespace,
+		Labels:      meta.Labels,
+		Annotations: meta.Annotations,
+	}
+}
+
+// restore creates objects of the backup
+func restore(c rest.Config, backup *migrationBackup) error {
+	for _, resDef := range backup.Definitions {
+		resDefs, err := newResourceDefinitions(c, resDef.Namespace)
+		if err != nil {
+			return err
+		}
+		resDef.ObjectMeta = migratedMeta(resDef.ObjectMeta)
+		if _, err = resDefs.Create(&resDef); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("Failed to migrate definition %s/%s: %v", resDef.Namespace, resDef.Name, err)
+		}
+	}
+	for _, dep := range backup.Dependencies {
+		deps, err := newDependencies(c, dep.Namespace)
+		if err != nil {
+			return err
+		}
+		dep.ObjectMeta = migratedMeta(dep.ObjectMeta)
+		if _, err = deps.Create(&dep); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("Failed to migrate dependency %s/%s: %v", dep.Namespace, dep.Name, err)
+		}
+	}
+	return nil
+}
+
+// waitFor polls condition every second until it returns true or timeout expires
+func waitFor(description string, condition func() (bool, error)) error {
+	deadline := time.Now().Add(crdEstablishTimeout)
+	for {
+		done, err := condition()
+		if err != nil || done {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Timed out waiting for %s", description)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// EnsureCustomResourceDefinitions creates CRDs of Definitions and Dependencies. If they are stored in
+// ThirdPartyResources, objects of all namespaces are saved to backup config map in given namespace,
+// TPRs are deleted and objects are recreated once CRDs are established
+func EnsureCustomResourceDefinitions(c *rest.Config, namespace string) error {
+	clientset, err := kubernetes.NewForConfig(c)
+	if err != nil {
+		return err
+	}
+	crds, err := newCRDClient(*c)
+	if err != nil {
+		return err
+	}
+
+	backup, err := loadBackup(clientset, namespace)
+	if err != nil {
+		return err
+	}
+	if backup != nil {
+		logging.Infof("Resuming migration of ThirdPartyResources from config map %s", MigrationBackupName)
+	}
+	for _, r := range customResources {
+		exists, err := tprExists(clientset, r)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		if backup == nil {
+			if backup, err = listAll(*c); err != nil {
+				return err
+			}
+			if err = saveBackup(clientset, namespace, backup); err != nil {
+				return err
+			}
+			logging.Infof("Saved %d definitions and %d dependencies to config map %s",
+				len(backup.Definitions), len(backup.Dependencies), MigrationBackupName)
+		}
+		logging.Infof("Deleting ThirdPartyResource %s", r.tprName())
+		if err = clientset.Extensions().ThirdPartyResources().Delete(r.tprName(), nil); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		err = waitFor("deletion of "+r.tprName(), func() (bool, error) {
+			exists, err := tprExists(clientset, r)
+			return !exists, err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, r := range customResources {
+		err = crds.create(newCustomResourceDefinition(r))
+		switch {
+		case errors.IsAlreadyExists(err):
+			logging.Infof("%s already exists, skipping", r.crdName())
+		case err != nil:
+			return err
+		default:
+			logging.Infof("Created %s", r.crdName())
+		}
+		err = waitFor("establishment of "+r.crdName(), func() (bool, error) {
+			crd, err := crds.get(r.crdName())
+			if err != nil {
+				return false, err
+			}
+			return crd.established(), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if backup == nil {
+		return nil
+	}
+	if err = restore(*c, backup); err != nil {
+		return err
+	}
+	logging.Infof("Migrated %d definitions and %d dependencies to CustomResourceDefinitions",
+		len(backup.Definitions), len(backup.Dependencies))
+	return clientset.Core().ConfigMaps(namespace).Delete(MigrationBackupName, nil)
+}
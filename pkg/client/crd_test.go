@@ -0,0 +1,137 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+// TestBackupRoundTrip checks that objects saved to backup config map are loaded back
+func TestBackupRoundTrip(t *testing.T) {
+	c := fake.NewSimpleClientset()
+
+	backup, err := loadBackup(c, "testing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup != nil {
+		t.Fatal("expected no backup before it is saved")
+	}
+
+	saved := &migrationBackup{
+		Definitions: []ResourceDefinition{
+			{
+				ObjectMeta: api.ObjectMeta{Name: "pod-a", Namespace: "apps"},
+				Pod:        &v1.Pod{ObjectMeta: v1.ObjectMeta{Name: "a"}},
+			},
+		},
+		Dependencies: []Dependency{
+			{ObjectMeta: api.ObjectMeta{Name: "dep", Namespace: "apps"}, Parent: "pod/a", Child: "pod/b"},
+		},
+	}
+	if err = saveBackup(c, "testing", saved); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err = loadBackup(c, "testing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup == nil {
+		t.Fatal("expected backup to be loaded")
+	}
+	if len(backup.Definitions) != 1 || backup.Definitions[0].Pod == nil || backup.Definitions[0].Pod.Name != "a" {
+		t.Errorf("definition was not restored: %v", backup.Definitions)
+	}
+	if backup.Definitions[0].Namespace != "apps" {
+		t.Errorf("expected namespace of definition to be kept, got %s", backup.Definitions[0].Namespace)
+	}
+	if len(backup.Dependencies) != 1 || backup.Dependencies[0].Parent != "pod/a" || backup.Dependencies[0].Child != "pod/b" {
+		t.Errorf("dependency was not restored: %v", backup.Dependencies)
+	}
+}
+
+// TestMigratedMeta checks that metadata assigned by the server is dropped
+func TestMigratedMeta(t *testing.T) {
+	meta := migratedMeta(api.ObjectMeta{
+		Name:            "dep",
+		Namespace:       "apps",
+		Labels:          map[string]string{"app": "test"},
+		ResourceVersion: "42",
+		UID:             "uid",
+		SelfLink:        "/apis/appcontroller.k8s/v1alpha1/namespaces/apps/dependencies/dep",
+	})
+	if meta.Name != "dep" || meta.Namespace != "apps" || meta.Labels["app"] != "test" {
+		t.Errorf("name, namespace and labels should be kept, got %v", meta)
+	}
+	if meta.ResourceVersion != "" || meta.UID != "" || meta.SelfLink != "" {
+		t.Errorf("server metadata should be dropped, got %v", meta)
+	}
+}
+
+// TestCustomResourceDefinition checks CRDs of AppController resources
+func TestCustomResourceDefinition(t *testing.T) {
+	crd := newCustomResourceDefinition(customResources[1])
+	if crd.Metadata.Name != "dependencies.appcontroller.k8s" {
+		t.Errorf("unexpected CRD name %s", crd.Metadata.Name)
+	}
+	if crd.Spec.Group != GroupName || crd.Spec.Version != Version || crd.Spec.Names.Kind != "Dependency" {
+		t.Errorf("CRD should serve Dependency kind of %s, got %v", SchemeGroupVersion, crd.Spec)
+	}
+	if crd.Spec.Subresources == nil || crd.Spec.Subresources.Status == nil {
+		t.Error("status of dependencies should be served as subresource")
+	}
+	if customResources[1].tprName() != "dependency.appcontroller.k8s" {
+		t.Errorf("unexpected TPR name %s", customResources[1].tprName())
+	}
+	if crd.established() {
+		t.Error("CRD without conditions should not be established")
+	}
+
+	status := `{"status": {"conditions": [{"type": "NamesAccepted", "status": "True"}, {"type": "Established", "status": "True"}]}}`
+	if err := json.Unmarshal([]byte(status), crd); err != nil {
+		t.Fatal(err)
+	}
+	if !crd.established() {
+		t.Error("CRD should be established")
+	}
+}
+
+// TestTPRExists checks detection of ThirdPartyResources
+func TestTPRExists(t *testing.T) {
+	c := fake.NewSimpleClientset(&v1beta1.ThirdPartyResource{ObjectMeta: v1.ObjectMeta{Name: "definition.appcontroller.k8s"}})
+
+	exists, err := tprExists(c, customResources[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Error("definition TPR should exist")
+	}
+
+	exists, err = tprExists(c, customResources[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Error("dependency TPR should not exist")
+	}
+}
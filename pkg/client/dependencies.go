@@ -17,8 +17,10 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"time"
 
 	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/api/errors"
 	"k8s.io/client-go/pkg/api/unversioned"
 	"k8s.io/client-go/rest"
 )
@@ -32,6 +34,21 @@ type Dependency struct {
 	Parent string            `json:"parent"`
 	Child  string            `json:"child"`
 	Meta   map[string]string `json:"meta,omitempty"`
+
+	// Status is a report of the parent published during graph runs
+	Status *DependencyStatus `json:"status,omitempty"`
+}
+
+// DependencyStatus is a report of the parent of the dependency as seen by the child
+type DependencyStatus struct {
+	RunID string `json:"runID,omitempty"`
+	// Blocks is true if the parent blocks creation of the child
+	Blocks     bool   `json:"blocks"`
+	Percentage int    `json:"percentage"`
+	Needed     int    `json:"needed"`
+	Message    string `json:"message,omitempty"`
+	// UpdateTime is a time the report was made at
+	UpdateTime time.Time `json:"updateTime"`
 }
 
 type DependencyList struct {
@@ -47,6 +64,8 @@ type DependenciesInterface interface {
 	List(opts api.ListOptions) (*DependencyList, error)
 	Create(*Dependency) (*Dependency, error)
 	Delete(name string, opts *api.DeleteOptions) error
+	// UpdateStatus replaces status of the dependency, keeping the rest of it
+	UpdateStatus(name string, status *DependencyStatus) error
 }
 
 type dependencies struct {
@@ -84,9 +103,14 @@ func (c dependencies) List(opts api.ListOptions) (*DependencyList, error) {
 }
 
 func (c dependencies) Create(d *Dependency) (result *Dependency, err error) {
+	// CustomResourceDefinitions require objects to have kind and version
+	if d.Kind == "" {
+		d.APIVersion = SchemeGroupVersion.String()
+		d.Kind = "Dependency"
+	}
 	result = &Dependency{}
 	err = c.rc.Post().
-		Resource("Dependencies").
+		Resource("dependencies").
 		Namespace(c.namespace).
 		Body(d).
 		Do().
@@ -103,3 +127,30 @@ func (c *dependencies) Delete(name string, opts *api.DeleteOptions) error {
 		Do().
 		Error()
 }
+
+// UpdateStatus patches status subresource of the dependency. Dependencies served without status
+// subresource, i.e. by ThirdPartyResources or CRDs created by older versions, are patched as a whole
+func (c *dependencies) UpdateStatus(name string, status *DependencyStatus) error {
+	patch, err := json.Marshal(map[string]interface{}{"status": status})
+	if err != nil {
+		return err
+	}
+	err = c.rc.Patch(api.MergePatchType).
+		Namespace(c.namespace).
+		Resource("dependencies").
+		Name(name).
+		SubResource("status").
+		Body(patch).
+		Do().
+		Error()
+	if !errors.IsNotFound(err) {
+		return err
+	}
+	return c.rc.Patch(api.MergePatchType).
+		Namespace(c.namespace).
+		Resource("dependencies").
+		Name(name).
+		Body(patch).
+		Do().
+		Error()
+}
@@ -0,0 +1,90 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/version"
+)
+
+// Discovery returns discovery client of the API server
+func (c Client) Discovery() discovery.DiscoveryInterface {
+	return c.Clientset.Discovery()
+}
+
+// ServerAtLeast returns true if version of the API server is major.minor or newer. Unknown server
+// version is treated as older than any version
+func (c Client) ServerAtLeast(major, minor int) bool {
+	serverMajor, serverMinor, ok := parseServerVersion(c.ServerVersion)
+	if !ok {
+		return false
+	}
+	if serverMajor != major {
+		return serverMajor > major
+	}
+	return serverMinor >= minor
+}
+
+// ResourceExists returns true if the API server serves objects of given kind in given group version
+func (c Client) ResourceExists(gvk unversioned.GroupVersionKind) bool {
+	if c.APIVersions != nil && !c.IsEnabled(gvk.GroupVersion()) {
+		return false
+	}
+	resources, err := c.Discovery().ServerResourcesForGroupVersion(gvk.GroupVersion().String())
+	if err != nil {
+		logging.Debugf("Could not discover resources of %s: %v", gvk.GroupVersion(), err)
+		return false
+	}
+	if resources == nil {
+		return false
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Kind == gvk.Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// parseServerVersion returns major and minor numbers of server version. Providers often append
+// suffixes to the numbers, e.g. "5+" for minor version, which are ignored
+func parseServerVersion(info *version.Info) (int, int, bool) {
+	if info == nil {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(leadingDigits(info.Major))
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(leadingDigits(info.Minor))
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+func leadingDigits(s string) string {
+	end := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if end < 0 {
+		return s
+	}
+	return s[:end]
+}
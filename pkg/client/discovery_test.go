@@ -0,0 +1,50 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"testing"
+
+	"k8s.io/client-go/pkg/version"
+)
+
+// TestServerAtLeast checks comparison of API server version, including versions with provider suffixes
+func TestServerAtLeast(t *testing.T) {
+	c := Client{ServerVersion: &version.Info{Major: "1", Minor: "5+"}}
+	cases := []struct {
+		major, minor int
+		expected     bool
+	}{
+		{1, 4, true},
+		{1, 5, true},
+		{1, 6, false},
+		{0, 9, true},
+		{2, 0, false},
+	}
+	for _, tc := range cases {
+		if result := c.ServerAtLeast(tc.major, tc.minor); result != tc.expected {
+			t.Errorf("ServerAtLeast(%d, %d) is %t, expected %t", tc.major, tc.minor, result, tc.expected)
+		}
+	}
+}
+
+// TestServerAtLeastUnknownVersion checks that unknown server version does not satisfy any version gate
+func TestServerAtLeastUnknownVersion(t *testing.T) {
+	for _, info := range []*version.Info{nil, {Major: "", Minor: ""}, {Major: "v1", Minor: "5"}} {
+		if (Client{ServerVersion: info}).ServerAtLeast(0, 0) {
+			t.Errorf("Server of version %v should not satisfy any version gate", info)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"k8s.io/client-go/pkg/api"
+)
+
+// Names of key providers
+const (
+	LocalKeyProvider = "local"
+	KMSKeyProvider   = "kms"
+)
+
+// dataKeySize is a size of AES-256 keys
+const dataKeySize = 32
+
+// EncryptedObject is an object of resource definition encrypted with a random data key, which is in turn
+// encrypted by key provider (envelope encryption). The object is decrypted only in memory of AppController
+type EncryptedObject struct {
+	// Provider is a name of key provider which encrypted the data key, local or kms
+	Provider string `json:"provider"`
+	// KeyID identifies key of local provider, so that wrong keys are reported clearly
+	KeyID string `json:"keyID,omitempty"`
+	// DataKey is the data key encrypted by the provider
+	DataKey []byte `json:"dataKey"`
+	// Data is JSON with the object field of the definition, e.g. {"secret": {...}}, encrypted with the
+	// data key by AES-GCM along with namespace, name and meta of the definition as associated data. It
+	// starts with the nonce
+	Data []byte `json:"data"`
+}
+
+// KeyProvider encrypts and decrypts data keys of encrypted definitions
+type KeyProvider interface {
+	// Name is a name of the provider stored in encrypted definitions
+	Name() string
+	// KeyID identifies the key of the provider, it is empty if the key is identified by encrypted data
+	KeyID() string
+	Encrypt(dataKey []byte) ([]byte, error)
+	Decrypt(encrypted []byte) ([]byte, error)
+}
+
+// localKeyProvider encrypts data keys with AES-256 key stored in a file
+type localKeyProvider struct {
+	key []byte
+}
+
+// NewLocalKeyProvider returns key provider using 32 byte key read from the file, either raw or base64
+// encoded
+func NewLocalKeyProvider(path string) (KeyProvider, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key := data
+	if len(key) != dataKeySize {
+		if key, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(data))); err != nil || len(key) != dataKeySize {
+			return nil, fmt.Errorf("Encryption key in %s must be %d bytes, raw or base64 encoded", path, dataKeySize)
+		}
+	}
+	return localKeyProvider{key: key}, nil
+}
+
+func (p localKeyProvider) Name() string {
+	return LocalKeyProvider
+}
+
+func (p localKeyProvider) KeyID() string {
+	sum := sha256.Sum256(p.key)
+	return hex.EncodeToString(sum[:8])
+}
+
+func (p localKeyProvider) Encrypt(dataKey []byte) ([]byte, error) {
+	return seal(p.key, dataKey, nil)
+}
+
+func (p localKeyProvider) Decrypt(encrypted []byte) ([]byte, error) {
+	return open(p.key, encrypted, nil)
+}
+
+// kmsKeyProvider encrypts data keys by external command talking to key management service
+type kmsKeyProvider struct {
+	command []string
+}
+
+// NewKMSKeyProvider returns key provider running the command with encrypt or decrypt argument appended.
+// The command reads base64 encoded data from stdin and prints base64 encoded result to stdout
+func NewKMSKeyProvider(command []string) (KeyProvider, error) {
+	if len(command) == 0 {
+		return nil, errors.New("KMS command is empty")
+	}
+	return kmsKeyProvider{command: command}, nil
+}
+
+func (p kmsKeyProvider) Name() string {
+	return KMSKeyProvider
+}
+
+func (p kmsKeyProvider) KeyID() string {
+	return ""
+}
+
+func (p kmsKeyProvider) Encrypt(dataKey []byte) ([]byte, error) {
+	return p.run("encrypt", dataKey)
+}
+
+func (p kmsKeyProvider) Decrypt(encrypted []byte) ([]byte, error) {
+	return p.run("decrypt", encrypted)
+}
+
+func (p kmsKeyProvider) run(operation string, data []byte) ([]byte, error) {
+	cmd := exec.Command(p.command[0], append(p.command[1:], operation)...)
+	cmd.Stdin = strings.NewReader(base64.StdEncoding.EncodeToString(data) + "\n")
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("KMS command %s failed to %s data key: %v", p.command[0], operation, err)
+	}
+	result, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(output)))
+	if err != nil {
+		return nil, fmt.Errorf("KMS command %s returned invalid base64: %v", p.command[0], err)
+	}
+	return result, nil
+}
+
+// seal encrypts data with AES-GCM, authenticating associated data with it, and prepends random nonce to
+// the result
+func seal(key, data, associated []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, associated), nil
+}
+
+// open decrypts data encrypted by seal with the same associated data
+func open(key, data, associated []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("Encrypted data is too short")
+	}
+	nonce := data[:gcm.NonceSize()]
+	return gcm.Open(nil, nonce, data[gcm.NonceSize():], associated)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// associatedData returns data which is not encrypted, but is authenticated with the encrypted object of
+// the definition: its namespace, name and meta. The object can not be moved to another definition and meta
+// can not be changed without the key
+func associatedData(r *ResourceDefinition) ([]byte, error) {
+	meta := r.Meta
+	if len(meta) == 0 {
+		meta = nil
+	}
+	return json.Marshal(struct {
+		Namespace string                 `json:"namespace"`
+		Name      string                 `json:"name"`
+		Meta      map[string]interface{} `json:"meta"`
+	}{r.Namespace, r.Name, meta})
+}
+
+// definitionEnvelopeFields are fields of resource definition which are not encrypted
+var definitionEnvelopeFields = map[string]bool{"apiVersion": true, "kind": true, "metadata": true, "meta": true, "encrypted": true}
+
+// EncryptDefinition replaces object of resource definition by its encrypted form. Metadata and meta of the
+// definition are left as they are, so that the graph can be built and validated without the key. Namespace,
+// name and meta are authenticated with the object, so the namespace must be set to the one the definition
+// is created in
+func EncryptDefinition(r *ResourceDefinition, provider KeyProvider) error {
+	if r.Encrypted != nil {
+		return fmt.Errorf("Resource definition %s is already encrypted", r.Name)
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	object := map[string]json.RawMessage{}
+	for field, value := range fields {
+		if !definitionEnvelopeFields[field] && !bytes.Equal(value, []byte("null")) {
+			object[field] = value
+		}
+	}
+	if len(object) == 0 {
+		return fmt.Errorf("Resource definition %s does not contain an object to encrypt", r.Name)
+	}
+	plaintext, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+
+	associated, err := associatedData(r)
+	if err != nil {
+		return err
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err = io.ReadFull(rand.Reader, dataKey); err != nil {
+		return err
+	}
+	encryptedData, err := seal(dataKey, plaintext, associated)
+	if err != nil {
+		return err
+	}
+	encryptedKey, err := provider.Encrypt(dataKey)
+	if err != nil {
+		return err
+	}
+	*r = ResourceDefinition{
+		TypeMeta:   r.TypeMeta,
+		ObjectMeta: r.ObjectMeta,
+		Meta:       r.Meta,
+		Encrypted: &EncryptedObject{
+			Provider: provider.Name(),
+			KeyID:    provider.KeyID(),
+			DataKey:  encryptedKey,
+			Data:     encryptedData,
+		},
+	}
+	return nil
+}
+
+// DecryptDefinition replaces encrypted object of resource definition by the object itself, using key
+// provider with the name stored in the definition
+func DecryptDefinition(r *ResourceDefinition, providers map[string]KeyProvider) error {
+	encrypted := r.Encrypted
+	if encrypted == nil {
+		return nil
+	}
+	provider, ok := providers[encrypted.Provider]
+	if !ok {
+		return fmt.Errorf("Resource definition %s is encrypted by %s key provider, which is not configured", r.Name, encrypted.Provider)
+	}
+	if encrypted.KeyID != provider.KeyID() {
+		return fmt.Errorf("Resource definition %s is encrypted with key %s, not with the configured key %s", r.Name, encrypted.KeyID, provider.KeyID())
+	}
+	dataKey, err := provider.Decrypt(encrypted.DataKey)
+	if err != nil {
+		return fmt.Errorf("Could not decrypt data key of resource definition %s: %v", r.Name, err)
+	}
+	associated, err := associatedData(r)
+	if err != nil {
+		return err
+	}
+	plaintext, err := open(dataKey, encrypted.Data, associated)
+	if err != nil {
+		return fmt.Errorf("Could not decrypt resource definition %s: %v", r.Name, err)
+	}
+	if err = json.Unmarshal(plaintext, r); err != nil {
+		return fmt.Errorf("Invalid decrypted object of resource definition %s: %v", r.Name, err)
+	}
+	r.Encrypted = nil
+	r.Decrypted = encrypted
+	return nil
+}
+
+// decryptingDefinitions decrypts listed resource definitions. Objects of decrypted definitions are
+// added to redactions of audit log, if it is set
+type decryptingDefinitions struct {
+	ResourceDefinitionsInterface
+	providers  map[string]KeyProvider
+	redactions *auditRedactions
+}
+
+func (c decryptingDefinitions) List(opts api.ListOptions) (*ResourceDefinitionList, error) {
+	list, err := c.ResourceDefinitionsInterface.List(opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		if err = DecryptDefinition(&list.Items[i], c.providers); err != nil {
+			return nil, err
+		}
+		if list.Items[i].Decrypted != nil && c.redactions != nil {
+			if err = c.redactions.addDefinition(&list.Items[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return list, nil
+}
+
+// WithDecryption makes encrypted resource definitions listed by the client decrypted by given key
+// providers. Definitions returned by Get are left encrypted
+func WithDecryption(c Interface, providers ...KeyProvider) error {
+	cl, ok := c.(*Client)
+	if !ok {
+		return errors.New("Client does not support decryption")
+	}
+	byName := make(map[string]KeyProvider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+	cl.ResDefs = decryptingDefinitions{ResourceDefinitionsInterface: cl.ResDefs, providers: byName, redactions: cl.auditRedactions}
+	return nil
+}
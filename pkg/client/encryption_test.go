@@ -0,0 +1,142 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// localKey writes base64 encoded key filled with given byte to a temporary file and returns provider using it
+func localKey(t *testing.T, b byte) KeyProvider {
+	file, err := ioutil.TempFile("", "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	key := []byte(strings.Repeat(string([]byte{b}), dataKeySize))
+	if _, err = file.WriteString(base64.StdEncoding.EncodeToString(key) + "\n"); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	provider, err := NewLocalKeyProvider(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func secretDefinition() ResourceDefinition {
+	r := ResourceDefinition{
+		Meta:   map[string]interface{}{"namespace": "storage"},
+		Secret: &v1.Secret{Data: map[string][]byte{"password": []byte("hunter2")}},
+	}
+	r.Name = "secret-db"
+	r.Namespace = "testing"
+	r.Secret.Name = "db"
+	return r
+}
+
+// TestEncryptDefinition checks that only the object of encrypted definition is hidden and that it is
+// restored by decryption with the same key
+func TestEncryptDefinition(t *testing.T) {
+	provider := localKey(t, 1)
+	r := secretDefinition()
+	if err := EncryptDefinition(&r, provider); err != nil {
+		t.Fatal(err)
+	}
+	if r.Secret != nil || r.Encrypted == nil || r.Name != "secret-db" || r.Meta["namespace"] != "storage" {
+		t.Fatalf("Unexpected encrypted definition %+v", r)
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), base64.StdEncoding.EncodeToString([]byte("hunter2"))) {
+		t.Errorf("Encrypted definition contains secret data: %s", data)
+	}
+
+	if err = DecryptDefinition(&r, map[string]KeyProvider{LocalKeyProvider: localKey(t, 2)}); err == nil {
+		t.Error("Decryption with another key should fail")
+	}
+	if err = DecryptDefinition(&r, map[string]KeyProvider{LocalKeyProvider: provider}); err != nil {
+		t.Fatal(err)
+	}
+	if r.Encrypted != nil || r.Secret == nil || string(r.Secret.Data["password"]) != "hunter2" || r.Secret.Name != "db" {
+		t.Errorf("Unexpected decrypted definition %+v", r)
+	}
+}
+
+// TestEncryptedDefinitionAssociatedData checks that encrypted object is not decrypted in a definition with
+// another namespace, name or meta
+func TestEncryptedDefinitionAssociatedData(t *testing.T) {
+	provider := localKey(t, 1)
+	providers := map[string]KeyProvider{LocalKeyProvider: provider}
+	for _, change := range []func(r *ResourceDefinition){
+		func(r *ResourceDefinition) { r.Namespace = "other" },
+		func(r *ResourceDefinition) { r.Name = "secret-other" },
+		func(r *ResourceDefinition) { r.Meta = map[string]interface{}{"namespace": "other"} },
+		func(r *ResourceDefinition) { r.Meta = nil },
+	} {
+		r := secretDefinition()
+		if err := EncryptDefinition(&r, provider); err != nil {
+			t.Fatal(err)
+		}
+		change(&r)
+		if err := DecryptDefinition(&r, providers); err == nil {
+			t.Errorf("Decryption of changed definition %s/%s with meta %v should fail", r.Namespace, r.Name, r.Meta)
+		}
+	}
+
+	r := secretDefinition()
+	r.Meta = map[string]interface{}{}
+	if err := EncryptDefinition(&r, provider); err != nil {
+		t.Fatal(err)
+	}
+	r.Meta = nil
+	if err := DecryptDefinition(&r, providers); err != nil {
+		t.Errorf("Empty meta should be the same as no meta, got %v", err)
+	}
+}
+
+// TestKMSKeyProvider checks that data keys are encrypted by the command, which echoes them in this test
+func TestKMSKeyProvider(t *testing.T) {
+	provider, err := NewKMSKeyProvider([]string{"sh", "-c", "cat", "kms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := secretDefinition()
+	if err = EncryptDefinition(&r, provider); err != nil {
+		t.Fatal(err)
+	}
+	if r.Encrypted.Provider != KMSKeyProvider {
+		t.Errorf("Unexpected provider %s", r.Encrypted.Provider)
+	}
+	if err = DecryptDefinition(&r, map[string]KeyProvider{LocalKeyProvider: localKey(t, 1)}); err == nil {
+		t.Error("Decryption without KMS provider should fail")
+	}
+	if err = DecryptDefinition(&r, map[string]KeyProvider{KMSKeyProvider: provider}); err != nil {
+		t.Fatal(err)
+	}
+	if r.Secret == nil || string(r.Secret.Data["password"]) != "hunter2" {
+		t.Errorf("Unexpected decrypted definition %+v", r)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/websocket"
+	"k8s.io/client-go/rest"
+)
+
+// execChannelProtocol is a websocket subprotocol of pod exec, every message starts with the number of
+// the stream it belongs to
+const execChannelProtocol = "channel.k8s.io"
+
+// exec stream numbers of channel.k8s.io protocol
+const (
+	execStdout = 1
+	execStderr = 2
+	execError  = 3
+)
+
+// PodExecutor runs commands in containers of pods
+type PodExecutor interface {
+	// Exec runs the command in the container of the pod and returns its output. Error is returned if the
+	// command could not be run or exited with non-zero code
+	Exec(ctx context.Context, namespace, pod, container string, command []string) (string, error)
+}
+
+// ErrExecNotSupported is returned by clients which can not run commands in pods
+var ErrExecNotSupported = errors.New("running commands in pods is not supported by the client")
+
+// Exec runs the command in the container of the pod in the namespace of the client
+func (c Client) Exec(ctx context.Context, pod, container string, command []string) (string, error) {
+	if c.Executor == nil {
+		return "", ErrExecNotSupported
+	}
+	return c.Executor.Exec(ctx, c.Namespace, pod, container, command)
+}
+
+// websocketExecutor runs commands through exec subresource of pods streamed over websocket, as SPDY
+// streaming is not available in the vendored client-go
+type websocketExecutor struct {
+	config *rest.Config
+}
+
+// Exec runs the command and collects its output until the API server closes the stream
+func (e websocketExecutor) Exec(ctx context.Context, namespace, pod, container string, command []string) (string, error) {
+	config, err := e.websocketConfig(namespace, pod, container, command)
+	if err != nil {
+		return "", err
+	}
+	conn, err := e.dial(ctx, config)
+	if err != nil {
+		return "", err
+	}
+	ws, err := websocket.NewClient(config, conn)
+	if err != nil {
+		conn.Close()
+		return "", err
+	}
+	defer ws.Close()
+	// the stream is closed when the context is done, so that reading it does not block
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			ws.Close()
+		case <-done:
+		}
+	}()
+
+	var stdout, stderr, errOut bytes.Buffer
+	for {
+		var message []byte
+		if err := websocket.Message.Receive(ws, &message); err != nil {
+			if err == io.EOF {
+				break
+			}
+			if ctx.Err() != nil {
+				return stdout.String(), ctx.Err()
+			}
+			return stdout.String(), err
+		}
+		if len(message) == 0 {
+			continue
+		}
+		switch message[0] {
+		case execStdout:
+			stdout.Write(message[1:])
+		case execStderr:
+			stderr.Write(message[1:])
+		case execError:
+			errOut.Write(message[1:])
+		}
+	}
+	if errOut.Len() > 0 {
+		if stderr.Len() > 0 {
+			return stdout.String(), fmt.Errorf("%s: %s", strings.TrimSpace(errOut.String()), strings.TrimSpace(stderr.String()))
+		}
+		return stdout.String(), errors.New(strings.TrimSpace(errOut.String()))
+	}
+	return stdout.String(), nil
+}
+
+// websocketConfig returns websocket config of exec request authenticated like other requests of the client
+func (e websocketExecutor) websocketConfig(namespace, pod, container string, command []string) (*websocket.Config, error) {
+	host := e.config.Host
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	base, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+	origin := *base
+	location := *base
+	location.Scheme = "ws"
+	if base.Scheme == "https" {
+		location.Scheme = "wss"
+	}
+	location.Path = strings.TrimRight(base.Path, "/") + fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/exec", namespace, pod)
+	query := url.Values{"stdout": {"1"}, "stderr": {"1"}, "command": command}
+	if container != "" {
+		query.Set("container", container)
+	}
+	location.RawQuery = query.Encode()
+
+	config, err := websocket.NewConfig(location.String(), origin.String())
+	if err != nil {
+		return nil, err
+	}
+	config.Protocol = []string{execChannelProtocol}
+	if base.Scheme == "https" {
+		if config.TlsConfig, err = rest.TLSConfigFor(e.config); err != nil {
+			return nil, err
+		}
+		if config.TlsConfig == nil {
+			config.TlsConfig = &tls.Config{}
+		}
+		if config.TlsConfig.ServerName == "" {
+			config.TlsConfig.ServerName = strings.Split(base.Host, ":")[0]
+		}
+	}
+	if e.config.BearerToken != "" {
+		config.Header.Set("Authorization", "Bearer "+e.config.BearerToken)
+	} else if e.config.Username != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(e.config.Username + ":" + e.config.Password))
+		config.Header.Set("Authorization", "Basic "+credentials)
+	}
+	return config, nil
+}
+
+// dial opens connection to the API server, within deadline of the context if it has one
+func (e websocketExecutor) dial(ctx context.Context, config *websocket.Config) (net.Conn, error) {
+	address := config.Location.Host
+	if !strings.Contains(address, ":") {
+		address += map[string]string{"ws": ":80", "wss": ":443"}[config.Location.Scheme]
+	}
+	dialer := &net.Dialer{}
+	if deadline, ok := ctx.Deadline(); ok {
+		dialer.Deadline = deadline
+	}
+	conn, err := dialer.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if config.Location.Scheme == "wss" {
+		tlsConn := tls.Client(conn, config.TlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	return conn, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client/petsets/typed/apps/v1alpha1"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// serviceAccountUser returns user name and groups service account with given name authenticates as.
+// Service account must belong to given namespace, either by its name or as <namespace>/<name>, so that
+// objects of one namespace cannot be managed with permissions of service accounts of another one
+func serviceAccountUser(namespace, serviceAccount string) (string, []string, error) {
+	name := serviceAccount
+	if i := strings.Index(serviceAccount, "/"); i >= 0 {
+		if serviceAccount[:i] != namespace {
+			return "", nil, fmt.Errorf("Service account %q does not belong to namespace %s", serviceAccount, namespace)
+		}
+		name = serviceAccount[i+1:]
+	}
+	if namespace == "" || name == "" || strings.Contains(name, "/") {
+		return "", nil, fmt.Errorf("Invalid service account %q, expected <name> or <namespace>/<name>", serviceAccount)
+	}
+	user := fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name)
+	return user, []string{"system:serviceaccounts", "system:serviceaccounts:" + namespace, "system:authenticated"}, nil
+}
+
+// Impersonate returns copy of the client whose requests are made on behalf of the service account, so
+// that they are authorized with its permissions. Service account is the name of service account in the
+// namespace of the client, or <namespace>/<name> with the same namespace. AppController dependencies and resource
+// definitions are still read by the client itself
+func (c Client) Impersonate(serviceAccount string) (Interface, error) {
+	if c.config == nil {
+		return nil, errors.New("Impersonation is not supported by the client")
+	}
+	user, groups, err := serviceAccountUser(c.Namespace, serviceAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	config := *c.config
+	wrap := config.WrapTransport
+	config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		if wrap != nil {
+			rt = wrap(rt)
+		}
+		return &impersonatingRoundTripper{user: user, groups: groups, base: rt}
+	}
+	cl, err := kubernetes.NewForConfig(builtinKindsConfig(config))
+	if err != nil {
+		return nil, err
+	}
+	apps, err := v1alpha1.NewForConfig(&config)
+	if err != nil {
+		return nil, err
+	}
+	c.Clientset = cl
+	c.AlphaApps = apps
+	c.Executor = websocketExecutor{config: &config}
+	c.LogReader = restLogReader{clientset: cl}
+	c.config = &config
+	return &c, nil
+}
+
+// impersonatingRoundTripper sends requests on behalf of the user with given groups
+type impersonatingRoundTripper struct {
+	user   string
+	groups []string
+	base   http.RoundTripper
+}
+
+// RoundTrip sends request with impersonation headers
+func (rt *impersonatingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// requests must not be modified by round trippers, so headers are set on a copy
+	impersonatedReq := new(http.Request)
+	*impersonatedReq = *req
+	impersonatedReq.Header = make(http.Header, len(req.Header)+2)
+	for key, values := range req.Header {
+		impersonatedReq.Header[key] = append([]string(nil), values...)
+	}
+	impersonatedReq.Header.Set("Impersonate-User", rt.user)
+	impersonatedReq.Header.Del("Impersonate-Group")
+	for _, group := range rt.groups {
+		impersonatedReq.Header.Add("Impersonate-Group", group)
+	}
+	return rt.base.RoundTrip(impersonatedReq)
+}
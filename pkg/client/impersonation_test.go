@@ -0,0 +1,86 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// TestServiceAccountUser checks user names of service accounts with and without namespace
+func TestServiceAccountUser(t *testing.T) {
+	user, groups, err := serviceAccountUser("testing", "deployer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user != "system:serviceaccount:testing:deployer" {
+		t.Errorf("Unexpected user %s", user)
+	}
+	if groups[1] != "system:serviceaccounts:testing" {
+		t.Errorf("Unexpected groups %v", groups)
+	}
+
+	if user, _, err = serviceAccountUser("testing", "testing/deployer"); err != nil || user != "system:serviceaccount:testing:deployer" {
+		t.Errorf("Unexpected user %s, error %v", user, err)
+	}
+	for _, serviceAccount := range []string{"team-a/deployer", "kube-system/default", "testing/", "/deployer", "testing/b/c"} {
+		if _, _, err = serviceAccountUser("testing", serviceAccount); err == nil {
+			t.Errorf("Service account %s should be invalid", serviceAccount)
+		}
+	}
+}
+
+// TestImpersonate checks that requests of impersonating client carry impersonation headers and
+// requests of the original client do not
+func TestImpersonate(t *testing.T) {
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind": "Pod", "apiVersion": "v1", "metadata": {"name": "pod1"}}`))
+	}))
+	defer server.Close()
+
+	config := &rest.Config{Host: server.URL}
+	cl, err := kubernetes.NewForConfig(builtinKindsConfig(*config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Client{Clientset: cl, Namespace: "testing", config: config}
+	impersonated, err := c.Impersonate("deployer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	impersonated.Pods().Get("pod1")
+	c.Pods().Get("pod1")
+	if len(headers) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(headers))
+	}
+	if user := headers[0].Get("Impersonate-User"); user != "system:serviceaccount:testing:deployer" {
+		t.Errorf("Unexpected impersonated user %q", user)
+	}
+	expected := []string{"system:serviceaccounts", "system:serviceaccounts:testing", "system:authenticated"}
+	if groups := headers[0]["Impersonate-Group"]; !reflect.DeepEqual(groups, expected) {
+		t.Errorf("Unexpected impersonated groups %v", groups)
+	}
+	if user := headers[1].Get("Impersonate-User"); user != "" {
+		t.Errorf("Original client must not impersonate, got user %q", user)
+	}
+}
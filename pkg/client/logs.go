@@ -0,0 +1,66 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"errors"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// PodLogReader reads logs of containers of pods
+type PodLogReader interface {
+	// Logs returns last tailLines lines of logs of the container of the pod
+	Logs(ctx context.Context, namespace, pod, container string, tailLines int64) (string, error)
+}
+
+// ErrLogsNotSupported is returned by clients which can not read logs of pods
+var ErrLogsNotSupported = errors.New("reading logs of pods is not supported by the client")
+
+// Logs returns last tailLines lines of logs of the container of the pod in the namespace of the client
+func (c Client) Logs(ctx context.Context, pod, container string, tailLines int64) (string, error) {
+	if c.LogReader == nil {
+		return "", ErrLogsNotSupported
+	}
+	return c.LogReader.Logs(ctx, c.Namespace, pod, container, tailLines)
+}
+
+// restLogReader reads logs through log subresource of pods
+type restLogReader struct {
+	clientset kubernetes.Interface
+}
+
+type logsResult struct {
+	logs []byte
+	err  error
+}
+
+// Logs requests the logs, request is abandoned when the context is done
+func (r restLogReader) Logs(ctx context.Context, namespace, pod, container string, tailLines int64) (string, error) {
+	options := &v1.PodLogOptions{Container: container, TailLines: &tailLines}
+	done := make(chan logsResult, 1)
+	go func() {
+		logs, err := r.clientset.Core().Pods(namespace).GetLogs(pod, options).Do().Raw()
+		done <- logsResult{logs, err}
+	}()
+	select {
+	case result := <-done:
+		return string(result.logs), result.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
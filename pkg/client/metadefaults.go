@@ -0,0 +1,159 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/util/yaml"
+)
+
+// Keys of config map data holding meta defaults of resource definitions and of dependencies
+const (
+	ResourceMetaDefaultsKey   = "resources"
+	DependencyMetaDefaultsKey = "dependencies"
+)
+
+// MetaDefaults are meta values set once for the whole graph. They are used by every resource definition
+// and dependency which does not set the key in its own meta
+type MetaDefaults struct {
+	Resources    map[string]interface{}
+	Dependencies map[string]string
+}
+
+// LoadMetaDefaults returns meta defaults stored in config map with given name in AppController namespace.
+// Its "resources" and "dependencies" keys hold YAML or JSON maps of meta keys to their default values
+func LoadMetaDefaults(c Interface, configMap string) (MetaDefaults, error) {
+	cm, err := c.ConfigMaps().Get(configMap)
+	if err != nil {
+		return MetaDefaults{}, err
+	}
+	return ParseMetaDefaults(cm.Data[ResourceMetaDefaultsKey], cm.Data[DependencyMetaDefaultsKey])
+}
+
+// ParseMetaDefaults parses YAML or JSON maps of default meta of resource definitions and of dependencies.
+// Meta of dependencies is a map of strings, so other values are converted to strings
+func ParseMetaDefaults(resources, dependencies string) (MetaDefaults, error) {
+	defaults := MetaDefaults{}
+	var err error
+	if defaults.Resources, err = decodeMeta(resources); err != nil {
+		return defaults, fmt.Errorf("Invalid default meta of resource definitions: %v", err)
+	}
+	depMeta, err := decodeMeta(dependencies)
+	if err != nil {
+		return defaults, fmt.Errorf("Invalid default meta of dependencies: %v", err)
+	}
+	defaults.Dependencies = map[string]string{}
+	for key, value := range depMeta {
+		if s, ok := value.(string); ok {
+			defaults.Dependencies[key] = s
+			continue
+		}
+		defaults.Dependencies[key] = fmt.Sprint(value)
+	}
+	return defaults, nil
+}
+
+func decodeMeta(data string) (map[string]interface{}, error) {
+	meta := map[string]interface{}{}
+	if strings.TrimSpace(data) == "" {
+		return meta, nil
+	}
+	if err := yaml.NewYAMLOrJSONDecoder(strings.NewReader(data), 4096).Decode(&meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// applyToDefinition sets default meta keys which are not set by the resource definition. Meta is
+// copied, as listed definitions may share it with the client
+func (d MetaDefaults) applyToDefinition(r *ResourceDefinition) {
+	if len(d.Resources) == 0 {
+		return
+	}
+	meta := map[string]interface{}{}
+	for key, value := range d.Resources {
+		meta[key] = value
+	}
+	for key, value := range r.Meta {
+		meta[key] = value
+	}
+	r.Meta = meta
+}
+
+// applyToDependency sets default meta keys which are not set by the dependency
+func (d MetaDefaults) applyToDependency(dep *Dependency) {
+	if len(d.Dependencies) == 0 {
+		return
+	}
+	meta := map[string]string{}
+	for key, value := range d.Dependencies {
+		meta[key] = value
+	}
+	for key, value := range dep.Meta {
+		meta[key] = value
+	}
+	dep.Meta = meta
+}
+
+// metaDefaultsDefinitions applies meta defaults to listed resource definitions
+type metaDefaultsDefinitions struct {
+	ResourceDefinitionsInterface
+	defaults MetaDefaults
+}
+
+func (c metaDefaultsDefinitions) List(opts api.ListOptions) (*ResourceDefinitionList, error) {
+	list, err := c.ResourceDefinitionsInterface.List(opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		c.defaults.applyToDefinition(&list.Items[i])
+	}
+	return list, nil
+}
+
+// metaDefaultsDependencies applies meta defaults to listed dependencies
+type metaDefaultsDependencies struct {
+	DependenciesInterface
+	defaults MetaDefaults
+}
+
+func (c metaDefaultsDependencies) List(opts api.ListOptions) (*DependencyList, error) {
+	list, err := c.DependenciesInterface.List(opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		c.defaults.applyToDependency(&list.Items[i])
+	}
+	return list, nil
+}
+
+// WithMetaDefaults makes resource definitions and dependencies listed by the client use given meta
+// defaults for keys they do not set. It must be called after WithParameters, as defaults are applied to
+// rendered definitions
+func WithMetaDefaults(c Interface, defaults MetaDefaults) error {
+	cl, ok := c.(*Client)
+	if !ok {
+		return errors.New("Client does not support meta defaults")
+	}
+	cl.ResDefs = metaDefaultsDefinitions{ResourceDefinitionsInterface: cl.ResDefs, defaults: defaults}
+	cl.Deps = metaDefaultsDependencies{DependenciesInterface: cl.Deps, defaults: defaults}
+	return nil
+}
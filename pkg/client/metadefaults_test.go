@@ -0,0 +1,82 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"testing"
+
+	"k8s.io/client-go/pkg/api"
+)
+
+// TestParseMetaDefaults checks that default meta keeps types of resource meta and converts dependency meta to strings
+func TestParseMetaDefaults(t *testing.T) {
+	defaults, err := ParseMetaDefaults("timeout: 60\non-failure: skip\n", `{"success_factor": 80, "on-timeout": "skip"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if defaults.Resources["timeout"] != float64(60) || defaults.Resources["on-failure"] != "skip" {
+		t.Errorf("Unexpected default meta of resource definitions %v", defaults.Resources)
+	}
+	if defaults.Dependencies["success_factor"] != "80" || defaults.Dependencies["on-timeout"] != "skip" {
+		t.Errorf("Unexpected default meta of dependencies %v", defaults.Dependencies)
+	}
+
+	if _, err = ParseMetaDefaults("- not a map", ""); err == nil {
+		t.Error("Error expected for default meta which is not a map")
+	}
+}
+
+// TestWithMetaDefaults checks that meta set by definitions and dependencies overrides defaults
+func TestWithMetaDefaults(t *testing.T) {
+	c := &Client{
+		ResDefs: &sourceDefinitions{items: []ResourceDefinition{
+			{Meta: map[string]interface{}{"timeout": float64(10)}},
+			{},
+		}},
+		Deps: &sourceDependencies{items: []Dependency{
+			{Parent: "pod/a", Child: "pod/b", Meta: map[string]string{"success_factor": "50"}},
+			{Parent: "pod/b", Child: "pod/c"},
+		}},
+	}
+	defaults := MetaDefaults{
+		Resources:    map[string]interface{}{"timeout": float64(60), "retry": float64(3)},
+		Dependencies: map[string]string{"success_factor": "80"},
+	}
+	if err := WithMetaDefaults(c, defaults); err != nil {
+		t.Fatal(err)
+	}
+
+	resDefs, err := c.ResourceDefinitions().List(api.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta := resDefs.Items[0].Meta; meta["timeout"] != float64(10) || meta["retry"] != float64(3) {
+		t.Errorf("Expected own timeout and default retry, got %v", meta)
+	}
+	if meta := resDefs.Items[1].Meta; meta["timeout"] != float64(60) || meta["retry"] != float64(3) {
+		t.Errorf("Expected default timeout and retry, got %v", meta)
+	}
+
+	deps, err := c.Dependencies().List(api.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if factor := deps.Items[0].Meta["success_factor"]; factor != "50" {
+		t.Errorf("Expected own success factor 50, got %s", factor)
+	}
+	if factor := deps.Items[1].Meta["success_factor"]; factor != "80" {
+		t.Errorf("Expected default success factor 80, got %s", factor)
+	}
+}
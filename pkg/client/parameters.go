@@ -0,0 +1,196 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Parameters are values substituted into Go template placeholders in resource definitions,
+// e.g. {{ .tag }} in image of a pod
+type Parameters map[string]string
+
+// ParseParameters parses parameters given as key=value pairs
+func ParseParameters(pairs []string) (Parameters, error) {
+	params := Parameters{}
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Parameter %q is not in key=value form", pair)
+		}
+		params[parts[0]] = parts[1]
+	}
+	return params, nil
+}
+
+// LoadParameters returns parameters stored in data of config map and secret with given names in
+// AppController namespace. Empty names are skipped, values from the secret override ones from the
+// config map
+func LoadParameters(c Interface, configMap, secret string) (Parameters, error) {
+	params := Parameters{}
+	if configMap != "" {
+		cm, err := c.ConfigMaps().Get(configMap)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range cm.Data {
+			params[key] = value
+		}
+	}
+	if secret != "" {
+		s, err := c.Secrets().Get(secret)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range s.Data {
+			params[key] = string(value)
+		}
+	}
+	return params, nil
+}
+
+// parameterizedDefinitions is implemented by resource definition clients which can render
+// definitions with parameters
+type parameterizedDefinitions interface {
+	WithParameters(params Parameters) ResourceDefinitionsInterface
+}
+
+// WithParameters makes resource definitions listed by the client rendered as Go templates with given
+// parameters. Listing definitions fails if any of their placeholders cannot be resolved
+func WithParameters(c Interface, params Parameters) error {
+	cl, ok := c.(*Client)
+	if !ok {
+		return errors.New("Client does not support parameters")
+	}
+	resDefs, ok := cl.ResDefs.(parameterizedDefinitions)
+	if !ok {
+		return errors.New("Resource definitions client does not support parameters")
+	}
+	cl.ResDefs = resDefs.WithParameters(params)
+	return nil
+}
+
+// typeFuncs are template functions which convert values to JSON numbers or booleans. If they are the
+// last command of the only placeholder of a string, the string is replaced by the converted value, so
+// that e.g. replica counts can be parameterized: "{{ .replicas | int }}"
+var typeFuncs = template.FuncMap{
+	"int": func(value interface{}) (int64, error) {
+		return strconv.ParseInt(fmt.Sprint(value), 10, 64)
+	},
+	"float": func(value interface{}) (float64, error) {
+		return strconv.ParseFloat(fmt.Sprint(value), 64)
+	},
+	"bool": func(value interface{}) (bool, error) {
+		return strconv.ParseBool(fmt.Sprint(value))
+	},
+}
+
+// renderDefinitions renders every string which contains placeholders in JSON list of resource
+// definitions as Go template with the parameters
+func renderDefinitions(data []byte, params Parameters) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var list map[string]interface{}
+	if err := decoder.Decode(&list); err != nil {
+		return nil, err
+	}
+	items, _ := list["items"].([]interface{})
+	for i, item := range items {
+		rendered, err := renderValue(item, params)
+		if err != nil {
+			name := ""
+			if obj, ok := item.(map[string]interface{}); ok {
+				metadata, _ := obj["metadata"].(map[string]interface{})
+				name, _ = metadata["name"].(string)
+			}
+			return nil, fmt.Errorf("Could not render resource definition %s: %v", name, err)
+		}
+		items[i] = rendered
+	}
+	return json.Marshal(list)
+}
+
+// renderValue renders strings with placeholders found in the value
+func renderValue(value interface{}, params Parameters) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			rendered, err := renderValue(item, params)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = rendered
+		}
+	case []interface{}:
+		for i, item := range v {
+			rendered, err := renderValue(item, params)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = rendered
+		}
+	case string:
+		return renderString(v, params)
+	}
+	return value, nil
+}
+
+// renderString renders the string as Go template if it has placeholders
+func renderString(s string, params Parameters) (interface{}, error) {
+	if !strings.Contains(s, "{{") {
+		return s, nil
+	}
+	tmpl, err := template.New("").Option("missingkey=error").Funcs(typeFuncs).Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	var result bytes.Buffer
+	if err = tmpl.Execute(&result, map[string]string(params)); err != nil {
+		return nil, err
+	}
+	rendered := result.String()
+
+	switch resultType(tmpl.Tree) {
+	case "int", "float":
+		return json.Number(rendered), nil
+	case "bool":
+		return strconv.ParseBool(rendered)
+	}
+	return rendered, nil
+}
+
+// resultType returns name of type function applied last in the template if the template consists of
+// a single placeholder, or empty string otherwise
+func resultType(tree *parse.Tree) string {
+	if tree == nil || len(tree.Root.Nodes) != 1 {
+		return ""
+	}
+	action, ok := tree.Root.Nodes[0].(*parse.ActionNode)
+	if !ok || len(action.Pipe.Cmds) == 0 {
+		return ""
+	}
+	cmd := action.Pipe.Cmds[len(action.Pipe.Cmds)-1]
+	if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok && typeFuncs[ident.Ident] != nil {
+		return ident.Ident
+	}
+	return ""
+}
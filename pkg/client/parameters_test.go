@@ -0,0 +1,122 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+const templatedDefinitions = `{"items": [
+	{
+		"metadata": {"name": "web"},
+		"deployment": {
+			"metadata": {"name": "web-{{ .env }}"},
+			"spec": {
+				"replicas": "{{ .replicas | int }}",
+				"paused": "{{ bool .paused }}",
+				"template": {"spec": {"containers": [{"name": "web", "image": "nginx:{{ .tag }}"}]}}
+			}
+		}
+	},
+	{
+		"metadata": {"name": "config"},
+		"configmap": {"metadata": {"name": "config"}, "data": {"port": "{{ .port }}", "plain": "value"}}
+	}
+]}`
+
+// TestRenderDefinitions checks that placeholders are replaced by parameters and converted to types
+// requested in templates
+func TestRenderDefinitions(t *testing.T) {
+	params := Parameters{"env": "prod", "replicas": "3", "paused": "true", "tag": "1.11", "port": "8080"}
+	data, err := renderDefinitions([]byte(templatedDefinitions), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := &ResourceDefinitionList{}
+	if err = json.Unmarshal(data, list); err != nil {
+		t.Fatal(err)
+	}
+
+	deployment := list.Items[0].Deployment
+	if deployment.Name != "web-prod" {
+		t.Errorf("Expected name web-prod, got %s", deployment.Name)
+	}
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != 3 {
+		t.Errorf("Expected 3 replicas, got %v", deployment.Spec.Replicas)
+	}
+	if !deployment.Spec.Paused {
+		t.Error("Expected deployment to be paused")
+	}
+	if image := deployment.Spec.Template.Spec.Containers[0].Image; image != "nginx:1.11" {
+		t.Errorf("Expected image nginx:1.11, got %s", image)
+	}
+	configMap := list.Items[1].ConfigMap
+	if configMap.Data["port"] != "8080" || configMap.Data["plain"] != "value" {
+		t.Errorf("Expected config map data to stay strings, got %v", configMap.Data)
+	}
+}
+
+// TestRenderDefinitionsMissingParameter checks that unresolved placeholders are reported with the definition
+func TestRenderDefinitionsMissingParameter(t *testing.T) {
+	_, err := renderDefinitions([]byte(templatedDefinitions), Parameters{"env": "prod"})
+	if err == nil {
+		t.Fatal("Error expected for missing parameters")
+	}
+	if !strings.Contains(err.Error(), "web") {
+		t.Errorf("Expected error to name the definition, got %v", err)
+	}
+
+	params := Parameters{"env": "prod", "replicas": "three", "paused": "true", "tag": "1.11", "port": "8080"}
+	if _, err = renderDefinitions([]byte(templatedDefinitions), params); err == nil {
+		t.Error("Error expected for parameter which is not a number")
+	}
+}
+
+// TestParseParameters checks parsing of key=value pairs
+func TestParseParameters(t *testing.T) {
+	params, err := ParseParameters([]string{"tag=1.11", "url=http://host/?a=b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params["tag"] != "1.11" || params["url"] != "http://host/?a=b" {
+		t.Errorf("Unexpected parameters %v", params)
+	}
+	if _, err = ParseParameters([]string{"tag"}); err == nil {
+		t.Error("Error expected for parameter without value")
+	}
+}
+
+// TestLoadParameters checks that secret values override config map ones
+func TestLoadParameters(t *testing.T) {
+	c := &Client{
+		Clientset: fake.NewSimpleClientset(
+			&v1.ConfigMap{ObjectMeta: v1.ObjectMeta{Name: "params", Namespace: "testing"}, Data: map[string]string{"tag": "1.11", "password": "none"}},
+			&v1.Secret{ObjectMeta: v1.ObjectMeta{Name: "params", Namespace: "testing"}, Data: map[string][]byte{"password": []byte("secret")}},
+		),
+		Namespace: "testing",
+	}
+	params, err := LoadParameters(c, "params", "params")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params["tag"] != "1.11" || params["password"] != "secret" {
+		t.Errorf("Unexpected parameters %v", params)
+	}
+}
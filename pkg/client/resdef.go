@@ -17,6 +17,8 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client/petsets/apis/apps/v1alpha1"
 
@@ -27,6 +29,8 @@ import (
 	appsbeta1 "k8s.io/client-go/pkg/apis/apps/v1beta1"
 	batchv1 "k8s.io/client-go/pkg/apis/batch/v1"
 	"k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	rbacalpha1 "k8s.io/client-go/pkg/apis/rbac/v1alpha1"
+	storagebeta1 "k8s.io/client-go/pkg/apis/storage/v1beta1"
 	"k8s.io/client-go/rest"
 )
 
@@ -51,6 +55,162 @@ type ResourceDefinition struct {
 	Secret                *v1.Secret                `json:"secret,omitempty"`
 	Deployment            *v1beta1.Deployment       `json:"deployment, omitempty"`
 	PersistentVolumeClaim *v1.PersistentVolumeClaim `json:"persistentvolumeclaim, omitempty"`
+	ExternalCheck         *ExternalCheck            `json:"externalcheck,omitempty"`
+	ExecCheck             *ExecCheck                `json:"execcheck,omitempty"`
+	Handler               *Handler                  `json:"handler,omitempty"`
+	Checkpoint            *Checkpoint               `json:"checkpoint,omitempty"`
+	ImageCheck            *ImageCheck               `json:"imagecheck,omitempty"`
+	Scale                 *Scale                    `json:"scale,omitempty"`
+	Patch                 *Patch                    `json:"patch,omitempty"`
+	// cluster-scoped objects, namespace meta of their definitions is ignored. NamespaceObject is named
+	// so to not shadow namespace of the definition itself
+	NamespaceObject  *v1.Namespace              `json:"namespace,omitempty"`
+	PersistentVolume *v1.PersistentVolume       `json:"persistentvolume,omitempty"`
+	StorageClass     *storagebeta1.StorageClass `json:"storageclass,omitempty"`
+	ClusterRole      *rbacalpha1.ClusterRole    `json:"clusterrole,omitempty"`
+	// Object is an object of a kind registered by downstream code or plugins, see resources.Register
+	Object *Object `json:"object,omitempty"`
+	// Encrypted is an encrypted object of any of the kinds above, see EncryptDefinition
+	Encrypted *EncryptedObject `json:"encrypted,omitempty"`
+	// Decrypted is the encrypted object the definition was decrypted from. It is never serialized, so that
+	// copies of the definition kept by AppController, e.g. revisions, can stay encrypted
+	Decrypted *EncryptedObject `json:"-"`
+}
+
+// ExternalCheck describes a check of an endpoint outside of the cluster. Exactly one of URL
+// (HTTP GET check) and Address (TCP connect check) should be set
+type ExternalCheck struct {
+	Name string `json:"name"`
+
+	// URL is requested with HTTP GET, the check passes if response has ExpectedStatus
+	// (200 if not set) and its body matches BodyRegex, if set
+	URL            string `json:"url,omitempty"`
+	ExpectedStatus int    `json:"expectedStatus,omitempty"`
+	BodyRegex      string `json:"bodyRegex,omitempty"`
+
+	// Address in host:port form, the check passes if TCP connection can be established
+	Address string `json:"address,omitempty"`
+
+	// TimeoutSeconds is a timeout of a single check, 5 seconds if not set
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+// ExecCheck describes a check run by a binary or script available to AppController, e.g. mounted into
+// its pod. The check passes if the command exits with zero code
+type ExecCheck struct {
+	Name string `json:"name"`
+
+	// Command is the path of the executable followed by its arguments
+	Command []string `json:"command"`
+
+	// TimeoutSeconds is a timeout of a single run of the command, 30 seconds if not set
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+// Handler describes a resource managed by an external service implementing ResourceHandler gRPC
+// protocol, see pkg/handler/handler.proto
+type Handler struct {
+	Name string `json:"name"`
+
+	// Endpoint is host:port of the gRPC server, e.g. db-handler.default:9000
+	Endpoint string `json:"endpoint"`
+	// Insecure makes the connection use no TLS. Otherwise TLS verified with system root certificates is used
+	Insecure bool `json:"insecure,omitempty"`
+
+	// Parameters are passed to the handler as JSON object
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+
+	// TimeoutSeconds is a timeout of a single call, 10 seconds if not set
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+// Checkpoint describes a manual gate in the graph. Its dependents are not created until an operator
+// approves the checkpoint
+type Checkpoint struct {
+	Name string `json:"name"`
+
+	// Message is shown to operators while the checkpoint waits for approval
+	Message string `json:"message,omitempty"`
+}
+
+// ImageCheck describes a pre-flight check that container images exist in their registries. Images of
+// resources depending on the check, directly or through other resources, are checked along with Images
+type ImageCheck struct {
+	Name string `json:"name"`
+
+	// Images are checked in addition to images of dependent resources
+	Images []string `json:"images,omitempty"`
+	// ImagePullSecrets are names of secrets with registry credentials for Images
+	ImagePullSecrets []string `json:"imagePullSecrets,omitempty"`
+
+	// TimeoutSeconds is a timeout of a single request to registry, 10 seconds if not set
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+// Scale describes an action setting number of replicas of an existing Deployment, ReplicaSet or
+// StatefulSet. It is ready when the new number of replicas is ready
+type Scale struct {
+	Name string `json:"name"`
+
+	// Target is a key of the scaled object, e.g. deployment/web
+	Target   string `json:"target"`
+	Replicas int32  `json:"replicas"`
+}
+
+// Patch describes an action applying a patch to an existing object. It is ready when the patched
+// fields are observed in the object
+type Patch struct {
+	Name string `json:"name"`
+
+	// Target is a key of the patched object, e.g. service/web
+	Target string `json:"target"`
+	// Type is one of strategic (default), merge or json
+	Type string `json:"type,omitempty"`
+	// Patch is a YAML or JSON document of the patch
+	Patch string `json:"patch"`
+}
+
+// Object is a serialized K8s object of a kind which has no dedicated field in ResourceDefinition. Its
+// kind and name are read from the object itself, the kind is lowercased as in resource keys
+type Object struct {
+	Kind string
+	Name string
+	Raw  json.RawMessage
+}
+
+type objectHeader struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+}
+
+// UnmarshalJSON keeps serialized object and reads its kind and name
+func (o *Object) UnmarshalJSON(data []byte) error {
+	var header objectHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return err
+	}
+	if header.Kind == "" {
+		return fmt.Errorf("object %s has no kind", header.Metadata.Name)
+	}
+	o.Kind = strings.ToLower(header.Kind)
+	o.Name = header.Metadata.Name
+	o.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// MarshalJSON returns serialized object as it was read
+func (o Object) MarshalJSON() ([]byte, error) {
+	if o.Raw == nil {
+		return []byte("null"), nil
+	}
+	return o.Raw, nil
+}
+
+// Decode unmarshals serialized object into given typed object
+func (o Object) Decode(into interface{}) error {
+	return json.Unmarshal(o.Raw, into)
 }
 
 type ResourceDefinitionList struct {
@@ -65,12 +225,18 @@ type ResourceDefinitionList struct {
 type ResourceDefinitionsInterface interface {
 	Create(*ResourceDefinition) (*ResourceDefinition, error)
 	List(opts api.ListOptions) (*ResourceDefinitionList, error)
+	// Get returns definition as it is stored, placeholders are not rendered with parameters. Read-only
+	// definitions loaded from a source are rendered as listed ones
+	Get(name string) (*ResourceDefinition, error)
+	Update(*ResourceDefinition) (*ResourceDefinition, error)
 	Delete(name string, opts *api.DeleteOptions) error
 }
 
 type resourceDefinitions struct {
 	rc        *rest.RESTClient
 	namespace string
+	// params are substituted into placeholders of listed definitions, if set
+	params Parameters
 }
 
 func (r *ResourceDefinition) GetObjectKind() unversioned.ObjectKind {
@@ -87,7 +253,7 @@ func newResourceDefinitions(c rest.Config, ns string) (*resourceDefinitions, err
 		return nil, err
 	}
 
-	return &resourceDefinitions{rc, ns}, nil
+	return &resourceDefinitions{rc: rc, namespace: ns}, nil
 }
 
 func (c *resourceDefinitions) List(opts api.ListOptions) (*ResourceDefinitionList, error) {
@@ -101,6 +267,13 @@ func (c *resourceDefinitions) List(opts api.ListOptions) (*ResourceDefinitionLis
 		return nil, err
 	}
 
+	if c.params != nil {
+		resp, err = renderDefinitions(resp, c.params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	result := &ResourceDefinitionList{}
 	err = json.NewDecoder(bytes.NewReader(resp)).Decode(result)
 	if err != nil {
@@ -110,7 +283,17 @@ func (c *resourceDefinitions) List(opts api.ListOptions) (*ResourceDefinitionLis
 	return result, nil
 }
 
+// WithParameters returns client which renders listed definitions with given parameters
+func (c *resourceDefinitions) WithParameters(params Parameters) ResourceDefinitionsInterface {
+	return &resourceDefinitions{rc: c.rc, namespace: c.namespace, params: params}
+}
+
 func (c *resourceDefinitions) Create(rd *ResourceDefinition) (result *ResourceDefinition, err error) {
+	// CustomResourceDefinitions require objects to have kind and version
+	if rd.Kind == "" {
+		rd.APIVersion = SchemeGroupVersion.String()
+		rd.Kind = "Definition"
+	}
 	result = &ResourceDefinition{}
 	err = c.rc.Post().
 		Resource("definitions").
@@ -121,6 +304,29 @@ func (c *resourceDefinitions) Create(rd *ResourceDefinition) (result *ResourceDe
 	return
 }
 
+func (c *resourceDefinitions) Get(name string) (result *ResourceDefinition, err error) {
+	result = &ResourceDefinition{}
+	err = c.rc.Get().
+		Namespace(c.namespace).
+		Resource("definitions").
+		Name(name).
+		Do().
+		Into(result)
+	return
+}
+
+func (c *resourceDefinitions) Update(rd *ResourceDefinition) (result *ResourceDefinition, err error) {
+	result = &ResourceDefinition{}
+	err = c.rc.Put().
+		Namespace(c.namespace).
+		Resource("definitions").
+		Name(rd.Name).
+		Body(rd).
+		Do().
+		Into(result)
+	return
+}
+
 func (c *resourceDefinitions) Delete(name string, opts *api.DeleteOptions) error {
 	return c.rc.Delete().
 		Namespace(c.namespace).
@@ -0,0 +1,56 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestObjectJSON checks that objects of registered kinds keep their serialization and expose kind and name
+func TestObjectJSON(t *testing.T) {
+	data := `{"object":{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"w"},"spec":{"size":3}}}`
+	var def ResourceDefinition
+	if err := json.Unmarshal([]byte(data), &def); err != nil {
+		t.Fatal(err)
+	}
+	if def.Object == nil || def.Object.Kind != "widget" || def.Object.Name != "w" {
+		t.Fatalf("unexpected object %+v", def.Object)
+	}
+
+	var spec struct {
+		Spec struct {
+			Size int `json:"size"`
+		} `json:"spec"`
+	}
+	if err := def.Object.Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.Spec.Size != 3 {
+		t.Errorf("expected size 3, got %d", spec.Spec.Size)
+	}
+
+	encoded, err := json.Marshal(def.Object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != data[len(`{"object":`):len(data)-1] {
+		t.Errorf("object should be serialized as it was read, got %s", encoded)
+	}
+
+	if err = json.Unmarshal([]byte(`{"object":{"metadata":{"name":"w"}}}`), &def); err == nil {
+		t.Error("object without kind should result in error")
+	}
+}
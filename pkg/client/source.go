@@ -0,0 +1,384 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/labels"
+	"k8s.io/client-go/pkg/util/yaml"
+)
+
+// gitPrefix marks source locations of git repositories
+const gitPrefix = "git+"
+
+// sourceExtensions are extensions of files read from source directories
+var sourceExtensions = map[string]bool{".yaml": true, ".yml": true, ".json": true}
+
+// maxSourceSize is the largest size of objects read from a URL, larger responses are rejected
+const maxSourceSize = 16 << 20
+
+// errReadOnlySource is returned on attempts to modify objects loaded from a source
+var errReadOnlySource = errors.New("Objects loaded from source cannot be modified")
+
+// LoadObjects reads resource definitions and dependencies from YAML or JSON stream. The stream may
+// contain several documents and lists of them, documents of other kinds are ignored
+func LoadObjects(r io.Reader) ([]ResourceDefinition, []Dependency, error) {
+	var resDefs []ResourceDefinition
+	var deps []Dependency
+	decoder := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		var data json.RawMessage
+		err := decoder.Decode(&data)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = loadObject(data, &resDefs, &deps); err != nil {
+			return nil, nil, err
+		}
+	}
+	return resDefs, deps, nil
+}
+
+// loadObject appends serialized definition or dependency, or ones from the list, to the slices
+func loadObject(data json.RawMessage, resDefs *[]ResourceDefinition, deps *[]Dependency) error {
+	var object struct {
+		Kind  string            `json:"kind"`
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	switch object.Kind {
+	case "Definition":
+		var r ResourceDefinition
+		if err := json.Unmarshal(data, &r); err != nil {
+			return err
+		}
+		*resDefs = append(*resDefs, r)
+	case "Dependency":
+		var d Dependency
+		if err := json.Unmarshal(data, &d); err != nil {
+			return err
+		}
+		*deps = append(*deps, d)
+	case "List", "DefinitionList", "DependencyList":
+		for _, item := range object.Items {
+			if err := loadObject(item, resDefs, deps); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadSource reads resource definitions and dependencies from the location, which is a local file, a
+// local directory searched recursively for .yaml, .yml and .json files, an http:// or https:// URL, or
+// a git repository given as git+<repository URL>[#<ref>][:<path>], e.g.
+// git+https://github.com/org/repo.git#v1.0:deploy. The repository is cloned and the path in it, or the
+// whole repository, is read as a local directory. Files outside the clone, including ones linked from it,
+// are not read
+func LoadSource(location string) ([]ResourceDefinition, []Dependency, error) {
+	switch {
+	case strings.HasPrefix(location, gitPrefix):
+		return loadGit(strings.TrimPrefix(location, gitPrefix))
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		return loadURL(location)
+	}
+	return loadPath(location, "")
+}
+
+// confinedPath returns path with symlinks resolved, or error if it is outside of root directory
+func confinedPath(root, path string) (string, error) {
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of %s", path, root)
+	}
+	return resolved, nil
+}
+
+// loadPath reads local file or directory. If root is set, files outside of it are not read
+func loadPath(path, root string) ([]ResourceDefinition, []Dependency, error) {
+	if root != "" {
+		resolved, err := confinedPath(root, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		path = resolved
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !info.IsDir() {
+		return loadFile(path)
+	}
+
+	var resDefs []ResourceDefinition
+	var deps []Dependency
+	err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			// skip metadata of git repositories
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !sourceExtensions[filepath.Ext(file)] {
+			return nil
+		}
+		if root != "" {
+			if file, err = confinedPath(root, file); err != nil {
+				return err
+			}
+		}
+		fileDefs, fileDeps, err := loadFile(file)
+		if err != nil {
+			return err
+		}
+		resDefs = append(resDefs, fileDefs...)
+		deps = append(deps, fileDeps...)
+		return nil
+	})
+	return resDefs, deps, err
+}
+
+// loadFile reads single local file
+func loadFile(path string) ([]ResourceDefinition, []Dependency, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	resDefs, deps, err := LoadObjects(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return resDefs, deps, nil
+}
+
+// loadURL reads objects served by HTTP(S) URL. Responses larger than maxSourceSize are rejected
+func loadURL(url string) ([]ResourceDefinition, []Dependency, error) {
+	httpClient := &http.Client{Timeout: time.Minute}
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("%s: unexpected response status %s", url, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSourceSize+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) > maxSourceSize {
+		return nil, nil, fmt.Errorf("%s: response is larger than %d bytes", url, maxSourceSize)
+	}
+
+	resDefs, deps, err := LoadObjects(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", url, err)
+	}
+	return resDefs, deps, nil
+}
+
+// parseGitLocation returns repository, ref and path of git location given as
+// <repository URL>[#<ref>][:<path>]. Refs must not look like options of git
+func parseGitLocation(location string) (string, string, string, error) {
+	repository, fragment := location, ""
+	if i := strings.Index(location, "#"); i >= 0 {
+		repository, fragment = location[:i], location[i+1:]
+	}
+	ref, path := fragment, ""
+	if i := strings.Index(fragment, ":"); i >= 0 {
+		ref, path = fragment[:i], fragment[i+1:]
+	}
+	if repository == "" {
+		return "", "", "", fmt.Errorf("Git source %s has no repository", location)
+	}
+	if strings.HasPrefix(ref, "-") {
+		return "", "", "", fmt.Errorf("Invalid git ref %s", ref)
+	}
+	return repository, ref, path, nil
+}
+
+// loadGit clones git repository given as <repository URL>[#<ref>][:<path>] and reads the path in it.
+// The path must stay inside the clone
+func loadGit(location string) ([]ResourceDefinition, []Dependency, error) {
+	repository, ref, path, err := parseGitLocation(location)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dir, err := ioutil.TempDir("", "appcontroller-source")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, "--", repository, dir)
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		return nil, nil, fmt.Errorf("Could not clone %s: %v: %s", repository, err, strings.TrimSpace(string(out)))
+	}
+	return loadPath(filepath.Join(dir, path), dir)
+}
+
+// WithSource makes the client serve resource definitions and dependencies loaded from the location
+// instead of the ones stored in the cluster. See LoadSource for supported locations
+func WithSource(c Interface, location string) error {
+	cl, ok := c.(*Client)
+	if !ok {
+		return errors.New("Client does not support sources")
+	}
+	resDefs, deps, err := LoadSource(location)
+	if err != nil {
+		return err
+	}
+	cl.ResDefs = &sourceDefinitions{items: resDefs}
+	cl.Deps = &sourceDependencies{items: deps}
+	return nil
+}
+
+// matchesSelector returns true if labels match selector of list options, if it is set
+func matchesSelector(opts api.ListOptions, objectLabels map[string]string) bool {
+	return opts.LabelSelector == nil || opts.LabelSelector.Matches(labels.Set(objectLabels))
+}
+
+// sourceDefinitions serves resource definitions loaded from a source
+type sourceDefinitions struct {
+	items []ResourceDefinition
+	// params are substituted into placeholders of listed definitions, if set
+	params Parameters
+}
+
+func (c *sourceDefinitions) List(opts api.ListOptions) (*ResourceDefinitionList, error) {
+	result := &ResourceDefinitionList{}
+	for _, r := range c.items {
+		if matchesSelector(opts, r.Labels) {
+			result.Items = append(result.Items, r)
+		}
+	}
+	return c.render(result)
+}
+
+// render renders definitions of the list with parameters, if they are set
+func (c *sourceDefinitions) render(result *ResourceDefinitionList) (*ResourceDefinitionList, error) {
+	if c.params == nil {
+		return result, nil
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = renderDefinitions(data, c.params); err != nil {
+		return nil, err
+	}
+	rendered := &ResourceDefinitionList{}
+	err = json.Unmarshal(data, rendered)
+	return rendered, err
+}
+
+// WithParameters returns client which renders listed definitions with given parameters
+func (c *sourceDefinitions) WithParameters(params Parameters) ResourceDefinitionsInterface {
+	return &sourceDefinitions{items: c.items, params: params}
+}
+
+func (c *sourceDefinitions) Create(*ResourceDefinition) (*ResourceDefinition, error) {
+	return nil, errReadOnlySource
+}
+
+// Get returns definition rendered with parameters, as List does. Definitions from source are read-only,
+// so unlike stored definitions they are never updated with the result
+func (c *sourceDefinitions) Get(name string) (*ResourceDefinition, error) {
+	for i := range c.items {
+		if c.items[i].Name == name {
+			rendered, err := c.render(&ResourceDefinitionList{Items: []ResourceDefinition{c.items[i]}})
+			if err != nil {
+				return nil, err
+			}
+			return &rendered.Items[0], nil
+		}
+	}
+	return nil, fmt.Errorf("Resource definition %s not found in source", name)
+}
+
+func (c *sourceDefinitions) Update(*ResourceDefinition) (*ResourceDefinition, error) {
+	return nil, errReadOnlySource
+}
+
+func (c *sourceDefinitions) Delete(name string, opts *api.DeleteOptions) error {
+	return errReadOnlySource
+}
+
+// sourceDependencies serves dependencies loaded from a source
+type sourceDependencies struct {
+	items []Dependency
+}
+
+func (c *sourceDependencies) List(opts api.ListOptions) (*DependencyList, error) {
+	result := &DependencyList{}
+	for _, d := range c.items {
+		if matchesSelector(opts, d.Labels) {
+			result.Items = append(result.Items, d)
+		}
+	}
+	return result, nil
+}
+
+func (c *sourceDependencies) Create(*Dependency) (*Dependency, error) {
+	return nil, errReadOnlySource
+}
+
+func (c *sourceDependencies) Delete(name string, opts *api.DeleteOptions) error {
+	return errReadOnlySource
+}
+
+func (c *sourceDependencies) UpdateStatus(name string, status *DependencyStatus) error {
+	return errReadOnlySource
+}
@@ -0,0 +1,222 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/labels"
+)
+
+const sourceObjects = `apiVersion: appcontroller.k8s/v1alpha1
+kind: Definition
+metadata:
+  name: pod-web
+  labels:
+    app: web
+pod:
+  metadata:
+    name: web-{{ .env }}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: appcontroller.k8s/v1alpha1
+  kind: Definition
+  metadata:
+    name: job-other
+  job:
+    metadata:
+      name: other
+- apiVersion: appcontroller.k8s/v1alpha1
+  kind: Dependency
+  metadata:
+    name: dependency
+  parent: pod/web
+  child: job/other
+`
+
+// TestLoadObjects checks that definitions and dependencies are read from documents and lists
+func TestLoadObjects(t *testing.T) {
+	resDefs, deps, err := LoadObjects(strings.NewReader(sourceObjects))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resDefs) != 2 || resDefs[0].Name != "pod-web" || resDefs[1].Job == nil {
+		t.Errorf("Unexpected resource definitions %v", resDefs)
+	}
+	if len(deps) != 1 || deps[0].Parent != "pod/web" || deps[0].Child != "job/other" {
+		t.Errorf("Unexpected dependencies %v", deps)
+	}
+}
+
+// TestLoadSourceDirectory checks that directories are searched for manifests recursively
+func TestLoadSourceDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"graph/objects.yaml": sourceObjects,
+		"graph/README.md":    "not a manifest",
+		"dependency.json":    `{"kind": "Dependency", "metadata": {"name": "other"}, "parent": "job/other", "child": "pod/web"}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resDefs, deps, err := LoadSource(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resDefs) != 2 || len(deps) != 2 {
+		t.Errorf("Expected 2 resource definitions and 2 dependencies, got %d and %d", len(resDefs), len(deps))
+	}
+}
+
+// TestLoadSourceURL checks that objects are read from HTTP URLs and failed responses are reported
+func TestLoadSourceURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graph.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, sourceObjects)
+	}))
+	defer server.Close()
+
+	resDefs, deps, err := LoadSource(server.URL + "/graph.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resDefs) != 2 || len(deps) != 1 {
+		t.Errorf("Expected 2 resource definitions and 1 dependency, got %d and %d", len(resDefs), len(deps))
+	}
+	if _, _, err = LoadSource(server.URL + "/missing.yaml"); err == nil {
+		t.Error("Error expected for missing URL")
+	}
+
+	large := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sourceObjects))
+		w.Write(make([]byte, maxSourceSize))
+	}))
+	defer large.Close()
+	if _, _, err = LoadSource(large.URL); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("Error expected for too large response, got %v", err)
+	}
+}
+
+// TestParseGitLocation checks that git locations are split into repository, ref and path, and that refs
+// which could be taken for options of git are rejected
+func TestParseGitLocation(t *testing.T) {
+	repository, ref, path, err := parseGitLocation("https://example.com/repo.git#v1.0:deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repository != "https://example.com/repo.git" || ref != "v1.0" || path != "deploy" {
+		t.Errorf("Unexpected repository %s, ref %s and path %s", repository, ref, path)
+	}
+	for _, invalid := range []string{"https://example.com/repo.git#--upload-pack=touch /tmp/x", "#v1.0"} {
+		if _, _, _, err = parseGitLocation(invalid); err == nil {
+			t.Errorf("%q should be invalid", invalid)
+		}
+	}
+}
+
+// TestConfinedSource checks that paths and files linked from the root directory are not read if they
+// are outside of it
+func TestConfinedSource(t *testing.T) {
+	outside, err := ioutil.TempDir("", "outside")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outside)
+	if err = ioutil.WriteFile(filepath.Join(outside, "graph.yaml"), []byte(sourceObjects), 0644); err != nil {
+		t.Fatal(err)
+	}
+	root, err := ioutil.TempDir("", "root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if err = ioutil.WriteFile(filepath.Join(root, "graph.yaml"), []byte(sourceObjects), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err = loadPath(root, root); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = loadPath(filepath.Join(root, "..", filepath.Base(outside)), root); err == nil {
+		t.Error("Error expected for path outside of the root")
+	}
+	if err = os.Symlink(filepath.Join(outside, "graph.yaml"), filepath.Join(root, "linked.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = loadPath(root, root); err == nil {
+		t.Error("Error expected for file linked from outside of the root")
+	}
+	if _, _, err = loadPath(root, ""); err != nil {
+		t.Errorf("Local directories should not be confined, got %v", err)
+	}
+}
+
+// TestSourceDefinitions checks that definitions from source are filtered by labels and that both listed
+// and single definitions are rendered with parameters
+func TestSourceDefinitions(t *testing.T) {
+	items, _, err := LoadObjects(strings.NewReader(sourceObjects))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resDefs := (&sourceDefinitions{items: items}).WithParameters(Parameters{"env": "prod"})
+
+	list, err := resDefs.List(api.ListOptions{LabelSelector: labels.SelectorFromSet(labels.Set{"app": "web"})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Pod.Name != "web-prod" {
+		t.Errorf("Expected only rendered pod definition, got %v", list.Items)
+	}
+	r, err := resDefs.Get(list.Items[0].Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Pod.Name != "web-prod" {
+		t.Errorf("Expected definition rendered as listed one, got %s", r.Pod.Name)
+	}
+	if _, err = resDefs.Create(&ResourceDefinition{}); err == nil {
+		t.Error("Error expected when creating definition in source")
+	}
+}
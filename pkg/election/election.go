@@ -0,0 +1,233 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package election implements leader election between AppController replicas. The leader holds a lease
+// recorded in annotation of a config map and renews it periodically; other replicas wait until the lease
+// expires. Config map updates use resource version, so only one replica can take over expired lease
+package election
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// LeaderAnnotation is an annotation of lock config map holding leader record
+const LeaderAnnotation = "appcontroller.k8s/leader"
+
+// Default timings of leader election
+const (
+	DefaultLeaseDuration = time.Second * 15
+	DefaultRenewInterval = time.Second * 5
+	DefaultRetryInterval = time.Second * 2
+)
+
+// LeaderRecord describes current holder of the lease
+type LeaderRecord struct {
+	HolderIdentity       string    `json:"holderIdentity"`
+	LeaseDurationSeconds int       `json:"leaseDurationSeconds"`
+	AcquireTime          time.Time `json:"acquireTime"`
+	RenewTime            time.Time `json:"renewTime"`
+}
+
+// Config is a configuration of leader election
+type Config struct {
+	// Client is a client of config maps in namespace of the lock
+	Client corev1.ConfigMapInterface
+	// Name is a name of lock config map
+	Name string
+	// Identity is a unique name of the replica, e.g. name of its pod
+	Identity string
+	// LeaseDuration is a time for which the lease is valid after renewal
+	LeaseDuration time.Duration
+	// RenewInterval is an interval between lease renewals of the leader
+	RenewInterval time.Duration
+	// RetryInterval is an interval between attempts of standby replica to acquire the lease
+	RetryInterval time.Duration
+}
+
+// Elector acquires and holds the lease
+type Elector struct {
+	config Config
+}
+
+// New returns elector with given config. Zero timings are replaced by defaults
+func New(config Config) *Elector {
+	if config.LeaseDuration <= 0 {
+		config.LeaseDuration = DefaultLeaseDuration
+	}
+	if config.RenewInterval <= 0 {
+		config.RenewInterval = DefaultRenewInterval
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = DefaultRetryInterval
+	}
+	return &Elector{config: config}
+}
+
+// leaderRecord returns leader record stored in the config map
+func leaderRecord(configMap *v1.ConfigMap) (LeaderRecord, error) {
+	var record LeaderRecord
+	data, ok := configMap.Annotations[LeaderAnnotation]
+	if !ok || data == "" {
+		return record, nil
+	}
+	err := json.Unmarshal([]byte(data), &record)
+	return record, err
+}
+
+// setLeaderRecord stores leader record in the config map
+func setLeaderRecord(configMap *v1.ConfigMap, record LeaderRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if configMap.Annotations == nil {
+		configMap.Annotations = map[string]string{}
+	}
+	configMap.Annotations[LeaderAnnotation] = string(data)
+	return nil
+}
+
+// tryAcquireOrRenew takes the lease if it is free or expired, or renews it if it is held by this replica.
+// Returns true if this replica holds the lease
+func (e *Elector) tryAcquireOrRenew(now time.Time) (bool, error) {
+	record := LeaderRecord{
+		HolderIdentity:       e.config.Identity,
+		LeaseDurationSeconds: int(e.config.LeaseDuration / time.Second),
+		AcquireTime:          now,
+		RenewTime:            now,
+	}
+
+	configMap, err := e.config.Client.Get(e.config.Name)
+	if errors.IsNotFound(err) {
+		configMap = &v1.ConfigMap{ObjectMeta: v1.ObjectMeta{Name: e.config.Name}}
+		if err = setLeaderRecord(configMap, record); err != nil {
+			return false, err
+		}
+		_, err = e.config.Client.Create(configMap)
+		if errors.IsAlreadyExists(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if err != nil {
+		return false, err
+	}
+
+	current, err := leaderRecord(configMap)
+	if err != nil {
+		logging.Warningf("Leader record in config map %s is malformed, overwriting it: %v", e.config.Name, err)
+	}
+	leaseEnd := current.RenewTime.Add(time.Duration(current.LeaseDurationSeconds) * time.Second)
+	if current.HolderIdentity != "" && current.HolderIdentity != e.config.Identity && now.Before(leaseEnd) {
+		return false, nil
+	}
+	if current.HolderIdentity == e.config.Identity {
+		record.AcquireTime = current.AcquireTime
+	}
+	if err = setLeaderRecord(configMap, record); err != nil {
+		return false, err
+	}
+	_, err = e.config.Client.Update(configMap)
+	if errors.IsConflict(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// release frees the lease if it is held by this replica, so that standby replica does not wait for
+// its expiration
+func (e *Elector) release() error {
+	configMap, err := e.config.Client.Get(e.config.Name)
+	if err != nil {
+		return err
+	}
+	current, err := leaderRecord(configMap)
+	if err != nil || current.HolderIdentity != e.config.Identity {
+		return err
+	}
+	if err = setLeaderRecord(configMap, LeaderRecord{}); err != nil {
+		return err
+	}
+	_, err = e.config.Client.Update(configMap)
+	return err
+}
+
+// Run blocks until this replica becomes the leader, then calls run while renewing the lease in background.
+// If the lease is not renewed before it expires, lost is called; it is expected to stop the process, since
+// another replica may take over. The lease is released once run returns
+func (e *Elector) Run(run func(), lost func()) error {
+	if e.config.Identity == "" {
+		return fmt.Errorf("Identity of the replica is required for leader election")
+	}
+
+	logging.Infof("Waiting to become leader of %s as %s", e.config.Name, e.config.Identity)
+	for {
+		acquired, err := e.tryAcquireOrRenew(time.Now())
+		if err != nil {
+			logging.Errorf("Error acquiring leader lease %s: %v", e.config.Name, err)
+		}
+		if acquired {
+			break
+		}
+		time.Sleep(e.config.RetryInterval)
+	}
+	logging.Infof("Became leader of %s as %s", e.config.Name, e.config.Identity)
+
+	done := make(chan struct{})
+	go e.renew(done, lost)
+	run()
+	close(done)
+
+	if err := e.release(); err != nil {
+		logging.Errorf("Error releasing leader lease %s: %v", e.config.Name, err)
+	}
+	return nil
+}
+
+// renew renews the lease until done channel is closed. If renewal fails for longer than lease
+// duration, lost is called
+func (e *Elector) renew(done chan struct{}, lost func()) {
+	renewed := time.Now()
+	for {
+		select {
+		case <-done:
+			return
+		case <-time.After(e.config.RenewInterval):
+		}
+
+		now := time.Now()
+		acquired, err := e.tryAcquireOrRenew(now)
+		if acquired {
+			renewed = now
+			continue
+		}
+		if err != nil {
+			logging.Errorf("Error renewing leader lease %s: %v", e.config.Name, err)
+		}
+		// without error, the lease is taken by another replica
+		if err == nil || now.Sub(renewed) >= e.config.LeaseDuration {
+			logging.Errorf("Lost leader lease %s", e.config.Name)
+			lost()
+			return
+		}
+	}
+}
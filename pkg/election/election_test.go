@@ -0,0 +1,111 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package election
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+func newElectors() (*Elector, *Elector, corev1.ConfigMapInterface) {
+	client := mocks.NewClient().ConfigMaps()
+	first := New(Config{Client: client, Name: "lock", Identity: "first"})
+	second := New(Config{Client: client, Name: "lock", Identity: "second"})
+	return first, second, client
+}
+
+func currentLeader(t *testing.T, client corev1.ConfigMapInterface) LeaderRecord {
+	configMap, err := client.Get("lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := leaderRecord(configMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return record
+}
+
+// TestAcquireLease checks that only one replica holds valid lease, and another takes over once it expires
+func TestAcquireLease(t *testing.T) {
+	first, second, client := newElectors()
+	now := time.Now()
+
+	if acquired, err := first.tryAcquireOrRenew(now); !acquired || err != nil {
+		t.Fatalf("First replica should acquire free lease, got %v, %v", acquired, err)
+	}
+	if acquired, err := second.tryAcquireOrRenew(now.Add(time.Second)); acquired || err != nil {
+		t.Errorf("Second replica should not acquire held lease, got %v, %v", acquired, err)
+	}
+	if leader := currentLeader(t, client); leader.HolderIdentity != "first" {
+		t.Errorf("Expected first replica to be the leader, got %s", leader.HolderIdentity)
+	}
+
+	if acquired, err := second.tryAcquireOrRenew(now.Add(DefaultLeaseDuration + time.Second)); !acquired || err != nil {
+		t.Errorf("Second replica should acquire expired lease, got %v, %v", acquired, err)
+	}
+	if leader := currentLeader(t, client); leader.HolderIdentity != "second" {
+		t.Errorf("Expected second replica to be the leader, got %s", leader.HolderIdentity)
+	}
+}
+
+// TestRenewLease checks that renewal keeps acquire time and moves renew time
+func TestRenewLease(t *testing.T) {
+	first, _, client := newElectors()
+	now := time.Now()
+	first.tryAcquireOrRenew(now)
+	if acquired, err := first.tryAcquireOrRenew(now.Add(5 * time.Second)); !acquired || err != nil {
+		t.Fatalf("Leader should renew its lease, got %v, %v", acquired, err)
+	}
+
+	leader := currentLeader(t, client)
+	if !leader.AcquireTime.Equal(now) {
+		t.Errorf("Acquire time should not change on renewal, got %v instead of %v", leader.AcquireTime, now)
+	}
+	if !leader.RenewTime.Equal(now.Add(5 * time.Second)) {
+		t.Errorf("Renew time should be updated, got %v", leader.RenewTime)
+	}
+}
+
+// TestRun checks that leader runs the function and releases the lease afterwards
+func TestRun(t *testing.T) {
+	first, second, client := newElectors()
+	called := false
+	err := first.Run(func() { called = true }, func() { t.Error("Lease should not be lost") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !called {
+		t.Error("Run function was not called")
+	}
+	if leader := currentLeader(t, client); leader.HolderIdentity != "" {
+		t.Errorf("Lease should be released, held by %s", leader.HolderIdentity)
+	}
+	if acquired, err := second.tryAcquireOrRenew(time.Now()); !acquired || err != nil {
+		t.Errorf("Second replica should acquire released lease, got %v, %v", acquired, err)
+	}
+}
+
+// TestRunWithoutIdentity checks that identity is required
+func TestRunWithoutIdentity(t *testing.T) {
+	elector := New(Config{Client: mocks.NewClient().ConfigMaps(), Name: "lock"})
+	if err := elector.Run(func() {}, func() {}); err == nil {
+		t.Error("Expected error for elector without identity")
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package handler implements ResourceHandler gRPC protocol described in handler.proto. Messages and
+// service stubs in handler.pb.go are generated from handler.proto with protoc-gen-go, so handlers written
+// in Go can register their implementation with RegisterResourceHandlerServer, while handlers in other
+// languages are generated from the same file
+package handler
+
+//go:generate protoc --go_out=plugins=grpc:. handler.proto
+
+import (
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+var (
+	connections     = map[string]*grpc.ClientConn{}
+	connectionsLock sync.Mutex
+)
+
+// Dial returns client of the handler at endpoint in host:port form. Connections use TLS verified with
+// system root certificates, unless insecure is true. Connections are shared by all resources using the
+// same endpoint and are established in background
+func Dial(endpoint string, insecure bool) (ResourceHandlerClient, error) {
+	key := endpoint
+	if insecure {
+		key = "insecure://" + endpoint
+	}
+	connectionsLock.Lock()
+	defer connectionsLock.Unlock()
+	if cc, ok := connections[key]; ok {
+		return NewResourceHandlerClient(cc), nil
+	}
+
+	option := grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(nil, ""))
+	if insecure {
+		option = grpc.WithInsecure()
+	}
+	cc, err := grpc.Dial(endpoint, option)
+	if err != nil {
+		return nil, err
+	}
+	connections[key] = cc
+	return NewResourceHandlerClient(cc), nil
+}
@@ -0,0 +1,400 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: handler.proto
+
+/*
+Package handler is a generated protocol buffer package.
+
+It is generated from these files:
+
+	handler.proto
+
+It has these top-level messages:
+
+	Request
+	CreateResponse
+	DeleteResponse
+	StatusResponse
+	ReportResponse
+*/
+package handler
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type Request struct {
+	// key of the graph node, e.g. handler/db
+	Key string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	// name of the node given in its definition
+	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	// parameters of the definition serialized as JSON object
+	Parameters string `protobuf:"bytes,3,opt,name=parameters" json:"parameters,omitempty"`
+	// meta of the dependency on the node, set for Status and Report
+	Meta map[string]string `protobuf:"bytes,4,rep,name=meta" json:"meta,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *Request) Reset()                    { *m = Request{} }
+func (m *Request) String() string            { return proto.CompactTextString(m) }
+func (*Request) ProtoMessage()               {}
+func (*Request) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+func (m *Request) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *Request) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Request) GetParameters() string {
+	if m != nil {
+		return m.Parameters
+	}
+	return ""
+}
+
+func (m *Request) GetMeta() map[string]string {
+	if m != nil {
+		return m.Meta
+	}
+	return nil
+}
+
+type CreateResponse struct {
+}
+
+func (m *CreateResponse) Reset()                    { *m = CreateResponse{} }
+func (m *CreateResponse) String() string            { return proto.CompactTextString(m) }
+func (*CreateResponse) ProtoMessage()               {}
+func (*CreateResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+type DeleteResponse struct {
+}
+
+func (m *DeleteResponse) Reset()                    { *m = DeleteResponse{} }
+func (m *DeleteResponse) String() string            { return proto.CompactTextString(m) }
+func (*DeleteResponse) ProtoMessage()               {}
+func (*DeleteResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+type StatusResponse struct {
+	// phase is one of "ready", "not ready" or "error"
+	Phase string `protobuf:"bytes,1,opt,name=phase" json:"phase,omitempty"`
+	// reason is a short machine-readable cause of the phase, e.g. Provisioning
+	Reason  string `protobuf:"bytes,2,opt,name=reason" json:"reason,omitempty"`
+	Message string `protobuf:"bytes,3,opt,name=message" json:"message,omitempty"`
+	// progress is a percentage of readiness
+	Progress int32 `protobuf:"varint,4,opt,name=progress" json:"progress,omitempty"`
+}
+
+func (m *StatusResponse) Reset()                    { *m = StatusResponse{} }
+func (m *StatusResponse) String() string            { return proto.CompactTextString(m) }
+func (*StatusResponse) ProtoMessage()               {}
+func (*StatusResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *StatusResponse) GetPhase() string {
+	if m != nil {
+		return m.Phase
+	}
+	return ""
+}
+
+func (m *StatusResponse) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *StatusResponse) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *StatusResponse) GetProgress() int32 {
+	if m != nil {
+		return m.Progress
+	}
+	return 0
+}
+
+type ReportResponse struct {
+	// blocks is true if the resource blocks creation of nodes depending on it
+	Blocks     bool   `protobuf:"varint,1,opt,name=blocks" json:"blocks,omitempty"`
+	Percentage int32  `protobuf:"varint,2,opt,name=percentage" json:"percentage,omitempty"`
+	Needed     int32  `protobuf:"varint,3,opt,name=needed" json:"needed,omitempty"`
+	Message    string `protobuf:"bytes,4,opt,name=message" json:"message,omitempty"`
+}
+
+func (m *ReportResponse) Reset()                    { *m = ReportResponse{} }
+func (m *ReportResponse) String() string            { return proto.CompactTextString(m) }
+func (*ReportResponse) ProtoMessage()               {}
+func (*ReportResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *ReportResponse) GetBlocks() bool {
+	if m != nil {
+		return m.Blocks
+	}
+	return false
+}
+
+func (m *ReportResponse) GetPercentage() int32 {
+	if m != nil {
+		return m.Percentage
+	}
+	return 0
+}
+
+func (m *ReportResponse) GetNeeded() int32 {
+	if m != nil {
+		return m.Needed
+	}
+	return 0
+}
+
+func (m *ReportResponse) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*Request)(nil), "appcontroller.handler.Request")
+	proto.RegisterType((*CreateResponse)(nil), "appcontroller.handler.CreateResponse")
+	proto.RegisterType((*DeleteResponse)(nil), "appcontroller.handler.DeleteResponse")
+	proto.RegisterType((*StatusResponse)(nil), "appcontroller.handler.StatusResponse")
+	proto.RegisterType((*ReportResponse)(nil), "appcontroller.handler.ReportResponse")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for ResourceHandler service
+
+type ResourceHandlerClient interface {
+	// Create starts creation of the resource. It should return ALREADY_EXISTS if the resource exists
+	Create(ctx context.Context, in *Request, opts ...grpc.CallOption) (*CreateResponse, error)
+	// Delete deletes the resource. It should return NOT_FOUND if there is no such resource
+	Delete(ctx context.Context, in *Request, opts ...grpc.CallOption) (*DeleteResponse, error)
+	// Status returns readiness of the resource. It should return NOT_FOUND if the resource was not created
+	Status(ctx context.Context, in *Request, opts ...grpc.CallOption) (*StatusResponse, error)
+	// Report returns report of the resource as a dependency of other nodes
+	Report(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ReportResponse, error)
+}
+
+type resourceHandlerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewResourceHandlerClient(cc *grpc.ClientConn) ResourceHandlerClient {
+	return &resourceHandlerClient{cc}
+}
+
+func (c *resourceHandlerClient) Create(ctx context.Context, in *Request, opts ...grpc.CallOption) (*CreateResponse, error) {
+	out := new(CreateResponse)
+	err := grpc.Invoke(ctx, "/appcontroller.handler.ResourceHandler/Create", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *resourceHandlerClient) Delete(ctx context.Context, in *Request, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	out := new(DeleteResponse)
+	err := grpc.Invoke(ctx, "/appcontroller.handler.ResourceHandler/Delete", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *resourceHandlerClient) Status(ctx context.Context, in *Request, opts ...grpc.CallOption) (*StatusResponse, error) {
+	out := new(StatusResponse)
+	err := grpc.Invoke(ctx, "/appcontroller.handler.ResourceHandler/Status", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *resourceHandlerClient) Report(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ReportResponse, error) {
+	out := new(ReportResponse)
+	err := grpc.Invoke(ctx, "/appcontroller.handler.ResourceHandler/Report", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for ResourceHandler service
+
+type ResourceHandlerServer interface {
+	// Create starts creation of the resource. It should return ALREADY_EXISTS if the resource exists
+	Create(context.Context, *Request) (*CreateResponse, error)
+	// Delete deletes the resource. It should return NOT_FOUND if there is no such resource
+	Delete(context.Context, *Request) (*DeleteResponse, error)
+	// Status returns readiness of the resource. It should return NOT_FOUND if the resource was not created
+	Status(context.Context, *Request) (*StatusResponse, error)
+	// Report returns report of the resource as a dependency of other nodes
+	Report(context.Context, *Request) (*ReportResponse, error)
+}
+
+func RegisterResourceHandlerServer(s *grpc.Server, srv ResourceHandlerServer) {
+	s.RegisterService(&_ResourceHandler_serviceDesc, srv)
+}
+
+func _ResourceHandler_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceHandlerServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/appcontroller.handler.ResourceHandler/Create",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceHandlerServer).Create(ctx, req.(*Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResourceHandler_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceHandlerServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/appcontroller.handler.ResourceHandler/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceHandlerServer).Delete(ctx, req.(*Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResourceHandler_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceHandlerServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/appcontroller.handler.ResourceHandler/Status",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceHandlerServer).Status(ctx, req.(*Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResourceHandler_Report_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceHandlerServer).Report(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/appcontroller.handler.ResourceHandler/Report",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceHandlerServer).Report(ctx, req.(*Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _ResourceHandler_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "appcontroller.handler.ResourceHandler",
+	HandlerType: (*ResourceHandlerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _ResourceHandler_Create_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _ResourceHandler_Delete_Handler,
+		},
+		{
+			MethodName: "Status",
+			Handler:    _ResourceHandler_Status_Handler,
+		},
+		{
+			MethodName: "Report",
+			Handler:    _ResourceHandler_Report_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "handler.proto",
+}
+
+func init() { proto.RegisterFile("handler.proto", fileDescriptor0) }
+
+var fileDescriptor0 = []byte{
+	// 374 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x53, 0x4d, 0x6b, 0xe3, 0x30,
+	0x14, 0xc4, 0x8e, 0xe3, 0x24, 0x6f, 0xd9, 0x6c, 0x10, 0xbb, 0xc1, 0xe4, 0x10, 0x42, 0x60, 0xc1,
+	0x27, 0x1f, 0xb2, 0x87, 0x2d, 0xa5, 0xa7, 0x7e, 0x40, 0x2f, 0x25, 0xa0, 0xde, 0x7a, 0x53, 0x9c,
+	0x47, 0x02, 0xb1, 0x25, 0x55, 0x92, 0x0b, 0xe9, 0x0f, 0xec, 0x3f, 0xe8, 0xff, 0x29, 0x92, 0x95,
+	0x60, 0x97, 0xb4, 0x87, 0xdc, 0x34, 0xf3, 0x78, 0x33, 0xcf, 0x33, 0x18, 0x7e, 0x6e, 0x19, 0x5f,
+	0x17, 0xa8, 0x32, 0xa9, 0x84, 0x11, 0xe4, 0x0f, 0x93, 0x32, 0x17, 0xdc, 0x28, 0x51, 0x58, 0xd2,
+	0x0f, 0xe7, 0x6f, 0x01, 0xf4, 0x28, 0x3e, 0x57, 0xa8, 0x0d, 0x19, 0x41, 0x67, 0x87, 0xfb, 0x24,
+	0x98, 0x05, 0xe9, 0x80, 0xda, 0x27, 0x21, 0x10, 0x71, 0x56, 0x62, 0x12, 0x3a, 0xca, 0xbd, 0xc9,
+	0x14, 0x40, 0x32, 0xc5, 0x4a, 0x34, 0xa8, 0x74, 0xd2, 0x71, 0x93, 0x06, 0x43, 0xae, 0x20, 0x2a,
+	0xd1, 0xb0, 0x24, 0x9a, 0x75, 0xd2, 0x1f, 0x8b, 0x34, 0x3b, 0xe9, 0x9b, 0x79, 0xcf, 0xec, 0x01,
+	0x0d, 0xbb, 0xe3, 0x46, 0xed, 0xa9, 0xdb, 0x9a, 0xfc, 0x87, 0xc1, 0x91, 0x3a, 0x71, 0xd0, 0x6f,
+	0xe8, 0xbe, 0xb0, 0xa2, 0x3a, 0x5c, 0x54, 0x83, 0xcb, 0xf0, 0x22, 0x98, 0x8f, 0x60, 0x78, 0xa3,
+	0x90, 0x19, 0xa4, 0xa8, 0xa5, 0xe0, 0x1a, 0x2d, 0x73, 0x8b, 0x05, 0x36, 0x18, 0x03, 0xc3, 0x47,
+	0xc3, 0x4c, 0xa5, 0x0f, 0x8c, 0xd5, 0x93, 0x5b, 0xa6, 0xd1, 0x7b, 0xd4, 0x80, 0x8c, 0x21, 0x56,
+	0xc8, 0xb4, 0xe0, 0xde, 0xc6, 0x23, 0x92, 0x40, 0xaf, 0x44, 0xad, 0xd9, 0x06, 0xfd, 0x77, 0x1f,
+	0x20, 0x99, 0x40, 0x5f, 0x2a, 0xb1, 0x51, 0xa8, 0x75, 0x12, 0xcd, 0x82, 0xb4, 0x4b, 0x8f, 0x78,
+	0xfe, 0x0a, 0x43, 0x8a, 0x52, 0x28, 0x73, 0x74, 0x1d, 0x43, 0xbc, 0x2a, 0x44, 0xbe, 0xd3, 0xce,
+	0xb6, 0x4f, 0x3d, 0x72, 0xd1, 0xa2, 0xca, 0x91, 0x1b, 0x6b, 0x11, 0x3a, 0x9d, 0x06, 0x63, 0xf7,
+	0x38, 0xe2, 0x1a, 0xd7, 0xce, 0xbe, 0x4b, 0x3d, 0x6a, 0xde, 0x15, 0xb5, 0xee, 0x5a, 0xbc, 0x87,
+	0xf0, 0x8b, 0xa2, 0x16, 0x95, 0xca, 0xf1, 0xbe, 0x8e, 0x9e, 0x2c, 0x21, 0xae, 0x93, 0x22, 0xd3,
+	0xef, 0xcb, 0x99, 0xfc, 0xfd, 0x62, 0xde, 0x0e, 0xda, 0x0a, 0xd6, 0x41, 0x9f, 0x2d, 0xd8, 0xee,
+	0xc9, 0x0a, 0xd6, 0x3d, 0x9d, 0x2d, 0xf8, 0xa9, 0xe6, 0x25, 0xc4, 0x75, 0x05, 0x67, 0x0b, 0xb6,
+	0x1b, 0xbc, 0x1e, 0x3c, 0xf5, 0xfc, 0x64, 0x15, 0xbb, 0xff, 0xeb, 0xdf, 0xc7, 0x00, 0x7a, 0xda,
+	0xe0, 0xdb, 0x70, 0x03, 0x00, 0x00,
+}
@@ -14,15 +14,86 @@
 
 package interfaces
 
-import "github.com/Mirantis/k8s-AppController/pkg/client"
+import (
+	"time"
 
-// BaseResource is an interface for AppController supported resources
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+)
+
+// ResourcePhase is a phase of resource in the cluster
+type ResourcePhase string
+
+// Possible values of ResourcePhase
+const (
+	// ResourceReady means that dependencies of the resource can be created
+	ResourceReady ResourcePhase = "ready"
+	// ResourceNotReady means that the resource exists, but its dependencies have to wait
+	ResourceNotReady ResourcePhase = "not ready"
+	// ResourceError means that status of the resource could not be determined or the resource failed
+	ResourceError ResourcePhase = "error"
+	// ResourceWaitingForUpgrade means that the resource exists in the cluster, but differs from its definition
+	ResourceWaitingForUpgrade ResourcePhase = "waiting for upgrade"
+)
+
+// ResourceStatus is a status of resource in the cluster. Reason is a short machine-readable cause of
+// the phase, e.g. CrashLoopBackOff, and Message explains it to humans. Progress is a percentage of
+// readiness, e.g. share of ready replicas. ObservedAt is a time the status was retrieved at
+type ResourceStatus struct {
+	Phase      ResourcePhase `json:"phase"`
+	Reason     string        `json:"reason,omitempty"`
+	Message    string        `json:"message,omitempty"`
+	ObservedAt time.Time     `json:"observedAt"`
+	Progress   int           `json:"progress"`
+}
+
+// NewStatus returns status in the given phase. Ready resources have 100% progress
+func NewStatus(phase ResourcePhase) ResourceStatus {
+	status := ResourceStatus{Phase: phase}
+	if phase == ResourceReady {
+		status.Progress = 100
+	}
+	return status
+}
+
+// WithReason returns copy of the status with given reason and message
+func (s ResourceStatus) WithReason(reason, message string) ResourceStatus {
+	s.Reason = reason
+	s.Message = message
+	return s
+}
+
+// WithProgress returns copy of the status with progress computed as ready share of total. Progress
+// of zero total is 100%
+func (s ResourceStatus) WithProgress(ready, total int) ResourceStatus {
+	s.Progress = 100
+	if total > 0 {
+		s.Progress = ready * 100 / total
+	}
+	return s
+}
+
+// IsReady returns true if dependencies of the resource can be created
+func (s ResourceStatus) IsReady() bool {
+	return s.Phase == ResourceReady
+}
+
+// String returns the phase of the status
+func (s ResourceStatus) String() string {
+	return string(s.Phase)
+}
+
+// BaseResource is an interface for AppController supported resources. Context passed to methods
+// talking to the cluster is cancelled when the graph run is cancelled or the call times out
 type BaseResource interface {
 	Key() string
 	// Ensure that Status() supports nil as meta
-	Status(meta map[string]string) (string, error)
-	Create() error
-	Delete() error
+	Status(ctx context.Context, meta map[string]string) (ResourceStatus, error)
+	Create(ctx context.Context) error
+	Delete(ctx context.Context) error
+	// Update makes the object in cluster match its definition
+	Update(ctx context.Context) error
 	Meta(string) interface{}
 	StatusIsCacheable(meta map[string]string) bool
 }
@@ -34,12 +105,33 @@ type DependencyReport struct {
 	Percentage int
 	Needed     int
 	Message    string
+	// Logs are last lines of container logs captured when the resource failed
+	Logs string `json:",omitempty"`
 }
 
 // Resource is an interface for a base resource that implements getting dependency reports
 type Resource interface {
 	BaseResource
-	GetDependencyReport(map[string]string) DependencyReport
+	GetDependencyReport(context.Context, map[string]string) DependencyReport
+}
+
+// Checksummer is an interface for resources whose content can be summarized with a checksum
+type Checksummer interface {
+	Checksum() (string, error)
+}
+
+// LiveObjectGetter is an interface for resources created from definitions, which can return the
+// object of their definition and the object as it is in the cluster
+type LiveObjectGetter interface {
+	Definition() interface{}
+	Live() (interface{}, error)
+}
+
+// PodRestarter is an interface for resources which can restart their pods
+type PodRestarter interface {
+	// RestartPods sets annotation of the pod template to value, which makes the controller
+	// replace the pods if the value differs from the current one
+	RestartPods(annotation, value string) error
 }
 
 // ResourceTemplate is an interface for AppController supported resource templates
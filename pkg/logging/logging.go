@@ -0,0 +1,250 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logging is a structured logger. Every line may be tagged with fields such as graph run ID,
+// resource key and processing phase, and can be written either as text or as JSON
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is a severity of log message
+type Level int
+
+// Possible values for Level
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarningLevel
+	ErrorLevel
+)
+
+var levelNames = map[Level]string{
+	DebugLevel:   "debug",
+	InfoLevel:    "info",
+	WarningLevel: "warning",
+	ErrorLevel:   "error",
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel returns level with given name
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.ToLower(name) == levelName {
+			return level, nil
+		}
+	}
+	return InfoLevel, fmt.Errorf("Unknown log level %s. Expected one of: debug, info, warning, error", name)
+}
+
+// Field names used by AppController
+const (
+	RunField      = "run"
+	ResourceField = "resource"
+	PhaseField    = "phase"
+)
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+var (
+	lock   sync.Mutex
+	output io.Writer = os.Stderr
+	level            = InfoLevel
+	asJSON bool
+	global []field
+)
+
+// SetOutput sets the writer log lines are written to
+func SetOutput(w io.Writer) {
+	lock.Lock()
+	defer lock.Unlock()
+	output = w
+}
+
+// SetLevel sets minimal level of messages which are written
+func SetLevel(l Level) {
+	lock.Lock()
+	defer lock.Unlock()
+	level = l
+}
+
+// SetJSON switches between JSON and text output
+func SetJSON(enabled bool) {
+	lock.Lock()
+	defer lock.Unlock()
+	asJSON = enabled
+}
+
+// SetRunID tags all following log lines with given graph run ID
+func SetRunID(id string) {
+	lock.Lock()
+	defer lock.Unlock()
+	for i, f := range global {
+		if f.key == RunField {
+			global[i].value = id
+			return
+		}
+	}
+	global = append(global, field{RunField, id})
+}
+
+// RunID returns ID of graph run log lines are tagged with, or empty string if it is not set
+func RunID() string {
+	lock.Lock()
+	defer lock.Unlock()
+	for _, f := range global {
+		if f.key == RunField {
+			return fmt.Sprint(f.value)
+		}
+	}
+	return ""
+}
+
+// NewRunID returns random ID of graph run
+func NewRunID() string {
+	data := make([]byte, 4)
+	if _, err := rand.Read(data); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(data)
+}
+
+// Logger writes log lines tagged with its fields
+type Logger struct {
+	fields []field
+}
+
+// With returns logger which tags lines with given field
+func With(key string, value interface{}) Logger {
+	return Logger{}.With(key, value)
+}
+
+// ForResource returns logger which tags lines with resource key
+func ForResource(key string) Logger {
+	return With(ResourceField, key)
+}
+
+// With returns copy of the logger which also tags lines with given field
+func (l Logger) With(key string, value interface{}) Logger {
+	fields := make([]field, len(l.fields), len(l.fields)+1)
+	copy(fields, l.fields)
+	return Logger{fields: append(fields, field{key, value})}
+}
+
+// Debugf writes debug message
+func (l Logger) Debugf(format string, args ...interface{}) {
+	l.write(DebugLevel, format, args)
+}
+
+// Infof writes informational message
+func (l Logger) Infof(format string, args ...interface{}) {
+	l.write(InfoLevel, format, args)
+}
+
+// Warningf writes warning message
+func (l Logger) Warningf(format string, args ...interface{}) {
+	l.write(WarningLevel, format, args)
+}
+
+// Errorf writes error message
+func (l Logger) Errorf(format string, args ...interface{}) {
+	l.write(ErrorLevel, format, args)
+}
+
+// Fatalf writes error message and exits the process
+func (l Logger) Fatalf(format string, args ...interface{}) {
+	l.write(ErrorLevel, format, args)
+	os.Exit(1)
+}
+
+func (l Logger) write(msgLevel Level, format string, args []interface{}) {
+	lock.Lock()
+	defer lock.Unlock()
+	if msgLevel < level {
+		return
+	}
+
+	message := fmt.Sprintf(format, args...)
+	now := time.Now()
+	fields := append(append([]field{}, global...), l.fields...)
+
+	if asJSON {
+		// fields are written in order, so that the output is stable
+		parts := []string{
+			jsonPair("time", now.Format(time.RFC3339)),
+			jsonPair("level", msgLevel.String()),
+			jsonPair("msg", message),
+		}
+		for _, f := range fields {
+			parts = append(parts, jsonPair(f.key, f.value))
+		}
+		fmt.Fprintf(output, "{%s}\n", strings.Join(parts, ","))
+		return
+	}
+
+	line := fmt.Sprintf("%s %-7s %s", now.Format("2006/01/02 15:04:05"), strings.ToUpper(msgLevel.String()), message)
+	for _, f := range fields {
+		line += fmt.Sprintf(" %s=%v", f.key, f.value)
+	}
+	fmt.Fprintln(output, line)
+}
+
+func jsonPair(key string, value interface{}) string {
+	k, _ := json.Marshal(key)
+	v, err := json.Marshal(value)
+	if err != nil {
+		v, _ = json.Marshal(fmt.Sprint(value))
+	}
+	return string(k) + ":" + string(v)
+}
+
+// Debugf writes debug message without fields
+func Debugf(format string, args ...interface{}) {
+	Logger{}.Debugf(format, args...)
+}
+
+// Infof writes informational message without fields
+func Infof(format string, args ...interface{}) {
+	Logger{}.Infof(format, args...)
+}
+
+// Warningf writes warning message without fields
+func Warningf(format string, args ...interface{}) {
+	Logger{}.Warningf(format, args...)
+}
+
+// Errorf writes error message without fields
+func Errorf(format string, args ...interface{}) {
+	Logger{}.Errorf(format, args...)
+}
+
+// Fatalf writes error message without fields and exits the process
+func Fatalf(format string, args ...interface{}) {
+	Logger{}.Fatalf(format, args...)
+}
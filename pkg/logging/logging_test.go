@@ -0,0 +1,110 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// capture redirects log output to a buffer until returned function is called
+func capture(jsonOutput bool, minLevel Level) (*bytes.Buffer, func()) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	SetJSON(jsonOutput)
+	SetLevel(minLevel)
+	return &buf, func() {
+		SetOutput(os.Stderr)
+		SetJSON(false)
+		SetLevel(InfoLevel)
+		lock.Lock()
+		global = nil
+		lock.Unlock()
+	}
+}
+
+// TestJSONOutput checks that JSON lines contain message, level and all fields
+func TestJSONOutput(t *testing.T) {
+	buf, restore := capture(true, InfoLevel)
+	defer restore()
+
+	SetRunID("abc")
+	ForResource("pod/p1").With(PhaseField, "create").Infof("Creating %s", "pod/p1")
+
+	var line map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("Output is not valid JSON: %v: %s", err, buf.String())
+	}
+	expected := map[string]string{
+		"level":       "info",
+		"msg":         "Creating pod/p1",
+		RunField:      "abc",
+		ResourceField: "pod/p1",
+		PhaseField:    "create",
+	}
+	for key, value := range expected {
+		if line[key] != value {
+			t.Errorf("Expected %s to be %q, got %q", key, value, line[key])
+		}
+	}
+	if line["time"] == "" {
+		t.Error("Time is missing")
+	}
+}
+
+// TestTextOutput checks that text lines contain fields as key=value pairs
+func TestTextOutput(t *testing.T) {
+	buf, restore := capture(false, InfoLevel)
+	defer restore()
+
+	ForResource("job/j1").Warningf("Retrying")
+
+	output := buf.String()
+	if !strings.Contains(output, "WARNING Retrying resource=job/j1") {
+		t.Errorf("Unexpected output: %s", output)
+	}
+}
+
+// TestLevelFiltering checks that messages below minimal level are not written
+func TestLevelFiltering(t *testing.T) {
+	buf, restore := capture(false, WarningLevel)
+	defer restore()
+
+	Debugf("debug")
+	Infof("info")
+	Errorf("error")
+
+	output := buf.String()
+	if strings.Contains(output, "debug") || strings.Contains(output, "info") {
+		t.Errorf("Messages below warning level were written: %s", output)
+	}
+	if !strings.Contains(output, "error") {
+		t.Errorf("Error message was not written: %s", output)
+	}
+}
+
+// TestParseLevel checks parsing of level names
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("Debug")
+	if err != nil || level != DebugLevel {
+		t.Errorf("Expected debug level, got %v, %v", level, err)
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Expected error for unknown level")
+	}
+}
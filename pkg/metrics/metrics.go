@@ -0,0 +1,209 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics keeps AppController metrics and exposes them in Prometheus text format
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// Types of metric families
+const (
+	counterType = "counter"
+	gaugeType   = "gauge"
+	summaryType = "summary"
+)
+
+type sample struct {
+	labelValues []string
+	value       float64
+	count       uint64
+}
+
+// Family is a group of metrics with the same name which differ by label values
+type Family struct {
+	name       string
+	help       string
+	metricType string
+	labels     []string
+	samples    map[string]*sample
+	sync.Mutex
+}
+
+var (
+	registryLock sync.Mutex
+	registry     []*Family
+)
+
+func newFamily(name, help, metricType string, labels []string) *Family {
+	f := &Family{
+		name:       name,
+		help:       help,
+		metricType: metricType,
+		labels:     labels,
+		samples:    map[string]*sample{},
+	}
+	registryLock.Lock()
+	registry = append(registry, f)
+	registryLock.Unlock()
+	return f
+}
+
+// NewCounter creates and registers a counter family with given label names
+func NewCounter(name, help string, labels ...string) *Family {
+	return newFamily(name, help, counterType, labels)
+}
+
+// NewGauge creates and registers a gauge family with given label names
+func NewGauge(name, help string, labels ...string) *Family {
+	return newFamily(name, help, gaugeType, labels)
+}
+
+// NewSummary creates and registers a summary family with given label names. Summaries keep sum
+// and count of observed values
+func NewSummary(name, help string, labels ...string) *Family {
+	return newFamily(name, help, summaryType, labels)
+}
+
+// get returns sample with given label values, creating it if needed. Caller must hold the lock
+func (f *Family) get(labelValues []string) *sample {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", f.name, len(f.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\x00")
+	s, ok := f.samples[key]
+	if !ok {
+		s = &sample{labelValues: labelValues}
+		f.samples[key] = s
+	}
+	return s
+}
+
+// Inc increments the metric with given label values by one
+func (f *Family) Inc(labelValues ...string) {
+	f.Add(1, labelValues...)
+}
+
+// Add adds value to the metric with given label values
+func (f *Family) Add(value float64, labelValues ...string) {
+	f.Lock()
+	defer f.Unlock()
+	f.get(labelValues).value += value
+}
+
+// Set sets value of the gauge with given label values
+func (f *Family) Set(value float64, labelValues ...string) {
+	f.Lock()
+	defer f.Unlock()
+	f.get(labelValues).value = value
+}
+
+// Observe adds an observation to the summary with given label values
+func (f *Family) Observe(value float64, labelValues ...string) {
+	f.Lock()
+	defer f.Unlock()
+	s := f.get(labelValues)
+	s.value += value
+	s.count++
+}
+
+// Value returns current value of the metric with given label values. For summaries it is a sum of observations
+func (f *Family) Value(labelValues ...string) float64 {
+	f.Lock()
+	defer f.Unlock()
+	return f.get(labelValues).value
+}
+
+// Reset removes all metrics of the family
+func (f *Family) Reset() {
+	f.Lock()
+	defer f.Unlock()
+	f.samples = map[string]*sample{}
+}
+
+func (f *Family) labelString(labelValues []string) string {
+	if len(f.labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(f.labels))
+	for i, label := range f.labels {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labelValues[i])
+		pairs[i] = fmt.Sprintf(`%s="%s"`, label, value)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func (f *Family) write(w io.Writer) {
+	f.Lock()
+	defer f.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, f.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.metricType)
+	keys := make([]string, 0, len(f.samples))
+	for key := range f.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := f.samples[key]
+		labels := f.labelString(s.labelValues)
+		if f.metricType == summaryType {
+			fmt.Fprintf(w, "%s_sum%s %v\n", f.name, labels, s.value)
+			fmt.Fprintf(w, "%s_count%s %d\n", f.name, labels, s.count)
+		} else {
+			fmt.Fprintf(w, "%s%s %v\n", f.name, labels, s.value)
+		}
+	}
+}
+
+// Write writes all registered metrics in Prometheus text format
+func Write(w io.Writer) {
+	registryLock.Lock()
+	families := append([]*Family(nil), registry...)
+	registryLock.Unlock()
+	for _, f := range families {
+		f.write(w)
+	}
+}
+
+// Handler returns HTTP handler serving registered metrics
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		Write(&buf)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(buf.Bytes())
+	})
+}
+
+// Serve starts HTTP server exposing metrics on /metrics path of given address in background
+func Serve(address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	go func() {
+		logging.Infof("Serving metrics on %s/metrics", address)
+		if err := http.ListenAndServe(address, mux); err != nil {
+			logging.Errorf("Metrics server failed: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,63 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestHandler checks that metrics are served in Prometheus text format
+func TestHandler(t *testing.T) {
+	counter := NewCounter("test_attempts_total", "Test counter", "kind")
+	summary := NewSummary("test_poll_seconds", "Test summary", "kind")
+	gauge := NewGauge("test_completion_percent", "Test gauge")
+
+	counter.Inc("pod")
+	counter.Inc("pod")
+	counter.Add(3, "job")
+	summary.Observe(0.5, "pod")
+	summary.Observe(1, "pod")
+	gauge.Set(42)
+
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"# TYPE test_attempts_total counter",
+		`test_attempts_total{kind="job"} 3`,
+		`test_attempts_total{kind="pod"} 2`,
+		"# TYPE test_poll_seconds summary",
+		`test_poll_seconds_sum{kind="pod"} 1.5`,
+		`test_poll_seconds_count{kind="pod"} 2`,
+		"test_completion_percent 42",
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("Output should contain %q, got:\n%s", line, body)
+		}
+	}
+}
@@ -15,18 +15,35 @@
 package mocks
 
 import (
+	"strings"
+
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	alphafake "github.com/Mirantis/k8s-AppController/pkg/client/petsets/typed/apps/v1alpha1/fake"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client/petsets/apis/apps/v1alpha1"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/pkg/api/meta"
 	"k8s.io/client-go/pkg/api/unversioned"
 	"k8s.io/client-go/pkg/apis/apps/v1beta1"
 	"k8s.io/client-go/pkg/runtime"
+	"k8s.io/client-go/pkg/version"
+	"k8s.io/client-go/testing"
 )
 
+// defaultNamespace sets namespace of created objects to namespace of the request, as API server does.
+// Fake clientset rejects namespaced objects without namespace otherwise
+func defaultNamespace(action testing.Action) (bool, runtime.Object, error) {
+	if create, ok := action.(testing.CreateAction); ok {
+		if accessor, err := meta.Accessor(create.GetObject()); err == nil && accessor.GetNamespace() == "" {
+			accessor.SetNamespace(action.GetNamespace())
+		}
+	}
+	return false, nil, nil
+}
+
 func newClient(objects ...runtime.Object) *client.Client {
 	fakeClientset := fake.NewSimpleClientset(objects...)
+	fakeClientset.PrependReactor("create", "*", defaultNamespace)
 	apps := &alphafake.FakeApps{&fakeClientset.Fake}
 	return &client.Client{
 		Clientset: fakeClientset,
@@ -37,6 +54,18 @@ func newClient(objects ...runtime.Object) *client.Client {
 	}
 }
 
+// makeResources returns discovery resources served by fake API server for the workload group version
+func makeResources(version unversioned.GroupVersion, kind string) map[string]*unversioned.APIResourceList {
+	return map[string]*unversioned.APIResourceList{
+		version.String(): {
+			GroupVersion: version.String(),
+			APIResources: []unversioned.APIResource{
+				{Name: strings.ToLower(kind) + "s", Namespaced: true, Kind: kind},
+			},
+		},
+	}
+}
+
 func makeVersionsList(version unversioned.GroupVersion) *unversioned.APIGroupList {
 	return &unversioned.APIGroupList{Groups: []unversioned.APIGroup{
 		{
@@ -52,11 +81,15 @@ func makeVersionsList(version unversioned.GroupVersion) *unversioned.APIGroupLis
 func NewClient(objects ...runtime.Object) *client.Client {
 	c := newClient(objects...)
 	c.APIVersions = makeVersionsList(v1beta1.SchemeGroupVersion)
+	c.ServerVersion = &version.Info{Major: "1", Minor: "5"}
+	c.Clientset.(*fake.Clientset).Resources = makeResources(v1beta1.SchemeGroupVersion, "StatefulSet")
 	return c
 }
 
 func NewClient1_4(objects ...runtime.Object) *client.Client {
 	c := newClient(objects...)
 	c.APIVersions = makeVersionsList(v1alpha1.SchemeGroupVersion)
+	c.ServerVersion = &version.Info{Major: "1", Minor: "4"}
+	c.Clientset.(*fake.Clientset).Resources = makeResources(v1alpha1.SchemeGroupVersion, "PetSet")
 	return c
 }
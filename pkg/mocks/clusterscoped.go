@@ -0,0 +1,62 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocks
+
+import (
+	"strings"
+
+	"k8s.io/client-go/pkg/api/v1"
+	rbacalpha1 "k8s.io/client-go/pkg/apis/rbac/v1alpha1"
+	storagebeta1 "k8s.io/client-go/pkg/apis/storage/v1beta1"
+)
+
+// MakeNamespace returns active namespace
+func MakeNamespace(name string) *v1.Namespace {
+	namespace := &v1.Namespace{}
+	namespace.Name = name
+	namespace.Status.Phase = v1.NamespaceActive
+	return namespace
+}
+
+// MakePersistentVolume returns persistent volume available for claims, unless its name starts with
+// "pending-" or "failed-"
+func MakePersistentVolume(name string) *v1.PersistentVolume {
+	persistentVolume := &v1.PersistentVolume{}
+	persistentVolume.Name = name
+	switch {
+	case strings.HasPrefix(name, "pending-"):
+		persistentVolume.Status.Phase = v1.VolumePending
+	case strings.HasPrefix(name, "failed-"):
+		persistentVolume.Status.Phase = v1.VolumeFailed
+		persistentVolume.Status.Message = "recycling failed"
+	default:
+		persistentVolume.Status.Phase = v1.VolumeAvailable
+	}
+	return persistentVolume
+}
+
+// MakeStorageClass returns storage class
+func MakeStorageClass(name string) *storagebeta1.StorageClass {
+	storageClass := &storagebeta1.StorageClass{}
+	storageClass.Name = name
+	return storageClass
+}
+
+// MakeClusterRole returns cluster role
+func MakeClusterRole(name string) *rbacalpha1.ClusterRole {
+	clusterRole := &rbacalpha1.ClusterRole{}
+	clusterRole.Name = name
+	return clusterRole
+}
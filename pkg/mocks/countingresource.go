@@ -17,6 +17,8 @@ package mocks
 import (
 	"time"
 
+	"golang.org/x/net/context"
+
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
 	"github.com/Mirantis/k8s-AppController/pkg/report"
@@ -26,7 +28,7 @@ import (
 // It also increases the counter when started and decreases it when becomes ready
 type CountingResource struct {
 	key       string
-	status    string
+	status    interfaces.ResourceStatus
 	counter   *CounterWithMemo
 	timeout   time.Duration
 	startTime time.Time
@@ -38,25 +40,30 @@ func (c CountingResource) Key() string {
 }
 
 // Status returns a status of the CountingResource. It also updates the status
-// after provided timeout and decrements counter
-func (c *CountingResource) Status(meta map[string]string) (string, error) {
-	if time.Since(c.startTime) >= c.timeout && c.status != "ready" {
+// after provided timeout and decrements counter. Resource is not ready until it is created
+func (c *CountingResource) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	if !c.startTime.IsZero() && time.Since(c.startTime) >= c.timeout && c.status.Phase != interfaces.ResourceReady {
 		c.counter.Dec()
-		c.status = "ready"
+		c.status = interfaces.NewStatus(interfaces.ResourceReady)
 	}
 
 	return c.status, nil
 }
 
 // Create increments counter and sets creation time
-func (c *CountingResource) Create() error {
+func (c *CountingResource) Create(ctx context.Context) error {
 	c.counter.Inc()
 	c.startTime = time.Now()
 	return nil
 }
 
 // Delete does nothing
-func (c *CountingResource) Delete() error {
+func (c *CountingResource) Delete(ctx context.Context) error {
+	return nil
+}
+
+// Update does nothing
+func (c *CountingResource) Update(ctx context.Context) error {
 	return nil
 }
 
@@ -89,7 +96,7 @@ func (c *CountingResource) StatusIsCacheable(meta map[string]string) bool {
 func NewCountingResource(key string, counter *CounterWithMemo, timeout time.Duration) *CountingResource {
 	return &CountingResource{
 		key:     key,
-		status:  "not ready",
+		status:  interfaces.NewStatus(interfaces.ResourceNotReady),
 		counter: counter,
 		timeout: timeout,
 	}
@@ -15,30 +15,45 @@
 package mocks
 
 import (
+	"sync"
+
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	"k8s.io/client-go/pkg/api"
 )
 
 type Dependency struct {
+	// Name is a name of the Dependency object, it may be empty
+	Name   string
 	Parent string
 	Child  string
 	Meta   map[string]string
+	// Status is a status the Dependency object has before it is updated through the client
+	Status *client.DependencyStatus
 }
 
 type dependencyClient struct {
 	dependencies []Dependency
+	// statuses are statuses of dependencies updated by name
+	statuses map[string]client.DependencyStatus
+	sync.Mutex
 }
 
 func (d *dependencyClient) List(opts api.ListOptions) (*client.DependencyList, error) {
 	list := &client.DependencyList{}
 
 	for _, dep := range d.dependencies {
+		meta := dep.Meta
+		if meta == nil {
+			meta = make(map[string]string)
+		}
 		list.Items = append(
 			list.Items,
 			client.Dependency{
-				Parent: dep.Parent,
-				Child:  dep.Child,
-				Meta:   make(map[string]string),
+				ObjectMeta: api.ObjectMeta{Name: dep.Name},
+				Parent:     dep.Parent,
+				Child:      dep.Child,
+				Meta:       meta,
+				Status:     dep.Status,
 			},
 		)
 	}
@@ -54,6 +69,25 @@ func (d *dependencyClient) Delete(_ string, _ *api.DeleteOptions) error {
 	panic("Not implemented")
 }
 
+func (d *dependencyClient) UpdateStatus(name string, status *client.DependencyStatus) error {
+	d.Lock()
+	defer d.Unlock()
+	d.statuses[name] = *status
+	return nil
+}
+
+// DependencyStatuses returns statuses of dependencies updated through the client by name
+func DependencyStatuses(c client.DependenciesInterface) map[string]client.DependencyStatus {
+	d := c.(*dependencyClient)
+	d.Lock()
+	defer d.Unlock()
+	result := make(map[string]client.DependencyStatus, len(d.statuses))
+	for name, status := range d.statuses {
+		result[name] = status
+	}
+	return result
+}
+
 func NewDependencyClient(dependencies ...Dependency) client.DependenciesInterface {
-	return &dependencyClient{dependencies}
+	return &dependencyClient{dependencies: dependencies, statuses: map[string]client.DependencyStatus{}}
 }
@@ -14,7 +14,10 @@
 
 package mocks
 
-import extbeta1 "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+import (
+	"k8s.io/client-go/pkg/api/v1"
+	extbeta1 "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
 
 type deploymentClient struct {
 }
@@ -24,7 +27,7 @@ func MakeDeployment(name string) *extbeta1.Deployment {
 	deployment := &extbeta1.Deployment{}
 	deployment.Name = name
 	deployment.Namespace = "testing"
-	deployment.Spec.Replicas = pointer(int32(3))
+	deployment.Spec.Replicas = Pointer(int32(3))
 	if name == "fail" {
 		deployment.Status.UpdatedReplicas = int32(2)
 		deployment.Status.AvailableReplicas = int32(3)
@@ -36,5 +39,26 @@ func MakeDeployment(name string) *extbeta1.Deployment {
 		deployment.Status.AvailableReplicas = int32(3)
 	}
 
+	switch name {
+	case "notobserved":
+		deployment.Generation = 2
+		deployment.Status.ObservedGeneration = 1
+	case "replicafailure":
+		deployment.Status.Conditions = append(
+			deployment.Status.Conditions,
+			extbeta1.DeploymentCondition{
+				Type:    extbeta1.DeploymentReplicaFailure,
+				Status:  v1.ConditionTrue,
+				Reason:  "FailedCreate",
+				Message: "quota exceeded",
+			},
+		)
+	case "unavailable":
+		deployment.Status.Conditions = append(
+			deployment.Status.Conditions,
+			extbeta1.DeploymentCondition{Type: extbeta1.DeploymentAvailable, Status: v1.ConditionFalse},
+		)
+	}
+
 	return deployment
 }
@@ -31,6 +31,18 @@ func MakeJob(name string) *batchapiv1.Job {
 			job.Status.Conditions,
 			batchapiv1.JobCondition{Type: "Complete", Status: "True"},
 		)
+	} else if status == "partial" {
+		job.Spec.Completions = Pointer(int32(5))
+		job.Status.Succeeded = int32(4)
+		job.Status.Failed = int32(1)
+	} else if status == "running" {
+		job.Status.Active = int32(1)
+	} else if status == "failed" {
+		job.Status.Failed = int32(1)
+		job.Status.Conditions = append(
+			job.Status.Conditions,
+			batchapiv1.JobCondition{Type: "Failed", Status: "True", Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"},
+		)
 	}
 
 	return job
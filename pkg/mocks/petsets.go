@@ -21,7 +21,7 @@ func MakePetSet(name string) *appsalpha1.PetSet {
 	petSet := &appsalpha1.PetSet{}
 	petSet.Name = name
 	petSet.Namespace = "testing"
-	petSet.Spec.Replicas = pointer(int32(3))
+	petSet.Spec.Replicas = Pointer(int32(3))
 	petSet.Spec.Template.ObjectMeta.Labels = make(map[string]string)
 	if name == "fail" {
 		petSet.Spec.Template.ObjectMeta.Labels["failedpod"] = "yes"
@@ -31,6 +31,15 @@ func MakePod(name string) *v1.Pod {
 			pod.Status.Conditions,
 			v1.PodCondition{Type: "Ready", Status: "True"},
 		)
+	} else if status == "crashloop" || status == "imagepull" {
+		reason := map[string]string{"crashloop": "CrashLoopBackOff", "imagepull": "ImagePullBackOff"}[status]
+		pod.Status.Phase = "Pending"
+		pod.Status.ContainerStatuses = []v1.ContainerStatus{
+			{
+				Name:  "app",
+				State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: reason, Message: "back-off"}},
+			},
+		}
 	} else {
 		pod.Status.Phase = "Pending"
 	}
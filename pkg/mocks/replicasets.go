@@ -20,9 +20,11 @@ func MakeReplicaSet(name string) *extbeta1.ReplicaSet {
 	replicaSet := &extbeta1.ReplicaSet{}
 	replicaSet.Name = name
 	replicaSet.Namespace = "testing"
-	replicaSet.Spec.Replicas = pointer(int32(2))
+	replicaSet.Spec.Replicas = Pointer(int32(2))
 	if name != "fail" {
 		replicaSet.Status.Replicas = int32(3)
+		replicaSet.Status.ReadyReplicas = int32(3)
+		replicaSet.Status.AvailableReplicas = int32(3)
 	}
 
 	return replicaSet
@@ -19,6 +19,8 @@ import (
 	"strings"
 
 	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/api/meta"
+	"k8s.io/client-go/pkg/runtime"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 )
@@ -36,36 +38,75 @@ func (r *resDefClient) List(opts api.ListOptions) (*client.ResourceDefinitionLis
 		splitted := strings.Split(name, "/")
 		objectType := splitted[0]
 		n := strings.Join(splitted[1:], "/")
+		// name may be qualified with namespace of the object, e.g. pod/name@namespace
+		namespace := ""
+		if i := strings.LastIndex(n, "@"); i >= 0 {
+			n, namespace = n[:i], n[i+1:]
+		}
 
+		var object runtime.Object
 		switch objectType {
 		case "pod":
 			rd.Pod = MakePod(n)
+			object = rd.Pod
 		case "job":
 			rd.Job = MakeJob(n)
+			object = rd.Job
 		case "service":
 			rd.Service = MakeService(n)
+			object = rd.Service
 		case "replicaset":
 			rd.ReplicaSet = MakeReplicaSet(n)
+			object = rd.ReplicaSet
 		case "statefulset":
 			rd.StatefulSet = MakeStatefulSet(n)
+			object = rd.StatefulSet
 		case "petset":
 			rd.PetSet = MakePetSet(n)
+			object = rd.PetSet
 		case "daemonset":
 			rd.DaemonSet = MakeDaemonSet(n)
+			object = rd.DaemonSet
 		case "configmap":
 			rd.ConfigMap = MakeConfigMap(n)
+			object = rd.ConfigMap
 		case "secret":
 			rd.Secret = MakeSecret(n)
+			object = rd.Secret
 		case "deployment":
 			rd.Deployment = MakeDeployment(n)
+			object = rd.Deployment
 		case "persistentvolumeclaim":
 			rd.PersistentVolumeClaim = MakePersistentVolumeClaim(n)
+			object = rd.PersistentVolumeClaim
 		case "serviceaccount":
 			rd.ServiceAccount = MakeServiceAccount(n)
+			object = rd.ServiceAccount
+		case "namespace":
+			rd.NamespaceObject = MakeNamespace(n)
+			object = rd.NamespaceObject
+		case "persistentvolume":
+			rd.PersistentVolume = MakePersistentVolume(n)
+			object = rd.PersistentVolume
+		case "storageclass":
+			rd.StorageClass = MakeStorageClass(n)
+			object = rd.StorageClass
+		case "clusterrole":
+			rd.ClusterRole = MakeClusterRole(n)
+			object = rd.ClusterRole
 		default:
 			log.Fatal("Unrecognized resource type for name ", objectType)
 		}
 
+		if namespace != "" {
+			rd.Meta = map[string]interface{}{"namespace": namespace}
+			accessor, err := meta.Accessor(object)
+			if err != nil {
+				return nil, err
+			}
+			accessor.SetNamespace(namespace)
+		}
+
 		list.Items = append(list.Items, rd)
 	}
 
@@ -76,10 +117,20 @@ func (r *resDefClient) Create(_ *client.ResourceDefinition) (*client.ResourceDef
 	panic("Not implemented")
 }
 
+func (r *resDefClient) Get(_ string) (*client.ResourceDefinition, error) {
+	panic("Not implemented")
+}
+
+func (r *resDefClient) Update(_ *client.ResourceDefinition) (*client.ResourceDefinition, error) {
+	panic("Not implemented")
+}
+
 func (r *resDefClient) Delete(_ string, _ *api.DeleteOptions) error {
 	panic("Not implemented")
 }
 
+// NewResourceDefinitionClient returns client listing definitions of objects with given keys. Keys of objects
+// outside ac namespace are qualified with the namespace, e.g. pod/name@namespace
 func NewResourceDefinitionClient(names ...string) client.ResourceDefinitionsInterface {
 	return &resDefClient{names}
 }
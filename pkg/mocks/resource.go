@@ -15,6 +15,8 @@
 package mocks
 
 import (
+	"golang.org/x/net/context"
+
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
 	"github.com/Mirantis/k8s-AppController/pkg/report"
@@ -23,7 +25,7 @@ import (
 // Resource is a fake resource
 type Resource struct {
 	key    string
-	status string
+	status interfaces.ResourceStatus
 }
 
 // Key returns a key of the Resource
@@ -32,17 +34,22 @@ func (c Resource) Key() string {
 }
 
 // Status returns a status of the Resource
-func (c *Resource) Status(meta map[string]string) (string, error) {
+func (c *Resource) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
 	return c.status, nil
 }
 
 // Create does nothing
-func (c *Resource) Create() error {
+func (c *Resource) Create(ctx context.Context) error {
 	return nil
 }
 
 // Delete does nothing
-func (c *Resource) Delete() error {
+func (c *Resource) Delete(ctx context.Context) error {
+	return nil
+}
+
+// Update does nothing
+func (c *Resource) Update(ctx context.Context) error {
 	return nil
 }
 
@@ -71,10 +78,10 @@ func (c *Resource) StatusIsCacheable(meta map[string]string) bool {
 	return true
 }
 
-// NewResource creates new instance of Resource
-func NewResource(key string, status string) *Resource {
+// NewResource creates new instance of Resource in given phase
+func NewResource(key string, phase interfaces.ResourcePhase) *Resource {
 	return &Resource{
 		key:    key,
-		status: status,
+		status: interfaces.NewStatus(phase),
 	}
 }
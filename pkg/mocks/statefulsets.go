@@ -19,7 +19,12 @@ import appsbeta1 "k8s.io/client-go/pkg/apis/apps/v1beta1"
 type statefulSetClient struct {
 }
 
-func pointer(i int32) *int32 {
+// Pointer returns a pointer to given int32 value
+func Pointer(i int32) *int32 {
+	return &i
+}
+
+func pointer64(i int64) *int64 {
 	return &i
 }
 
@@ -28,14 +33,21 @@ func MakeStatefulSet(name string) *appsbeta1.StatefulSet {
 	statefulSet := &appsbeta1.StatefulSet{}
 	statefulSet.Name = name
 	statefulSet.Namespace = "testing"
-	statefulSet.Spec.Replicas = pointer(int32(3))
+	statefulSet.Spec.Replicas = Pointer(int32(3))
 	statefulSet.Spec.Template.ObjectMeta.Labels = make(map[string]string)
 	if name == "fail" {
 		statefulSet.Spec.Template.ObjectMeta.Labels["failedpod"] = "yes"
 		statefulSet.Status.Replicas = int32(2)
+	} else if name == "scaling" {
+		statefulSet.Status.Replicas = int32(2)
 	} else {
 		statefulSet.Status.Replicas = int32(3)
 	}
 
+	if name == "notobserved" {
+		statefulSet.Generation = 2
+		statefulSet.Status.ObservedGeneration = pointer64(1)
+	}
+
 	return statefulSet
 }
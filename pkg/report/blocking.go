@@ -0,0 +1,124 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+)
+
+// BlockingCause is a resource which blocks a node without being blocked itself, so it has to become
+// ready, or be fixed, before the node can be created
+type BlockingCause struct {
+	Key string `json:"key"`
+	// Status is the status of the resource, it is empty if the resource is not in the report
+	Status interfaces.ResourceStatus `json:"status"`
+	// Message is the message of dependency report of the resource
+	Message string `json:"message"`
+	// Path are keys of resources from the cause to the blocked node, both included
+	Path []string `json:"path"`
+}
+
+// BlockingAnalysis tells what exactly is blocking a node: the deepest not ready ancestors on every
+// path of blocking dependencies leading to it
+type BlockingAnalysis struct {
+	Key     string          `json:"key"`
+	Ready   bool            `json:"ready"`
+	Blocked bool            `json:"blocked"`
+	Causes  []BlockingCause `json:"causes,omitempty"`
+}
+
+// AnalyzeBlocking walks blocking dependencies of the node with given key up the graph, breadth first,
+// and returns resources where the walk stops: those which are blocking but are not blocked by anything
+// themselves. Every cause is reported once, with the shortest path to the node
+func (d DeploymentReport) AnalyzeBlocking(key string) (BlockingAnalysis, error) {
+	nodes := make(map[string]NodeReport, len(d))
+	for _, n := range d {
+		nodes[n.Dependent] = n
+	}
+	node, ok := nodes[key]
+	if !ok {
+		return BlockingAnalysis{}, fmt.Errorf("resource %s is not in the report", key)
+	}
+	analysis := BlockingAnalysis{Key: key, Ready: node.Ready, Blocked: node.Blocked}
+
+	// child is the next resource on the path to the node, message is the message of the dependency
+	// report of the resource
+	child := map[string]string{key: ""}
+	message := map[string]string{}
+	addCause := func(parent string) {
+		cause := BlockingCause{Key: parent, Message: message[parent]}
+		if parentNode, ok := nodes[parent]; ok {
+			cause.Status = parentNode.Status
+		}
+		for k := parent; k != ""; k = child[k] {
+			cause.Path = append(cause.Path, k)
+		}
+		analysis.Causes = append(analysis.Causes, cause)
+	}
+	queue := []string{key}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		blocking := false
+		for _, dependency := range nodes[current].Dependencies {
+			if !dependency.Blocks {
+				continue
+			}
+			blocking = true
+			parent := dependency.Dependency
+			if _, seen := child[parent]; seen {
+				continue
+			}
+			child[parent] = current
+			message[parent] = dependency.Message
+			if parentNode, ok := nodes[parent]; ok && parentNode.Blocked {
+				queue = append(queue, parent)
+				continue
+			}
+			addCause(parent)
+		}
+		// resource reported as blocked without blocking dependencies is where the walk stops
+		if !blocking && current != key {
+			addCause(current)
+		}
+	}
+	return analysis, nil
+}
+
+// AsText returns a human-readable representation of the analysis as a slice
+func (a BlockingAnalysis) AsText(indent int) []string {
+	if !a.Blocked {
+		state := "is not ready"
+		if a.Ready {
+			state = "is ready"
+		}
+		return Indent(indent, []string{fmt.Sprintf("Resource %s is not blocked and %s", a.Key, state)})
+	}
+	ret := []string{fmt.Sprintf("Resource %s is blocked by %d resource(s):", a.Key, len(a.Causes))}
+	for _, cause := range a.Causes {
+		message := cause.Message
+		if cause.Status.Phase != "" {
+			message = StatusMessage(cause.Status)
+		}
+		ret = append(ret, Indent(ReportIndentSize, []string{
+			fmt.Sprintf("%s: %s", cause.Key, message),
+			fmt.Sprintf("%sPath: %s", strings.Repeat(" ", ReportIndentSize), strings.Join(cause.Path, " -> ")),
+		})...)
+	}
+	return Indent(indent, ret)
+}
@@ -0,0 +1,86 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+)
+
+func blockingNode(key string, ready bool, dependencies ...interfaces.DependencyReport) NodeReport {
+	blocked := false
+	for _, d := range dependencies {
+		blocked = blocked || d.Blocks
+	}
+	status := interfaces.NewStatus(interfaces.ResourceReady)
+	if !ready {
+		status = interfaces.NewStatus(interfaces.ResourceNotReady).WithReason("Pending", key+" is pending")
+	}
+	return NodeReport{Dependent: key, Ready: ready, Blocked: blocked, Status: status, Dependencies: dependencies}
+}
+
+func blocks(key string) interfaces.DependencyReport {
+	return interfaces.DependencyReport{Dependency: key, Blocks: true, Message: key + " blocks"}
+}
+
+func ready(key string) interfaces.DependencyReport {
+	return interfaces.DependencyReport{Dependency: key, Percentage: 100, Needed: 100}
+}
+
+// TestAnalyzeBlocking checks that the deepest not ready ancestors on blocking paths are found
+func TestAnalyzeBlocking(t *testing.T) {
+	// configmap/a and job/b are not ready, service/c is ready; deployment/d depends on all of them
+	// through pod/e, which depends on configmap/a directly as well
+	d := DeploymentReport{
+		blockingNode("configmap/a", false),
+		blockingNode("job/b", false, ready("service/c")),
+		blockingNode("service/c", true),
+		blockingNode("pod/e", false, blocks("configmap/a"), blocks("job/b"), ready("service/c")),
+		blockingNode("deployment/d", false, blocks("pod/e"), blocks("configmap/a")),
+	}
+
+	analysis, err := d.AnalyzeBlocking("deployment/d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !analysis.Blocked || len(analysis.Causes) != 2 {
+		t.Fatalf("Expected two causes, got %+v", analysis)
+	}
+	if cause := analysis.Causes[0]; cause.Key != "configmap/a" || !reflect.DeepEqual(cause.Path, []string{"configmap/a", "deployment/d"}) {
+		t.Errorf("Expected configmap/a with the shortest path, got %+v", cause)
+	}
+	if cause := analysis.Causes[1]; cause.Key != "job/b" || !reflect.DeepEqual(cause.Path, []string{"job/b", "pod/e", "deployment/d"}) || cause.Status.Reason != "Pending" {
+		t.Errorf("Expected job/b through pod/e, got %+v", cause)
+	}
+	text := strings.Join(analysis.AsText(0), "\n")
+	if !strings.Contains(text, "job/b: Pending: job/b is pending") || !strings.Contains(text, "Path: job/b -> pod/e -> deployment/d") {
+		t.Errorf("Unexpected text %s", text)
+	}
+
+	analysis, err = d.AnalyzeBlocking("job/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analysis.Blocked || len(analysis.Causes) != 0 {
+		t.Errorf("job/b should not be blocked, got %+v", analysis)
+	}
+
+	if _, err = d.AnalyzeBlocking("pod/missing"); err == nil {
+		t.Error("Analysis of resource which is not in the report should fail")
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+)
+
+// diffContext is a number of unchanged lines shown around changes in unified diff
+const diffContext = 3
+
+// diffOp is a line of diff. Kind is ' ' for unchanged lines, '-' for lines removed from the first
+// text and '+' for lines added in the second one. fromPos and toPos are numbers of lines of both
+// texts before the line
+type diffOp struct {
+	kind           byte
+	text           string
+	fromPos, toPos int
+}
+
+// diffLines returns operations turning from into to, based on their longest common subsequence
+func diffLines(from, to []string) []diffOp {
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(from) || j < len(to) {
+		switch {
+		case i < len(from) && j < len(to) && from[i] == to[j]:
+			ops = append(ops, diffOp{' ', from[i], i, j})
+			i++
+			j++
+		case j == len(to) || (i < len(from) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', from[i], i, j})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', to[j], i, j})
+			j++
+		}
+	}
+	return ops
+}
+
+// UnifiedDiff returns lines of unified diff between texts given as lines, or nil if they are equal
+func UnifiedDiff(fromName, toName string, from, to []string) []string {
+	ops := diffLines(from, to)
+	var changes []int
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	result := []string{"--- " + fromName, "+++ " + toName}
+	for first := 0; first < len(changes); {
+		last := first
+		for last+1 < len(changes) && changes[last+1]-changes[last] <= 2*diffContext+1 {
+			last++
+		}
+		start := changes[first] - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := changes[last] + diffContext + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+		result = append(result, hunk(ops[start:end])...)
+		first = last + 1
+	}
+	return result
+}
+
+// hunk returns lines of diff hunk with its header
+func hunk(ops []diffOp) []string {
+	fromCount, toCount := 0, 0
+	lines := make([]string, 0, len(ops)+1)
+	lines = append(lines, "")
+	for _, op := range ops {
+		if op.kind != '+' {
+			fromCount++
+		}
+		if op.kind != '-' {
+			toCount++
+		}
+		lines = append(lines, string(op.kind)+op.text)
+	}
+	fromStart, toStart := ops[0].fromPos, ops[0].toPos
+	if fromCount > 0 {
+		fromStart++
+	}
+	if toCount > 0 {
+		toStart++
+	}
+	lines[0] = fmt.Sprintf("@@ -%d,%d +%d,%d @@", fromStart, fromCount, toStart, toCount)
+	return lines
+}
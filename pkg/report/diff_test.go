@@ -0,0 +1,52 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestUnifiedDiff checks hunks of diff with changes far apart
+func TestUnifiedDiff(t *testing.T) {
+	from := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+	to := []string{"a", "B", "c", "d", "e", "f", "g", "h", "i", "j", "k"}
+	expected := []string{
+		"--- live",
+		"+++ definition",
+		"@@ -1,5 +1,5 @@",
+		" a",
+		"-b",
+		"+B",
+		" c",
+		" d",
+		" e",
+		"@@ -8,3 +8,4 @@",
+		" h",
+		" i",
+		" j",
+		"+k",
+	}
+	if diff := UnifiedDiff("live", "definition", from, to); !reflect.DeepEqual(diff, expected) {
+		t.Errorf("Expected diff %v, got %v", expected, diff)
+	}
+}
+
+// TestUnifiedDiffEqual checks that equal texts have no diff
+func TestUnifiedDiffEqual(t *testing.T) {
+	if diff := UnifiedDiff("a", "b", []string{"x", "y"}, []string{"x", "y"}); diff != nil {
+		t.Errorf("Expected no diff, got %v", diff)
+	}
+}
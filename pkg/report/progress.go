@@ -0,0 +1,242 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProgressNode is a resource of the graph as seen by progress estimation
+type ProgressNode struct {
+	Key string
+	// Requires are keys of resources the node depends on
+	Requires []string
+	// Completed is true if the resource is ready or was skipped
+	Completed bool
+	Failed    bool
+	// Elapsed is time since creation of the resource started in the current run, zero if it did not start
+	Elapsed time.Duration
+}
+
+// Durations are times resources took to become ready in previous runs, by resource key
+type Durations map[string]time.Duration
+
+// Estimate is a progress of a set of resources with estimated time until all of them are ready
+type Estimate struct {
+	Completed  int `json:"completed"`
+	Failed     int `json:"failed"`
+	Total      int `json:"total"`
+	Percentage int `json:"percentage"`
+	// RemainingSeconds is a length of the critical path, i.e. the longest chain of resources which are
+	// not ready yet, according to their durations in previous runs
+	RemainingSeconds int `json:"remainingSeconds"`
+	// CriticalPath are keys of resources of the critical path, starting from the first one to be created
+	CriticalPath []string `json:"criticalPath"`
+	// Unestimated are keys of resources which are not ready and have no recorded durations. If there
+	// are any, the remaining time is a lower bound
+	Unestimated []string `json:"unestimated,omitempty"`
+}
+
+// BranchProgress is a progress of a branch of the graph: a resource nothing depends on, together with
+// all resources it depends on directly or indirectly
+type BranchProgress struct {
+	Leaf string `json:"leaf"`
+	Estimate
+}
+
+// GraphProgress is an overall progress of the graph with breakdown by branches
+type GraphProgress struct {
+	Estimate
+	Branches []BranchProgress `json:"branches"`
+}
+
+// progressEstimator computes times remaining until resources are ready
+type progressEstimator struct {
+	nodes     map[string]ProgressNode
+	durations Durations
+	// finish are times remaining until resources are ready, including their dependencies
+	finish map[string]time.Duration
+}
+
+// remaining returns time remaining until the resource is ready, not counting its dependencies, and
+// false if it is unknown
+func (e *progressEstimator) remaining(node ProgressNode) (time.Duration, bool) {
+	if node.Completed || node.Failed {
+		return 0, true
+	}
+	duration, ok := e.durations[node.Key]
+	if duration -= node.Elapsed; duration < 0 {
+		duration = 0
+	}
+	return duration, ok
+}
+
+// finishTime returns time remaining until the resource and all its dependencies are ready
+func (e *progressEstimator) finishTime(key string) time.Duration {
+	if finish, ok := e.finish[key]; ok {
+		return finish
+	}
+	// dependency cycles are not followed
+	e.finish[key] = 0
+	node := e.nodes[key]
+	var dependencies time.Duration
+	if !node.Completed {
+		for _, req := range node.Requires {
+			if _, ok := e.nodes[req]; ok {
+				if finish := e.finishTime(req); finish > dependencies {
+					dependencies = finish
+				}
+			}
+		}
+	}
+	own, _ := e.remaining(node)
+	e.finish[key] = own + dependencies
+	return e.finish[key]
+}
+
+// estimate returns progress of resources with given keys
+func (e *progressEstimator) estimate(keys []string) Estimate {
+	result := Estimate{Total: len(keys), CriticalPath: []string{}, Percentage: 100}
+	var last string
+	for _, key := range keys {
+		node := e.nodes[key]
+		switch {
+		case node.Completed:
+			result.Completed++
+		case node.Failed:
+			result.Failed++
+		default:
+			if _, ok := e.remaining(node); !ok {
+				result.Unestimated = append(result.Unestimated, key)
+			}
+			if last == "" || e.finishTime(key) > e.finishTime(last) {
+				last = key
+			}
+		}
+	}
+	if result.Total > 0 {
+		result.Percentage = result.Completed * 100 / result.Total
+	}
+	if last == "" {
+		return result
+	}
+	result.RemainingSeconds = int((e.finishTime(last) + time.Second - 1) / time.Second)
+
+	// the path is followed back from its last resource through dependencies finishing last
+	visited := map[string]bool{}
+	for key := last; key != "" && !visited[key]; {
+		visited[key] = true
+		result.CriticalPath = append([]string{key}, result.CriticalPath...)
+		next := ""
+		for _, req := range e.nodes[key].Requires {
+			if node, ok := e.nodes[req]; !ok || node.Completed || node.Failed {
+				continue
+			}
+			if next == "" || e.finishTime(req) > e.finishTime(next) {
+				next = req
+			}
+		}
+		key = next
+	}
+	return result
+}
+
+// ancestors returns sorted keys of the resource and all resources it depends on
+func (e *progressEstimator) ancestors(key string) []string {
+	seen := map[string]bool{}
+	var visit func(key string)
+	visit = func(key string) {
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		for _, req := range e.nodes[key].Requires {
+			if _, ok := e.nodes[req]; ok {
+				visit(req)
+			}
+		}
+	}
+	visit(key)
+	return sortedKeys(seen)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// EstimateProgress returns progress of the graph of given resources. Time remaining is estimated by
+// the critical path of the graph, assuming every resource takes as long to become ready as in
+// previous runs, and resources without recorded durations are ready instantly
+func EstimateProgress(nodes []ProgressNode, durations Durations) GraphProgress {
+	e := &progressEstimator{
+		nodes:     make(map[string]ProgressNode, len(nodes)),
+		durations: durations,
+		finish:    make(map[string]time.Duration, len(nodes)),
+	}
+	all := map[string]bool{}
+	leaves := map[string]bool{}
+	for _, node := range nodes {
+		e.nodes[node.Key] = node
+		all[node.Key] = true
+		leaves[node.Key] = true
+	}
+	for _, node := range nodes {
+		for _, req := range node.Requires {
+			delete(leaves, req)
+		}
+	}
+
+	result := GraphProgress{Estimate: e.estimate(sortedKeys(all)), Branches: []BranchProgress{}}
+	for _, leaf := range sortedKeys(leaves) {
+		result.Branches = append(result.Branches, BranchProgress{Leaf: leaf, Estimate: e.estimate(e.ancestors(leaf))})
+	}
+	return result
+}
+
+// AsText returns a human-readable summary of the estimate
+func (e Estimate) AsText() string {
+	text := fmt.Sprintf("%d/%d ready (%d%%)", e.Completed, e.Total, e.Percentage)
+	if e.Failed > 0 {
+		text += fmt.Sprintf(", %d failed", e.Failed)
+	}
+	if len(e.CriticalPath) == 0 {
+		return text
+	}
+	remaining := (time.Duration(e.RemainingSeconds) * time.Second).String()
+	if len(e.Unestimated) > 0 {
+		remaining = "at least " + remaining
+	}
+	return fmt.Sprintf("%s, %s remaining, critical path: %s", text, remaining, strings.Join(e.CriticalPath, " -> "))
+}
+
+// AsText returns a human-readable representation of the progress as a slice
+func (p GraphProgress) AsText(indent int) []string {
+	ret := []string{"Graph: " + p.Estimate.AsText()}
+	if len(p.Unestimated) > 0 {
+		ret = append(ret, "No recorded durations: "+strings.Join(p.Unestimated, ", "))
+	}
+	for _, b := range p.Branches {
+		ret = append(ret, Indent(ReportIndentSize, []string{fmt.Sprintf("Branch %s: %s", b.Leaf, b.Estimate.AsText())})...)
+	}
+	return Indent(indent, ret)
+}
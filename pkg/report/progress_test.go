@@ -0,0 +1,84 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestEstimateProgress checks critical path and branches of graph where db is needed by two
+// branches, one of which is ready
+func TestEstimateProgress(t *testing.T) {
+	nodes := []ProgressNode{
+		{Key: "configmap/cfg", Completed: true},
+		{Key: "pod/db", Requires: []string{"configmap/cfg"}, Elapsed: 10 * time.Second},
+		{Key: "job/migrate", Requires: []string{"pod/db"}},
+		{Key: "pod/web", Requires: []string{"job/migrate", "configmap/cfg"}},
+		{Key: "pod/cache", Completed: true},
+		{Key: "service/cache", Requires: []string{"pod/cache"}, Completed: true},
+	}
+	durations := Durations{
+		"pod/db":      30 * time.Second,
+		"job/migrate": time.Minute,
+		"pod/web":     15 * time.Second,
+		"pod/cache":   time.Hour,
+	}
+	progress := EstimateProgress(nodes, durations)
+
+	if progress.Completed != 3 || progress.Total != 6 || progress.Percentage != 50 {
+		t.Errorf("Unexpected overall progress %+v", progress.Estimate)
+	}
+	// pod/cache takes longest, but it is ready already
+	expectedPath := []string{"pod/db", "job/migrate", "pod/web"}
+	if !reflect.DeepEqual(progress.CriticalPath, expectedPath) {
+		t.Errorf("Expected critical path %v, got %v", expectedPath, progress.CriticalPath)
+	}
+	if progress.RemainingSeconds != 95 {
+		t.Errorf("Expected 95 seconds remaining, got %d", progress.RemainingSeconds)
+	}
+
+	if len(progress.Branches) != 2 {
+		t.Fatalf("Expected 2 branches, got %+v", progress.Branches)
+	}
+	web, cache := progress.Branches[0], progress.Branches[1]
+	if cache.Leaf != "service/cache" || web.Leaf != "pod/web" {
+		t.Fatalf("Unexpected branches %+v", progress.Branches)
+	}
+	if cache.Total != 2 || cache.Completed != 2 || cache.RemainingSeconds != 0 {
+		t.Errorf("Unexpected progress of cache branch %+v", cache)
+	}
+	if web.Total != 4 || web.Completed != 1 || web.RemainingSeconds != 95 {
+		t.Errorf("Unexpected progress of web branch %+v", web)
+	}
+}
+
+// TestEstimateProgressUnknownDurations checks that resources without history are reported
+func TestEstimateProgressUnknownDurations(t *testing.T) {
+	nodes := []ProgressNode{
+		{Key: "pod/a"},
+		{Key: "pod/b", Requires: []string{"pod/a"}},
+		{Key: "pod/c", Failed: true},
+	}
+	progress := EstimateProgress(nodes, Durations{"pod/a": time.Minute})
+
+	if progress.Failed != 1 || progress.RemainingSeconds != 60 {
+		t.Errorf("Unexpected progress %+v", progress.Estimate)
+	}
+	if !reflect.DeepEqual(progress.Unestimated, []string{"pod/b"}) {
+		t.Errorf("Expected pod/b to have no estimate, got %v", progress.Unestimated)
+	}
+}
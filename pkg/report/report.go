@@ -4,16 +4,22 @@ import (
 	"fmt"
 	"strings"
 
+	"golang.org/x/net/context"
+
 	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
 )
 
 const ReportIndentSize = 4
 
-// NodeReport is a report of a node in graph
+// NodeReport is a report of a node in graph. Skipped is true if the node was skipped in the last run,
+// e.g. as a part of the subtree of node which failed with on-failure policy set to skip
 type NodeReport struct {
-	Dependent    string
-	Blocked      bool
-	Ready        bool
+	Dependent string
+	Blocked   bool
+	Ready     bool
+	Skipped   bool
+	// Status is the status of the resource itself, explaining why it is not ready
+	Status       interfaces.ResourceStatus
 	Dependencies []interfaces.DependencyReport
 }
 
@@ -28,6 +34,8 @@ func (n NodeReport) AsText(indent int) []string {
 
 	if n.Ready {
 		readyStr = "READY"
+	} else if n.Status.Reason != "" || n.Status.Message != "" {
+		readyStr = fmt.Sprintf("NOT READY (%s)", StatusMessage(n.Status))
 	} else {
 		readyStr = "NOT READY"
 	}
@@ -37,6 +45,9 @@ func (n NodeReport) AsText(indent int) []string {
 		blockedStr,
 		readyStr,
 	}
+	if n.Skipped {
+		ret = append(ret, "SKIPPED")
+	}
 	for _, dependency := range n.Dependencies {
 		ret = append(ret, dependencyReportAsText(dependency, ReportIndentSize)...)
 	}
@@ -61,26 +72,26 @@ type SimpleReporter struct {
 }
 
 // GetDependencyReport returns a dependency report for this reporter
-func (r SimpleReporter) GetDependencyReport(meta map[string]string) interfaces.DependencyReport {
-	status, err := r.Status(meta)
+func (r SimpleReporter) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	status, err := r.Status(ctx, meta)
 	if err != nil {
 		return ErrorReport(r.Key(), err)
 	}
-	if status == "ready" {
+	if status.IsReady() {
 		return interfaces.DependencyReport{
 			Dependency: r.Key(),
 			Blocks:     false,
 			Percentage: 100,
 			Needed:     100,
-			Message:    status,
+			Message:    status.String(),
 		}
 	}
 	return interfaces.DependencyReport{
 		Dependency: r.Key(),
 		Blocks:     true,
-		Percentage: 0,
+		Percentage: status.Progress,
 		Needed:     0,
-		Message:    status,
+		Message:    StatusMessage(status),
 	}
 }
 
@@ -89,6 +100,20 @@ func (r SimpleReporter) GetResource() interfaces.BaseResource {
 	return r.BaseResource
 }
 
+// StatusMessage returns human-readable explanation of the status: its message or reason if they are
+// known, or the phase otherwise
+func StatusMessage(status interfaces.ResourceStatus) string {
+	switch {
+	case status.Reason != "" && status.Message != "":
+		return fmt.Sprintf("%s: %s", status.Reason, status.Message)
+	case status.Message != "":
+		return status.Message
+	case status.Reason != "":
+		return status.Reason
+	}
+	return status.String()
+}
+
 // ErrorReport creates a report for error cases
 func ErrorReport(name string, err error) interfaces.DependencyReport {
 	return interfaces.DependencyReport{
@@ -129,5 +154,9 @@ func dependencyReportAsText(d interfaces.DependencyReport, indent int) []string
 	if percStr != "" {
 		ret = append(ret, percStr)
 	}
+	if d.Logs != "" {
+		ret = append(ret, "Logs:")
+		ret = append(ret, Indent(ReportIndentSize, strings.Split(d.Logs, "\n"))...)
+	}
 	return Indent(indent, ret)
 }
@@ -0,0 +1,136 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+)
+
+// Outcome is a result of the whole graph run
+type Outcome string
+
+// Possible values for Outcome
+const (
+	// OutcomeSucceeded means that every resource was created or skipped by its failure policy
+	OutcomeSucceeded Outcome = "succeeded"
+	// OutcomePartialFailure means that some resources failed, independent branches may be created
+	OutcomePartialFailure Outcome = "partial-failure"
+	// OutcomeValidationError means that the graph was not run because it is invalid
+	OutcomeValidationError Outcome = "validation-error"
+	// OutcomeTimeout means that deadline of the run or of some resources was exceeded
+	OutcomeTimeout Outcome = "timeout"
+)
+
+// Process exit codes for outcomes of the run. Other errors, e.g. failure to connect to the cluster,
+// exit with code 1
+const (
+	ExitSuccess         = 0
+	ExitPartialFailure  = 2
+	ExitValidationError = 3
+	ExitTimeout         = 4
+)
+
+// ExitCode returns process exit code for the outcome
+func (o Outcome) ExitCode() int {
+	switch o {
+	case OutcomePartialFailure:
+		return ExitPartialFailure
+	case OutcomeValidationError:
+		return ExitValidationError
+	case OutcomeTimeout:
+		return ExitTimeout
+	}
+	return ExitSuccess
+}
+
+// Possible values of NodeResult state
+const (
+	NodeCreated = "created"
+	NodeSkipped = "skipped"
+	NodeFailed  = "failed"
+	// NodeInProgress resources are being created or waited for, it is reported only during the run
+	NodeInProgress = "in-progress"
+	// NodePending resources were neither created nor failed, e.g. because the run was aborted
+	NodePending = "pending"
+	// NodeDeferred resources wait for their deployment window to open
+	NodeDeferred = "deferred"
+)
+
+// NodeResult is a result of a single resource in the run. Dependencies are reported for resources
+// which were not created, to show what blocked them
+type NodeResult struct {
+	Key             string                        `json:"key"`
+	State           string                        `json:"state"`
+	DurationSeconds float64                       `json:"durationSeconds,omitempty"`
+	Error           string                        `json:"error,omitempty"`
+	Status          *interfaces.ResourceStatus    `json:"status,omitempty"`
+	Dependencies    []interfaces.DependencyReport `json:"dependencies,omitempty"`
+	// DeferredUntil is a time when deployment window of deferred resource opens
+	DeferredUntil *time.Time `json:"deferredUntil,omitempty"`
+}
+
+// RunReport is a machine-readable report of the whole graph run
+type RunReport struct {
+	Graph   string    `json:"graph"`
+	RunID   string    `json:"runID,omitempty"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Outcome Outcome   `json:"outcome"`
+	// Errors are errors of the run itself, e.g. validation problems
+	Errors  []string `json:"errors,omitempty"`
+	Created int      `json:"created"`
+	Skipped int      `json:"skipped"`
+	Failed  int      `json:"failed"`
+	Pending int      `json:"pending"`
+	// Deferred resources were waiting for their deployment windows when the run ended
+	Deferred int          `json:"deferred,omitempty"`
+	Nodes    []NodeResult `json:"nodes"`
+}
+
+// ExitCode returns process exit code for the outcome of the run
+func (r RunReport) ExitCode() int {
+	return r.Outcome.ExitCode()
+}
+
+// Write writes indented JSON of the report
+func (r RunReport) Write(w io.Writer) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WriteFile writes JSON of the report to the file, "-" means stdout
+func (r RunReport) WriteFile(path string) error {
+	if path == "-" {
+		return r.Write(os.Stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = r.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
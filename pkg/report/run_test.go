@@ -0,0 +1,57 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// TestOutcomeExitCode checks that every outcome has its own exit code
+func TestOutcomeExitCode(t *testing.T) {
+	cases := map[Outcome]int{
+		OutcomeSucceeded:       0,
+		OutcomePartialFailure:  2,
+		OutcomeValidationError: 3,
+		OutcomeTimeout:         4,
+	}
+	for outcome, expected := range cases {
+		if code := (RunReport{Outcome: outcome}).ExitCode(); code != expected {
+			t.Errorf("Expected exit code %d for %s, got %d", expected, outcome, code)
+		}
+	}
+}
+
+// TestRunReportWrite checks that written report can be parsed back
+func TestRunReportWrite(t *testing.T) {
+	runReport := RunReport{
+		Graph:   "test",
+		Outcome: OutcomePartialFailure,
+		Failed:  1,
+		Nodes:   []NodeResult{{Key: "pod/1", State: NodeFailed, Error: "failed"}},
+	}
+	var buf bytes.Buffer
+	if err := runReport.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var parsed RunReport
+	if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Outcome != OutcomePartialFailure || len(parsed.Nodes) != 1 || parsed.Nodes[0].Error != "failed" {
+		t.Errorf("Unexpected report %+v", parsed)
+	}
+}
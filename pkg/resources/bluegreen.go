@@ -0,0 +1,170 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"golang.org/x/net/context"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// BlueGreenKey is a meta key of resource definition of the "green" workload. Once the workload is ready,
+// selector of the service is switched to its pods and, optionally, the "blue" workload is deleted
+const BlueGreenKey = "blue-green"
+
+// BlueGreen describes switching traffic of a service from the "blue" workload to the "green" one
+type BlueGreen struct {
+	// Service is a name of the service whose selector is switched
+	Service string `json:"service"`
+	// Selector is a new selector of the service, which selects pods of the green workload
+	Selector map[string]string `json:"selector"`
+	// Blue is a key of the workload serving the traffic before the switch, e.g. deployment/frontend-v1
+	Blue string `json:"blue,omitempty"`
+	// DeleteBlue makes the blue workload deleted once the traffic is switched
+	DeleteBlue bool `json:"deleteBlue,omitempty"`
+}
+
+// GetBlueGreen returns blue/green switch described in meta of the resource, or nil if it has none
+func GetBlueGreen(r interfaces.BaseResource) (*BlueGreen, error) {
+	value := r.Meta(BlueGreenKey)
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	blueGreen := &BlueGreen{}
+	if err := json.Unmarshal(data, blueGreen); err != nil {
+		return nil, fmt.Errorf("%s of %s is invalid: %v", BlueGreenKey, r.Key(), err)
+	}
+	if blueGreen.Service == "" || len(blueGreen.Selector) == 0 {
+		return nil, fmt.Errorf("%s of %s must set service and selector", BlueGreenKey, r.Key())
+	}
+	if blueGreen.DeleteBlue && blueGreen.Blue == "" {
+		return nil, fmt.Errorf("%s of %s must set blue workload to delete it", BlueGreenKey, r.Key())
+	}
+	return blueGreen, nil
+}
+
+// ServiceSwitch is a graph node which switches selector of the service to pods of the green workload.
+// It has no resource definition of its own, it is added for resources with blue-green meta
+type ServiceSwitch struct {
+	Base
+	Service  string
+	Selector map[string]string
+	Client   corev1.ServiceInterface
+}
+
+// ServiceSwitchKey returns key of graph node switching selector of the service with given name
+func ServiceSwitchKey(service string) string {
+	return "switch/" + serviceKey(service)
+}
+
+// NewServiceSwitch is a constructor for ServiceSwitch
+func NewServiceSwitch(blueGreen *BlueGreen, client corev1.ServiceInterface) interfaces.Resource {
+	return ServiceSwitch{Service: blueGreen.Service, Selector: blueGreen.Selector, Client: client}
+}
+
+// Key returns service switch key
+func (s ServiceSwitch) Key() string {
+	return ServiceSwitchKey(s.Service)
+}
+
+// Status returns ready if selector of the service is switched
+func (s ServiceSwitch) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	service, err := s.Client.Get(s.Service)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	if !reflect.DeepEqual(service.Spec.Selector, s.Selector) {
+		return interfaces.NewStatus(interfaces.ResourceNotReady).
+			WithReason("SelectorNotSwitched", fmt.Sprintf("service selects %v", service.Spec.Selector)), nil
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+// GetDependencyReport returns a DependencyReport for this ServiceSwitch
+func (s ServiceSwitch) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	status, err := s.Status(ctx, meta)
+	return statusReport(s.Key(), status, err, "traffic is switched")
+}
+
+// Create sets the new selector of the service
+func (s ServiceSwitch) Create(ctx context.Context) error {
+	service, err := s.Client.Get(s.Service)
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(service.Spec.Selector, s.Selector) {
+		return nil
+	}
+	logging.ForResource(s.Key()).Infof("Switching selector of %s from %v to %v", serviceKey(s.Service), service.Spec.Selector, s.Selector)
+	service.Spec.Selector = s.Selector
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	_, err = s.Client.Update(service)
+	return err
+}
+
+// Delete does nothing, the service is managed by its own resource
+func (s ServiceSwitch) Delete(ctx context.Context) error {
+	return nil
+}
+
+// StatusIsCacheable returns false, the selector may be changed by other graph runs
+func (s ServiceSwitch) StatusIsCacheable(meta map[string]string) bool {
+	return false
+}
+
+// GreenRollback is a graph node which deletes the green workload if it fails, so that the traffic stays
+// on the blue one and the broken workload does not linger
+type GreenRollback struct {
+	Deletion
+}
+
+// NewGreenRollback is a constructor for GreenRollback
+func NewGreenRollback(green interfaces.Resource) interfaces.Resource {
+	return GreenRollback{Deletion{Resource: green}}
+}
+
+// GreenRollbackKey returns key of graph node rolling back the green workload with given key
+func GreenRollbackKey(green string) string {
+	return "rollback/" + green
+}
+
+// Key returns green rollback key
+func (r GreenRollback) Key() string {
+	return GreenRollbackKey(r.Resource.Key())
+}
+
+// Meta returns nil, meta of the green workload does not apply to its rollback
+func (r GreenRollback) Meta(paramName string) interface{} {
+	return nil
+}
+
+// GetDependencyReport returns a DependencyReport for this GreenRollback
+func (r GreenRollback) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	depReport := r.Deletion.GetDependencyReport(ctx, meta)
+	depReport.Dependency = r.Key()
+	return depReport
+}
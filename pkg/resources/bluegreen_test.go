@@ -0,0 +1,45 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestGetBlueGreen checks parsing and validation of blue-green meta
+func TestGetBlueGreen(t *testing.T) {
+	c := mocks.NewClient()
+	cases := []struct {
+		value interface{}
+		valid bool
+	}{
+		{map[string]interface{}{"service": "frontend", "selector": map[string]interface{}{"version": "2"}}, true},
+		{map[string]interface{}{"service": "frontend"}, false},
+		{map[string]interface{}{"service": "frontend", "selector": map[string]interface{}{"version": "2"}, "deleteBlue": true}, false},
+		{"frontend", false},
+	}
+	for _, tc := range cases {
+		pod := NewPod(mocks.MakePod("green"), c.Pods(), map[string]interface{}{BlueGreenKey: tc.value})
+		blueGreen, err := GetBlueGreen(pod)
+		if tc.valid && (err != nil || blueGreen == nil) {
+			t.Errorf("Expected %v to be valid, got %v", tc.value, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("Expected %v to be invalid", tc.value)
+		}
+	}
+}
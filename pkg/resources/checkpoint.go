@@ -0,0 +1,190 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// CheckpointConfigMap is a name of config map keeping approvals of checkpoints
+const CheckpointConfigMap = "appcontroller-checkpoints"
+
+// CheckpointAnnotationPrefix is a prefix of config map annotations holding approvals. The annotation
+// of checkpoint is the prefix followed by checkpoint name
+const CheckpointAnnotationPrefix = "checkpoint.appcontroller.k8s/"
+
+// Possible values of checkpoint annotations
+const (
+	CheckpointPending  = "pending"
+	CheckpointApproved = "approved"
+	CheckpointRejected = "rejected"
+)
+
+// Checkpoint is a node which blocks its dependents until an operator approves it. Approvals are kept
+// in annotations of CheckpointConfigMap, so that they can be given with kubectl annotate as well as
+// through AppController API and CLI
+type Checkpoint struct {
+	Base
+	Checkpoint *client.Checkpoint
+	Client     corev1.ConfigMapInterface
+}
+
+func checkpointKey(name string) string {
+	return "checkpoint/" + name
+}
+
+// Key returns Checkpoint key
+func (c Checkpoint) Key() string {
+	return checkpointKey(c.Checkpoint.Name)
+}
+
+// checkpointApproval returns value of checkpoint annotation, or empty string if there is none
+func checkpointApproval(c corev1.ConfigMapInterface, name string) (string, error) {
+	configMap, err := c.Get(CheckpointConfigMap)
+	if errors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return configMap.Annotations[CheckpointAnnotationPrefix+name], nil
+}
+
+// checkpointStatus returns status of checkpoint with message for dependency report
+func checkpointStatus(c corev1.ConfigMapInterface, name string) (interfaces.ResourceStatus, string, error) {
+	approval, err := checkpointApproval(c, name)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), "", err
+	}
+	switch approval {
+	case CheckpointApproved:
+		return interfaces.NewStatus(interfaces.ResourceReady), "checkpoint is approved", nil
+	case CheckpointRejected:
+		return interfaces.NewStatus(interfaces.ResourceError), "", fmt.Errorf("%s was rejected", checkpointKey(name))
+	}
+	return interfaces.NewStatus(interfaces.ResourceNotReady), "checkpoint waits for approval", nil
+}
+
+// SetCheckpointApproval sets annotation of checkpoint with given name to the value, creating config
+// map of approvals if needed
+func SetCheckpointApproval(c corev1.ConfigMapInterface, name, value string) error {
+	annotation := CheckpointAnnotationPrefix + name
+	configMap, err := c.Get(CheckpointConfigMap)
+	if errors.IsNotFound(err) {
+		configMap = &v1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{
+				Name:        CheckpointConfigMap,
+				Annotations: map[string]string{annotation: value},
+			},
+		}
+		_, err = c.Create(configMap)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if configMap.Annotations == nil {
+		configMap.Annotations = map[string]string{}
+	}
+	configMap.Annotations[annotation] = value
+	_, err = c.Update(configMap)
+	return err
+}
+
+// PendingCheckpoints returns sorted names of checkpoints waiting for approval
+func PendingCheckpoints(c corev1.ConfigMapInterface) ([]string, error) {
+	configMap, err := c.Get(CheckpointConfigMap)
+	if errors.IsNotFound(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for annotation, value := range configMap.Annotations {
+		if strings.HasPrefix(annotation, CheckpointAnnotationPrefix) && value == CheckpointPending {
+			names = append(names, strings.TrimPrefix(annotation, CheckpointAnnotationPrefix))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Status returns ready if the checkpoint is approved, error if it is rejected and not ready otherwise
+func (c Checkpoint) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	status, message, err := checkpointStatus(c.Client, c.Checkpoint.Name)
+	if status.Phase == interfaces.ResourceNotReady {
+		status = status.WithReason("WaitingForApproval", message)
+	}
+	return status, err
+}
+
+// GetDependencyReport returns a DependencyReport for this Checkpoint
+func (c Checkpoint) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	status, message, err := checkpointStatus(c.Client, c.Checkpoint.Name)
+	if status.Phase == interfaces.ResourceNotReady && c.Checkpoint.Message != "" {
+		message = fmt.Sprintf("%s: %s", message, c.Checkpoint.Message)
+	}
+	return statusReport(c.Key(), status, err, message)
+}
+
+// Create requests approval of the checkpoint. Approvals given in previous runs are discarded, so
+// every run waits for a new one
+func (c Checkpoint) Create(ctx context.Context) error {
+	log := logging.ForResource(c.Key())
+	if c.Checkpoint.Message != "" {
+		log.Infof("%s waits for approval: %s", c.Key(), c.Checkpoint.Message)
+	} else {
+		log.Infof("%s waits for approval", c.Key())
+	}
+	return SetCheckpointApproval(c.Client, c.Checkpoint.Name, CheckpointPending)
+}
+
+// Delete does nothing, as there is nothing to delete from the cluster
+func (c Checkpoint) Delete(ctx context.Context) error {
+	return nil
+}
+
+// NameMatches gets resource definition and a name and checks if
+// the Checkpoint part of resource definition has matching name.
+func (c Checkpoint) NameMatches(def client.ResourceDefinition, name string) bool {
+	return def.Checkpoint != nil && def.Checkpoint.Name == name
+}
+
+// New returns new Checkpoint based on resource definition
+func (c Checkpoint) New(def client.ResourceDefinition, ci client.Interface) interfaces.Resource {
+	return NewCheckpoint(def.Checkpoint, ci.ConfigMaps(), def.Meta)
+}
+
+// NewExisting returns Checkpoint with given name. Its status depends on approval only
+func (c Checkpoint) NewExisting(name string, ci client.Interface) interfaces.Resource {
+	return NewCheckpoint(&client.Checkpoint{Name: name}, ci.ConfigMaps(), nil)
+}
+
+// NewCheckpoint is a constructor for Checkpoint
+func NewCheckpoint(checkpoint *client.Checkpoint, c corev1.ConfigMapInterface, meta map[string]interface{}) interfaces.Resource {
+	return Checkpoint{Base: Base{meta}, Checkpoint: checkpoint, Client: c}
+}
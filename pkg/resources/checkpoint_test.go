@@ -0,0 +1,92 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestCheckpointApproval checks that checkpoint waits for approval requested on creation
+func TestCheckpointApproval(t *testing.T) {
+	c := mocks.NewClient()
+	checkpoint := NewCheckpoint(&client.Checkpoint{Name: "canary", Message: "check canary metrics"}, c.ConfigMaps(), nil)
+
+	// approval of the previous run is discarded
+	if err := SetCheckpointApproval(c.ConfigMaps(), "canary", CheckpointApproved); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkpoint.Create(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	status, err := checkpoint.Status(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != interfaces.ResourceNotReady {
+		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
+	}
+	depReport := checkpoint.GetDependencyReport(context.Background(), nil)
+	if !depReport.Blocks || depReport.Message != "checkpoint waits for approval: check canary metrics" {
+		t.Errorf("Unexpected dependency report %+v", depReport)
+	}
+
+	pending, err := PendingCheckpoints(c.ConfigMaps())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0] != "canary" {
+		t.Errorf("Expected canary checkpoint to be pending, got %v", pending)
+	}
+
+	if err = SetCheckpointApproval(c.ConfigMaps(), "canary", CheckpointApproved); err != nil {
+		t.Fatal(err)
+	}
+	status, err = checkpoint.Status(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != interfaces.ResourceReady {
+		t.Errorf("Status should be `ready`, is `%s` instead.", status)
+	}
+	if pending, _ = PendingCheckpoints(c.ConfigMaps()); len(pending) != 0 {
+		t.Errorf("Expected no pending checkpoints, got %v", pending)
+	}
+}
+
+// TestCheckpointRejection checks that rejected checkpoint fails
+func TestCheckpointRejection(t *testing.T) {
+	c := mocks.NewClient()
+	checkpoint := NewCheckpoint(&client.Checkpoint{Name: "canary"}, c.ConfigMaps(), nil)
+	if err := checkpoint.Create(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetCheckpointApproval(c.ConfigMaps(), "canary", CheckpointRejected); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := checkpoint.Status(context.Background(), nil)
+	if err == nil {
+		t.Error("Error not found, expected error")
+	}
+	if status.Phase != interfaces.ResourceError {
+		t.Errorf("Status should be `error`, is `%s` instead.", status)
+	}
+}
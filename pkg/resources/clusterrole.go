@@ -0,0 +1,141 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"golang.org/x/net/context"
+	rbacclient "k8s.io/client-go/kubernetes/typed/rbac/v1alpha1"
+	"k8s.io/client-go/pkg/api/v1"
+	rbacalpha1 "k8s.io/client-go/pkg/apis/rbac/v1alpha1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// ClusterRole is a cluster-scoped set of permissions granted across all namespaces. It is ready as soon
+// as it exists
+type ClusterRole struct {
+	Base
+	ClusterRole *rbacalpha1.ClusterRole
+	Client      rbacclient.ClusterRoleInterface
+}
+
+func clusterRoleKey(name string) string {
+	return "clusterrole/" + name
+}
+
+// Key returns ClusterRole key
+func (r ClusterRole) Key() string {
+	return clusterRoleKey(r.ClusterRole.Name)
+}
+
+func clusterRoleStatus(c rbacclient.ClusterRoleInterface, name string) (interfaces.ResourceStatus, error) {
+	if _, err := c.Get(name); err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+func clusterRoleReport(c rbacclient.ClusterRoleInterface, name string) interfaces.DependencyReport {
+	status, err := clusterRoleStatus(c, name)
+	return statusReport(clusterRoleKey(name), status, err, "cluster role exists")
+}
+
+// Status returns ClusterRole status
+func (r ClusterRole) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	return clusterRoleStatus(r.Client, r.ClusterRole.Name)
+}
+
+// GetDependencyReport returns a DependencyReport for this ClusterRole
+func (r ClusterRole) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return clusterRoleReport(r.Client, r.ClusterRole.Name)
+}
+
+// Create creates ClusterRole if it does not exist
+func (r ClusterRole) Create(ctx context.Context) error {
+	if err := checkExistence(ctx, r); err != nil {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		logging.ForResource(r.Key()).Infof("Creating %s", r.Key())
+		r.ClusterRole, err = r.Client.Create(r.ClusterRole)
+		return err
+	}
+	return nil
+}
+
+// Delete deletes ClusterRole from the cluster
+func (r ClusterRole) Delete(ctx context.Context) error {
+	return r.Client.Delete(r.ClusterRole.Name, &v1.DeleteOptions{})
+}
+
+// NameMatches gets resource definition and a name and checks if
+// the ClusterRole part of resource definition has matching name.
+func (r ClusterRole) NameMatches(def client.ResourceDefinition, name string) bool {
+	return def.ClusterRole != nil && def.ClusterRole.Name == name
+}
+
+// New returns new ClusterRole based on resource definition
+func (r ClusterRole) New(def client.ResourceDefinition, c client.Interface) interfaces.Resource {
+	return NewClusterRole(def.ClusterRole, c.ClusterRoles(), def.Meta)
+}
+
+// NewExisting returns new ExistingClusterRole based on resource definition
+func (r ClusterRole) NewExisting(name string, c client.Interface) interfaces.Resource {
+	return NewExistingClusterRole(name, c.ClusterRoles())
+}
+
+// NewClusterRole returns ClusterRole wrapped as Resource
+func NewClusterRole(obj *rbacalpha1.ClusterRole, client rbacclient.ClusterRoleInterface, meta map[string]interface{}) interfaces.Resource {
+	return ClusterRole{Base: Base{meta}, ClusterRole: obj, Client: client}
+}
+
+// ExistingClusterRole is a ClusterRole without definition
+type ExistingClusterRole struct {
+	Base
+	Name   string
+	Client rbacclient.ClusterRoleInterface
+}
+
+// Key returns ClusterRole key
+func (r ExistingClusterRole) Key() string {
+	return clusterRoleKey(r.Name)
+}
+
+// Status returns ClusterRole status
+func (r ExistingClusterRole) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	return clusterRoleStatus(r.Client, r.Name)
+}
+
+// GetDependencyReport returns a DependencyReport for this ClusterRole
+func (r ExistingClusterRole) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return clusterRoleReport(r.Client, r.Name)
+}
+
+// Create looks for existing ClusterRole and returns error if there is no such object
+func (r ExistingClusterRole) Create(ctx context.Context) error {
+	return createExistingResource(ctx, r)
+}
+
+// Delete deletes ClusterRole from the cluster
+func (r ExistingClusterRole) Delete(ctx context.Context) error {
+	return r.Client.Delete(r.Name, nil)
+}
+
+// NewExistingClusterRole returns existing ClusterRole wrapped as Resource
+func NewExistingClusterRole(name string, client rbacclient.ClusterRoleInterface) interfaces.Resource {
+	return ExistingClusterRole{Name: name, Client: client}
+}
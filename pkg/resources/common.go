@@ -15,17 +15,21 @@
 package resources
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"strconv"
 	"strings"
 
+	"golang.org/x/net/context"
 	"k8s.io/client-go/pkg/api/v1"
 	"k8s.io/client-go/pkg/labels"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
 )
 
 // Base is a base struct that contains data common for all resources
@@ -54,46 +58,63 @@ func (b Base) StatusIsCacheable(meta map[string]string) bool {
 	return true
 }
 
-// KindToResourceTemplate is a map mapping kind strings to empty structs representing proper resources
-// structs implement interfaces.ResourceTemplate
-var KindToResourceTemplate = map[string]interfaces.ResourceTemplate{
-	"daemonset":             DaemonSet{},
-	"job":                   Job{},
-	"statefulset":           StatefulSet{},
-	"petset":                PetSet{},
-	"pod":                   Pod{},
-	"replicaset":            ReplicaSet{},
-	"service":               Service{},
-	"configmap":             ConfigMap{},
-	"secret":                Secret{},
-	"deployment":            Deployment{},
-	"persistentvolumeclaim": PersistentVolumeClaim{},
-	"serviceaccount":        ServiceAccount{},
+// Update does nothing for resources which do not support upgrades
+func (b Base) Update(ctx context.Context) error {
+	return nil
 }
 
-// Kinds is slice of keys from KindToResourceTemplate
-var Kinds = getKeys(KindToResourceTemplate)
-
-func getKeys(m map[string]interfaces.ResourceTemplate) (keys []string) {
-	for key := range m {
-		keys = append(keys, key)
+// setTemplateAnnotation sets annotation in pod template metadata. Returns false if it already had the value
+func setTemplateAnnotation(meta *v1.ObjectMeta, annotation, value string) bool {
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
 	}
+	if meta.Annotations[annotation] == value {
+		return false
+	}
+	meta.Annotations[annotation] = value
+	return true
+}
+
+// prepareUpdate makes definition with given metadata replace the live object on update
+func prepareUpdate(definition *v1.ObjectMeta, live v1.ObjectMeta, obj interface{}) error {
+	definition.ResourceVersion = live.ResourceVersion
+	return setLastApplied(definition, obj)
+}
 
-	return keys
+// ResourceError is returned by status checks when K8s object reports a failure
+// which is not expected to be resolved without user intervention
+type ResourceError struct {
+	Key     string
+	Reason  string
+	Message string
 }
 
-func resourceListReady(resources []interfaces.BaseResource) (string, error) {
+// NewResourceError is a constructor for ResourceError
+func NewResourceError(key, reason, message string) ResourceError {
+	return ResourceError{Key: key, Reason: reason, Message: message}
+}
+
+func (e ResourceError) Error() string {
+	return fmt.Sprintf("Resource %s failed: %s: %s", e.Key, e.Reason, e.Message)
+}
+
+// failedStatus returns error status carrying reason and message of the resource error along with the error
+func failedStatus(err ResourceError) (interfaces.ResourceStatus, error) {
+	return interfaces.NewStatus(interfaces.ResourceError).WithReason(err.Reason, err.Message), err
+}
+
+func resourceListReady(ctx context.Context, resources []interfaces.BaseResource) (interfaces.ResourceStatus, error) {
 	for _, r := range resources {
-		log.Printf("Checking status for resource %s", r.Key())
-		status, err := r.Status(nil)
+		logging.ForResource(r.Key()).Debugf("Checking status for resource %s", r.Key())
+		status, err := r.Status(ctx, nil)
 		if err != nil {
-			return "error", err
+			return interfaces.NewStatus(interfaces.ResourceError), err
 		}
-		if status != "ready" {
-			return "not ready", fmt.Errorf("Resource %s is not ready", r.Key())
+		if status.Phase != interfaces.ResourceReady {
+			return interfaces.NewStatus(interfaces.ResourceNotReady), fmt.Errorf("Resource %s is not ready", r.Key())
 		}
 	}
-	return "ready", nil
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
 }
 
 func getPercentage(factorName string, meta map[string]string) (int32, error) {
@@ -112,27 +133,64 @@ func getPercentage(factorName string, meta map[string]string) (int32, error) {
 	return int32(f), err
 }
 
-func checkExistence(r interfaces.BaseResource) error {
-	log.Println("Looking for ", r.Key())
-	status, err := r.Status(nil)
+// percentageReport creates a report for resources consisting of several replicas
+func percentageReport(key string, status interfaces.ResourceStatus, ready, total, needed int32, message string) interfaces.DependencyReport {
+	percentage := int32(100)
+	if total > 0 && ready < total {
+		percentage = ready * 100 / total
+	}
+	return interfaces.DependencyReport{
+		Dependency: key,
+		Blocks:     status.Phase != interfaces.ResourceReady,
+		Percentage: int(percentage),
+		Needed:     int(needed),
+		Message:    message,
+	}
+}
+
+// statusReport creates a report for resources that are either ready or not
+func statusReport(key string, status interfaces.ResourceStatus, err error, message string) interfaces.DependencyReport {
+	if err != nil {
+		if status.Phase == interfaces.ResourceError {
+			return report.ErrorReport(key, err)
+		}
+		message = err.Error()
+	}
+	// status explaining why the resource is not ready is more specific than the generic message
+	if !status.IsReady() && status.Message != "" {
+		message = report.StatusMessage(status)
+	}
+	return interfaces.DependencyReport{
+		Dependency: key,
+		Blocks:     !status.IsReady(),
+		Percentage: status.Progress,
+		Needed:     100,
+		Message:    message,
+	}
+}
+
+// checkExistence returns nil if the object of the resource exists, failed objects included
+func checkExistence(ctx context.Context, r interfaces.BaseResource) error {
+	logging.ForResource(r.Key()).Debugf("Looking for %s", r.Key())
+	status, err := r.Status(ctx, nil)
 
-	if err == nil {
-		log.Printf("Found %s, status: %s ", r.Key(), status)
+	if _, failed := err.(ResourceError); err == nil || failed {
+		logging.ForResource(r.Key()).Debugf("Found %s, status: %s", r.Key(), status)
 		return nil
 	}
 
 	return err
 }
 
-func createExistingResource(r interfaces.BaseResource) error {
-	if err := checkExistence(r); err != nil {
-		log.Printf("Expected resource %s to exist, not found", r.Key())
+func createExistingResource(ctx context.Context, r interfaces.BaseResource) error {
+	if err := checkExistence(ctx, r); err != nil {
+		logging.ForResource(r.Key()).Errorf("Expected resource %s to exist, not found", r.Key())
 		return errors.New("Resource not found")
 	}
 	return nil
 }
 
-func podsStateFromLabels(apiClient client.Interface, objLabels map[string]string) (string, error) {
+func podsFromLabels(apiClient client.Interface, objLabels map[string]string) (*v1.PodList, error) {
 	var labelSelectors []string
 	for k, v := range objLabels {
 		labelSelectors = append(labelSelectors, fmt.Sprintf("%s=%s", k, v))
@@ -140,13 +198,33 @@ func podsStateFromLabels(apiClient client.Interface, objLabels map[string]string
 	stringSelector := strings.Join(labelSelectors, ",")
 	selector, err := labels.Parse(stringSelector)
 	if err != nil {
-		return "error", err
+		return nil, err
 	}
 	options := v1.ListOptions{LabelSelector: selector.String()}
 
-	pods, err := apiClient.Pods().List(options)
+	return apiClient.Pods().List(options)
+}
+
+// readyPodsCountFromLabels returns the number of ready pods matching given labels
+func readyPodsCountFromLabels(apiClient client.Interface, objLabels map[string]string) (int32, error) {
+	pods, err := podsFromLabels(apiClient, objLabels)
 	if err != nil {
-		return "error", err
+		return 0, err
+	}
+	var ready int32
+	for _, pod := range pods.Items {
+		p := pod
+		if (p.Status.Phase == "Running" && isReady(&p)) || p.Status.Phase == "Succeeded" {
+			ready++
+		}
+	}
+	return ready, nil
+}
+
+func podsStateFromLabels(ctx context.Context, apiClient client.Interface, objLabels map[string]string) (interfaces.ResourceStatus, error) {
+	pods, err := podsFromLabels(apiClient, objLabels)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
 	}
 	resources := make([]interfaces.BaseResource, 0, len(pods.Items))
 	for _, pod := range pods.Items {
@@ -154,12 +232,58 @@ func podsStateFromLabels(apiClient client.Interface, objLabels map[string]string
 		resources = append(resources, NewPod(&p, apiClient.Pods(), nil))
 	}
 
-	status, err := resourceListReady(resources)
-	if status != "ready" || err != nil {
+	status, err := resourceListReady(ctx, resources)
+	if status.Phase != interfaces.ResourceReady || err != nil {
 		return status, err
 	}
 
-	return "ready", nil
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+// GetBoolMeta returns metadata value for parameter 'paramName', or 'defaultValue'
+// if parameter is not set or is not a boolean value. String values like "true" are accepted as well
+func GetBoolMeta(r interfaces.BaseResource, paramName string, defaultValue bool) bool {
+	value := r.Meta(paramName)
+	if value == nil {
+		return defaultValue
+	}
+
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		boolVal, err := strconv.ParseBool(v)
+		if err == nil {
+			return boolVal
+		}
+	}
+	logging.ForResource(r.Key()).Warningf("Metadata parameter '%s' for resource '%s' is set to '%v' but it does not seem to be a boolean, using default value %t", paramName, r.Key(), value, defaultValue)
+	return defaultValue
+}
+
+// GetStringMeta returns metadata value for parameter 'paramName', or 'defaultValue'
+// if parameter is not set or is not a string value
+func GetStringMeta(r interfaces.BaseResource, paramName string, defaultValue string) string {
+	value := r.Meta(paramName)
+	if value == nil {
+		return defaultValue
+	}
+
+	strVal, ok := value.(string)
+	if !ok {
+		logging.ForResource(r.Key()).Warningf("Metadata parameter '%s' for resource '%s' is set to '%v' but it does not seem to be a string, using default value %s", paramName, r.Key(), value, defaultValue)
+		return defaultValue
+	}
+	return strVal
+}
+
+// checksum returns hex encoded sha256 digest of JSON representation of the object
+func checksum(obj interface{}) (string, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
 }
 
 // GetIntMeta returns metadata value for parameter 'paramName', or 'defaultValue'
@@ -172,7 +296,7 @@ func GetIntMeta(r interfaces.BaseResource, paramName string, defaultValue int) i
 
 	intVal, ok := value.(float64)
 	if !ok {
-		log.Printf("Metadata parameter '%s' for resource '%s' is set to '%v' but it does not seem to be a number, using default value %d", paramName, r.Key(), value, defaultValue)
+		logging.ForResource(r.Key()).Warningf("Metadata parameter '%s' for resource '%s' is set to '%v' but it does not seem to be a number, using default value %d", paramName, r.Key(), value, defaultValue)
 		return defaultValue
 	}
 
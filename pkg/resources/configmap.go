@@ -15,14 +15,15 @@
 package resources
 
 import (
-	"log"
+	"fmt"
 
+	"golang.org/x/net/context"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/pkg/api/v1"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
-	"github.com/Mirantis/k8s-AppController/pkg/report"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
 )
 
 type ConfigMap struct {
@@ -45,29 +46,88 @@ func (c ConfigMap) Key() string {
 	return configMapKey(c.ConfigMap.Name)
 }
 
-func configMapStatus(c corev1.ConfigMapInterface, name string) (string, error) {
+func configMapStatus(c corev1.ConfigMapInterface, name string) (interfaces.ResourceStatus, error) {
 	_, err := c.Get(name)
 	if err != nil {
-		return "error", err
+		return interfaces.NewStatus(interfaces.ResourceError), err
 	}
 
-	return "ready", nil
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
 }
 
-func (c ConfigMap) Status(meta map[string]string) (string, error) {
-	return configMapStatus(c.Client, c.ConfigMap.Name)
+func configMapReport(c corev1.ConfigMapInterface, name string) interfaces.DependencyReport {
+	status, err := configMapStatus(c, name)
+	return statusReport(configMapKey(name), status, err, "config map exists")
 }
 
-func (c ConfigMap) Create() error {
-	if err := checkExistence(c); err != nil {
-		log.Println("Creating ", c.Key())
+// Status returns ConfigMap status. ConfigMap is ready when it exists and its data matches the definition
+func (c ConfigMap) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	configMap, err := c.Client.Get(c.ConfigMap.Name)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	if !c.EqualToDefinition(configMap) {
+		return interfaces.NewStatus(interfaces.ResourceWaitingForUpgrade), nil
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+// EqualToDefinition checks if definition in object is compatible with provided object.
+// Data of ConfigMap must match the definition exactly, extra keys in cluster make it different
+func (c ConfigMap) EqualToDefinition(configMap interface{}) bool {
+	if cm, ok := configMap.(*v1.ConfigMap); ok && !equalStringMaps(c.ConfigMap.Data, cm.Data) {
+		return false
+	}
+	return c.equalToDefinition(c.Key(), c.ConfigMap, configMap)
+}
+
+// GetDependencyReport returns a DependencyReport for this ConfigMap
+func (c ConfigMap) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	status, err := c.Status(ctx, meta)
+	return statusReport(c.Key(), status, err, fmt.Sprintf("config map is %s", status))
+}
+
+func (c ConfigMap) Create(ctx context.Context) error {
+	if err := checkExistence(ctx, c); err != nil {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		logging.ForResource(c.Key()).Infof("Creating %s", c.Key())
+		if err = setLastApplied(&c.ConfigMap.ObjectMeta, c.ConfigMap); err != nil {
+			return err
+		}
 		c.ConfigMap, err = c.Client.Create(c.ConfigMap)
 		return err
 	}
 	return nil
 }
 
-func (c ConfigMap) Delete() error {
+// RestartDependentsKey is a meta key of ConfigMap and Secret definitions. If it is set to true,
+// pods of dependent Deployments and StatefulSets are restarted when the object is upgraded
+const RestartDependentsKey = "restart-dependents"
+
+// Checksum returns the checksum of ConfigMap data from definition
+func (c ConfigMap) Checksum() (string, error) {
+	return checksum(c.ConfigMap.Data)
+}
+
+// Update replaces ConfigMap in the cluster with its definition
+func (c ConfigMap) Update(ctx context.Context) error {
+	configMap, err := c.Client.Get(c.ConfigMap.Name)
+	if err != nil {
+		return err
+	}
+	if err = prepareUpdate(&c.ConfigMap.ObjectMeta, configMap.ObjectMeta, c.ConfigMap); err != nil {
+		return err
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	_, err = c.Client.Update(c.ConfigMap)
+	return err
+}
+
+func (c ConfigMap) Delete(ctx context.Context) error {
 	return c.Client.Delete(c.ConfigMap.Name, &v1.DeleteOptions{})
 }
 
@@ -76,11 +136,11 @@ func (c ConfigMap) NameMatches(def client.ResourceDefinition, name string) bool
 }
 
 func NewConfigMap(c *v1.ConfigMap, client corev1.ConfigMapInterface, meta map[string]interface{}) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: ConfigMap{Base: Base{meta}, ConfigMap: c, Client: client}}
+	return ConfigMap{Base: Base{meta}, ConfigMap: c, Client: client}
 }
 
 func NewExistingConfigMap(name string, client corev1.ConfigMapInterface) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: ExistingConfigMap{Name: name, Client: client}}
+	return ExistingConfigMap{Name: name, Client: client}
 }
 
 // New returns a new object wrapped as Resource
@@ -97,14 +157,19 @@ func (c ExistingConfigMap) Key() string {
 	return configMapKey(c.Name)
 }
 
-func (c ExistingConfigMap) Status(meta map[string]string) (string, error) {
+func (c ExistingConfigMap) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
 	return configMapStatus(c.Client, c.Name)
 }
 
-func (c ExistingConfigMap) Create() error {
-	return createExistingResource(c)
+// GetDependencyReport returns a DependencyReport for this ConfigMap
+func (c ExistingConfigMap) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return configMapReport(c.Client, c.Name)
+}
+
+func (c ExistingConfigMap) Create(ctx context.Context) error {
+	return createExistingResource(ctx, c)
 }
 
-func (c ExistingConfigMap) Delete() error {
+func (c ExistingConfigMap) Delete(ctx context.Context) error {
 	return c.Client.Delete(c.Name, nil)
 }
@@ -17,6 +17,9 @@ package resources
 import (
 	"testing"
 
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
 	"github.com/Mirantis/k8s-AppController/pkg/mocks"
 )
 
@@ -29,7 +32,7 @@ func TestConfigMapSuccessCheck(t *testing.T) {
 		t.Error(err)
 	}
 
-	if status != "ready" {
+	if status.Phase != "ready" {
 		t.Errorf("Status should be `ready`, is `%s` instead.", status)
 	}
 }
@@ -43,7 +46,47 @@ func TestConfigMapFailCheck(t *testing.T) {
 		t.Error("Error not found, expected error")
 	}
 
-	if status != "error" {
+	if status.Phase != "error" {
 		t.Errorf("Status should be `error`, is `%s` instead.", status)
 	}
 }
+
+// TestConfigMapUpgradeCheck checks that ConfigMap with changed data is waiting for upgrade
+func TestConfigMapUpgradeCheck(t *testing.T) {
+	c := mocks.NewClient(mocks.ConfigMaps("cfg"))
+	definition := mocks.MakeConfigMap("cfg")
+	definition.Data = map[string]string{"key": "value"}
+	configMap := NewConfigMap(definition, c.ConfigMaps(), nil)
+
+	status, err := configMap.Status(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != interfaces.ResourceWaitingForUpgrade {
+		t.Errorf("Status should be `%s`, is `%s` instead.", interfaces.ResourceWaitingForUpgrade, status)
+	}
+
+	if err = configMap.Update(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	status, err = configMap.Status(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != interfaces.ResourceReady {
+		t.Errorf("Status should be `ready`, is `%s` instead.", status)
+	}
+}
+
+// TestConfigMapExtraKeys checks that keys missing from definition make ConfigMap different
+func TestConfigMapExtraKeys(t *testing.T) {
+	definition := mocks.MakeConfigMap("cfg")
+	definition.Data = map[string]string{"key": "value"}
+	live := mocks.MakeConfigMap("cfg")
+	live.Data = map[string]string{"key": "value", "other": "value"}
+
+	configMap := ConfigMap{ConfigMap: definition}
+	if configMap.EqualToDefinition(live) {
+		t.Error("ConfigMap with extra data keys should not be equal to definition")
+	}
+}
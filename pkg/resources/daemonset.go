@@ -1,12 +1,14 @@
 package resources
 
 import (
-	"log"
+	"fmt"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
 	"github.com/Mirantis/k8s-AppController/pkg/report"
 
+	"golang.org/x/net/context"
 	"k8s.io/client-go/kubernetes/typed/extensions/v1beta1"
 	"k8s.io/client-go/pkg/api/v1"
 	extbeta1 "k8s.io/client-go/pkg/apis/extensions/v1beta1"
@@ -23,15 +25,59 @@ func daemonSetKey(name string) string {
 	return "daemonset/" + name
 }
 
-func daemonSetStatus(d v1beta1.DaemonSetInterface, name string) (string, error) {
+func daemonSetStatus(d v1beta1.DaemonSetInterface, name string, meta map[string]string) (interfaces.ResourceStatus, error) {
 	daemonSet, err := d.Get(name)
 	if err != nil {
-		return "error", err
+		return interfaces.NewStatus(interfaces.ResourceError), err
 	}
-	if daemonSet.Status.CurrentNumberScheduled == daemonSet.Status.DesiredNumberScheduled {
-		return "ready", nil
+	return daemonSetReadiness(daemonSet, meta)
+}
+
+// daemonSetReadiness returns ready if pods of the daemon set are scheduled to success factor percent of
+// nodes which should run them, all of them by default
+func daemonSetReadiness(daemonSet *extbeta1.DaemonSet, meta map[string]string) (interfaces.ResourceStatus, error) {
+	successFactor, err := getPercentage(SuccessFactorKey, meta)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	scheduled, desired := daemonSet.Status.CurrentNumberScheduled, daemonSet.Status.DesiredNumberScheduled
+	if scheduled*100 >= desired*successFactor {
+		return interfaces.NewStatus(interfaces.ResourceReady), nil
+	}
+	return interfaces.NewStatus(interfaces.ResourceNotReady).
+		WithReason("PodsNotScheduled", fmt.Sprintf("%d of %d pods scheduled", scheduled, desired)).
+		WithProgress(int(scheduled), int(desired)), nil
+}
+
+func daemonSetReport(d v1beta1.DaemonSetInterface, name string, meta map[string]string) interfaces.DependencyReport {
+	daemonSet, err := d.Get(name)
+	if err != nil {
+		return report.ErrorReport(daemonSetKey(name), err)
+	}
+	status, err := daemonSetReadiness(daemonSet, meta)
+	if err != nil {
+		return report.ErrorReport(daemonSetKey(name), err)
 	}
-	return "not ready", nil
+	successFactor, err := getPercentage(SuccessFactorKey, meta)
+	if err != nil {
+		return report.ErrorReport(daemonSetKey(name), err)
+	}
+
+	scheduled, desired := daemonSet.Status.CurrentNumberScheduled, daemonSet.Status.DesiredNumberScheduled
+	return percentageReport(
+		daemonSetKey(name),
+		status,
+		scheduled,
+		desired,
+		successFactor,
+		fmt.Sprintf("%d of %d pods scheduled", scheduled, desired),
+	)
+}
+
+// daemonSetStatusIsCacheable returns false if meta contains SuccessFactorKey
+func daemonSetStatusIsCacheable(meta map[string]string) bool {
+	_, ok := meta[SuccessFactorKey]
+	return !ok
 }
 
 // Key return DaemonSet key
@@ -40,14 +86,27 @@ func (d DaemonSet) Key() string {
 }
 
 // Status returns DaemonSet status as a string "ready" means that its dependencies can be created
-func (d DaemonSet) Status(meta map[string]string) (string, error) {
-	return daemonSetStatus(d.Client, d.DaemonSet.Name)
+func (d DaemonSet) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	return daemonSetStatus(d.Client, d.DaemonSet.Name, meta)
+}
+
+// GetDependencyReport returns a DependencyReport for this DaemonSet
+func (d DaemonSet) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return daemonSetReport(d.Client, d.DaemonSet.Name, meta)
+}
+
+// StatusIsCacheable returns false if meta contains SuccessFactorKey
+func (d DaemonSet) StatusIsCacheable(meta map[string]string) bool {
+	return daemonSetStatusIsCacheable(meta)
 }
 
 // Create looks for DaemonSet in K8s and creates it if not present
-func (d DaemonSet) Create() error {
-	if err := checkExistence(d); err != nil {
-		log.Println("Creating ", d.Key())
+func (d DaemonSet) Create(ctx context.Context) error {
+	if err := checkExistence(ctx, d); err != nil {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		logging.ForResource(d.Key()).Infof("Creating %s", d.Key())
 		d.DaemonSet, err = d.Client.Create(d.DaemonSet)
 		return err
 	}
@@ -55,10 +114,15 @@ func (d DaemonSet) Create() error {
 }
 
 // Delete deletes DaemonSet from the cluster
-func (d DaemonSet) Delete() error {
+func (d DaemonSet) Delete(ctx context.Context) error {
 	return d.Client.Delete(d.DaemonSet.Name, &v1.DeleteOptions{})
 }
 
+// DeleteWithPolicy deletes DaemonSet from the cluster, policy defines what happens with its pods
+func (d DaemonSet) DeleteWithPolicy(policy DeletionPolicy) error {
+	return d.Client.Delete(d.DaemonSet.Name, policy.options())
+}
+
 // NameMatches gets resource definition and a name and checks if
 // the DaemonSet part of resource definition has matching name.
 func (d DaemonSet) NameMatches(def client.ResourceDefinition, name string) bool {
@@ -77,7 +141,7 @@ func (d DaemonSet) NewExisting(name string, c client.Interface) interfaces.Resou
 
 // NewDaemonSet is a constructor
 func NewDaemonSet(daemonset *extbeta1.DaemonSet, client v1beta1.DaemonSetInterface, meta map[string]interface{}) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: DaemonSet{Base: Base{meta}, DaemonSet: daemonset, Client: client}}
+	return DaemonSet{Base: Base{meta}, DaemonSet: daemonset, Client: client}
 }
 
 // ExistingDaemonSet is a wrapper for K8s DaemonSet object which is deployed on a cluster before AppController
@@ -93,21 +157,36 @@ func (d ExistingDaemonSet) Key() string {
 }
 
 // Status returns DaemonSet status as a string "ready" means that its dependencies can be created
-func (d ExistingDaemonSet) Status(meta map[string]string) (string, error) {
-	return daemonSetStatus(d.Client, d.Name)
+func (d ExistingDaemonSet) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	return daemonSetStatus(d.Client, d.Name, meta)
+}
+
+// GetDependencyReport returns a DependencyReport for this DaemonSet
+func (d ExistingDaemonSet) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return daemonSetReport(d.Client, d.Name, meta)
+}
+
+// StatusIsCacheable returns false if meta contains SuccessFactorKey
+func (d ExistingDaemonSet) StatusIsCacheable(meta map[string]string) bool {
+	return daemonSetStatusIsCacheable(meta)
 }
 
 // Create looks for existing DaemonSet and returns error if there is no such DaemonSet
-func (d ExistingDaemonSet) Create() error {
-	return createExistingResource(d)
+func (d ExistingDaemonSet) Create(ctx context.Context) error {
+	return createExistingResource(ctx, d)
 }
 
 // Delete deletes DaemonSet from the cluster
-func (d ExistingDaemonSet) Delete() error {
+func (d ExistingDaemonSet) Delete(ctx context.Context) error {
 	return d.Client.Delete(d.Name, nil)
 }
 
+// DeleteWithPolicy deletes DaemonSet from the cluster, policy defines what happens with its pods
+func (d ExistingDaemonSet) DeleteWithPolicy(policy DeletionPolicy) error {
+	return d.Client.Delete(d.Name, policy.options())
+}
+
 // NewExistingDaemonSet is a constructor
 func NewExistingDaemonSet(name string, client v1beta1.DaemonSetInterface) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: ExistingDaemonSet{Name: name, Client: client}}
+	return ExistingDaemonSet{Name: name, Client: client}
 }
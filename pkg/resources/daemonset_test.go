@@ -9,12 +9,12 @@ import (
 // TestDaemonSetSuccessCheck check status for ready DaemonSet
 func TestDaemonSetSuccessCheck(t *testing.T) {
 	c := mocks.NewClient(mocks.MakeDaemonSet("not-fail"))
-	status, err := daemonSetStatus(c.DaemonSets(), "not-fail")
+	status, err := daemonSetStatus(c.DaemonSets(), "not-fail", nil)
 
 	if err != nil {
 		t.Error(err)
 	}
-	if status != "ready" {
+	if status.Phase != "ready" {
 		t.Errorf("Status should be ready , is %s instead", status)
 	}
 }
@@ -22,11 +22,32 @@ func TestDaemonSetSuccessCheck(t *testing.T) {
 // TestDaemonSetFailCheck status of not ready daemonset
 func TestDaemonSetFailCheck(t *testing.T) {
 	c := mocks.NewClient(mocks.MakeDaemonSet("fail"))
-	status, err := daemonSetStatus(c.DaemonSets(), "fail")
+	status, err := daemonSetStatus(c.DaemonSets(), "fail", nil)
 	if err != nil {
 		t.Error(err)
 	}
-	if status != "not ready" {
+	if status.Phase != "not ready" {
 		t.Errorf("Status should be not ready, is %s instead.", status)
 	}
 }
+
+// TestExistingDaemonSetSuccessFactor checks that existing daemonset honours success_factor in status and report
+func TestExistingDaemonSetSuccessFactor(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeDaemonSet("fail"))
+	ds := NewExistingDaemonSet("fail", c.DaemonSets())
+	meta := map[string]string{SuccessFactorKey: "60"}
+
+	status, err := ds.Status(nil, meta)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != "ready" {
+		t.Errorf("Status should be ready, is %s instead.", status)
+	}
+	if depReport := ds.GetDependencyReport(nil, meta); depReport.Blocks {
+		t.Errorf("Report should not block, got %+v", depReport)
+	}
+	if depReport := ds.GetDependencyReport(nil, nil); !depReport.Blocks || depReport.Dependency != "daemonset/fail" {
+		t.Errorf("Report of daemonset/fail should block without success factor, got %+v", depReport)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+// DeletionPolicy defines what happens with objects owned by deleted resource
+type DeletionPolicy string
+
+const (
+	// DeletionDefault leaves the decision to the API server
+	DeletionDefault DeletionPolicy = ""
+	// DeletionCascade deletes owned objects together with the resource
+	DeletionCascade DeletionPolicy = "cascade"
+	// DeletionOrphan keeps owned objects in the cluster
+	DeletionOrphan DeletionPolicy = "orphan"
+)
+
+func (p DeletionPolicy) options() *v1.DeleteOptions {
+	if p == DeletionDefault {
+		return nil
+	}
+	orphan := p == DeletionOrphan
+	return &v1.DeleteOptions{OrphanDependents: &orphan}
+}
+
+// policyDeleter is implemented by resources which own other objects and can be deleted with a DeletionPolicy
+type policyDeleter interface {
+	DeleteWithPolicy(policy DeletionPolicy) error
+}
+
+// DeleteAndWait deletes the resource and waits until it is gone from the cluster, including objects
+// kept by finalizers, or the context is done. Deleting resource which does not exist is not an error
+func DeleteAndWait(ctx context.Context, r interfaces.BaseResource, policy DeletionPolicy, checkInterval, timeout time.Duration) error {
+	target := r
+	if reporter, ok := r.(report.SimpleReporter); ok {
+		target = reporter.BaseResource
+	}
+
+	var err error
+	if deleter, ok := target.(policyDeleter); ok {
+		err = deleter.DeleteWithPolicy(policy)
+	} else {
+		if policy != DeletionDefault {
+			logging.ForResource(r.Key()).Warningf("%s does not support deletion policy %q, using default one", r.Key(), policy)
+		}
+		err = target.Delete(ctx)
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := deletionStatus(ctx, target)
+		if err != nil {
+			return err
+		}
+		if status.Phase == interfaces.ResourceReady {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for %s to be deleted", r.Key())
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(checkInterval):
+		}
+	}
+}
+
+// Deletion is a node which deletes wrapped resource instead of creating it. It is ready when the
+// resource is gone from the cluster, so that its dependents are created only after that
+type Deletion struct {
+	interfaces.Resource
+}
+
+// NewDeletion is a constructor for Deletion
+func NewDeletion(r interfaces.Resource) interfaces.Resource {
+	return Deletion{Resource: r}
+}
+
+// deletionStatus returns ready if the resource does not exist. Resources which have deletion
+// timestamp set, but are kept by finalizers, still exist, as well as failed ones
+func deletionStatus(ctx context.Context, r interfaces.BaseResource) (interfaces.ResourceStatus, error) {
+	_, err := r.Status(ctx, nil)
+	if _, failed := err.(ResourceError); err == nil || failed {
+		return interfaces.NewStatus(interfaces.ResourceNotReady).WithReason("Deleting", r.Key()+" still exists"), nil
+	}
+	if errors.IsNotFound(err) {
+		return interfaces.NewStatus(interfaces.ResourceReady), nil
+	}
+	return interfaces.NewStatus(interfaces.ResourceError), err
+}
+
+// Status returns ready if the resource was deleted
+func (d Deletion) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	return deletionStatus(ctx, d.Resource)
+}
+
+// GetDependencyReport returns a DependencyReport for this Deletion
+func (d Deletion) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	status, err := deletionStatus(ctx, d.Resource)
+	message := fmt.Sprintf("waiting for %s to be deleted", d.Key())
+	if status.Phase == interfaces.ResourceReady {
+		message = fmt.Sprintf("%s is deleted", d.Key())
+	}
+	return statusReport(d.Key(), status, err, message)
+}
+
+// Create deletes the resource if it exists
+func (d Deletion) Create(ctx context.Context) error {
+	status, err := deletionStatus(ctx, d.Resource)
+	if err != nil || status.Phase == interfaces.ResourceReady {
+		return err
+	}
+	logging.ForResource(d.Key()).Infof("Deleting %s before creating its dependents", d.Key())
+	err = d.Resource.Delete(ctx)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// Delete does nothing, as the resource is already deleted
+func (d Deletion) Delete(ctx context.Context) error {
+	return nil
+}
+
+// Update does nothing, as the resource is deleted instead of being upgraded
+func (d Deletion) Update(ctx context.Context) error {
+	return nil
+}
+
+// StatusIsCacheable returns true, once the resource is gone it is not expected to appear again
+func (d Deletion) StatusIsCacheable(meta map[string]string) bool {
+	return true
+}
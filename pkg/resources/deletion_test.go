@@ -0,0 +1,85 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestDeletion checks that deletion node deletes existing resource and becomes ready when it is gone
+func TestDeletion(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePod("ready-1"))
+	deletion := NewDeletion(NewExistingPod("ready-1", c.Pods()))
+
+	status, err := deletion.Status(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != interfaces.ResourceNotReady {
+		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
+	}
+
+	if err = deletion.Create(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	status, err = deletion.Status(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != interfaces.ResourceReady {
+		t.Errorf("Status should be `ready`, is `%s` instead.", status)
+	}
+
+	// deleting resource which is already gone is not an error
+	if err = deletion.Create(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestDeleteAndWait checks that DeleteAndWait returns once the resource is gone
+func TestDeleteAndWait(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeDeployment("ready-1"))
+	deployment := NewExistingDeployment("ready-1", c.Deployments())
+
+	for _, policy := range []DeletionPolicy{DeletionOrphan, DeletionCascade} {
+		if err := DeleteAndWait(context.Background(), deployment, policy, 10*time.Millisecond, time.Second); err != nil {
+			t.Errorf("Deletion with %q policy failed: %v", policy, err)
+		}
+	}
+	if _, err := c.Deployments().Get("ready-1"); err == nil {
+		t.Error("Deployment should be deleted")
+	}
+}
+
+// TestDeletionPolicyOptions checks delete options generated for deletion policies
+func TestDeletionPolicyOptions(t *testing.T) {
+	if DeletionDefault.options() != nil {
+		t.Error("Default policy should not set delete options")
+	}
+	options := DeletionOrphan.options()
+	if options == nil || options.OrphanDependents == nil || !*options.OrphanDependents {
+		t.Errorf("Orphan policy should orphan dependents, got %v", options)
+	}
+	options = DeletionCascade.options()
+	if options == nil || options.OrphanDependents == nil || *options.OrphanDependents {
+		t.Errorf("Cascade policy should not orphan dependents, got %v", options)
+	}
+}
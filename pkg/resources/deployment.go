@@ -1,14 +1,16 @@
 package resources
 
 import (
-	"errors"
-	"log"
+	"fmt"
 
+	"golang.org/x/net/context"
 	"k8s.io/client-go/kubernetes/typed/extensions/v1beta1"
+	"k8s.io/client-go/pkg/api/v1"
 	extbeta1 "k8s.io/client-go/pkg/apis/extensions/v1beta1"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
 	"github.com/Mirantis/k8s-AppController/pkg/report"
 )
 
@@ -23,16 +25,100 @@ func deploymentKey(name string) string {
 	return "deployment/" + name
 }
 
-func deploymentStatus(d v1beta1.DeploymentInterface, name string) (string, error) {
+func deploymentStatus(d v1beta1.DeploymentInterface, name string, meta map[string]string) (interfaces.ResourceStatus, error) {
 	deployment, err := d.Get(name)
 	if err != nil {
-		return "error", err
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	return deploymentReadiness(deployment, meta)
+}
+
+func deploymentReadiness(deployment *extbeta1.Deployment, meta map[string]string) (interfaces.ResourceStatus, error) {
+	// deployment controller has not processed the latest spec yet, so status fields are stale
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return interfaces.NewStatus(interfaces.ResourceNotReady).WithReason(
+			"ObservedGenerationStale", "deployment controller has not observed the latest spec yet"), nil
+	}
+
+	_, hasFactor := meta[SuccessFactorKey]
+
+	// conditions are not populated by clusters older than 1.5, in that case
+	// only replica counts are taken into account
+	for _, cond := range deployment.Status.Conditions {
+		switch cond.Type {
+		case extbeta1.DeploymentReplicaFailure:
+			if cond.Status == v1.ConditionTrue {
+				return failedStatus(NewResourceError(deploymentKey(deployment.Name), cond.Reason, cond.Message))
+			}
+		case extbeta1.DeploymentProgressing:
+			if cond.Status == v1.ConditionFalse {
+				return failedStatus(NewResourceError(deploymentKey(deployment.Name), cond.Reason, cond.Message))
+			}
+		case extbeta1.DeploymentAvailable:
+			// Available condition is based on deployment strategy, so it does not apply
+			// when user has chosen own success factor
+			if cond.Status != v1.ConditionTrue && !hasFactor {
+				return interfaces.NewStatus(interfaces.ResourceNotReady).WithReason(cond.Reason, cond.Message).
+					WithProgress(int(deployment.Status.AvailableReplicas), int(*deployment.Spec.Replicas)), nil
+			}
+		}
+	}
+
+	successFactor, err := getPercentage(SuccessFactorKey, meta)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
 	}
 
-	if deployment.Status.UpdatedReplicas >= *deployment.Spec.Replicas && deployment.Status.AvailableReplicas >= *deployment.Spec.Replicas {
-		return "ready", nil
+	needed := *deployment.Spec.Replicas * successFactor
+	if deployment.Status.UpdatedReplicas*100 >= needed && deployment.Status.AvailableReplicas*100 >= needed {
+		return interfaces.NewStatus(interfaces.ResourceReady), nil
+	}
+	return interfaces.NewStatus(interfaces.ResourceNotReady).
+		WithReason("ReplicasNotAvailable", fmt.Sprintf("%d updated and %d available of %d replicas",
+			deployment.Status.UpdatedReplicas, deployment.Status.AvailableReplicas, *deployment.Spec.Replicas)).
+		WithProgress(int(deployment.Status.AvailableReplicas), int(*deployment.Spec.Replicas)), nil
+}
+
+func deploymentReport(d v1beta1.DeploymentInterface, name string, meta map[string]string) interfaces.DependencyReport {
+	deployment, err := d.Get(name)
+	if err != nil {
+		return report.ErrorReport(deploymentKey(name), err)
 	}
-	return "not ready", nil
+	status, err := deploymentReadiness(deployment, meta)
+	if err != nil {
+		return report.ErrorReport(deploymentKey(name), err)
+	}
+	successFactor, err := getPercentage(SuccessFactorKey, meta)
+	if err != nil {
+		return report.ErrorReport(deploymentKey(name), err)
+	}
+
+	return percentageReport(
+		deploymentKey(name),
+		status,
+		deployment.Status.AvailableReplicas,
+		*deployment.Spec.Replicas,
+		successFactor,
+		fmt.Sprintf(
+			"%d of %d replicas available, %d updated",
+			deployment.Status.AvailableReplicas,
+			*deployment.Spec.Replicas,
+			deployment.Status.UpdatedReplicas,
+		),
+	)
+}
+
+func restartDeploymentPods(d v1beta1.DeploymentInterface, name, annotation, value string) error {
+	deployment, err := d.Get(name)
+	if err != nil {
+		return err
+	}
+	if !setTemplateAnnotation(&deployment.Spec.Template.ObjectMeta, annotation, value) {
+		return nil
+	}
+	logging.ForResource(deploymentKey(name)).Infof("Restarting pods of %s", deploymentKey(name))
+	_, err = d.Update(deployment)
+	return err
 }
 
 // Key return Deployment key
@@ -40,30 +126,84 @@ func (d Deployment) Key() string {
 	return deploymentKey(d.Deployment.Name)
 }
 
-// Status returns Deployment status as a string "ready" means that its dependencies can be created
-func (d Deployment) Status(meta map[string]string) (string, error) {
-	return deploymentStatus(d.Client, d.Deployment.Name)
+// Status returns interfaces.ResourceStatus of the Deployment. Its phase is ready when enough replicas are
+// available for dependencies to be created, not ready with the reason and message of the deployment
+// condition while it is rolled out, error if the rollout failed, and waiting for upgrade if the
+// Deployment differs from its definition
+func (d Deployment) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	deployment, err := d.Client.Get(d.Deployment.Name)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	if !d.EqualToDefinition(deployment) {
+		return interfaces.NewStatus(interfaces.ResourceWaitingForUpgrade), nil
+	}
+	return deploymentReadiness(deployment, meta)
+}
+
+// EqualToDefinition checks if definition in object is compatible with provided object
+func (d Deployment) EqualToDefinition(deployment interface{}) bool {
+	return d.equalToDefinition(d.Key(), d.Deployment, deployment)
+}
+
+// GetDependencyReport returns a DependencyReport for this deployment
+func (d Deployment) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return deploymentReport(d.Client, d.Deployment.Name, meta)
+}
+
+// StatusIsCacheable returns false if meta contains SuccessFactorKey
+func (d Deployment) StatusIsCacheable(meta map[string]string) bool {
+	_, ok := meta[SuccessFactorKey]
+	return !ok
 }
 
 // Create looks for Deployment in K8s and creates it if not present
-func (d Deployment) Create() error {
-	log.Println("Looking for deployment", d.Deployment.Name)
-	status, err := d.Status(nil)
+func (d Deployment) Create(ctx context.Context) error {
+	if err := checkExistence(ctx, d); err != nil {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		logging.ForResource(d.Key()).Infof("Creating %s", d.Key())
+		if err = setLastApplied(&d.Deployment.ObjectMeta, d.Deployment); err != nil {
+			return err
+		}
+		d.Deployment, err = d.Client.Create(d.Deployment)
+		return err
+	}
+	return nil
+}
 
-	if err == nil {
-		log.Printf("Found deployment %s, status: %s", d.Deployment.Name, status)
-		log.Println("Skipping creation of deployment", d.Deployment.Name)
+// Update replaces Deployment in the cluster with its definition
+func (d Deployment) Update(ctx context.Context) error {
+	deployment, err := d.Client.Get(d.Deployment.Name)
+	if err != nil {
+		return err
+	}
+	if err = prepareUpdate(&d.Deployment.ObjectMeta, deployment.ObjectMeta, d.Deployment); err != nil {
+		return err
 	}
-	log.Println("Creating deployment", d.Deployment.Name)
-	d.Deployment, err = d.Client.Create(d.Deployment)
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	_, err = d.Client.Update(d.Deployment)
 	return err
 }
 
+// RestartPods triggers rolling restart of Deployment pods by changing pod template annotation
+func (d Deployment) RestartPods(annotation, value string) error {
+	return restartDeploymentPods(d.Client, d.Deployment.Name, annotation, value)
+}
+
 // Delete deletes Deployment from the cluster
-func (d Deployment) Delete() error {
+func (d Deployment) Delete(ctx context.Context) error {
 	return d.Client.Delete(d.Deployment.Name, nil)
 }
 
+// DeleteWithPolicy deletes Deployment from the cluster, policy defines what happens with its replica sets
+func (d Deployment) DeleteWithPolicy(policy DeletionPolicy) error {
+	return d.Client.Delete(d.Deployment.Name, policy.options())
+}
+
 // NameMatches gets resource definition and a name and checks if
 // the Deployment part of resource definition has matching name.
 func (d Deployment) NameMatches(def client.ResourceDefinition, name string) bool {
@@ -82,7 +222,7 @@ func (d Deployment) NewExisting(name string, c client.Interface) interfaces.Reso
 
 // NewDeployment is a constructor
 func NewDeployment(deployment *extbeta1.Deployment, client v1beta1.DeploymentInterface, meta map[string]interface{}) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: Deployment{Base: Base{meta}, Deployment: deployment, Client: client}}
+	return Deployment{Base: Base{meta}, Deployment: deployment, Client: client}
 }
 
 // ExistingDeployment is a wrapper for K8s Deployment object which is deployed on a cluster before AppController
@@ -102,31 +242,46 @@ func (d ExistingDeployment) Key() string {
 	return deploymentKey(d.Name)
 }
 
-// Status returns Deployment status as a string "ready" means that its dependencies can be created
-func (d ExistingDeployment) Status(meta map[string]string) (string, error) {
-	return deploymentStatus(d.Client, d.Name)
+// Status returns interfaces.ResourceStatus of the existing Deployment. Its phase is ready when enough
+// replicas are available for dependencies to be created, not ready with the reason and message of the
+// deployment condition while it is rolled out, and error if the rollout failed
+func (d ExistingDeployment) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	return deploymentStatus(d.Client, d.Name, meta)
 }
 
-// Create looks for existing Deployment and returns error if there is no such Deployment
-func (d ExistingDeployment) Create() error {
-	log.Println("Looking for deployment", d.Name)
-	status, err := d.Status(nil)
+// GetDependencyReport returns a DependencyReport for this deployment
+func (d ExistingDeployment) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return deploymentReport(d.Client, d.Name, meta)
+}
 
-	if err == nil {
-		log.Printf("Found deployment %s, status: %s", d.Name, status)
-		return nil
-	}
+// StatusIsCacheable returns false if meta contains SuccessFactorKey
+func (d ExistingDeployment) StatusIsCacheable(meta map[string]string) bool {
+	_, ok := meta[SuccessFactorKey]
+	return !ok
+}
+
+// Create looks for existing Deployment and returns error if there is no such Deployment. Deployment which
+// fails its rollout exists, its failure is reported by Status
+func (d ExistingDeployment) Create(ctx context.Context) error {
+	return createExistingResource(ctx, d)
+}
 
-	log.Fatalf("Deployment %s not found", d.Name)
-	return errors.New("Deployment not found")
+// RestartPods triggers rolling restart of Deployment pods by changing pod template annotation
+func (d ExistingDeployment) RestartPods(annotation, value string) error {
+	return restartDeploymentPods(d.Client, d.Name, annotation, value)
 }
 
 // Delete deletes Deployment from the cluster
-func (d ExistingDeployment) Delete() error {
+func (d ExistingDeployment) Delete(ctx context.Context) error {
 	return d.Client.Delete(d.Name, nil)
 }
 
+// DeleteWithPolicy deletes Deployment from the cluster, policy defines what happens with its replica sets
+func (d ExistingDeployment) DeleteWithPolicy(policy DeletionPolicy) error {
+	return d.Client.Delete(d.Name, policy.options())
+}
+
 // NewExistingDeployment is a constructor
 func NewExistingDeployment(name string, client v1beta1.DeploymentInterface) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: ExistingDeployment{Name: name, Client: client}}
+	return ExistingDeployment{Name: name, Client: client}
 }
@@ -17,19 +17,21 @@ package resources
 import (
 	"testing"
 
+	"golang.org/x/net/context"
+
 	"github.com/Mirantis/k8s-AppController/pkg/mocks"
 )
 
 // TestDeploymentSuccessCheck checks status of ready Deployment
 func TestDeploymentSuccessCheck(t *testing.T) {
 	c := mocks.NewClient(mocks.MakeDeployment("notfail"))
-	status, err := deploymentStatus(c.Deployments(), "notfail")
+	status, err := deploymentStatus(c.Deployments(), "notfail", nil)
 
 	if err != nil {
 		t.Error(err)
 	}
 
-	if status != "ready" {
+	if status.Phase != "ready" {
 		t.Errorf("Status should be `ready`, is `%s` instead.", status)
 	}
 }
@@ -37,13 +39,13 @@ func TestDeploymentSuccessCheck(t *testing.T) {
 // TestDeploymentFailUpdatedCheck checks status of not ready deployment
 func TestDeploymentFailUpdatedCheck(t *testing.T) {
 	c := mocks.NewClient(mocks.MakeDeployment("fail"))
-	status, err := deploymentStatus(c.Deployments(), "fail")
+	status, err := deploymentStatus(c.Deployments(), "fail", nil)
 
 	if err != nil {
 		t.Error(err)
 	}
 
-	if status != "not ready" {
+	if status.Phase != "not ready" {
 		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
 	}
 }
@@ -51,13 +53,125 @@ func TestDeploymentFailUpdatedCheck(t *testing.T) {
 // TestDeploymentFailAvailableCheck checks status of not ready deployment
 func TestDeploymentFailAvailableCheck(t *testing.T) {
 	c := mocks.NewClient(mocks.MakeDeployment("failav"))
-	status, err := deploymentStatus(c.Deployments(), "failav")
+	status, err := deploymentStatus(c.Deployments(), "failav", nil)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status.Phase != "not ready" {
+		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
+	}
+}
+
+// TestDeploymentNotObservedCheck checks that deployment with unprocessed spec is not ready
+func TestDeploymentNotObservedCheck(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeDeployment("notobserved"))
+	status, err := deploymentStatus(c.Deployments(), "notobserved", nil)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status.Phase != "not ready" {
+		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
+	}
+}
+
+// TestDeploymentUnavailableCheck checks that deployment with Available condition set to false is not ready
+func TestDeploymentUnavailableCheck(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeDeployment("unavailable"))
+	status, err := deploymentStatus(c.Deployments(), "unavailable", nil)
 
 	if err != nil {
 		t.Error(err)
 	}
 
-	if status != "not ready" {
+	if status.Phase != "not ready" {
 		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
 	}
 }
+
+// TestDeploymentReplicaFailureCheck checks that ReplicaFailure condition is reported as ResourceError
+func TestDeploymentReplicaFailureCheck(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeDeployment("replicafailure"))
+	status, err := deploymentStatus(c.Deployments(), "replicafailure", nil)
+
+	if _, ok := err.(ResourceError); !ok {
+		t.Errorf("Expected ResourceError, got %v", err)
+	}
+
+	if status.Phase != "error" {
+		t.Errorf("Status should be `error`, is `%s` instead.", status)
+	}
+}
+
+// TestExistingDeploymentFailingRollout checks that existing deployment which fails its rollout is found
+func TestExistingDeploymentFailingRollout(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeDeployment("replicafailure"))
+	if err := NewExistingDeployment("replicafailure", c.Deployments()).Create(context.Background()); err != nil {
+		t.Errorf("Expected existing deployment to be found, got %v", err)
+	}
+	if err := NewExistingDeployment("missing", c.Deployments()).Create(context.Background()); err == nil {
+		t.Error("Expected missing deployment not to be found")
+	}
+}
+
+// TestDeploymentSuccessFactorCheck checks that deployment is ready when success factor is reached
+func TestDeploymentSuccessFactorCheck(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeDeployment("fail"))
+	status, err := deploymentStatus(c.Deployments(), "fail", map[string]string{SuccessFactorKey: "60"})
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status.Phase != "ready" {
+		t.Errorf("Status should be `ready`, is `%s` instead.", status)
+	}
+}
+
+// TestDeploymentDependencyReport checks the report of not ready deployment
+func TestDeploymentDependencyReport(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeDeployment("failav"))
+	depReport := NewExistingDeployment("failav", c.Deployments()).GetDependencyReport(context.Background(), nil)
+
+	if !depReport.Blocks {
+		t.Error("Dependency report should block")
+	}
+
+	if depReport.Percentage != 66 {
+		t.Errorf("Expected percentage 66, got %d", depReport.Percentage)
+	}
+
+	expected := "2 of 3 replicas available, 3 updated"
+	if depReport.Message != expected {
+		t.Errorf("Expected message `%s`, got `%s`", expected, depReport.Message)
+	}
+
+	depReport = NewExistingDeployment("failav", c.Deployments()).GetDependencyReport(context.Background(), map[string]string{SuccessFactorKey: "60"})
+	if depReport.Blocks {
+		t.Error("Dependency report should not block")
+	}
+	if depReport.Needed != 60 {
+		t.Errorf("Expected needed percentage 60, got %d", depReport.Needed)
+	}
+}
+
+// TestDeploymentRestartPods checks that restarting pods sets pod template annotation
+func TestDeploymentRestartPods(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeDeployment("ready"))
+	deployment := ExistingDeployment{Name: "ready", Client: c.Deployments()}
+
+	if err := deployment.RestartPods("checksum", "abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := c.Deployments().Get("ready")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Spec.Template.Annotations["checksum"] != "abc" {
+		t.Errorf("Pod template annotation should be `abc`, is `%s` instead", d.Spec.Template.Annotations["checksum"])
+	}
+}
@@ -0,0 +1,373 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// IgnoreFieldsKey is a resource meta key with a list of dot-separated paths (e.g. "spec.replicas")
+// of fields which should not be compared when checking if the object in cluster matches the definition
+const IgnoreFieldsKey = "ignore-fields"
+
+// serverPopulatedFields are paths of fields which are set by API server and must never be compared
+var serverPopulatedFields = []string{
+	"metadata.uid",
+	"metadata.resourceVersion",
+	"metadata.creationTimestamp",
+	"metadata.deletionTimestamp",
+	"metadata.deletionGracePeriodSeconds",
+	"metadata.selfLink",
+	"metadata.generation",
+	"status",
+}
+
+// LastAppliedAnnotation is an annotation in which AppController stores the definition an object was created from
+const LastAppliedAnnotation = "appcontroller.k8s/last-applied-configuration"
+
+// equalToDefinition checks if the live object matches definition. Objects are compared in their
+// JSON form. Only fields present in the definition are compared, so fields defaulted or added by
+// API server do not make objects different. Fields listed in ignored are not compared at all.
+//
+// If the live object has LastAppliedAnnotation, a three-way comparison is made: when definition
+// did not change since it was applied, changes made to the object by others are not considered
+// a difference. Otherwise fields changed in the definition must match the live object and fields
+// removed from the definition must be absent from it.
+func equalToDefinition(definition, live interface{}, ignored []string) (bool, error) {
+	defObj, err := toUnstructured(definition)
+	if err != nil {
+		return false, err
+	}
+	liveObj, err := toUnstructured(live)
+	if err != nil {
+		return false, err
+	}
+	lastObj, err := lastApplied(liveObj)
+	if err != nil {
+		return false, err
+	}
+
+	for _, obj := range []map[string]interface{}{defObj, liveObj, lastObj} {
+		if obj == nil {
+			continue
+		}
+		removeLastApplied(obj)
+		removeRunLabel(obj)
+		for _, path := range append(serverPopulatedFields, ignored...) {
+			deletePath(obj, path)
+		}
+	}
+
+	if lastObj != nil && reflect.DeepEqual(defObj, lastObj) {
+		return true, nil
+	}
+	if !isSubset(defObj, liveObj) {
+		return false, nil
+	}
+	return lastObj == nil || !hasRemovedFields(defObj, lastObj, liveObj), nil
+}
+
+// ComparableObjects returns objects of the definition and of the cluster in the form in which they are
+// compared: as JSON, without fields populated by API server or ignored by IgnoreFieldsKey meta of the
+// resource, last applied configuration and run label. Fields of the live object which are not in the
+// definition and empty fields of the definition which are not in the live object are dropped, as they
+// never make objects different
+func ComparableObjects(r interfaces.BaseResource, definition, live interface{}) (map[string]interface{}, map[string]interface{}, error) {
+	defObj, err := toUnstructured(definition)
+	if err != nil {
+		return nil, nil, err
+	}
+	liveObj, err := toUnstructured(live)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, obj := range []map[string]interface{}{defObj, liveObj} {
+		if obj == nil {
+			continue
+		}
+		removeLastApplied(obj)
+		removeRunLabel(obj)
+		for _, path := range append(serverPopulatedFields, stringListMeta(r.Meta(IgnoreFieldsKey))...) {
+			deletePath(obj, path)
+		}
+	}
+	def, aligned := alignToDefinition(defObj, liveObj)
+	defObj, _ = def.(map[string]interface{})
+	liveObj, _ = aligned.(map[string]interface{})
+	return defObj, liveObj, nil
+}
+
+// alignToDefinition returns definition without empty fields missing from the live object, and the
+// live object with only the fields present in definition. Lists are aligned item by item if they
+// have the same length
+func alignToDefinition(def, live interface{}) (interface{}, interface{}) {
+	switch d := def.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return def, live
+		}
+		defResult := map[string]interface{}{}
+		liveResult := map[string]interface{}{}
+		for key, value := range d {
+			liveValue, ok := l[key]
+			if !ok {
+				if !isZero(value) {
+					defResult[key] = value
+				}
+				continue
+			}
+			defResult[key], liveResult[key] = alignToDefinition(value, liveValue)
+		}
+		return defResult, liveResult
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok || len(l) != len(d) {
+			return def, live
+		}
+		defResult := make([]interface{}, len(d))
+		liveResult := make([]interface{}, len(l))
+		for i := range d {
+			defResult[i], liveResult[i] = alignToDefinition(d[i], l[i])
+		}
+		return defResult, liveResult
+	}
+	return def, live
+}
+
+// lastApplied returns the object stored in LastAppliedAnnotation of given object, or nil if there is none
+func lastApplied(obj map[string]interface{}) (map[string]interface{}, error) {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	value, ok := annotations[LastAppliedAnnotation].(string)
+	if !ok {
+		return nil, nil
+	}
+	result := map[string]interface{}{}
+	err := json.Unmarshal([]byte(value), &result)
+	return result, err
+}
+
+func removeLastApplied(obj map[string]interface{}) {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	annotations, ok := metadata["annotations"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	delete(annotations, LastAppliedAnnotation)
+	if len(annotations) == 0 {
+		delete(metadata, "annotations")
+	}
+}
+
+// removeRunLabel removes RunIDLabel, which is different in every run, so it never makes objects different
+func removeRunLabel(obj map[string]interface{}) {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	labels, ok := metadata["labels"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	delete(labels, RunIDLabel)
+	if len(labels) == 0 {
+		delete(metadata, "labels")
+	}
+}
+
+// hasRemovedFields returns true if a field present in last applied definition was removed from
+// the current definition, but is still set in the live object
+func hasRemovedFields(def, last, live map[string]interface{}) bool {
+	for key, lastValue := range last {
+		liveValue, inLive := live[key]
+		defValue, inDef := def[key]
+		if !inDef {
+			if inLive && !isZero(liveValue) {
+				return true
+			}
+			continue
+		}
+		defMap, defIsMap := defValue.(map[string]interface{})
+		lastMap, lastIsMap := lastValue.(map[string]interface{})
+		liveMap, liveIsMap := liveValue.(map[string]interface{})
+		if defIsMap && lastIsMap && liveIsMap && hasRemovedFields(defMap, lastMap, liveMap) {
+			return true
+		}
+	}
+	return false
+}
+
+// setLastApplied stores the object in its LastAppliedAnnotation
+func setLastApplied(meta *v1.ObjectMeta, obj interface{}) error {
+	data, err := toUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	removeLastApplied(data)
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[LastAppliedAnnotation] = string(encoded)
+	return nil
+}
+
+func toUnstructured(obj interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+// deletePath removes field with given dot-separated path from the object. Paths cannot go through lists
+func deletePath(obj map[string]interface{}, path string) {
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := obj[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+		obj = next
+	}
+	delete(obj, parts[len(parts)-1])
+}
+
+// isSubset returns true if every field set in def has the same value in live
+func isSubset(def, live interface{}) bool {
+	switch d := def.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return isZero(def) && live == nil
+		}
+		for key, value := range d {
+			liveValue, ok := l[key]
+			if !ok {
+				if !isZero(value) {
+					return false
+				}
+				continue
+			}
+			if !isSubset(value, liveValue) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok {
+			return isZero(def) && live == nil
+		}
+		if len(d) != len(l) {
+			return false
+		}
+		for i := range d {
+			if !isSubset(d[i], l[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		if live == nil {
+			return isZero(def)
+		}
+		return reflect.DeepEqual(def, live)
+	}
+}
+
+// isZero checks if JSON value is empty, such values are usually omitted by API server
+func isZero(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		for _, item := range v {
+			if !isZero(item) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		return len(v) == 0
+	case string:
+		return v == ""
+	case float64:
+		return v == 0
+	case bool:
+		return !v
+	}
+	return false
+}
+
+// equalStringMaps compares maps treating nil and empty map as equal
+func equalStringMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if bValue, ok := b[key]; !ok || bValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// stringListMeta converts meta value which is either a list of strings or a comma-separated string to a slice
+func stringListMeta(value interface{}) []string {
+	var result []string
+	switch v := value.(type) {
+	case string:
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+	case []string:
+		result = v
+	}
+	return result
+}
+
+// equalToDefinition checks if the live object matches definition taking IgnoreFieldsKey meta into account.
+// If objects could not be compared, or if-exists policy is skip, they are considered equal, so that no
+// upgrade is attempted
+func (b Base) equalToDefinition(key string, definition, live interface{}) bool {
+	if b.Meta(IfExistsKey) == string(IfExistsSkip) {
+		return true
+	}
+	equal, err := equalToDefinition(definition, live, stringListMeta(b.Meta(IgnoreFieldsKey)))
+	if err != nil {
+		logging.ForResource(key).Warningf("Could not compare %s with its definition: %v", key, err)
+		return true
+	}
+	return equal
+}
@@ -0,0 +1,236 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestEqualToDefinitionServerFields checks that fields populated by API server are not compared
+func TestEqualToDefinitionServerFields(t *testing.T) {
+	definition := mocks.MakeService("svc")
+	definition.Spec.Ports = []v1.ServicePort{{Port: 80}}
+
+	live := mocks.MakeService("svc")
+	live.UID = "1234"
+	live.ResourceVersion = "42"
+	live.Annotations = map[string]string{"added-by": "controller"}
+	live.Spec.ClusterIP = "10.0.0.1"
+	live.Spec.Ports = []v1.ServicePort{{Port: 80, Protocol: v1.ProtocolTCP}}
+
+	equal, err := equalToDefinition(definition, live, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal {
+		t.Error("Service with server populated fields should be equal to its definition")
+	}
+}
+
+// TestEqualToDefinitionChangedField checks that changed field is detected
+func TestEqualToDefinitionChangedField(t *testing.T) {
+	definition := mocks.MakeDeployment("notfail")
+	definition.Spec.Replicas = mocks.Pointer(int32(5))
+	live := mocks.MakeDeployment("notfail")
+
+	equal, err := equalToDefinition(definition, live, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if equal {
+		t.Error("Deployment with changed replicas should not be equal to its definition")
+	}
+
+	equal, err = equalToDefinition(definition, live, []string{"spec.replicas"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal {
+		t.Error("Deployment with changed replicas should be equal to its definition when replicas are ignored")
+	}
+}
+
+// TestStringListMeta checks parsing of list meta values
+func TestStringListMeta(t *testing.T) {
+	fromString := stringListMeta("spec.replicas, metadata.labels")
+	fromList := stringListMeta([]interface{}{"spec.replicas", "metadata.labels"})
+	for _, list := range [][]string{fromString, fromList} {
+		if len(list) != 2 || list[0] != "spec.replicas" || list[1] != "metadata.labels" {
+			t.Errorf("Unexpected list %v", list)
+		}
+	}
+}
+
+// TestDeploymentWaitingForUpgrade checks that deployment differing from definition is waiting for upgrade
+func TestDeploymentWaitingForUpgrade(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeDeployment("notfail"))
+	definition := mocks.MakeDeployment("notfail")
+	definition.Spec.Replicas = mocks.Pointer(int32(5))
+
+	status, err := NewDeployment(definition, c.Deployments(), nil).Status(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != interfaces.ResourceWaitingForUpgrade {
+		t.Errorf("Status should be `%s`, is `%s` instead.", interfaces.ResourceWaitingForUpgrade, status)
+	}
+
+	meta := map[string]interface{}{IgnoreFieldsKey: []interface{}{"spec.replicas"}}
+	status, err = NewDeployment(definition, c.Deployments(), meta).Status(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != "ready" {
+		t.Errorf("Status should be `ready`, is `%s` instead.", status)
+	}
+
+	meta = map[string]interface{}{IfExistsKey: "skip"}
+	status, err = NewDeployment(definition, c.Deployments(), meta).Status(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != "ready" {
+		t.Errorf("Status of deployment with skip policy should be `ready`, is `%s` instead.", status)
+	}
+}
+
+// TestEqualToDefinitionThreeWay checks that changes made by others are ignored while definition stays the same
+func TestEqualToDefinitionThreeWay(t *testing.T) {
+	definition := mocks.MakeDeployment("notfail")
+	if err := setLastApplied(&definition.ObjectMeta, definition); err != nil {
+		t.Fatal(err)
+	}
+
+	// e.g. horizontal pod autoscaler changed the number of replicas
+	live := mocks.MakeDeployment("notfail")
+	live.Annotations = definition.Annotations
+	live.Spec.Replicas = mocks.Pointer(int32(7))
+
+	equal, err := equalToDefinition(definition, live, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal {
+		t.Error("Deployment changed by other controller should be equal to unchanged definition")
+	}
+
+	changed := mocks.MakeDeployment("notfail")
+	changed.Spec.Paused = true
+	equal, err = equalToDefinition(changed, live, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if equal {
+		t.Error("Deployment should not be equal to changed definition")
+	}
+}
+
+// TestEqualToDefinitionRemovedField checks that field removed from definition is detected
+func TestEqualToDefinitionRemovedField(t *testing.T) {
+	applied := mocks.MakeService("svc")
+	applied.Labels = map[string]string{"tier": "backend"}
+	live := mocks.MakeService("svc")
+	live.Labels = map[string]string{"tier": "backend"}
+	if err := setLastApplied(&live.ObjectMeta, applied); err != nil {
+		t.Fatal(err)
+	}
+
+	definition := mocks.MakeService("svc")
+	equal, err := equalToDefinition(definition, live, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if equal {
+		t.Error("Service should not be equal to definition with removed labels")
+	}
+}
+
+// TestDeploymentUpdate checks that updated deployment is no longer waiting for upgrade
+func TestDeploymentUpdate(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeDeployment("notfail"))
+	definition := mocks.MakeDeployment("notfail")
+	definition.Spec.Paused = true
+	deployment := NewDeployment(definition, c.Deployments(), nil)
+
+	if err := deployment.Update(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	live, err := c.Deployments().Get("notfail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !live.Spec.Paused {
+		t.Error("Deployment in cluster was not updated")
+	}
+	if _, ok := live.Annotations[LastAppliedAnnotation]; !ok {
+		t.Errorf("Updated deployment has no %s annotation", LastAppliedAnnotation)
+	}
+}
+
+// TestEqualToDefinitionRunLabel checks that objects created by different runs are equal
+func TestEqualToDefinitionRunLabel(t *testing.T) {
+	definition := mocks.MakeService("svc")
+	definition.Labels = map[string]string{GraphLabel: "default", RunIDLabel: "2"}
+	live := mocks.MakeService("svc")
+	live.Labels = map[string]string{GraphLabel: "default", RunIDLabel: "1"}
+	if err := setLastApplied(&live.ObjectMeta, live); err != nil {
+		t.Fatal(err)
+	}
+
+	equal, err := equalToDefinition(definition, live, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal {
+		t.Error("Service created by previous run should be equal to its definition")
+	}
+}
+
+// TestComparableObjects checks that only fields of the definition are kept in the live object
+func TestComparableObjects(t *testing.T) {
+	definition := mocks.MakeService("svc")
+	definition.Spec.Ports = []v1.ServicePort{{Port: 80}}
+
+	live := mocks.MakeService("svc")
+	live.UID = "1234"
+	live.Spec.ClusterIP = "10.0.0.1"
+	live.Spec.Ports = []v1.ServicePort{{Port: 8080, Protocol: v1.ProtocolTCP}}
+
+	defObj, liveObj, err := ComparableObjects(NewService(definition, nil, nil, nil), definition, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	liveSpec := liveObj["spec"].(map[string]interface{})
+	if _, ok := liveSpec["clusterIP"]; ok {
+		t.Error("Fields missing from definition should be dropped from live object")
+	}
+	livePort := liveSpec["ports"].([]interface{})[0].(map[string]interface{})
+	if livePort["port"] != 8080.0 {
+		t.Errorf("Changed port should be kept, got %v", livePort)
+	}
+	if _, ok := liveObj["metadata"].(map[string]interface{})["uid"]; ok {
+		t.Error("Server populated fields should be dropped")
+	}
+	if defPort := defObj["spec"].(map[string]interface{})["ports"].([]interface{})[0]; defPort.(map[string]interface{})["port"] != 80.0 {
+		t.Errorf("Unexpected port of definition %v", defPort)
+	}
+}
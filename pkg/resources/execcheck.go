@@ -0,0 +1,215 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+const defaultExecCheckTimeout = 30 * time.Second
+
+// ExecChecksDir is a directory with executables exec checks may run. Exec checks are disabled unless it
+// is set, as their commands run in AppController pod with its permissions
+var ExecChecksDir = ""
+
+// execCheckPath is PATH of exec check commands
+const execCheckPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// execCheckOutputLimit is the number of trailing bytes of the output of failed command shown in status
+const execCheckOutputLimit = 512
+
+// ExecCheck is a node which runs a command in AppController pod and waits for it to exit with zero
+// code. Nothing is created for it in the cluster
+type ExecCheck struct {
+	Base
+	Check *client.ExecCheck
+}
+
+func execCheckKey(name string) string {
+	return "execcheck/" + name
+}
+
+// Key returns ExecCheck key
+func (c ExecCheck) Key() string {
+	return execCheckKey(c.Check.Name)
+}
+
+// metaEnvName returns name of environment variable for meta key, e.g. AC_META_WAIT_TIMEOUT for wait-timeout
+func metaEnvName(key string) string {
+	return "AC_META_" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+// env returns environment of the command: PATH, key of the node in AC_KEY and meta of its definition
+// in AC_META_ variables. Values which are not strings are passed as JSON. Environment of AppController,
+// which may hold its credentials, is not passed
+func (c ExecCheck) env() []string {
+	env := []string{"PATH=" + execCheckPath, "AC_KEY=" + c.Key()}
+	keys := make([]string, 0, len(c.meta))
+	for key := range c.meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, ok := c.meta[key].(string)
+		if !ok {
+			data, err := json.Marshal(c.meta[key])
+			if err != nil {
+				continue
+			}
+			value = string(data)
+		}
+		env = append(env, metaEnvName(key)+"="+value)
+	}
+	return env
+}
+
+// executable returns path of the executable of the command, which must be in ExecChecksDir. Relative
+// paths are relative to the directory
+func (c ExecCheck) executable() (string, error) {
+	if ExecChecksDir == "" {
+		return "", fmt.Errorf("%s can not run, exec checks are disabled", c.Key())
+	}
+	dir, err := filepath.EvalSymlinks(ExecChecksDir)
+	if err != nil {
+		return "", err
+	}
+	path := c.Check.Command[0]
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(ExecChecksDir, path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("could not run %s: %v", c.Check.Command[0], err)
+	}
+	if rel, err := filepath.Rel(dir, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not in exec checks directory %s", c.Check.Command[0], ExecChecksDir)
+	}
+	return resolved, nil
+}
+
+// execCheckStatus runs the command. Non-zero exit code or timeout makes the check not ready, with
+// message explaining the reason, while missing command results in error. The command is killed when
+// the context is cancelled
+func (c ExecCheck) execCheckStatus(ctx context.Context) (interfaces.ResourceStatus, string, error) {
+	if len(c.Check.Command) == 0 {
+		return interfaces.NewStatus(interfaces.ResourceError), "", fmt.Errorf("%s must have command", c.Key())
+	}
+	executable, err := c.executable()
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), "", err
+	}
+	timeout := defaultExecCheckTimeout
+	if c.Check.TimeoutSeconds > 0 {
+		timeout = time.Duration(c.Check.TimeoutSeconds) * time.Second
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(runCtx, executable, c.Check.Command[1:]...)
+	cmd.Env = c.env()
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err = cmd.Run()
+	if err == nil {
+		return interfaces.NewStatus(interfaces.ResourceReady), fmt.Sprintf("%s exited with zero code", c.Check.Command[0]), nil
+	}
+	if ctx.Err() != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), "", ctx.Err()
+	}
+	if runCtx.Err() != nil {
+		return interfaces.NewStatus(interfaces.ResourceNotReady), fmt.Sprintf("%s did not exit in %v", c.Check.Command[0], timeout), nil
+	}
+	if _, ok := err.(*exec.ExitError); !ok {
+		return interfaces.NewStatus(interfaces.ResourceError), "", fmt.Errorf("could not run %s: %v", c.Check.Command[0], err)
+	}
+	message := fmt.Sprintf("%s failed: %v", c.Check.Command[0], err)
+	if out := strings.TrimSpace(output.String()); out != "" {
+		if len(out) > execCheckOutputLimit {
+			out = "..." + out[len(out)-execCheckOutputLimit:]
+		}
+		message += ": " + out
+	}
+	return interfaces.NewStatus(interfaces.ResourceNotReady), message, nil
+}
+
+// Status runs the command
+func (c ExecCheck) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	status, message, err := c.execCheckStatus(ctx)
+	if err == nil && status.Phase != interfaces.ResourceReady {
+		logging.ForResource(c.Key()).Debugf("%s is not ready: %s", c.Key(), message)
+		status = status.WithReason("CheckFailed", message)
+	}
+	return status, err
+}
+
+// GetDependencyReport returns a DependencyReport for this ExecCheck
+func (c ExecCheck) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	status, message, err := c.execCheckStatus(ctx)
+	return statusReport(c.Key(), status, err, message)
+}
+
+// Create does nothing, as there is nothing to create in the cluster
+func (c ExecCheck) Create(ctx context.Context) error {
+	return nil
+}
+
+// Delete does nothing, as there is nothing to delete from the cluster
+func (c ExecCheck) Delete(ctx context.Context) error {
+	return nil
+}
+
+// NameMatches gets resource definition and a name and checks if
+// the ExecCheck part of resource definition has matching name.
+func (c ExecCheck) NameMatches(def client.ResourceDefinition, name string) bool {
+	return def.ExecCheck != nil && def.ExecCheck.Name == name
+}
+
+// New returns new ExecCheck based on resource definition
+func (c ExecCheck) New(def client.ResourceDefinition, ci client.Interface) interfaces.Resource {
+	return NewExecCheck(def.ExecCheck, def.Meta)
+}
+
+// NewExisting returns ExecCheck without command, as exec checks can not exist without definition.
+// Its status is always an error
+func (c ExecCheck) NewExisting(name string, ci client.Interface) interfaces.Resource {
+	return NewExecCheck(&client.ExecCheck{Name: name}, nil)
+}
+
+// NewExecCheck is a constructor for ExecCheck
+func NewExecCheck(check *client.ExecCheck, meta map[string]interface{}) interfaces.Resource {
+	return ExecCheck{Base: Base{meta}, Check: check}
+}
@@ -0,0 +1,123 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+)
+
+// withExecChecks makes scripts with given names and bodies the only exec checks which can be run, and
+// returns function restoring exec checks directory
+func withExecChecks(t *testing.T, scripts map[string]string) (string, func()) {
+	dir, err := ioutil.TempDir("", "execchecks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range scripts {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	previous := ExecChecksDir
+	ExecChecksDir = dir
+	return dir, func() {
+		ExecChecksDir = previous
+		os.RemoveAll(dir)
+	}
+}
+
+// TestExecCheck checks that exec check is ready when the command exits with zero code and gets meta
+// of the node, but not environment of AppController
+func TestExecCheck(t *testing.T) {
+	dir, restore := withExecChecks(t, map[string]string{
+		"ok":   "exit 0",
+		"fail": "exit 1",
+		"env":  `test "$AC_KEY" = execcheck/check && test "$AC_META_EXPECTED_SIZE" = "$1" && test "$AC_META_MODE" = fast && test -z "$EXEC_CHECK_SECRET"`,
+		"slow": "exec sleep 5",
+	})
+	defer restore()
+	os.Setenv("EXEC_CHECK_SECRET", "token")
+	defer os.Unsetenv("EXEC_CHECK_SECRET")
+
+	meta := map[string]interface{}{"expected-size": 3, "mode": "fast"}
+	cases := []struct {
+		command  []string
+		expected interfaces.ResourcePhase
+	}{
+		{[]string{"ok"}, interfaces.ResourceReady},
+		{[]string{filepath.Join(dir, "ok")}, interfaces.ResourceReady},
+		{[]string{"fail"}, interfaces.ResourceNotReady},
+		{[]string{"env", "3"}, interfaces.ResourceReady},
+		{[]string{"env", "4"}, interfaces.ResourceNotReady},
+		{[]string{"slow"}, interfaces.ResourceNotReady},
+	}
+	for _, c := range cases {
+		check := NewExecCheck(&client.ExecCheck{Name: "check", Command: c.command, TimeoutSeconds: 1}, meta)
+		status, err := check.Status(context.Background(), nil)
+		if err != nil {
+			t.Error(err)
+		}
+		if status.Phase != c.expected {
+			t.Errorf("Status of %v should be `%s`, is `%s` instead.", c.command, c.expected, status)
+		}
+	}
+}
+
+// TestExecCheckOutput checks that output of failed command is shown in status
+func TestExecCheckOutput(t *testing.T) {
+	_, restore := withExecChecks(t, map[string]string{"db": "echo database is down; exit 3"})
+	defer restore()
+
+	check := NewExecCheck(&client.ExecCheck{Name: "check", Command: []string{"db"}}, nil)
+	status, err := check.Status(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(status.Message, "database is down") {
+		t.Errorf("expected output of the command in status, got %q", status.Message)
+	}
+}
+
+// TestExecCheckInvalid checks that check without command, with missing executable or executable outside
+// of exec checks directory results in error
+func TestExecCheckInvalid(t *testing.T) {
+	dir, restore := withExecChecks(t, map[string]string{"ok": "exit 0"})
+	defer restore()
+	if err := os.Symlink("/bin/true", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, command := range [][]string{nil, {"missing"}, {"/bin/true"}, {"../" + filepath.Base(dir) + "/../../bin/true"}, {"link"}} {
+		status, err := NewExecCheck(&client.ExecCheck{Name: "check", Command: command}, nil).Status(context.Background(), nil)
+		if err == nil || status.Phase != interfaces.ResourceError {
+			t.Errorf("Status of %v should be error, got %s, %v", command, status, err)
+		}
+	}
+
+	ExecChecksDir = ""
+	status, err := NewExecCheck(&client.ExecCheck{Name: "check", Command: []string{"ok"}}, nil).Status(context.Background(), nil)
+	if err == nil || status.Phase != interfaces.ResourceError {
+		t.Errorf("Exec checks should be disabled without directory, got %s, %v", status, err)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"regexp"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+const defaultExternalCheckTimeout = 5 * time.Second
+
+// ExternalCheck is a node which waits for an endpoint outside of the cluster to become available.
+// Nothing is created for it in the cluster
+type ExternalCheck struct {
+	Base
+	Check *client.ExternalCheck
+}
+
+func externalCheckKey(name string) string {
+	return "externalcheck/" + name
+}
+
+// Key returns ExternalCheck key
+func (c ExternalCheck) Key() string {
+	return externalCheckKey(c.Check.Name)
+}
+
+// externalCheckStatus performs the check. Unavailable endpoint makes check not ready, with
+// message explaining the reason, while invalid check definition results in error. HTTP requests are
+// cancelled along with the context
+func externalCheckStatus(ctx context.Context, check *client.ExternalCheck) (interfaces.ResourceStatus, string, error) {
+	timeout := defaultExternalCheckTimeout
+	if check.TimeoutSeconds > 0 {
+		timeout = time.Duration(check.TimeoutSeconds) * time.Second
+	}
+
+	switch {
+	case check.URL != "" && check.Address != "":
+		return interfaces.NewStatus(interfaces.ResourceError), "", errors.New("only one of url and address can be set")
+	case check.URL != "":
+		return httpCheckStatus(ctx, check, timeout)
+	case check.Address != "":
+		conn, err := net.DialTimeout("tcp", check.Address, timeout)
+		if err != nil {
+			return interfaces.NewStatus(interfaces.ResourceNotReady), fmt.Sprintf("could not connect to %s: %v", check.Address, err), nil
+		}
+		conn.Close()
+		return interfaces.NewStatus(interfaces.ResourceReady), fmt.Sprintf("%s is reachable", check.Address), nil
+	}
+	return interfaces.NewStatus(interfaces.ResourceError), "", fmt.Errorf("%s must have either url or address", externalCheckKey(check.Name))
+}
+
+func httpCheckStatus(ctx context.Context, check *client.ExternalCheck, timeout time.Duration) (interfaces.ResourceStatus, string, error) {
+	var bodyRegex *regexp.Regexp
+	if check.BodyRegex != "" {
+		var err error
+		if bodyRegex, err = regexp.Compile(check.BodyRegex); err != nil {
+			return interfaces.NewStatus(interfaces.ResourceError), "", err
+		}
+	}
+	expectedStatus := check.ExpectedStatus
+	if expectedStatus == 0 {
+		expectedStatus = http.StatusOK
+	}
+
+	httpClient := &http.Client{Timeout: timeout}
+	resp, err := ctxhttp.Get(ctx, httpClient, check.URL)
+	if err != nil {
+		if ctx.Err() != nil {
+			return interfaces.NewStatus(interfaces.ResourceError), "", ctx.Err()
+		}
+		return interfaces.NewStatus(interfaces.ResourceNotReady), fmt.Sprintf("GET %s failed: %v", check.URL, err), nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatus {
+		return interfaces.NewStatus(interfaces.ResourceNotReady), fmt.Sprintf("GET %s returned %d, expected %d", check.URL, resp.StatusCode, expectedStatus), nil
+	}
+	if bodyRegex != nil {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return interfaces.NewStatus(interfaces.ResourceNotReady), fmt.Sprintf("could not read response of GET %s: %v", check.URL, err), nil
+		}
+		if !bodyRegex.Match(body) {
+			return interfaces.NewStatus(interfaces.ResourceNotReady), fmt.Sprintf("response of GET %s does not match %s", check.URL, check.BodyRegex), nil
+		}
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), fmt.Sprintf("GET %s returned %d", check.URL, resp.StatusCode), nil
+}
+
+// Status performs the check
+func (c ExternalCheck) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	status, message, err := externalCheckStatus(ctx, c.Check)
+	if err == nil && status.Phase != interfaces.ResourceReady {
+		logging.ForResource(c.Key()).Debugf("%s is not ready: %s", c.Key(), message)
+		status = status.WithReason("CheckFailed", message)
+	}
+	return status, err
+}
+
+// GetDependencyReport returns a DependencyReport for this ExternalCheck
+func (c ExternalCheck) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	status, message, err := externalCheckStatus(ctx, c.Check)
+	return statusReport(c.Key(), status, err, message)
+}
+
+// Create does nothing, as there is nothing to create in the cluster
+func (c ExternalCheck) Create(ctx context.Context) error {
+	return nil
+}
+
+// Delete does nothing, as there is nothing to delete from the cluster
+func (c ExternalCheck) Delete(ctx context.Context) error {
+	return nil
+}
+
+// NameMatches gets resource definition and a name and checks if
+// the ExternalCheck part of resource definition has matching name.
+func (c ExternalCheck) NameMatches(def client.ResourceDefinition, name string) bool {
+	return def.ExternalCheck != nil && def.ExternalCheck.Name == name
+}
+
+// New returns new ExternalCheck based on resource definition
+func (c ExternalCheck) New(def client.ResourceDefinition, ci client.Interface) interfaces.Resource {
+	return NewExternalCheck(def.ExternalCheck, def.Meta)
+}
+
+// NewExisting returns ExternalCheck without url and address, as external checks can not exist
+// without definition. Its status is always an error
+func (c ExternalCheck) NewExisting(name string, ci client.Interface) interfaces.Resource {
+	return NewExternalCheck(&client.ExternalCheck{Name: name}, nil)
+}
+
+// NewExternalCheck is a constructor for ExternalCheck
+func NewExternalCheck(check *client.ExternalCheck, meta map[string]interface{}) interfaces.Resource {
+	return ExternalCheck{Base: Base{meta}, Check: check}
+}
@@ -0,0 +1,96 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+)
+
+// TestExternalCheckHTTP checks HTTP external check with expected status and body regex
+func TestExternalCheckHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unavailable" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"status": "ok"}`)
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		check    client.ExternalCheck
+		expected interfaces.ResourcePhase
+	}{
+		{client.ExternalCheck{URL: server.URL}, interfaces.ResourceReady},
+		{client.ExternalCheck{URL: server.URL, BodyRegex: `"status": "ok"`}, interfaces.ResourceReady},
+		{client.ExternalCheck{URL: server.URL, BodyRegex: `"status": "failed"`}, interfaces.ResourceNotReady},
+		{client.ExternalCheck{URL: server.URL + "/unavailable"}, interfaces.ResourceNotReady},
+		{client.ExternalCheck{URL: server.URL + "/unavailable", ExpectedStatus: 503}, interfaces.ResourceReady},
+	}
+	for _, c := range cases {
+		check := c.check
+		status, err := NewExternalCheck(&check, nil).Status(context.Background(), nil)
+		if err != nil {
+			t.Error(err)
+		}
+		if status.Phase != c.expected {
+			t.Errorf("Status of %+v should be `%s`, is `%s` instead.", c.check, c.expected, status)
+		}
+	}
+}
+
+// TestExternalCheckTCP checks TCP external check
+func TestExternalCheckTCP(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	address := strings.TrimPrefix(server.URL, "http://")
+
+	check := NewExternalCheck(&client.ExternalCheck{Name: "tcp", Address: address}, nil)
+	status, err := check.Status(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != interfaces.ResourceReady {
+		t.Errorf("Status should be `ready`, is `%s` instead.", status)
+	}
+
+	server.Close()
+	depReport := check.GetDependencyReport(context.Background(), nil)
+	if !depReport.Blocks {
+		t.Error("Dependency report for closed port should block")
+	}
+	if !strings.Contains(depReport.Message, address) {
+		t.Errorf("Dependency report message `%s` should mention the address", depReport.Message)
+	}
+}
+
+// TestExternalCheckInvalid checks that check without url and address is an error
+func TestExternalCheckInvalid(t *testing.T) {
+	status, err := ExternalCheck{}.NewExisting("missing", nil).Status(context.Background(), nil)
+	if err == nil {
+		t.Error("Error not found, expected error")
+	}
+	if status.Phase != interfaces.ResourceError {
+		t.Errorf("Status should be `error`, is `%s` instead.", status)
+	}
+}
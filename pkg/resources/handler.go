@@ -0,0 +1,170 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	apierrors "k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/unversioned"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/handler"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+const defaultHandlerTimeout = 10 * time.Second
+
+// handlerResource is a name of resources of handlers in not found and already exists errors
+var handlerResource = unversioned.GroupResource{Resource: "handlers"}
+
+// Handler is a node whose resource is created, deleted and checked by an external service implementing
+// ResourceHandler gRPC protocol
+type Handler struct {
+	Base
+	Handler *client.Handler
+}
+
+func handlerKey(name string) string {
+	return "handler/" + name
+}
+
+// Key returns Handler key
+func (h Handler) Key() string {
+	return handlerKey(h.Handler.Name)
+}
+
+// call sends request for the resource to the handler with timeout. NOT_FOUND and ALREADY_EXISTS codes
+// are converted to errors of K8s API, so that resources of handlers are treated as K8s objects
+func (h Handler) call(ctx context.Context, meta map[string]string, method func(handler.ResourceHandlerClient, context.Context, *handler.Request) error) error {
+	if h.Handler.Endpoint == "" {
+		return fmt.Errorf("%s must have endpoint", h.Key())
+	}
+	parameters := []byte("{}")
+	if h.Handler.Parameters != nil {
+		var err error
+		if parameters, err = json.Marshal(h.Handler.Parameters); err != nil {
+			return err
+		}
+	}
+	c, err := handler.Dial(h.Handler.Endpoint, h.Handler.Insecure)
+	if err != nil {
+		return err
+	}
+	timeout := defaultHandlerTimeout
+	if h.Handler.TimeoutSeconds > 0 {
+		timeout = time.Duration(h.Handler.TimeoutSeconds) * time.Second
+	}
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err = method(c, callCtx, &handler.Request{Key: h.Key(), Name: h.Handler.Name, Parameters: string(parameters), Meta: meta})
+	switch grpc.Code(err) {
+	case codes.NotFound:
+		return apierrors.NewNotFound(handlerResource, h.Handler.Name)
+	case codes.AlreadyExists:
+		return apierrors.NewAlreadyExists(handlerResource, h.Handler.Name)
+	}
+	return err
+}
+
+// Status returns status reported by the handler
+func (h Handler) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	var resp *handler.StatusResponse
+	err := h.call(ctx, meta, func(c handler.ResourceHandlerClient, ctx context.Context, req *handler.Request) (err error) {
+		resp, err = c.Status(ctx, req)
+		return err
+	})
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+
+	phase := interfaces.ResourcePhase(resp.Phase)
+	switch phase {
+	case interfaces.ResourceReady:
+		return interfaces.NewStatus(phase).WithReason(resp.Reason, resp.Message), nil
+	case interfaces.ResourceNotReady:
+		logging.ForResource(h.Key()).Debugf("%s is not ready: %s", h.Key(), resp.Message)
+		status := interfaces.NewStatus(phase).WithReason(resp.Reason, resp.Message)
+		status.Progress = int(resp.Progress)
+		return status, nil
+	case interfaces.ResourceError:
+		return failedStatus(NewResourceError(h.Key(), resp.Reason, resp.Message))
+	}
+	return interfaces.NewStatus(interfaces.ResourceError), fmt.Errorf("handler of %s returned unknown phase %q", h.Key(), resp.Phase)
+}
+
+// GetDependencyReport returns a DependencyReport made by the handler
+func (h Handler) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	var resp *handler.ReportResponse
+	err := h.call(ctx, meta, func(c handler.ResourceHandlerClient, ctx context.Context, req *handler.Request) (err error) {
+		resp, err = c.Report(ctx, req)
+		return err
+	})
+	if err != nil {
+		return statusReport(h.Key(), interfaces.NewStatus(interfaces.ResourceError), err, "")
+	}
+	return interfaces.DependencyReport{
+		Dependency: h.Key(),
+		Blocks:     resp.Blocks,
+		Percentage: int(resp.Percentage),
+		Needed:     int(resp.Needed),
+		Message:    resp.Message,
+	}
+}
+
+// Create asks the handler to create the resource
+func (h Handler) Create(ctx context.Context) error {
+	return h.call(ctx, nil, func(c handler.ResourceHandlerClient, ctx context.Context, req *handler.Request) error {
+		_, err := c.Create(ctx, req)
+		return err
+	})
+}
+
+// Delete asks the handler to delete the resource
+func (h Handler) Delete(ctx context.Context) error {
+	return h.call(ctx, nil, func(c handler.ResourceHandlerClient, ctx context.Context, req *handler.Request) error {
+		_, err := c.Delete(ctx, req)
+		return err
+	})
+}
+
+// NameMatches gets resource definition and a name and checks if
+// the Handler part of resource definition has matching name.
+func (h Handler) NameMatches(def client.ResourceDefinition, name string) bool {
+	return def.Handler != nil && def.Handler.Name == name
+}
+
+// New returns new Handler based on resource definition
+func (h Handler) New(def client.ResourceDefinition, ci client.Interface) interfaces.Resource {
+	return NewHandler(def.Handler, def.Meta)
+}
+
+// NewExisting returns Handler without endpoint, as handler resources can not be found without
+// definition. Its status is always an error
+func (h Handler) NewExisting(name string, ci client.Interface) interfaces.Resource {
+	return NewHandler(&client.Handler{Name: name}, nil)
+}
+
+// NewHandler is a constructor for Handler
+func NewHandler(h *client.Handler, meta map[string]interface{}) interfaces.Resource {
+	return Handler{Base: Base{meta}, Handler: h}
+}
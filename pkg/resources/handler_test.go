@@ -0,0 +1,130 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	apierrors "k8s.io/client-go/pkg/api/errors"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/handler"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+)
+
+// fakeHandler keeps sizes of created databases, a database is ready when its size is at least the
+// size required by dependency meta
+type fakeHandler struct {
+	sizes map[string]int
+}
+
+func (f *fakeHandler) Create(ctx context.Context, req *handler.Request) (*handler.CreateResponse, error) {
+	if _, ok := f.sizes[req.Name]; ok {
+		return nil, grpc.Errorf(codes.AlreadyExists, "%s exists", req.Name)
+	}
+	var parameters struct {
+		Size int `json:"size"`
+	}
+	if err := json.Unmarshal([]byte(req.Parameters), &parameters); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "invalid parameters: %v", err)
+	}
+	f.sizes[req.Name] = parameters.Size
+	return &handler.CreateResponse{}, nil
+}
+
+func (f *fakeHandler) Delete(ctx context.Context, req *handler.Request) (*handler.DeleteResponse, error) {
+	if _, ok := f.sizes[req.Name]; !ok {
+		return nil, grpc.Errorf(codes.NotFound, "%s not found", req.Name)
+	}
+	delete(f.sizes, req.Name)
+	return &handler.DeleteResponse{}, nil
+}
+
+func (f *fakeHandler) Status(ctx context.Context, req *handler.Request) (*handler.StatusResponse, error) {
+	size, ok := f.sizes[req.Name]
+	if !ok {
+		return nil, grpc.Errorf(codes.NotFound, "%s not found", req.Name)
+	}
+	if req.Meta["min-size"] == "10" && size < 10 {
+		return &handler.StatusResponse{Phase: "not ready", Reason: "TooSmall", Message: "database is too small", Progress: int32(size * 10)}, nil
+	}
+	return &handler.StatusResponse{Phase: "ready"}, nil
+}
+
+func (f *fakeHandler) Report(ctx context.Context, req *handler.Request) (*handler.ReportResponse, error) {
+	return &handler.ReportResponse{Blocks: false, Percentage: 100, Needed: 100, Message: "ready"}, nil
+}
+
+// TestHandler checks that handler resource is created, checked and deleted by gRPC calls to its endpoint
+func TestHandler(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	handler.RegisterResourceHandlerServer(server, &fakeHandler{sizes: map[string]int{}})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	ctx := context.Background()
+	h := NewHandler(&client.Handler{Name: "db", Endpoint: listener.Addr().String(), Insecure: true, Parameters: map[string]interface{}{"size": 5}}, nil)
+	if _, err = h.Status(ctx, nil); !apierrors.IsNotFound(err) {
+		t.Errorf("expected not found error before creation, got %v", err)
+	}
+	if err = h.Create(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = h.Create(ctx); !apierrors.IsAlreadyExists(err) {
+		t.Errorf("expected already exists error, got %v", err)
+	}
+
+	status, err := h.Status(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Phase != interfaces.ResourceReady {
+		t.Errorf("expected ready status, got %v", status)
+	}
+	status, err = h.Status(ctx, map[string]string{"min-size": "10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Phase != interfaces.ResourceNotReady || status.Reason != "TooSmall" || status.Progress != 50 {
+		t.Errorf("expected not ready status with reason and progress, got %v", status)
+	}
+	if report := h.GetDependencyReport(ctx, nil); report.Blocks || report.Dependency != "handler/db" {
+		t.Errorf("unexpected report %v", report)
+	}
+
+	if err = h.Delete(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = h.Delete(ctx); !apierrors.IsNotFound(err) {
+		t.Errorf("expected not found error after deletion, got %v", err)
+	}
+}
+
+// TestHandlerWithoutEndpoint checks that handler without endpoint results in error
+func TestHandlerWithoutEndpoint(t *testing.T) {
+	status, err := NewHandler(&client.Handler{Name: "db"}, nil).Status(context.Background(), nil)
+	if err == nil || status.Phase != interfaces.ResourceError {
+		t.Errorf("expected error status, got %v, %v", status, err)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/apis/batch/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+)
+
+const (
+	// PreCreateHookKey is a meta key of resource definition with a job which must succeed before the object
+	// of the definition is created
+	PreCreateHookKey = "pre-create-hook"
+	// PostReadyHookKey is a meta key of resource definition with a job which is run once the object of the
+	// definition is ready, e.g. to seed data. Dependents of the resource wait for the job to succeed
+	PostReadyHookKey = "post-ready-hook"
+)
+
+// hook phases, which are a part of keys of hook nodes
+const (
+	HookPreCreate = "pre-create"
+	HookPostReady = "post-ready"
+)
+
+// hookMetaKeys are meta keys of hooks by their phases
+var hookMetaKeys = map[string]string{
+	HookPreCreate: PreCreateHookKey,
+	HookPostReady: PostReadyHookKey,
+}
+
+// Hook is a job attached to another resource of the graph, which runs before the resource is created or
+// after it becomes ready. Hooks are synthetic graph nodes, they have no resource definitions of their own
+type Hook struct {
+	Job
+	// Owner is a key of the resource the hook is attached to
+	Owner string
+	Phase string
+}
+
+// HookKey returns key of graph node of the hook of given phase attached to the resource with given key
+func HookKey(owner, phase string) string {
+	return "hook/" + phase + "/" + owner
+}
+
+// Key returns hook key
+func (h Hook) Key() string {
+	return HookKey(h.Owner, h.Phase)
+}
+
+// GetDependencyReport returns a DependencyReport of the hook job
+func (h Hook) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	depReport := h.Job.GetDependencyReport(ctx, meta)
+	depReport.Dependency = h.Key()
+	return depReport
+}
+
+// NewHook returns hook of given phase described in meta of the resource, or nil if the resource does not
+// have one. The job of the hook is named after the resource and the phase unless its name is set
+func NewHook(r interfaces.BaseResource, phase string, c client.Interface) (interfaces.Resource, error) {
+	key := hookMetaKeys[phase]
+	value := r.Meta(key)
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	job := &v1.Job{}
+	if err := json.Unmarshal(data, job); err != nil {
+		return nil, fmt.Errorf("%s of %s is not a valid job: %v", key, r.Key(), err)
+	}
+	if job.Name == "" {
+		job.Name = strings.Replace(r.Key(), "/", "-", -1) + "-" + phase
+	}
+	return Hook{Job: Job{Job: job, Client: c.Jobs()}, Owner: r.Key(), Phase: phase}, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestNewHook checks that hook job is taken from meta of the resource and named after it
+func TestNewHook(t *testing.T) {
+	c := mocks.NewClient()
+	meta := map[string]interface{}{
+		PreCreateHookKey: map[string]interface{}{"metadata": map[string]interface{}{"name": "migrate"}},
+		PostReadyHookKey: map[string]interface{}{"spec": map[string]interface{}{}},
+	}
+	pod := NewPod(mocks.MakePod("db"), c.Pods(), meta)
+
+	hook, err := NewHook(pod, HookPreCreate, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hook.Key() != "hook/pre-create/pod/db" {
+		t.Errorf("Unexpected hook key %s", hook.Key())
+	}
+	if name := hook.(Hook).Job.Job.Name; name != "migrate" {
+		t.Errorf("Hook job should keep its name, got %s", name)
+	}
+
+	hook, err = NewHook(pod, HookPostReady, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := hook.(Hook).Job.Job.Name; name != "pod-db-post-ready" {
+		t.Errorf("Hook job should be named after the resource, got %s", name)
+	}
+	if err := hook.Create(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Jobs().Get("pod-db-post-ready"); err != nil {
+		t.Errorf("Hook job should be created, got %v", err)
+	}
+	if depReport := hook.GetDependencyReport(context.Background(), nil); depReport.Dependency != hook.Key() || !depReport.Blocks {
+		t.Errorf("Report of running hook should block and refer to the hook, got %+v", depReport)
+	}
+
+	if hook, err = NewHook(NewPod(mocks.MakePod("db"), c.Pods(), nil), HookPreCreate, c); hook != nil || err != nil {
+		t.Errorf("Resource without hooks should not have one, got %v, %v", hook, err)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// IfExistsKey is a meta key of resource definition which sets what happens when the object of the
+// definition already exists in the cluster before it is created
+const IfExistsKey = "if-exists"
+
+// ExistencePolicy describes how creation of the resource treats object which already exists
+type ExistencePolicy string
+
+// Possible values for ExistencePolicy
+const (
+	// IfExistsAdopt keeps the object and upgrades it if it differs from the definition. This is the default
+	IfExistsAdopt ExistencePolicy = "adopt"
+	// IfExistsSkip keeps the object as it is, it is never compared with the definition nor upgraded
+	IfExistsSkip ExistencePolicy = "skip"
+	// IfExistsFail fails creation of the resource
+	IfExistsFail ExistencePolicy = "fail"
+	// IfExistsReplace deletes the object and creates it again from the definition
+	IfExistsReplace ExistencePolicy = "replace"
+)
+
+// GetExistencePolicy returns existence policy set in meta of the resource
+func GetExistencePolicy(r interfaces.BaseResource) ExistencePolicy {
+	policy := ExistencePolicy(GetStringMeta(r, IfExistsKey, string(IfExistsAdopt)))
+	switch policy {
+	case IfExistsAdopt, IfExistsSkip, IfExistsFail, IfExistsReplace:
+		return policy
+	}
+	logging.ForResource(r.Key()).Warningf("Unknown %s policy '%s' of %s, using %s", IfExistsKey, policy, r.Key(), IfExistsAdopt)
+	return IfExistsAdopt
+}
@@ -0,0 +1,223 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+const defaultImageCheckTimeout = 10 * time.Second
+
+// CheckedImage is an image verified by ImageCheck together with image pull secrets of the pod using it
+type CheckedImage struct {
+	Image       string
+	PullSecrets []string
+	// Client reads pull secrets from the namespace of the pod
+	Client corev1.SecretInterface
+}
+
+// ImageCheck is a node which verifies that container images exist in their registries before
+// resources using them are created, so that the graph fails fast instead of waiting for pods stuck
+// in ImagePullBackOff. Nothing is created for it in the cluster
+type ImageCheck struct {
+	Base
+	Check  *client.ImageCheck
+	Images []CheckedImage
+
+	httpClient *http.Client
+	// found are images which are known to exist, shared by copies of the resource
+	found *foundImages
+}
+
+type foundImages struct {
+	images map[string]bool
+	sync.Mutex
+}
+
+func imageCheckKey(name string) string {
+	return "imagecheck/" + name
+}
+
+// Key returns ImageCheck key
+func (c ImageCheck) Key() string {
+	return imageCheckKey(c.Check.Name)
+}
+
+// WithImages returns the check verifying given images in addition to the ones of its definition
+func (c ImageCheck) WithImages(images []CheckedImage) ImageCheck {
+	c.Images = append(append([]CheckedImage{}, c.Images...), images...)
+	return c
+}
+
+// credentials returns registry credentials of the pull secrets
+func (i CheckedImage) credentials() (map[string]registryCredentials, error) {
+	result := map[string]registryCredentials{}
+	for _, name := range i.PullSecrets {
+		secret, err := i.Client.Get(name)
+		if err != nil {
+			return nil, fmt.Errorf("could not read image pull secret %s: %v", name, err)
+		}
+		creds, err := pullSecretCredentials(secret)
+		if err != nil {
+			return nil, err
+		}
+		for registry, c := range creds {
+			if _, ok := result[registry]; !ok {
+				result[registry] = c
+			}
+		}
+	}
+	return result, nil
+}
+
+// imageCheckStatus checks images which were not found yet. Missing images and images the registry
+// denies access to make the check fail, while unavailable registries make it not ready
+func (c ImageCheck) imageCheckStatus(ctx context.Context) (interfaces.ResourceStatus, string, error) {
+	var missing, unavailable []string
+	checked := map[string]bool{}
+	for _, image := range c.Images {
+		if checked[image.Image] || c.found.has(image.Image) {
+			continue
+		}
+		checked[image.Image] = true
+		ref, err := parseImage(image.Image)
+		if err != nil {
+			return interfaces.NewStatus(interfaces.ResourceError), "", err
+		}
+		creds, err := image.credentials()
+		if err != nil {
+			return interfaces.NewStatus(interfaces.ResourceError), "", err
+		}
+		var registryCreds *registryCredentials
+		if rc, ok := creds[ref.Registry]; ok {
+			registryCreds = &rc
+		}
+
+		exists, err := manifestExists(ctx, c.httpClient, ref, registryCreds)
+		switch {
+		case err == errImageAccessDenied:
+			missing = append(missing, image.Image+" (access denied)")
+		case err != nil:
+			if ctx.Err() != nil {
+				return interfaces.NewStatus(interfaces.ResourceError), "", ctx.Err()
+			}
+			unavailable = append(unavailable, fmt.Sprintf("%s (%v)", image.Image, err))
+		case !exists:
+			missing = append(missing, image.Image)
+		default:
+			c.found.add(image.Image)
+		}
+	}
+
+	if len(missing) > 0 {
+		return interfaces.NewStatus(interfaces.ResourceError), "", fmt.Errorf("images not found: %s", strings.Join(missing, ", "))
+	}
+	if len(unavailable) > 0 {
+		return interfaces.NewStatus(interfaces.ResourceNotReady), "could not check images: " + strings.Join(unavailable, ", "), nil
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), fmt.Sprintf("%d images exist", len(c.Images)), nil
+}
+
+func (f *foundImages) has(image string) bool {
+	f.Lock()
+	defer f.Unlock()
+	return f.images[image]
+}
+
+func (f *foundImages) add(image string) {
+	f.Lock()
+	defer f.Unlock()
+	f.images[image] = true
+}
+
+// Status checks that images exist
+func (c ImageCheck) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	status, message, err := c.imageCheckStatus(ctx)
+	if err == nil && status.Phase != interfaces.ResourceReady {
+		logging.ForResource(c.Key()).Debugf("%s is not ready: %s", c.Key(), message)
+		status = status.WithReason("RegistryUnavailable", message)
+	}
+	return status, err
+}
+
+// GetDependencyReport returns a DependencyReport for this ImageCheck
+func (c ImageCheck) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	status, message, err := c.imageCheckStatus(ctx)
+	return statusReport(c.Key(), status, err, message)
+}
+
+// Create logs images which are checked, as there is nothing to create in the cluster
+func (c ImageCheck) Create(ctx context.Context) error {
+	images := make([]string, 0, len(c.Images))
+	for _, image := range c.Images {
+		images = append(images, image.Image)
+	}
+	sort.Strings(images)
+	logging.ForResource(c.Key()).Infof("Checking images %s", strings.Join(images, ", "))
+	return nil
+}
+
+// Delete does nothing, as there is nothing to delete from the cluster
+func (c ImageCheck) Delete(ctx context.Context) error {
+	return nil
+}
+
+// NameMatches gets resource definition and a name and checks if
+// the ImageCheck part of resource definition has matching name.
+func (c ImageCheck) NameMatches(def client.ResourceDefinition, name string) bool {
+	return def.ImageCheck != nil && def.ImageCheck.Name == name
+}
+
+// New returns new ImageCheck based on resource definition
+func (c ImageCheck) New(def client.ResourceDefinition, ci client.Interface) interfaces.Resource {
+	return NewImageCheck(def.ImageCheck, ci.Secrets(), def.Meta)
+}
+
+// NewExisting returns ImageCheck without images, as image checks can not exist without definition.
+// Images of dependent resources are still checked
+func (c ImageCheck) NewExisting(name string, ci client.Interface) interfaces.Resource {
+	return NewImageCheck(&client.ImageCheck{Name: name}, ci.Secrets(), nil)
+}
+
+// NewImageCheck is a constructor for ImageCheck. Pull secrets of its images are read with the client
+func NewImageCheck(check *client.ImageCheck, c corev1.SecretInterface, meta map[string]interface{}) interfaces.Resource {
+	timeout := defaultImageCheckTimeout
+	if check.TimeoutSeconds > 0 {
+		timeout = time.Duration(check.TimeoutSeconds) * time.Second
+	}
+	images := make([]CheckedImage, 0, len(check.Images))
+	for _, image := range check.Images {
+		images = append(images, CheckedImage{Image: image, PullSecrets: check.ImagePullSecrets, Client: c})
+	}
+	return ImageCheck{
+		Base:       Base{meta},
+		Check:      check,
+		Images:     images,
+		httpClient: &http.Client{Timeout: timeout},
+		found:      &foundImages{images: map[string]bool{}},
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestParseImage checks that registry, repository and reference are found in image names
+func TestParseImage(t *testing.T) {
+	cases := map[string]imageReference{
+		"nginx":                               {dockerHubRegistry, "library/nginx", "latest"},
+		"mirantis/k8s-appcontroller:v1":       {dockerHubRegistry, "mirantis/k8s-appcontroller", "v1"},
+		"quay.io/coreos/etcd:v3.1.0":          {"quay.io", "coreos/etcd", "v3.1.0"},
+		"localhost:5000/app":                  {"localhost:5000", "app", "latest"},
+		"gcr.io/google/pause@sha256:abcdef01": {"gcr.io", "google/pause", "sha256:abcdef01"},
+	}
+	for image, expected := range cases {
+		ref, err := parseImage(image)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ref != expected {
+			t.Errorf("Expected %v for %s, got %v", expected, image, ref)
+		}
+	}
+}
+
+// TestPullSecretCredentials checks that credentials are read from both formats of pull secrets
+func TestPullSecretCredentials(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("user:pass"))
+	secret := &v1.Secret{Data: map[string][]byte{
+		v1.DockerConfigJsonKey: []byte(`{"auths": {"https://index.docker.io/v1/": {"auth": "` + auth + `"}, "quay.io": {"username": "robot", "password": "token"}}}`),
+	}}
+	creds, err := pullSecretCredentials(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hub := creds[dockerHubRegistry]; hub.Username != "user" || hub.Password != "pass" {
+		t.Errorf("Unexpected credentials of Docker Hub %v", hub)
+	}
+	if quay := creds["quay.io"]; quay.Username != "robot" || quay.Password != "token" {
+		t.Errorf("Unexpected credentials of quay.io %v", quay)
+	}
+
+	secret = &v1.Secret{Data: map[string][]byte{v1.DockerConfigKey: []byte(`{"registry.local": {"username": "u", "password": "p"}}`)}}
+	if creds, err = pullSecretCredentials(secret); err != nil || creds["registry.local"].Username != "u" {
+		t.Errorf("Unexpected credentials %v (%v)", creds, err)
+	}
+}
+
+// newRegistry returns TLS server serving manifests of given images of the repository, which requires
+// bearer token issued for user with password
+func newRegistry(repository string, tags ...string) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if user, password, _ := r.BasicAuth(); user != "user" || password != "password" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"token": "secret-token"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		for _, tag := range tags {
+			if r.URL.Path == "/v2/"+repository+"/manifests/"+tag {
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	return server
+}
+
+func pullSecret(name, registry string) *v1.Secret {
+	secret := mocks.MakeSecret(name)
+	secret.Data = map[string][]byte{
+		v1.DockerConfigJsonKey: []byte(`{"auths": {"` + registry + `": {"username": "user", "password": "password"}}}`),
+	}
+	return secret
+}
+
+// TestImageCheck checks that existing images make the check ready and missing ones make it fail
+func TestImageCheck(t *testing.T) {
+	server := newRegistry("app", "v1")
+	defer server.Close()
+	registry := strings.TrimPrefix(server.URL, "https://")
+	c := mocks.NewClient(pullSecret("registry-key", registry))
+
+	check := NewImageCheck(&client.ImageCheck{Name: "images"}, c.Secrets(), nil).(ImageCheck)
+	check.httpClient = server.Client()
+	check = check.WithImages([]CheckedImage{{Image: registry + "/app:v1", PullSecrets: []string{"registry-key"}, Client: c.Secrets()}})
+
+	status, err := check.Status(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Phase != interfaces.ResourceReady {
+		t.Errorf("Check of existing image should be ready, got %s", status)
+	}
+
+	check = check.WithImages([]CheckedImage{{Image: registry + "/app:v2", PullSecrets: []string{"registry-key"}, Client: c.Secrets()}})
+	status, err = check.Status(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), registry+"/app:v2") || status.Phase != interfaces.ResourceError {
+		t.Errorf("Check of missing image should fail, got %s (%v)", status, err)
+	}
+
+	check = NewImageCheck(&client.ImageCheck{Name: "images", Images: []string{registry + "/app:v1"}}, c.Secrets(), nil).(ImageCheck)
+	check.httpClient = server.Client()
+	if _, err = check.Status(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("Check without credentials should be denied, got %v", err)
+	}
+}
+
+// TestParseChallenge checks parsing of WWW-Authenticate header
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"`)
+	expected := map[string]string{"realm": "https://auth.docker.io/token", "service": "registry.docker.io", "scope": "repository:library/nginx:pull"}
+	if scheme != "Bearer" || !reflect.DeepEqual(params, expected) {
+		t.Errorf("Unexpected challenge %s %v", scheme, params)
+	}
+}
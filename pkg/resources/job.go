@@ -15,12 +15,15 @@
 package resources
 
 import (
-	"log"
+	"fmt"
+	"strconv"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
 	"github.com/Mirantis/k8s-AppController/pkg/report"
 
+	"golang.org/x/net/context"
 	batchv1 "k8s.io/client-go/kubernetes/typed/batch/v1"
 	"k8s.io/client-go/pkg/apis/batch/v1"
 )
@@ -35,19 +38,136 @@ func jobKey(name string) string {
 	return "job/" + name
 }
 
-func jobStatus(j batchv1.JobInterface, name string) (string, error) {
+// JobPolicyKey is a dependency meta key selecting the condition at which a Job is considered ready
+const JobPolicyKey = "job_policy"
+
+// JobSuccessesKey is a dependency meta key with the number of successful pods needed for JobPolicySuccesses
+const JobSuccessesKey = "job_successes"
+
+// Possible values for JobPolicyKey
+const (
+	// JobPolicyCompletions requires all completions of the Job to be done (default)
+	JobPolicyCompletions = "completions"
+	// JobPolicySuccesses requires at least job_successes pods of the Job to succeed
+	JobPolicySuccesses = "successes"
+	// JobPolicyRunning requires any pod of the Job to be running or succeeded
+	JobPolicyRunning = "running"
+)
+
+func jobStatus(j batchv1.JobInterface, name string, meta map[string]string) (interfaces.ResourceStatus, error) {
 	job, err := j.Get(name)
 	if err != nil {
-		return "error", err
+		return interfaces.NewStatus(interfaces.ResourceError), err
 	}
+	return jobReadiness(job, meta)
+}
 
+func jobReadiness(job *v1.Job, meta map[string]string) (interfaces.ResourceStatus, error) {
 	for _, cond := range job.Status.Conditions {
 		if cond.Type == "Complete" && cond.Status == "True" {
-			return "ready", nil
+			return interfaces.NewStatus(interfaces.ResourceReady), nil
+		}
+		if cond.Type == v1.JobFailed && cond.Status == "True" {
+			reason := cond.Reason
+			if reason == "" {
+				reason = "JobFailed"
+			}
+			return failedStatus(NewResourceError(jobKey(job.Name), reason, cond.Message))
 		}
 	}
 
-	return "not ready", nil
+	policy, ok := meta[JobPolicyKey]
+	if !ok {
+		policy = JobPolicyCompletions
+	}
+
+	switch policy {
+	case JobPolicyCompletions:
+		if _, ok := meta[SuccessFactorKey]; ok {
+			successFactor, err := getPercentage(SuccessFactorKey, meta)
+			if err != nil {
+				return interfaces.NewStatus(interfaces.ResourceError), err
+			}
+			if job.Status.Succeeded*100 >= jobCompletions(job)*successFactor {
+				return interfaces.NewStatus(interfaces.ResourceReady), nil
+			}
+		}
+	case JobPolicySuccesses:
+		successes, err := jobSuccesses(meta)
+		if err != nil {
+			return interfaces.NewStatus(interfaces.ResourceError), err
+		}
+		if job.Status.Succeeded >= successes {
+			return interfaces.NewStatus(interfaces.ResourceReady), nil
+		}
+	case JobPolicyRunning:
+		if job.Status.Active > 0 || job.Status.Succeeded > 0 {
+			return interfaces.NewStatus(interfaces.ResourceReady), nil
+		}
+	default:
+		return interfaces.NewStatus(interfaces.ResourceError), fmt.Errorf("unknown %s '%s', expected one of '%s', '%s', '%s'",
+			JobPolicyKey, policy, JobPolicyCompletions, JobPolicySuccesses, JobPolicyRunning)
+	}
+
+	return interfaces.NewStatus(interfaces.ResourceNotReady).
+		WithReason("JobNotComplete", fmt.Sprintf("%d active, %d succeeded, %d failed pods", job.Status.Active, job.Status.Succeeded, job.Status.Failed)).
+		WithProgress(int(job.Status.Succeeded), int(jobCompletions(job))), nil
+}
+
+// jobCompletions returns the number of successful pod completions the job needs
+func jobCompletions(job *v1.Job) int32 {
+	if job.Spec.Completions == nil {
+		return 1
+	}
+	return *job.Spec.Completions
+}
+
+// jobSuccesses returns the number of successful pods needed by JobPolicySuccesses
+func jobSuccesses(meta map[string]string) (int32, error) {
+	value, ok := meta[JobSuccessesKey]
+	if !ok {
+		return 1, nil
+	}
+	successes, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	if successes < 1 {
+		return 0, fmt.Errorf("%s must be a positive number", JobSuccessesKey)
+	}
+	return int32(successes), nil
+}
+
+func jobReport(j batchv1.JobInterface, name string, meta map[string]string) interfaces.DependencyReport {
+	job, err := j.Get(name)
+	if err != nil {
+		return report.ErrorReport(jobKey(name), err)
+	}
+	status, err := jobReadiness(job, meta)
+	if err != nil {
+		return report.ErrorReport(jobKey(name), err)
+	}
+
+	percentage := 100
+	if completions := jobCompletions(job); status.Phase != interfaces.ResourceReady && completions > 0 {
+		percentage = int(job.Status.Succeeded * 100 / completions)
+		if percentage > 100 {
+			percentage = 100
+		}
+	}
+	return interfaces.DependencyReport{
+		Dependency: jobKey(name),
+		Blocks:     status.Phase != interfaces.ResourceReady,
+		Percentage: percentage,
+		Needed:     100,
+		Message: fmt.Sprintf(
+			"%d succeeded, %d failed, %d active (%d completions needed)",
+			job.Status.Succeeded,
+			job.Status.Failed,
+			job.Status.Active,
+			jobCompletions(job),
+		),
+	}
 }
 
 // Key returns job name
@@ -56,14 +176,27 @@ func (j Job) Key() string {
 }
 
 // Status returns job status
-func (j Job) Status(meta map[string]string) (string, error) {
-	return jobStatus(j.Client, j.Job.Name)
+func (j Job) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	return jobStatus(j.Client, j.Job.Name, meta)
+}
+
+// StatusIsCacheable returns false if meta contains SuccessFactorKey or JobPolicyKey
+func (j Job) StatusIsCacheable(meta map[string]string) bool {
+	return jobStatusIsCacheable(meta)
+}
+
+// GetDependencyReport returns a DependencyReport for this job
+func (j Job) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return jobReport(j.Client, j.Job.Name, meta)
 }
 
 // Create creates k8s job object
-func (j Job) Create() error {
-	if err := checkExistence(j); err != nil {
-		log.Println("Creating ", j.Key())
+func (j Job) Create(ctx context.Context) error {
+	if err := checkExistence(ctx, j); err != nil {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		logging.ForResource(j.Key()).Infof("Creating %s", j.Key())
 		j.Job, err = j.Client.Create(j.Job)
 		return err
 	}
@@ -71,10 +204,15 @@ func (j Job) Create() error {
 }
 
 // Delete deletes Job from the cluster
-func (j Job) Delete() error {
+func (j Job) Delete(ctx context.Context) error {
 	return j.Client.Delete(j.Job.Name, nil)
 }
 
+// DeleteWithPolicy deletes Job from the cluster, policy defines what happens with its pods
+func (j Job) DeleteWithPolicy(policy DeletionPolicy) error {
+	return j.Client.Delete(j.Job.Name, policy.options())
+}
+
 // NameMatches gets resource definition and a name and checks if
 // the Job part of resource definition has matching name.
 func (j Job) NameMatches(def client.ResourceDefinition, name string) bool {
@@ -92,7 +230,7 @@ func (j Job) NewExisting(name string, c client.Interface) interfaces.Resource {
 }
 
 func NewJob(job *v1.Job, client batchv1.JobInterface, meta map[string]interface{}) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: Job{Base: Base{meta}, Job: job, Client: client}}
+	return Job{Base: Base{meta}, Job: job, Client: client}
 }
 
 type ExistingJob struct {
@@ -105,19 +243,40 @@ func (j ExistingJob) Key() string {
 	return jobKey(j.Name)
 }
 
-func (j ExistingJob) Status(meta map[string]string) (string, error) {
-	return jobStatus(j.Client, j.Name)
+func (j ExistingJob) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	return jobStatus(j.Client, j.Name, meta)
+}
+
+// StatusIsCacheable returns false if meta contains SuccessFactorKey or JobPolicyKey
+func (j ExistingJob) StatusIsCacheable(meta map[string]string) bool {
+	return jobStatusIsCacheable(meta)
 }
 
-func (j ExistingJob) Create() error {
-	return createExistingResource(j)
+// GetDependencyReport returns a DependencyReport for this job
+func (j ExistingJob) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return jobReport(j.Client, j.Name, meta)
+}
+
+func jobStatusIsCacheable(meta map[string]string) bool {
+	_, hasFactor := meta[SuccessFactorKey]
+	_, hasPolicy := meta[JobPolicyKey]
+	return !hasFactor && !hasPolicy
+}
+
+func (j ExistingJob) Create(ctx context.Context) error {
+	return createExistingResource(ctx, j)
 }
 
 // Delete deletes Job from the cluster
-func (j ExistingJob) Delete() error {
+func (j ExistingJob) Delete(ctx context.Context) error {
 	return j.Client.Delete(j.Name, nil)
 }
 
+// DeleteWithPolicy deletes Job from the cluster, policy defines what happens with its pods
+func (j ExistingJob) DeleteWithPolicy(policy DeletionPolicy) error {
+	return j.Client.Delete(j.Name, policy.options())
+}
+
 func NewExistingJob(name string, client batchv1.JobInterface) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: ExistingJob{Name: name, Client: client}}
+	return ExistingJob{Name: name, Client: client}
 }
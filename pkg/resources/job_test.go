@@ -0,0 +1,182 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestJobSuccessCheck checks status of completed Job
+func TestJobSuccessCheck(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeJob("ready-1"))
+	status, err := jobStatus(c.Jobs(), "ready-1", nil)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status.Phase != "ready" {
+		t.Errorf("Status should be `ready`, is `%s` instead.", status)
+	}
+}
+
+// TestJobPartialCheck checks that partially completed Job is not ready without success factor
+func TestJobPartialCheck(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeJob("partial-1"))
+	status, err := jobStatus(c.Jobs(), "partial-1", nil)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status.Phase != "not ready" {
+		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
+	}
+}
+
+// TestJobSuccessFactorCheck checks that partially completed Job is ready when success factor is reached
+func TestJobSuccessFactorCheck(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeJob("partial-1"))
+	status, err := jobStatus(c.Jobs(), "partial-1", map[string]string{SuccessFactorKey: "80"})
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status.Phase != "ready" {
+		t.Errorf("Status should be `ready`, is `%s` instead.", status)
+	}
+
+	status, err = jobStatus(c.Jobs(), "partial-1", map[string]string{SuccessFactorKey: "90"})
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status.Phase != "not ready" {
+		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
+	}
+}
+
+// TestJobPolicySuccesses checks that Job is ready when enough pods succeeded with "successes" policy
+func TestJobPolicySuccesses(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeJob("partial-1"))
+	meta := map[string]string{JobPolicyKey: JobPolicySuccesses, JobSuccessesKey: "4"}
+	status, err := jobStatus(c.Jobs(), "partial-1", meta)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status.Phase != "ready" {
+		t.Errorf("Status should be `ready`, is `%s` instead.", status)
+	}
+
+	meta[JobSuccessesKey] = "5"
+	status, err = jobStatus(c.Jobs(), "partial-1", meta)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status.Phase != "not ready" {
+		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
+	}
+}
+
+// TestJobPolicyRunning checks that Job with an active pod is ready with "running" policy
+func TestJobPolicyRunning(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeJob("running-1"), mocks.MakeJob("pending-1"))
+	meta := map[string]string{JobPolicyKey: JobPolicyRunning}
+
+	status, err := jobStatus(c.Jobs(), "running-1", meta)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != "ready" {
+		t.Errorf("Status should be `ready`, is `%s` instead.", status)
+	}
+
+	status, err = jobStatus(c.Jobs(), "pending-1", meta)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != "not ready" {
+		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
+	}
+}
+
+// TestJobPolicyUnknown checks that unknown policy results in error
+func TestJobPolicyUnknown(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeJob("partial-1"))
+	status, err := jobStatus(c.Jobs(), "partial-1", map[string]string{JobPolicyKey: "whatever"})
+
+	if err == nil {
+		t.Error("Error should be returned, got nil")
+	}
+
+	if status.Phase != "error" {
+		t.Errorf("Status should be `error`, is `%s` instead.", status)
+	}
+}
+
+// TestJobFailedCheck checks that Job with Failed condition is an error with the reason of the condition
+func TestJobFailedCheck(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeJob("failed-1"))
+	status, err := jobStatus(c.Jobs(), "failed-1", nil)
+
+	if _, ok := err.(ResourceError); !ok {
+		t.Errorf("Expected resource error, got %v", err)
+	}
+	if status.Phase != "error" || status.Reason != "BackoffLimitExceeded" {
+		t.Errorf("Status should be `error` with reason BackoffLimitExceeded, is `%+v` instead.", status)
+	}
+}
+
+// TestJobDependencyReport checks the report of partially completed Job
+func TestJobDependencyReport(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeJob("partial-1"))
+	job := NewExistingJob("partial-1", c.Jobs())
+	depReport := job.GetDependencyReport(context.Background(), nil)
+
+	if !depReport.Blocks {
+		t.Error("Dependency report should block")
+	}
+
+	if depReport.Percentage != 80 {
+		t.Errorf("Expected percentage 80, got %d", depReport.Percentage)
+	}
+
+	expected := "4 succeeded, 1 failed, 0 active (5 completions needed)"
+	if depReport.Message != expected {
+		t.Errorf("Expected message `%s`, got `%s`", expected, depReport.Message)
+	}
+}
+
+// TestJobDependencyReportNoCompletions checks the report of Job which needs no completions and is not complete yet
+func TestJobDependencyReportNoCompletions(t *testing.T) {
+	job := mocks.MakeJob("running-1")
+	job.Spec.Completions = mocks.Pointer(int32(0))
+	c := mocks.NewClient(job)
+	depReport := NewExistingJob("running-1", c.Jobs()).GetDependencyReport(context.Background(), nil)
+
+	if !depReport.Blocks || depReport.Percentage != 100 {
+		t.Errorf("Expected blocking report with percentage 100, got %+v", depReport)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+)
+
+// KindTemplate describes a kind of graph nodes. Kinds which are not built into AppController are
+// defined in the object field of resource definitions and are added with Register by downstream code
+// or plugins
+type KindTemplate struct {
+	// Kind is a lowercase kind used in resource keys, e.g. "pod"
+	Kind string
+	// Template creates resources of the kind
+	Template interfaces.ResourceTemplate
+	// ClusterScoped kinds do not belong to any namespace, their keys are never qualified with namespace
+	ClusterScoped bool
+	// NonObject kinds do not correspond to K8s objects, e.g. checks, so there is nothing to delete
+	NonObject bool
+	// Workload kinds create pods from the template in their spec, which services can select
+	Workload bool
+
+	// builtin kinds have their own fields in resource definitions
+	builtin bool
+}
+
+// KindToResourceTemplate maps registered kinds to templates of their resources
+var KindToResourceTemplate = map[string]interfaces.ResourceTemplate{}
+
+// ClusterScopedKinds are kinds of objects which do not belong to any namespace. Their keys are never
+// qualified with namespace and they are created in the same graph as namespaced objects
+var ClusterScopedKinds = map[string]bool{}
+
+// NonObjectKinds are kinds of graph nodes which do not correspond to K8s objects
+var NonObjectKinds = map[string]bool{}
+
+// WorkloadKinds are kinds of objects creating pods from the template in their spec
+var WorkloadKinds = map[string]bool{}
+
+// Kinds are registered kinds in lexical order
+var Kinds []string
+
+// objectKinds are registered kinds without their own fields in resource definitions
+var objectKinds = map[string]bool{}
+
+var builtinKinds = []KindTemplate{
+	{Kind: "daemonset", Template: DaemonSet{}, Workload: true},
+	{Kind: "job", Template: Job{}, Workload: true},
+	{Kind: "statefulset", Template: StatefulSet{}, Workload: true},
+	{Kind: "petset", Template: PetSet{}, Workload: true},
+	{Kind: "pod", Template: Pod{}},
+	{Kind: "replicaset", Template: ReplicaSet{}, Workload: true},
+	{Kind: "service", Template: Service{}},
+	{Kind: "configmap", Template: ConfigMap{}},
+	{Kind: "secret", Template: Secret{}},
+	{Kind: "deployment", Template: Deployment{}, Workload: true},
+	{Kind: "persistentvolumeclaim", Template: PersistentVolumeClaim{}},
+	{Kind: "serviceaccount", Template: ServiceAccount{}},
+	{Kind: "externalcheck", Template: ExternalCheck{}, NonObject: true},
+	{Kind: "execcheck", Template: ExecCheck{}, NonObject: true},
+	{Kind: "handler", Template: Handler{}},
+	{Kind: "checkpoint", Template: Checkpoint{}, NonObject: true},
+	{Kind: "imagecheck", Template: ImageCheck{}, NonObject: true},
+	{Kind: "scale", Template: Scale{}, NonObject: true},
+	{Kind: "patch", Template: Patch{}, NonObject: true},
+	{Kind: "selector", Template: LabelSelector{}, NonObject: true},
+	{Kind: "namespace", Template: Namespace{}, ClusterScoped: true},
+	{Kind: "persistentvolume", Template: PersistentVolume{}, ClusterScoped: true},
+	{Kind: "storageclass", Template: StorageClass{}, ClusterScoped: true},
+	{Kind: "clusterrole", Template: ClusterRole{}, ClusterScoped: true},
+}
+
+func init() {
+	for _, t := range builtinKinds {
+		t.builtin = true
+		if err := Register(t); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Register adds a kind of graph nodes. Kinds must be registered before graphs are built, e.g. in init
+// functions of packages compiled into kubeac or of plugins loaded with LoadPlugin. Built-in kinds
+// cannot be replaced
+func Register(t KindTemplate) error {
+	if t.Kind == "" || t.Kind != strings.ToLower(t.Kind) || strings.Contains(t.Kind, "/") {
+		return fmt.Errorf("invalid kind %q, kinds must be lowercase and cannot contain slashes", t.Kind)
+	}
+	if t.Template == nil {
+		return fmt.Errorf("kind %s has no template", t.Kind)
+	}
+	if _, ok := KindToResourceTemplate[t.Kind]; ok {
+		return fmt.Errorf("kind %s is already registered", t.Kind)
+	}
+
+	KindToResourceTemplate[t.Kind] = t.Template
+	if t.ClusterScoped {
+		ClusterScopedKinds[t.Kind] = true
+	}
+	if t.NonObject {
+		NonObjectKinds[t.Kind] = true
+	}
+	if t.Workload {
+		WorkloadKinds[t.Kind] = true
+	}
+	if !t.builtin {
+		objectKinds[t.Kind] = true
+	}
+	Kinds = append(Kinds, t.Kind)
+	sort.Strings(Kinds)
+	return nil
+}
+
+// DefinitionField returns name of the field of resource definition which holds objects of the kind.
+// Objects of registered kinds which are not built in are held by the object field
+func DefinitionField(kind string) string {
+	if objectKinds[strings.ToLower(kind)] {
+		return "object"
+	}
+	return kind
+}
+
+// ObjectNameMatches returns true if resource definition holds object of the kind with given name in its
+// object field. It is meant for NameMatches of templates of registered kinds
+func ObjectNameMatches(def client.ResourceDefinition, kind, name string) bool {
+	return def.Object != nil && def.Object.Kind == kind && def.Object.Name == name
+}
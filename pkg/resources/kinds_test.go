@@ -0,0 +1,70 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"sort"
+	"testing"
+)
+
+// TestBuiltinKinds checks that built-in kinds are registered with their properties
+func TestBuiltinKinds(t *testing.T) {
+	if len(Kinds) != len(KindToResourceTemplate) || !sort.StringsAreSorted(Kinds) {
+		t.Errorf("Kinds should be sorted keys of KindToResourceTemplate, got %v", Kinds)
+	}
+	if !ClusterScopedKinds["namespace"] || ClusterScopedKinds["pod"] {
+		t.Error("only cluster-scoped kinds should be in ClusterScopedKinds")
+	}
+	if !NonObjectKinds["checkpoint"] || NonObjectKinds["service"] {
+		t.Error("only kinds without K8s objects should be in NonObjectKinds")
+	}
+	if !WorkloadKinds["deployment"] || WorkloadKinds["pod"] {
+		t.Error("only kinds with pod templates should be in WorkloadKinds")
+	}
+	if field := DefinitionField("Deployment"); field != "Deployment" {
+		t.Errorf("built-in kinds should be wrapped into their own fields, got %s", field)
+	}
+}
+
+// TestRegister checks registration of new kinds and that registered kinds cannot be replaced
+func TestRegister(t *testing.T) {
+	if err := Register(KindTemplate{Kind: "pod", Template: Pod{}}); err == nil {
+		t.Error("built-in kind should not be replaced")
+	}
+	for _, kind := range []string{"", "Gizmo", "giz/mo"} {
+		if err := Register(KindTemplate{Kind: kind, Template: Pod{}}); err == nil {
+			t.Errorf("kind %q should be rejected", kind)
+		}
+	}
+	if err := Register(KindTemplate{Kind: "gizmo"}); err == nil {
+		t.Error("kind without template should be rejected")
+	}
+
+	if err := Register(KindTemplate{Kind: "gizmo", Template: ConfigMap{}, ClusterScoped: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := KindToResourceTemplate["gizmo"]; !ok || !ClusterScopedKinds["gizmo"] {
+		t.Error("registered kind should be added to KindToResourceTemplate and ClusterScopedKinds")
+	}
+	if i := sort.SearchStrings(Kinds, "gizmo"); i == len(Kinds) || Kinds[i] != "gizmo" {
+		t.Errorf("registered kind should be added to Kinds, got %v", Kinds)
+	}
+	if field := DefinitionField("Gizmo"); field != "object" {
+		t.Errorf("registered kinds should be wrapped into object field, got %s", field)
+	}
+	if err := Register(KindTemplate{Kind: "gizmo", Template: ConfigMap{}}); err == nil {
+		t.Error("kind should not be registered twice")
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import "strings"
+
+// Labels set by AppController on objects it creates, so that they can be found with label selectors
+const (
+	// GraphLabel is a name of the graph the object belongs to
+	GraphLabel = "appcontroller.k8s/graph"
+	// RunIDLabel is an ID of the graph run which created or last updated the object
+	RunIDLabel = "appcontroller.k8s/run-id"
+	// NodeLabel is a key of the graph node of the object, with characters not allowed in label
+	// values replaced by dots, e.g. pod.db for pod/db
+	NodeLabel = "appcontroller.k8s/node"
+)
+
+// maxLabelValueLength is the maximum length of label value accepted by K8s
+const maxLabelValueLength = 63
+
+// LabelValue converts the string to a valid label value: characters other than alphanumerics,
+// '-', '_' and '.' are replaced by dots, the value is cut to 63 characters and must begin and end
+// with an alphanumeric character
+func LabelValue(s string) string {
+	value := strings.Map(func(r rune) rune {
+		if isAlphanumeric(r) || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '.'
+	}, s)
+	if len(value) > maxLabelValueLength {
+		value = value[:maxLabelValueLength]
+	}
+	return strings.TrimFunc(value, func(r rune) bool { return !isAlphanumeric(r) })
+}
+
+func isAlphanumeric(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
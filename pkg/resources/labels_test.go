@@ -0,0 +1,36 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestLabelValue checks conversion of strings to valid label values
+func TestLabelValue(t *testing.T) {
+	cases := map[string]string{
+		"pod/db":                       "pod.db",
+		"service/web@frontend":         "service.web.frontend",
+		"selector/app=web,tier=front/": "selector.app.web.tier.front",
+		"-_job_-":                      "job",
+		strings.Repeat("a", 70):        strings.Repeat("a", 63),
+	}
+	for s, expected := range cases {
+		if value := LabelValue(s); value != expected {
+			t.Errorf("Expected label value of %q to be %q, got %q", s, expected, value)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// Definition and Live methods of resources created from definitions implement interfaces.LiveObjectGetter,
+// so that objects of definitions can be compared with the ones in the cluster
+
+func (p Pod) Definition() interface{} { return p.Pod }
+
+func (p Pod) Live() (interface{}, error) { return p.Client.Get(p.Pod.Name) }
+
+func (j Job) Definition() interface{} { return j.Job }
+
+func (j Job) Live() (interface{}, error) { return j.Client.Get(j.Job.Name) }
+
+func (s Service) Definition() interface{} { return s.Service }
+
+func (s Service) Live() (interface{}, error) { return s.Client.Get(s.Service.Name) }
+
+func (r ReplicaSet) Definition() interface{} { return r.ReplicaSet }
+
+func (r ReplicaSet) Live() (interface{}, error) { return r.Client.Get(r.ReplicaSet.Name) }
+
+func (p StatefulSet) Definition() interface{} { return p.StatefulSet }
+
+func (p StatefulSet) Live() (interface{}, error) { return p.Client.Get(p.StatefulSet.Name) }
+
+func (p PetSet) Definition() interface{} { return p.PetSet }
+
+func (p PetSet) Live() (interface{}, error) { return p.Client.Get(p.PetSet.Name) }
+
+func (d DaemonSet) Definition() interface{} { return d.DaemonSet }
+
+func (d DaemonSet) Live() (interface{}, error) { return d.Client.Get(d.DaemonSet.Name) }
+
+func (d Deployment) Definition() interface{} { return d.Deployment }
+
+func (d Deployment) Live() (interface{}, error) { return d.Client.Get(d.Deployment.Name) }
+
+func (c ConfigMap) Definition() interface{} { return c.ConfigMap }
+
+func (c ConfigMap) Live() (interface{}, error) { return c.Client.Get(c.ConfigMap.Name) }
+
+func (p PersistentVolumeClaim) Definition() interface{} { return p.PersistentVolumeClaim }
+
+func (p PersistentVolumeClaim) Live() (interface{}, error) {
+	return p.Client.Get(p.PersistentVolumeClaim.Name)
+}
+
+func (c ServiceAccount) Definition() interface{} { return c.ServiceAccount }
+
+func (c ServiceAccount) Live() (interface{}, error) { return c.Client.Get(c.ServiceAccount.Name) }
+
+func (n Namespace) Definition() interface{} { return n.Namespace }
+
+func (n Namespace) Live() (interface{}, error) { return n.Client.Get(n.Name) }
+
+func (p PersistentVolume) Definition() interface{} { return p.PersistentVolume }
+
+func (p PersistentVolume) Live() (interface{}, error) { return p.Client.Get(p.PersistentVolume.Name) }
+
+func (r StorageClass) Definition() interface{} { return r.StorageClass }
+
+func (r StorageClass) Live() (interface{}, error) { return r.Client.Get(r.StorageClass.Name) }
+
+func (r ClusterRole) Definition() interface{} { return r.ClusterRole }
+
+func (r ClusterRole) Live() (interface{}, error) { return r.Client.Get(r.ClusterRole.Name) }
+
+// Definition returns Secret of the definition with values replaced by their digests. Values of
+// external source are used if they can be read
+func (s Secret) Definition() interface{} {
+	if resolved, err := s.resolved(context.Background()); err == nil {
+		s = resolved
+	}
+	return redactedSecret(s.Secret)
+}
+
+// Live returns Secret in the cluster with values replaced by their digests
+func (s Secret) Live() (interface{}, error) {
+	secret, err := s.Client.Get(s.Secret.Name)
+	if err != nil {
+		return nil, err
+	}
+	return redactedSecret(secret), nil
+}
+
+// redactedSecret returns a copy of the secret whose values are replaced by their checksums in
+// StringData, so that secrets can be shown and compared without exposing the values
+func redactedSecret(secret *v1.Secret) *v1.Secret {
+	result := withoutData(secret)
+	for key, value := range secretData(secret) {
+		digest, err := checksum(value)
+		if err != nil {
+			continue
+		}
+		if result.StringData == nil {
+			result.StringData = map[string]string{}
+		}
+		result.StringData[key] = "sha256:" + digest
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+)
+
+// FailureLogLinesKey is a key of resource definition meta with number of last lines of container logs
+// captured when a Job or Pod fails, 0 disables capturing
+const FailureLogLinesKey = "failure-log-lines"
+
+// DefaultFailureLogLines is a number of captured log lines when failure-log-lines is not set
+const DefaultFailureLogLines = 20
+
+// maxLogPods is a maximal number of pods of a Job whose logs are captured
+const maxLogPods = 3
+
+// podLogs returns last lines of logs of every container of the pod, each prefixed with a header naming
+// the pod and the container
+func podLogs(ctx context.Context, c client.Interface, pod *v1.Pod, tailLines int64) (string, error) {
+	var sections []string
+	for _, container := range pod.Spec.Containers {
+		logs, err := c.Logs(ctx, pod.Name, container.Name, tailLines)
+		if err != nil {
+			return "", fmt.Errorf("could not read logs of container %s of pod %s: %v", container.Name, pod.Name, err)
+		}
+		sections = append(sections, fmt.Sprintf("==> pod/%s %s <==\n%s", pod.Name, container.Name, strings.TrimRight(logs, "\n")))
+	}
+	return strings.Join(sections, "\n"), nil
+}
+
+// newestPodsFirst sorts pods by creation time, the most recent first
+type newestPodsFirst []v1.Pod
+
+func (p newestPodsFirst) Len() int      { return len(p) }
+func (p newestPodsFirst) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p newestPodsFirst) Less(i, j int) bool {
+	return p[j].CreationTimestamp.Time.Before(p[i].CreationTimestamp.Time)
+}
+
+// jobLogPods returns pods of the job whose logs are captured: the most recent failed pods, or the most
+// recent pods if none of them failed
+func jobLogPods(pods []v1.Pod) []v1.Pod {
+	var failed []v1.Pod
+	for _, pod := range pods {
+		if pod.Status.Phase == v1.PodFailed {
+			failed = append(failed, pod)
+		}
+	}
+	if len(failed) > 0 {
+		pods = failed
+	}
+	sort.Sort(newestPodsFirst(pods))
+	if len(pods) > maxLogPods {
+		pods = pods[:maxLogPods]
+	}
+	return pods
+}
+
+// FailureLogs returns last tailLines lines of logs of containers of the Job or Pod with given key,
+// or empty string for resources of other kinds
+func FailureLogs(ctx context.Context, c client.Interface, key string, tailLines int64) (string, error) {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 {
+		return "", nil
+	}
+	switch parts[0] {
+	case "pod":
+		pod, err := c.Pods().Get(parts[1])
+		if err != nil {
+			return "", err
+		}
+		return podLogs(ctx, c, pod, tailLines)
+	case "job":
+		job, err := c.Jobs().Get(parts[1])
+		if err != nil {
+			return "", err
+		}
+		if job.Spec.Selector == nil {
+			return "", nil
+		}
+		pods, err := podsFromLabels(c, job.Spec.Selector.MatchLabels)
+		if err != nil {
+			return "", err
+		}
+		var sections []string
+		for _, pod := range jobLogPods(pods.Items) {
+			logs, err := podLogs(ctx, c, &pod, tailLines)
+			if err != nil {
+				return "", err
+			}
+			sections = append(sections, logs)
+		}
+		return strings.Join(sections, "\n"), nil
+	}
+	return "", nil
+}
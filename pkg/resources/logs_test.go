@@ -0,0 +1,104 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// fakeLogReader returns logs naming the container and the number of requested lines
+type fakeLogReader struct{}
+
+func (fakeLogReader) Logs(ctx context.Context, namespace, pod, container string, tailLines int64) (string, error) {
+	return fmt.Sprintf("%s/%s: %d lines\n", pod, container, tailLines), nil
+}
+
+func podWithContainers(name string, phase v1.PodPhase, created time.Time, containers ...string) *v1.Pod {
+	pod := mocks.MakePod(name)
+	pod.Status.Phase = phase
+	pod.CreationTimestamp = unversioned.NewTime(created)
+	pod.Labels = map[string]string{"job-name": "migrate"}
+	for _, container := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Name: container})
+	}
+	return pod
+}
+
+// TestFailureLogsPod checks that logs of every container of the pod are captured
+func TestFailureLogsPod(t *testing.T) {
+	c := mocks.NewClient(podWithContainers("app", v1.PodFailed, time.Now(), "app", "proxy"))
+	c.LogReader = fakeLogReader{}
+
+	logs, err := FailureLogs(context.Background(), c, "pod/app", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "==> pod/app app <==\napp/app: 5 lines\n==> pod/app proxy <==\napp/proxy: 5 lines"
+	if logs != expected {
+		t.Errorf("expected logs %q, got %q", expected, logs)
+	}
+}
+
+// TestFailureLogsJob checks that logs of the most recent failed pods of the job are captured
+func TestFailureLogsJob(t *testing.T) {
+	now := time.Now()
+	job := mocks.MakeJob("failed-migrate")
+	job.Spec.Selector = &unversioned.LabelSelector{MatchLabels: map[string]string{"job-name": "migrate"}}
+	objects := []*v1.Pod{podWithContainers("succeeded", v1.PodSucceeded, now, "main")}
+	for i := 0; i < 4; i++ {
+		objects = append(objects, podWithContainers(fmt.Sprintf("failed-%d", i), v1.PodFailed, now.Add(time.Duration(i)*time.Minute), "main"))
+	}
+	c := mocks.NewClient(job, objects[0], objects[1], objects[2], objects[3], objects[4])
+	c.LogReader = fakeLogReader{}
+
+	logs, err := FailureLogs(context.Background(), c, "job/failed-migrate", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pod := range []string{"failed-3", "failed-2", "failed-1"} {
+		if !strings.Contains(logs, "==> pod/"+pod+" main <==") {
+			t.Errorf("logs of %s should be captured, got %q", pod, logs)
+		}
+	}
+	for _, pod := range []string{"failed-0", "succeeded"} {
+		if strings.Contains(logs, "pod/"+pod+" ") {
+			t.Errorf("logs of %s should not be captured, got %q", pod, logs)
+		}
+	}
+	if strings.Index(logs, "failed-3") > strings.Index(logs, "failed-1") {
+		t.Errorf("logs of the most recent pod should be first, got %q", logs)
+	}
+}
+
+// TestFailureLogsNotSupported checks that other kinds have no logs and clients without log reader fail
+func TestFailureLogsNotSupported(t *testing.T) {
+	c := mocks.NewClient(podWithContainers("app", v1.PodFailed, time.Now(), "app"), mocks.MakeService("web"))
+	if logs, err := FailureLogs(context.Background(), c, "service/web", 5); err != nil || logs != "" {
+		t.Errorf("services should have no logs, got %q, %v", logs, err)
+	}
+	if _, err := FailureLogs(context.Background(), c, "pod/app", 5); err == nil || !strings.Contains(err.Error(), client.ErrLogsNotSupported.Error()) {
+		t.Errorf("expected error of client without log reader, got %v", err)
+	}
+}
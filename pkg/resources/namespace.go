@@ -0,0 +1,111 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"golang.org/x/net/context"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+// Namespace is a namespace of graph resources. Namespaces are added to the graph for every namespace
+// other than ac namespace, and created if they do not exist. Namespace can also have resource definition
+// to be created with labels and annotations
+type Namespace struct {
+	Base
+	Name string
+	// Namespace is an object created for the namespace, only name is set if it has no definition
+	Namespace *v1.Namespace
+	Client    corev1.NamespaceInterface
+}
+
+// NamespaceKey returns key of graph node of the namespace with given name
+func NamespaceKey(name string) string {
+	return "namespace/" + name
+}
+
+// Key returns namespace key
+func (n Namespace) Key() string {
+	return NamespaceKey(n.Name)
+}
+
+// Status returns ready if the namespace is active
+func (n Namespace) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	namespace, err := n.Client.Get(n.Name)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	if namespace.Status.Phase == v1.NamespaceTerminating {
+		return interfaces.NewStatus(interfaces.ResourceNotReady), nil
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+// Create creates the namespace if it does not exist
+func (n Namespace) Create(ctx context.Context) error {
+	if err := checkExistence(ctx, n); err == nil {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	logging.ForResource(n.Key()).Infof("Creating %s", n.Key())
+	namespace := n.Namespace
+	if namespace == nil {
+		namespace = &v1.Namespace{ObjectMeta: v1.ObjectMeta{Name: n.Name}}
+	}
+	_, err := n.Client.Create(namespace)
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// Delete deletes the namespace with all objects in it
+func (n Namespace) Delete(ctx context.Context) error {
+	return n.Client.Delete(n.Name, nil)
+}
+
+// NewNamespace returns namespace with given name wrapped as Resource
+func NewNamespace(name string, client corev1.NamespaceInterface) interfaces.Resource {
+	return report.SimpleReporter{BaseResource: Namespace{Name: name, Client: client}}
+}
+
+// NameMatches gets resource definition and a name and checks if the Namespace part of resource
+// definition has matching name
+func (n Namespace) NameMatches(def client.ResourceDefinition, name string) bool {
+	return def.NamespaceObject != nil && def.NamespaceObject.Name == name
+}
+
+// New returns namespace described by resource definition wrapped as Resource
+func (n Namespace) New(def client.ResourceDefinition, ci client.Interface) interfaces.Resource {
+	return report.SimpleReporter{BaseResource: Namespace{
+		Base:      Base{def.Meta},
+		Name:      def.NamespaceObject.Name,
+		Namespace: def.NamespaceObject,
+		Client:    ci.Namespaces(),
+	}}
+}
+
+// NewExisting returns existing namespace wrapped as Resource
+func (n Namespace) NewExisting(name string, ci client.Interface) interfaces.Resource {
+	return NewNamespace(name, ci.Namespaces())
+}
@@ -0,0 +1,148 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/labels"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+// IsSelectorName returns true if name part of the resource key is a label selector rather than
+// a name of the object. Object names never contain "="
+func IsSelectorName(name string) bool {
+	return strings.Contains(name, "=")
+}
+
+// NewestSelected is an existing object resolved by its kind and label selector rather than by name, e.g.
+// deployment/app=frontend. The newest of matching objects is used, and once found it stays the object of
+// the node, so that later objects matching the selector do not replace it in the middle of the run
+type NewestSelected struct {
+	Base
+	Kind      string
+	Selector  string
+	APIClient client.Interface
+	resolved  *resolvedObject
+}
+
+// resolvedObject is shared by copies of NewestSelected
+type resolvedObject struct {
+	resource interfaces.Resource
+	sync.Mutex
+}
+
+// NewNewestSelected is a constructor for NewestSelected. Only kinds supported by label selector
+// dependencies can be resolved by selector
+func NewNewestSelected(kind, selector string, apiClient client.Interface) (interfaces.Resource, error) {
+	if _, ok := selectorListers[kind]; !ok {
+		return nil, fmt.Errorf("%s/%s: kind '%s' can not be resolved by label selector", kind, selector, kind)
+	}
+	if _, err := labels.Parse(selector); err != nil {
+		return nil, fmt.Errorf("%s/%s: %v", kind, selector, err)
+	}
+	return NewestSelected{Kind: kind, Selector: selector, APIClient: apiClient, resolved: &resolvedObject{}}, nil
+}
+
+// Key returns key of the node, which is made of kind and the selector
+func (s NewestSelected) Key() string {
+	return s.Kind + "/" + s.Selector
+}
+
+// resolve returns existing resource of the newest object matching the selector, or NotFound error if
+// there is none
+func (s NewestSelected) resolve() (interfaces.Resource, error) {
+	s.resolved.Lock()
+	defer s.resolved.Unlock()
+	if s.resolved.resource != nil {
+		return s.resolved.resource, nil
+	}
+	parsed, err := labels.Parse(s.Selector)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := selectorListers[s.Kind](s.APIClient, v1.ListOptions{LabelSelector: parsed.String()})
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, errors.NewNotFound(unversioned.GroupResource{Resource: s.Kind}, s.Selector)
+	}
+	newest := objects[0]
+	for _, object := range objects[1:] {
+		if newest.CreationTimestamp.Before(object.CreationTimestamp) ||
+			newest.CreationTimestamp.Equal(object.CreationTimestamp) && object.Name > newest.Name {
+			newest = object
+		}
+	}
+	s.resolved.resource = KindToResourceTemplate[s.Kind].NewExisting(newest.Name, s.APIClient)
+	return s.resolved.resource, nil
+}
+
+// Status returns status of the newest object matching the selector
+func (s NewestSelected) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	r, err := s.resolve()
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	return r.Status(ctx, meta)
+}
+
+// GetDependencyReport returns a DependencyReport of the newest object matching the selector
+func (s NewestSelected) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	r, err := s.resolve()
+	if err != nil {
+		return report.ErrorReport(s.Key(), err)
+	}
+	depReport := r.GetDependencyReport(ctx, meta)
+	depReport.Dependency = s.Key()
+	return depReport
+}
+
+// StatusIsCacheable returns false while no object is resolved, so that objects created later are found
+func (s NewestSelected) StatusIsCacheable(meta map[string]string) bool {
+	r, err := s.resolve()
+	if err != nil {
+		return false
+	}
+	return r.StatusIsCacheable(meta)
+}
+
+// Create checks that an object matching the selector exists, as existing objects are not created
+func (s NewestSelected) Create(ctx context.Context) error {
+	r, err := s.resolve()
+	if err != nil {
+		return err
+	}
+	return r.Create(ctx)
+}
+
+// Delete deletes the newest object matching the selector
+func (s NewestSelected) Delete(ctx context.Context) error {
+	r, err := s.resolve()
+	if err != nil {
+		return err
+	}
+	return r.Delete(ctx)
+}
@@ -0,0 +1,85 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// makeLabeledPod returns pod labeled app=web created at given time
+func makeLabeledPod(name string, created time.Time) *v1.Pod {
+	pod := mocks.MakePod(name)
+	pod.Labels = map[string]string{"app": "web"}
+	pod.CreationTimestamp = unversioned.NewTime(created)
+	return pod
+}
+
+// TestNewestSelected checks that the newest matching object is resolved and kept for the rest of the run
+func TestNewestSelected(t *testing.T) {
+	now := time.Now()
+	c := mocks.NewClient(makeLabeledPod("ready-old", now.Add(-time.Hour)), makeLabeledPod("pending-new", now), mocks.MakePod("ready-other"))
+	r, err := NewNewestSelected("pod", "app=web", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Key() != "pod/app=web" {
+		t.Errorf("Unexpected key %s", r.Key())
+	}
+
+	status, err := r.Status(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Phase != "not ready" {
+		t.Errorf("Status of the newest pod pending-new should be `not ready`, is `%s` instead", status)
+	}
+	if err := r.Create(context.Background()); err != nil {
+		t.Errorf("Existing pod should be found, got %v", err)
+	}
+
+	if _, err := c.Pods().Create(makeLabeledPod("ready-newer", now.Add(time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+	if depReport := r.GetDependencyReport(context.Background(), nil); !depReport.Blocks || depReport.Dependency != "pod/app=web" {
+		t.Errorf("Resolved pod should be kept, got %+v", depReport)
+	}
+}
+
+// TestNewestSelectedMissing checks that missing objects and unsupported kinds are errors
+func TestNewestSelectedMissing(t *testing.T) {
+	c := mocks.NewClient()
+	r, err := NewNewestSelected("deployment", "app=web", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Status(context.Background(), nil); !errors.IsNotFound(err) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+	if err := r.Create(context.Background()); err == nil {
+		t.Error("Create should fail when no object matches")
+	}
+
+	if _, err := NewNewestSelected("configmap", "app=web", c); err == nil {
+		t.Error("Config maps should not be resolved by selector")
+	}
+}
@@ -0,0 +1,409 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/util/yaml"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// Patch types supported by patch nodes
+const (
+	PatchStrategic = "strategic"
+	PatchMerge     = "merge"
+	PatchJSON      = "json"
+)
+
+var patchTypes = map[string]api.PatchType{
+	PatchStrategic: api.StrategicMergePatchType,
+	PatchMerge:     api.MergePatchType,
+	PatchJSON:      api.JSONPatchType,
+}
+
+// Patch is a node which applies a patch to an existing object, e.g. to switch a Service selector
+// during cutover. It is ready when patched fields are observed in the object. Patches are not
+// reverted when the graph is destroyed
+type Patch struct {
+	Base
+	Patch     *client.Patch
+	APIClient client.Interface
+}
+
+func patchKey(name string) string {
+	return "patch/" + name
+}
+
+// Key returns Patch key
+func (p Patch) Key() string {
+	return patchKey(p.Patch.Name)
+}
+
+// jsonPatchOperation is a single operation of JSON patch
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// PatchData returns type and JSON body of the patch, or error if the patch is invalid
+func PatchData(p *client.Patch) (api.PatchType, []byte, error) {
+	patchType := p.Type
+	if patchType == "" {
+		patchType = PatchStrategic
+	}
+	pt, ok := patchTypes[patchType]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown patch type %q, expected one of strategic, merge or json", p.Type)
+	}
+	data, err := yaml.ToJSON([]byte(p.Patch))
+	if err != nil {
+		return "", nil, fmt.Errorf("patch is neither valid YAML nor JSON: %v", err)
+	}
+	if pt == api.JSONPatchType {
+		var ops []jsonPatchOperation
+		if err = json.Unmarshal(data, &ops); err != nil {
+			return "", nil, fmt.Errorf("JSON patch must be a list of operations: %v", err)
+		}
+	} else {
+		var fields map[string]interface{}
+		if err = json.Unmarshal(data, &fields); err != nil || fields == nil {
+			return "", nil, fmt.Errorf("%s patch must be an object", patchType)
+		}
+	}
+	return pt, data, nil
+}
+
+// patchTarget returns kind and name of the patched object
+func (p Patch) patchTarget() (string, string, error) {
+	parts := strings.Split(p.Patch.Target, "/")
+	if len(parts) != 2 || parts[1] == "" {
+		return "", "", fmt.Errorf("%s has invalid target %q, expected kind/name", p.Key(), p.Patch.Target)
+	}
+	return parts[0], parts[1], nil
+}
+
+// live returns the patched object as it is in the cluster, decoded to generic JSON values
+func (p Patch) live() (map[string]interface{}, error) {
+	kind, name, err := p.patchTarget()
+	if err != nil {
+		return nil, err
+	}
+	template, ok := KindToResourceTemplate[kind]
+	if !ok {
+		return nil, fmt.Errorf("%s has target of unknown kind %s", p.Key(), kind)
+	}
+	getter, ok := template.NewExisting(name, p.APIClient).(interfaces.LiveObjectGetter)
+	if !ok {
+		return nil, fmt.Errorf("%s has target of kind %s, which can not be patched", p.Key(), kind)
+	}
+	obj, err := getter.Live()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var live map[string]interface{}
+	err = json.Unmarshal(data, &live)
+	return live, err
+}
+
+// apply sends the patch to the API server
+func (p Patch) apply(pt api.PatchType, data []byte) error {
+	kind, name, err := p.patchTarget()
+	if err != nil {
+		return err
+	}
+	c := p.APIClient
+	switch kind {
+	case "pod":
+		_, err = c.Pods().Patch(name, pt, data)
+	case "job":
+		_, err = c.Jobs().Patch(name, pt, data)
+	case "service":
+		_, err = c.Services().Patch(name, pt, data)
+	case "replicaset":
+		_, err = c.ReplicaSets().Patch(name, pt, data)
+	case "statefulset":
+		_, err = c.StatefulSets().Patch(name, pt, data)
+	case "daemonset":
+		_, err = c.DaemonSets().Patch(name, pt, data)
+	case "deployment":
+		_, err = c.Deployments().Patch(name, pt, data)
+	case "configmap":
+		_, err = c.ConfigMaps().Patch(name, pt, data)
+	case "secret":
+		_, err = c.Secrets().Patch(name, pt, data)
+	case "serviceaccount":
+		_, err = c.ServiceAccounts().Patch(name, pt, data)
+	case "persistentvolumeclaim":
+		_, err = c.PersistentVolumeClaims().Patch(name, pt, data)
+	case "persistentvolume":
+		_, err = c.PersistentVolumes().Patch(name, pt, data)
+	case "namespace":
+		_, err = c.Namespaces().Patch(name, pt, data)
+	default:
+		return fmt.Errorf("%s has target of kind %s, which can not be patched", p.Key(), kind)
+	}
+	return err
+}
+
+// Status returns ready when all fields set by the patch are observed in the object and, for objects
+// with controllers, the controller has observed the patched spec
+func (p Patch) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	pt, data, err := PatchData(p.Patch)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), fmt.Errorf("%s: %v", p.Key(), err)
+	}
+	live, err := p.live()
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	observed, err := patchObserved(pt, data, live)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	if !observed {
+		return interfaces.NewStatus(interfaces.ResourceNotReady).WithReason("NotPatched",
+			fmt.Sprintf("%s does not match the patch", p.Patch.Target)), nil
+	}
+	if generationStale(live) {
+		return interfaces.NewStatus(interfaces.ResourceNotReady).WithReason("ObservedGenerationStale",
+			fmt.Sprintf("controller of %s has not observed the patch yet", p.Patch.Target)), nil
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+// generationStale returns true if status of the object was computed for an older generation of its spec
+func generationStale(live map[string]interface{}) bool {
+	generation, ok := jsonPointerValue(live, "/metadata/generation")
+	if !ok {
+		return false
+	}
+	observed, ok := jsonPointerValue(live, "/status/observedGeneration")
+	if !ok {
+		return false
+	}
+	g, _ := generation.(float64)
+	o, _ := observed.(float64)
+	return o < g
+}
+
+// patchObserved returns true if the live object, decoded to generic JSON values, has the patch applied
+func patchObserved(pt api.PatchType, data []byte, live map[string]interface{}) (bool, error) {
+	if pt == api.JSONPatchType {
+		var ops []jsonPatchOperation
+		if err := json.Unmarshal(data, &ops); err != nil {
+			return false, err
+		}
+		for _, op := range ops {
+			if !jsonPatchObserved(op, live) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return false, err
+	}
+	return mergePatchObserved(fields, live, pt == api.StrategicMergePatchType), nil
+}
+
+// mergePatchObserved checks that live object has values set by merge patch, and has no fields removed by it.
+// Lists of strategic merge patches are merged with the live ones, so every patch item must match some
+// live item; lists of merge patches replace the live ones
+func mergePatchObserved(patch, live map[string]interface{}, strategic bool) bool {
+	for key, value := range patch {
+		if strategic && strings.HasPrefix(key, "$") {
+			continue
+		}
+		liveValue, ok := live[key]
+		if value == nil {
+			if ok && liveValue != nil {
+				return false
+			}
+			continue
+		}
+		if !ok || !patchValueObserved(value, liveValue, strategic) {
+			return false
+		}
+	}
+	return true
+}
+
+func patchValueObserved(value, live interface{}, strategic bool) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		return ok && mergePatchObserved(v, l, strategic)
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok {
+			return false
+		}
+		if !strategic {
+			return reflect.DeepEqual(v, l)
+		}
+		for _, item := range v {
+			if directive, ok := item.(map[string]interface{}); ok {
+				if _, ok = directive["$patch"]; ok {
+					continue
+				}
+			}
+			found := false
+			for _, liveItem := range l {
+				if patchValueObserved(item, liveItem, strategic) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(value, live)
+}
+
+// jsonPatchObserved checks that effect of JSON patch operation is seen in the live object. Removal of
+// list items can not be checked, so it is treated as observed
+func jsonPatchObserved(op jsonPatchOperation, live map[string]interface{}) bool {
+	switch op.Op {
+	case "add", "replace", "test":
+		if strings.HasSuffix(op.Path, "/-") {
+			parent, ok := jsonPointerValue(live, strings.TrimSuffix(op.Path, "/-"))
+			if !ok {
+				return false
+			}
+			items, _ := parent.([]interface{})
+			for _, item := range items {
+				if reflect.DeepEqual(item, op.Value) {
+					return true
+				}
+			}
+			return false
+		}
+		value, ok := jsonPointerValue(live, op.Path)
+		return ok && reflect.DeepEqual(value, op.Value)
+	case "remove":
+		parent, _ := jsonPointerValue(live, op.Path[:strings.LastIndex(op.Path, "/")+1])
+		if _, ok := parent.([]interface{}); ok {
+			return true
+		}
+		_, ok := jsonPointerValue(live, op.Path)
+		return !ok
+	case "copy", "move":
+		_, ok := jsonPointerValue(live, op.Path)
+		return ok
+	}
+	return true
+}
+
+// jsonPointerValue returns value of the object at RFC 6901 pointer
+func jsonPointerValue(obj interface{}, pointer string) (interface{}, bool) {
+	pointer = strings.TrimSuffix(pointer, "/")
+	if pointer == "" {
+		return obj, true
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		switch v := obj.(type) {
+		case map[string]interface{}:
+			value, ok := v[token]
+			if !ok {
+				return nil, false
+			}
+			obj = value
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			obj = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return obj, true
+}
+
+// GetDependencyReport returns a DependencyReport for this Patch
+func (p Patch) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	status, err := p.Status(ctx, meta)
+	message := fmt.Sprintf("patch of %s is observed", p.Patch.Target)
+	if status.Message != "" {
+		message = status.Message
+	}
+	return statusReport(p.Key(), status, err, message)
+}
+
+// Create applies the patch, unless it is already observed in the object
+func (p Patch) Create(ctx context.Context) error {
+	pt, data, err := PatchData(p.Patch)
+	if err != nil {
+		return fmt.Errorf("%s: %v", p.Key(), err)
+	}
+	live, err := p.live()
+	if err != nil {
+		return err
+	}
+	if observed, err := patchObserved(pt, data, live); err != nil || observed {
+		return err
+	}
+	logging.ForResource(p.Key()).Infof("Applying %s patch to %s", pt, p.Patch.Target)
+	return p.apply(pt, data)
+}
+
+// Delete does nothing, as patches are not reverted
+func (p Patch) Delete(ctx context.Context) error {
+	return nil
+}
+
+// NameMatches gets resource definition and a name and checks if
+// the Patch part of resource definition has matching name.
+func (p Patch) NameMatches(def client.ResourceDefinition, name string) bool {
+	return def.Patch != nil && def.Patch.Name == name
+}
+
+// New returns new Patch based on resource definition
+func (p Patch) New(def client.ResourceDefinition, ci client.Interface) interfaces.Resource {
+	return NewPatch(def.Patch, ci, def.Meta)
+}
+
+// NewExisting returns Patch without target, as patch nodes can not exist without definition. Its
+// status is always an error
+func (p Patch) NewExisting(name string, ci client.Interface) interfaces.Resource {
+	return NewPatch(&client.Patch{Name: name}, ci, nil)
+}
+
+// NewPatch is a constructor for Patch
+func NewPatch(patch *client.Patch, c client.Interface, meta map[string]interface{}) interfaces.Resource {
+	return Patch{Base: Base{meta}, Patch: patch, APIClient: c}
+}
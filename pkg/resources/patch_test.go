@@ -0,0 +1,106 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestPatchObserved checks that patch nodes are ready only when the object matches the patch
+func TestPatchObserved(t *testing.T) {
+	service := mocks.MakeService("web")
+	service.Spec.Selector = map[string]string{"app": "web", "color": "blue"}
+	service.Annotations = map[string]string{"owner": "team"}
+	c := mocks.NewClient(service)
+
+	cases := []struct {
+		patchType string
+		patch     string
+		ready     bool
+	}{
+		{"", "spec:\n  selector:\n    color: blue", true},
+		{"", "spec:\n  selector:\n    color: green", false},
+		{"merge", `{"metadata": {"annotations": {"owner": null}}}`, false},
+		{"merge", `{"metadata": {"annotations": {"cutover": null}}}`, true},
+		{"json", `[{"op": "replace", "path": "/spec/selector/color", "value": "blue"}]`, true},
+		{"json", `[{"op": "add", "path": "/metadata/annotations/cutover", "value": "done"}]`, false},
+		{"json", `[{"op": "remove", "path": "/metadata/annotations/owner"}]`, false},
+	}
+	for _, tc := range cases {
+		patch := NewPatch(&client.Patch{Name: "cutover", Target: "service/web", Type: tc.patchType, Patch: tc.patch}, c, nil)
+		status, err := patch.Status(context.Background(), nil)
+		if err != nil {
+			t.Errorf("%s: %v", tc.patch, err)
+			continue
+		}
+		if status.IsReady() != tc.ready {
+			t.Errorf("patch %s of type %q should have ready=%t, got %v", tc.patch, tc.patchType, tc.ready, status)
+		}
+	}
+}
+
+// TestPatchStrategicLists checks that list items of strategic merge patch must be present in live lists
+func TestPatchStrategicLists(t *testing.T) {
+	live := map[string]interface{}{
+		"containers": []interface{}{
+			map[string]interface{}{"name": "app", "image": "app:v2"},
+			map[string]interface{}{"name": "proxy", "image": "proxy:v1"},
+		},
+	}
+	patch := map[string]interface{}{
+		"containers": []interface{}{map[string]interface{}{"name": "app", "image": "app:v2"}},
+	}
+	if !mergePatchObserved(patch, live, true) {
+		t.Error("strategic patch of one container should be observed")
+	}
+	if mergePatchObserved(patch, live, false) {
+		t.Error("merge patch replaces lists, so it should not be observed")
+	}
+}
+
+// TestPatchData checks validation of patches
+func TestPatchData(t *testing.T) {
+	invalid := []client.Patch{
+		{Type: "apply", Patch: "metadata: {}"},
+		{Patch: "- a"},
+		{Type: "json", Patch: "metadata: {}"},
+		{Patch: "{"},
+	}
+	for _, p := range invalid {
+		if _, _, err := PatchData(&p); err == nil {
+			t.Errorf("patch %+v should be invalid", p)
+		}
+	}
+	if _, _, err := PatchData(&client.Patch{Patch: "metadata:\n  labels:\n    a: b"}); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestPatchInvalidTarget checks that patch of unsupported target results in error
+func TestPatchInvalidTarget(t *testing.T) {
+	c := mocks.NewClient()
+	for _, target := range []string{"web", "flow/web", "service/missing"} {
+		patch := NewPatch(&client.Patch{Name: "p", Target: target, Patch: "metadata: {}"}, c, nil)
+		if status, _ := patch.Status(context.Background(), nil); status.Phase != interfaces.ResourceError {
+			t.Errorf("status of patch of %s should be error, got %v", target, status)
+		}
+	}
+}
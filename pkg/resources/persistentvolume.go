@@ -0,0 +1,158 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+// PersistentVolume is a cluster-scoped volume which claims of the graph can be bound to
+type PersistentVolume struct {
+	Base
+	PersistentVolume *v1.PersistentVolume
+	Client           corev1.PersistentVolumeInterface
+}
+
+func persistentVolumeKey(name string) string {
+	return "persistentvolume/" + name
+}
+
+// Key returns PersistentVolume key
+func (p PersistentVolume) Key() string {
+	return persistentVolumeKey(p.PersistentVolume.Name)
+}
+
+func persistentVolumeStatus(p corev1.PersistentVolumeInterface, name string) (interfaces.ResourceStatus, error) {
+	persistentVolume, err := p.Get(name)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	return persistentVolumeReadiness(persistentVolume)
+}
+
+// persistentVolumeReadiness returns ready status if the volume is available for claims or already bound
+func persistentVolumeReadiness(persistentVolume *v1.PersistentVolume) (interfaces.ResourceStatus, error) {
+	switch persistentVolume.Status.Phase {
+	case v1.VolumeAvailable, v1.VolumeBound:
+		return interfaces.NewStatus(interfaces.ResourceReady), nil
+	case v1.VolumeFailed:
+		return failedStatus(NewResourceError(persistentVolumeKey(persistentVolume.Name), string(v1.VolumeFailed), persistentVolume.Status.Message))
+	}
+	return interfaces.NewStatus(interfaces.ResourceNotReady), nil
+}
+
+func persistentVolumeReport(p corev1.PersistentVolumeInterface, name string) interfaces.DependencyReport {
+	persistentVolume, err := p.Get(name)
+	if err != nil {
+		return report.ErrorReport(persistentVolumeKey(name), err)
+	}
+	status, err := persistentVolumeReadiness(persistentVolume)
+	return statusReport(persistentVolumeKey(name), status, err, fmt.Sprintf("volume is %s", persistentVolume.Status.Phase))
+}
+
+// Create creates PersistentVolume if it does not exist
+func (p PersistentVolume) Create(ctx context.Context) error {
+	if err := checkExistence(ctx, p); err != nil {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		logging.ForResource(p.Key()).Infof("Creating %s", p.Key())
+		p.PersistentVolume, err = p.Client.Create(p.PersistentVolume)
+		return err
+	}
+	return nil
+}
+
+// Delete deletes PersistentVolume from the cluster
+func (p PersistentVolume) Delete(ctx context.Context) error {
+	return p.Client.Delete(p.PersistentVolume.Name, &v1.DeleteOptions{})
+}
+
+// Status returns PersistentVolume status
+func (p PersistentVolume) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	return persistentVolumeStatus(p.Client, p.PersistentVolume.Name)
+}
+
+// GetDependencyReport returns a DependencyReport for this PersistentVolume
+func (p PersistentVolume) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return persistentVolumeReport(p.Client, p.PersistentVolume.Name)
+}
+
+// NameMatches gets resource definition and a name and checks if
+// the PersistentVolume part of resource definition has matching name.
+func (p PersistentVolume) NameMatches(def client.ResourceDefinition, name string) bool {
+	return def.PersistentVolume != nil && def.PersistentVolume.Name == name
+}
+
+// New returns new PersistentVolume based on resource definition
+func (p PersistentVolume) New(def client.ResourceDefinition, c client.Interface) interfaces.Resource {
+	return NewPersistentVolume(def.PersistentVolume, c.PersistentVolumes(), def.Meta)
+}
+
+// NewExisting returns new ExistingPersistentVolume based on resource definition
+func (p PersistentVolume) NewExisting(name string, c client.Interface) interfaces.Resource {
+	return NewExistingPersistentVolume(name, c.PersistentVolumes())
+}
+
+// NewPersistentVolume returns PersistentVolume wrapped as Resource
+func NewPersistentVolume(persistentVolume *v1.PersistentVolume, client corev1.PersistentVolumeInterface, meta map[string]interface{}) interfaces.Resource {
+	return PersistentVolume{Base: Base{meta}, PersistentVolume: persistentVolume, Client: client}
+}
+
+// ExistingPersistentVolume is a PersistentVolume without definition
+type ExistingPersistentVolume struct {
+	Base
+	Name   string
+	Client corev1.PersistentVolumeInterface
+}
+
+// Key returns PersistentVolume key
+func (p ExistingPersistentVolume) Key() string {
+	return persistentVolumeKey(p.Name)
+}
+
+// Status returns PersistentVolume status
+func (p ExistingPersistentVolume) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	return persistentVolumeStatus(p.Client, p.Name)
+}
+
+// GetDependencyReport returns a DependencyReport for this PersistentVolume
+func (p ExistingPersistentVolume) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return persistentVolumeReport(p.Client, p.Name)
+}
+
+// Create looks for existing PersistentVolume and returns error if there is no such volume
+func (p ExistingPersistentVolume) Create(ctx context.Context) error {
+	return createExistingResource(ctx, p)
+}
+
+// Delete deletes PersistentVolume from the cluster
+func (p ExistingPersistentVolume) Delete(ctx context.Context) error {
+	return p.Client.Delete(p.Name, nil)
+}
+
+// NewExistingPersistentVolume returns existing PersistentVolume wrapped as Resource
+func NewExistingPersistentVolume(name string, client corev1.PersistentVolumeInterface) interfaces.Resource {
+	return ExistingPersistentVolume{Name: name, Client: client}
+}
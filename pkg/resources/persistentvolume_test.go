@@ -0,0 +1,65 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestPersistentVolumeStatus checks statuses of available, pending and failed volumes
+func TestPersistentVolumeStatus(t *testing.T) {
+	c := mocks.NewClient(
+		mocks.MakePersistentVolume("available"),
+		mocks.MakePersistentVolume("pending-volume"),
+		mocks.MakePersistentVolume("failed-volume"),
+	)
+	cases := map[string]interfaces.ResourcePhase{
+		"available":      interfaces.ResourceReady,
+		"pending-volume": interfaces.ResourceNotReady,
+		"failed-volume":  interfaces.ResourceError,
+		"missing":        interfaces.ResourceError,
+	}
+	for name, expected := range cases {
+		status, _ := persistentVolumeStatus(c.PersistentVolumes(), name)
+		if status.Phase != expected {
+			t.Errorf("Status of volume %s should be `%s`, is `%s` instead", name, expected, status.Phase)
+		}
+	}
+
+	status, err := persistentVolumeStatus(c.PersistentVolumes(), "failed-volume")
+	if _, ok := err.(ResourceError); !ok {
+		t.Errorf("Failed volume should result in resource error, got %v", err)
+	}
+	if status.Reason != "Failed" {
+		t.Errorf("Expected Failed reason, got %s", status.Reason)
+	}
+}
+
+// TestPersistentVolumeCreate checks that volume is created in the cluster
+func TestPersistentVolumeCreate(t *testing.T) {
+	c := mocks.NewClient()
+	volume := NewPersistentVolume(mocks.MakePersistentVolume("data"), c.PersistentVolumes(), nil)
+	if err := volume.Create(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.PersistentVolumes().Get("data"); err != nil {
+		t.Errorf("Volume should be created: %v", err)
+	}
+}
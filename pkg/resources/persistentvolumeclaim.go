@@ -15,13 +15,17 @@
 package resources
 
 import (
-	"log"
+	"fmt"
+	"strconv"
+	"time"
 
+	"golang.org/x/net/context"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/pkg/api/v1"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
 	"github.com/Mirantis/k8s-AppController/pkg/report"
 )
 
@@ -29,6 +33,13 @@ type PersistentVolumeClaim struct {
 	Base
 	PersistentVolumeClaim *v1.PersistentVolumeClaim
 	Client                corev1.PersistentVolumeClaimInterface
+	Events                corev1.EventInterface
+}
+
+// persistentVolumeClaimFailureReasons are reasons of warning events which mean that claim can not be provisioned
+var persistentVolumeClaimFailureReasons = map[string]bool{
+	"ProvisioningFailed": true,
+	"FailedBinding":      true,
 }
 
 func persistentVolumeClaimKey(name string) string {
@@ -39,22 +50,96 @@ func (p PersistentVolumeClaim) Key() string {
 	return persistentVolumeClaimKey(p.PersistentVolumeClaim.Name)
 }
 
-func persistentVolumeClaimStatus(p corev1.PersistentVolumeClaimInterface, name string) (string, error) {
+func persistentVolumeClaimStatus(p corev1.PersistentVolumeClaimInterface, events corev1.EventInterface, name string, timeout int) (interfaces.ResourceStatus, error) {
 	persistentVolumeClaim, err := p.Get(name)
 	if err != nil {
-		return "error", err
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	return persistentVolumeClaimReadiness(persistentVolumeClaim, events, timeout)
+}
+
+// persistentVolumeClaimReadiness returns error status if the claim is lost, provisioning of its volume
+// failed or it is still pending after timeout (in seconds) since its creation. Timeout is ignored if not positive
+func persistentVolumeClaimReadiness(persistentVolumeClaim *v1.PersistentVolumeClaim, events corev1.EventInterface, timeout int) (interfaces.ResourceStatus, error) {
+	key := persistentVolumeClaimKey(persistentVolumeClaim.Name)
+	switch persistentVolumeClaim.Status.Phase {
+	case v1.ClaimBound:
+		return interfaces.NewStatus(interfaces.ResourceReady), nil
+	case v1.ClaimLost:
+		return failedStatus(NewResourceError(key, "ClaimLost", "bound persistent volume does not exist any more"))
+	}
+
+	if events != nil {
+		event, err := persistentVolumeClaimFailure(persistentVolumeClaim, events)
+		if err != nil {
+			return interfaces.NewStatus(interfaces.ResourceError), err
+		}
+		if event != nil {
+			return failedStatus(NewResourceError(key, event.Reason, event.Message))
+		}
+	}
+
+	if timeout > 0 {
+		age := time.Since(persistentVolumeClaim.CreationTimestamp.Time)
+		if age > time.Duration(timeout)*time.Second {
+			return failedStatus(NewResourceError(
+				key, "ProvisioningTimeout", fmt.Sprintf("claim is still %s after %d seconds", persistentVolumeClaim.Status.Phase, timeout)))
+		}
 	}
 
-	if persistentVolumeClaim.Status.Phase == v1.ClaimBound {
-		return "ready", nil
+	return interfaces.NewStatus(interfaces.ResourceNotReady), nil
+}
+
+// persistentVolumeClaimFailure returns the latest warning event of the claim which means that
+// it can not be provisioned, or nil if there is none
+func persistentVolumeClaimFailure(persistentVolumeClaim *v1.PersistentVolumeClaim, events corev1.EventInterface) (*v1.Event, error) {
+	selector := fmt.Sprintf("involvedObject.kind=PersistentVolumeClaim,involvedObject.name=%s", persistentVolumeClaim.Name)
+	eventList, err := events.List(v1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return nil, err
 	}
 
-	return "not ready", nil
+	var result *v1.Event
+	for i := range eventList.Items {
+		event := &eventList.Items[i]
+		object := event.InvolvedObject
+		// events of previously deleted claim with the same name must not be taken into account
+		if object.Kind != "PersistentVolumeClaim" || object.Name != persistentVolumeClaim.Name || object.UID != persistentVolumeClaim.UID {
+			continue
+		}
+		if event.Type != v1.EventTypeWarning || !persistentVolumeClaimFailureReasons[event.Reason] {
+			continue
+		}
+		if result == nil || result.LastTimestamp.Time.Before(event.LastTimestamp.Time) {
+			result = event
+		}
+	}
+	return result, nil
 }
 
-func (p PersistentVolumeClaim) Create() error {
-	if err := checkExistence(p); err != nil {
-		log.Println("Creating ", p.Key())
+func persistentVolumeClaimReport(p corev1.PersistentVolumeClaimInterface, events corev1.EventInterface, name string, timeout int) interfaces.DependencyReport {
+	persistentVolumeClaim, err := p.Get(name)
+	if err != nil {
+		return report.ErrorReport(persistentVolumeClaimKey(name), err)
+	}
+	status, err := persistentVolumeClaimReadiness(persistentVolumeClaim, events, timeout)
+	return statusReport(
+		persistentVolumeClaimKey(name),
+		status,
+		err,
+		fmt.Sprintf("claim is %s", persistentVolumeClaim.Status.Phase),
+	)
+}
+
+func (p PersistentVolumeClaim) Create(ctx context.Context) error {
+	if err := checkExistence(ctx, p); err != nil {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		logging.ForResource(p.Key()).Infof("Creating %s", p.Key())
+		if err = setLastApplied(&p.PersistentVolumeClaim.ObjectMeta, p.PersistentVolumeClaim); err != nil {
+			return err
+		}
 		p.PersistentVolumeClaim, err = p.Client.Create(p.PersistentVolumeClaim)
 		return err
 	}
@@ -62,12 +147,29 @@ func (p PersistentVolumeClaim) Create() error {
 }
 
 // Delete deletes persistentVolumeClaim from the cluster
-func (p PersistentVolumeClaim) Delete() error {
+func (p PersistentVolumeClaim) Delete(ctx context.Context) error {
 	return p.Client.Delete(p.PersistentVolumeClaim.Name, &v1.DeleteOptions{})
 }
 
-func (p PersistentVolumeClaim) Status(meta map[string]string) (string, error) {
-	return persistentVolumeClaimStatus(p.Client, p.PersistentVolumeClaim.Name)
+func (p PersistentVolumeClaim) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	persistentVolumeClaim, err := p.Client.Get(p.PersistentVolumeClaim.Name)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	if !p.EqualToDefinition(persistentVolumeClaim) {
+		return interfaces.NewStatus(interfaces.ResourceWaitingForUpgrade), nil
+	}
+	return persistentVolumeClaimReadiness(persistentVolumeClaim, p.Events, GetIntMeta(p, "timeout", -1))
+}
+
+// EqualToDefinition checks if definition in object is compatible with provided object
+func (p PersistentVolumeClaim) EqualToDefinition(persistentVolumeClaim interface{}) bool {
+	return p.equalToDefinition(p.Key(), p.PersistentVolumeClaim, persistentVolumeClaim)
+}
+
+// GetDependencyReport returns a DependencyReport for this persistentVolumeClaim
+func (p PersistentVolumeClaim) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return persistentVolumeClaimReport(p.Client, p.Events, p.PersistentVolumeClaim.Name, GetIntMeta(p, "timeout", -1))
 }
 
 // NameMatches gets resource definition and a name and checks if
@@ -78,41 +180,64 @@ func (p PersistentVolumeClaim) NameMatches(def client.ResourceDefinition, name s
 
 // New returns new PersistentVolumeClaim based on resource definition
 func (p PersistentVolumeClaim) New(def client.ResourceDefinition, c client.Interface) interfaces.Resource {
-	return NewPersistentVolumeClaim(def.PersistentVolumeClaim, c.PersistentVolumeClaims(), def.Meta)
+	return NewPersistentVolumeClaim(def.PersistentVolumeClaim, c.PersistentVolumeClaims(), c.Events(), def.Meta)
 }
 
 // NewExisting returns new ExistingPersistentVolumeClaim based on resource definition
 func (p PersistentVolumeClaim) NewExisting(name string, c client.Interface) interfaces.Resource {
-	return NewExistingPersistentVolumeClaim(name, c.PersistentVolumeClaims())
+	return NewExistingPersistentVolumeClaim(name, c.PersistentVolumeClaims(), c.Events())
 }
 
-func NewPersistentVolumeClaim(persistentVolumeClaim *v1.PersistentVolumeClaim, client corev1.PersistentVolumeClaimInterface, meta map[string]interface{}) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: PersistentVolumeClaim{Base: Base{meta}, PersistentVolumeClaim: persistentVolumeClaim, Client: client}}
+func NewPersistentVolumeClaim(persistentVolumeClaim *v1.PersistentVolumeClaim, client corev1.PersistentVolumeClaimInterface, events corev1.EventInterface, meta map[string]interface{}) interfaces.Resource {
+	return PersistentVolumeClaim{Base: Base{meta}, PersistentVolumeClaim: persistentVolumeClaim, Client: client, Events: events}
 }
 
 type ExistingPersistentVolumeClaim struct {
 	Base
 	Name   string
 	Client corev1.PersistentVolumeClaimInterface
+	Events corev1.EventInterface
 }
 
 func (p ExistingPersistentVolumeClaim) Key() string {
 	return persistentVolumeClaimKey(p.Name)
 }
 
-func (p ExistingPersistentVolumeClaim) Create() error {
-	return createExistingResource(p)
+func (p ExistingPersistentVolumeClaim) Create(ctx context.Context) error {
+	return createExistingResource(ctx, p)
 }
 
-func (p ExistingPersistentVolumeClaim) Status(meta map[string]string) (string, error) {
-	return persistentVolumeClaimStatus(p.Client, p.Name)
+// existingClaimTimeout returns timeout of existing claim in seconds. Existing claims have no definition, so
+// timeout of the dependency on the claim is used, which is either a number of seconds or a duration. Claim
+// which is still pending after it fails like claims created from definitions with timeout in meta
+func existingClaimTimeout(meta map[string]string) int {
+	value, ok := meta["timeout"]
+	if !ok {
+		return -1
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return seconds
+	}
+	if timeout, err := time.ParseDuration(value); err == nil {
+		return int(timeout.Seconds())
+	}
+	return -1
+}
+
+func (p ExistingPersistentVolumeClaim) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	return persistentVolumeClaimStatus(p.Client, p.Events, p.Name, existingClaimTimeout(meta))
 }
 
 // Delete deletes persistentVolumeClaim from the cluster
-func (p ExistingPersistentVolumeClaim) Delete() error {
+func (p ExistingPersistentVolumeClaim) Delete(ctx context.Context) error {
 	return p.Client.Delete(p.Name, nil)
 }
 
-func NewExistingPersistentVolumeClaim(name string, client corev1.PersistentVolumeClaimInterface) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: ExistingPersistentVolumeClaim{Name: name, Client: client}}
+// GetDependencyReport returns a DependencyReport for this persistentVolumeClaim
+func (p ExistingPersistentVolumeClaim) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return persistentVolumeClaimReport(p.Client, p.Events, p.Name, existingClaimTimeout(meta))
+}
+
+func NewExistingPersistentVolumeClaim(name string, client corev1.PersistentVolumeClaimInterface, events corev1.EventInterface) interfaces.Resource {
+	return ExistingPersistentVolumeClaim{Name: name, Client: client, Events: events}
 }
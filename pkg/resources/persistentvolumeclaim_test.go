@@ -0,0 +1,113 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestPersistentVolumeClaimBound checks status of bound claim
+func TestPersistentVolumeClaimBound(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePersistentVolumeClaim("Bound-1"))
+	status, err := persistentVolumeClaimStatus(c.PersistentVolumeClaims(), c.Events(), "Bound-1", -1)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status.Phase != interfaces.ResourceReady {
+		t.Errorf("Status should be `ready`, is `%s` instead.", status)
+	}
+}
+
+// TestPersistentVolumeClaimProvisioningFailed checks that provisioning failure event makes claim status an error
+func TestPersistentVolumeClaimProvisioningFailed(t *testing.T) {
+	pvc := mocks.MakePersistentVolumeClaim("Pending-1")
+	event := &v1.Event{
+		InvolvedObject: v1.ObjectReference{Kind: "PersistentVolumeClaim", Name: "Pending-1"},
+		Type:           v1.EventTypeWarning,
+		Reason:         "ProvisioningFailed",
+		Message:        "storageclass.storage.k8s.io \"fast\" not found",
+	}
+	event.Name = "Pending-1.event"
+	event.Namespace = "testing"
+	c := mocks.NewClient(pvc, event)
+
+	status, err := persistentVolumeClaimStatus(c.PersistentVolumeClaims(), c.Events(), "Pending-1", -1)
+	if status.Phase != interfaces.ResourceError {
+		t.Errorf("Status should be `error`, is `%s` instead.", status)
+	}
+	resourceError, ok := err.(ResourceError)
+	if !ok {
+		t.Fatalf("Expected ResourceError, got %v", err)
+	}
+	if resourceError.Reason != "ProvisioningFailed" {
+		t.Errorf("Expected reason `ProvisioningFailed`, got `%s`", resourceError.Reason)
+	}
+}
+
+// TestPersistentVolumeClaimTimeout checks that pending claim fails after timeout
+func TestPersistentVolumeClaimTimeout(t *testing.T) {
+	pvc := mocks.MakePersistentVolumeClaim("Pending-1")
+	pvc.CreationTimestamp = unversioned.NewTime(time.Now().Add(-time.Minute))
+	c := mocks.NewClient(pvc)
+
+	status, err := persistentVolumeClaimStatus(c.PersistentVolumeClaims(), c.Events(), "Pending-1", 3600)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != interfaces.ResourceNotReady {
+		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
+	}
+
+	status, err = persistentVolumeClaimStatus(c.PersistentVolumeClaims(), c.Events(), "Pending-1", 30)
+	if err == nil {
+		t.Error("Error not found, expected timeout error")
+	}
+	if status.Phase != interfaces.ResourceError {
+		t.Errorf("Status should be `error`, is `%s` instead.", status)
+	}
+}
+
+// TestExistingPersistentVolumeClaimTimeout checks that existing pending claim fails after timeout of the
+// dependency on it
+func TestExistingPersistentVolumeClaimTimeout(t *testing.T) {
+	pvc := mocks.MakePersistentVolumeClaim("Pending-1")
+	pvc.CreationTimestamp = unversioned.NewTime(time.Now().Add(-time.Minute))
+	c := mocks.NewClient(pvc)
+	existing := NewExistingPersistentVolumeClaim("Pending-1", c.PersistentVolumeClaims(), c.Events())
+
+	for _, meta := range []map[string]string{nil, {"timeout": "3600"}, {"timeout": "1h"}} {
+		if status, err := existing.Status(context.Background(), meta); err != nil || status.Phase != interfaces.ResourceNotReady {
+			t.Errorf("Expected claim to be not ready with meta %v, got %s, %v", meta, status, err)
+		}
+	}
+	for _, meta := range []map[string]string{{"timeout": "30"}, {"timeout": "30s"}} {
+		if status, err := existing.Status(context.Background(), meta); err == nil || status.Phase != interfaces.ResourceError {
+			t.Errorf("Expected claim to time out with meta %v, got %s, %v", meta, status, err)
+		}
+		if depReport := existing.GetDependencyReport(context.Background(), meta); !depReport.Blocks {
+			t.Errorf("Expected report of claim which timed out to block, got %+v", depReport)
+		}
+	}
+}
@@ -15,15 +15,22 @@
 package resources
 
 import (
-	"log"
+	"fmt"
+
+	"golang.org/x/net/context"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	appsalpha1 "github.com/Mirantis/k8s-AppController/pkg/client/petsets/apis/apps/v1alpha1"
 	"github.com/Mirantis/k8s-AppController/pkg/client/petsets/typed/apps/v1alpha1"
 	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
 	"github.com/Mirantis/k8s-AppController/pkg/report"
 )
 
+// PetSetsEnabled enables support of PetSets, which are replaced by StatefulSets since Kubernetes 1.5.
+// Graphs with PetSets are rejected unless it is set
+var PetSetsEnabled = false
+
 // PetSet is a wrapper for K8s PetSet object
 type PetSet struct {
 	Base
@@ -32,13 +39,72 @@ type PetSet struct {
 	APIClient client.Interface
 }
 
-func petsetStatus(p v1alpha1.PetSetInterface, name string, apiClient client.Interface) (string, error) {
+func petsetStatus(ctx context.Context, p v1alpha1.PetSetInterface, name string, apiClient client.Interface, meta map[string]string) (interfaces.ResourceStatus, error) {
 	// Use label from petset spec to get needed pods
 	ps, err := p.Get(name)
 	if err != nil {
-		return "error", err
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	if _, ok := meta[SuccessFactorKey]; !ok {
+		return podsStateFromLabels(ctx, apiClient, ps.Spec.Template.ObjectMeta.Labels)
+	}
+
+	successFactor, err := getPercentage(SuccessFactorKey, meta)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	ready, err := readyPodsCountFromLabels(apiClient, ps.Spec.Template.ObjectMeta.Labels)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	if ready*100 < petsetReplicas(ps)*successFactor {
+		return interfaces.NewStatus(interfaces.ResourceNotReady).
+			WithReason("PodsNotReady", fmt.Sprintf("%d of %d pods ready", ready, petsetReplicas(ps))).
+			WithProgress(int(ready), int(petsetReplicas(ps))), nil
 	}
-	return podsStateFromLabels(apiClient, ps.Spec.Template.ObjectMeta.Labels)
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+// petsetReplicas returns the desired number of pets, which is 1 if it is not set
+func petsetReplicas(ps *appsalpha1.PetSet) int32 {
+	if ps.Spec.Replicas == nil {
+		return 1
+	}
+	return *ps.Spec.Replicas
+}
+
+func petsetReport(ctx context.Context, p v1alpha1.PetSetInterface, name string, apiClient client.Interface, meta map[string]string) interfaces.DependencyReport {
+	ps, err := p.Get(name)
+	if err != nil {
+		return report.ErrorReport(petsetKey(name), err)
+	}
+	status, err := petsetStatus(ctx, p, name, apiClient, meta)
+	if status.Phase == interfaces.ResourceError {
+		return report.ErrorReport(petsetKey(name), err)
+	}
+	successFactor, err := getPercentage(SuccessFactorKey, meta)
+	if err != nil {
+		return report.ErrorReport(petsetKey(name), err)
+	}
+	ready, err := readyPodsCountFromLabels(apiClient, ps.Spec.Template.ObjectMeta.Labels)
+	if err != nil {
+		return report.ErrorReport(petsetKey(name), err)
+	}
+
+	return percentageReport(
+		petsetKey(name),
+		status,
+		ready,
+		petsetReplicas(ps),
+		successFactor,
+		fmt.Sprintf("%d of %d pods ready", ready, petsetReplicas(ps)),
+	)
+}
+
+// petsetStatusIsCacheable returns false if meta contains SuccessFactorKey
+func petsetStatusIsCacheable(meta map[string]string) bool {
+	_, ok := meta[SuccessFactorKey]
+	return !ok
 }
 
 func petsetKey(name string) string {
@@ -51,9 +117,12 @@ func (p PetSet) Key() string {
 }
 
 // Create looks for a PetSet in Kubernetes cluster and creates it if it's not there
-func (p PetSet) Create() error {
-	if err := checkExistence(p); err != nil {
-		log.Println("Creating ", p.Key())
+func (p PetSet) Create(ctx context.Context) error {
+	if err := checkExistence(ctx, p); err != nil {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		logging.ForResource(p.Key()).Infof("Creating %s", p.Key())
 		_, err = p.Client.Create(p.PetSet)
 		return err
 	}
@@ -61,13 +130,23 @@ func (p PetSet) Create() error {
 }
 
 // Delete deletes PetSet from the cluster
-func (p PetSet) Delete() error {
+func (p PetSet) Delete(ctx context.Context) error {
 	return p.Client.Delete(p.PetSet.Name, nil)
 }
 
 // Status returns PetSet status as a string. "ready" is regarded as sufficient for it's dependencies to be created.
-func (p PetSet) Status(meta map[string]string) (string, error) {
-	return petsetStatus(p.Client, p.PetSet.Name, p.APIClient)
+func (p PetSet) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	return petsetStatus(ctx, p.Client, p.PetSet.Name, p.APIClient, meta)
+}
+
+// GetDependencyReport returns a DependencyReport for this PetSet
+func (p PetSet) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return petsetReport(ctx, p.Client, p.PetSet.Name, p.APIClient, meta)
+}
+
+// StatusIsCacheable returns false if meta contains SuccessFactorKey
+func (p PetSet) StatusIsCacheable(meta map[string]string) bool {
+	return petsetStatusIsCacheable(meta)
 }
 
 // NameMatches gets resource definition and a name and checks if
@@ -88,7 +167,7 @@ func (p PetSet) NewExisting(name string, c client.Interface) interfaces.Resource
 
 // NewPetSet is a constructor
 func NewPetSet(petset *appsalpha1.PetSet, client v1alpha1.PetSetInterface, apiClient client.Interface, meta map[string]interface{}) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: PetSet{Base: Base{meta}, PetSet: petset, Client: client, APIClient: apiClient}}
+	return PetSet{Base: Base{meta}, PetSet: petset, Client: client, APIClient: apiClient}
 }
 
 // ExistingPetSet is a wrapper for K8s PetSet object which is meant to already be in a cluster bofer AppController execution
@@ -105,21 +184,31 @@ func (p ExistingPetSet) Key() string {
 }
 
 // Create looks for existing PetSet and returns an error if there is no such PetSet in a cluster
-func (p ExistingPetSet) Create() error {
-	return createExistingResource(p)
+func (p ExistingPetSet) Create(ctx context.Context) error {
+	return createExistingResource(ctx, p)
 }
 
 // Status returns PetSet status as a string. "ready" is regarded as sufficient for it's dependencies to be created.
-func (p ExistingPetSet) Status(meta map[string]string) (string, error) {
-	return petsetStatus(p.Client, p.Name, p.APIClient)
+func (p ExistingPetSet) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	return petsetStatus(ctx, p.Client, p.Name, p.APIClient, meta)
+}
+
+// GetDependencyReport returns a DependencyReport for this PetSet
+func (p ExistingPetSet) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return petsetReport(ctx, p.Client, p.Name, p.APIClient, meta)
+}
+
+// StatusIsCacheable returns false if meta contains SuccessFactorKey
+func (p ExistingPetSet) StatusIsCacheable(meta map[string]string) bool {
+	return petsetStatusIsCacheable(meta)
 }
 
 // Delete deletes PetSet from the cluster
-func (p ExistingPetSet) Delete() error {
+func (p ExistingPetSet) Delete(ctx context.Context) error {
 	return p.Client.Delete(p.Name, nil)
 }
 
 // NewExistingPetSet is a constructor
 func NewExistingPetSet(name string, client v1alpha1.PetSetInterface, apiClient client.Interface) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: ExistingPetSet{Name: name, Client: client, APIClient: apiClient}}
+	return ExistingPetSet{Name: name, Client: client, APIClient: apiClient}
 }
@@ -0,0 +1,48 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux,cgo darwin,cgo
+
+package resources
+
+import (
+	"fmt"
+	"plugin"
+
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// LoadPlugin opens Go plugin built with -buildmode=plugin against the same AppController sources.
+// Plugins register their kinds with Register in init functions, or in exported Register function
+// of type func() error, which is called after the plugin is opened
+func LoadPlugin(path string) error {
+	registered := len(Kinds)
+	p, err := plugin.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not load plugin %s: %v", path, err)
+	}
+	if symbol, err := p.Lookup("Register"); err == nil {
+		register, ok := symbol.(func() error)
+		if !ok {
+			return fmt.Errorf("Register of plugin %s must be func() error, got %T", path, symbol)
+		}
+		if err = register(); err != nil {
+			return fmt.Errorf("plugin %s: %v", path, err)
+		}
+	}
+	if len(Kinds) == registered {
+		logging.Warningf("Plugin %s did not register any kinds", path)
+	}
+	return nil
+}
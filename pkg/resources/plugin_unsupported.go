@@ -0,0 +1,24 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !linux,!darwin !cgo
+
+package resources
+
+import "fmt"
+
+// LoadPlugin returns error, as Go plugins are supported only on Linux and macOS with cgo enabled
+func LoadPlugin(path string) error {
+	return fmt.Errorf("could not load plugin %s: plugins are not supported on this platform", path)
+}
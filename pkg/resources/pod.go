@@ -15,13 +15,15 @@
 package resources
 
 import (
-	"log"
+	"fmt"
 
+	"golang.org/x/net/context"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/pkg/api/v1"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
 	"github.com/Mirantis/k8s-AppController/pkg/report"
 )
 
@@ -39,21 +41,95 @@ func (p Pod) Key() string {
 	return podKey(p.Pod.Name)
 }
 
-func podStatus(p corev1.PodInterface, name string) (string, error) {
+func podStatus(p corev1.PodInterface, name string) (interfaces.ResourceStatus, error) {
 	pod, err := p.Get(name)
 	if err != nil {
-		return "error", err
+		return interfaces.NewStatus(interfaces.ResourceError), err
 	}
+	return podReadiness(pod)
+}
+
+// podWaitingErrors are reasons of waiting container state which will not resolve without user action
+var podWaitingErrors = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+}
 
-	if pod.Status.Phase == "Succeeded" {
-		return "ready", nil
+// podReadiness checks pod phase, its Ready condition and states of its containers. Ready condition
+// is only set by kubelet when all containers pass their readiness probes, so it is used instead of
+// container statuses to decide whether pod is ready
+func podReadiness(pod *v1.Pod) (interfaces.ResourceStatus, error) {
+	if pod.Status.Phase == v1.PodSucceeded {
+		return interfaces.NewStatus(interfaces.ResourceReady), nil
+	}
+
+	statuses := append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...)
+	for _, container := range statuses {
+		waiting := container.State.Waiting
+		if waiting != nil && podWaitingErrors[waiting.Reason] {
+			return failedStatus(NewResourceError(
+				podKey(pod.Name),
+				waiting.Reason,
+				fmt.Sprintf("container %s: %s", container.Name, waiting.Message),
+			))
+		}
+	}
+
+	if pod.Status.Phase == v1.PodRunning && isReady(pod) {
+		return interfaces.NewStatus(interfaces.ResourceReady), nil
+	}
+
+	return podNotReadyStatus(pod, statuses), nil
+}
+
+// podNotReadyStatus explains why the pod is not ready: reason is taken from the first waiting container,
+// or from the pod itself, and progress is the share of ready containers
+func podNotReadyStatus(pod *v1.Pod, statuses []v1.ContainerStatus) interfaces.ResourceStatus {
+	reason := pod.Status.Reason
+	if reason == "" {
+		reason = string(pod.Status.Phase)
+	}
+	message := pod.Status.Message
+	for _, container := range statuses {
+		if waiting := container.State.Waiting; waiting != nil && waiting.Reason != "" {
+			reason = waiting.Reason
+			message = fmt.Sprintf("container %s: %s", container.Name, waiting.Message)
+			break
+		}
 	}
 
-	if pod.Status.Phase == "Running" && isReady(pod) {
-		return "ready", nil
+	ready := 0
+	for _, container := range pod.Status.ContainerStatuses {
+		if container.Ready {
+			ready++
+		}
+	}
+	total := len(pod.Spec.Containers)
+	if message == "" && total > 0 {
+		message = fmt.Sprintf("%d of %d containers ready", ready, total)
+	}
+	status := interfaces.NewStatus(interfaces.ResourceNotReady).WithReason(reason, message)
+	if total > 0 {
+		status = status.WithProgress(ready, total)
 	}
+	return status
+}
 
-	return "not ready", nil
+func podReport(p corev1.PodInterface, name string) interfaces.DependencyReport {
+	pod, err := p.Get(name)
+	if err != nil {
+		return report.ErrorReport(podKey(name), err)
+	}
+	status, err := podReadiness(pod)
+	return statusReport(
+		podKey(name),
+		status,
+		err,
+		fmt.Sprintf("pod is %s, ready condition: %t", pod.Status.Phase, isReady(pod)),
+	)
 }
 
 func isReady(pod *v1.Pod) bool {
@@ -66,9 +142,12 @@ func isReady(pod *v1.Pod) bool {
 	return false
 }
 
-func (p Pod) Create() error {
-	if err := checkExistence(p); err != nil {
-		log.Println("Creating ", p.Key())
+func (p Pod) Create(ctx context.Context) error {
+	if err := checkExistence(ctx, p); err != nil {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		logging.ForResource(p.Key()).Infof("Creating %s", p.Key())
 		p.Pod, err = p.Client.Create(p.Pod)
 		return err
 	}
@@ -76,14 +155,19 @@ func (p Pod) Create() error {
 }
 
 // Delete deletes pod from the cluster
-func (p Pod) Delete() error {
+func (p Pod) Delete(ctx context.Context) error {
 	return p.Client.Delete(p.Pod.Name, nil)
 }
 
-func (p Pod) Status(meta map[string]string) (string, error) {
+func (p Pod) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
 	return podStatus(p.Client, p.Pod.Name)
 }
 
+// GetDependencyReport returns a DependencyReport for this pod
+func (p Pod) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return podReport(p.Client, p.Pod.Name)
+}
+
 // NameMatches gets resource definition and a name and checks if
 // the Pod part of resource definition has matching name.
 func (p Pod) NameMatches(def client.ResourceDefinition, name string) bool {
@@ -101,7 +185,7 @@ func (p Pod) NewExisting(name string, c client.Interface) interfaces.Resource {
 }
 
 func NewPod(pod *v1.Pod, client corev1.PodInterface, meta map[string]interface{}) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: Pod{Base: Base{meta}, Pod: pod, Client: client}}
+	return Pod{Base: Base{meta}, Pod: pod, Client: client}
 }
 
 type ExistingPod struct {
@@ -114,19 +198,24 @@ func (p ExistingPod) Key() string {
 	return podKey(p.Name)
 }
 
-func (p ExistingPod) Create() error {
-	return createExistingResource(p)
+func (p ExistingPod) Create(ctx context.Context) error {
+	return createExistingResource(ctx, p)
 }
 
-func (p ExistingPod) Status(meta map[string]string) (string, error) {
+func (p ExistingPod) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
 	return podStatus(p.Client, p.Name)
 }
 
 // Delete deletes pod from the cluster
-func (p ExistingPod) Delete() error {
+func (p ExistingPod) Delete(ctx context.Context) error {
 	return p.Client.Delete(p.Name, nil)
 }
 
+// GetDependencyReport returns a DependencyReport for this pod
+func (p ExistingPod) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return podReport(p.Client, p.Name)
+}
+
 func NewExistingPod(name string, client corev1.PodInterface) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: ExistingPod{Name: name, Client: client}}
+	return ExistingPod{Name: name, Client: client}
 }
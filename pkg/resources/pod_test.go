@@ -0,0 +1,119 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestPodDependencyReport checks reports of ready and pending pods
+func TestPodDependencyReport(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePod("ready-1"), mocks.MakePod("pending-1"))
+
+	depReport := NewExistingPod("ready-1", c.Pods()).GetDependencyReport(context.Background(), nil)
+	if depReport.Blocks {
+		t.Error("Dependency report for ready pod should not block")
+	}
+	if depReport.Percentage != 100 {
+		t.Errorf("Expected percentage 100, got %d", depReport.Percentage)
+	}
+
+	depReport = NewExistingPod("pending-1", c.Pods()).GetDependencyReport(context.Background(), nil)
+	if !depReport.Blocks {
+		t.Error("Dependency report for pending pod should block")
+	}
+	expected := "pod is Pending, ready condition: false"
+	if depReport.Message != expected {
+		t.Errorf("Expected message `%s`, got `%s`", expected, depReport.Message)
+	}
+}
+
+// TestPodCrashLoopStatus checks that pods which can not start are reported as errors
+func TestPodCrashLoopStatus(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePod("crashloop-1"), mocks.MakePod("imagepull-1"))
+
+	for name, reason := range map[string]string{"crashloop-1": "CrashLoopBackOff", "imagepull-1": "ImagePullBackOff"} {
+		status, err := podStatus(c.Pods(), name)
+		if status.Phase != interfaces.ResourceError {
+			t.Errorf("Status should be `error`, is `%s` instead.", status)
+		}
+		resourceError, ok := err.(ResourceError)
+		if !ok {
+			t.Fatalf("Expected ResourceError, got %v", err)
+		}
+		if resourceError.Reason != reason {
+			t.Errorf("Expected reason `%s`, got `%s`", reason, resourceError.Reason)
+		}
+
+		depReport := NewExistingPod(name, c.Pods()).GetDependencyReport(context.Background(), nil)
+		if !depReport.Blocks {
+			t.Error("Dependency report for failing pod should block")
+		}
+		if !strings.Contains(depReport.Message, reason) {
+			t.Errorf("Dependency report message `%s` should contain `%s`", depReport.Message, reason)
+		}
+	}
+}
+
+// TestPodRunningNotReady checks that running pod without Ready condition is not ready
+func TestPodRunningNotReady(t *testing.T) {
+	pod := mocks.MakePod("pending-1")
+	pod.Status.Phase = "Running"
+	pod.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionFalse}}
+
+	status, err := podReadiness(pod)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != interfaces.ResourceNotReady {
+		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
+	}
+}
+
+// TestPodNotReadyReason checks that status of pod which is not ready explains why and shows share of ready containers
+func TestPodNotReadyReason(t *testing.T) {
+	pod := mocks.MakePod("pending-1")
+	pod.Spec.Containers = []v1.Container{{Name: "app"}, {Name: "sidecar"}}
+	pod.Status.ContainerStatuses = []v1.ContainerStatus{
+		{Name: "app", Ready: true},
+		{Name: "sidecar", State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ContainerCreating"}}},
+	}
+
+	status, err := podReadiness(pod)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != interfaces.ResourceNotReady {
+		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
+	}
+	if status.Reason != "ContainerCreating" {
+		t.Errorf("Expected reason `ContainerCreating`, got `%s`", status.Reason)
+	}
+	if status.Progress != 50 {
+		t.Errorf("Expected progress 50, got %d", status.Progress)
+	}
+
+	depReport := NewExistingPod("pending-1", mocks.NewClient(pod).Pods()).GetDependencyReport(context.Background(), nil)
+	if depReport.Percentage != 50 || !strings.HasPrefix(depReport.Message, "ContainerCreating: ") {
+		t.Errorf("Dependency report should show reason and progress, got %+v", depReport)
+	}
+}
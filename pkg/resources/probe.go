@@ -0,0 +1,175 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+)
+
+// ReadinessProbeKey is a meta key of resource definition holding a probe which has to pass, in addition to
+// the status of the object, before the resource is ready
+const ReadinessProbeKey = "readiness-probe"
+
+// ReadinessProbe checks readiness of the application which can not be told by the status of its objects,
+// e.g. that database schema is migrated. Exactly one of Exec and HTTP must be set
+type ReadinessProbe struct {
+	Exec *ExecProbe `json:"exec,omitempty"`
+	HTTP *HTTPProbe `json:"http,omitempty"`
+	// TimeoutSeconds is a timeout of a single probe, 5 seconds if not set
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+
+	client client.Interface
+}
+
+// ExecProbe passes if the command exits with zero code in a ready pod
+type ExecProbe struct {
+	// Pod is the name of the pod to run the command in. If it is not set, the command is run in the first
+	// ready pod matching Selector
+	Pod       string   `json:"pod,omitempty"`
+	Selector  string   `json:"selector,omitempty"`
+	Container string   `json:"container,omitempty"`
+	Command   []string `json:"command"`
+}
+
+// HTTPProbe passes if HTTP GET of the URL, or of the path on the port of the service, returns expected status
+// (200 if not set) with body matching BodyRegex, if set
+type HTTPProbe struct {
+	URL            string `json:"url,omitempty"`
+	Service        string `json:"service,omitempty"`
+	Port           int    `json:"port,omitempty"`
+	Path           string `json:"path,omitempty"`
+	ExpectedStatus int    `json:"expectedStatus,omitempty"`
+	BodyRegex      string `json:"bodyRegex,omitempty"`
+}
+
+// GetReadinessProbe returns readiness probe of the resource bound to the client, or nil if the resource
+// does not have one
+func GetReadinessProbe(r interfaces.BaseResource, c client.Interface) (*ReadinessProbe, error) {
+	value := r.Meta(ReadinessProbeKey)
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	probe := &ReadinessProbe{}
+	if err := json.Unmarshal(data, probe); err != nil {
+		return nil, fmt.Errorf("%s of %s is invalid: %v", ReadinessProbeKey, r.Key(), err)
+	}
+	if err := probe.validate(); err != nil {
+		return nil, fmt.Errorf("%s of %s is invalid: %v", ReadinessProbeKey, r.Key(), err)
+	}
+	probe.client = c
+	return probe, nil
+}
+
+func (p *ReadinessProbe) validate() error {
+	switch {
+	case (p.Exec == nil) == (p.HTTP == nil):
+		return errors.New("exactly one of exec and http must be set")
+	case p.Exec != nil && len(p.Exec.Command) == 0:
+		return errors.New("exec must have command")
+	case p.Exec != nil && (p.Exec.Pod == "") == (p.Exec.Selector == ""):
+		return errors.New("exec must have either pod or selector")
+	case p.HTTP != nil && (p.HTTP.URL == "") == (p.HTTP.Service == ""):
+		return errors.New("http must have either url or service")
+	case p.HTTP != nil && p.HTTP.Service != "" && p.HTTP.Port <= 0:
+		return errors.New("http with service must have port")
+	}
+	return nil
+}
+
+// Status returns ready if the probe passes and not ready with the reason otherwise, so that the probe
+// is retried until the resource times out
+func (p *ReadinessProbe) Status(ctx context.Context) (interfaces.ResourceStatus, error) {
+	timeout := defaultExternalCheckTimeout
+	if p.TimeoutSeconds > 0 {
+		timeout = time.Duration(p.TimeoutSeconds) * time.Second
+	}
+	if p.HTTP != nil {
+		return p.httpStatus(ctx, timeout)
+	}
+	return p.execStatus(ctx, timeout)
+}
+
+func (p *ReadinessProbe) httpStatus(ctx context.Context, timeout time.Duration) (interfaces.ResourceStatus, error) {
+	url := p.HTTP.URL
+	if url == "" {
+		url = fmt.Sprintf("http://%s:%d/%s", p.HTTP.Service, p.HTTP.Port, strings.TrimPrefix(p.HTTP.Path, "/"))
+	}
+	check := &client.ExternalCheck{URL: url, ExpectedStatus: p.HTTP.ExpectedStatus, BodyRegex: p.HTTP.BodyRegex}
+	status, message, err := httpCheckStatus(ctx, check, timeout)
+	if err == nil && !status.IsReady() {
+		status = status.WithReason("ReadinessProbeFailed", message)
+	}
+	return status, err
+}
+
+func (p *ReadinessProbe) execStatus(ctx context.Context, timeout time.Duration) (interfaces.ResourceStatus, error) {
+	pod := p.Exec.Pod
+	if pod == "" {
+		var err error
+		if pod, err = p.selectPod(); err != nil {
+			return interfaces.NewStatus(interfaces.ResourceError), err
+		}
+		if pod == "" {
+			return interfaces.NewStatus(interfaces.ResourceNotReady).WithReason(
+				"ReadinessProbeFailed", fmt.Sprintf("no ready pods match %s", p.Exec.Selector)), nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if _, err := p.client.Exec(ctx, pod, p.Exec.Container, p.Exec.Command); err != nil {
+		if err == client.ErrExecNotSupported {
+			return interfaces.NewStatus(interfaces.ResourceError), err
+		}
+		return interfaces.NewStatus(interfaces.ResourceNotReady).WithReason(
+			"ReadinessProbeFailed", fmt.Sprintf("%s in pod %s failed: %v", strings.Join(p.Exec.Command, " "), pod, err)), nil
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+// selectPod returns name of the first ready pod matching the selector of exec probe in order of names,
+// or empty string if there is none
+func (p *ReadinessProbe) selectPod() (string, error) {
+	pods, err := p.client.Pods().List(v1.ListOptions{LabelSelector: p.Exec.Selector})
+	if err != nil {
+		return "", err
+	}
+	var ready []string
+	for i := range pods.Items {
+		if status, err := podReadiness(&pods.Items[i]); err == nil && status.IsReady() {
+			ready = append(ready, pods.Items[i].Name)
+		}
+	}
+	if len(ready) == 0 {
+		return "", nil
+	}
+	sort.Strings(ready)
+	return ready[0], nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/runtime"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// fakeExecutor records executed commands and fails those which are not expected to pass
+type fakeExecutor struct {
+	pods     []string
+	commands [][]string
+	passing  bool
+}
+
+func (e *fakeExecutor) Exec(ctx context.Context, namespace, pod, container string, command []string) (string, error) {
+	e.pods = append(e.pods, pod)
+	e.commands = append(e.commands, command)
+	if !e.passing {
+		return "", errors.New("command terminated with non-zero exit code")
+	}
+	return "", nil
+}
+
+// probedPod returns pod with readiness probe in its meta
+func probedPod(probe map[string]interface{}) *Pod {
+	return &Pod{Base: Base{meta: map[string]interface{}{ReadinessProbeKey: probe}}, Pod: mocks.MakePod("ready-1")}
+}
+
+// TestGetReadinessProbe checks parsing and validation of readiness probes
+func TestGetReadinessProbe(t *testing.T) {
+	c := mocks.NewClient()
+	probe, err := GetReadinessProbe(&Pod{Pod: mocks.MakePod("ready-1")}, c)
+	if probe != nil || err != nil {
+		t.Errorf("Resource without probe should not have one, got %v, %v", probe, err)
+	}
+
+	probe, err = GetReadinessProbe(probedPod(map[string]interface{}{
+		"exec": map[string]interface{}{"selector": "app=db", "command": []interface{}{"test", "-f", "/migrated"}},
+	}), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if probe.Exec == nil || !reflect.DeepEqual(probe.Exec.Command, []string{"test", "-f", "/migrated"}) {
+		t.Errorf("Unexpected exec probe %+v", probe.Exec)
+	}
+
+	invalid := []map[string]interface{}{
+		{},
+		{"exec": map[string]interface{}{"selector": "app=db"}},
+		{"exec": map[string]interface{}{"command": []interface{}{"true"}}},
+		{"http": map[string]interface{}{"service": "db"}},
+		{"http": map[string]interface{}{"url": "http://db"}, "exec": map[string]interface{}{"pod": "db", "command": []interface{}{"true"}}},
+	}
+	for _, meta := range invalid {
+		if _, err := GetReadinessProbe(probedPod(meta), c); err == nil {
+			t.Errorf("Probe %v should be invalid", meta)
+		}
+	}
+}
+
+// TestReadinessProbeExec checks that exec probe runs the command in the first ready pod matching the selector
+func TestReadinessProbeExec(t *testing.T) {
+	var pods []runtime.Object
+	for _, name := range []string{"ready-2", "ready-1", "pending-1"} {
+		pod := mocks.MakePod(name)
+		pod.Labels = map[string]string{"app": "db"}
+		pods = append(pods, pod)
+	}
+	c := mocks.NewClient(append(pods, mocks.MakePod("ready-0"))...)
+	executor := &fakeExecutor{}
+	c.Executor = executor
+	probe := &ReadinessProbe{Exec: &ExecProbe{Selector: "app=db", Command: []string{"migrated"}}, client: c}
+
+	status, err := probe.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Phase != interfaces.ResourceNotReady || status.Reason != "ReadinessProbeFailed" {
+		t.Errorf("Failing probe should make resource not ready, got %+v", status)
+	}
+	if !reflect.DeepEqual(executor.pods, []string{"ready-1"}) {
+		t.Errorf("Expected command to run in pod ready-1, ran in %v", executor.pods)
+	}
+
+	executor.passing = true
+	if status, err = probe.Status(context.Background()); err != nil || !status.IsReady() {
+		t.Errorf("Passing probe should be ready, got %+v, %v", status, err)
+	}
+
+	c.Executor = nil
+	if _, err = probe.Status(context.Background()); err != client.ErrExecNotSupported {
+		t.Errorf("Expected exec to be not supported, got %v", err)
+	}
+}
+
+// TestReadinessProbeHTTP checks HTTP probe
+func TestReadinessProbeHTTP(t *testing.T) {
+	migrated := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"migrated": %t}`, migrated)
+	}))
+	defer server.Close()
+
+	probe := &ReadinessProbe{HTTP: &HTTPProbe{URL: server.URL, BodyRegex: `"migrated": true`}}
+	status, err := probe.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Phase != interfaces.ResourceNotReady || status.Reason != "ReadinessProbeFailed" {
+		t.Errorf("Failing probe should make resource not ready, got %+v", status)
+	}
+
+	migrated = true
+	if status, err = probe.Status(context.Background()); err != nil || !status.IsReady() {
+		t.Errorf("Passing probe should be ready, got %+v, %v", status, err)
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// dockerHubRegistry is the registry of images without registry host in their names
+const dockerHubRegistry = "registry-1.docker.io"
+
+// manifestMediaTypes are accepted by HEAD requests for manifests, so that registries find manifests
+// of all formats
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v1+prettyjws",
+}
+
+// imageReference is a parsed name of container image
+type imageReference struct {
+	Registry   string
+	Repository string
+	// Reference is a tag or a digest
+	Reference string
+}
+
+// parseImage splits image name into registry, repository and tag or digest. Images without registry
+// are on Docker Hub, and images without tag are tagged latest
+func parseImage(image string) (imageReference, error) {
+	ref := imageReference{Registry: dockerHubRegistry}
+	name := image
+	if i := strings.Index(name, "/"); i > 0 {
+		host := name[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry = host
+			name = name[i+1:]
+		}
+	}
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Reference = name[i+1:]
+		name = name[:i]
+	} else if i := strings.LastIndex(name, ":"); i >= 0 {
+		ref.Reference = name[i+1:]
+		name = name[:i]
+	} else {
+		ref.Reference = "latest"
+	}
+	if name == "" || ref.Reference == "" {
+		return ref, fmt.Errorf("invalid image name %q", image)
+	}
+	if ref.Registry == dockerHubRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	ref.Repository = name
+	return ref, nil
+}
+
+// registryCredentials are username and password for a registry
+type registryCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
+
+// normalizeRegistry returns registry host of a key of docker config, e.g. https://index.docker.io/v1/
+func normalizeRegistry(key string) string {
+	if u, err := url.Parse(key); err == nil && u.Host != "" {
+		key = u.Host
+	}
+	key = strings.SplitN(key, "/", 2)[0]
+	if key == "index.docker.io" || key == "docker.io" {
+		return dockerHubRegistry
+	}
+	return key
+}
+
+// pullSecretCredentials returns credentials of registries from image pull secret of dockercfg or
+// dockerconfigjson type
+func pullSecretCredentials(secret *v1.Secret) (map[string]registryCredentials, error) {
+	var entries map[string]registryCredentials
+	data := secretData(secret)
+	if config, ok := data[v1.DockerConfigJsonKey]; ok {
+		var parsed struct {
+			Auths map[string]registryCredentials `json:"auths"`
+		}
+		if err := json.Unmarshal(config, &parsed); err != nil {
+			return nil, fmt.Errorf("invalid image pull secret %s: %v", secret.Name, err)
+		}
+		entries = parsed.Auths
+	} else if config, ok := data[v1.DockerConfigKey]; ok {
+		if err := json.Unmarshal(config, &entries); err != nil {
+			return nil, fmt.Errorf("invalid image pull secret %s: %v", secret.Name, err)
+		}
+	}
+
+	result := map[string]registryCredentials{}
+	for key, creds := range entries {
+		if creds.Username == "" && creds.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(creds.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid auth of %s in image pull secret %s: %v", key, secret.Name, err)
+			}
+			parts := strings.SplitN(string(decoded), ":", 2)
+			if len(parts) == 2 {
+				creds.Username, creds.Password = parts[0], parts[1]
+			}
+		}
+		result[normalizeRegistry(key)] = creds
+	}
+	return result, nil
+}
+
+// errImageAccessDenied is returned when registry does not allow pulling the image, which for most
+// registries also means that the image does not exist
+var errImageAccessDenied = fmt.Errorf("access denied")
+
+// manifestExists checks if manifest of the image exists in the registry with HEAD request, obtaining
+// bearer token if the registry requires it
+func manifestExists(ctx context.Context, httpClient *http.Client, ref imageReference, creds *registryCredentials) (bool, error) {
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.Registry, ref.Repository, ref.Reference)
+	send := func(authorization string) (*http.Response, error) {
+		req, err := http.NewRequest("HEAD", manifestURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := ctxhttp.Do(ctx, httpClient, req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		return resp, nil
+	}
+
+	resp, err := send("")
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		authorization, err := registryAuthorization(ctx, httpClient, resp.Header.Get("WWW-Authenticate"), ref, creds)
+		if err != nil {
+			return false, err
+		}
+		if resp, err = send(authorization); err != nil {
+			return false, err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return false, errImageAccessDenied
+	}
+	return false, fmt.Errorf("registry %s returned %d", ref.Registry, resp.StatusCode)
+}
+
+// registryAuthorization returns value of Authorization header for the challenge of the registry. Bearer
+// tokens are requested from the realm of the challenge, with credentials if there are any
+func registryAuthorization(ctx context.Context, httpClient *http.Client, challenge string, ref imageReference, creds *registryCredentials) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if creds == nil {
+			return "", errImageAccessDenied
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(creds.Username+":"+creds.Password)), nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("registry %s requires unsupported authentication %q", ref.Registry, scheme)
+	}
+
+	tokenURL, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("registry %s returned invalid authentication realm %q", ref.Registry, params["realm"])
+	}
+	query := tokenURL.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", fmt.Sprintf("repository:%s:pull", ref.Repository))
+	tokenURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequest("GET", tokenURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if creds != nil {
+		req.SetBasicAuth(creds.Username, creds.Password)
+	}
+	resp, err := ctxhttp.Do(ctx, httpClient, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return "", errImageAccessDenied
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token service of registry %s returned %d", ref.Registry, resp.StatusCode)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("invalid token of registry %s: %v", ref.Registry, err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// parseChallenge returns scheme and parameters of WWW-Authenticate header, e.g.
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+	parts := strings.SplitN(strings.TrimSpace(challenge), " ", 2)
+	if len(parts) < 2 {
+		return parts[0], params
+	}
+	rest := parts[1]
+	for rest != "" {
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.TrimSpace(rest[:eq])
+		rest = rest[eq+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else if comma := strings.Index(rest, ","); comma >= 0 {
+			value, rest = rest[:comma], rest[comma:]
+		} else {
+			value, rest = rest, ""
+		}
+		params[strings.ToLower(key)] = value
+		rest = strings.TrimLeft(rest, ", ")
+	}
+	return parts[0], params
+}
@@ -16,13 +16,14 @@ package resources
 
 import (
 	"fmt"
-	"log"
 
+	"golang.org/x/net/context"
 	"k8s.io/client-go/kubernetes/typed/extensions/v1beta1"
 	extbeta1 "k8s.io/client-go/pkg/apis/extensions/v1beta1"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
 	"github.com/Mirantis/k8s-AppController/pkg/report"
 )
 
@@ -32,59 +33,81 @@ type ReplicaSet struct {
 	Base
 	ReplicaSet *extbeta1.ReplicaSet
 	Client     v1beta1.ReplicaSetInterface
+	APIClient  client.Interface
 }
 
-func replicaSetStatus(r v1beta1.ReplicaSetInterface, name string, meta map[string]string) (string, error) {
+func replicaSetStatus(r v1beta1.ReplicaSetInterface, name string, meta map[string]string) (interfaces.ResourceStatus, error) {
 	rs, err := r.Get(name)
 	if err != nil {
-		return "error", err
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	return replicaSetReadiness(rs, meta)
+}
+
+// replicaSetDesiredReplicas returns the number of replicas requested by the spec, which defaults to 1
+func replicaSetDesiredReplicas(rs *extbeta1.ReplicaSet) int32 {
+	if rs.Spec.Replicas == nil {
+		return 1
+	}
+	return *rs.Spec.Replicas
+}
+
+// replicaSetReadyReplicas returns the number of replicas which count towards readiness. If the replica
+// set requires pods to be ready for some time, only available ones are counted
+func replicaSetReadyReplicas(rs *extbeta1.ReplicaSet) int32 {
+	if rs.Spec.MinReadySeconds > 0 {
+		return rs.Status.AvailableReplicas
+	}
+	return rs.Status.ReadyReplicas
+}
+
+func replicaSetReadiness(rs *extbeta1.ReplicaSet, meta map[string]string) (interfaces.ResourceStatus, error) {
+	// replica set controller has not processed the latest spec yet, so status fields are stale
+	if rs.Status.ObservedGeneration < rs.Generation {
+		return interfaces.NewStatus(interfaces.ResourceNotReady).WithReason(
+			"ObservedGenerationStale", "replicaset controller has not observed the latest spec yet"), nil
 	}
 
 	successFactor, err := getPercentage(SuccessFactorKey, meta)
 	if err != nil {
-		return "error", err
+		return interfaces.NewStatus(interfaces.ResourceError), err
 	}
 
-	if rs.Status.Replicas*100 < *rs.Spec.Replicas*successFactor {
-		return "not ready", nil
+	desired := replicaSetDesiredReplicas(rs)
+	ready := replicaSetReadyReplicas(rs)
+	if ready*100 < desired*successFactor {
+		return interfaces.NewStatus(interfaces.ResourceNotReady).
+			WithReason("ReplicasNotReady", fmt.Sprintf("%d of %d replicas ready, %d created", ready, desired, rs.Status.Replicas)).
+			WithProgress(int(ready), int(desired)), nil
 	}
 
-	return "ready", nil
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
 }
 
 func replicaSetReport(r v1beta1.ReplicaSetInterface, name string, meta map[string]string) interfaces.DependencyReport {
 	rs, err := r.Get(name)
 	if err != nil {
-		return report.ErrorReport(name, err)
+		return report.ErrorReport(replicaSetKey(name), err)
+	}
+	status, err := replicaSetReadiness(rs, meta)
+	if err != nil {
+		return report.ErrorReport(replicaSetKey(name), err)
 	}
 	successFactor, err := getPercentage(SuccessFactorKey, meta)
 	if err != nil {
-		return report.ErrorReport(name, err)
+		return report.ErrorReport(replicaSetKey(name), err)
 	}
-	percentage := (*rs.Spec.Replicas * 100 / rs.Status.Replicas)
-	message := fmt.Sprintf(
-		"%d of %d replicas up (%d %%, needed %d%%)",
-		rs.Status.Replicas,
-		rs.Spec.Replicas,
-		percentage,
+
+	desired := replicaSetDesiredReplicas(rs)
+	ready := replicaSetReadyReplicas(rs)
+	return percentageReport(
+		replicaSetKey(name),
+		status,
+		ready,
+		desired,
 		successFactor,
+		fmt.Sprintf("%d of %d replicas ready", ready, desired),
 	)
-	if percentage >= successFactor {
-		return interfaces.DependencyReport{
-			Dependency: name,
-			Blocks:     false,
-			Percentage: int(percentage),
-			Needed:     int(successFactor),
-			Message:    message,
-		}
-	}
-	return interfaces.DependencyReport{
-		Dependency: name,
-		Blocks:     false,
-		Percentage: int(percentage),
-		Needed:     int(successFactor),
-		Message:    message,
-	}
 }
 
 func replicaSetKey(name string) string {
@@ -95,22 +118,62 @@ func (r ReplicaSet) Key() string {
 	return replicaSetKey(r.ReplicaSet.Name)
 }
 
-func (r ReplicaSet) Create() error {
-	if err := checkExistence(r); err != nil {
-		log.Println("Creating ", r.Key())
+func (r ReplicaSet) Create(ctx context.Context) error {
+	if err := checkExistence(ctx, r); err != nil {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		logging.ForResource(r.Key()).Infof("Creating %s", r.Key())
+		if err = setLastApplied(&r.ReplicaSet.ObjectMeta, r.ReplicaSet); err != nil {
+			return err
+		}
 		r.ReplicaSet, err = r.Client.Create(r.ReplicaSet)
 		return err
 	}
 	return nil
 }
 
+// Update replaces ReplicaSet in the cluster with its definition
+func (r ReplicaSet) Update(ctx context.Context) error {
+	rs, err := r.Client.Get(r.ReplicaSet.Name)
+	if err != nil {
+		return err
+	}
+	if err = prepareUpdate(&r.ReplicaSet.ObjectMeta, rs.ObjectMeta, r.ReplicaSet); err != nil {
+		return err
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	_, err = r.Client.Update(r.ReplicaSet)
+	return err
+}
+
 // Delete deletes ReplicaSet from the cluster
-func (r ReplicaSet) Delete() error {
+func (r ReplicaSet) Delete(ctx context.Context) error {
 	return r.Client.Delete(r.ReplicaSet.Name, nil)
 }
 
-func (r ReplicaSet) Status(meta map[string]string) (string, error) {
-	return replicaSetStatus(r.Client, r.ReplicaSet.Name, meta)
+// DeleteWithPolicy deletes ReplicaSet from the cluster, policy defines what happens with its pods
+func (r ReplicaSet) DeleteWithPolicy(policy DeletionPolicy) error {
+	return r.Client.Delete(r.ReplicaSet.Name, policy.options())
+}
+
+func (r ReplicaSet) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	rs, err := r.Client.Get(r.ReplicaSet.Name)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	if !r.EqualToDefinition(rs) {
+		return interfaces.NewStatus(interfaces.ResourceWaitingForUpgrade), nil
+	}
+	status, err := replicaSetReadiness(rs, meta)
+	return withSpread(r.Base, r.Key(), r.APIClient, rs.Spec.Template.ObjectMeta.Labels, status, err)
+}
+
+// EqualToDefinition checks if definition in object is compatible with provided object
+func (r ReplicaSet) EqualToDefinition(replicaSet interface{}) bool {
+	return r.equalToDefinition(r.Key(), r.ReplicaSet, replicaSet)
 }
 
 // NameMatches gets resource definition and a name and checks if
@@ -121,7 +184,7 @@ func (r ReplicaSet) NameMatches(def client.ResourceDefinition, name string) bool
 
 // New returns new ReplicaSet based on resource definition
 func (r ReplicaSet) New(def client.ResourceDefinition, c client.Interface) interfaces.Resource {
-	return NewReplicaSet(def.ReplicaSet, c.ReplicaSets(), def.Meta)
+	return NewReplicaSet(def.ReplicaSet, c.ReplicaSets(), c, def.Meta)
 }
 
 // NewExisting returns new ExistingReplicaSet based on resource definition
@@ -130,8 +193,8 @@ func (r ReplicaSet) NewExisting(name string, c client.Interface) interfaces.Reso
 }
 
 // GetDependencyReport returns a DependencyReport for this replicaset
-func (r ReplicaSet) GetDependencyReport(meta map[string]string) interfaces.DependencyReport {
-	return replicaSetReport(r.Client, r.ReplicaSet.Name, meta)
+func (r ReplicaSet) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return spreadReport(ctx, r, replicaSetReport(r.Client, r.ReplicaSet.Name, meta), meta)
 }
 
 // StatusIsCacheable returns false if meta contains SuccessFactorKey
@@ -140,8 +203,8 @@ func (r ReplicaSet) StatusIsCacheable(meta map[string]string) bool {
 	return !ok
 }
 
-func NewReplicaSet(replicaSet *extbeta1.ReplicaSet, client v1beta1.ReplicaSetInterface, meta map[string]interface{}) ReplicaSet {
-	return ReplicaSet{Base: Base{meta}, ReplicaSet: replicaSet, Client: client}
+func NewReplicaSet(replicaSet *extbeta1.ReplicaSet, client v1beta1.ReplicaSetInterface, apiClient client.Interface, meta map[string]interface{}) ReplicaSet {
+	return ReplicaSet{Base: Base{meta}, ReplicaSet: replicaSet, Client: client, APIClient: apiClient}
 }
 
 type ExistingReplicaSet struct {
@@ -154,25 +217,30 @@ func (r ExistingReplicaSet) Key() string {
 	return replicaSetKey(r.Name)
 }
 
-func (r ExistingReplicaSet) Create() error {
-	return createExistingResource(r)
+func (r ExistingReplicaSet) Create(ctx context.Context) error {
+	return createExistingResource(ctx, r)
 }
 
-func (r ExistingReplicaSet) Status(meta map[string]string) (string, error) {
+func (r ExistingReplicaSet) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
 	return replicaSetStatus(r.Client, r.Name, meta)
 }
 
 // Delete deletes ReplicaSet from the cluster
-func (r ExistingReplicaSet) Delete() error {
+func (r ExistingReplicaSet) Delete(ctx context.Context) error {
 	return r.Client.Delete(r.Name, nil)
 }
 
-func NewExistingReplicaSet(name string, client v1beta1.ReplicaSetInterface) ExistingReplicaSet {
+// DeleteWithPolicy deletes ReplicaSet from the cluster, policy defines what happens with its pods
+func (r ExistingReplicaSet) DeleteWithPolicy(policy DeletionPolicy) error {
+	return r.Client.Delete(r.Name, policy.options())
+}
+
+func NewExistingReplicaSet(name string, client v1beta1.ReplicaSetInterface) interfaces.Resource {
 	return ExistingReplicaSet{Name: name, Client: client}
 }
 
 // GetDependencyReport returns a DependencyReport for this replicaset
-func (r ExistingReplicaSet) GetDependencyReport(meta map[string]string) interfaces.DependencyReport {
+func (r ExistingReplicaSet) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
 	return replicaSetReport(r.Client, r.Name, meta)
 }
 
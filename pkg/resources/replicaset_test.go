@@ -17,6 +17,8 @@ package resources
 import (
 	"testing"
 
+	extbeta1 "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
 	"github.com/Mirantis/k8s-AppController/pkg/mocks"
 )
 
@@ -28,7 +30,7 @@ func TestSuccessCheck(t *testing.T) {
 		t.Error(err)
 	}
 
-	if status != "ready" {
+	if status.Phase != "ready" {
 		t.Errorf("Status should be `ready`, is `%s` instead.", status)
 	}
 }
@@ -41,7 +43,77 @@ func TestFailCheck(t *testing.T) {
 		t.Error(err)
 	}
 
-	if status != "not ready" {
+	if status.Phase != "not ready" {
 		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
 	}
 }
+
+// TestExistingReplicaSetReport checks that report of existing replicaset blocks until enough replicas are up
+func TestExistingReplicaSetReport(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeReplicaSet("fail"))
+	rs := NewExistingReplicaSet("fail", c.ReplicaSets())
+
+	depReport := rs.GetDependencyReport(nil, map[string]string{SuccessFactorKey: "80"})
+	if !depReport.Blocks {
+		t.Errorf("Report should block, got %+v", depReport)
+	}
+	if depReport.Dependency != "replicaset/fail" {
+		t.Errorf("Expected dependency replicaset/fail, got %s", depReport.Dependency)
+	}
+	if depReport.Percentage != 0 {
+		t.Errorf("Expected percentage 0, got %d", depReport.Percentage)
+	}
+}
+
+// TestReplicaSetReadiness checks that only ready replicas of the latest observed spec count towards readiness
+func TestReplicaSetReadiness(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(rs *extbeta1.ReplicaSet)
+		meta   map[string]string
+		ready  bool
+		reason string
+	}{
+		{"ready", func(rs *extbeta1.ReplicaSet) {}, nil, true, ""},
+		{"created but not ready", func(rs *extbeta1.ReplicaSet) { rs.Status.ReadyReplicas = 1 }, nil, false, "ReplicasNotReady"},
+		{"enough ready for success factor", func(rs *extbeta1.ReplicaSet) { rs.Status.ReadyReplicas = 1 }, map[string]string{SuccessFactorKey: "50"}, true, ""},
+		{"stale generation", func(rs *extbeta1.ReplicaSet) { rs.Generation = 2; rs.Status.ObservedGeneration = 1 }, nil, false, "ObservedGenerationStale"},
+		{"not available for min ready seconds", func(rs *extbeta1.ReplicaSet) {
+			rs.Spec.MinReadySeconds = 10
+			rs.Status.AvailableReplicas = 0
+		}, nil, false, "ReplicasNotReady"},
+		{"default replicas", func(rs *extbeta1.ReplicaSet) { rs.Spec.Replicas = nil; rs.Status.ReadyReplicas = 1 }, nil, true, ""},
+		{"no replicas", func(rs *extbeta1.ReplicaSet) {
+			rs.Spec.Replicas = mocks.Pointer(int32(0))
+			rs.Status = extbeta1.ReplicaSetStatus{}
+		}, nil, true, ""},
+	}
+	for _, tc := range cases {
+		rs := mocks.MakeReplicaSet("web")
+		tc.modify(rs)
+		status, err := replicaSetReadiness(rs, tc.meta)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if status.IsReady() != tc.ready || status.Reason != tc.reason {
+			t.Errorf("%s: expected ready %t with reason %q, got %s", tc.name, tc.ready, tc.reason, status)
+		}
+	}
+}
+
+// TestReplicaSetReportNoReplicas checks that report of replica set without replicas does not divide by zero
+// and that percentage does not exceed 100 while replicas are scaled down
+func TestReplicaSetReportNoReplicas(t *testing.T) {
+	empty := mocks.MakeReplicaSet("empty")
+	empty.Spec.Replicas = mocks.Pointer(int32(0))
+	empty.Status = extbeta1.ReplicaSetStatus{}
+	c := mocks.NewClient(empty, mocks.MakeReplicaSet("web"))
+
+	for _, name := range []string{"empty", "web"} {
+		depReport := replicaSetReport(c.ReplicaSets(), name, nil)
+		if depReport.Blocks || depReport.Percentage != 100 {
+			t.Errorf("Expected %s not to block at 100%%, got %+v", name, depReport)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// Scale is a node which sets number of replicas of an existing Deployment, ReplicaSet or StatefulSet
+// and waits until they are ready, e.g. to scale down the old tier before migrating its data. Nothing
+// is created for it in the cluster, and replicas are not restored when the graph is destroyed
+type Scale struct {
+	Base
+	Scale     *client.Scale
+	APIClient client.Interface
+}
+
+func scaleKey(name string) string {
+	return "scale/" + name
+}
+
+// Key returns Scale key
+func (s Scale) Key() string {
+	return scaleKey(s.Scale.Name)
+}
+
+// scaledObject reads and updates replicas of one kind of objects and checks their readiness
+type scaledObject struct {
+	// replicas returns desired and current number of replicas
+	replicas func() (int32, int32, error)
+	scale    func(replicas int32) error
+	status   func(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error)
+}
+
+// target returns object scaled by the node
+func (s Scale) target() (scaledObject, error) {
+	parts := strings.Split(s.Scale.Target, "/")
+	if len(parts) != 2 || parts[1] == "" {
+		return scaledObject{}, fmt.Errorf("%s has invalid target %q, expected kind/name", s.Key(), s.Scale.Target)
+	}
+	name := parts[1]
+	c := s.APIClient
+	switch parts[0] {
+	case "deployment":
+		return scaledObject{
+			replicas: func() (int32, int32, error) {
+				d, err := c.Deployments().Get(name)
+				if err != nil {
+					return 0, 0, err
+				}
+				return *d.Spec.Replicas, d.Status.Replicas, nil
+			},
+			scale: func(replicas int32) error {
+				d, err := c.Deployments().Get(name)
+				if err != nil {
+					return err
+				}
+				d.Spec.Replicas = &replicas
+				_, err = c.Deployments().Update(d)
+				return err
+			},
+			status: func(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+				return deploymentStatus(c.Deployments(), name, meta)
+			},
+		}, nil
+	case "replicaset":
+		return scaledObject{
+			replicas: func() (int32, int32, error) {
+				rs, err := c.ReplicaSets().Get(name)
+				if err != nil {
+					return 0, 0, err
+				}
+				return *rs.Spec.Replicas, rs.Status.Replicas, nil
+			},
+			scale: func(replicas int32) error {
+				rs, err := c.ReplicaSets().Get(name)
+				if err != nil {
+					return err
+				}
+				rs.Spec.Replicas = &replicas
+				_, err = c.ReplicaSets().Update(rs)
+				return err
+			},
+			status: func(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+				return replicaSetStatus(c.ReplicaSets(), name, meta)
+			},
+		}, nil
+	case "statefulset":
+		return scaledObject{
+			replicas: func() (int32, int32, error) {
+				ps, err := c.StatefulSets().Get(name)
+				if err != nil {
+					return 0, 0, err
+				}
+				return *ps.Spec.Replicas, ps.Status.Replicas, nil
+			},
+			scale: func(replicas int32) error {
+				ps, err := c.StatefulSets().Get(name)
+				if err != nil {
+					return err
+				}
+				ps.Spec.Replicas = &replicas
+				_, err = c.StatefulSets().Update(ps)
+				return err
+			},
+			status: func(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+				return statefulsetStatus(ctx, c.StatefulSets(), name, c, meta)
+			},
+		}, nil
+	}
+	return scaledObject{}, fmt.Errorf("%s has target %s of kind which cannot be scaled, expected deployment, replicaset or statefulset", s.Key(), s.Scale.Target)
+}
+
+// Status returns ready when the target has the desired number of replicas, all of them ready
+// according to readiness of its kind, and no replicas above the desired number are left
+func (s Scale) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	target, err := s.target()
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	desired, current, err := target.replicas()
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	if desired != s.Scale.Replicas {
+		return interfaces.NewStatus(interfaces.ResourceNotReady).WithReason("NotScaled",
+			fmt.Sprintf("%s has %d replicas, expected %d", s.Scale.Target, desired, s.Scale.Replicas)), nil
+	}
+	if current > desired {
+		return interfaces.NewStatus(interfaces.ResourceNotReady).WithReason("ScalingDown",
+			fmt.Sprintf("%s has %d of %d replicas", s.Scale.Target, current, desired)).
+			WithProgress(int(desired), int(current)), nil
+	}
+	return target.status(ctx, meta)
+}
+
+// GetDependencyReport returns a DependencyReport for this Scale
+func (s Scale) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	status, err := s.Status(ctx, meta)
+	message := fmt.Sprintf("%s is scaled to %d replicas", s.Scale.Target, s.Scale.Replicas)
+	if status.Message != "" {
+		message = status.Message
+	}
+	return statusReport(s.Key(), status, err, message)
+}
+
+// Create sets replicas of the target, unless it already has the desired number of them
+func (s Scale) Create(ctx context.Context) error {
+	target, err := s.target()
+	if err != nil {
+		return err
+	}
+	desired, _, err := target.replicas()
+	if err != nil {
+		return err
+	}
+	if desired == s.Scale.Replicas {
+		return nil
+	}
+	logging.ForResource(s.Key()).Infof("Scaling %s from %d to %d replicas", s.Scale.Target, desired, s.Scale.Replicas)
+	return target.scale(s.Scale.Replicas)
+}
+
+// Delete does nothing, as scaling is not undone
+func (s Scale) Delete(ctx context.Context) error {
+	return nil
+}
+
+// NameMatches gets resource definition and a name and checks if
+// the Scale part of resource definition has matching name.
+func (s Scale) NameMatches(def client.ResourceDefinition, name string) bool {
+	return def.Scale != nil && def.Scale.Name == name
+}
+
+// New returns new Scale based on resource definition
+func (s Scale) New(def client.ResourceDefinition, ci client.Interface) interfaces.Resource {
+	return NewScale(def.Scale, ci, def.Meta)
+}
+
+// NewExisting returns Scale without target, as scale nodes can not exist without definition. Its
+// status is always an error
+func (s Scale) NewExisting(name string, ci client.Interface) interfaces.Resource {
+	return NewScale(&client.Scale{Name: name}, ci, nil)
+}
+
+// NewScale is a constructor for Scale
+func NewScale(scale *client.Scale, c client.Interface, meta map[string]interface{}) interfaces.Resource {
+	return Scale{Base: Base{meta}, Scale: scale, APIClient: c}
+}
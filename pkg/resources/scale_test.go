@@ -0,0 +1,108 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestScaleUp checks that scale node sets replicas of the target and waits for new replicas
+func TestScaleUp(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeDeployment("web"))
+	scale := NewScale(&client.Scale{Name: "web-up", Target: "deployment/web", Replicas: 5}, c, nil)
+
+	status, err := scale.Status(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Phase != interfaces.ResourceNotReady || status.Reason != "NotScaled" {
+		t.Errorf("scale should not be ready before replicas are set, got %v", status)
+	}
+
+	if err = scale.Create(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	deployment, err := c.Deployments().Get("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *deployment.Spec.Replicas != 5 {
+		t.Errorf("expected 5 replicas, got %d", *deployment.Spec.Replicas)
+	}
+
+	status, err = scale.Status(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Phase != interfaces.ResourceNotReady {
+		t.Errorf("scale should not be ready until new replicas are ready, got %v", status)
+	}
+}
+
+// TestScaleAlreadyScaled checks that scale node is ready when the target already has desired ready replicas
+func TestScaleAlreadyScaled(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeDeployment("web"))
+	scale := NewScale(&client.Scale{Name: "web", Target: "deployment/web", Replicas: 3}, c, nil)
+
+	if err := scale.Create(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	status, err := scale.Status(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Phase != interfaces.ResourceReady {
+		t.Errorf("scale should be ready, got %v", status)
+	}
+}
+
+// TestScaleDown checks that scale node waits until replicas above desired number are terminated
+func TestScaleDown(t *testing.T) {
+	deployment := mocks.MakeDeployment("web")
+	deployment.Status.Replicas = 3
+	c := mocks.NewClient(deployment)
+	scale := NewScale(&client.Scale{Name: "web-down", Target: "deployment/web", Replicas: 1}, c, nil)
+
+	if err := scale.Create(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	status, err := scale.Status(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Phase != interfaces.ResourceNotReady || status.Reason != "ScalingDown" {
+		t.Errorf("scale should wait for replicas to terminate, got %v", status)
+	}
+}
+
+// TestScaleInvalidTarget checks that targets of kinds which can not be scaled result in error
+func TestScaleInvalidTarget(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePod("web"))
+	for _, target := range []string{"pod/web", "web", "deployment/"} {
+		scale := NewScale(&client.Scale{Name: "web", Target: target, Replicas: 1}, c, nil)
+		if err := scale.Create(context.Background()); err == nil {
+			t.Errorf("scaling of %q should result in error", target)
+		}
+		if status, _ := scale.Status(context.Background(), nil); status.Phase != interfaces.ResourceError {
+			t.Errorf("status of scale of %q should be error, got %v", target, status)
+		}
+	}
+}
@@ -15,20 +15,24 @@
 package resources
 
 import (
-	"log"
+	"fmt"
+	"strings"
 
+	"golang.org/x/net/context"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/pkg/api/v1"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
-	"github.com/Mirantis/k8s-AppController/pkg/report"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
 )
 
 type Secret struct {
 	Base
 	Secret *v1.Secret
 	Client corev1.SecretInterface
+	// source provides data of the Secret if its definition refers to external source
+	source *resolvedSecret
 }
 
 type ExistingSecret struct {
@@ -49,29 +53,159 @@ func (s ExistingSecret) Key() string {
 	return secretKey(s.Name)
 }
 
-func secretStatus(s corev1.SecretInterface, name string) (string, error) {
+func secretStatus(s corev1.SecretInterface, name string) (interfaces.ResourceStatus, error) {
 	_, err := s.Get(name)
 	if err != nil {
-		return "error", err
+		return interfaces.NewStatus(interfaces.ResourceError), err
 	}
 
-	return "ready", nil
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
 }
 
-func (s Secret) Status(meta map[string]string) (string, error) {
-	return secretStatus(s.Client, s.Secret.Name)
+// secretData returns data of the secret the way API server stores it, with StringData merged into Data
+func secretData(secret *v1.Secret) map[string][]byte {
+	data := map[string][]byte{}
+	for key, value := range secret.Data {
+		data[key] = value
+	}
+	for key, value := range secret.StringData {
+		data[key] = []byte(value)
+	}
+	return data
+}
+
+// secretDigest returns checksum of secret data, so that secrets can be compared without exposing their values
+func secretDigest(secret *v1.Secret) (string, error) {
+	return checksum(secretData(secret))
+}
+
+// withoutData returns a copy of the secret without its data. It is used whenever the secret is
+// serialized for comparison or stored in an annotation, so that values never leave the Secret object
+func withoutData(secret *v1.Secret) *v1.Secret {
+	result := *secret
+	result.Data = nil
+	result.StringData = nil
+	return &result
+}
+
+func secretReport(s corev1.SecretInterface, name string) interfaces.DependencyReport {
+	status, err := secretStatus(s, name)
+	return statusReport(secretKey(name), status, err, "secret exists")
+}
+
+// resolved returns the Secret with data read from its external source, if it has one
+func (s Secret) resolved(ctx context.Context) (Secret, error) {
+	if s.source == nil {
+		return s, nil
+	}
+	data, err := s.source.resolve(ctx)
+	if err != nil {
+		return s, err
+	}
+	s.Secret = withSourceData(s.Secret, data)
+	return s, nil
+}
+
+// Status returns Secret status. Secret is ready when it exists and its data matches the definition
+func (s Secret) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	s, err := s.resolved(ctx)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	secret, err := s.Client.Get(s.Secret.Name)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	if !s.EqualToDefinition(secret) {
+		return interfaces.NewStatus(interfaces.ResourceWaitingForUpgrade), nil
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+// EqualToDefinition checks if definition in object is compatible with provided object.
+// Secret data is compared by digest, the rest of the object is compared like for other resources
+func (s Secret) EqualToDefinition(secret interface{}) bool {
+	live, ok := secret.(*v1.Secret)
+	if !ok {
+		return s.equalToDefinition(s.Key(), withoutData(s.Secret), secret)
+	}
+	definitionDigest, err := secretDigest(s.Secret)
+	if err != nil {
+		logging.ForResource(s.Key()).Warningf("Could not compare %s with its definition: %v", s.Key(), err)
+		return true
+	}
+	liveDigest, err := secretDigest(live)
+	if err != nil {
+		logging.ForResource(s.Key()).Warningf("Could not compare %s with its definition: %v", s.Key(), err)
+		return true
+	}
+	if definitionDigest != liveDigest {
+		return false
+	}
+	return s.equalToDefinition(s.Key(), withoutData(s.Secret), withoutData(live))
+}
+
+// GetDependencyReport returns a DependencyReport for this Secret. It never contains secret data
+func (s Secret) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	status, err := s.Status(ctx, meta)
+	return statusReport(s.Key(), status, err, fmt.Sprintf("secret is %s", status))
 }
 
-func (s Secret) Create() error {
-	if err := checkExistence(s); err != nil {
-		log.Println("Creating ", s.Key())
+func (s Secret) Create(ctx context.Context) error {
+	s, err := s.resolved(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkExistence(ctx, s); err != nil {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if s.source != nil {
+			logging.ForResource(s.Key()).Infof("Creating %s with keys %s from %s", s.Key(), strings.Join(sortedDataKeys(s.Secret.Data), ", "), s.source.source)
+		} else {
+			logging.ForResource(s.Key()).Infof("Creating %s", s.Key())
+		}
+		if err = setLastApplied(&s.Secret.ObjectMeta, withoutData(s.Secret)); err != nil {
+			return err
+		}
 		s.Secret, err = s.Client.Create(s.Secret)
 		return err
 	}
 	return nil
 }
 
-func (s Secret) Delete() error {
+// Checksum returns the checksum of UID and resource version of the Secret in the cluster. Digest of secret
+// data is never used, as checksum is stored in pod template annotations of dependents, readable by anyone
+// who can read them, and low-entropy values could be confirmed offline
+func (s Secret) Checksum() (string, error) {
+	live, err := s.Client.Get(s.Secret.Name)
+	if err != nil {
+		return "", err
+	}
+	return checksum([]string{string(live.UID), live.ResourceVersion})
+}
+
+// Update replaces Secret in the cluster with its definition
+func (s Secret) Update(ctx context.Context) error {
+	s, err := s.resolved(ctx)
+	if err != nil {
+		return err
+	}
+	secret, err := s.Client.Get(s.Secret.Name)
+	if err != nil {
+		return err
+	}
+	if err = prepareUpdate(&s.Secret.ObjectMeta, secret.ObjectMeta, withoutData(s.Secret)); err != nil {
+		return err
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	_, err = s.Client.Update(s.Secret)
+	return err
+}
+
+func (s Secret) Delete(ctx context.Context) error {
 	return s.Client.Delete(s.Secret.Name, nil)
 }
 
@@ -79,12 +213,18 @@ func (s Secret) NameMatches(def client.ResourceDefinition, name string) bool {
 	return def.Secret != nil && def.Secret.Name == name
 }
 
+// NewSecret is a constructor for Secret. Invalid secret source in meta makes the Secret fail when it is
+// created or checked
 func NewSecret(s *v1.Secret, client corev1.SecretInterface, meta map[string]interface{}) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: Secret{Base: Base{meta}, Secret: s, Client: client}}
+	secret := Secret{Base: Base{meta}, Secret: s, Client: client}
+	if source, err := NewSecretSource(secret.Meta(SecretSourceKey), client); source != nil || err != nil {
+		secret.source = &resolvedSecret{source: source, err: err}
+	}
+	return secret
 }
 
 func NewExistingSecret(name string, client corev1.SecretInterface) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: ExistingSecret{Name: name, Client: client}}
+	return ExistingSecret{Name: name, Client: client}
 }
 
 func (s Secret) New(def client.ResourceDefinition, ci client.Interface) interfaces.Resource {
@@ -95,14 +235,19 @@ func (s Secret) NewExisting(name string, ci client.Interface) interfaces.Resourc
 	return NewExistingSecret(name, ci.Secrets())
 }
 
-func (s ExistingSecret) Status(meta map[string]string) (string, error) {
+func (s ExistingSecret) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
 	return secretStatus(s.Client, s.Name)
 }
 
-func (s ExistingSecret) Create() error {
-	return createExistingResource(s)
+// GetDependencyReport returns a DependencyReport for this Secret
+func (s ExistingSecret) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return secretReport(s.Client, s.Name)
+}
+
+func (s ExistingSecret) Create(ctx context.Context) error {
+	return createExistingResource(ctx, s)
 }
 
-func (s ExistingSecret) Delete() error {
+func (s ExistingSecret) Delete(ctx context.Context) error {
 	return s.Client.Delete(s.Name, nil)
 }
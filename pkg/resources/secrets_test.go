@@ -15,8 +15,12 @@
 package resources
 
 import (
+	"strings"
 	"testing"
 
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
 	"github.com/Mirantis/k8s-AppController/pkg/mocks"
 )
 
@@ -29,7 +33,7 @@ func TestSecretSuccessCheck(t *testing.T) {
 		t.Error(err)
 	}
 
-	if status != "ready" {
+	if status.Phase != "ready" {
 		t.Errorf("Status should be `ready`, is `%s` instead.", status)
 	}
 }
@@ -43,7 +47,87 @@ func TestSecretFailCheck(t *testing.T) {
 		t.Error("Error not found, expected error")
 	}
 
-	if status != "error" {
+	if status.Phase != "error" {
 		t.Errorf("Status should be `error`, is `%s` instead.", status)
 	}
 }
+
+// TestSecretUpgradeCheck checks that Secret with changed data is waiting for upgrade
+func TestSecretUpgradeCheck(t *testing.T) {
+	live := mocks.MakeSecret("secret")
+	live.Data = map[string][]byte{"password": []byte("old")}
+	c := mocks.NewClient(live)
+
+	definition := mocks.MakeSecret("secret")
+	definition.StringData = map[string]string{"password": "new"}
+	secret := NewSecret(definition, c.Secrets(), nil)
+
+	status, err := secret.Status(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != interfaces.ResourceWaitingForUpgrade {
+		t.Errorf("Status should be `%s`, is `%s` instead.", interfaces.ResourceWaitingForUpgrade, status)
+	}
+
+	definition.StringData = map[string]string{"password": "old"}
+	status, err = secret.Status(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != interfaces.ResourceReady {
+		t.Errorf("Status should be `ready`, is `%s` instead.", status)
+	}
+}
+
+// TestSecretUpdateDoesNotStoreData checks that last applied annotation and report do not contain secret values
+func TestSecretUpdateDoesNotStoreData(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeSecret("secret"))
+	definition := mocks.MakeSecret("secret")
+	definition.StringData = map[string]string{"password": "topsecret"}
+	secret := NewSecret(definition, c.Secrets(), nil)
+
+	if err := secret.Update(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	live, err := c.Secrets().Get("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(live.Annotations[LastAppliedAnnotation], "topsecret") {
+		t.Error("Last applied annotation contains secret data")
+	}
+
+	depReport := NewSecret(mocks.MakeSecret("secret"), c.Secrets(), nil).GetDependencyReport(context.Background(), nil)
+	if strings.Contains(depReport.Message, "topsecret") {
+		t.Errorf("Dependency report contains secret data: %s", depReport.Message)
+	}
+}
+
+// TestSecretChecksumDoesNotDigestData checks that checksum of Secret changes with its version, but is not
+// computed from its data
+func TestSecretChecksumDoesNotDigestData(t *testing.T) {
+	live := mocks.MakeSecret("secret")
+	live.UID = "uid"
+	live.ResourceVersion = "1"
+	c := mocks.NewClient(live)
+	definition := mocks.MakeSecret("secret")
+	definition.StringData = map[string]string{"password": "topsecret"}
+	secret := NewSecret(definition, c.Secrets(), nil)
+
+	sum, err := secret.Checksum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dataSum, _ := secretDigest(definition); sum == dataSum {
+		t.Error("Checksum must not be a digest of secret data")
+	}
+
+	live.ResourceVersion = "2"
+	if _, err = c.Secrets().Update(live); err != nil {
+		t.Fatal(err)
+	}
+	if updated, err := secret.Checksum(); err != nil || updated == sum {
+		t.Errorf("Expected checksum to change with resource version, got %s, %v", updated, err)
+	}
+}
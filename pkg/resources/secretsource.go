@@ -0,0 +1,254 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// SecretSourceKey is a meta key of Secret definitions whose data is taken from an external source at
+// deploy time, so that it does not have to be stored in the definition. Its value is a map with exactly
+// one of the keys: vault (path of Vault secret), sealed-secret (name of SealedSecret) or env-file (path of
+// a file with KEY=VALUE lines, e.g. in a volume mounted to AppController pod)
+const SecretSourceKey = "secret-source"
+
+// Environment variables with address of Vault server and token used to read secrets from it
+const (
+	VaultAddrEnv  = "VAULT_ADDR"
+	VaultTokenEnv = "VAULT_TOKEN"
+)
+
+const vaultTimeout = 10 * time.Second
+
+// SecretSource provides data of a Secret
+type SecretSource interface {
+	// Data returns data of the Secret
+	Data(ctx context.Context) (map[string][]byte, error)
+	// String returns description of the source without secret values
+	String() string
+}
+
+// VaultSource reads data of generic (KV) secret from Vault. Both versions of KV engine are supported
+type VaultSource struct {
+	Address string
+	Token   string
+	Path    string
+}
+
+// Data returns values of Vault secret, non-string values are JSON-encoded
+func (s VaultSource) Data(ctx context.Context) (map[string][]byte, error) {
+	if s.Address == "" {
+		return nil, fmt.Errorf("address of Vault is not set, set %s environment variable", VaultAddrEnv)
+	}
+	req, err := http.NewRequest("GET", strings.TrimSuffix(s.Address, "/")+"/v1/"+strings.TrimPrefix(s.Path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", s.Token)
+	resp, err := ctxhttp.Do(ctx, &http.Client{Timeout: vaultTimeout}, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading %s returned %d", s, resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err = json.Unmarshal(body, &secret); err != nil {
+		return nil, fmt.Errorf("invalid response of %s: %v", s, err)
+	}
+	values := secret.Data
+	// KV version 2 nests values together with metadata
+	if nested, ok := values["data"].(map[string]interface{}); ok {
+		if _, ok = values["metadata"]; ok {
+			values = nested
+		}
+	}
+
+	data := map[string][]byte{}
+	for key, value := range values {
+		if str, ok := value.(string); ok {
+			data[key] = []byte(str)
+			continue
+		}
+		if data[key], err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func (s VaultSource) String() string {
+	return "Vault secret " + s.Path
+}
+
+// SealedSecretSource reads data of the Secret unsealed by SealedSecrets controller. The controller
+// creates Secret with the same name as the SealedSecret, so encrypted data can be kept in version
+// control and the graph refers to it by name
+type SealedSecretSource struct {
+	Name   string
+	Client corev1.SecretInterface
+}
+
+// Data returns data of the unsealed Secret
+func (s SealedSecretSource) Data(ctx context.Context) (map[string][]byte, error) {
+	secret, err := s.Client.Get(s.Name)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not unsealed yet: %v", s, err)
+	}
+	return secretData(secret), nil
+}
+
+func (s SealedSecretSource) String() string {
+	return "SealedSecret " + s.Name
+}
+
+// EnvFileSource reads data from a file of KEY=VALUE lines. Empty lines and lines starting with # are
+// skipped, values may be quoted
+type EnvFileSource struct {
+	Path string
+}
+
+// Data returns values of the file
+func (s EnvFileSource) Data(ctx context.Context) (map[string][]byte, error) {
+	content, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	data := map[string][]byte{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(line, "export "), "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("line %d of %s is not KEY=VALUE", number, s.Path)
+		}
+		value := strings.TrimSpace(parts[1])
+		if len(value) > 1 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			if value[0] == '"' {
+				if value, err = strconv.Unquote(value); err != nil {
+					return nil, fmt.Errorf("line %d of %s has invalid value: %v", number, s.Path, err)
+				}
+			} else {
+				value = value[1 : len(value)-1]
+			}
+		}
+		data[strings.TrimSpace(parts[0])] = []byte(value)
+	}
+	return data, scanner.Err()
+}
+
+func (s EnvFileSource) String() string {
+	return "env file " + s.Path
+}
+
+// NewSecretSource returns source of Secret data described by the value of SecretSourceKey meta, or nil
+// if the value is nil. Sealed secrets are read with the client
+func NewSecretSource(value interface{}, client corev1.SecretInterface) (SecretSource, error) {
+	if value == nil {
+		return nil, nil
+	}
+	spec, ok := value.(map[string]interface{})
+	if !ok || len(spec) != 1 {
+		return nil, fmt.Errorf("%s must be a map with one of the keys: vault, sealed-secret, env-file", SecretSourceKey)
+	}
+	for kind, location := range spec {
+		name, ok := location.(string)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%s of %s must be a non-empty string", kind, SecretSourceKey)
+		}
+		switch kind {
+		case "vault":
+			return VaultSource{Address: os.Getenv(VaultAddrEnv), Token: os.Getenv(VaultTokenEnv), Path: name}, nil
+		case "sealed-secret":
+			return SealedSecretSource{Name: name, Client: client}, nil
+		case "env-file":
+			return EnvFileSource{Path: name}, nil
+		}
+		return nil, fmt.Errorf("unknown %s %s, expected one of: vault, sealed-secret, env-file", SecretSourceKey, kind)
+	}
+	return nil, nil
+}
+
+// resolvedSecret caches data of the Secret read from its source, shared by copies of the resource
+type resolvedSecret struct {
+	source SecretSource
+	err    error
+	data   map[string][]byte
+	sync.Mutex
+}
+
+// resolve returns data of the source. Data is read once, failed reads are retried on the next call
+func (r *resolvedSecret) resolve(ctx context.Context) (map[string][]byte, error) {
+	r.Lock()
+	defer r.Unlock()
+	if r.err != nil || r.data != nil {
+		return r.data, r.err
+	}
+	data, err := r.source.Data(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %v", r.source, err)
+	}
+	r.data = data
+	return data, nil
+}
+
+// sortedDataKeys returns keys of secret data in order, so that they can be logged without values
+func sortedDataKeys(data map[string][]byte) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// withSourceData returns a copy of the secret with data of the source. Values of the definition are
+// kept for keys the source does not have
+func withSourceData(secret *v1.Secret, data map[string][]byte) *v1.Secret {
+	result := *secret
+	result.Data = secretData(secret)
+	result.StringData = nil
+	for key, value := range data {
+		result.Data[key] = value
+	}
+	return &result
+}
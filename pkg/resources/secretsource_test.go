@@ -0,0 +1,219 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestVaultSource checks reading of KV version 1 and 2 secrets and handling of Vault errors
+func TestVaultSource(t *testing.T) {
+	responses := map[string]string{
+		"/v1/secret/db":     `{"data": {"password": "topsecret", "port": 5432}}`,
+		"/v1/kv/data/db":    `{"data": {"data": {"password": "topsecret"}, "metadata": {"version": 3}}}`,
+		"/v1/secret/nested": `{"data": {"data": {"password": "topsecret"}}}`,
+		"/v1/secret/broken": `{"data": `,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		source   VaultSource
+		expected map[string]string
+	}{
+		{VaultSource{Address: server.URL, Token: "token", Path: "secret/db"}, map[string]string{"password": "topsecret", "port": "5432"}},
+		{VaultSource{Address: server.URL + "/", Token: "token", Path: "/kv/data/db"}, map[string]string{"password": "topsecret"}},
+		// KV version 1 secret may have a key named data, which is kept as it is
+		{VaultSource{Address: server.URL, Token: "token", Path: "secret/nested"}, map[string]string{"data": `{"password":"topsecret"}`}},
+		{VaultSource{Address: server.URL, Token: "token", Path: "secret/missing"}, nil},
+		{VaultSource{Address: server.URL, Token: "token", Path: "secret/broken"}, nil},
+		{VaultSource{Address: server.URL, Token: "wrong", Path: "secret/db"}, nil},
+		{VaultSource{Token: "token", Path: "secret/db"}, nil},
+	}
+	for _, tc := range cases {
+		data, err := tc.source.Data(context.Background())
+		if tc.expected == nil {
+			if err == nil {
+				t.Errorf("Expected reading %s from %q to fail, got %v", tc.source, tc.source.Address, data)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Reading %s failed: %v", tc.source, err)
+			continue
+		}
+		if len(data) != len(tc.expected) {
+			t.Errorf("Expected %d keys of %s, got %d", len(tc.expected), tc.source, len(data))
+		}
+		for key, value := range tc.expected {
+			if string(data[key]) != value {
+				t.Errorf("Expected %s of %s to be %q, got %q", key, tc.source, value, data[key])
+			}
+		}
+	}
+}
+
+// TestEnvFileSource checks parsing of env files
+func TestEnvFileSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		content  string
+		expected map[string]string
+	}{
+		{"USER=admin\n\n# comment\nexport PASSWORD = top=secret \n", map[string]string{"USER": "admin", "PASSWORD": "top=secret"}},
+		{`A="quoted \"value\"\n"` + "\nB='single # quoted'\nC=\"\n", map[string]string{"A": "quoted \"value\"\n", "B": "single # quoted", "C": "\""}},
+		{"EMPTY=\n", map[string]string{"EMPTY": ""}},
+		{"NOVALUE\n", nil},
+		{"=value\n", nil},
+		{`A="unterminated \"`, nil},
+	}
+	for i, tc := range cases {
+		path := filepath.Join(dir, "env")
+		if err = ioutil.WriteFile(path, []byte(tc.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		data, err := EnvFileSource{Path: path}.Data(context.Background())
+		if tc.expected == nil {
+			if err == nil {
+				t.Errorf("Case %d: expected parsing to fail, got %v", i, data)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Case %d: parsing failed: %v", i, err)
+			continue
+		}
+		if len(data) != len(tc.expected) {
+			t.Errorf("Case %d: expected %d keys, got %d", i, len(tc.expected), len(data))
+		}
+		for key, value := range tc.expected {
+			if string(data[key]) != value {
+				t.Errorf("Case %d: expected %s to be %q, got %q", i, key, value, data[key])
+			}
+		}
+	}
+
+	if _, err = (EnvFileSource{Path: filepath.Join(dir, "missing")}).Data(context.Background()); err == nil {
+		t.Error("Reading missing file should fail")
+	}
+}
+
+// TestSealedSecretSource checks that data is read from the Secret unsealed by the controller
+func TestSealedSecretSource(t *testing.T) {
+	unsealed := mocks.MakeSecret("unsealed")
+	unsealed.Data = map[string][]byte{"password": []byte("topsecret")}
+	c := mocks.NewClient(unsealed)
+
+	data, err := SealedSecretSource{Name: "unsealed", Client: c.Secrets()}.Data(context.Background())
+	if err != nil || string(data["password"]) != "topsecret" {
+		t.Errorf("Expected data of unsealed secret, got %v, %v", data, err)
+	}
+	if _, err = (SealedSecretSource{Name: "sealed", Client: c.Secrets()}).Data(context.Background()); err == nil {
+		t.Error("Reading secret which is not unsealed yet should fail")
+	}
+}
+
+// TestNewSecretSource checks parsing of secret-source meta
+func TestNewSecretSource(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		expected SecretSource
+		valid    bool
+	}{
+		{nil, nil, true},
+		{map[string]interface{}{"env-file": "/etc/secrets/env"}, EnvFileSource{Path: "/etc/secrets/env"}, true},
+		{map[string]interface{}{"sealed-secret": "db"}, SealedSecretSource{Name: "db"}, true},
+		{"vault", nil, false},
+		{map[string]interface{}{"vault": "secret/db", "env-file": "/env"}, nil, false},
+		{map[string]interface{}{"vault": ""}, nil, false},
+		{map[string]interface{}{"file": "/env"}, nil, false},
+	}
+	for _, tc := range cases {
+		source, err := NewSecretSource(tc.value, nil)
+		if (err == nil) != tc.valid {
+			t.Errorf("Expected %v to be valid: %t, got %v", tc.value, tc.valid, err)
+		}
+		if source != tc.expected {
+			t.Errorf("Expected source %v for %v, got %v", tc.expected, tc.value, source)
+		}
+	}
+
+	source, err := NewSecretSource(map[string]interface{}{"vault": "secret/db"}, nil)
+	if vault, ok := source.(VaultSource); err != nil || !ok || vault.Path != "secret/db" {
+		t.Errorf("Expected Vault source, got %v, %v", source, err)
+	}
+}
+
+// flakySource fails the first read
+type flakySource struct {
+	reads int
+}
+
+func (s *flakySource) Data(ctx context.Context) (map[string][]byte, error) {
+	s.reads++
+	if s.reads == 1 {
+		return nil, errors.New("unavailable")
+	}
+	return map[string][]byte{"password": []byte("topsecret")}, nil
+}
+
+func (s *flakySource) String() string {
+	return "flaky source"
+}
+
+// TestResolvedSecret checks that failed reads of the source are retried and data is read only once
+func TestResolvedSecret(t *testing.T) {
+	source := &flakySource{}
+	resolved := &resolvedSecret{source: source}
+
+	if _, err := resolved.resolve(context.Background()); err == nil {
+		t.Error("Expected the first read to fail")
+	}
+	for i := 0; i < 2; i++ {
+		data, err := resolved.resolve(context.Background())
+		if err != nil || string(data["password"]) != "topsecret" {
+			t.Errorf("Expected data of the source, got %v, %v", data, err)
+		}
+	}
+	if source.reads != 2 {
+		t.Errorf("Expected data to be read once after the failure, got %d reads", source.reads)
+	}
+}
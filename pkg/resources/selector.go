@@ -0,0 +1,255 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/labels"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+)
+
+// SelectorKindsKey is a dependency meta key with comma-separated list of kinds of objects
+// a label selector node should check. Only pods are checked if it is not set
+const SelectorKindsKey = "selector-kinds"
+
+// selectorListers return metadata of objects of given kind matching list options
+var selectorListers = map[string]func(client.Interface, v1.ListOptions) ([]v1.ObjectMeta, error){
+	"pod": func(c client.Interface, options v1.ListOptions) ([]v1.ObjectMeta, error) {
+		list, err := c.Pods().List(options)
+		if err != nil {
+			return nil, err
+		}
+		var objects []v1.ObjectMeta
+		for _, item := range list.Items {
+			objects = append(objects, item.ObjectMeta)
+		}
+		return objects, nil
+	},
+	"job": func(c client.Interface, options v1.ListOptions) ([]v1.ObjectMeta, error) {
+		list, err := c.Jobs().List(options)
+		if err != nil {
+			return nil, err
+		}
+		var objects []v1.ObjectMeta
+		for _, item := range list.Items {
+			objects = append(objects, item.ObjectMeta)
+		}
+		return objects, nil
+	},
+	"replicaset": func(c client.Interface, options v1.ListOptions) ([]v1.ObjectMeta, error) {
+		list, err := c.ReplicaSets().List(options)
+		if err != nil {
+			return nil, err
+		}
+		var objects []v1.ObjectMeta
+		for _, item := range list.Items {
+			objects = append(objects, item.ObjectMeta)
+		}
+		return objects, nil
+	},
+	"deployment": func(c client.Interface, options v1.ListOptions) ([]v1.ObjectMeta, error) {
+		list, err := c.Deployments().List(options)
+		if err != nil {
+			return nil, err
+		}
+		var objects []v1.ObjectMeta
+		for _, item := range list.Items {
+			objects = append(objects, item.ObjectMeta)
+		}
+		return objects, nil
+	},
+	"statefulset": func(c client.Interface, options v1.ListOptions) ([]v1.ObjectMeta, error) {
+		list, err := c.StatefulSets().List(options)
+		if err != nil {
+			return nil, err
+		}
+		var objects []v1.ObjectMeta
+		for _, item := range list.Items {
+			objects = append(objects, item.ObjectMeta)
+		}
+		return objects, nil
+	},
+	"daemonset": func(c client.Interface, options v1.ListOptions) ([]v1.ObjectMeta, error) {
+		list, err := c.DaemonSets().List(options)
+		if err != nil {
+			return nil, err
+		}
+		var objects []v1.ObjectMeta
+		for _, item := range list.Items {
+			objects = append(objects, item.ObjectMeta)
+		}
+		return objects, nil
+	},
+	"persistentvolumeclaim": func(c client.Interface, options v1.ListOptions) ([]v1.ObjectMeta, error) {
+		list, err := c.PersistentVolumeClaims().List(options)
+		if err != nil {
+			return nil, err
+		}
+		var objects []v1.ObjectMeta
+		for _, item := range list.Items {
+			objects = append(objects, item.ObjectMeta)
+		}
+		return objects, nil
+	},
+}
+
+// LabelSelector is a node which represents all objects matching label selector. It is ready when
+// at least one object matches and all matching objects are ready. Nothing is created for it
+type LabelSelector struct {
+	Base
+	Selector  string
+	APIClient client.Interface
+}
+
+func labelSelectorKey(selector string) string {
+	return "selector/" + selector
+}
+
+// Key returns LabelSelector key
+func (s LabelSelector) Key() string {
+	return labelSelectorKey(s.Selector)
+}
+
+func selectorKinds(meta map[string]string) []string {
+	value, ok := meta[SelectorKindsKey]
+	if !ok {
+		return []string{"pod"}
+	}
+	var kinds []string
+	for _, kind := range strings.Split(value, ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+// selectedResources returns existing resources of given kinds matching the selector
+func selectedResources(apiClient client.Interface, selector string, kinds []string) ([]interfaces.Resource, error) {
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return nil, err
+	}
+	options := v1.ListOptions{LabelSelector: parsed.String()}
+
+	var result []interfaces.Resource
+	for _, kind := range kinds {
+		lister, ok := selectorListers[kind]
+		if !ok {
+			return nil, fmt.Errorf("kind '%s' is not supported by label selector dependency", kind)
+		}
+		objects, err := lister(apiClient, options)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range objects {
+			result = append(result, KindToResourceTemplate[kind].NewExisting(object.Name, apiClient))
+		}
+	}
+	return result, nil
+}
+
+// labelSelectorStatus returns status of selected objects along with numbers of ready and all selected objects
+func labelSelectorStatus(ctx context.Context, apiClient client.Interface, selector string, meta map[string]string) (interfaces.ResourceStatus, int, int, error) {
+	selected, err := selectedResources(apiClient, selector, selectorKinds(meta))
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), 0, 0, err
+	}
+
+	ready := 0
+	for _, r := range selected {
+		status, err := r.Status(ctx, nil)
+		if err != nil {
+			return interfaces.NewStatus(interfaces.ResourceError), ready, len(selected), err
+		}
+		if status.Phase == interfaces.ResourceReady {
+			ready++
+		}
+	}
+	if len(selected) == 0 {
+		return interfaces.NewStatus(interfaces.ResourceNotReady).WithReason("NoObjectsSelected", "no objects match "+selector), 0, 0, nil
+	}
+	if ready < len(selected) {
+		status := interfaces.NewStatus(interfaces.ResourceNotReady).
+			WithReason("ObjectsNotReady", fmt.Sprintf("%d of %d selected objects ready", ready, len(selected))).
+			WithProgress(ready, len(selected))
+		return status, ready, len(selected), nil
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), ready, len(selected), nil
+}
+
+// Status returns ready if there are objects matching the selector and all of them are ready
+func (s LabelSelector) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	status, _, _, err := labelSelectorStatus(ctx, s.APIClient, s.Selector, meta)
+	return status, err
+}
+
+// GetDependencyReport returns a DependencyReport for this LabelSelector
+func (s LabelSelector) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	status, ready, total, err := labelSelectorStatus(ctx, s.APIClient, s.Selector, meta)
+	if err != nil {
+		return statusReport(s.Key(), status, err, "")
+	}
+	return percentageReport(
+		s.Key(),
+		status,
+		int32(ready),
+		int32(total),
+		100,
+		fmt.Sprintf("%d of %d selected objects ready (kinds: %s)", ready, total, strings.Join(selectorKinds(meta), ", ")),
+	)
+}
+
+// StatusIsCacheable returns false if meta selects kinds, as the same node can be checked with different kinds
+func (s LabelSelector) StatusIsCacheable(meta map[string]string) bool {
+	_, ok := meta[SelectorKindsKey]
+	return !ok
+}
+
+// Create does nothing, as label selector is only a dependency
+func (s LabelSelector) Create(ctx context.Context) error {
+	return nil
+}
+
+// Delete does nothing, as label selector is only a dependency
+func (s LabelSelector) Delete(ctx context.Context) error {
+	return nil
+}
+
+// NameMatches always returns false, as label selectors have no resource definitions
+func (s LabelSelector) NameMatches(def client.ResourceDefinition, name string) bool {
+	return false
+}
+
+// New is never called, as label selectors have no resource definitions
+func (s LabelSelector) New(def client.ResourceDefinition, ci client.Interface) interfaces.Resource {
+	return NewLabelSelector("", ci)
+}
+
+// NewExisting returns a LabelSelector for selector from the node key
+func (s LabelSelector) NewExisting(selector string, ci client.Interface) interfaces.Resource {
+	return NewLabelSelector(selector, ci)
+}
+
+// NewLabelSelector is a constructor for LabelSelector
+func NewLabelSelector(selector string, apiClient client.Interface) interfaces.Resource {
+	return LabelSelector{Selector: selector, APIClient: apiClient}
+}
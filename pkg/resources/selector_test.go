@@ -0,0 +1,72 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestLabelSelectorStatus checks that label selector is ready only when all matching pods are ready
+func TestLabelSelectorStatus(t *testing.T) {
+	ready := mocks.MakePod("ready-1")
+	ready.Labels = map[string]string{"app": "db"}
+	pending := mocks.MakePod("pending-1")
+	pending.Labels = map[string]string{"app": "db"}
+	other := mocks.MakePod("ready-2")
+	other.Labels = map[string]string{"app": "web"}
+	c := mocks.NewClient(ready, pending, other)
+
+	status, err := NewLabelSelector("app=web", c).Status(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != interfaces.ResourceReady {
+		t.Errorf("Status should be `ready`, is `%s` instead.", status)
+	}
+
+	depReport := NewLabelSelector("app=db", c).GetDependencyReport(context.Background(), nil)
+	if !depReport.Blocks {
+		t.Error("Dependency report should block")
+	}
+	expected := "1 of 2 selected objects ready (kinds: pod)"
+	if depReport.Message != expected {
+		t.Errorf("Expected message `%s`, got `%s`", expected, depReport.Message)
+	}
+
+	status, err = NewLabelSelector("app=none", c).Status(context.Background(), nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != interfaces.ResourceNotReady {
+		t.Errorf("Status should be `not ready` when nothing matches, is `%s` instead.", status)
+	}
+}
+
+// TestLabelSelectorKinds checks that unsupported kinds in meta result in error
+func TestLabelSelectorKinds(t *testing.T) {
+	c := mocks.NewClient()
+	status, err := NewLabelSelector("app=db", c).Status(context.Background(), map[string]string{SelectorKindsKey: "pod, configmap"})
+	if err == nil {
+		t.Error("Error not found, expected error")
+	}
+	if status.Phase != interfaces.ResourceError {
+		t.Errorf("Status should be `error`, is `%s` instead.", status)
+	}
+}
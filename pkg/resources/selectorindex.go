@@ -0,0 +1,342 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/api/meta"
+	"k8s.io/client-go/pkg/api/v1"
+	appsbeta1 "k8s.io/client-go/pkg/apis/apps/v1beta1"
+	batchv1 "k8s.io/client-go/pkg/apis/batch/v1"
+	extbeta1 "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/pkg/runtime"
+	"k8s.io/client-go/pkg/watch"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	appsalpha1 "github.com/Mirantis/k8s-AppController/pkg/client/petsets/apis/apps/v1alpha1"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// indexedKind lists and watches objects of a kind selected by services and checks their readiness
+type indexedKind struct {
+	// list returns all objects of the kind along with resource version of the list
+	list func(client.Interface) ([]runtime.Object, string, error)
+	// watch watches objects of the kind changed after given resource version
+	watch func(client.Interface, string) (watch.Interface, error)
+	// readiness returns status of the object. Objects depending on other objects look them up in the index
+	readiness func(*SelectorIndex, runtime.Object) (interfaces.ResourceStatus, error)
+}
+
+var indexedKinds = map[string]indexedKind{
+	"pod": {
+		list: func(c client.Interface) ([]runtime.Object, string, error) {
+			list, err := c.Pods().List(v1.ListOptions{})
+			if err != nil {
+				return nil, "", err
+			}
+			objects := make([]runtime.Object, 0, len(list.Items))
+			for i := range list.Items {
+				objects = append(objects, &list.Items[i])
+			}
+			return objects, list.ResourceVersion, nil
+		},
+		watch: func(c client.Interface, resourceVersion string) (watch.Interface, error) {
+			return c.Pods().Watch(v1.ListOptions{ResourceVersion: resourceVersion})
+		},
+		readiness: func(_ *SelectorIndex, object runtime.Object) (interfaces.ResourceStatus, error) {
+			return podReadiness(object.(*v1.Pod))
+		},
+	},
+	"job": {
+		list: func(c client.Interface) ([]runtime.Object, string, error) {
+			list, err := c.Jobs().List(v1.ListOptions{})
+			if err != nil {
+				return nil, "", err
+			}
+			objects := make([]runtime.Object, 0, len(list.Items))
+			for i := range list.Items {
+				objects = append(objects, &list.Items[i])
+			}
+			return objects, list.ResourceVersion, nil
+		},
+		watch: func(c client.Interface, resourceVersion string) (watch.Interface, error) {
+			return c.Jobs().Watch(v1.ListOptions{ResourceVersion: resourceVersion})
+		},
+		readiness: func(_ *SelectorIndex, object runtime.Object) (interfaces.ResourceStatus, error) {
+			return jobReadiness(object.(*batchv1.Job), nil)
+		},
+	},
+	"replicaset": {
+		list: func(c client.Interface) ([]runtime.Object, string, error) {
+			list, err := c.ReplicaSets().List(v1.ListOptions{})
+			if err != nil {
+				return nil, "", err
+			}
+			objects := make([]runtime.Object, 0, len(list.Items))
+			for i := range list.Items {
+				objects = append(objects, &list.Items[i])
+			}
+			return objects, list.ResourceVersion, nil
+		},
+		watch: func(c client.Interface, resourceVersion string) (watch.Interface, error) {
+			return c.ReplicaSets().Watch(v1.ListOptions{ResourceVersion: resourceVersion})
+		},
+		readiness: func(_ *SelectorIndex, object runtime.Object) (interfaces.ResourceStatus, error) {
+			return replicaSetReadiness(object.(*extbeta1.ReplicaSet), nil)
+		},
+	},
+	"statefulset": {
+		list: func(c client.Interface) ([]runtime.Object, string, error) {
+			list, err := c.StatefulSets().List(v1.ListOptions{})
+			if err != nil {
+				return nil, "", err
+			}
+			objects := make([]runtime.Object, 0, len(list.Items))
+			for i := range list.Items {
+				objects = append(objects, &list.Items[i])
+			}
+			return objects, list.ResourceVersion, nil
+		},
+		watch: func(c client.Interface, resourceVersion string) (watch.Interface, error) {
+			return c.StatefulSets().Watch(v1.ListOptions{ResourceVersion: resourceVersion})
+		},
+		readiness: func(index *SelectorIndex, object runtime.Object) (interfaces.ResourceStatus, error) {
+			// same as statefulsetReadiness without success factor, with pods taken from the index
+			ps := object.(*appsbeta1.StatefulSet)
+			if ps.Status.ObservedGeneration != nil && *ps.Status.ObservedGeneration < ps.Generation {
+				return statefulsetStaleStatus(), nil
+			}
+			status, err := index.readiness("pod", ps.Spec.Template.ObjectMeta.Labels)
+			if status.Phase == interfaces.ResourceReady && ps.Spec.Replicas != nil && ps.Status.Replicas < *ps.Spec.Replicas {
+				return statefulsetScalingStatus(ps), nil
+			}
+			return status, err
+		},
+	},
+	"petset": {
+		list: func(c client.Interface) ([]runtime.Object, string, error) {
+			list, err := c.PetSets().List(api.ListOptions{})
+			if err != nil {
+				return nil, "", err
+			}
+			objects := make([]runtime.Object, 0, len(list.Items))
+			for i := range list.Items {
+				objects = append(objects, &list.Items[i])
+			}
+			return objects, list.ResourceVersion, nil
+		},
+		watch: func(c client.Interface, resourceVersion string) (watch.Interface, error) {
+			return c.PetSets().Watch(api.ListOptions{ResourceVersion: resourceVersion})
+		},
+		readiness: func(index *SelectorIndex, object runtime.Object) (interfaces.ResourceStatus, error) {
+			return index.readiness("pod", object.(*appsalpha1.PetSet).Spec.Template.ObjectMeta.Labels)
+		},
+	},
+}
+
+// indexedObjects are objects of a kind by name
+type indexedObjects struct {
+	objects map[string]runtime.Object
+	// synced is false until objects are listed, and again after their watch is closed
+	synced bool
+}
+
+// SelectorIndex keeps pods, jobs, replica sets and stateful sets (pet sets on 1.4 clusters) of the
+// namespace in memory, so that selectors of services are evaluated without querying the API. Each kind
+// is listed on first use and then kept up to date by a watch, which is shared by all services of the
+// graph. If the watch is closed, the kind is listed again on next use
+type SelectorIndex struct {
+	client client.Interface
+	kinds  map[string]indexedKind
+	// watched is false for indexes which list objects once and never update them
+	watched bool
+	objects map[string]*indexedObjects
+	stop    chan struct{}
+	sync.Mutex
+}
+
+// NewSelectorIndex returns index of objects accessible by the client. It must be stopped when it is
+// not needed anymore
+func NewSelectorIndex(c client.Interface) *SelectorIndex {
+	return &SelectorIndex{
+		client:  c,
+		kinds:   indexedKinds,
+		watched: true,
+		objects: map[string]*indexedObjects{},
+		stop:    make(chan struct{}),
+	}
+}
+
+// snapshotIndex returns index which lists each kind at most once and is not updated afterwards. It is
+// used for single status checks of resources not attached to a shared index
+func snapshotIndex(c client.Interface) *SelectorIndex {
+	return &SelectorIndex{
+		client:  c,
+		kinds:   indexedKinds,
+		objects: map[string]*indexedObjects{},
+	}
+}
+
+// Stop stops all watches and drops indexed objects. Kinds are listed and watched again on next use
+func (i *SelectorIndex) Stop() {
+	i.Lock()
+	defer i.Unlock()
+	if i.stop != nil {
+		close(i.stop)
+		i.stop = make(chan struct{})
+	}
+	i.objects = map[string]*indexedObjects{}
+}
+
+// selectorKinds returns kinds of objects selected by services
+func (i *SelectorIndex) selectorKinds() []string {
+	if i.client.ResourceExists(appsbeta1.SchemeGroupVersion.WithKind("StatefulSet")) {
+		return []string{"pod", "job", "replicaset", "statefulset"}
+	}
+	if !PetSetsEnabled {
+		return []string{"pod", "job", "replicaset"}
+	}
+	return []string{"pod", "job", "replicaset", "petset"}
+}
+
+// selected returns objects of the kind having all the labels, ordered by name
+func (i *SelectorIndex) selected(kind string, selector map[string]string) ([]runtime.Object, error) {
+	i.Lock()
+	defer i.Unlock()
+	indexed, ok := i.objects[kind]
+	if !ok || !indexed.synced {
+		var err error
+		if indexed, err = i.sync(kind); err != nil {
+			return nil, err
+		}
+	}
+
+	var names []string
+	for name, object := range indexed.objects {
+		accessor, err := meta.Accessor(object)
+		if err != nil {
+			continue
+		}
+		if matchesLabels(selector, accessor.GetLabels()) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	result := make([]runtime.Object, 0, len(names))
+	for _, name := range names {
+		result = append(result, indexed.objects[name])
+	}
+	return result, nil
+}
+
+// sync lists objects of the kind and starts their watch. Must be called with the lock held
+func (i *SelectorIndex) sync(kind string) (*indexedObjects, error) {
+	objects, resourceVersion, err := i.kinds[kind].list(i.client)
+	if err != nil {
+		return nil, err
+	}
+	indexed := &indexedObjects{objects: map[string]runtime.Object{}, synced: true}
+	for _, object := range objects {
+		accessor, err := meta.Accessor(object)
+		if err != nil {
+			continue
+		}
+		indexed.objects[accessor.GetName()] = object
+	}
+	i.objects[kind] = indexed
+	if i.watched {
+		logging.Debugf("Indexed %d %s objects, watching changes", len(indexed.objects), kind)
+		go i.watchKind(kind, indexed, resourceVersion, i.stop)
+	}
+	return indexed, nil
+}
+
+// watchKind applies changes of objects of the kind to the index until the watch is closed or stop
+// channel is closed. Objects are listed again on next use after the watch ends
+func (i *SelectorIndex) watchKind(kind string, indexed *indexedObjects, resourceVersion string, stop chan struct{}) {
+	defer func() {
+		i.Lock()
+		indexed.synced = false
+		i.Unlock()
+	}()
+
+	wi, err := i.kinds[kind].watch(i.client, resourceVersion)
+	if err != nil {
+		logging.Errorf("Error watching %s objects of selector index: %v", kind, err)
+		return
+	}
+	defer wi.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case event, ok := <-wi.ResultChan():
+			if !ok || event.Type == watch.Error {
+				return
+			}
+			accessor, err := meta.Accessor(event.Object)
+			if err != nil {
+				continue
+			}
+			i.Lock()
+			if event.Type == watch.Deleted {
+				delete(indexed.objects, accessor.GetName())
+			} else {
+				indexed.objects[accessor.GetName()] = event.Object
+			}
+			i.Unlock()
+		}
+	}
+}
+
+// readiness returns ready if all objects of the kind having the labels are ready. Error names the
+// first object which is not
+func (i *SelectorIndex) readiness(kind string, selector map[string]string) (interfaces.ResourceStatus, error) {
+	objects, err := i.selected(kind, selector)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	for _, object := range objects {
+		accessor, err := meta.Accessor(object)
+		if err != nil {
+			return interfaces.NewStatus(interfaces.ResourceError), err
+		}
+		key := kind + "/" + accessor.GetName()
+		logging.ForResource(key).Debugf("Checking status for resource %s", key)
+		status, err := i.kinds[kind].readiness(i, object)
+		if err != nil {
+			return interfaces.NewStatus(interfaces.ResourceError), err
+		}
+		if status.Phase != interfaces.ResourceReady {
+			return interfaces.NewStatus(interfaces.ResourceNotReady), fmt.Errorf("Resource %s is not ready", key)
+		}
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+// matchesLabels returns true if labels have all key-value pairs of the selector
+func matchesLabels(selector, objectLabels map[string]string) bool {
+	for key, value := range selector {
+		if objectLabels[key] != value {
+			return false
+		}
+	}
+	return true
+}
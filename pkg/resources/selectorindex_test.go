@@ -0,0 +1,139 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/runtime"
+	"k8s.io/client-go/pkg/watch"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// fakeIndex returns index of pods, counter of their lists and fake watch sending their changes
+func fakeIndex(pods ...*v1.Pod) (*SelectorIndex, *int, *watch.FakeWatcher) {
+	lists := 0
+	fake := watch.NewFake()
+	index := NewSelectorIndex(mocks.NewClient())
+	index.kinds = map[string]indexedKind{
+		"pod": {
+			list: func(client.Interface) ([]runtime.Object, string, error) {
+				lists++
+				var objects []runtime.Object
+				for _, pod := range pods {
+					objects = append(objects, pod)
+				}
+				return objects, "1", nil
+			},
+			watch: func(client.Interface, string) (watch.Interface, error) {
+				return fake, nil
+			},
+			readiness: indexedKinds["pod"].readiness,
+		},
+	}
+	return index, &lists, fake
+}
+
+func labeledPod(name, app string) *v1.Pod {
+	pod := mocks.MakePod(name)
+	pod.Labels = map[string]string{"app": app}
+	return pod
+}
+
+// TestSelectorIndexListsOnce checks that repeated checks are served from memory
+func TestSelectorIndexListsOnce(t *testing.T) {
+	index, lists, _ := fakeIndex(labeledPod("ready-1", "a"), labeledPod("pending-1", "b"))
+	defer index.Stop()
+
+	for i := 0; i < 3; i++ {
+		status, err := index.readiness("pod", map[string]string{"app": "a"})
+		if status.Phase != interfaces.ResourceReady || err != nil {
+			t.Fatalf("Expected ready status, got %s, %v", status, err)
+		}
+	}
+	status, err := index.readiness("pod", map[string]string{"app": "b"})
+	if status.Phase != interfaces.ResourceNotReady || err == nil || err.Error() != "Resource pod/pending-1 is not ready" {
+		t.Errorf("Expected pending pod to be not ready, got %s, %v", status, err)
+	}
+	if *lists != 1 {
+		t.Errorf("Pods should be listed once, listed %d times", *lists)
+	}
+}
+
+// TestSelectorIndexWatch checks that watched changes are applied to the index
+func TestSelectorIndexWatch(t *testing.T) {
+	index, lists, fake := fakeIndex(labeledPod("pending-1", "a"))
+	defer index.Stop()
+
+	if status, _ := index.readiness("pod", map[string]string{"app": "a"}); status.Phase != interfaces.ResourceNotReady {
+		t.Fatalf("Expected not ready status, got %s", status)
+	}
+
+	fake.Delete(labeledPod("pending-1", "a"))
+	fake.Add(labeledPod("ready-1", "a"))
+	// events are applied one by one, so the previous ones are in the index once this one is received
+	fake.Add(labeledPod("ready-2", "b"))
+
+	objects, err := index.selected("pod", map[string]string{"app": "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 1 {
+		t.Fatalf("Expected 1 selected pod, got %d", len(objects))
+	}
+	if status, err := index.readiness("pod", map[string]string{"app": "a"}); status.Phase != interfaces.ResourceReady {
+		t.Errorf("Expected ready status, got %s, %v", status, err)
+	}
+	if *lists != 1 {
+		t.Errorf("Pods should be listed once, listed %d times", *lists)
+	}
+}
+
+// TestSelectorIndexWatchClosed checks that objects are listed again after their watch is closed
+func TestSelectorIndexWatchClosed(t *testing.T) {
+	index, lists, fake := fakeIndex(labeledPod("ready-1", "a"))
+	defer index.Stop()
+
+	if _, err := index.selected("pod", nil); err != nil {
+		t.Fatal(err)
+	}
+	fake.Stop()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		index.Lock()
+		synced := index.objects["pod"].synced
+		index.Unlock()
+		if !synced {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Index should not be synced after watch is closed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := index.selected("pod", nil); err != nil {
+		t.Fatal(err)
+	}
+	if *lists != 2 {
+		t.Errorf("Pods should be listed again, listed %d times", *lists)
+	}
+}
@@ -15,18 +15,13 @@
 package resources
 
 import (
-	"fmt"
-	"log"
-
+	"golang.org/x/net/context"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
-	"k8s.io/client-go/pkg/api"
 	"k8s.io/client-go/pkg/api/v1"
-	"k8s.io/client-go/pkg/apis/apps/v1beta1"
-	"k8s.io/client-go/pkg/labels"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
-	"github.com/Mirantis/k8s-AppController/pkg/report"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
 )
 
 type Service struct {
@@ -34,72 +29,62 @@ type Service struct {
 	Service   *v1.Service
 	Client    corev1.ServiceInterface
 	APIClient client.Interface
+	// Index is a shared index of selected objects. Objects are listed on every status check if it is not set
+	Index *SelectorIndex
 }
 
-func serviceStatus(s corev1.ServiceInterface, name string, apiClient client.Interface) (string, error) {
+func serviceStatus(s corev1.ServiceInterface, name string, index *SelectorIndex) (interfaces.ResourceStatus, error) {
 	service, err := s.Get(name)
 
 	if err != nil {
-		return "error", err
+		return interfaces.NewStatus(interfaces.ResourceError), err
 	}
+	return serviceReadiness(service, index)
+}
 
-	log.Printf("Checking service status for selector %v", service.Spec.Selector)
+// serviceReadiness checks that objects selected by every key-value pair of service selector are ready.
+// Objects are taken from the index, so selectors are evaluated in memory
+func serviceReadiness(service *v1.Service, index *SelectorIndex) (interfaces.ResourceStatus, error) {
+	logging.ForResource(serviceKey(service.Name)).Debugf("Checking service status for selector %v", service.Spec.Selector)
 	for k, v := range service.Spec.Selector {
-		stringSelector := fmt.Sprintf("%s=%s", k, v)
-		log.Printf("Checking status for %s", stringSelector)
-		selector, err := labels.Parse(stringSelector)
-		if err != nil {
-			return "error", err
-		}
-
-		options := v1.ListOptions{LabelSelector: selector.String()}
-
-		pods, err := apiClient.Pods().List(options)
-		if err != nil {
-			return "error", err
-		}
-		jobs, err := apiClient.Jobs().List(options)
-		if err != nil {
-			return "error", err
-		}
-		replicasets, err := apiClient.ReplicaSets().List(options)
-		if err != nil {
-			return "error", err
-		}
-		resources := make([]interfaces.BaseResource, 0, len(pods.Items)+len(jobs.Items)+len(replicasets.Items))
-		for _, pod := range pods.Items {
-			resources = append(resources, NewPod(&pod, apiClient.Pods(), nil))
-		}
-		for _, j := range jobs.Items {
-			resources = append(resources, NewJob(&j, apiClient.Jobs(), nil))
-		}
-		for _, r := range replicasets.Items {
-			resources = append(resources, NewReplicaSet(&r, apiClient.ReplicaSets(), nil))
-		}
-		if apiClient.IsEnabled(v1beta1.SchemeGroupVersion) {
-			statefulsets, err := apiClient.StatefulSets().List(options)
-			if err != nil {
-				return "error", err
-			}
-			for _, ps := range statefulsets.Items {
-				resources = append(resources, NewStatefulSet(&ps, apiClient.StatefulSets(), apiClient, nil))
-			}
-		} else {
-			petsets, err := apiClient.PetSets().List(api.ListOptions{LabelSelector: selector})
-			if err != nil {
-				return "error", err
-			}
-			for _, ps := range petsets.Items {
-				resources = append(resources, NewPetSet(&ps, apiClient.PetSets(), apiClient, nil))
+		logging.ForResource(serviceKey(service.Name)).Debugf("Checking status for %s=%s", k, v)
+		for _, kind := range index.selectorKinds() {
+			status, err := index.readiness(kind, map[string]string{k: v})
+			if status.Phase != interfaces.ResourceReady || err != nil {
+				return status, err
 			}
 		}
-		status, err := resourceListReady(resources)
-		if status != "ready" || err != nil {
-			return status, err
-		}
 	}
 
-	return "ready", nil
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+func serviceReport(s corev1.ServiceInterface, name string, index *SelectorIndex) interfaces.DependencyReport {
+	status, err := serviceStatus(s, name, index)
+	return statusReport(serviceKey(name), status, err, "all selected resources are ready")
+}
+
+// serviceIndex returns shared selector index of the service if it is set, or index listing selected
+// objects for a single status check otherwise
+func serviceIndex(index *SelectorIndex, apiClient client.Interface) *SelectorIndex {
+	if index != nil {
+		return index
+	}
+	return snapshotIndex(apiClient)
+}
+
+// WithSelectorIndex returns the resource with status of services checked using the shared index.
+// Other resources are returned as is
+func WithSelectorIndex(r interfaces.Resource, index *SelectorIndex) interfaces.Resource {
+	switch s := r.(type) {
+	case Service:
+		s.Index = index
+		return s
+	case ExistingService:
+		s.Index = index
+		return s
+	}
+	return r
 }
 
 func serviceKey(name string) string {
@@ -110,22 +95,60 @@ func (s Service) Key() string {
 	return serviceKey(s.Service.Name)
 }
 
-func (s Service) Create() error {
-	if err := checkExistence(s); err != nil {
-		log.Println("Creating ", s.Key())
+func (s Service) Create(ctx context.Context) error {
+	if err := checkExistence(ctx, s); err != nil {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		logging.ForResource(s.Key()).Infof("Creating %s", s.Key())
+		if err = setLastApplied(&s.Service.ObjectMeta, s.Service); err != nil {
+			return err
+		}
 		s.Service, err = s.Client.Create(s.Service)
 		return err
 	}
 	return nil
 }
 
+// Update replaces Service in the cluster with its definition. Cluster IP of the service is kept
+// unless it is set in the definition, since it cannot be changed
+func (s Service) Update(ctx context.Context) error {
+	service, err := s.Client.Get(s.Service.Name)
+	if err != nil {
+		return err
+	}
+	if s.Service.Spec.ClusterIP == "" {
+		s.Service.Spec.ClusterIP = service.Spec.ClusterIP
+	}
+	if err = prepareUpdate(&s.Service.ObjectMeta, service.ObjectMeta, s.Service); err != nil {
+		return err
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	_, err = s.Client.Update(s.Service)
+	return err
+}
+
 // Delete deletes Service from the cluster
-func (s Service) Delete() error {
+func (s Service) Delete(ctx context.Context) error {
 	return s.Client.Delete(s.Service.Name, nil)
 }
 
-func (s Service) Status(meta map[string]string) (string, error) {
-	return serviceStatus(s.Client, s.Service.Name, s.APIClient)
+func (s Service) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	service, err := s.Client.Get(s.Service.Name)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	if !s.EqualToDefinition(service) {
+		return interfaces.NewStatus(interfaces.ResourceWaitingForUpgrade), nil
+	}
+	return serviceReadiness(service, serviceIndex(s.Index, s.APIClient))
+}
+
+// EqualToDefinition checks if definition in object is compatible with provided object
+func (s Service) EqualToDefinition(service interface{}) bool {
+	return s.equalToDefinition(s.Key(), s.Service, service)
 }
 
 // NameMatches gets resource definition and a name and checks if
@@ -141,12 +164,17 @@ func (s Service) New(def client.ResourceDefinition, c client.Interface) interfac
 
 // NewExisting returns new ExistingService based on resource definition
 func (s Service) NewExisting(name string, c client.Interface) interfaces.Resource {
-	return NewExistingService(name, c.Services())
+	return NewExistingService(name, c.Services(), c)
 }
 
 // NewService is Service constructor. Needs apiClient for service status checks
 func NewService(service *v1.Service, client corev1.ServiceInterface, apiClient client.Interface, meta map[string]interface{}) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: Service{Base: Base{meta}, Service: service, Client: client, APIClient: apiClient}}
+	return Service{Base: Base{meta}, Service: service, Client: client, APIClient: apiClient}
+}
+
+// GetDependencyReport returns a DependencyReport for this service
+func (s Service) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return serviceReport(s.Client, s.Service.Name, serviceIndex(s.Index, s.APIClient))
 }
 
 // StatusIsCacheable for service always returns false since the status must be
@@ -160,31 +188,40 @@ type ExistingService struct {
 	Name      string
 	Client    corev1.ServiceInterface
 	APIClient client.Interface
+	// Index is a shared index of selected objects, see Service
+	Index *SelectorIndex
 }
 
 func (s ExistingService) Key() string {
 	return serviceKey(s.Name)
 }
 
-func (s ExistingService) Create() error {
-	return createExistingResource(s)
+func (s ExistingService) Create(ctx context.Context) error {
+	return createExistingResource(ctx, s)
 }
 
-func (s ExistingService) Status(meta map[string]string) (string, error) {
-	return serviceStatus(s.Client, s.Name, s.APIClient)
+func (s ExistingService) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	return serviceStatus(s.Client, s.Name, serviceIndex(s.Index, s.APIClient))
 }
 
 // Delete deletes Service from the cluster
-func (s ExistingService) Delete() error {
+func (s ExistingService) Delete(ctx context.Context) error {
 	return s.Client.Delete(s.Name, nil)
 }
 
+// GetDependencyReport returns a DependencyReport for this service
+func (s ExistingService) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return serviceReport(s.Client, s.Name, serviceIndex(s.Index, s.APIClient))
+}
+
 // StatusIsCacheable for service always returns false since the status must be
 // checked on each request and not be cached
 func (s ExistingService) StatusIsCacheable(meta map[string]string) bool {
 	return false
 }
 
-func NewExistingService(name string, client corev1.ServiceInterface) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: ExistingService{Name: name, Client: client}}
+// NewExistingService is ExistingService constructor. Needs apiClient for status checks of services which
+// are not attached to shared index, e.g. services outside ac namespace
+func NewExistingService(name string, client corev1.ServiceInterface, apiClient client.Interface) interfaces.Resource {
+	return ExistingService{Name: name, Client: client, APIClient: apiClient}
 }
@@ -19,19 +19,21 @@ import (
 
 	"fmt"
 
+	"golang.org/x/net/context"
+
 	"github.com/Mirantis/k8s-AppController/pkg/mocks"
 )
 
 // TestCheckServiceStatusReady checks if the service status check is fine for healthy service
 func TestCheckServiceStatusReady(t *testing.T) {
 	c := mocks.NewClient(mocks.MakeService("success"))
-	status, err := serviceStatus(c.Services(), "success", c)
+	status, err := serviceStatus(c.Services(), "success", snapshotIndex(c))
 
 	if err != nil {
 		t.Errorf("%s", err)
 	}
 
-	if status != "ready" {
+	if status.Phase != "ready" {
 		t.Errorf("service should be `ready`, is `%s` instead", status)
 	}
 }
@@ -42,7 +44,7 @@ func TestCheckServiceStatusPodNotReady(t *testing.T) {
 	pod := mocks.MakePod("error")
 	pod.Labels = svc.Spec.Selector
 	c := mocks.NewClient(svc, pod)
-	status, err := serviceStatus(c.Services(), "failedpod", c)
+	status, err := serviceStatus(c.Services(), "failedpod", snapshotIndex(c))
 
 	if err == nil {
 		t.Fatal("Error should be returned, got nil")
@@ -52,7 +54,7 @@ func TestCheckServiceStatusPodNotReady(t *testing.T) {
 		t.Errorf("Expected `%s` as error, got `%s`", expectedError, err.Error())
 	}
 
-	if status != "not ready" {
+	if status.Phase != "not ready" {
 		t.Errorf("service should be `not ready`, is `%s` instead", status)
 	}
 }
@@ -63,7 +65,7 @@ func TestCheckServiceStatusJobNotReady(t *testing.T) {
 	job := mocks.MakeJob("error")
 	job.Labels = svc.Spec.Selector
 	c := mocks.NewClient(svc, job)
-	status, err := serviceStatus(c.Services(), "failedjob", c)
+	status, err := serviceStatus(c.Services(), "failedjob", snapshotIndex(c))
 
 	if err == nil {
 		t.Error("Error should be returned, got nil")
@@ -74,7 +76,7 @@ func TestCheckServiceStatusJobNotReady(t *testing.T) {
 		t.Errorf("Expected `%s` as error, got `%s`", expectedError, err.Error())
 	}
 
-	if status != "not ready" {
+	if status.Phase != "not ready" {
 		t.Errorf("service should be `not ready`, is `%s` instead", status)
 	}
 }
@@ -85,7 +87,7 @@ func TestCheckServiceStatusReplicaSetNotReady(t *testing.T) {
 	rc := mocks.MakeReplicaSet("fail")
 	rc.Labels = svc.Spec.Selector
 	c := mocks.NewClient(svc, rc)
-	status, err := serviceStatus(c.Services(), "failedrc", c)
+	status, err := serviceStatus(c.Services(), "failedrc", snapshotIndex(c))
 
 	if err == nil {
 		t.Error("Error should be returned, got nil")
@@ -96,7 +98,43 @@ func TestCheckServiceStatusReplicaSetNotReady(t *testing.T) {
 		t.Errorf("Expected `%s` as error, got `%s`", expectedError, err.Error())
 	}
 
-	if status != "not ready" {
+	if status.Phase != "not ready" {
 		t.Errorf("service should be `not ready`, is `%s` instead", status)
 	}
 }
+
+// TestServiceDependencyReport tests that report of service selecting not ready pod blocks
+func TestServiceDependencyReport(t *testing.T) {
+	svc := mocks.MakeService("failedpod")
+	pod := mocks.MakePod("error")
+	pod.Labels = svc.Spec.Selector
+	c := mocks.NewClient(svc, pod)
+
+	depReport := NewService(svc, c.Services(), c, nil).GetDependencyReport(context.Background(), nil)
+	if !depReport.Blocks {
+		t.Error("Dependency report should block")
+	}
+	expected := fmt.Sprintf("Resource pod/%v is not ready", pod.Name)
+	if depReport.Message != expected {
+		t.Errorf("Expected message `%s`, got `%s`", expected, depReport.Message)
+	}
+}
+
+// TestExistingServiceOutsideIndex checks status of existing service which is not attached to shared index,
+// like services in namespaces other than ac namespace
+func TestExistingServiceOutsideIndex(t *testing.T) {
+	svc := mocks.MakeService("failedpod")
+	svc.Namespace = "other"
+	pod := mocks.MakePod("error")
+	pod.Namespace = "other"
+	pod.Labels = svc.Spec.Selector
+	c := mocks.NewClient(svc, pod).WithNamespace("other")
+
+	existing := Service{}.NewExisting("failedpod", c)
+	if status, err := existing.Status(context.Background(), nil); err == nil || status.Phase != "not ready" {
+		t.Errorf("Expected service selecting failed pod to be not ready, got %s, %v", status, err)
+	}
+	if depReport := existing.GetDependencyReport(context.Background(), nil); !depReport.Blocks {
+		t.Errorf("Expected report of service selecting failed pod to block, got %+v", depReport)
+	}
+}
@@ -15,14 +15,13 @@
 package resources
 
 import (
-	"log"
-
+	"golang.org/x/net/context"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/pkg/api/v1"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
-	"github.com/Mirantis/k8s-AppController/pkg/report"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
 )
 
 type ServiceAccount struct {
@@ -45,29 +44,42 @@ func (c ServiceAccount) Key() string {
 	return serviceAccountKey(c.ServiceAccount.Name)
 }
 
-func serviceAccountStatus(c corev1.ServiceAccountInterface, name string) (string, error) {
+func serviceAccountStatus(c corev1.ServiceAccountInterface, name string) (interfaces.ResourceStatus, error) {
 	_, err := c.Get(name)
 	if err != nil {
-		return "error", err
+		return interfaces.NewStatus(interfaces.ResourceError), err
 	}
 
-	return "ready", nil
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+func serviceAccountReport(c corev1.ServiceAccountInterface, name string) interfaces.DependencyReport {
+	status, err := serviceAccountStatus(c, name)
+	return statusReport(serviceAccountKey(name), status, err, "service account exists")
 }
 
-func (c ServiceAccount) Status(meta map[string]string) (string, error) {
+func (c ServiceAccount) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
 	return serviceAccountStatus(c.Client, c.ServiceAccount.Name)
 }
 
-func (c ServiceAccount) Create() error {
-	if err := checkExistence(c); err != nil {
-		log.Println("Creating ", c.Key())
+// GetDependencyReport returns a DependencyReport for this ServiceAccount
+func (c ServiceAccount) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return serviceAccountReport(c.Client, c.ServiceAccount.Name)
+}
+
+func (c ServiceAccount) Create(ctx context.Context) error {
+	if err := checkExistence(ctx, c); err != nil {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		logging.ForResource(c.Key()).Infof("Creating %s", c.Key())
 		c.ServiceAccount, err = c.Client.Create(c.ServiceAccount)
 		return err
 	}
 	return nil
 }
 
-func (c ServiceAccount) Delete() error {
+func (c ServiceAccount) Delete(ctx context.Context) error {
 	return c.Client.Delete(c.ServiceAccount.Name, &v1.DeleteOptions{})
 }
 
@@ -76,11 +88,11 @@ func (c ServiceAccount) NameMatches(def client.ResourceDefinition, name string)
 }
 
 func NewServiceAccount(c *v1.ServiceAccount, client corev1.ServiceAccountInterface, meta map[string]interface{}) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: ServiceAccount{Base: Base{meta}, ServiceAccount: c, Client: client}}
+	return ServiceAccount{Base: Base{meta}, ServiceAccount: c, Client: client}
 }
 
 func NewExistingServiceAccount(name string, client corev1.ServiceAccountInterface) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: ExistingServiceAccount{Name: name, Client: client}}
+	return ExistingServiceAccount{Name: name, Client: client}
 }
 
 // New returns a new object wrapped as Resource
@@ -97,14 +109,19 @@ func (c ExistingServiceAccount) Key() string {
 	return serviceAccountKey(c.Name)
 }
 
-func (c ExistingServiceAccount) Status(meta map[string]string) (string, error) {
+func (c ExistingServiceAccount) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
 	return serviceAccountStatus(c.Client, c.Name)
 }
 
-func (c ExistingServiceAccount) Create() error {
-	return createExistingResource(c)
+// GetDependencyReport returns a DependencyReport for this ServiceAccount
+func (c ExistingServiceAccount) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return serviceAccountReport(c.Client, c.Name)
+}
+
+func (c ExistingServiceAccount) Create(ctx context.Context) error {
+	return createExistingResource(ctx, c)
 }
 
-func (c ExistingServiceAccount) Delete() error {
+func (c ExistingServiceAccount) Delete(ctx context.Context) error {
 	return c.Client.Delete(c.Name, nil)
 }
@@ -29,7 +29,7 @@ func TestServiceAccountSuccessCheck(t *testing.T) {
 		t.Error(err)
 	}
 
-	if status != "ready" {
+	if status.Phase != "ready" {
 		t.Errorf("Status should be `ready`, is `%s` instead.", status)
 	}
 }
@@ -43,7 +43,7 @@ func TestServiceAccountFailCheck(t *testing.T) {
 		t.Error("Error not found, expected error")
 	}
 
-	if status != "error" {
+	if status.Phase != "error" {
 		t.Errorf("Status should be `error`, is `%s` instead.", status)
 	}
 }
@@ -0,0 +1,131 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+)
+
+// SpreadKey is a key of resource definition meta requiring ready replicas of ReplicaSet or StatefulSet
+// to run in at least min distinct failure domains, e.g. nodes or zones, before the resource is ready
+const SpreadKey = "spread"
+
+// HostnameTopologyKey is a node label of the node name, which is the default failure domain of spread
+const HostnameTopologyKey = "kubernetes.io/hostname"
+
+// Spread is a requirement of spreading ready replicas across failure domains. Domains are values of
+// TopologyKey label of the nodes pods run on
+type Spread struct {
+	Min         int
+	TopologyKey string
+}
+
+// ParseSpread parses spread meta, which is an object with min number of domains and optional
+// topologyKey label (kubernetes.io/hostname by default)
+func ParseSpread(value interface{}) (Spread, error) {
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return Spread{}, fmt.Errorf("%s must be an object with min and topologyKey, got %v", SpreadKey, value)
+	}
+	spread := Spread{TopologyKey: HostnameTopologyKey}
+	min, ok := fields["min"].(float64)
+	if !ok || min < 1 || min != float64(int(min)) {
+		return Spread{}, fmt.Errorf("%s must have positive integer min, got %v", SpreadKey, fields["min"])
+	}
+	spread.Min = int(min)
+	if key, ok := fields["topologyKey"]; ok {
+		topologyKey, ok := key.(string)
+		if !ok || topologyKey == "" {
+			return Spread{}, fmt.Errorf("topologyKey of %s must be a node label, got %v", SpreadKey, key)
+		}
+		spread.TopologyKey = topologyKey
+	}
+	for key := range fields {
+		if key != "min" && key != "topologyKey" {
+			return Spread{}, fmt.Errorf("unknown field %s of %s", key, SpreadKey)
+		}
+	}
+	return spread, nil
+}
+
+// spreadStatus checks that ready pods with given labels run in at least spread.Min failure domains. Node
+// names are the domains of the hostname topology key, so nodes are not fetched for it. Pods on nodes
+// without the topology label are not counted
+func spreadStatus(key string, apiClient client.Interface, podLabels map[string]string, spread Spread) (interfaces.ResourceStatus, error) {
+	pods, err := podsFromLabels(apiClient, podLabels)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	domains := map[string]bool{}
+	nodeDomains := map[string]string{}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || !isReady(&pod) {
+			continue
+		}
+		if spread.TopologyKey == HostnameTopologyKey {
+			domains[pod.Spec.NodeName] = true
+			continue
+		}
+		domain, ok := nodeDomains[pod.Spec.NodeName]
+		if !ok {
+			node, err := apiClient.Nodes().Get(pod.Spec.NodeName)
+			if err != nil {
+				return interfaces.NewStatus(interfaces.ResourceError), err
+			}
+			domain = node.Labels[spread.TopologyKey]
+			nodeDomains[pod.Spec.NodeName] = domain
+		}
+		if domain != "" {
+			domains[domain] = true
+		}
+	}
+	if len(domains) < spread.Min {
+		return interfaces.NewStatus(interfaces.ResourceNotReady).WithReason("InsufficientSpread",
+			fmt.Sprintf("ready replicas of %s run in %d of %d required distinct %s domains", key, len(domains), spread.Min, spread.TopologyKey)).
+			WithProgress(len(domains), spread.Min), nil
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+// withSpread checks spread of ready replicas of resource which is ready otherwise, if its meta requires it
+func withSpread(r Base, key string, apiClient client.Interface, podLabels map[string]string, status interfaces.ResourceStatus, err error) (interfaces.ResourceStatus, error) {
+	value := r.Meta(SpreadKey)
+	if err != nil || value == nil || !status.IsReady() {
+		return status, err
+	}
+	spread, err := ParseSpread(value)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), fmt.Errorf("%s: %v", key, err)
+	}
+	return spreadStatus(key, apiClient, podLabels, spread)
+}
+
+// spreadReport makes report of resource which is ready otherwise block its dependents until its replicas
+// are spread, if its meta requires it
+func spreadReport(ctx context.Context, r interfaces.BaseResource, depReport interfaces.DependencyReport, meta map[string]string) interfaces.DependencyReport {
+	if depReport.Blocks || r.Meta(SpreadKey) == nil {
+		return depReport
+	}
+	status, err := r.Status(ctx, meta)
+	if err == nil && status.IsReady() {
+		return depReport
+	}
+	return statusReport(r.Key(), status, err, status.Message)
+}
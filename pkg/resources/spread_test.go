@@ -0,0 +1,99 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+	"testing"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/runtime"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// spreadObjects returns replica set web with ready pods on given nodes, and nodes in zones
+func spreadObjects(nodes ...string) []runtime.Object {
+	rs := mocks.MakeReplicaSet("web")
+	rs.Spec.Template.ObjectMeta.Labels = map[string]string{"app": "web"}
+	objects := []runtime.Object{rs}
+	for i, node := range nodes {
+		pod := mocks.MakePod(fmt.Sprintf("ready-%d", i))
+		pod.Labels = map[string]string{"app": "web"}
+		pod.Spec.NodeName = node
+		objects = append(objects, pod)
+	}
+	zones := map[string]string{"node-1": "a", "node-2": "a", "node-3": "b"}
+	for name, zone := range zones {
+		node := &v1.Node{}
+		node.Name = name
+		node.Labels = map[string]string{"failure-domain.beta.kubernetes.io/zone": zone}
+		objects = append(objects, node)
+	}
+	return objects
+}
+
+// TestSpread checks that replica set is ready only when its ready pods run in enough failure domains
+func TestSpread(t *testing.T) {
+	cases := []struct {
+		nodes  []string
+		spread map[string]interface{}
+		ready  bool
+	}{
+		{[]string{"node-1", "node-1"}, map[string]interface{}{"min": 2.0}, false},
+		{[]string{"node-1", "node-2"}, map[string]interface{}{"min": 2.0}, true},
+		{[]string{"node-1", "node-2"}, map[string]interface{}{"min": 2.0, "topologyKey": "failure-domain.beta.kubernetes.io/zone"}, false},
+		{[]string{"node-1", "node-3"}, map[string]interface{}{"min": 2.0, "topologyKey": "failure-domain.beta.kubernetes.io/zone"}, true},
+		{[]string{"node-1", ""}, map[string]interface{}{"min": 2.0}, false},
+	}
+	for i, tc := range cases {
+		c := mocks.NewClient(spreadObjects(tc.nodes...)...)
+		rs := NewReplicaSet(mocks.MakeReplicaSet("web"), c.ReplicaSets(), c, map[string]interface{}{SpreadKey: tc.spread})
+		status, err := rs.Status(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.IsReady() != tc.ready {
+			t.Errorf("case %d: expected ready=%t, got %v", i, tc.ready, status)
+		}
+		if depReport := rs.GetDependencyReport(context.Background(), nil); depReport.Blocks == tc.ready {
+			t.Errorf("case %d: expected report blocking=%t, got %v", i, !tc.ready, depReport)
+		}
+	}
+}
+
+// TestParseSpread checks validation of spread meta
+func TestParseSpread(t *testing.T) {
+	invalid := []interface{}{
+		"2",
+		map[string]interface{}{},
+		map[string]interface{}{"min": 1.5},
+		map[string]interface{}{"min": 2.0, "topologyKey": ""},
+		map[string]interface{}{"min": 2.0, "zone": "a"},
+	}
+	for _, value := range invalid {
+		if _, err := ParseSpread(value); err == nil {
+			t.Errorf("spread %v should be invalid", value)
+		}
+	}
+	spread, err := ParseSpread(map[string]interface{}{"min": 3.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spread.Min != 3 || spread.TopologyKey != HostnameTopologyKey {
+		t.Errorf("unexpected spread %+v", spread)
+	}
+}
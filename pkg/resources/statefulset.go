@@ -15,13 +15,15 @@
 package resources
 
 import (
-	"log"
+	"fmt"
 
+	"golang.org/x/net/context"
 	"k8s.io/client-go/kubernetes/typed/apps/v1beta1"
 	appsbeta1 "k8s.io/client-go/pkg/apis/apps/v1beta1"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
 	"github.com/Mirantis/k8s-AppController/pkg/report"
 )
 
@@ -33,13 +35,102 @@ type StatefulSet struct {
 	APIClient   client.Interface
 }
 
-func statefulsetStatus(p v1beta1.StatefulSetInterface, name string, apiClient client.Interface) (string, error) {
+func statefulsetStatus(ctx context.Context, p v1beta1.StatefulSetInterface, name string, apiClient client.Interface, meta map[string]string) (interfaces.ResourceStatus, error) {
 	// Use label from statefulset spec to get needed pods
 	ps, err := p.Get(name)
 	if err != nil {
-		return "error", err
+		return interfaces.NewStatus(interfaces.ResourceError), err
 	}
-	return podsStateFromLabels(apiClient, ps.Spec.Template.ObjectMeta.Labels)
+	return statefulsetReadiness(ctx, ps, apiClient, meta)
+}
+
+func statefulsetReadiness(ctx context.Context, ps *appsbeta1.StatefulSet, apiClient client.Interface, meta map[string]string) (interfaces.ResourceStatus, error) {
+	// statefulset controller has not processed the latest spec yet, so status fields are stale
+	if ps.Status.ObservedGeneration != nil && *ps.Status.ObservedGeneration < ps.Generation {
+		return statefulsetStaleStatus(), nil
+	}
+
+	// NOTE: apps/v1beta1 StatefulSetStatus of the vendored client-go has neither ReadyReplicas
+	// nor Current/UpdateRevision, so readiness of pods still has to be checked by listing them.
+	// Status.Replicas is used to detect scaling which is still in progress
+	if _, ok := meta[SuccessFactorKey]; !ok {
+		status, err := podsStateFromLabels(ctx, apiClient, ps.Spec.Template.ObjectMeta.Labels)
+		if status.Phase == interfaces.ResourceReady && ps.Status.Replicas < *ps.Spec.Replicas {
+			return statefulsetScalingStatus(ps), nil
+		}
+		return status, err
+	}
+
+	successFactor, err := getPercentage(SuccessFactorKey, meta)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	ready, err := readyPodsCountFromLabels(apiClient, ps.Spec.Template.ObjectMeta.Labels)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	if ready*100 < *ps.Spec.Replicas*successFactor {
+		return interfaces.NewStatus(interfaces.ResourceNotReady).
+			WithReason("PodsNotReady", fmt.Sprintf("%d of %d pods ready", ready, *ps.Spec.Replicas)).
+			WithProgress(int(ready), int(*ps.Spec.Replicas)), nil
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+// statefulsetStaleStatus is the status of statefulset whose latest spec was not processed by the controller yet
+func statefulsetStaleStatus() interfaces.ResourceStatus {
+	return interfaces.NewStatus(interfaces.ResourceNotReady).WithReason(
+		"ObservedGenerationStale", "statefulset controller has not observed the latest spec yet")
+}
+
+// statefulsetScalingStatus is the status of statefulset whose pods are ready, but not all replicas exist yet
+func statefulsetScalingStatus(ps *appsbeta1.StatefulSet) interfaces.ResourceStatus {
+	return interfaces.NewStatus(interfaces.ResourceNotReady).
+		WithReason("Scaling", fmt.Sprintf("%d of %d replicas created", ps.Status.Replicas, *ps.Spec.Replicas)).
+		WithProgress(int(ps.Status.Replicas), int(*ps.Spec.Replicas))
+}
+
+func statefulsetReport(ctx context.Context, p v1beta1.StatefulSetInterface, name string, apiClient client.Interface, meta map[string]string) interfaces.DependencyReport {
+	ps, err := p.Get(name)
+	if err != nil {
+		return report.ErrorReport(statefulsetKey(name), err)
+	}
+	status, err := statefulsetStatus(ctx, p, name, apiClient, meta)
+	if status.Phase == interfaces.ResourceError {
+		return report.ErrorReport(statefulsetKey(name), err)
+	}
+	successFactor, err := getPercentage(SuccessFactorKey, meta)
+	if err != nil {
+		return report.ErrorReport(statefulsetKey(name), err)
+	}
+	ready, err := readyPodsCountFromLabels(apiClient, ps.Spec.Template.ObjectMeta.Labels)
+	if err != nil {
+		return report.ErrorReport(statefulsetKey(name), err)
+	}
+
+	return percentageReport(
+		statefulsetKey(name),
+		status,
+		ready,
+		*ps.Spec.Replicas,
+		successFactor,
+		fmt.Sprintf("%d of %d pods ready", ready, *ps.Spec.Replicas),
+	)
+}
+
+// restartStatefulSetPods sets pod template annotation of StatefulSet. Note that apps/v1beta1 StatefulSets
+// have no update strategy, so existing pods get the new template only once they are recreated
+func restartStatefulSetPods(p v1beta1.StatefulSetInterface, name, annotation, value string) error {
+	ps, err := p.Get(name)
+	if err != nil {
+		return err
+	}
+	if !setTemplateAnnotation(&ps.Spec.Template.ObjectMeta, annotation, value) {
+		return nil
+	}
+	logging.ForResource(statefulsetKey(name)).Infof("Restarting pods of %s", statefulsetKey(name))
+	_, err = p.Update(ps)
+	return err
 }
 
 func statefulsetKey(name string) string {
@@ -52,23 +143,79 @@ func (p StatefulSet) Key() string {
 }
 
 // Create looks for a StatefulSet in Kubernetes cluster and creates it if it's not there
-func (p StatefulSet) Create() error {
-	if err := checkExistence(p); err != nil {
-		log.Println("Creating ", p.Key())
+func (p StatefulSet) Create(ctx context.Context) error {
+	if err := checkExistence(ctx, p); err != nil {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		logging.ForResource(p.Key()).Infof("Creating %s", p.Key())
+		if err = setLastApplied(&p.StatefulSet.ObjectMeta, p.StatefulSet); err != nil {
+			return err
+		}
 		_, err = p.Client.Create(p.StatefulSet)
 		return err
 	}
 	return nil
 }
 
+// Update replaces StatefulSet in the cluster with its definition
+func (p StatefulSet) Update(ctx context.Context) error {
+	ps, err := p.Client.Get(p.StatefulSet.Name)
+	if err != nil {
+		return err
+	}
+	if err = prepareUpdate(&p.StatefulSet.ObjectMeta, ps.ObjectMeta, p.StatefulSet); err != nil {
+		return err
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	_, err = p.Client.Update(p.StatefulSet)
+	return err
+}
+
+// RestartPods changes pod template annotation of StatefulSet
+func (p StatefulSet) RestartPods(annotation, value string) error {
+	return restartStatefulSetPods(p.Client, p.StatefulSet.Name, annotation, value)
+}
+
 // Delete deletes StatefulSet from the cluster
-func (p StatefulSet) Delete() error {
+func (p StatefulSet) Delete(ctx context.Context) error {
 	return p.Client.Delete(p.StatefulSet.Name, nil)
 }
 
+// DeleteWithPolicy deletes StatefulSet from the cluster, policy defines what happens with its pods
+func (p StatefulSet) DeleteWithPolicy(policy DeletionPolicy) error {
+	return p.Client.Delete(p.StatefulSet.Name, policy.options())
+}
+
 // Status returns StatefulSet status as a string. "ready" is regarded as sufficient for it's dependencies to be created.
-func (p StatefulSet) Status(meta map[string]string) (string, error) {
-	return statefulsetStatus(p.Client, p.StatefulSet.Name, p.APIClient)
+func (p StatefulSet) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	ps, err := p.Client.Get(p.StatefulSet.Name)
+	if err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	if !p.EqualToDefinition(ps) {
+		return interfaces.NewStatus(interfaces.ResourceWaitingForUpgrade), nil
+	}
+	status, err := statefulsetReadiness(ctx, ps, p.APIClient, meta)
+	return withSpread(p.Base, p.Key(), p.APIClient, ps.Spec.Template.ObjectMeta.Labels, status, err)
+}
+
+// EqualToDefinition checks if definition in object is compatible with provided object
+func (p StatefulSet) EqualToDefinition(statefulset interface{}) bool {
+	return p.equalToDefinition(p.Key(), p.StatefulSet, statefulset)
+}
+
+// GetDependencyReport returns a DependencyReport for this statefulset
+func (p StatefulSet) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return spreadReport(ctx, p, statefulsetReport(ctx, p.Client, p.StatefulSet.Name, p.APIClient, meta), meta)
+}
+
+// StatusIsCacheable returns false if meta contains SuccessFactorKey
+func (p StatefulSet) StatusIsCacheable(meta map[string]string) bool {
+	_, ok := meta[SuccessFactorKey]
+	return !ok
 }
 
 // NameMatches gets resource definition and a name and checks if
@@ -89,7 +236,7 @@ func (p StatefulSet) NewExisting(name string, c client.Interface) interfaces.Res
 
 // NewStatefulSet is a constructor
 func NewStatefulSet(statefulset *appsbeta1.StatefulSet, client v1beta1.StatefulSetInterface, apiClient client.Interface, meta map[string]interface{}) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: StatefulSet{Base: Base{meta}, StatefulSet: statefulset, Client: client, APIClient: apiClient}}
+	return StatefulSet{Base: Base{meta}, StatefulSet: statefulset, Client: client, APIClient: apiClient}
 }
 
 // ExistingStatefulSet is a wrapper for K8s StatefulSet object which is meant to already be in a cluster bofer AppController execution
@@ -106,21 +253,42 @@ func (p ExistingStatefulSet) Key() string {
 }
 
 // Create looks for existing StatefulSet and returns an error if there is no such StatefulSet in a cluster
-func (p ExistingStatefulSet) Create() error {
-	return createExistingResource(p)
+func (p ExistingStatefulSet) Create(ctx context.Context) error {
+	return createExistingResource(ctx, p)
 }
 
 // Status returns StatefulSet status as a string. "ready" is regarded as sufficient for it's dependencies to be created.
-func (p ExistingStatefulSet) Status(meta map[string]string) (string, error) {
-	return statefulsetStatus(p.Client, p.Name, p.APIClient)
+func (p ExistingStatefulSet) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	return statefulsetStatus(ctx, p.Client, p.Name, p.APIClient, meta)
+}
+
+// GetDependencyReport returns a DependencyReport for this statefulset
+func (p ExistingStatefulSet) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return statefulsetReport(ctx, p.Client, p.Name, p.APIClient, meta)
+}
+
+// StatusIsCacheable returns false if meta contains SuccessFactorKey
+func (p ExistingStatefulSet) StatusIsCacheable(meta map[string]string) bool {
+	_, ok := meta[SuccessFactorKey]
+	return !ok
+}
+
+// RestartPods changes pod template annotation of StatefulSet
+func (p ExistingStatefulSet) RestartPods(annotation, value string) error {
+	return restartStatefulSetPods(p.Client, p.Name, annotation, value)
 }
 
 // Delete deletes StatefulSet from the cluster
-func (p ExistingStatefulSet) Delete() error {
+func (p ExistingStatefulSet) Delete(ctx context.Context) error {
 	return p.Client.Delete(p.Name, nil)
 }
 
+// DeleteWithPolicy deletes StatefulSet from the cluster, policy defines what happens with its pods
+func (p ExistingStatefulSet) DeleteWithPolicy(policy DeletionPolicy) error {
+	return p.Client.Delete(p.Name, policy.options())
+}
+
 // NewExistingStatefulSet is a constructor
 func NewExistingStatefulSet(name string, client v1beta1.StatefulSetInterface, apiClient client.Interface) interfaces.Resource {
-	return report.SimpleReporter{BaseResource: ExistingStatefulSet{Name: name, Client: client, APIClient: apiClient}}
+	return ExistingStatefulSet{Name: name, Client: client, APIClient: apiClient}
 }
@@ -17,6 +17,7 @@ package resources
 import (
 	"testing"
 
+	"golang.org/x/net/context"
 	"k8s.io/client-go/pkg/apis/apps/v1beta1"
 
 	"github.com/Mirantis/k8s-AppController/pkg/mocks"
@@ -25,13 +26,13 @@ import (
 // TestStatefulSetSuccessCheck checks status of ready StatefulSet
 func TestStatefulSetSuccessCheck(t *testing.T) {
 	c := mocks.NewClient(mocks.MakeStatefulSet("notfail"))
-	status, err := statefulsetStatus(c.StatefulSets(), "notfail", c)
+	status, err := statefulsetStatus(context.Background(), c.StatefulSets(), "notfail", c, nil)
 
 	if err != nil {
 		t.Error(err)
 	}
 
-	if status != "ready" {
+	if status.Phase != "ready" {
 		t.Errorf("Status should be `ready`, is `%s` instead.", status)
 	}
 }
@@ -42,18 +43,92 @@ func TestStatefulSetFailCheck(t *testing.T) {
 	pod := mocks.MakePod("fail")
 	pod.Labels = ss.Spec.Template.ObjectMeta.Labels
 	c := mocks.NewClient(ss, pod)
-	status, err := statefulsetStatus(c.StatefulSets(), "fail", c)
+	status, err := statefulsetStatus(context.Background(), c.StatefulSets(), "fail", c, nil)
 
 	expectedError := "Resource pod/fail is not ready"
 	if err.Error() != expectedError {
 		t.Errorf("Expected `%s` as error, got `%s`", expectedError, err.Error())
 	}
 
-	if status != "not ready" {
+	if status.Phase != "not ready" {
 		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
 	}
 }
 
+// TestStatefulSetScalingCheck checks that statefulset which has not created all replicas is not ready
+func TestStatefulSetScalingCheck(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeStatefulSet("scaling"))
+	status, err := statefulsetStatus(context.Background(), c.StatefulSets(), "scaling", c, nil)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status.Phase != "not ready" {
+		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
+	}
+}
+
+// TestStatefulSetNotObservedCheck checks that statefulset with unprocessed spec is not ready
+func TestStatefulSetNotObservedCheck(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeStatefulSet("notobserved"))
+	status, err := statefulsetStatus(context.Background(), c.StatefulSets(), "notobserved", c, nil)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if status.Phase != "not ready" {
+		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
+	}
+}
+
+// TestStatefulSetSuccessFactorCheck checks that statefulset is ready when enough pods are ready
+func TestStatefulSetSuccessFactorCheck(t *testing.T) {
+	ss := mocks.MakeStatefulSet("fail")
+	readyPod := mocks.MakePod("ready-1")
+	readyPod.Labels = ss.Spec.Template.ObjectMeta.Labels
+	pod := mocks.MakePod("fail")
+	pod.Labels = ss.Spec.Template.ObjectMeta.Labels
+	c := mocks.NewClient(ss, readyPod, pod)
+
+	status, err := statefulsetStatus(context.Background(), c.StatefulSets(), "fail", c, map[string]string{SuccessFactorKey: "30"})
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != "ready" {
+		t.Errorf("Status should be `ready`, is `%s` instead.", status)
+	}
+
+	status, err = statefulsetStatus(context.Background(), c.StatefulSets(), "fail", c, map[string]string{SuccessFactorKey: "50"})
+	if err != nil {
+		t.Error(err)
+	}
+	if status.Phase != "not ready" {
+		t.Errorf("Status should be `not ready`, is `%s` instead.", status)
+	}
+}
+
+// TestStatefulSetDependencyReport checks the report of partially ready statefulset
+func TestStatefulSetDependencyReport(t *testing.T) {
+	ss := mocks.MakeStatefulSet("fail")
+	readyPod := mocks.MakePod("ready-1")
+	readyPod.Labels = ss.Spec.Template.ObjectMeta.Labels
+	c := mocks.NewClient(ss, readyPod)
+
+	depReport := NewExistingStatefulSet("fail", c.StatefulSets(), c).GetDependencyReport(context.Background(), map[string]string{SuccessFactorKey: "50"})
+	if !depReport.Blocks {
+		t.Error("Dependency report should block")
+	}
+	if depReport.Percentage != 33 {
+		t.Errorf("Expected percentage 33, got %d", depReport.Percentage)
+	}
+	expected := "1 of 3 pods ready"
+	if depReport.Message != expected {
+		t.Errorf("Expected message `%s`, got `%s`", expected, depReport.Message)
+	}
+}
+
 func TestStatefulSetIsEnabled(t *testing.T) {
 	c := mocks.NewClient()
 	if !c.IsEnabled(v1beta1.SchemeGroupVersion) {
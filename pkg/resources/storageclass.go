@@ -0,0 +1,141 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"golang.org/x/net/context"
+	storageclient "k8s.io/client-go/kubernetes/typed/storage/v1beta1"
+	"k8s.io/client-go/pkg/api/v1"
+	storagebeta1 "k8s.io/client-go/pkg/apis/storage/v1beta1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// StorageClass is a cluster-scoped class of dynamically provisioned volumes. It is ready as soon as it
+// exists
+type StorageClass struct {
+	Base
+	StorageClass *storagebeta1.StorageClass
+	Client       storageclient.StorageClassInterface
+}
+
+func storageClassKey(name string) string {
+	return "storageclass/" + name
+}
+
+// Key returns StorageClass key
+func (r StorageClass) Key() string {
+	return storageClassKey(r.StorageClass.Name)
+}
+
+func storageClassStatus(c storageclient.StorageClassInterface, name string) (interfaces.ResourceStatus, error) {
+	if _, err := c.Get(name); err != nil {
+		return interfaces.NewStatus(interfaces.ResourceError), err
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+func storageClassReport(c storageclient.StorageClassInterface, name string) interfaces.DependencyReport {
+	status, err := storageClassStatus(c, name)
+	return statusReport(storageClassKey(name), status, err, "storage class exists")
+}
+
+// Status returns StorageClass status
+func (r StorageClass) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	return storageClassStatus(r.Client, r.StorageClass.Name)
+}
+
+// GetDependencyReport returns a DependencyReport for this StorageClass
+func (r StorageClass) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return storageClassReport(r.Client, r.StorageClass.Name)
+}
+
+// Create creates StorageClass if it does not exist
+func (r StorageClass) Create(ctx context.Context) error {
+	if err := checkExistence(ctx, r); err != nil {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		logging.ForResource(r.Key()).Infof("Creating %s", r.Key())
+		r.StorageClass, err = r.Client.Create(r.StorageClass)
+		return err
+	}
+	return nil
+}
+
+// Delete deletes StorageClass from the cluster
+func (r StorageClass) Delete(ctx context.Context) error {
+	return r.Client.Delete(r.StorageClass.Name, &v1.DeleteOptions{})
+}
+
+// NameMatches gets resource definition and a name and checks if
+// the StorageClass part of resource definition has matching name.
+func (r StorageClass) NameMatches(def client.ResourceDefinition, name string) bool {
+	return def.StorageClass != nil && def.StorageClass.Name == name
+}
+
+// New returns new StorageClass based on resource definition
+func (r StorageClass) New(def client.ResourceDefinition, c client.Interface) interfaces.Resource {
+	return NewStorageClass(def.StorageClass, c.StorageClasses(), def.Meta)
+}
+
+// NewExisting returns new ExistingStorageClass based on resource definition
+func (r StorageClass) NewExisting(name string, c client.Interface) interfaces.Resource {
+	return NewExistingStorageClass(name, c.StorageClasses())
+}
+
+// NewStorageClass returns StorageClass wrapped as Resource
+func NewStorageClass(obj *storagebeta1.StorageClass, client storageclient.StorageClassInterface, meta map[string]interface{}) interfaces.Resource {
+	return StorageClass{Base: Base{meta}, StorageClass: obj, Client: client}
+}
+
+// ExistingStorageClass is a StorageClass without definition
+type ExistingStorageClass struct {
+	Base
+	Name   string
+	Client storageclient.StorageClassInterface
+}
+
+// Key returns StorageClass key
+func (r ExistingStorageClass) Key() string {
+	return storageClassKey(r.Name)
+}
+
+// Status returns StorageClass status
+func (r ExistingStorageClass) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	return storageClassStatus(r.Client, r.Name)
+}
+
+// GetDependencyReport returns a DependencyReport for this StorageClass
+func (r ExistingStorageClass) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	return storageClassReport(r.Client, r.Name)
+}
+
+// Create looks for existing StorageClass and returns error if there is no such object
+func (r ExistingStorageClass) Create(ctx context.Context) error {
+	return createExistingResource(ctx, r)
+}
+
+// Delete deletes StorageClass from the cluster
+func (r ExistingStorageClass) Delete(ctx context.Context) error {
+	return r.Client.Delete(r.Name, nil)
+}
+
+// NewExistingStorageClass returns existing StorageClass wrapped as Resource
+func NewExistingStorageClass(name string, client storageclient.StorageClassInterface) interfaces.Resource {
+	return ExistingStorageClass{Name: name, Client: client}
+}
@@ -0,0 +1,34 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+)
+
+// AuditAsTable returns a human-readable table of audit log entries
+func AuditAsTable(entries []client.AuditEntry) []string {
+	rows := [][]string{{"TIME", "RUN", "VERB", "NAMESPACE", "KEY", "OUTCOME", "ERROR"}}
+	for _, e := range entries {
+		key := e.Key
+		if e.Subresource != "" {
+			key += "/" + e.Subresource
+		}
+		rows = append(rows, []string{e.Time.Format(time.RFC3339), e.RunID, e.Verb, e.Namespace, key, e.Outcome, e.Error})
+	}
+	return formatTable(rows)
+}
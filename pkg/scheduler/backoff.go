@@ -0,0 +1,155 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/errors"
+
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+var (
+	// BackoffInitial is a delay before the first retry of a node after API server error. It is doubled
+	// on each following error up to BackoffMax
+	BackoffInitial = time.Second
+	BackoffMax     = time.Minute
+
+	// BreakerThreshold is a number of consecutive API server errors which trips the circuit breaker.
+	// Tripped breaker pauses API requests of the whole run for BreakerCooldown, which is doubled up to
+	// BackoffMax every time the breaker trips again before a request succeeds
+	BreakerThreshold = 5
+	BreakerCooldown  = time.Second * 5
+)
+
+// throttlingError returns true if the error means that API server is overloaded or unavailable: it
+// returned 429 or 5xx status, could not be reached at all or did not respond within RequestTimeout.
+// Requests failing with such errors are retried after backoff instead of failing the resource
+func throttlingError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if err == context.DeadlineExceeded {
+		return true
+	}
+	if status, ok := err.(errors.APIStatus); ok {
+		code := int(status.Status().Code)
+		return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+	}
+	_, ok := err.(net.Error)
+	return ok
+}
+
+// backoff is an exponentially growing delay between retries of a node
+type backoff struct {
+	delay time.Duration
+}
+
+// next returns delay before the next retry and doubles it for the following one
+func (b *backoff) next() time.Duration {
+	switch {
+	case b.delay == 0:
+		b.delay = BackoffInitial
+	case b.delay < BackoffMax:
+		b.delay *= 2
+	}
+	if b.delay > BackoffMax {
+		b.delay = BackoffMax
+	}
+	return b.delay
+}
+
+// reset starts delays over after successful request
+func (b *backoff) reset() {
+	b.delay = 0
+}
+
+// circuitBreaker counts consecutive API server errors of all nodes. Once there are too many of them,
+// it opens and creation and status polling of all nodes wait until it closes, so that retries of the whole run do not keep an
+// overloaded server busy. The first error after the breaker closes opens it again with longer cooldown
+type circuitBreaker struct {
+	failures  int
+	cooldown  time.Duration
+	openUntil time.Time
+	sync.Mutex
+}
+
+// apiBreaker is shared by all graphs, since they talk to the same API server
+var apiBreaker = &circuitBreaker{}
+
+// record counts the result of API request
+func (b *circuitBreaker) record(err error) {
+	b.Lock()
+	defer b.Unlock()
+	if !throttlingError(err) {
+		b.failures = 0
+		b.cooldown = 0
+		return
+	}
+	b.failures++
+	if b.failures < BreakerThreshold || time.Now().Before(b.openUntil) {
+		return
+	}
+	switch {
+	case b.cooldown == 0:
+		b.cooldown = BreakerCooldown
+	case b.cooldown < BackoffMax:
+		b.cooldown *= 2
+	}
+	if b.cooldown > BackoffMax {
+		b.cooldown = BackoffMax
+	}
+	b.openUntil = time.Now().Add(b.cooldown)
+	breakerTrips.Inc()
+	logging.Warningf("%d consecutive API server errors, pausing requests for %v: %v", b.failures, b.cooldown, err)
+}
+
+// wait blocks while the breaker is open
+func (b *circuitBreaker) wait() {
+	b.Lock()
+	delay := b.openUntil.Sub(time.Now())
+	b.Unlock()
+	if delay <= 0 {
+		return
+	}
+	breakerOpen.Set(1)
+	throttledSeconds.Add(delay.Seconds(), "breaker")
+	time.Sleep(delay)
+	breakerOpen.Set(0)
+}
+
+// recordAPIResult feeds the result of API request made for the resource to the circuit breaker
+func (sr *ScheduledResource) recordAPIResult(err error) {
+	apiBreaker.record(err)
+	if throttlingError(err) {
+		apiErrors.Inc(resourceKind(sr.Key()))
+	}
+}
+
+// sleepBackoff waits for the delay and returns false if done channel is closed before it passes
+func sleepBackoff(delay time.Duration, done <-chan struct{}) bool {
+	throttledSeconds.Add(delay.Seconds(), "backoff")
+	select {
+	case <-done:
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	apierrors "k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/unversioned"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+func statusError(code int32) error {
+	return &apierrors.StatusError{ErrStatus: unversioned.Status{Code: code}}
+}
+
+// TestThrottlingError checks which errors are retried after backoff
+func TestThrottlingError(t *testing.T) {
+	cases := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errors.New("pod is not ready"), false},
+		{statusError(404), false},
+		{statusError(409), false},
+		{statusError(429), true},
+		{statusError(500), true},
+		{statusError(503), true},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{context.DeadlineExceeded, true},
+		{context.Canceled, false},
+	}
+	for _, c := range cases {
+		if throttlingError(c.err) != c.expected {
+			t.Errorf("Expected throttlingError(%v) to be %t", c.err, c.expected)
+		}
+	}
+}
+
+// TestBackoff checks that delays double up to the maximum and start over after reset
+func TestBackoff(t *testing.T) {
+	var b backoff
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+	for _, delay := range expected {
+		if next := b.next(); next != delay {
+			t.Errorf("Expected delay %v, got %v", delay, next)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		b.next()
+	}
+	if next := b.next(); next != BackoffMax {
+		t.Errorf("Expected delay to be capped at %v, got %v", BackoffMax, next)
+	}
+	b.reset()
+	if next := b.next(); next != BackoffInitial {
+		t.Errorf("Expected delay to start over after reset, got %v", next)
+	}
+}
+
+// TestCircuitBreaker checks that consecutive errors open the breaker and successful request resets it
+func TestCircuitBreaker(t *testing.T) {
+	b := &circuitBreaker{}
+	for i := 1; i < BreakerThreshold; i++ {
+		b.record(statusError(503))
+	}
+	if !b.openUntil.IsZero() {
+		t.Fatal("Breaker should not open before threshold is reached")
+	}
+	b.record(errors.New("not a throttling error"))
+	b.record(statusError(429))
+	if !b.openUntil.IsZero() {
+		t.Fatal("Other errors should reset the count")
+	}
+
+	for i := 1; i < BreakerThreshold; i++ {
+		b.record(statusError(429))
+	}
+	if b.cooldown != BreakerCooldown || !b.openUntil.After(time.Now()) {
+		t.Fatalf("Breaker should open for %v, cooldown is %v", BreakerCooldown, b.cooldown)
+	}
+
+	// error after the breaker closes opens it again for longer
+	b.openUntil = time.Now().Add(-time.Second)
+	b.record(statusError(500))
+	if b.cooldown != 2*BreakerCooldown {
+		t.Errorf("Expected cooldown to double, got %v", b.cooldown)
+	}
+
+	b.record(nil)
+	if b.failures != 0 || b.cooldown != 0 {
+		t.Errorf("Successful request should reset the breaker, got %d failures and cooldown %v", b.failures, b.cooldown)
+	}
+}
+
+// flakyResource fails status checks with API server errors before it becomes ready
+type flakyResource struct {
+	*mocks.Resource
+	errors int
+}
+
+func (r *flakyResource) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	if r.errors > 0 {
+		r.errors--
+		return interfaces.NewStatus(interfaces.ResourceError), statusError(503)
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+// TestWaitRetriesAPIErrors checks that API server errors do not fail the resource
+func TestWaitRetriesAPIErrors(t *testing.T) {
+	defer func(initial time.Duration) { BackoffInitial = initial }(BackoffInitial)
+	BackoffInitial = time.Millisecond
+
+	r := &flakyResource{Resource: mocks.NewResource("pod/flaky", interfaces.ResourceReady), errors: 3}
+	sr := NewScheduledResourceFor(report.SimpleReporter{BaseResource: r})
+	if err := sr.Wait(time.Millisecond, time.Second); err != nil {
+		t.Errorf("Resource should become ready after API errors, got %v", err)
+	}
+	if r.errors != 0 {
+		t.Errorf("Expected all errors to be retried, %d left", r.errors)
+	}
+}
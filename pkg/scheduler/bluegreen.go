@@ -0,0 +1,89 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// addBlueGreen adds nodes switching traffic to green workloads, i.e. resources with blue-green meta.
+// Service switch depends on the green workload, and deletion of the blue workload, if requested, depends
+// on the switch. Rollback deleting the green workload is its on-error dependent, so that the traffic
+// is not switched and the failed workload is cleaned up
+func (depGraph DependencyGraph) addBlueGreen(clients *namespacedClients) error {
+	greens := make([]*ScheduledResource, 0, len(depGraph))
+	for _, sr := range depGraph {
+		greens = append(greens, sr)
+	}
+	for _, green := range greens {
+		blueGreen, err := resources.GetBlueGreen(green.Resource)
+		if err != nil {
+			return err
+		}
+		if blueGreen == nil {
+			continue
+		}
+		c := clients.get(green.namespace)
+
+		switchNode, err := depGraph.addSyntheticNode(resources.NewServiceSwitch(blueGreen, c.Services()), green.namespace)
+		if err != nil {
+			return err
+		}
+		switchNode.dependOn(green, nil)
+		green.logger("blue-green").Debugf("Adding %s switching traffic to %s", switchNode.Key(), green.Key())
+
+		rollback, err := depGraph.addSyntheticNode(resources.NewGreenRollback(green.Resource), green.namespace)
+		if err != nil {
+			return err
+		}
+		rollback.dependOn(green, map[string]string{"on-error": "true"})
+
+		if !blueGreen.DeleteBlue {
+			continue
+		}
+		kind, name, err := keyParts(blueGreen.Blue)
+		if err != nil {
+			return err
+		}
+		template, ok := resources.KindToResourceTemplate[kind]
+		if !ok || resources.NonObjectKinds[kind] {
+			return fmt.Errorf("%s of %s: %s is not a workload", resources.BlueGreenKey, green.Key(), blueGreen.Blue)
+		}
+		if _, ok := depGraph[namespacedKey(blueGreen.Blue, green.namespace)]; ok {
+			return fmt.Errorf("%s of %s: blue workload %s must not be a part of the graph", resources.BlueGreenKey, green.Key(), blueGreen.Blue)
+		}
+		deletion, err := depGraph.addSyntheticNode(resources.NewDeletion(template.NewExisting(name, c)), green.namespace)
+		if err != nil {
+			return err
+		}
+		deletion.dependOn(switchNode, nil)
+	}
+	return nil
+}
+
+// addSyntheticNode adds node of the resource which has no definition. Such nodes are not deleted on destroy
+func (depGraph DependencyGraph) addSyntheticNode(r interfaces.Resource, namespace string) (*ScheduledResource, error) {
+	sr := NewScheduledResourceFor(r)
+	sr.namespace = namespace
+	sr.Existing = true
+	if _, ok := depGraph[sr.Key()]; ok {
+		return nil, fmt.Errorf("Resource %s is already a part of the graph", sr.Key())
+	}
+	depGraph[sr.Key()] = sr
+	return sr, nil
+}
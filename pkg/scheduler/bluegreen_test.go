@@ -0,0 +1,101 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/pkg/api/errors"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// blueGreenGraph returns graph with the green pod switching traffic of service frontend from pod ready-blue
+func blueGreenGraph(t *testing.T, c client.Interface, green string) DependencyGraph {
+	meta := map[string]interface{}{
+		resources.BlueGreenKey: map[string]interface{}{
+			"service":    "frontend",
+			"selector":   map[string]interface{}{"version": "green"},
+			"blue":       "pod/ready-blue",
+			"deleteBlue": true,
+		},
+	}
+	sr := NewScheduledResourceFor(resources.NewPod(mocks.MakePod(green), c.Pods(), meta))
+	depGraph := DependencyGraph{sr.Key(): sr}
+	if err := depGraph.addBlueGreen(newNamespacedClients(c)); err != nil {
+		t.Fatal(err)
+	}
+	return depGraph
+}
+
+// TestBlueGreenSwitch checks that traffic is switched once green workload is ready and then blue one is deleted
+func TestBlueGreenSwitch(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeService("frontend"), mocks.MakePod("ready-blue"))
+	depGraph := blueGreenGraph(t, c, "ready-green")
+
+	switchNode := depGraph["switch/service/frontend"]
+	rollback := depGraph["rollback/pod/ready-green"]
+	deletion := depGraph["pod/ready-blue"]
+	if switchNode == nil || rollback == nil || deletion == nil {
+		t.Fatalf("Expected switch, rollback and blue deletion nodes, got %v", depGraph)
+	}
+	if !requires(deletion, switchNode) || !rollback.isOnErrorDependency("pod/ready-green") {
+		t.Fatal("Blue should be deleted after the switch and rollback should be on-error dependency of green")
+	}
+
+	Create(depGraph, 0)
+
+	service, err := c.Services().Get("frontend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(service.Spec.Selector, map[string]string{"version": "green"}) {
+		t.Errorf("Service selector should be switched, got %v", service.Spec.Selector)
+	}
+	if _, err := c.Pods().Get("ready-blue"); !errors.IsNotFound(err) {
+		t.Errorf("Blue pod should be deleted, got %v", err)
+	}
+	if !rollback.Skipped {
+		t.Error("Rollback should be skipped when green is ready")
+	}
+}
+
+// TestBlueGreenRollback checks that failed green workload is deleted while the traffic stays on blue one
+func TestBlueGreenRollback(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeService("frontend"), mocks.MakePod("ready-blue"), mocks.MakePod("crashloop-green"))
+	depGraph := blueGreenGraph(t, c, "crashloop-green")
+
+	Create(depGraph, 0)
+
+	if !depGraph["rollback/pod/crashloop-green"].created {
+		t.Errorf("Rollback should be done, got error %v", depGraph["rollback/pod/crashloop-green"].Error)
+	}
+	if _, err := c.Pods().Get("crashloop-green"); !errors.IsNotFound(err) {
+		t.Errorf("Green pod should be deleted, got %v", err)
+	}
+	service, err := c.Services().Get("frontend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if service.Spec.Selector["frontend"] != "yes" {
+		t.Errorf("Service selector should not be switched, got %v", service.Spec.Selector)
+	}
+	if _, err := c.Pods().Get("ready-blue"); err != nil {
+		t.Errorf("Blue pod should be kept, got %v", err)
+	}
+}
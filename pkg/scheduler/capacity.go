@@ -0,0 +1,215 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/client-go/pkg/api/resource"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/labels"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// capacityResources are resources whose requests are compared with capacity of nodes
+var capacityResources = []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory}
+
+// ResourceCapacity compares requests of one resource, e.g. cpu, with allocatable capacity of nodes
+type ResourceCapacity struct {
+	Resource    string `json:"resource"`
+	Requested   string `json:"requested"`
+	Allocatable string `json:"allocatable"`
+	// LargestPod is the largest request of a single pod, made by pods of LargestPodKey resource
+	LargestPod    string `json:"largestPod"`
+	LargestPodKey string `json:"largestPodKey,omitempty"`
+	// LargestNode is the largest allocatable capacity of a single node
+	LargestNode string `json:"largestNode"`
+}
+
+// CapacityReport compares resources requested by pods of the graph with allocatable capacity of
+// schedulable nodes. Problems are reasons why the pods can never be scheduled
+type CapacityReport struct {
+	Nodes     int                `json:"nodes"`
+	Resources []ResourceCapacity `json:"resources"`
+	Problems  []string           `json:"problems,omitempty"`
+}
+
+// Fits returns true if no problems were found
+func (r CapacityReport) Fits() bool {
+	return len(r.Problems) == 0
+}
+
+// AsText returns a human-readable table of requested and allocatable resources followed by problems
+func (r CapacityReport) AsText() []string {
+	rows := [][]string{{"RESOURCE", "REQUESTED", "ALLOCATABLE", "LARGEST POD", "LARGEST NODE"}}
+	for _, capacity := range r.Resources {
+		largestPod := capacity.LargestPod
+		if capacity.LargestPodKey != "" {
+			largestPod = fmt.Sprintf("%s (%s)", largestPod, capacity.LargestPodKey)
+		}
+		rows = append(rows, []string{capacity.Resource, capacity.Requested, capacity.Allocatable, largestPod, capacity.LargestNode})
+	}
+	ret := append([]string{fmt.Sprintf("Schedulable nodes: %d", r.Nodes)}, formatTable(rows)...)
+	return append(ret, r.Problems...)
+}
+
+// podRequests returns requests of the pod spec in milli-units. Containers without requests of a
+// resource request their limit, as API server defaults requests to limits
+func podRequests(spec *v1.PodSpec) map[v1.ResourceName]int64 {
+	requests := map[v1.ResourceName]int64{}
+	for _, container := range spec.Containers {
+		for _, name := range capacityResources {
+			quantity, ok := container.Resources.Requests[name]
+			if !ok {
+				quantity, ok = container.Resources.Limits[name]
+			}
+			if ok {
+				requests[name] += quantity.MilliValue()
+			}
+		}
+	}
+	return requests
+}
+
+// definitionPods returns spec of pods of the resource definition and number of them running at once.
+// Daemon sets run a pod on every node matching their node selector
+func definitionPods(r client.ResourceDefinition, nodes []v1.Node) (*v1.PodSpec, int64) {
+	spec := definitionPodSpec(r)
+	if spec == nil {
+		return nil, 0
+	}
+	replicas := func(value *int32) int64 {
+		if value == nil {
+			return 1
+		}
+		return int64(*value)
+	}
+	switch {
+	case r.Job != nil:
+		count := replicas(r.Job.Spec.Parallelism)
+		if r.Job.Spec.Completions != nil && int64(*r.Job.Spec.Completions) < count {
+			count = int64(*r.Job.Spec.Completions)
+		}
+		return spec, count
+	case r.ReplicaSet != nil:
+		return spec, replicas(r.ReplicaSet.Spec.Replicas)
+	case r.StatefulSet != nil:
+		return spec, replicas(r.StatefulSet.Spec.Replicas)
+	case r.PetSet != nil:
+		return spec, replicas(r.PetSet.Spec.Replicas)
+	case r.Deployment != nil:
+		return spec, replicas(r.Deployment.Spec.Replicas)
+	case r.DaemonSet != nil:
+		selector := labels.SelectorFromSet(labels.Set(spec.NodeSelector))
+		var count int64
+		for _, node := range nodes {
+			if selector.Matches(labels.Set(node.Labels)) {
+				count++
+			}
+		}
+		return spec, count
+	}
+	return spec, 1
+}
+
+// formatQuantity formats amount of the resource given in milli-units
+func formatQuantity(name v1.ResourceName, milli int64) string {
+	if name == v1.ResourceCPU {
+		return resource.NewMilliQuantity(milli, resource.DecimalSI).String()
+	}
+	return resource.NewQuantity(milli/1000, resource.BinarySI).String()
+}
+
+// CheckCapacity sums resources requested by pods of all workloads of the graph and compares them with
+// allocatable capacity of schedulable nodes matching the selector. Workloads to be deleted and objects
+// without definitions are not counted. Requests of objects which already exist are counted as well, as
+// their pods occupy the nodes anyway
+func CheckCapacity(c client.Interface, depGraph DependencyGraph, nodeSelector labels.Selector) (CapacityReport, error) {
+	nodeList, err := c.Nodes().List(v1.ListOptions{LabelSelector: nodeSelector.String()})
+	if err != nil {
+		return CapacityReport{}, err
+	}
+	var nodes []v1.Node
+	allocatable := map[v1.ResourceName]int64{}
+	largestNode := map[v1.ResourceName]int64{}
+	for _, node := range nodeList.Items {
+		if node.Spec.Unschedulable {
+			continue
+		}
+		nodes = append(nodes, node)
+		capacity := node.Status.Allocatable
+		if len(capacity) == 0 {
+			capacity = node.Status.Capacity
+		}
+		for _, name := range capacityResources {
+			quantity := capacity[name]
+			allocatable[name] += quantity.MilliValue()
+			if quantity.MilliValue() > largestNode[name] {
+				largestNode[name] = quantity.MilliValue()
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(depGraph))
+	for key := range depGraph {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	requested := map[v1.ResourceName]int64{}
+	largestPod := map[v1.ResourceName]int64{}
+	largestPodKey := map[v1.ResourceName]string{}
+	for _, key := range keys {
+		sr := depGraph[key]
+		if _, ok := sr.Resource.(resources.Deletion); ok || sr.resDef == nil {
+			continue
+		}
+		spec, count := definitionPods(*sr.resDef, nodes)
+		if spec == nil {
+			continue
+		}
+		for name, amount := range podRequests(spec) {
+			requested[name] += amount * count
+			if count > 0 && amount > largestPod[name] {
+				largestPod[name] = amount
+				largestPodKey[name] = key
+			}
+		}
+	}
+
+	report := CapacityReport{Nodes: len(nodes)}
+	for _, name := range capacityResources {
+		report.Resources = append(report.Resources, ResourceCapacity{
+			Resource:      string(name),
+			Requested:     formatQuantity(name, requested[name]),
+			Allocatable:   formatQuantity(name, allocatable[name]),
+			LargestPod:    formatQuantity(name, largestPod[name]),
+			LargestPodKey: largestPodKey[name],
+			LargestNode:   formatQuantity(name, largestNode[name]),
+		})
+		if requested[name] > allocatable[name] {
+			report.Problems = append(report.Problems, fmt.Sprintf("Pods of the graph request %s of %s, but nodes have only %s allocatable",
+				formatQuantity(name, requested[name]), name, formatQuantity(name, allocatable[name])))
+		}
+		if largestPod[name] > largestNode[name] {
+			report.Problems = append(report.Problems, fmt.Sprintf("Pods of %s request %s of %s, but the largest node has only %s allocatable",
+				largestPodKey[name], formatQuantity(name, largestPod[name]), name, formatQuantity(name, largestNode[name])))
+		}
+	}
+	return report, nil
+}
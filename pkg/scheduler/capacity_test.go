@@ -0,0 +1,114 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/pkg/api/resource"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/labels"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+func capacityNode(name, cpu, memory string, unschedulable bool) *v1.Node {
+	node := &v1.Node{}
+	node.Name = name
+	node.Spec.Unschedulable = unschedulable
+	node.Status.Allocatable = v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu), v1.ResourceMemory: resource.MustParse(memory)}
+	return node
+}
+
+func requestingContainers(cpu, memory string) []v1.Container {
+	return []v1.Container{{
+		Name: "main",
+		Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)},
+			Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse(memory)},
+		},
+	}}
+}
+
+// capacityGraph returns graph of a deployment with given number of replicas and a pod
+func capacityGraph(t *testing.T, c client.Interface, replicas int32, podCPU string) DependencyGraph {
+	deployment := mocks.MakeDeployment("ready-1")
+	deployment.Spec.Replicas = &replicas
+	deployment.Spec.Template.Spec.Containers = requestingContainers("500m", "1Gi")
+	pod := mocks.MakePod("ready-1")
+	pod.Spec.Containers = requestingContainers(podCPU, "512Mi")
+	resDefs := []client.ResourceDefinition{{Deployment: deployment}, {Pod: pod}}
+
+	depGraph := DependencyGraph{}
+	for _, r := range resDefs {
+		resource, err := newResourceFromDefinition(r, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		depGraph[resource.Key()] = NewScheduledResourceFor(resource)
+	}
+	depGraph.withDefinitions(resDefs)
+	return depGraph
+}
+
+// TestCheckCapacity checks that requests of all replicas are compared with allocatable capacity of
+// schedulable nodes
+func TestCheckCapacity(t *testing.T) {
+	c := mocks.NewClient(capacityNode("node-1", "2", "4Gi", false), capacityNode("node-2", "2", "4Gi", false), capacityNode("cordoned", "8", "16Gi", true))
+
+	report, err := CheckCapacity(c, capacityGraph(t, c, 3, "1"), labels.Everything())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Fits() || report.Nodes != 2 {
+		t.Errorf("Graph should fit on 2 nodes, got %v", report)
+	}
+	if cpu := report.Resources[0]; cpu.Requested != "2500m" || cpu.Allocatable != "4" || cpu.LargestPodKey != "pod/ready-1" {
+		t.Errorf("Unexpected cpu capacity %v", cpu)
+	}
+	if memory := report.Resources[1]; memory.Requested != "3584Mi" || memory.Allocatable != "8Gi" {
+		t.Errorf("Unexpected memory capacity %v", memory)
+	}
+
+	report, err = CheckCapacity(c, capacityGraph(t, c, 8, "3"), labels.Everything())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Problems) != 3 {
+		t.Fatalf("Expected 3 problems, got %v", report.Problems)
+	}
+	if !strings.Contains(report.Problems[0], "request 7 of cpu") || !strings.Contains(report.Problems[1], "Pods of pod/ready-1 request 3 of cpu") ||
+		!strings.Contains(report.Problems[2], "request 8704Mi of memory") {
+		t.Errorf("Unexpected problems %v", report.Problems)
+	}
+}
+
+// TestCheckCapacityDeletion checks that resources being deleted are not counted
+func TestCheckCapacityDeletion(t *testing.T) {
+	c := mocks.NewClient(capacityNode("node-1", "1", "1Gi", false))
+	depGraph := capacityGraph(t, c, 4, "1")
+	depGraph["deployment/ready-1"].Resource = resources.NewDeletion(depGraph["deployment/ready-1"].Resource)
+
+	report, err := CheckCapacity(c, depGraph, labels.Everything())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Fits() {
+		t.Errorf("Only the pod should be counted, got %v", report.Problems)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/client-go/pkg/api/v1"
+	appsbeta1 "k8s.io/client-go/pkg/apis/apps/v1beta1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/client/petsets/apis/apps/v1alpha1"
+)
+
+// ConditionKey is a key of resource definition and dependency meta holding comma-separated list of
+// conditions which must all be met for the resource or dependency to be a part of the graph.
+// Condition can be negated with "!" prefix, e.g. "!statefulsets-enabled"
+const ConditionKey = "if"
+
+// serverVersionCondition is a prefix of conditions met by API servers of given or newer version, e.g.
+// "server-version-1.6"
+const serverVersionCondition = "server-version-"
+
+// Condition checks whether the cluster has some capability
+type Condition func(c client.Interface) (bool, error)
+
+// Conditions is a map of known conditions by their names
+var Conditions = map[string]Condition{
+	"statefulsets-enabled": func(c client.Interface) (bool, error) {
+		return c.ResourceExists(appsbeta1.SchemeGroupVersion.WithKind("StatefulSet")), nil
+	},
+	"petsets-enabled": func(c client.Interface) (bool, error) {
+		return c.ResourceExists(v1alpha1.SchemeGroupVersion.WithKind("PetSet")), nil
+	},
+	"gpu-nodes-present": gpuNodesPresent,
+}
+
+func gpuNodesPresent(c client.Interface) (bool, error) {
+	nodes, err := c.Nodes().List(v1.ListOptions{})
+	if err != nil {
+		return false, err
+	}
+	for _, node := range nodes.Items {
+		if gpus, ok := node.Status.Capacity[v1.ResourceNvidiaGPU]; ok && !gpus.IsZero() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// serverAtLeast returns condition met by API servers of version given as "major.minor" or newer
+func serverAtLeast(version string) (Condition, bool) {
+	parts := strings.Split(version, ".")
+	if len(parts) != 2 {
+		return nil, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, false
+	}
+	return func(c client.Interface) (bool, error) {
+		return c.ServerAtLeast(major, minor), nil
+	}, true
+}
+
+// conditionEvaluator evaluates conditions against the cluster, each condition is checked at most once
+type conditionEvaluator struct {
+	client client.Interface
+	cache  map[string]bool
+}
+
+func newConditionEvaluator(c client.Interface) *conditionEvaluator {
+	return &conditionEvaluator{client: c, cache: map[string]bool{}}
+}
+
+// matches returns true if all conditions from ConditionKey meta value are met. Empty value always matches
+func (e *conditionEvaluator) matches(value interface{}) (bool, error) {
+	if value == nil {
+		return true, nil
+	}
+	expression, ok := value.(string)
+	if !ok {
+		return false, fmt.Errorf("condition must be a string, got %v", value)
+	}
+	for _, name := range strings.Split(expression, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		negate := strings.HasPrefix(name, "!")
+		name = strings.TrimPrefix(name, "!")
+
+		result, err := e.evaluate(name)
+		if err != nil {
+			return false, err
+		}
+		if result == negate {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (e *conditionEvaluator) evaluate(name string) (bool, error) {
+	if result, ok := e.cache[name]; ok {
+		return result, nil
+	}
+	condition, ok := Conditions[name]
+	if !ok && strings.HasPrefix(name, serverVersionCondition) {
+		condition, ok = serverAtLeast(strings.TrimPrefix(name, serverVersionCondition))
+	}
+	if !ok {
+		return false, fmt.Errorf("unknown condition '%s'", name)
+	}
+	result, err := condition(e.client)
+	if err != nil {
+		return false, fmt.Errorf("could not evaluate condition '%s': %v", name, err)
+	}
+	e.cache[name] = result
+	return result, nil
+}
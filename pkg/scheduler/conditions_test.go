@@ -0,0 +1,97 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+
+	"k8s.io/client-go/pkg/api/resource"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestConditionMatches checks evaluation of conditions against API versions of the cluster
+func TestConditionMatches(t *testing.T) {
+	evaluator := newConditionEvaluator(mocks.NewClient())
+	cases := map[string]bool{
+		"":                                       true,
+		"statefulsets-enabled":                   true,
+		"!statefulsets-enabled":                  false,
+		"petsets-enabled":                        false,
+		"statefulsets-enabled, !petsets-enabled": true,
+		"server-version-1.4":                     true,
+		"server-version-1.5":                     true,
+		"server-version-1.6":                     false,
+		"!server-version-2.0":                    true,
+	}
+	for expression, expected := range cases {
+		result, err := evaluator.matches(expression)
+		if err != nil {
+			t.Error(err)
+		}
+		if result != expected {
+			t.Errorf("Condition `%s` evaluated to %t, expected %t", expression, result, expected)
+		}
+	}
+
+	for _, unknown := range []string{"unknown", "server-version-1", "server-version-x.5"} {
+		if _, err := evaluator.matches(unknown); err == nil {
+			t.Errorf("Unknown condition `%s` should result in error", unknown)
+		}
+	}
+}
+
+// TestGPUNodesPresent checks detection of nodes with GPUs
+func TestGPUNodesPresent(t *testing.T) {
+	node := &v1.Node{}
+	node.Name = "gpu-node"
+	node.Status.Capacity = v1.ResourceList{v1.ResourceNvidiaGPU: resource.MustParse("2")}
+
+	result, err := gpuNodesPresent(mocks.NewClient(node))
+	if err != nil {
+		t.Error(err)
+	}
+	if !result {
+		t.Error("GPU node should be found")
+	}
+
+	result, err = gpuNodesPresent(mocks.NewClient())
+	if err != nil {
+		t.Error(err)
+	}
+	if result {
+		t.Error("GPU node should not be found")
+	}
+}
+
+// TestConditionalDependency checks that dependency with unmet condition is not a part of the graph
+func TestConditionalDependency(t *testing.T) {
+	c := mocks.NewClient1_4()
+	c.ResDefs = mocks.NewResourceDefinitionClient("pod/ready-1", "pod/ready-2")
+	c.Deps = mocks.NewDependencyClient(
+		mocks.Dependency{Parent: "pod/ready-1", Child: "pod/ready-2", Meta: map[string]string{ConditionKey: "statefulsets-enabled"}})
+
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(depGraph) != 2 {
+		t.Errorf("Wrong length of dependency graph, expected %d, actual %d", 2, len(depGraph))
+	}
+	if len(depGraph["pod/ready-2"].Requires) != 0 {
+		t.Error("Dependency with unmet condition should be skipped")
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+// RequestTimeout limits a single call of resource methods talking to the cluster, such as status check
+// or creation of the object. Calls which time out are retried like API server errors. 0 means calls are
+// limited only by deadlines of resources
+var RequestTimeout = 30 * time.Second
+
+// ErrRunCancelled is an error of resources which were not created because the run was cancelled
+var ErrRunCancelled = errors.New("run cancelled")
+
+// Cancel stops the run of the graph in progress: calls to the cluster made by its resources are
+// cancelled, and resources which are not created yet are not created. Resources created by the run are
+// deleted if rollback is enabled
+func (depGraph DependencyGraph) Cancel() {
+	run := depGraph.lastRun()
+	if run == nil {
+		return
+	}
+	run.Lock()
+	defer run.Unlock()
+	if run.finished {
+		return
+	}
+	if run.abortErr == nil {
+		run.abortErr = ErrRunCancelled
+	}
+	run.cancel()
+}
+
+// context returns context of the run, which is done once the run is cancelled
+func (r *runState) context() context.Context {
+	if r == nil {
+		return context.Background()
+	}
+	r.RLock()
+	defer r.RUnlock()
+	return r.ctx
+}
+
+// finish marks the run finished, so that it can not be cancelled anymore
+func (r *runState) finish() {
+	if r == nil {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	r.finished = true
+	r.end = time.Now()
+}
+
+// newRequestContext returns context of a single call of resource methods within the run. It is done when
+// the run is cancelled, the deadline passes or RequestTimeout elapses
+func newRequestContext(run *runState, deadline time.Time) (context.Context, context.CancelFunc) {
+	ctx := run.context()
+	if RequestTimeout > 0 {
+		if timeout := time.Now().Add(RequestTimeout); deadline.IsZero() || timeout.Before(deadline) {
+			deadline = timeout
+		}
+	}
+	if deadline.IsZero() {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline)
+}
+
+// requestContext returns context of a single call of methods of the resource within the current run
+func (sr *ScheduledResource) requestContext() (context.Context, context.CancelFunc) {
+	sr.RLock()
+	defer sr.RUnlock()
+	return newRequestContext(sr.run, sr.deadline)
+}
+
+// callWithContext runs the call and returns its error, or error of the context if it is done first.
+// Requests of the vendored client-go can not be interrupted, so the call is left to finish in background
+// and its result is discarded, but the caller does not hang on it. Resources check the context between
+// their requests, and the next call waits until the abandoned one returns
+func (sr *ScheduledResource) callWithContext(ctx context.Context, call func(context.Context) error) error {
+	if err := sr.waitForAbandonedCall(ctx); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- call(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		sr.Lock()
+		sr.abandoned = done
+		sr.Unlock()
+		return ctx.Err()
+	}
+}
+
+// waitForAbandonedCall waits until the last call which timed out returns, or the context is done
+func (sr *ScheduledResource) waitForAbandonedCall(ctx context.Context) error {
+	sr.RLock()
+	abandoned := sr.abandoned
+	sr.RUnlock()
+	if abandoned == nil {
+		return nil
+	}
+	select {
+	case err := <-abandoned:
+		if err != nil {
+			sr.logger("create").Debugf("Abandoned call of %s returned: %v", sr.Key(), err)
+		}
+		sr.Lock()
+		sr.abandoned = nil
+		sr.Unlock()
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// statusWithContext checks status of the resource like callWithContext
+func statusWithContext(ctx context.Context, r interfaces.BaseResource, meta map[string]string) (interfaces.ResourceStatus, error) {
+	type result struct {
+		status interfaces.ResourceStatus
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		status, err := r.Status(ctx, meta)
+		done <- result{status, err}
+	}()
+	select {
+	case res := <-done:
+		return res.status, res.err
+	case <-ctx.Done():
+		return interfaces.NewStatus(interfaces.ResourceError), ctx.Err()
+	}
+}
+
+// Create creates the object of the resource, the call is cancelled along with the run
+func (sr *ScheduledResource) Create() error {
+	ctx, cancel := sr.requestContext()
+	defer cancel()
+	return sr.callWithContext(ctx, sr.Resource.Create)
+}
+
+// Delete deletes the object of the resource, the call is cancelled along with the run
+func (sr *ScheduledResource) Delete() error {
+	ctx, cancel := sr.requestContext()
+	defer cancel()
+	return sr.callWithContext(ctx, sr.Resource.Delete)
+}
+
+// Update updates the object of the resource, the call is cancelled along with the run
+func (sr *ScheduledResource) Update() error {
+	ctx, cancel := sr.requestContext()
+	defer cancel()
+	return sr.callWithContext(ctx, sr.Resource.Update)
+}
+
+// GetDependencyReport returns dependency report of the resource, or error report if it could not be
+// retrieved in time. Resource which is ready blocks its dependents until its readiness probe passes
+func (sr *ScheduledResource) GetDependencyReport(meta map[string]string) interfaces.DependencyReport {
+	ctx, cancel := sr.requestContext()
+	defer cancel()
+	done := make(chan interfaces.DependencyReport, 1)
+	go func() {
+		done <- sr.Resource.GetDependencyReport(ctx, meta)
+	}()
+	select {
+	case depReport := <-done:
+		sr.RLock()
+		depReport.Logs = sr.failureLogs
+		sr.RUnlock()
+		if depReport.Blocks || sr.probe == nil {
+			return sr.stableReport(depReport)
+		}
+		status, err := sr.probe.Status(ctx)
+		if err != nil {
+			return report.ErrorReport(sr.Key(), err)
+		}
+		if !status.IsReady() {
+			depReport.Blocks = true
+			depReport.Percentage = 0
+			depReport.Message = report.StatusMessage(status)
+		}
+		return sr.stableReport(depReport)
+	case <-ctx.Done():
+		return report.ErrorReport(sr.Key(), ctx.Err())
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+// stuckResource ignores context and does not return from status checks or creation until released,
+// like a request to unresponsive API server
+type stuckResource struct {
+	*mocks.Resource
+	stuckStatus bool
+	started     chan struct{}
+	release     chan struct{}
+}
+
+func newStuckResource(key string, stuckStatus bool) *stuckResource {
+	return &stuckResource{
+		Resource:    mocks.NewResource(key, interfaces.ResourceReady),
+		stuckStatus: stuckStatus,
+		started:     make(chan struct{}, 1),
+		release:     make(chan struct{}),
+	}
+}
+
+func (r *stuckResource) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	if r.stuckStatus {
+		<-r.release
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+func (r *stuckResource) Create(ctx context.Context) error {
+	r.started <- struct{}{}
+	<-r.release
+	return nil
+}
+
+// TestRequestTimeout checks that status check which does not return within RequestTimeout fails with
+// error retried like API server errors
+func TestRequestTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { RequestTimeout = timeout }(RequestTimeout)
+	RequestTimeout = 10 * time.Millisecond
+
+	r := newStuckResource("pod/stuck", true)
+	defer close(r.release)
+	sr := NewScheduledResourceFor(report.SimpleReporter{BaseResource: r})
+
+	_, err := sr.Status(nil)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected status check to time out, got %v", err)
+	}
+	if !throttlingError(err) {
+		t.Error("Timed out request should be retried")
+	}
+	if depReport := sr.GetDependencyReport(nil); !depReport.Blocks {
+		t.Errorf("Report of resource with timed out status check should block, got %+v", depReport)
+	}
+}
+
+// TestRequestContextDeadline checks that request context ends at the deadline of the resource if it is
+// earlier than RequestTimeout
+func TestRequestContextDeadline(t *testing.T) {
+	deadline := time.Now().Add(time.Second)
+	ctx, cancel := newRequestContext(nil, deadline)
+	defer cancel()
+	if d, ok := ctx.Deadline(); !ok || !d.Equal(deadline) {
+		t.Errorf("Expected context deadline %v, got %v", deadline, d)
+	}
+
+	ctx, cancel = newRequestContext(nil, time.Now().Add(2*RequestTimeout))
+	defer cancel()
+	if d, ok := ctx.Deadline(); !ok || d.After(time.Now().Add(RequestTimeout)) {
+		t.Errorf("Expected context deadline to be limited by request timeout, got %v", d)
+	}
+}
+
+// TestCancel checks that cancelling the run interrupts creation in progress and prevents creation of
+// resources depending on it
+func TestCancel(t *testing.T) {
+	r := newStuckResource("pod/stuck", false)
+	defer close(r.release)
+	stuck := NewScheduledResourceFor(report.SimpleReporter{BaseResource: r})
+	child := newPolicyResource("pod/child", nil, false)
+	dependOn(child, stuck)
+	depGraph := DependencyGraph{"pod/stuck": stuck, "pod/child": child}
+
+	done := make(chan struct{})
+	go func() {
+		Create(depGraph, 0)
+		close(done)
+	}()
+
+	<-r.started
+	depGraph.Cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Cancelled run did not finish")
+	}
+
+	if err := depGraph.Aborted(); err != ErrRunCancelled {
+		t.Errorf("Expected run to be cancelled, got %v", err)
+	}
+	if !stuck.failed {
+		t.Error("Resource being created should fail")
+	}
+	if child.created {
+		t.Error("Dependent resource should not be created")
+	}
+}
+
+// TestCancelFinished checks that finished run can not be cancelled
+func TestCancelFinished(t *testing.T) {
+	depGraph := DependencyGraph{"pod/ready": newPolicyResource("pod/ready", nil, false)}
+	Create(depGraph, 0)
+	depGraph.Cancel()
+	if err := depGraph.Aborted(); err != nil {
+		t.Errorf("Finished run should not be cancelled, got %v", err)
+	}
+	if err := depGraph.lastRun().context().Err(); err != nil {
+		t.Errorf("Context of finished run should not be done, got %v", err)
+	}
+}
+
+// TestAbandonedCall checks that creation which timed out is not retried until the abandoned call returns
+func TestAbandonedCall(t *testing.T) {
+	defer func(timeout time.Duration) { RequestTimeout = timeout }(RequestTimeout)
+	RequestTimeout = 10 * time.Millisecond
+
+	r := newStuckResource("pod/stuck", false)
+	sr := NewScheduledResourceFor(report.SimpleReporter{BaseResource: r})
+
+	if err := sr.Create(); err != context.DeadlineExceeded {
+		t.Fatalf("Expected creation to time out, got %v", err)
+	}
+	<-r.started
+	if err := sr.Create(); err != context.DeadlineExceeded {
+		t.Errorf("Expected retry to wait for abandoned call, got %v", err)
+	}
+	if len(r.started) != 0 {
+		t.Error("Creation must not be retried while abandoned call is in progress")
+	}
+
+	close(r.release)
+	if err := sr.Create(); err != nil {
+		t.Errorf("Expected creation to be retried once abandoned call returned, got %v", err)
+	}
+	if len(r.started) != 1 {
+		t.Error("Expected creation to be retried")
+	}
+}
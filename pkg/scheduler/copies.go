@@ -0,0 +1,130 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+)
+
+// CopiesKey is a meta key of resource definition with number of copies of the resource to deploy
+const CopiesKey = "copies"
+
+// IndexPlaceholder is replaced by index of the copy, starting from 0, in every string of resource
+// definition with copies and in keys of dependencies
+const IndexPlaceholder = "$(index)"
+
+// definitionCopies returns number of copies set in meta of resource definition, or 0 if it is not set
+func definitionCopies(r client.ResourceDefinition) (int, error) {
+	value, ok := r.Meta[CopiesKey]
+	if !ok {
+		return 0, nil
+	}
+	copies, ok := value.(float64)
+	if !ok || copies < 1 || copies != float64(int(copies)) {
+		return 0, fmt.Errorf("%s of resource definition %s must be a positive integer, got %v", CopiesKey, r.Name, value)
+	}
+	return int(copies), nil
+}
+
+// copyDefinition returns copy of resource definition with given index substituted for IndexPlaceholder
+func copyDefinition(r client.ResourceDefinition, index int) (client.ResourceDefinition, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return r, err
+	}
+	data = []byte(strings.Replace(string(data), IndexPlaceholder, strconv.Itoa(index), -1))
+	var result client.ResourceDefinition
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+// ExpandCopies replaces resource definitions with copies meta by given number of copies, and
+// dependencies referring to them by dependencies of every copy. Keys of copies must contain
+// IndexPlaceholder, so that they are unique. A dependency between two resources with copies connects
+// copies with the same index, so they must have the same number of copies. Dependency on a resource
+// with copies makes the child depend on all its copies, and dependency of a resource with copies on a
+// resource without them is shared by all copies
+func ExpandCopies(resDefs []client.ResourceDefinition, deps []client.Dependency) ([]client.ResourceDefinition, []client.Dependency, error) {
+	// copies is number of copies by key with placeholder
+	copies := map[string]int{}
+	var expandedDefs []client.ResourceDefinition
+	for _, r := range resDefs {
+		n, err := definitionCopies(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, err := definitionKey(r)
+		if err != nil {
+			// invalid definitions are reported by their users
+			expandedDefs = append(expandedDefs, r)
+			continue
+		}
+		hasPlaceholder := strings.Contains(key, IndexPlaceholder)
+		if n == 0 {
+			if hasPlaceholder {
+				return nil, nil, fmt.Errorf("Resource %s has %s in its name, but no %s in meta", key, IndexPlaceholder, CopiesKey)
+			}
+			expandedDefs = append(expandedDefs, r)
+			continue
+		}
+		if !hasPlaceholder {
+			return nil, nil, fmt.Errorf("Resource %s has %d copies, but no %s in its name", key, n, IndexPlaceholder)
+		}
+		copies[key] = n
+		for i := 0; i < n; i++ {
+			copied, err := copyDefinition(r, i)
+			if err != nil {
+				return nil, nil, err
+			}
+			expandedDefs = append(expandedDefs, copied)
+		}
+	}
+
+	var expandedDeps []client.Dependency
+	for _, d := range deps {
+		n := 0
+		for _, key := range []string{d.Parent, d.Child} {
+			if !strings.Contains(key, IndexPlaceholder) {
+				continue
+			}
+			keyCopies, ok := copies[key]
+			if !ok {
+				return nil, nil, fmt.Errorf("Dependency %s refers to %s, but there is no resource definition with copies for it", d.Name, key)
+			}
+			if n != 0 && n != keyCopies {
+				return nil, nil, fmt.Errorf("Dependency %s connects resources with different number of copies", d.Name)
+			}
+			n = keyCopies
+		}
+		if n == 0 {
+			expandedDeps = append(expandedDeps, d)
+			continue
+		}
+		for i := 0; i < n; i++ {
+			index := strconv.Itoa(i)
+			copied := d
+			copied.Name = fmt.Sprintf("%s-%d", d.Name, i)
+			copied.Parent = strings.Replace(d.Parent, IndexPlaceholder, index, -1)
+			copied.Child = strings.Replace(d.Child, IndexPlaceholder, index, -1)
+			expandedDeps = append(expandedDeps, copied)
+		}
+	}
+	return expandedDefs, expandedDeps, nil
+}
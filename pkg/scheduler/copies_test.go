@@ -0,0 +1,106 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// jobCopies returns definition of job with given number of copies
+func jobCopies(name string, copies float64) client.ResourceDefinition {
+	job := mocks.MakeJob(name)
+	job.Labels = map[string]string{"shard": "$(index)"}
+	return client.ResourceDefinition{Job: job, Meta: map[string]interface{}{CopiesKey: copies}}
+}
+
+// TestExpandCopies checks that copies get their index and are wired to shared parents and children
+func TestExpandCopies(t *testing.T) {
+	resDefs := []client.ResourceDefinition{
+		{Pod: mocks.MakePod("db")},
+		jobCopies("shard-$(index)", 3),
+		jobCopies("check-$(index)", 3),
+		{Pod: mocks.MakePod("report")},
+	}
+	deps := []client.Dependency{
+		dependency("pod/db", "job/shard-$(index)"),
+		dependency("job/shard-$(index)", "job/check-$(index)"),
+		dependency("job/check-$(index)", "pod/report"),
+	}
+
+	expandedDefs, expandedDeps, err := ExpandCopies(resDefs, deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	for _, r := range expandedDefs {
+		key, err := definitionKey(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	expectedKeys := []string{
+		"job/check-0", "job/check-1", "job/check-2", "job/shard-0", "job/shard-1", "job/shard-2", "pod/db", "pod/report",
+	}
+	if !reflect.DeepEqual(keys, expectedKeys) {
+		t.Errorf("Expected definitions %v, got %v", expectedKeys, keys)
+	}
+	if shard := expandedDefs[2].Job; shard.Labels["shard"] != "1" {
+		t.Errorf("Expected index in labels of %s, got %v", shard.Name, shard.Labels)
+	}
+
+	var edges []string
+	for _, d := range expandedDeps {
+		edges = append(edges, d.Parent+" -> "+d.Child)
+	}
+	sort.Strings(edges)
+	expectedEdges := []string{
+		"job/check-0 -> pod/report", "job/check-1 -> pod/report", "job/check-2 -> pod/report",
+		"job/shard-0 -> job/check-0", "job/shard-1 -> job/check-1", "job/shard-2 -> job/check-2",
+		"pod/db -> job/shard-0", "pod/db -> job/shard-1", "pod/db -> job/shard-2",
+	}
+	if !reflect.DeepEqual(edges, expectedEdges) {
+		t.Errorf("Expected dependencies %v, got %v", expectedEdges, edges)
+	}
+}
+
+// TestExpandCopiesErrors checks that ambiguous copies are rejected
+func TestExpandCopiesErrors(t *testing.T) {
+	cases := map[string]struct {
+		resDefs []client.ResourceDefinition
+		deps    []client.Dependency
+	}{
+		"no copies":        {[]client.ResourceDefinition{{Pod: mocks.MakePod("shard-$(index)")}}, nil},
+		"no placeholder":   {[]client.ResourceDefinition{jobCopies("shard", 2)}, nil},
+		"invalid copies":   {[]client.ResourceDefinition{jobCopies("shard-$(index)", 0.5)}, nil},
+		"undefined copies": {nil, []client.Dependency{dependency("pod/db", "job/shard-$(index)")}},
+		"different numbers": {
+			[]client.ResourceDefinition{jobCopies("a-$(index)", 2), jobCopies("b-$(index)", 3)},
+			[]client.Dependency{dependency("job/a-$(index)", "job/b-$(index)")},
+		},
+	}
+	for name, c := range cases {
+		if _, _, err := ExpandCopies(c.resDefs, c.deps); err == nil {
+			t.Errorf("Error expected for %s", name)
+		}
+	}
+}
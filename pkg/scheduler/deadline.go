@@ -0,0 +1,154 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// DeadlineKey is a meta key of resource definition with time in seconds since the start of the graph
+// run within which the resource must become ready
+const DeadlineKey = "deadline"
+
+// DeadlineExceededError is an error of resource which was not ready before its deadline
+type DeadlineExceededError struct {
+	Key      string
+	Deadline time.Time
+}
+
+func (e DeadlineExceededError) Error() string {
+	return fmt.Sprintf("deadline of %s exceeded at %s", e.Key, e.Deadline.Format(time.RFC3339))
+}
+
+// IsDeadlineExceeded returns true if the error is caused by exceeded deadline
+func IsDeadlineExceeded(err error) bool {
+	_, ok := err.(DeadlineExceededError)
+	return ok
+}
+
+// WithDeadline sets deadline of the whole graph run: resources which are not ready within timeout
+// after the run starts fail, as well as resources depending on them. Zero timeout means no deadline.
+// Deadlines of resources set in their meta are used if they are shorter
+func (depGraph DependencyGraph) WithDeadline(timeout time.Duration) {
+	for _, sr := range depGraph {
+		sr.runTimeout = timeout
+	}
+}
+
+// setDeadlines computes deadlines of graph resources for the run started at given time
+func (depGraph DependencyGraph) setDeadlines(start time.Time) {
+	for _, sr := range depGraph {
+		timeout := sr.runTimeout
+		if seconds := resources.GetIntMeta(sr.Resource, DeadlineKey, 0); seconds > 0 {
+			nodeTimeout := time.Duration(seconds) * time.Second
+			if timeout == 0 || nodeTimeout < timeout {
+				timeout = nodeTimeout
+			}
+		}
+		sr.Lock()
+		sr.deadline = time.Time{}
+		if timeout > 0 {
+			sr.deadline = start.Add(timeout)
+		}
+		sr.Unlock()
+	}
+}
+
+// deadlineExceeded returns error if deadline of the resource has passed, nil otherwise
+func (sr *ScheduledResource) deadlineExceeded() error {
+	sr.RLock()
+	deadline := sr.deadline
+	sr.RUnlock()
+	if deadline.IsZero() || time.Now().Before(deadline) {
+		return nil
+	}
+	return DeadlineExceededError{Key: sr.Key(), Deadline: deadline}
+}
+
+// untilDeadline returns timeout shortened to the time left until deadline of the resource
+func (sr *ScheduledResource) untilDeadline(timeout time.Duration) time.Duration {
+	sr.RLock()
+	deadline := sr.deadline
+	sr.RUnlock()
+	if deadline.IsZero() {
+		return timeout
+	}
+	if left := deadline.Sub(time.Now()); left < timeout {
+		return left
+	}
+	return timeout
+}
+
+// Failed returns sorted keys of resources which failed in the last run of the graph
+func (depGraph DependencyGraph) Failed() []string {
+	var keys []string
+	for key, sr := range depGraph {
+		sr.RLock()
+		failed := sr.failed
+		sr.RUnlock()
+		if failed {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// UnreadyReport returns reports of resources which were neither created nor skipped in the last run,
+// sorted by key. Reports show dependencies which block the resources
+func (depGraph DependencyGraph) UnreadyReport() report.DeploymentReport {
+	var keys []string
+	for key, sr := range depGraph {
+		sr.RLock()
+		done := sr.created || sr.Skipped
+		sr.RUnlock()
+		if !done {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	result := make(report.DeploymentReport, len(keys))
+	parallel(len(keys), func(i int) {
+		result[i] = depGraph[keys[i]].GetNodeReport(keys[i])
+	})
+	return result
+}
+
+// reportDeadline logs resources which are not ready if any of them exceeded its deadline
+func (depGraph DependencyGraph) reportDeadline() {
+	exceeded := false
+	for _, sr := range depGraph {
+		sr.RLock()
+		if IsDeadlineExceeded(sr.Error) {
+			exceeded = true
+		}
+		sr.RUnlock()
+	}
+	if !exceeded {
+		return
+	}
+	unready := depGraph.UnreadyReport()
+	logging.Errorf("Deadline exceeded, %d resources are not ready:", len(unready))
+	for _, line := range unready.AsText(0) {
+		logging.Errorf("%s", line)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+// deadlineResource is a resource with deadline in its meta
+type deadlineResource struct {
+	*mocks.Resource
+	deadline interface{}
+}
+
+func (r *deadlineResource) Meta(key string) interface{} {
+	if key == DeadlineKey {
+		return r.deadline
+	}
+	return nil
+}
+
+// newDeadlineResource returns resource with given phase of status and deadline in seconds, or nil for no deadline
+func newDeadlineResource(key string, phase interfaces.ResourcePhase, deadline interface{}) *ScheduledResource {
+	r := &deadlineResource{Resource: mocks.NewResource(key, phase), deadline: deadline}
+	return NewScheduledResourceFor(report.SimpleReporter{BaseResource: r})
+}
+
+// TestSetDeadlines checks that the shortest of graph and resource deadlines is used
+func TestSetDeadlines(t *testing.T) {
+	depGraph := DependencyGraph{
+		"pod/short": newDeadlineResource("pod/short", interfaces.ResourceReady, 10.0),
+		"pod/long":  newDeadlineResource("pod/long", interfaces.ResourceReady, 1000.0),
+		"pod/none":  newDeadlineResource("pod/none", interfaces.ResourceReady, nil),
+	}
+	start := time.Now()
+
+	depGraph.setDeadlines(start)
+	if depGraph["pod/short"].deadline != start.Add(10*time.Second) {
+		t.Errorf("Expected deadline from meta, got %v", depGraph["pod/short"].deadline)
+	}
+	if !depGraph["pod/none"].deadline.IsZero() {
+		t.Errorf("Expected no deadline, got %v", depGraph["pod/none"].deadline)
+	}
+
+	depGraph.WithDeadline(time.Minute)
+	depGraph.setDeadlines(start)
+	expected := map[string]time.Duration{"pod/short": 10 * time.Second, "pod/long": time.Minute, "pod/none": time.Minute}
+	for key, timeout := range expected {
+		if depGraph[key].deadline != start.Add(timeout) {
+			t.Errorf("Expected deadline of %s to be %v after start, got %v", key, timeout, depGraph[key].deadline.Sub(start))
+		}
+	}
+}
+
+// TestGraphDeadline checks that resources which are not ready by the deadline fail along with their dependents
+func TestGraphDeadline(t *testing.T) {
+	parent := newDeadlineResource("pod/parent", interfaces.ResourceNotReady, nil)
+	child := newDeadlineResource("pod/child", interfaces.ResourceReady, nil)
+	child.Requires = []*ScheduledResource{parent}
+	parent.RequiredBy = []*ScheduledResource{child}
+	depGraph := DependencyGraph{"pod/parent": parent, "pod/child": child}
+	depGraph.WithDeadline(200 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		Create(depGraph, 0)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop at the deadline")
+	}
+
+	if !IsDeadlineExceeded(parent.Error) {
+		t.Errorf("Expected parent to exceed deadline, got %v", parent.Error)
+	}
+	if failed := depGraph.Failed(); len(failed) != 2 {
+		t.Errorf("Expected both resources to fail, got %v", failed)
+	}
+	unready := depGraph.UnreadyReport()
+	if len(unready) != 2 || unready[0].Dependent != "pod/child" || !unready[0].Blocked {
+		t.Errorf("Expected report of blocked child and unready parent, got %v", unready)
+	}
+}
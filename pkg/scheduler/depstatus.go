@@ -0,0 +1,189 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/client-go/pkg/api/errors"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// DependencyStatusInterval is an interval between updates of status of Dependency objects during graph runs
+var DependencyStatusInterval = 5 * time.Second
+
+// WithDependencyStatus makes graph runs publish reports of parents in status of Dependency objects, so
+// that progress of the run can be watched with kubectl get dependencies -w
+func (depGraph DependencyGraph) WithDependencyStatus(deps client.DependenciesInterface) {
+	for _, sr := range depGraph {
+		sr.dependencyStatus = deps
+	}
+}
+
+// dependencyStatusClient returns client publishing status of dependencies of the graph, or nil if it is not set
+func (depGraph DependencyGraph) dependencyStatusClient() client.DependenciesInterface {
+	for _, sr := range depGraph {
+		return sr.dependencyStatus
+	}
+	return nil
+}
+
+// setDependency records Dependency object between the parent and the resource
+func (sr *ScheduledResource) setDependency(parentKey string, dep client.Dependency) {
+	if sr.dependencies == nil {
+		sr.dependencies = map[string]client.Dependency{}
+	}
+	sr.dependencies[parentKey] = dep
+}
+
+// dependencyEdge is a Dependency object between resources of the graph
+type dependencyEdge struct {
+	name   string
+	parent *ScheduledResource
+	meta   map[string]string
+}
+
+// reportKey identifies report of the parent for the edge. Edges of the parent with the same meta
+// share the report
+func (edge dependencyEdge) reportKey() string {
+	return fmt.Sprintf("%s%v", edge.parent.Key(), edge.meta)
+}
+
+// dependencyStatusPublisher updates status of Dependency objects whose reports changed since the last update
+type dependencyStatusPublisher struct {
+	client    client.DependenciesInterface
+	runID     string
+	edges     []dependencyEdge
+	published map[string]client.DependencyStatus
+}
+
+func newDependencyStatusPublisher(depGraph DependencyGraph, deps client.DependenciesInterface, runID string) *dependencyStatusPublisher {
+	p := &dependencyStatusPublisher{client: deps, runID: runID, published: map[string]client.DependencyStatus{}}
+	for _, child := range depGraph {
+		child.RLock()
+		for _, parent := range child.Requires {
+			dep := child.dependencies[parent.Key()]
+			if dep.Name == "" {
+				continue
+			}
+			p.edges = append(p.edges, dependencyEdge{name: dep.Name, parent: parent, meta: child.Meta[parent.Key()]})
+			if dep.Status != nil {
+				p.published[dep.Name] = *dep.Status
+			}
+		}
+		child.RUnlock()
+	}
+	return p
+}
+
+// sameReport checks if statuses report the same state of the parent, regardless of the run and time
+// they were published at
+func sameReport(a, b client.DependencyStatus) bool {
+	a.RunID, a.UpdateTime = b.RunID, b.UpdateTime
+	return a == b
+}
+
+// publish updates status of every dependency whose report changed. Status which already reports the
+// same state of the parent, including the one left by a previous run, is not patched, so that runs
+// which do not change anything do not change Dependency objects. Dependencies which do not exist as
+// objects, e.g. the ones made from copies, are not updated anymore
+func (p *dependencyStatusPublisher) publish() {
+	var keys []string
+	edgesByKey := map[string]dependencyEdge{}
+	for _, edge := range p.edges {
+		key := edge.reportKey()
+		if _, ok := edgesByKey[key]; !ok {
+			keys = append(keys, key)
+			edgesByKey[key] = edge
+		}
+	}
+	reports := make([]interfaces.DependencyReport, len(keys))
+	parallel(len(keys), func(i int) {
+		edge := edgesByKey[keys[i]]
+		reports[i] = edge.parent.GetDependencyReport(edge.meta)
+	})
+	reportsByKey := make(map[string]interfaces.DependencyReport, len(keys))
+	for i, key := range keys {
+		reportsByKey[key] = reports[i]
+	}
+
+	now := time.Now()
+	var edges []dependencyEdge
+	for _, edge := range p.edges {
+		depReport := reportsByKey[edge.reportKey()]
+		status := client.DependencyStatus{
+			RunID:      p.runID,
+			Blocks:     depReport.Blocks,
+			Percentage: depReport.Percentage,
+			Needed:     depReport.Needed,
+			Message:    depReport.Message,
+			UpdateTime: now,
+		}
+		if previous, ok := p.published[edge.name]; ok && sameReport(previous, status) {
+			edges = append(edges, edge)
+			continue
+		}
+		err := p.client.UpdateStatus(edge.name, &status)
+		if errors.IsNotFound(err) {
+			logging.Debugf("Dependency %s does not exist, its status is not published", edge.name)
+			continue
+		}
+		edges = append(edges, edge)
+		if err != nil {
+			logging.Warningf("Could not update status of dependency %s: %v", edge.name, err)
+			continue
+		}
+		p.published[edge.name] = status
+	}
+	p.edges = edges
+}
+
+// publishDependencyStatus starts publishing status of dependencies of the graph every
+// DependencyStatusInterval. Returned function stops it after publishing final status
+func (depGraph DependencyGraph) publishDependencyStatus(runID string) func() {
+	deps := depGraph.dependencyStatusClient()
+	if deps == nil {
+		return func() {}
+	}
+	p := newDependencyStatusPublisher(depGraph, deps, runID)
+	if len(p.edges) == 0 {
+		return func() {}
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(DependencyStatusInterval)
+		defer ticker.Stop()
+		for {
+			p.publish()
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+		p.publish()
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+// TestCreatePublishesDependencyStatus checks that status of named Dependency objects is published by graph run
+func TestCreatePublishesDependencyStatus(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePod("ready-1"), mocks.MakePod("ready-2"), mocks.MakePod("ready-3"))
+	c.Deps = mocks.NewDependencyClient(
+		mocks.Dependency{Name: "dep-1", Parent: "pod/ready-1", Child: "pod/ready-2"},
+		mocks.Dependency{Parent: "pod/ready-2", Child: "pod/ready-3"},
+	)
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	depGraph.WithDependencyStatus(c.Dependencies())
+	Create(depGraph, 0)
+
+	statuses := mocks.DependencyStatuses(c.Dependencies())
+	if len(statuses) != 1 {
+		t.Fatalf("Expected status of a single named dependency, got %v", statuses)
+	}
+	status, ok := statuses["dep-1"]
+	if !ok {
+		t.Fatalf("Expected status of dep-1, got %v", statuses)
+	}
+	if status.Blocks || status.RunID == "" || status.UpdateTime.IsZero() {
+		t.Errorf("Unexpected status %+v", status)
+	}
+}
+
+// TestDependencyStatusKeptBetweenRuns checks that status which already reports the same state of the
+// parent is not patched by the next run
+func TestDependencyStatusKeptBetweenRuns(t *testing.T) {
+	deps := []mocks.Dependency{{Name: "dep-1", Parent: "pod/ready-1", Child: "pod/ready-2"}}
+	run := func() map[string]client.DependencyStatus {
+		c := mocks.NewClient(mocks.MakePod("ready-1"), mocks.MakePod("ready-2"))
+		c.Deps = mocks.NewDependencyClient(deps...)
+		depGraph, err := BuildDependencyGraph(c, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		depGraph.WithDependencyStatus(c.Dependencies())
+		Create(depGraph, 0)
+		return mocks.DependencyStatuses(c.Dependencies())
+	}
+
+	status := run()["dep-1"]
+	deps[0].Status = &status
+	if statuses := run(); len(statuses) != 0 {
+		t.Errorf("Expected unchanged status not to be updated, got %v", statuses)
+	}
+
+	changed := status
+	changed.Blocks = true
+	deps[0].Status = &changed
+	if statuses := run(); statuses["dep-1"].Blocks || statuses["dep-1"].RunID == status.RunID {
+		t.Errorf("Expected changed status to be updated by the new run, got %v", statuses)
+	}
+}
+
+// countingReporter counts dependency reports made for it
+type countingReporter struct {
+	interfaces.BaseResource
+	reports int32
+}
+
+func (r *countingReporter) GetDependencyReport(ctx context.Context, meta map[string]string) interfaces.DependencyReport {
+	atomic.AddInt32(&r.reports, 1)
+	return report.SimpleReporter{BaseResource: r.BaseResource}.GetDependencyReport(ctx, meta)
+}
+
+// TestDependencyStatusReportPerParent checks that dependencies of the same parent share its report
+func TestDependencyStatusReportPerParent(t *testing.T) {
+	reporter := &countingReporter{BaseResource: mocks.NewResource("pod/parent", interfaces.ResourceReady)}
+	parent := NewScheduledResourceFor(reporter)
+	depGraph := DependencyGraph{parent.Key(): parent}
+	for _, name := range []string{"child-1", "child-2", "child-3"} {
+		child := NewScheduledResourceFor(report.SimpleReporter{BaseResource: mocks.NewResource("pod/"+name, interfaces.ResourceReady)})
+		child.Requires = append(child.Requires, parent)
+		child.Meta[parent.Key()] = nil
+		child.setDependency(parent.Key(), client.Dependency{ObjectMeta: api.ObjectMeta{Name: "dep-" + name}})
+		depGraph[child.Key()] = child
+	}
+
+	deps := mocks.NewDependencyClient()
+	newDependencyStatusPublisher(depGraph, deps, "run").publish()
+	if reporter.reports != 1 {
+		t.Errorf("Expected a single report of the parent, got %d", reporter.reports)
+	}
+	if statuses := mocks.DependencyStatuses(deps); len(statuses) != 3 {
+		t.Errorf("Expected status of every dependency, got %v", statuses)
+	}
+}
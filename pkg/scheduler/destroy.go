@@ -0,0 +1,130 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// DestroyOptions define how the graph is torn down
+type DestroyOptions struct {
+	// KeepKinds are kinds of resources which are not deleted, e.g. "persistentvolumeclaim"
+	KeepKinds []string
+	// Policy defines what happens with objects owned by deleted resources
+	Policy resources.DeletionPolicy
+	// Timeout is a time to wait for deletion of each resource
+	Timeout time.Duration
+}
+
+// byKey sorts resources by their keys
+type byKey []*ScheduledResource
+
+func (b byKey) Len() int           { return len(b) }
+func (b byKey) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byKey) Less(i, j int) bool { return b[i].Key() < b[j].Key() }
+
+// destroyOrder returns resources of the graph in reverse topological order, so that every resource
+// goes after all resources which depend on it. Resources which can be deleted at the same step are
+// ordered by key
+func destroyOrder(depGraph DependencyGraph) ([]*ScheduledResource, error) {
+	remaining := map[string]int{}
+	var current []*ScheduledResource
+	for key, sr := range depGraph {
+		remaining[key] = len(sr.RequiredBy)
+		if len(sr.RequiredBy) == 0 {
+			current = append(current, sr)
+		}
+	}
+
+	var order []*ScheduledResource
+	for len(current) > 0 {
+		sort.Sort(byKey(current))
+		order = append(order, current...)
+
+		var next []*ScheduledResource
+		for _, sr := range current {
+			for _, req := range sr.Requires {
+				remaining[req.Key()]--
+				if remaining[req.Key()] == 0 {
+					next = append(next, req)
+				}
+			}
+		}
+		current = next
+	}
+
+	if len(order) != len(depGraph) {
+		return nil, fmt.Errorf("Dependency graph has cycles, it cannot be destroyed")
+	}
+	return order, nil
+}
+
+// Destroy deletes resources of the graph in reverse order of their creation, waiting for each resource
+// to be gone before deleting resources it depends on. Resources without definitions are not deleted,
+// as they were not created by AppController. Destroy stops on the first error
+func Destroy(depGraph DependencyGraph, options DestroyOptions) error {
+	order, err := destroyOrder(depGraph)
+	if err != nil {
+		return err
+	}
+
+	keep := map[string]bool{}
+	for _, kind := range options.KeepKinds {
+		keep[kind] = true
+	}
+
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = WaitTimeout
+	}
+
+	for _, sr := range order {
+		if sr.Existing {
+			sr.logger("destroy").Infof("Resource %s has no definition, keeping it", sr.Key())
+			continue
+		}
+		if _, ok := sr.Resource.(resources.Deletion); ok {
+			continue
+		}
+		kind, _, err := keyParts(sr.Key())
+		if err != nil {
+			return err
+		}
+		if resources.NonObjectKinds[kind] {
+			continue
+		}
+		// deleting namespace would delete all objects in it, including ones not managed by AppController
+		if kind == namespaceKind {
+			continue
+		}
+		if keep[kind] {
+			sr.logger("destroy").Infof("Keeping %s", sr.Key())
+			continue
+		}
+
+		sr.logger("destroy").Infof("Deleting %s", sr.Key())
+		if err := resources.DeleteAndWait(context.Background(), sr.Resource, options.Policy, CheckInterval, timeout); err != nil {
+			return fmt.Errorf("Could not delete %s: %v", sr.Key(), err)
+		}
+		sr.logger("destroy").Infof("Resource %s deleted", sr.Key())
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestDestroyOrder checks that resources go after all their dependents
+func TestDestroyOrder(t *testing.T) {
+	c := mocks.NewClient()
+	c.Deps = mocks.NewDependencyClient(
+		mocks.Dependency{Parent: "secret/s", Child: "deployment/d"},
+		mocks.Dependency{Parent: "persistentvolumeclaim/p", Child: "deployment/d"},
+		mocks.Dependency{Parent: "deployment/d", Child: "pod/ready-1"},
+		mocks.Dependency{Parent: "secret/s", Child: "pod/ready-1"},
+	)
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := destroyOrder(depGraph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"pod/ready-1", "deployment/d", "persistentvolumeclaim/p", "secret/s"}
+	if len(order) != len(expected) {
+		t.Fatalf("Expected %d resources, got %d", len(expected), len(order))
+	}
+	for i, key := range expected {
+		if order[i].Key() != key {
+			t.Errorf("Expected %s at position %d, got %s", key, i, order[i].Key())
+		}
+	}
+}
+
+// TestDestroyOrderCycle checks that graph with cycles is not destroyed
+func TestDestroyOrderCycle(t *testing.T) {
+	c := mocks.NewClient()
+	c.Deps = mocks.NewDependencyClient(
+		mocks.Dependency{Parent: "pod/ready-1", Child: "pod/ready-2"},
+		mocks.Dependency{Parent: "pod/ready-2", Child: "pod/ready-1"},
+	)
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = destroyOrder(depGraph); err == nil {
+		t.Error("Error expected for graph with cycles")
+	}
+}
+
+// TestDestroy checks that only resources with definitions are deleted, and kept kinds stay in the cluster
+func TestDestroy(t *testing.T) {
+	c := mocks.NewClient(
+		mocks.MakeSecret("s"),
+		mocks.MakeDeployment("d"),
+		mocks.MakePod("ready-1"),
+		mocks.MakePod("ready-2"),
+	)
+	c.ResDefs = mocks.NewResourceDefinitionClient("secret/s", "deployment/d", "pod/ready-1")
+	c.Deps = mocks.NewDependencyClient(
+		mocks.Dependency{Parent: "secret/s", Child: "deployment/d"},
+		mocks.Dependency{Parent: "deployment/d", Child: "pod/ready-1"},
+		mocks.Dependency{Parent: "pod/ready-2", Child: "pod/ready-1"},
+	)
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = Destroy(depGraph, DestroyOptions{KeepKinds: []string{"secret"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = c.Deployments().Get("d"); err == nil {
+		t.Error("Deployment should be deleted")
+	}
+	if _, err = c.Pods().Get("ready-1"); err == nil {
+		t.Error("Pod with definition should be deleted")
+	}
+	if _, err = c.Pods().Get("ready-2"); err != nil {
+		t.Errorf("Pod without definition should be kept: %v", err)
+	}
+	if _, err = c.Secrets().Get("s"); err != nil {
+		t.Errorf("Secret should be kept: %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/client-go/pkg/api/errors"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// DiffAction is a change of the object made by the next run of the graph
+type DiffAction string
+
+// Possible values of DiffAction
+const (
+	DiffCreate    DiffAction = "create"
+	DiffUpdate    DiffAction = "update"
+	DiffDelete    DiffAction = "delete"
+	DiffUnchanged DiffAction = "unchanged"
+	// DiffDrifted objects differ from their definitions, but resources of their kinds are not updated
+	DiffDrifted DiffAction = "drifted"
+)
+
+// definitionComparer is implemented by resources which update their objects to match definitions
+type definitionComparer interface {
+	EqualToDefinition(interface{}) bool
+}
+
+// NodeDiff is a difference between the definition of the resource and its object in the cluster
+type NodeDiff struct {
+	Key    string     `json:"key"`
+	Action DiffAction `json:"action"`
+	// Diff is a unified diff from the object in the cluster to the definition
+	Diff []string `json:"diff,omitempty"`
+}
+
+// GraphDiff is a difference between the definitions of the graph and the cluster
+type GraphDiff struct {
+	Nodes []NodeDiff `json:"nodes"`
+	// Pruned are keys of objects of the graph which do not belong to any of its resources, they are
+	// deleted if the graph is run with pruning
+	Pruned []string `json:"pruned,omitempty"`
+}
+
+// Diff compares every resource of the graph which has a definition with its object in the cluster, the
+// same way resources are compared when they are created. Objects are not modified. If prune options are
+// given, objects which would be pruned are reported as well
+func Diff(c client.Interface, depGraph DependencyGraph, prune *PruneOptions) (GraphDiff, error) {
+	keys := make([]string, 0, len(depGraph))
+	for key, sr := range depGraph {
+		if !sr.Existing {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var diff GraphDiff
+	for _, key := range keys {
+		node, ok, err := nodeDiff(depGraph[key].Resource)
+		if err != nil {
+			return diff, fmt.Errorf("Could not compare %s with the cluster: %v", key, err)
+		}
+		if ok {
+			diff.Nodes = append(diff.Nodes, node)
+		}
+	}
+
+	if prune != nil {
+		options := *prune
+		options.DryRun = true
+		pruned, err := Prune(c, depGraph, options)
+		if err != nil {
+			return diff, err
+		}
+		diff.Pruned = pruned
+	}
+	return diff, nil
+}
+
+// nodeDiff returns difference for the resource, or false if the resource has no object to compare, e.g.
+// it is an external check
+func nodeDiff(resource interfaces.BaseResource) (NodeDiff, bool, error) {
+	node := NodeDiff{Key: resource.Key()}
+	if deletion, ok := resource.(resources.Deletion); ok {
+		getter, ok := liveObjectGetter(deletion.Resource)
+		if !ok {
+			return node, false, nil
+		}
+		_, err := getter.Live()
+		if errors.IsNotFound(err) {
+			node.Action = DiffUnchanged
+			return node, true, nil
+		}
+		node.Action = DiffDelete
+		return node, true, err
+	}
+
+	if _, ok := liveObjectGetter(resource); !ok {
+		return node, false, nil
+	}
+	lines, exists, err := liveDiff(resource, "definition "+resource.Key())
+	if err != nil {
+		return node, false, err
+	}
+	node.Diff = lines
+	switch {
+	case !exists:
+		node.Action = DiffCreate
+	case len(lines) > 0 && upgradable(resource):
+		node.Action = DiffUpdate
+	case len(lines) > 0:
+		node.Action = DiffDrifted
+	default:
+		node.Action = DiffUnchanged
+	}
+	return node, true, nil
+}
+
+// upgradable returns true if the resource updates its object when it differs from definition
+func upgradable(r interfaces.BaseResource) bool {
+	if reporter, ok := r.(report.SimpleReporter); ok {
+		r = reporter.BaseResource
+	}
+	_, ok := r.(definitionComparer)
+	return ok
+}
+
+// Keys returns keys of resources with given action
+func (d GraphDiff) Keys(action DiffAction) []string {
+	var keys []string
+	for _, node := range d.Nodes {
+		if node.Action == action {
+			keys = append(keys, node.Key)
+		}
+	}
+	if action == DiffDelete {
+		keys = append(keys, d.Pruned...)
+	}
+	return keys
+}
+
+// Changed returns true if the next run would create, update or delete any object
+func (d GraphDiff) Changed() bool {
+	return len(d.Keys(DiffCreate))+len(d.Keys(DiffUpdate))+len(d.Keys(DiffDelete)) > 0
+}
+
+// AsText returns diffs of changed resources followed by summary of changes
+func (d GraphDiff) AsText() []string {
+	var ret []string
+	for _, node := range d.Nodes {
+		ret = append(ret, node.Diff...)
+	}
+	for _, action := range []DiffAction{DiffCreate, DiffUpdate, DiffDelete} {
+		keys := d.Keys(action)
+		ret = append(ret, fmt.Sprintf("%d to %s:", len(keys), action))
+		ret = append(ret, report.Indent(report.ReportIndentSize, keys)...)
+	}
+	if keys := d.Keys(DiffDrifted); len(keys) > 0 {
+		ret = append(ret, fmt.Sprintf("%d differ from definitions, but are not updated:", len(keys)))
+		ret = append(ret, report.Indent(report.ReportIndentSize, keys)...)
+	}
+	return ret
+}
@@ -0,0 +1,76 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// TestDiff checks that resources are reported as created, updated, drifted or unchanged according to
+// their objects in the cluster
+func TestDiff(t *testing.T) {
+	live := mocks.MakeConfigMap("changed")
+	live.Data = map[string]string{"key": "old"}
+	livePod := mocks.MakePod("drifted")
+	livePod.Labels = map[string]string{"app": "old"}
+	c := mocks.NewClient(mocks.MakeConfigMap("same"), live, livePod)
+
+	changed := mocks.MakeConfigMap("changed")
+	changed.Data = map[string]string{"key": "new"}
+	pod := mocks.MakePod("drifted")
+	pod.Labels = map[string]string{"app": "new"}
+	depGraph := DependencyGraph{}
+	for _, r := range []interfaces.Resource{
+		resources.NewConfigMap(mocks.MakeConfigMap("same"), c.ConfigMaps(), nil),
+		resources.NewConfigMap(changed, c.ConfigMaps(), nil),
+		resources.NewConfigMap(mocks.MakeConfigMap("missing"), c.ConfigMaps(), nil),
+		resources.NewPod(pod, c.Pods(), nil),
+	} {
+		depGraph[r.Key()] = NewScheduledResourceFor(r)
+	}
+
+	diff, err := Diff(c, depGraph, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[DiffAction][]string{
+		DiffCreate:    {"configmap/missing"},
+		DiffUpdate:    {"configmap/changed"},
+		DiffDelete:    nil,
+		DiffUnchanged: {"configmap/same"},
+		DiffDrifted:   {"pod/drifted"},
+	}
+	for action, keys := range expected {
+		if result := diff.Keys(action); !reflect.DeepEqual(result, keys) {
+			t.Errorf("Expected %v to %s, got %v", keys, action, result)
+		}
+	}
+	if !diff.Changed() {
+		t.Error("Diff with created and updated resources should be changed")
+	}
+
+	text := strings.Join(diff.AsText(), "\n")
+	for _, line := range []string{`-    "key": "old"`, `+    "key": "new"`, "1 to create:"} {
+		if !strings.Contains(text, line) {
+			t.Errorf("Expected %q in diff:\n%s", line, text)
+		}
+	}
+}
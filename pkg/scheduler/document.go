@@ -0,0 +1,328 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"k8s.io/client-go/pkg/util/yaml"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+)
+
+// DocumentNode is a resource of graph document. Object is the k8s object created for the node, nodes
+// without it only name resources defined elsewhere
+type DocumentNode struct {
+	Key    string                 `json:"key,omitempty"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+	Object map[string]interface{} `json:"object,omitempty"`
+}
+
+// GraphDocument describes the whole dependency graph in a single document, as an alternative to
+// individual Definition and Dependency objects
+type GraphDocument struct {
+	Nodes []DocumentNode `json:"nodes"`
+	Edges []GraphEdge    `json:"edges"`
+}
+
+// definitionFields are fields of serialized resource definition which do not hold the k8s object
+var definitionFields = map[string]bool{"apiVersion": true, "kind": true, "metadata": true, "meta": true}
+
+// NewGraphDocument returns document describing the graph of resource definitions and dependencies
+func NewGraphDocument(resDefs []client.ResourceDefinition, deps []client.Dependency) (GraphDocument, error) {
+	document := GraphDocument{Nodes: []DocumentNode{}, Edges: []GraphEdge{}}
+	for _, r := range resDefs {
+		key, err := definitionKey(r)
+		if err != nil {
+			return document, err
+		}
+		data, err := json.Marshal(r)
+		if err != nil {
+			return document, err
+		}
+		var fields map[string]interface{}
+		if err = json.Unmarshal(data, &fields); err != nil {
+			return document, err
+		}
+		node := DocumentNode{Key: key, Meta: r.Meta}
+		for field, value := range fields {
+			if object, ok := value.(map[string]interface{}); ok && !definitionFields[field] {
+				node.Object = object
+			}
+		}
+		document.Nodes = append(document.Nodes, node)
+	}
+	for _, d := range deps {
+		document.Edges = append(document.Edges, GraphEdge{Parent: d.Parent, Child: d.Child, Meta: d.Meta})
+	}
+	return document, nil
+}
+
+// Materialize returns resource definitions for nodes with objects, named <kind>-<name>, and
+// dependencies for edges of the document, named after their parents and children
+func (d GraphDocument) Materialize() ([]client.ResourceDefinition, []client.Dependency, error) {
+	var resDefs []client.ResourceDefinition
+	for _, node := range d.Nodes {
+		if node.Object == nil {
+			continue
+		}
+		r, err := node.definition()
+		if err != nil {
+			return nil, nil, err
+		}
+		resDefs = append(resDefs, r)
+	}
+
+	var deps []client.Dependency
+	for _, edge := range d.Edges {
+		if edge.Parent == "" || edge.Child == "" {
+			return nil, nil, fmt.Errorf("Edge %s -> %s must have both parent and child", edge.Parent, edge.Child)
+		}
+		dep := client.Dependency{Parent: edge.Parent, Child: edge.Child, Meta: edge.Meta}
+		dep.APIVersion = client.SchemeGroupVersion.String()
+		dep.Kind = "Dependency"
+		dep.Name = objectName(edge.Parent + "-" + edge.Child)
+		deps = append(deps, dep)
+	}
+	return resDefs, deps, nil
+}
+
+// definition returns resource definition for the node object
+func (n DocumentNode) definition() (client.ResourceDefinition, error) {
+	var r client.ResourceDefinition
+	kind, _ := n.Object["kind"].(string)
+	kind = strings.ToLower(kind)
+	if kind == "" {
+		kind = strings.SplitN(n.Key, "/", 2)[0]
+	}
+	metadata, _ := n.Object["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	if kind == "" || name == "" {
+		return r, fmt.Errorf("Object of node %s must have kind and name", n.Key)
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"apiVersion": client.SchemeGroupVersion.String(),
+		"kind":       "Definition",
+		"metadata":   map[string]string{"name": objectName(kind + "-" + name)},
+		"meta":       n.Meta,
+		kind:         n.Object,
+	})
+	if err != nil {
+		return r, err
+	}
+	if err = json.Unmarshal(data, &r); err != nil {
+		return r, err
+	}
+	key, err := definitionKey(r)
+	if err != nil {
+		return r, fmt.Errorf("Object of node %s: %v", n.Key, err)
+	}
+	if n.Key != "" && n.Key != key {
+		return r, fmt.Errorf("Node %s has object of resource %s", n.Key, key)
+	}
+	return r, nil
+}
+
+// objectName turns resource keys into valid names of k8s objects
+func objectName(s string) string {
+	return strings.ToLower(strings.NewReplacer("/", "-", "@", "-").Replace(s))
+}
+
+// ParseGraphDocument parses graph document in YAML, JSON or Graphviz DOT format. DOT graphs have only
+// node keys and edges, with meta given by edge labels of key=value lines, as printed by AsDOT
+func ParseGraphDocument(data []byte) (GraphDocument, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("digraph")) || bytes.HasPrefix(trimmed, []byte("strict digraph")) {
+		return ParseDOT(string(trimmed))
+	}
+	var document GraphDocument
+	data, err := yaml.ToJSON(data)
+	if err != nil {
+		return document, err
+	}
+	err = json.Unmarshal(data, &document)
+	return document, err
+}
+
+// dotTokens splits DOT source into identifiers, quoted strings and punctuation. Comments are skipped
+func dotTokens(source string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case strings.HasPrefix(source[i:], "//") || c == '#':
+			end := strings.Index(source[i:], "\n")
+			if end < 0 {
+				end = len(source) - i
+			}
+			i += end
+		case strings.HasPrefix(source[i:], "/*"):
+			end := strings.Index(source[i:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("Unterminated comment")
+			}
+			i += end + 2
+		case strings.HasPrefix(source[i:], "->"), strings.HasPrefix(source[i:], "--"):
+			tokens = append(tokens, source[i:i+2])
+			i += 2
+		case strings.IndexByte("{}[];,=", c) >= 0:
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(source) && source[end] != '"' {
+				if source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(source) {
+				return nil, fmt.Errorf("Unterminated string")
+			}
+			tokens = append(tokens, source[i:end+1])
+			i = end + 1
+		default:
+			end := i
+			for end < len(source) && !unicode.IsSpace(rune(source[end])) && strings.IndexByte("{}[];,=\"", source[end]) < 0 &&
+				!strings.HasPrefix(source[end:], "->") {
+				end++
+			}
+			tokens = append(tokens, source[i:end])
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+// dotID returns value of DOT identifier token
+func dotID(token string) string {
+	if strings.HasPrefix(token, `"`) {
+		if value, err := strconv.Unquote(token); err == nil {
+			return value
+		}
+		return strings.Replace(strings.Trim(token, `"`), `\"`, `"`, -1)
+	}
+	return token
+}
+
+// ParseDOT parses Graphviz digraph into graph document. Subgraphs and attributes other than edge
+// labels are ignored
+func ParseDOT(source string) (GraphDocument, error) {
+	document := GraphDocument{Nodes: []DocumentNode{}, Edges: []GraphEdge{}}
+	tokens, err := dotTokens(source)
+	if err != nil {
+		return document, err
+	}
+
+	seen := map[string]bool{}
+	addNode := func(key string) {
+		if !seen[key] {
+			seen[key] = true
+			document.Nodes = append(document.Nodes, DocumentNode{Key: key})
+		}
+	}
+
+	// header is skipped up to the opening brace
+	i := 0
+	for i < len(tokens) && tokens[i] != "{" {
+		i++
+	}
+	i++
+
+	for i < len(tokens) {
+		switch tokens[i] {
+		case "}", "{", ";", ",":
+			i++
+			continue
+		case "node", "edge", "graph":
+			// default attributes
+			i, _ = parseDOTAttributes(tokens, i+1)
+			continue
+		case "subgraph":
+			// statements of subgraphs belong to the graph, only their names are skipped
+			i++
+			if i < len(tokens) && tokens[i] != "{" {
+				i++
+			}
+			continue
+		}
+
+		ids := []string{dotID(tokens[i])}
+		i++
+		for i+1 < len(tokens) && (tokens[i] == "->" || tokens[i] == "--") {
+			ids = append(ids, dotID(tokens[i+1]))
+			i += 2
+		}
+		if len(ids) == 1 && i < len(tokens) && tokens[i] == "=" {
+			// graph attribute
+			i += 2
+			continue
+		}
+		var attributes map[string]string
+		i, attributes = parseDOTAttributes(tokens, i)
+
+		for _, id := range ids {
+			addNode(id)
+		}
+		meta := labelMeta(attributes["label"])
+		for j := 1; j < len(ids); j++ {
+			document.Edges = append(document.Edges, GraphEdge{Parent: ids[j-1], Child: ids[j], Meta: meta})
+		}
+	}
+	return document, nil
+}
+
+// parseDOTAttributes parses attribute lists starting at the position, if any, and returns position
+// after them
+func parseDOTAttributes(tokens []string, i int) (int, map[string]string) {
+	attributes := map[string]string{}
+	for i < len(tokens) && tokens[i] == "[" {
+		i++
+		for i < len(tokens) && tokens[i] != "]" {
+			if i+2 < len(tokens) && tokens[i+1] == "=" {
+				attributes[dotID(tokens[i])] = dotID(tokens[i+2])
+				i += 3
+				continue
+			}
+			i++
+		}
+		i++
+	}
+	return i, attributes
+}
+
+// labelMeta parses edge label of key=value lines into dependency meta
+func labelMeta(label string) map[string]string {
+	var meta map[string]string
+	for _, line := range strings.Split(label, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			continue
+		}
+		if meta == nil {
+			meta = map[string]string{}
+		}
+		meta[parts[0]] = parts[1]
+	}
+	return meta
+}
@@ -0,0 +1,128 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestGraphDocumentRoundTrip checks that exported document materializes into the same graph
+func TestGraphDocumentRoundTrip(t *testing.T) {
+	resDefs := []client.ResourceDefinition{
+		{Pod: mocks.MakePod("db"), Meta: map[string]interface{}{"timeout": float64(60)}},
+		{Job: mocks.MakeJob("migrate")},
+	}
+	deps := []client.Dependency{
+		{Parent: "pod/db", Child: "job/migrate", Meta: map[string]string{"on-error": "skip"}},
+	}
+
+	document, err := NewGraphDocument(resDefs, deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if document.Nodes[0].Key != "pod/db" || document.Nodes[0].Object == nil {
+		t.Errorf("Unexpected node %v", document.Nodes[0])
+	}
+
+	materializedDefs, materializedDeps, err := document.Materialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(materializedDefs) != 2 || materializedDefs[0].Name != "pod-db" || materializedDefs[0].Pod.Name != "db" {
+		t.Errorf("Unexpected resource definitions %v", materializedDefs)
+	}
+	if !reflect.DeepEqual(materializedDefs[0].Meta, resDefs[0].Meta) {
+		t.Errorf("Expected meta %v, got %v", resDefs[0].Meta, materializedDefs[0].Meta)
+	}
+	if materializedDefs[1].Job == nil || materializedDefs[1].Job.Name != "migrate" {
+		t.Errorf("Expected job definition, got %v", materializedDefs[1])
+	}
+	if len(materializedDeps) != 1 || materializedDeps[0].Name != "pod-db-job-migrate" ||
+		!reflect.DeepEqual(materializedDeps[0].Meta, deps[0].Meta) {
+		t.Errorf("Unexpected dependencies %v", materializedDeps)
+	}
+}
+
+// TestParseYamlDocument checks that nodes with objects become definitions and node keys are verified
+func TestParseYamlDocument(t *testing.T) {
+	document, err := ParseGraphDocument([]byte(`nodes:
+- meta:
+    timeout: 30
+  object:
+    apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: config
+- key: pod/external
+edges:
+- parent: configmap/config
+  child: pod/external
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resDefs, deps, err := document.Materialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resDefs) != 1 || resDefs[0].ConfigMap == nil || resDefs[0].Meta["timeout"] != float64(30) {
+		t.Errorf("Unexpected resource definitions %v", resDefs)
+	}
+	if len(deps) != 1 || deps[0].Parent != "configmap/config" {
+		t.Errorf("Unexpected dependencies %v", deps)
+	}
+
+	document.Nodes[0].Key = "configmap/other"
+	if _, _, err = document.Materialize(); err == nil {
+		t.Error("Error expected for node key not matching its object")
+	}
+}
+
+// TestParseDOT checks that graphs printed by AsDOT and hand-written DOT graphs are parsed
+func TestParseDOT(t *testing.T) {
+	export := GraphExport{
+		Nodes: []GraphNode{{Key: "pod/db", Status: NodeStatusReady}, {Key: "job/migrate"}},
+		Edges: []GraphEdge{{Parent: "pod/db", Child: "job/migrate", Meta: map[string]string{"on-error": "skip", "timeout": "5"}}},
+	}
+	document, err := ParseGraphDocument([]byte(export.AsDOT()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(document.Edges, export.Edges) {
+		t.Errorf("Expected edges %v, got %v", export.Edges, document.Edges)
+	}
+	if len(document.Nodes) != 2 || document.Nodes[0].Key != "pod/db" {
+		t.Errorf("Unexpected nodes %v", document.Nodes)
+	}
+
+	document, err = ParseDOT(`digraph deploy {
+		// comment
+		rankdir = LR;
+		node [shape=box];
+		subgraph cluster_db { "pod/db" }
+		"pod/db" -> "job/migrate" -> "pod/web"
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []GraphEdge{{Parent: "pod/db", Child: "job/migrate"}, {Parent: "job/migrate", Child: "pod/web"}}
+	if !reflect.DeepEqual(document.Edges, expected) {
+		t.Errorf("Expected edges %v, got %v", expected, document.Edges)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+)
+
+// EventComponent is a source component of events recorded by AppController
+const EventComponent = "appcontroller"
+
+// Reasons of events recorded for resource definitions
+const (
+	EventCreated    = "Created"
+	EventUpgrading  = "Upgrading"
+	EventReady      = "Ready"
+	EventFailed     = "Failed"
+	EventSkipped    = "Skipped"
+	EventRolledBack = "RolledBack"
+)
+
+// definitionReference returns reference to resource definition used as involved object of events
+func definitionReference(r client.ResourceDefinition) *v1.ObjectReference {
+	return &v1.ObjectReference{
+		Kind:            "Definition",
+		APIVersion:      "appcontroller.k8s/v1alpha1",
+		Namespace:       r.Namespace,
+		Name:            r.Name,
+		UID:             r.UID,
+		ResourceVersion: r.ResourceVersion,
+	}
+}
+
+// recordEvent records K8s event for resource definition of the resource, so that deployment
+// history is shown by kubectl describe. Resources without definitions have no events
+func (sr *ScheduledResource) recordEvent(eventType, reason, message string) {
+	if sr.events == nil || sr.definition == nil {
+		return
+	}
+	now := unversioned.NewTime(time.Now())
+	event := &v1.Event{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", sr.definition.Name, now.UnixNano()),
+			Namespace: sr.definition.Namespace,
+		},
+		InvolvedObject: *sr.definition,
+		Reason:         reason,
+		Message:        message,
+		Source:         v1.EventSource{Component: EventComponent},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+		Type:           eventType,
+	}
+	if _, err := sr.events.Create(event); err != nil {
+		sr.logger("event").Warningf("Could not record event %s for %s: %v", reason, sr.Key(), err)
+	}
+}
+
+// withEvents makes resources of the graph which have definitions record events
+func (depGraph DependencyGraph) withEvents(events corev1.EventInterface, resDefs []client.ResourceDefinition) {
+	for _, r := range resDefs {
+		key, err := definitionKey(r)
+		if err != nil {
+			continue
+		}
+		if sr, ok := depGraph[key]; ok {
+			sr.events = events
+			sr.definition = definitionReference(r)
+		}
+	}
+}
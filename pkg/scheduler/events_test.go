@@ -0,0 +1,51 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestEvents checks that events are recorded for resources with definitions only
+func TestEvents(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePod("ready-1"), mocks.MakePod("ready-2"))
+	c.ResDefs = mocks.NewResourceDefinitionClient("pod/ready-1")
+	c.Deps = mocks.NewDependencyClient(mocks.Dependency{Parent: "pod/ready-2", Child: "pod/ready-1"})
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Create(depGraph, 0)
+
+	events, err := c.Events().List(v1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reasons := map[string]int{}
+	for _, event := range events.Items {
+		if event.InvolvedObject.Kind != "Definition" || event.Source.Component != EventComponent {
+			t.Errorf("Unexpected event %v", event)
+		}
+		reasons[event.Reason]++
+	}
+	if len(events.Items) != 2 || reasons[EventCreated] != 1 || reasons[EventReady] != 1 {
+		t.Errorf("Expected one %s and one %s event, got %v", EventCreated, EventReady, reasons)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+)
+
+// Live statuses of exported graph nodes
+const (
+	NodeStatusReady    = "ready"
+	NodeStatusNotReady = "not ready"
+	NodeStatusError    = "error"
+	NodeStatusBlocked  = "blocked"
+)
+
+// nodeColors are DOT fill colors of nodes by their status
+var nodeColors = map[string]string{
+	NodeStatusReady:    "palegreen",
+	NodeStatusNotReady: "khaki",
+	NodeStatusError:    "salmon",
+	NodeStatusBlocked:  "lightgrey",
+}
+
+// GraphNode is a resource of exported graph
+type GraphNode struct {
+	Key string `json:"key"`
+	// Status is empty if the graph was exported without live status
+	Status string `json:"status,omitempty"`
+}
+
+// GraphEdge is a dependency of exported graph
+type GraphEdge struct {
+	Parent string            `json:"parent"`
+	Child  string            `json:"child"`
+	Meta   map[string]string `json:"meta,omitempty"`
+}
+
+// GraphExport is a representation of the dependency graph suitable for visualization
+type GraphExport struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// nodeStatus returns live status of the resource
+func nodeStatus(sr *ScheduledResource) string {
+	status, err := sr.Status(nil)
+	switch {
+	case err != nil || status.Phase == interfaces.ResourceError:
+		return NodeStatusError
+	case status.Phase == interfaces.ResourceReady:
+		return NodeStatusReady
+	case sr.IsBlocked():
+		return NodeStatusBlocked
+	}
+	return NodeStatusNotReady
+}
+
+// Export returns nodes and edges of the graph sorted by keys. If withStatus is true, status of
+// every resource is retrieved from the cluster
+func (depGraph DependencyGraph) Export(withStatus bool) GraphExport {
+	keys := make([]string, 0, len(depGraph))
+	for key := range depGraph {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	statuses := make([]string, len(keys))
+	if withStatus {
+		parallel(len(keys), func(i int) {
+			statuses[i] = nodeStatus(depGraph[keys[i]])
+		})
+	}
+
+	result := GraphExport{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	for i, key := range keys {
+		sr := depGraph[key]
+		result.Nodes = append(result.Nodes, GraphNode{Key: key, Status: statuses[i]})
+
+		parents := make([]string, 0, len(sr.Requires))
+		for _, parent := range sr.Requires {
+			parents = append(parents, parent.Key())
+		}
+		sort.Strings(parents)
+		for _, parent := range parents {
+			result.Edges = append(result.Edges, GraphEdge{Parent: parent, Child: key, Meta: sr.Meta[parent]})
+		}
+	}
+	return result
+}
+
+// AsDOT returns the graph in Graphviz DOT format. Nodes are colored by their status
+func (g GraphExport) AsDOT() string {
+	var buf bytes.Buffer
+	buf.WriteString("digraph appcontroller {\n")
+	buf.WriteString("    node [shape=box, style=filled, fillcolor=white];\n")
+	for _, node := range g.Nodes {
+		if node.Status == "" {
+			fmt.Fprintf(&buf, "    %q;\n", node.Key)
+			continue
+		}
+		fmt.Fprintf(&buf, "    %q [fillcolor=%s, tooltip=%q];\n", node.Key, nodeColors[node.Status], node.Status)
+	}
+	for _, edge := range g.Edges {
+		label := ""
+		metaKeys := make([]string, 0, len(edge.Meta))
+		for key := range edge.Meta {
+			metaKeys = append(metaKeys, key)
+		}
+		sort.Strings(metaKeys)
+		for _, key := range metaKeys {
+			label += fmt.Sprintf("%s=%s\n", key, edge.Meta[key])
+		}
+		if label == "" {
+			fmt.Fprintf(&buf, "    %q -> %q;\n", edge.Parent, edge.Child)
+		} else {
+			fmt.Fprintf(&buf, "    %q -> %q [label=%q];\n", edge.Parent, edge.Child, label)
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
@@ -0,0 +1,67 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestExport checks that exported graph has nodes with their statuses and edges with dependency meta
+func TestExport(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePod("ready-1"), mocks.MakePod("pending-2"), mocks.MakePod("ready-3"))
+	c.Deps = mocks.NewDependencyClient(
+		mocks.Dependency{Parent: "pod/ready-1", Child: "pod/pending-2"},
+		mocks.Dependency{Parent: "pod/pending-2", Child: "pod/ready-3", Meta: map[string]string{"timeout": "60"}},
+	)
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	export := depGraph.Export(true)
+	expectedNodes := []GraphNode{
+		{Key: "pod/pending-2", Status: NodeStatusNotReady},
+		{Key: "pod/ready-1", Status: NodeStatusReady},
+		{Key: "pod/ready-3", Status: NodeStatusReady},
+	}
+	if len(export.Nodes) != len(expectedNodes) {
+		t.Fatalf("Expected %d nodes, got %v", len(expectedNodes), export.Nodes)
+	}
+	for i, node := range expectedNodes {
+		if export.Nodes[i] != node {
+			t.Errorf("Expected node %v, got %v", node, export.Nodes[i])
+		}
+	}
+	if len(export.Edges) != 2 {
+		t.Fatalf("Expected 2 edges, got %v", export.Edges)
+	}
+	if export.Edges[1].Parent != "pod/pending-2" || export.Edges[1].Meta["timeout"] != "60" {
+		t.Errorf("Unexpected edge %v", export.Edges[1])
+	}
+
+	dot := export.AsDOT()
+	for _, line := range []string{
+		`"pod/ready-1" [fillcolor=palegreen, tooltip="ready"];`,
+		`"pod/ready-1" -> "pod/pending-2";`,
+		`"pod/pending-2" -> "pod/ready-3" [label="timeout=60\n"];`,
+	} {
+		if !strings.Contains(dot, line) {
+			t.Errorf("DOT output should contain %s, got:\n%s", line, dot)
+		}
+	}
+}
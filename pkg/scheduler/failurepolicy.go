@@ -0,0 +1,135 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// FailurePolicyKey is a meta key of resource definition which sets what happens to the graph run
+// when the resource fails
+const FailurePolicyKey = "on-failure"
+
+// FailurePolicy describes how failure of the resource affects the graph run
+type FailurePolicy string
+
+// Possible values for FailurePolicy
+const (
+	// FailureBlock marks the resource and resources depending on it failed, independent branches
+	// continue. This is the default
+	FailureBlock FailurePolicy = "block"
+	// FailureSkip marks the resource and resources depending on it skipped, so the run does not fail
+	FailureSkip FailurePolicy = "skip"
+	// FailureAbort stops the whole run: resources which are not being created yet are not created
+	FailureAbort FailurePolicy = "abort"
+)
+
+// failurePolicy returns failure policy set in meta of the resource
+func (sr *ScheduledResource) failurePolicy() FailurePolicy {
+	policy := FailurePolicy(resources.GetStringMeta(sr.Resource, FailurePolicyKey, string(FailureBlock)))
+	switch policy {
+	case FailureBlock, FailureSkip, FailureAbort:
+		return policy
+	}
+	sr.logger("create").Warningf("Unknown %s policy '%s' of %s, using %s", FailurePolicyKey, policy, sr.Key(), FailureBlock)
+	return FailureBlock
+}
+
+// RunAbortedError is an error of resources which were not created because the run was aborted
+type RunAbortedError struct {
+	// Key is a key of the failed resource which aborted the run
+	Key string
+	Err error
+}
+
+func (e RunAbortedError) Error() string {
+	return fmt.Sprintf("run aborted because %s failed: %v", e.Key, e.Err)
+}
+
+// IsRunAborted returns true if the error is caused by aborted run
+func IsRunAborted(err error) bool {
+	_, ok := err.(RunAbortedError)
+	return ok
+}
+
+// runState is a state of the graph run shared by its resources
+type runState struct {
+	// id, start and end identify the run and its duration, end is zero while the run is in progress
+	id       string
+	start    time.Time
+	end      time.Time
+	abortErr error
+	// ctx is cancelled when the run is cancelled, finished runs can not be cancelled
+	ctx      context.Context
+	cancel   context.CancelFunc
+	finished bool
+	// owned are resources created by the run in order of their creation
+	owned []*ScheduledResource
+	sync.RWMutex
+}
+
+// abort stops the run. Only the first reason is kept
+func (r *runState) abort(err error) {
+	if r == nil {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	if r.abortErr == nil {
+		r.abortErr = err
+	}
+}
+
+// aborted returns the reason of abort, or nil if the run was not aborted
+func (r *runState) aborted() error {
+	if r == nil {
+		return nil
+	}
+	r.RLock()
+	defer r.RUnlock()
+	return r.abortErr
+}
+
+// resetRunState makes resources of the graph share state of a new run
+func (depGraph DependencyGraph) resetRunState(runID string, start time.Time) {
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &runState{id: runID, start: start, ctx: ctx, cancel: cancel}
+	for _, sr := range depGraph {
+		sr.Lock()
+		sr.run = run
+		sr.Unlock()
+	}
+}
+
+// lastRun returns state of the last run of the graph, or nil if the graph was not run
+func (depGraph DependencyGraph) lastRun() *runState {
+	for _, sr := range depGraph {
+		sr.RLock()
+		defer sr.RUnlock()
+		return sr.run
+	}
+	return nil
+}
+
+// Aborted returns the reason of abort of the last run of the graph, or nil if it was not aborted
+func (depGraph DependencyGraph) Aborted() error {
+	return depGraph.lastRun().aborted()
+}
@@ -0,0 +1,137 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+// policyResource is a resource with failure policy in its meta, which fails if failing is true
+type policyResource struct {
+	*mocks.Resource
+	policy  interface{}
+	failing bool
+}
+
+func (r *policyResource) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	if r.failing {
+		return interfaces.NewStatus(interfaces.ResourceError), errors.New("failed")
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+func (r *policyResource) Meta(key string) interface{} {
+	if key == FailurePolicyKey {
+		return r.policy
+	}
+	return nil
+}
+
+func newPolicyResource(key string, policy interface{}, failing bool) *ScheduledResource {
+	r := &policyResource{Resource: mocks.NewResource(key, interfaces.ResourceReady), policy: policy, failing: failing}
+	return NewScheduledResourceFor(report.SimpleReporter{BaseResource: r})
+}
+
+// dependOn makes child depend on parent
+func dependOn(child, parent *ScheduledResource) {
+	child.Requires = append(child.Requires, parent)
+	parent.RequiredBy = append(parent.RequiredBy, child)
+}
+
+// TestFailurePolicy checks parsing of on-failure meta
+func TestFailurePolicy(t *testing.T) {
+	cases := map[interface{}]FailurePolicy{
+		nil:       FailureBlock,
+		"block":   FailureBlock,
+		"skip":    FailureSkip,
+		"abort":   FailureAbort,
+		"unknown": FailureBlock,
+		1.0:       FailureBlock,
+	}
+	for value, expected := range cases {
+		if policy := newPolicyResource("pod/1", value, false).failurePolicy(); policy != expected {
+			t.Errorf("Policy %v parsed as %s, expected %s", value, policy, expected)
+		}
+	}
+}
+
+// TestSkipPolicy checks that failed resource with skip policy is skipped with its subtree, while
+// independent branches are created and the run does not fail
+func TestSkipPolicy(t *testing.T) {
+	failing := newPolicyResource("pod/failing", "skip", true)
+	child := newPolicyResource("pod/child", nil, false)
+	grandchild := newPolicyResource("pod/grandchild", nil, false)
+	independent := newPolicyResource("pod/independent", nil, false)
+	dependOn(child, failing)
+	dependOn(grandchild, child)
+	depGraph := DependencyGraph{
+		"pod/failing": failing, "pod/child": child, "pod/grandchild": grandchild, "pod/independent": independent,
+	}
+
+	Create(depGraph, 0)
+
+	for _, sr := range []*ScheduledResource{failing, child, grandchild} {
+		if !sr.Skipped {
+			t.Errorf("Expected %s to be skipped", sr.Key())
+		}
+	}
+	if failing.Error == nil {
+		t.Error("Skipped resource should keep its error")
+	}
+	if !independent.created {
+		t.Error("Independent resource should be created")
+	}
+	if failed := depGraph.Failed(); len(failed) != 0 {
+		t.Errorf("Run should not fail, failed resources: %v", failed)
+	}
+
+	text := strings.Join(child.GetNodeReport(child.Key()).AsText(0), "\n")
+	if !strings.Contains(text, "SKIPPED") {
+		t.Errorf("Report of skipped resource should show it, got %s", text)
+	}
+}
+
+// TestAbortPolicy checks that failed resource with abort policy prevents creation of other resources
+func TestAbortPolicy(t *testing.T) {
+	failing := newPolicyResource("pod/failing", "abort", true)
+	independent := newPolicyResource("pod/independent", nil, false)
+	depGraph := DependencyGraph{"pod/failing": failing, "pod/independent": independent}
+	depGraph.resetRunState("test", time.Now())
+
+	finished := make(chan string, 2)
+	failing.finish(errors.New("failed"), finished)
+	if err := depGraph.Aborted(); !IsRunAborted(err) {
+		t.Fatalf("Expected run to be aborted, got %v", err)
+	}
+
+	ccLimiter := make(chan struct{}, 1)
+	ccLimiter <- struct{}{}
+	createResource(independent, make(chan *ScheduledResource, 1), finished, ccLimiter)
+	if independent.created || !IsRunAborted(independent.Error) {
+		t.Errorf("Resource should not be created after abort, got error %v", independent.Error)
+	}
+	if len(finished) != 2 {
+		t.Errorf("Expected both resources to be finished, got %d", len(finished))
+	}
+}
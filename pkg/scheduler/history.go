@@ -0,0 +1,195 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+// DurationHistory keeps times resources took to become ready in previous runs, used to estimate
+// time remaining until the deployment is complete
+type DurationHistory interface {
+	// Load returns average readiness durations by resource keys
+	Load() (report.Durations, error)
+	// Record adds readiness duration of resource with given key to its history
+	Record(key string, duration time.Duration) error
+}
+
+// historyDataKey is a key of config map data holding JSON with readiness durations
+const historyDataKey = "durations"
+
+// historySamples is a number of runs averaged by history, so that older runs are forgotten gradually
+const historySamples = 5
+
+// durationRecord is an average readiness duration of resource over a number of runs
+type durationRecord struct {
+	Seconds float64 `json:"seconds"`
+	Samples int     `json:"samples"`
+}
+
+type configMapDurationHistory struct {
+	client corev1.ConfigMapInterface
+	name   string
+	sync.Mutex
+}
+
+// NewConfigMapDurationHistory returns DurationHistory which keeps durations in config map with given name
+func NewConfigMapDurationHistory(client corev1.ConfigMapInterface, name string) DurationHistory {
+	return &configMapDurationHistory{client: client, name: name}
+}
+
+// read returns config map with durations recorded in it. Config map is nil if it does not exist
+func (h *configMapDurationHistory) read() (*v1.ConfigMap, map[string]durationRecord, error) {
+	records := map[string]durationRecord{}
+	configMap, err := h.client.Get(h.name)
+	if errors.IsNotFound(err) {
+		return nil, records, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if data, ok := configMap.Data[historyDataKey]; ok {
+		if err = json.Unmarshal([]byte(data), &records); err != nil {
+			return nil, nil, err
+		}
+	}
+	return configMap, records, nil
+}
+
+func (h *configMapDurationHistory) Load() (report.Durations, error) {
+	h.Lock()
+	defer h.Unlock()
+
+	_, records, err := h.read()
+	if err != nil {
+		return nil, err
+	}
+	durations := make(report.Durations, len(records))
+	for key, record := range records {
+		durations[key] = time.Duration(record.Seconds * float64(time.Second))
+	}
+	return durations, nil
+}
+
+func (h *configMapDurationHistory) Record(key string, duration time.Duration) error {
+	h.Lock()
+	defer h.Unlock()
+
+	configMap, records, err := h.read()
+	if err != nil {
+		return err
+	}
+	record := records[key]
+	if record.Samples < historySamples {
+		record.Samples++
+	}
+	record.Seconds += (duration.Seconds() - record.Seconds) / float64(record.Samples)
+	records[key] = record
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	if configMap == nil {
+		configMap = &v1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: h.name},
+			Data:       map[string]string{historyDataKey: string(data)},
+		}
+		_, err = h.client.Create(configMap)
+		return err
+	}
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[historyDataKey] = string(data)
+	_, err = h.client.Update(configMap)
+	return err
+}
+
+// WithHistory makes graph runs record readiness durations of resources in the history, which is
+// used to estimate progress of the graph
+func (depGraph DependencyGraph) WithHistory(history DurationHistory) {
+	for _, sr := range depGraph {
+		sr.history = history
+	}
+}
+
+// history returns duration history of the graph, or nil if it is not set
+func (depGraph DependencyGraph) history() DurationHistory {
+	for _, sr := range depGraph {
+		return sr.history
+	}
+	return nil
+}
+
+// recordDuration adds time the resource took to become ready in the current run to the history
+func (sr *ScheduledResource) recordDuration(duration time.Duration) {
+	if sr.history == nil {
+		return
+	}
+	if err := sr.history.Record(sr.Key(), duration); err != nil {
+		sr.logger("history").Warningf("Could not record readiness duration of %s: %v", sr.Key(), err)
+	}
+}
+
+// EstimateProgress returns overall progress of the graph with time remaining estimated from
+// durations of previous runs, if the graph has history. States are the ones saved by deployment in a
+// StateStore, they are used to find out since when resources are being created if the graph is
+// deployed by another process. They may be nil
+func (depGraph DependencyGraph) EstimateProgress(states map[string]NodeRecord, now time.Time) (report.GraphProgress, error) {
+	durations := report.Durations{}
+	if history := depGraph.history(); history != nil {
+		var err error
+		if durations, err = history.Load(); err != nil {
+			return report.GraphProgress{}, err
+		}
+	}
+
+	keys := make([]string, 0, len(depGraph))
+	for key := range depGraph {
+		keys = append(keys, key)
+	}
+	nodes := make([]report.ProgressNode, len(keys))
+	parallel(len(keys), func(i int) {
+		key, sr := keys[i], depGraph[keys[i]]
+		node := report.ProgressNode{Key: key}
+		for _, req := range sr.Requires {
+			node.Requires = append(node.Requires, req.Key())
+		}
+		status, _ := sr.Status(nil)
+		sr.RLock()
+		node.Completed = status.Phase == interfaces.ResourceReady || sr.Skipped
+		node.Failed = sr.failed || states[key].State == NodeFailed
+		creationStart := sr.creationStart
+		sr.RUnlock()
+		if creationStart.IsZero() && states[key].State == NodeCreated {
+			creationStart = states[key].Since
+		}
+		if !creationStart.IsZero() {
+			node.Elapsed = now.Sub(creationStart)
+		}
+		nodes[i] = node
+	})
+	return report.EstimateProgress(nodes, durations), nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestConfigMapDurationHistory checks that recorded durations are averaged
+func TestConfigMapDurationHistory(t *testing.T) {
+	c := mocks.NewClient()
+	history := NewConfigMapDurationHistory(c.ConfigMaps(), "history")
+
+	if err := history.Record("pod/ready-1", 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := history.Record("pod/ready-1", 20*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	durations, err := NewConfigMapDurationHistory(c.ConfigMaps(), "history").Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if durations["pod/ready-1"] != 15*time.Second {
+		t.Errorf("Expected average duration 15s, got %v", durations["pod/ready-1"])
+	}
+}
+
+// TestCreateRecordsDurations checks that durations are recorded by graph run and used to estimate
+// progress of the next one
+func TestCreateRecordsDurations(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePod("ready-1"), mocks.MakePod("ready-2"))
+	c.Deps = mocks.NewDependencyClient(mocks.Dependency{Parent: "pod/ready-1", Child: "pod/ready-2"})
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := NewConfigMapDurationHistory(c.ConfigMaps(), "history")
+	depGraph.WithHistory(history)
+	Create(depGraph, 0)
+
+	durations, err := history.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := durations["pod/ready-2"]; !ok || len(durations) != 2 {
+		t.Errorf("Expected durations of both pods, got %v", durations)
+	}
+
+	progress, err := depGraph.EstimateProgress(nil, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Completed != 2 || progress.Total != 2 || progress.RemainingSeconds != 0 {
+		t.Errorf("Unexpected progress %+v", progress.Estimate)
+	}
+	if len(progress.Branches) != 1 || progress.Branches[0].Leaf != "pod/ready-2" {
+		t.Errorf("Unexpected branches %+v", progress.Branches)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// addHooks adds nodes of pre-create and post-ready hooks set in meta of graph resources. Pre-create hook
+// depends on the same parents as its resource, and the resource depends on it. Post-ready hook depends
+// on its resource, and dependents of the resource, except on-error ones, depend on it as well
+func (depGraph DependencyGraph) addHooks(clients *namespacedClients) error {
+	owners := make([]*ScheduledResource, 0, len(depGraph))
+	for _, sr := range depGraph {
+		owners = append(owners, sr)
+	}
+	for _, sr := range owners {
+		for _, phase := range []string{resources.HookPreCreate, resources.HookPostReady} {
+			hook, err := resources.NewHook(sr.Resource, phase, clients.get(sr.namespace))
+			if err != nil {
+				return err
+			}
+			if hook == nil {
+				continue
+			}
+			hookNode := NewScheduledResourceFor(hook)
+			hookNode.namespace = sr.namespace
+			depGraph[hookNode.Key()] = hookNode
+			sr.logger("hooks").Debugf("Adding %s hook %s", phase, hookNode.Key())
+
+			if phase == resources.HookPreCreate {
+				for _, parent := range sr.Requires {
+					hookNode.dependOn(parent, sr.Meta[parent.Key()])
+				}
+				sr.dependOn(hookNode, nil)
+				continue
+			}
+			for _, child := range sr.RequiredBy {
+				if !child.isOnErrorDependency(sr.Key()) {
+					child.dependOn(hookNode, nil)
+				}
+			}
+			hookNode.dependOn(sr, nil)
+		}
+	}
+	return nil
+}
+
+// dependOn adds dependency of the resource on the parent with given meta
+func (sr *ScheduledResource) dependOn(parent *ScheduledResource, meta map[string]string) {
+	sr.Requires = append(sr.Requires, parent)
+	sr.Meta[parent.Key()] = meta
+	parent.RequiredBy = append(parent.RequiredBy, sr)
+}
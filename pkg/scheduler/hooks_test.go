@@ -0,0 +1,76 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// requires returns true if the resource directly depends on the parent
+func requires(sr, parent *ScheduledResource) bool {
+	for _, r := range sr.Requires {
+		if r == parent {
+			return true
+		}
+	}
+	return false
+}
+
+// TestAddHooks checks that hook nodes are added between the resource, its parents and its dependents
+func TestAddHooks(t *testing.T) {
+	c := mocks.NewClient()
+	job := map[string]interface{}{"spec": map[string]interface{}{}}
+	meta := map[string]interface{}{resources.PreCreateHookKey: job, resources.PostReadyHookKey: job}
+	parent := NewScheduledResourceFor(resources.NewPod(mocks.MakePod("ready-1"), c.Pods(), nil))
+	db := NewScheduledResourceFor(resources.NewPod(mocks.MakePod("ready-2"), c.Pods(), meta))
+	child := NewScheduledResourceFor(resources.NewPod(mocks.MakePod("ready-3"), c.Pods(), nil))
+	cleanup := NewScheduledResourceFor(resources.NewPod(mocks.MakePod("ready-4"), c.Pods(), nil))
+	dependOn(db, parent)
+	dependOn(child, db)
+	dependOn(cleanup, db)
+	cleanup.Meta[db.Key()] = map[string]string{"on-error": "true"}
+	depGraph := DependencyGraph{}
+	for _, sr := range []*ScheduledResource{parent, db, child, cleanup} {
+		depGraph[sr.Key()] = sr
+	}
+
+	if err := depGraph.addHooks(newNamespacedClients(c)); err != nil {
+		t.Fatal(err)
+	}
+	preCreate, ok := depGraph["hook/pre-create/pod/ready-2"]
+	if !ok {
+		t.Fatalf("Pre-create hook should be added to the graph, got %v", depGraph)
+	}
+	postReady, ok := depGraph["hook/post-ready/pod/ready-2"]
+	if !ok {
+		t.Fatalf("Post-ready hook should be added to the graph, got %v", depGraph)
+	}
+
+	if !requires(preCreate, parent) || !requires(db, preCreate) {
+		t.Error("Pre-create hook should depend on parents of the resource and the resource on the hook")
+	}
+	if !requires(postReady, db) || !requires(child, postReady) {
+		t.Error("Post-ready hook should depend on the resource and dependents of the resource on the hook")
+	}
+	if requires(cleanup, postReady) {
+		t.Error("On-error dependents of the resource should not depend on its post-ready hook")
+	}
+	if len(depGraph) != 6 {
+		t.Errorf("Expected 6 nodes, got %d", len(depGraph))
+	}
+}
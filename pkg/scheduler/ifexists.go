@@ -0,0 +1,78 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/pkg/api/errors"
+
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// ObjectExistsError is an error of resources with fail if-exists policy whose object already existed
+type ObjectExistsError struct {
+	Key string
+}
+
+func (e ObjectExistsError) Error() string {
+	return fmt.Sprintf("%s already exists and its %s policy is %s", e.Key, resources.IfExistsKey, resources.IfExistsFail)
+}
+
+// IsObjectExists returns true if the error is caused by existing object with fail if-exists policy
+func IsObjectExists(err error) bool {
+	_, ok := err.(ObjectExistsError)
+	return ok
+}
+
+// exists returns true if the object of the resource exists in the cluster, failed objects included
+func (sr *ScheduledResource) exists() (bool, error) {
+	ctx, cancel := sr.requestContext()
+	defer cancel()
+	_, err := statusWithContext(ctx, sr.Resource, nil)
+	if _, failed := err.(resources.ResourceError); err == nil || failed {
+		return true, nil
+	}
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// applyExistencePolicy is called before the first creation of the resource in the run. It fails if the
+// object exists with fail policy, or deletes the object with replace policy. Existing objects with
+// adopt and skip policies are kept, they are compared with the definition by the resource itself.
+// Resources without definitions and deletions are not affected
+func (sr *ScheduledResource) applyExistencePolicy() error {
+	if sr.Existing {
+		return nil
+	}
+	if _, ok := sr.Resource.(resources.Deletion); ok {
+		return nil
+	}
+	policy := resources.GetExistencePolicy(sr.Resource)
+	if policy != resources.IfExistsFail && policy != resources.IfExistsReplace {
+		return nil
+	}
+	exists, err := sr.exists()
+	if err != nil || !exists {
+		return err
+	}
+	if policy == resources.IfExistsFail {
+		return ObjectExistsError{Key: sr.Key()}
+	}
+	sr.logger("create").Infof("Resource %s exists, replacing it according to its %s policy", sr.Key(), resources.IfExistsKey)
+	return resources.DeleteAndWait(sr.run.context(), sr.Resource, resources.DeletionDefault, CheckInterval, sr.untilDeadline(WaitTimeout))
+}
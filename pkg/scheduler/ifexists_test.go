@@ -0,0 +1,66 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// TestIfExistsFail checks that resource with fail policy fails if its object exists and is created otherwise
+func TestIfExistsFail(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePod("ready-1"))
+	meta := map[string]interface{}{resources.IfExistsKey: "fail"}
+	existing := NewScheduledResourceFor(resources.NewPod(mocks.MakePod("ready-1"), c.Pods(), meta))
+	missing := NewScheduledResourceFor(resources.NewPod(mocks.MakePod("ready-2"), c.Pods(), meta))
+	depGraph := DependencyGraph{existing.Key(): existing, missing.Key(): missing}
+
+	Create(depGraph, 0)
+
+	if !existing.failed || !IsObjectExists(existing.Error) {
+		t.Errorf("Resource with existing object should fail, got %v", existing.Error)
+	}
+	if !missing.created {
+		t.Errorf("Resource without existing object should be created, got %v", missing.Error)
+	}
+}
+
+// TestIfExistsReplace checks that existing object of resource with replace policy is created again from definition
+func TestIfExistsReplace(t *testing.T) {
+	live := mocks.MakePod("ready-1")
+	live.Labels = map[string]string{"old": "true"}
+	c := mocks.NewClient(live)
+	meta := map[string]interface{}{resources.IfExistsKey: "replace"}
+	sr := NewScheduledResourceFor(resources.NewPod(mocks.MakePod("ready-1"), c.Pods(), meta))
+	depGraph := DependencyGraph{sr.Key(): sr}
+
+	Create(depGraph, 0)
+
+	if !sr.created {
+		t.Fatalf("Resource should be created, got %v", sr.Error)
+	}
+	pod, err := c.Pods().Get("ready-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := pod.Labels["old"]; ok {
+		t.Error("Existing pod should be replaced with its definition")
+	}
+	if created := depGraph.Created(); len(created) != 1 {
+		t.Errorf("Replaced object should be owned by the run, got %v", created)
+	}
+}
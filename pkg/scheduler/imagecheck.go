@@ -0,0 +1,87 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// definitionPodSpec returns spec of pods created from resource definition, or nil if the resource does
+// not create pods
+func definitionPodSpec(r client.ResourceDefinition) *v1.PodSpec {
+	switch {
+	case r.Pod != nil:
+		return &r.Pod.Spec
+	case r.Job != nil:
+		return &r.Job.Spec.Template.Spec
+	case r.ReplicaSet != nil:
+		return &r.ReplicaSet.Spec.Template.Spec
+	case r.StatefulSet != nil:
+		return &r.StatefulSet.Spec.Template.Spec
+	case r.PetSet != nil:
+		return &r.PetSet.Spec.Template.Spec
+	case r.DaemonSet != nil:
+		return &r.DaemonSet.Spec.Template.Spec
+	case r.Deployment != nil:
+		return &r.Deployment.Spec.Template.Spec
+	}
+	return nil
+}
+
+// podSpecImages returns images of containers of the pod spec with its pull secrets
+func podSpecImages(spec *v1.PodSpec, c client.Interface) []resources.CheckedImage {
+	pullSecrets := make([]string, 0, len(spec.ImagePullSecrets))
+	for _, secret := range spec.ImagePullSecrets {
+		pullSecrets = append(pullSecrets, secret.Name)
+	}
+	images := make([]resources.CheckedImage, 0, len(spec.Containers))
+	for _, container := range spec.Containers {
+		images = append(images, resources.CheckedImage{Image: container.Image, PullSecrets: pullSecrets, Client: c.Secrets()})
+	}
+	return images
+}
+
+// withImageChecks makes image checks of the graph verify images of all resources depending on them,
+// directly or through other resources
+func (depGraph DependencyGraph) withImageChecks(clients *namespacedClients) {
+	for _, sr := range depGraph {
+		check, ok := sr.Resource.(resources.ImageCheck)
+		if !ok {
+			continue
+		}
+		var images []resources.CheckedImage
+		visited := map[string]bool{}
+		var visit func(sr *ScheduledResource)
+		visit = func(sr *ScheduledResource) {
+			for _, child := range sr.RequiredBy {
+				if visited[child.Key()] {
+					continue
+				}
+				visited[child.Key()] = true
+				if child.resDef != nil {
+					if spec := definitionPodSpec(*child.resDef); spec != nil {
+						images = append(images, podSpecImages(spec, clients.get(child.namespace))...)
+					}
+				}
+				visit(child)
+			}
+		}
+		visit(sr)
+		sr.Resource = check.WithImages(images)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// TestWithImageChecks checks that image check verifies images of all resources depending on it
+func TestWithImageChecks(t *testing.T) {
+	c := mocks.NewClient()
+	pod := mocks.MakePod("ready-1")
+	pod.Spec.Containers = []v1.Container{{Name: "app", Image: "app:v1"}}
+	pod.Spec.ImagePullSecrets = []v1.LocalObjectReference{{Name: "registry-key"}}
+	job := mocks.MakeJob("ready-1")
+	job.Spec.Template.Spec.Containers = []v1.Container{{Name: "migrate", Image: "migrate:v1"}}
+	unrelated := mocks.MakePod("ready-2")
+	unrelated.Spec.Containers = []v1.Container{{Name: "other", Image: "other:v1"}}
+	resDefs := []client.ResourceDefinition{{Pod: pod}, {Job: job}, {Pod: unrelated}}
+
+	check := NewScheduledResourceFor(resources.NewImageCheck(&client.ImageCheck{Name: "images", Images: []string{"base:v1"}}, c.Secrets(), nil))
+	depGraph := DependencyGraph{"imagecheck/images": check}
+	for _, r := range resDefs {
+		resource, err := newResourceFromDefinition(r, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		depGraph[resource.Key()] = NewScheduledResourceFor(resource)
+	}
+	dependOn(depGraph["pod/ready-1"], check)
+	dependOn(depGraph["job/ready-1"], depGraph["pod/ready-1"])
+	depGraph.withDefinitions(resDefs)
+	depGraph.withImageChecks(newNamespacedClients(c))
+
+	var images []string
+	for _, image := range depGraph["imagecheck/images"].Resource.(resources.ImageCheck).Images {
+		images = append(images, image.Image)
+		if image.Image == "app:v1" && !reflect.DeepEqual(image.PullSecrets, []string{"registry-key"}) {
+			t.Errorf("Pull secrets of the pod should be used, got %v", image.PullSecrets)
+		}
+	}
+	sort.Strings(images)
+	if expected := []string{"app:v1", "base:v1", "migrate:v1"}; !reflect.DeepEqual(images, expected) {
+		t.Errorf("Expected images %v, got %v", expected, images)
+	}
+}
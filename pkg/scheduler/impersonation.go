@@ -0,0 +1,52 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+)
+
+// ServiceAccountKey is a meta key of resource definition with service account its object is created and
+// deleted on behalf of. Service account must be in the namespace of the object, it is given by its name
+// or as <namespace>/<name>. Objects of definitions without it are managed on behalf of DefaultServiceAccount
+const ServiceAccountKey = "serviceAccount"
+
+// DefaultServiceAccount is a name of service account in the namespace of the object which is impersonated
+// for definitions without ServiceAccountKey meta. If it is not set, objects of such definitions are managed
+// with permissions of AppController itself
+var DefaultServiceAccount = ""
+
+// definitionClient returns client impersonating service account of resource definition, or the default one
+// if the definition does not set it
+func definitionClient(r client.ResourceDefinition, c client.Interface) (client.Interface, error) {
+	value, ok := r.Meta[ServiceAccountKey]
+	if !ok {
+		if DefaultServiceAccount == "" {
+			return c, nil
+		}
+		value = DefaultServiceAccount
+	}
+	serviceAccount, ok := value.(string)
+	if !ok || serviceAccount == "" {
+		return nil, fmt.Errorf("Service account of resource definition %s must be a non-empty string, got %v", r.Name, value)
+	}
+	impersonated, err := c.Impersonate(serviceAccount)
+	if err != nil {
+		return nil, fmt.Errorf("Could not impersonate service account %s for resource definition %s: %v", serviceAccount, r.Name, err)
+	}
+	return impersonated, nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestDefinitionClient checks that only definitions with service account meta use impersonating clients
+func TestDefinitionClient(t *testing.T) {
+	c := mocks.NewClient()
+	r := client.ResourceDefinition{Meta: map[string]interface{}{}}
+	r.Name = "pod-ready-1"
+	if dc, err := definitionClient(r, c); err != nil || dc != client.Interface(c) {
+		t.Errorf("Definitions without service account should use the client itself, got %v, %v", dc, err)
+	}
+
+	r.Meta[ServiceAccountKey] = 42
+	if _, err := definitionClient(r, c); err == nil {
+		t.Error("Service account which is not a string should result in error")
+	}
+
+	// mock clients are not created from REST config, so they cannot impersonate
+	r.Meta[ServiceAccountKey] = "deployer"
+	_, err := definitionClient(r, c)
+	if err == nil || !strings.Contains(err.Error(), "deployer") {
+		t.Errorf("Expected impersonation error, got %v", err)
+	}
+}
+
+// TestDefaultServiceAccount checks that definitions without service account meta impersonate the default
+// service account if it is set
+func TestDefaultServiceAccount(t *testing.T) {
+	defer func(serviceAccount string) { DefaultServiceAccount = serviceAccount }(DefaultServiceAccount)
+	DefaultServiceAccount = "team-deployer"
+
+	r := client.ResourceDefinition{Meta: map[string]interface{}{}}
+	r.Name = "pod-ready-1"
+	_, err := definitionClient(r, mocks.NewClient())
+	if err == nil || !strings.Contains(err.Error(), "team-deployer") {
+		t.Errorf("Expected impersonation of the default service account, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// widgetTemplate is a template of kind registered by tests as downstream code would
+type widgetTemplate struct{}
+
+func (widgetTemplate) NameMatches(def client.ResourceDefinition, name string) bool {
+	return resources.ObjectNameMatches(def, "widget", name)
+}
+
+func (widgetTemplate) New(def client.ResourceDefinition, c client.Interface) interfaces.Resource {
+	return report.SimpleReporter{BaseResource: mocks.NewResource("widget/"+def.Object.Name, interfaces.ResourceReady)}
+}
+
+func (widgetTemplate) NewExisting(name string, c client.Interface) interfaces.Resource {
+	return report.SimpleReporter{BaseResource: mocks.NewResource("widget/"+name, interfaces.ResourceReady)}
+}
+
+func init() {
+	if err := resources.Register(resources.KindTemplate{Kind: "widget", Template: widgetTemplate{}}); err != nil {
+		panic(err)
+	}
+}
+
+// TestRegisteredKind checks that definitions of registered kinds are dispatched to their templates
+func TestRegisteredKind(t *testing.T) {
+	var def client.ResourceDefinition
+	err := json.Unmarshal([]byte(`{"metadata": {"name": "def"}, "object": {"kind": "Widget", "metadata": {"name": "w"}}}`), &def)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := mocks.NewClient()
+
+	key, err := definitionKey(def)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "widget/w" {
+		t.Errorf("expected key widget/w, got %s", key)
+	}
+	resource, err := newResourceFromDefinition(def, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resource.Key() != "widget/w" {
+		t.Errorf("expected resource widget/w, got %s", resource.Key())
+	}
+
+	sr, err := NewScheduledResource("widget", "w", []client.ResourceDefinition{def}, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sr.Existing {
+		t.Error("widget with definition should not be treated as existing")
+	}
+}
+
+// TestUnregisteredKind checks that definitions of kinds which are not registered are rejected
+func TestUnregisteredKind(t *testing.T) {
+	var def client.ResourceDefinition
+	err := json.Unmarshal([]byte(`{"metadata": {"name": "def"}, "object": {"kind": "Gadget", "metadata": {"name": "g"}}}`), &def)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = newResourceFromDefinition(def, mocks.NewClient()); err == nil {
+		t.Error("definition of unregistered kind should result in error")
+	}
+}
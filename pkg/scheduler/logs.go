@@ -0,0 +1,65 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// maxEventLogBytes is a maximal length of logs attached to failure events, longer logs are cut from
+// the beginning
+const maxEventLogBytes = 1024
+
+// withFailureLogs gives resources of the graph clients of their namespaces, which are used to capture
+// logs of Jobs and Pods when they fail
+func (depGraph DependencyGraph) withFailureLogs(clients *namespacedClients) {
+	for _, sr := range depGraph {
+		sr.logsClient = clients.get(sr.namespace)
+	}
+}
+
+// captureFailureLogs saves last lines of container logs of the failed resource and returns them.
+// Resources which have no containers have no logs
+func (sr *ScheduledResource) captureFailureLogs() string {
+	if sr.logsClient == nil {
+		return ""
+	}
+	lines := resources.GetIntMeta(sr.Resource, resources.FailureLogLinesKey, resources.DefaultFailureLogLines)
+	if lines <= 0 {
+		return ""
+	}
+	ctx, cancel := sr.requestContext()
+	defer cancel()
+	logs, err := resources.FailureLogs(ctx, sr.logsClient, sr.Resource.Key(), int64(lines))
+	if err != nil {
+		sr.logger("logs").Warningf("Could not capture logs of failed resource %s: %v", sr.Key(), err)
+		return ""
+	}
+	sr.Lock()
+	sr.failureLogs = logs
+	sr.Unlock()
+	return logs
+}
+
+// failureMessage returns message of failure event with the end of captured logs
+func failureMessage(err error, logs string) string {
+	if logs == "" {
+		return err.Error()
+	}
+	if len(logs) > maxEventLogBytes {
+		logs = "..." + logs[len(logs)-maxEventLogBytes:]
+	}
+	return err.Error() + "\nLast log lines:\n" + logs
+}
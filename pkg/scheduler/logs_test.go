@@ -0,0 +1,85 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+type fakeLogReader struct{}
+
+func (fakeLogReader) Logs(ctx context.Context, namespace, pod, container string, tailLines int64) (string, error) {
+	return "panic: database is not reachable\n", nil
+}
+
+// TestFailureLogsCaptured checks that logs of failed pod are kept in the run record, its event and its
+// dependency report
+func TestFailureLogsCaptured(t *testing.T) {
+	pod := mocks.MakePod("crashloop-1")
+	pod.Spec.Containers = []v1.Container{{Name: "app"}}
+	c := mocks.NewClient(pod)
+	c.LogReader = fakeLogReader{}
+	c.ResDefs = mocks.NewResourceDefinitionClient("pod/crashloop-1")
+	store := NewConfigMapRunRecordStore(c.ConfigMaps(), "test", 0)
+
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	depGraph.WithRunRecords(store)
+	Create(depGraph, 0)
+
+	expected := "==> pod/crashloop-1 app <==\npanic: database is not reachable"
+	if node := lastRecord(t, store).Nodes["pod/crashloop-1"]; node.Logs != expected {
+		t.Errorf("Expected logs %q in run record, got %+v", expected, node)
+	}
+	if depReport := depGraph["pod/crashloop-1"].GetDependencyReport(nil); depReport.Logs != expected {
+		t.Errorf("Expected logs %q in dependency report, got %+v", expected, depReport)
+	}
+
+	events, err := c.Events().List(v1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, event := range events.Items {
+		if event.Reason == EventFailed && strings.HasSuffix(event.Message, "Last log lines:\n"+expected) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected failure event with logs, got %v", events.Items)
+	}
+}
+
+// TestFailureMessage checks that only the end of long logs is attached to events
+func TestFailureMessage(t *testing.T) {
+	err := errors.New("failed")
+	if message := failureMessage(err, ""); message != "failed" {
+		t.Errorf("Expected message without logs, got %q", message)
+	}
+	logs := strings.Repeat("a", maxEventLogBytes) + "end"
+	message := failureMessage(err, logs)
+	if !strings.HasSuffix(message, "end") || len(message) > maxEventLogBytes+len("failed\nLast log lines:\n...") {
+		t.Errorf("Expected truncated logs, got %d bytes", len(message))
+	}
+}
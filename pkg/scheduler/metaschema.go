@@ -0,0 +1,371 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// MetaType is a type of meta value
+type MetaType string
+
+// Possible values for MetaType
+const (
+	// MetaAny values are not checked, e.g. because they are validated separately
+	MetaAny MetaType = ""
+	// MetaBool values are booleans or strings "true" and "false"
+	MetaBool MetaType = "boolean"
+	// MetaInteger values are whole numbers
+	MetaInteger MetaType = "integer"
+	// MetaSeconds values are non-negative numbers of seconds, fractions are allowed
+	MetaSeconds MetaType = "number of seconds"
+	// MetaDuration values are durations like "10m" or numbers of seconds
+	MetaDuration MetaType = "duration"
+	// MetaPercentage values are whole numbers between 0 and 100
+	MetaPercentage MetaType = "percentage"
+	// MetaString values are strings
+	MetaString MetaType = "string"
+	// MetaStringList values are lists of strings or comma-separated strings
+	MetaStringList MetaType = "list of strings"
+	// MetaObject values are objects, e.g. jobs of hooks
+	MetaObject MetaType = "object"
+)
+
+// MetaKeySchema describes a meta key recognized by AppController
+type MetaKeySchema struct {
+	Type MetaType
+	// Kinds are kinds of resources the key applies to, any kind if empty. Kinds of dependency meta
+	// keys are kinds of the parent
+	Kinds []string
+	// Values are allowed values of string keys, any value if empty
+	Values []string
+}
+
+// ResourceMetaKeys are meta keys recognized in resource definitions
+var ResourceMetaKeys = map[string]MetaKeySchema{
+	"retry":                        {Type: MetaInteger},
+	"timeout":                      {Type: MetaInteger},
+	"priority":                     {Type: MetaInteger},
+	"concurrency":                  {Type: MetaInteger},
+	CopiesKey:                      {Type: MetaInteger},
+	DeadlineKey:                    {Type: MetaInteger},
+	RecreateLimitKey:               {Type: MetaInteger},
+	StatusCacheTTLKey:              {Type: MetaInteger},
+	resources.FailureLogLinesKey:   {Type: MetaInteger},
+	PollIntervalKey:                {Type: MetaSeconds},
+	InitialDelayKey:                {Type: MetaSeconds},
+	StableForKey:                   {Type: MetaDuration},
+	RecreateOnFailureKey:           {Type: MetaBool},
+	resources.RestartDependentsKey: {Type: MetaBool, Kinds: []string{"configmap", "secret"}},
+	ConditionKey:                   {Type: MetaString},
+	NamespaceKey:                   {Type: MetaString},
+	ServiceAccountKey:              {Type: MetaString},
+	WindowKey:                      {Type: MetaString},
+	FailurePolicyKey:               {Type: MetaString, Values: []string{string(FailureBlock), string(FailureSkip), string(FailureAbort)}},
+	resources.IfExistsKey:          {Type: MetaString, Values: []string{string(resources.IfExistsAdopt), string(resources.IfExistsSkip), string(resources.IfExistsFail), string(resources.IfExistsReplace)}},
+	resources.IgnoreFieldsKey:      {Type: MetaStringList},
+	resources.PreCreateHookKey:     {Type: MetaObject},
+	resources.PostReadyHookKey:     {Type: MetaObject},
+	resources.ReadinessProbeKey:    {Type: MetaObject},
+	resources.BlueGreenKey:         {Type: MetaObject},
+	resources.SpreadKey:            {Type: MetaAny},
+	resources.SecretSourceKey:      {Type: MetaAny},
+}
+
+// DependencyMetaKeys are meta keys recognized in dependencies. Their values are always strings, types
+// describe what the strings must contain
+var DependencyMetaKeys = map[string]MetaKeySchema{
+	resources.SuccessFactorKey: {Type: MetaPercentage, Kinds: []string{"replicaset", "daemonset", "deployment", "statefulset", "petset", "job"}},
+	resources.JobPolicyKey:     {Type: MetaString, Kinds: []string{"job"}, Values: []string{resources.JobPolicyCompletions, resources.JobPolicySuccesses, resources.JobPolicyRunning}},
+	resources.JobSuccessesKey:  {Type: MetaInteger, Kinds: []string{"job"}},
+	resources.SelectorKindsKey: {Type: MetaStringList, Kinds: []string{"selector"}},
+	"timeout":                  {Type: MetaDuration},
+	"on-timeout":               {Type: MetaString, Values: []string{"skip", "fail"}},
+	"on-error":                 {Type: MetaString},
+	ConditionKey:               {Type: MetaString},
+	DeleteBeforeCreateKey:      {Type: MetaBool},
+}
+
+// RegisterResourceMetaKey makes meta key of resource definitions recognized by validation
+func RegisterResourceMetaKey(key string, schema MetaKeySchema) {
+	ResourceMetaKeys[key] = schema
+}
+
+// RegisterDependencyMetaKey makes meta key of dependencies recognized by validation
+func RegisterDependencyMetaKey(key string, schema MetaKeySchema) {
+	DependencyMetaKeys[key] = schema
+}
+
+// maxTypoDistance is the largest edit distance between unknown key and recognized one at which the
+// unknown key is reported as a typo
+const maxTypoDistance = 2
+
+// ValidateMeta checks meta of resource definitions and dependencies against recognized meta keys. Unknown
+// keys which are close to recognized ones are likely typos and are reported as errors, other unknown keys
+// and keys set on resources which do not use them are warnings. Values of wrong types are errors
+func ValidateMeta(resDefs []client.ResourceDefinition, deps []client.Dependency) []ValidationProblem {
+	var problems []ValidationProblem
+	add := func(problemType string, severity Severity, message string, keys ...string) {
+		problems = append(problems, ValidationProblem{
+			Type:      problemType,
+			Severity:  severity,
+			Message:   message,
+			Resources: keys,
+		})
+	}
+
+	for _, r := range resDefs {
+		key, err := definitionKey(r)
+		if err != nil {
+			// invalid definitions are reported by ValidateGraph
+			continue
+		}
+		kind, _, _ := keyParts(key)
+		for _, name := range sortedMetaKeys(r.Meta) {
+			subject := fmt.Sprintf("Resource %s", key)
+			schema, ok := ResourceMetaKeys[name]
+			if !ok {
+				if _, isDependencyKey := DependencyMetaKeys[name]; isDependencyKey {
+					add(ProblemUnknownMetaKey, SeverityWarning,
+						fmt.Sprintf("%s: %s is a meta key of dependencies, it is ignored in resource definitions", subject, name), key)
+					continue
+				}
+				unknownMetaKey(add, subject, name, ResourceMetaKeys, key)
+				continue
+			}
+			if !schema.appliesTo(kind) {
+				add(ProblemUnknownMetaKey, SeverityWarning,
+					fmt.Sprintf("%s: %s is not used by resources of kind %s, it is ignored", subject, name, kind), key)
+				continue
+			}
+			if err := schema.check(r.Meta[name]); err != nil {
+				add(ProblemInvalidMetaValue, SeverityError, fmt.Sprintf("%s: %s %v", subject, name, err), key)
+			}
+		}
+	}
+
+	for _, d := range deps {
+		kind, _, err := keyParts(d.Parent)
+		if err != nil {
+			// invalid keys are reported by ValidateGraph
+			continue
+		}
+		names := make([]string, 0, len(d.Meta))
+		for name := range d.Meta {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			subject := fmt.Sprintf("Dependency %s -> %s", d.Parent, d.Child)
+			schema, ok := DependencyMetaKeys[name]
+			if !ok {
+				unknownMetaKey(add, subject, name, DependencyMetaKeys, d.Parent, d.Child)
+				continue
+			}
+			if !schema.appliesTo(kind) {
+				add(ProblemUnknownMetaKey, SeverityWarning,
+					fmt.Sprintf("%s: %s is not used by dependencies on resources of kind %s, it is ignored", subject, name, kind), d.Parent, d.Child)
+				continue
+			}
+			value, err := schema.dependencyValue(d.Meta[name])
+			if err == nil {
+				err = schema.check(value)
+			}
+			if err != nil {
+				add(ProblemInvalidMetaValue, SeverityError, fmt.Sprintf("%s: %s %v", subject, name, err), d.Parent, d.Child)
+			}
+		}
+	}
+	return problems
+}
+
+// unknownMetaKey reports meta key which is not recognized, as an error if it is likely a typo of a
+// recognized key
+func unknownMetaKey(add func(string, Severity, string, ...string), subject, name string, known map[string]MetaKeySchema, keys ...string) {
+	if suggestion := closestMetaKey(name, known); suggestion != "" {
+		add(ProblemUnknownMetaKey, SeverityError, fmt.Sprintf("%s: unknown meta key %s, did you mean %s?", subject, name, suggestion), keys...)
+		return
+	}
+	add(ProblemUnknownMetaKey, SeverityWarning, fmt.Sprintf("%s: unknown meta key %s, it is ignored", subject, name), keys...)
+}
+
+// closestMetaKey returns recognized key closest to the name if it is within maxTypoDistance edits of it
+func closestMetaKey(name string, known map[string]MetaKeySchema) string {
+	best := ""
+	bestDistance := maxTypoDistance + 1
+	for _, key := range sortedSchemaKeys(known) {
+		if distance := editDistance(name, key); distance < bestDistance && distance < len(key)/2 {
+			best = key
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+// editDistance returns edit distance between two strings, counting insertions, deletions, substitutions
+// and transpositions of adjacent characters
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(minInt(d[i-1][j]+1, d[i][j-1]+1), d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// appliesTo returns true if the key is used by resources of given kind
+func (s MetaKeySchema) appliesTo(kind string) bool {
+	if len(s.Kinds) == 0 {
+		return true
+	}
+	for _, k := range s.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// dependencyValue converts string value of dependency meta to the value checked against the schema
+func (s MetaKeySchema) dependencyValue(value string) (interface{}, error) {
+	switch s.Type {
+	case MetaInteger, MetaSeconds, MetaPercentage:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be a %s, got %q", s.Type, value)
+		}
+		return number, nil
+	case MetaDuration:
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return float64(seconds), nil
+		}
+	}
+	return value, nil
+}
+
+// check returns error if the value does not match the schema
+func (s MetaKeySchema) check(value interface{}) error {
+	valid := true
+	switch s.Type {
+	case MetaBool:
+		switch v := value.(type) {
+		case bool:
+		case string:
+			_, err := strconv.ParseBool(v)
+			valid = err == nil
+		default:
+			valid = false
+		}
+	case MetaInteger:
+		number, ok := value.(float64)
+		valid = ok && number == float64(int(number))
+	case MetaSeconds:
+		number, ok := value.(float64)
+		valid = ok && number >= 0
+	case MetaDuration:
+		switch v := value.(type) {
+		case float64:
+			valid = v >= 0
+		case string:
+			_, err := time.ParseDuration(v)
+			valid = err == nil
+		default:
+			valid = false
+		}
+	case MetaPercentage:
+		number, ok := value.(float64)
+		valid = ok && number == float64(int(number)) && number >= 0 && number <= 100
+	case MetaString:
+		str, ok := value.(string)
+		if ok && len(s.Values) > 0 && !containsString(s.Values, str) {
+			return fmt.Errorf("must be one of %s, got %q", strings.Join(s.Values, ", "), str)
+		}
+		valid = ok
+	case MetaStringList:
+		switch v := value.(type) {
+		case string:
+		case []interface{}:
+			for _, item := range v {
+				if _, ok := item.(string); !ok {
+					valid = false
+				}
+			}
+		default:
+			valid = false
+		}
+	case MetaObject:
+		_, valid = value.(map[string]interface{})
+	}
+	if !valid {
+		return fmt.Errorf("must be a %s, got %v", s.Type, value)
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedMetaKeys(meta map[string]interface{}) []string {
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedSchemaKeys(schemas map[string]MetaKeySchema) []string {
+	keys := make([]string, 0, len(schemas))
+	for key := range schemas {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
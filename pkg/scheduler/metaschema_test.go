@@ -0,0 +1,102 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestValidateMetaTypo checks that misspelled meta keys are reported as errors with suggestion
+func TestValidateMetaTypo(t *testing.T) {
+	resDefs := []client.ResourceDefinition{
+		{Pod: mocks.MakePod("ready-1"), Meta: map[string]interface{}{"retyr": 3.0, "custom-annotation": "x"}},
+	}
+	deps := []client.Dependency{
+		{Parent: "replicaset/rs", Child: "pod/ready-1", Meta: map[string]string{"sucess_factor": "80"}},
+	}
+
+	problems := ValidateMeta(resDefs, deps)
+	expected := []struct {
+		severity Severity
+		message  string
+	}{
+		{SeverityWarning, "Resource pod/ready-1: unknown meta key custom-annotation, it is ignored"},
+		{SeverityError, "Resource pod/ready-1: unknown meta key retyr, did you mean retry?"},
+		{SeverityError, "Dependency replicaset/rs -> pod/ready-1: unknown meta key sucess_factor, did you mean success_factor?"},
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %v", len(expected), problems)
+	}
+	for i, e := range expected {
+		if problems[i].Type != ProblemUnknownMetaKey || problems[i].Severity != e.severity || problems[i].Message != e.message {
+			t.Errorf("Expected %s %q, got %v", e.severity, e.message, problems[i])
+		}
+	}
+}
+
+// TestValidateMetaValues checks that values of wrong types are reported as errors
+func TestValidateMetaValues(t *testing.T) {
+	resDefs := []client.ResourceDefinition{
+		{Pod: mocks.MakePod("valid"), Meta: map[string]interface{}{
+			"timeout": 60.0, "on-failure": "skip", "recreate-on-failure": "true", "stable-for": "30s", "ignore-fields": []interface{}{"spec.replicas"},
+		}},
+		{Pod: mocks.MakePod("timeout"), Meta: map[string]interface{}{"timeout": "60"}},
+		{Pod: mocks.MakePod("policy"), Meta: map[string]interface{}{"on-failure": "ignore"}},
+		{Pod: mocks.MakePod("stable"), Meta: map[string]interface{}{"stable-for": "a while"}},
+	}
+	deps := []client.Dependency{
+		{Parent: "job/a", Child: "pod/valid", Meta: map[string]string{"success_factor": "50", "timeout": "10m", "job_policy": "running"}},
+		{Parent: "deployment/a", Child: "pod/valid", Meta: map[string]string{"success_factor": "120"}},
+		{Parent: "pod/a", Child: "pod/valid", Meta: map[string]string{"timeout": "1.5"}},
+	}
+
+	problems := ValidateMeta(resDefs, deps)
+	expected := []string{"pod/timeout", "pod/policy", "pod/stable", "deployment/a", "pod/a"}
+	if len(problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %v", len(expected), problems)
+	}
+	for i, key := range expected {
+		if problems[i].Type != ProblemInvalidMetaValue || problems[i].Severity != SeverityError || problems[i].Resources[0] != key {
+			t.Errorf("Expected invalid meta value of %s, got %v", key, problems[i])
+		}
+	}
+	if !strings.Contains(problems[1].Message, "must be one of block, skip, abort") {
+		t.Errorf("Expected allowed values in message, got %s", problems[1].Message)
+	}
+}
+
+// TestValidateMetaKinds checks that keys set on resources which do not use them are reported as warnings
+func TestValidateMetaKinds(t *testing.T) {
+	resDefs := []client.ResourceDefinition{
+		{Pod: mocks.MakePod("ready-1"), Meta: map[string]interface{}{"restart-dependents": true, "success_factor": "80"}},
+	}
+	deps := []client.Dependency{
+		{Parent: "pod/ready-1", Child: "pod/ready-2", Meta: map[string]string{"success_factor": "80"}},
+	}
+
+	problems := ValidateMeta(resDefs, deps)
+	if len(problems) != 3 {
+		t.Fatalf("Expected 3 problems, got %v", problems)
+	}
+	for _, problem := range problems {
+		if problem.Type != ProblemUnknownMetaKey || problem.Severity != SeverityWarning {
+			t.Errorf("Expected warning about ignored key, got %v", problem)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"github.com/Mirantis/k8s-AppController/pkg/metrics"
+)
+
+var (
+	nodesByStatus = metrics.NewGauge("appcontroller_nodes",
+		"Number of graph resources by their status", "status")
+	createAttempts = metrics.NewCounter("appcontroller_create_attempts_total",
+		"Number of attempts to create a resource", "kind")
+	createFailures = metrics.NewCounter("appcontroller_create_failures_total",
+		"Number of resources which were not created after all attempts", "kind")
+	statusPollSeconds = metrics.NewSummary("appcontroller_status_poll_seconds",
+		"Time spent checking status of resources", "kind")
+	timeToReadySeconds = metrics.NewGauge("appcontroller_time_to_ready_seconds",
+		"Time from the start of resource creation until it became ready", "resource")
+	graphCompletion = metrics.NewGauge("appcontroller_graph_completion_percent",
+		"Percentage of graph resources which are processed")
+	apiErrors = metrics.NewCounter("appcontroller_api_throttling_errors_total",
+		"Number of API requests which failed because API server was overloaded or unavailable", "kind")
+	throttledSeconds = metrics.NewCounter("appcontroller_throttled_seconds_total",
+		"Time spent waiting before retrying API requests, by node backoff or open circuit breaker", "reason")
+	breakerTrips = metrics.NewCounter("appcontroller_circuit_breaker_trips_total",
+		"Number of times API requests were paused after consecutive API server errors")
+	breakerOpen = metrics.NewGauge("appcontroller_circuit_breaker_open",
+		"1 if API requests are paused by circuit breaker, 0 otherwise")
+)
+
+// Statuses of resources used as label values of appcontroller_nodes metric
+var metricStatuses = []string{"pending", "creating", "ready", "failed", "skipped"}
+
+// resourceKind returns kind part of resource key
+func resourceKind(key string) string {
+	kind, _, err := keyParts(key)
+	if err != nil {
+		return "unknown"
+	}
+	return kind
+}
+
+// metricStatus returns status of the resource for appcontroller_nodes metric and whether its processing is over
+func (sr *ScheduledResource) metricStatus() (string, bool) {
+	sr.RLock()
+	defer sr.RUnlock()
+	switch {
+	case sr.failed:
+		return "failed", true
+	case sr.Skipped:
+		return "skipped", true
+	case sr.created:
+		return "ready", true
+	case sr.Started:
+		return "creating", false
+	}
+	return "pending", false
+}
+
+// updateGraphMetrics updates metrics describing the whole graph
+func updateGraphMetrics(depGraph DependencyGraph) {
+	counts := map[string]int{}
+	processed := 0
+	for _, sr := range depGraph {
+		status, done := sr.metricStatus()
+		counts[status]++
+		if done {
+			processed++
+		}
+	}
+	for _, status := range metricStatuses {
+		nodesByStatus.Set(float64(counts[status]), status)
+	}
+	completion := 100.0
+	if len(depGraph) > 0 {
+		completion = float64(processed) * 100 / float64(len(depGraph))
+	}
+	graphCompletion.Set(completion)
+}
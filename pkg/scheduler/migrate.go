@@ -0,0 +1,190 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/pkg/api"
+	apierrors "k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/v1"
+	appsbeta1 "k8s.io/client-go/pkg/apis/apps/v1beta1"
+	"k8s.io/client-go/pkg/labels"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	appsalpha1 "github.com/Mirantis/k8s-AppController/pkg/client/petsets/apis/apps/v1alpha1"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// errPetSetsDisabled is returned when the graph contains PetSets, which are supported only with
+// resources.PetSetsEnabled
+var errPetSetsDisabled = errors.New("PetSets are not supported anymore, migrate them to StatefulSets with 'kubeac migrate-petsets' or pass --enable-petsets")
+
+// PetSetToStatefulSet converts PetSet to StatefulSet with the same name, labels, annotations and spec.
+// StatefulSet names claims of its volume claim templates like PetSet does, so claims of the PetSet are
+// used by the StatefulSet. Fields set by the API server and the status are not copied
+func PetSetToStatefulSet(ps *appsalpha1.PetSet) (*appsbeta1.StatefulSet, error) {
+	data, err := json.Marshal(ps.Spec)
+	if err != nil {
+		return nil, err
+	}
+	statefulSet := &appsbeta1.StatefulSet{}
+	if err = json.Unmarshal(data, &statefulSet.Spec); err != nil {
+		return nil, err
+	}
+	statefulSet.ObjectMeta = v1.ObjectMeta{
+		Name:        ps.Name,
+		Namespace:   ps.Namespace,
+		Labels:      ps.Labels,
+		Annotations: ps.Annotations,
+	}
+	return statefulSet, nil
+}
+
+// petSetKeyPrefix and statefulSetKeyPrefix are prefixes of keys of PetSets and StatefulSets in dependencies
+const (
+	petSetKeyPrefix      = "petset/"
+	statefulSetKeyPrefix = "statefulset/"
+)
+
+// MigratePetSets converts PetSets of the graph to StatefulSets. Definitions of PetSets are replaced by
+// definitions of StatefulSets with the same spec, PetSets in the cluster are deleted keeping their pods and
+// replaced by StatefulSets adopting the pods, and dependencies referring to PetSets are recreated referring
+// to StatefulSets. Claims of volume claim templates are never deleted, so StatefulSets use the volumes of
+// PetSets. Definitions are converted as they are stored, without parameters substituted. Returns
+// descriptions of performed changes, or of changes which would be performed if dryRun is true
+func MigratePetSets(c client.Interface, sel labels.Selector, dryRun bool) ([]string, error) {
+	if !c.ResourceExists(appsbeta1.SchemeGroupVersion.WithKind("StatefulSet")) {
+		return nil, errors.New("StatefulSets are not served by the API server, Kubernetes 1.5 or newer is required")
+	}
+	var changes []string
+	change := func(format string, args ...interface{}) {
+		message := fmt.Sprintf(format, args...)
+		logging.Infof(message)
+		changes = append(changes, message)
+	}
+
+	resDefList, err := c.ResourceDefinitions().List(api.ListOptions{LabelSelector: sel})
+	if err != nil {
+		return nil, err
+	}
+	clients := newNamespacedClients(c)
+	for _, listed := range resDefList.Items {
+		if listed.PetSet == nil {
+			continue
+		}
+		// listed definitions may be rendered with parameters, stored ones are converted
+		resDef, err := c.ResourceDefinitions().Get(listed.Name)
+		if err != nil {
+			return changes, err
+		}
+		if resDef.PetSet == nil {
+			continue
+		}
+		statefulSet, err := PetSetToStatefulSet(resDef.PetSet)
+		if err != nil {
+			return changes, fmt.Errorf("Could not convert definition %s: %v", resDef.Name, err)
+		}
+		name := resDef.PetSet.Name
+		namespace := definitionNamespace(*resDef)
+
+		change("Definition %s: petset/%s replaced by statefulset/%s", resDef.Name, name, name)
+		if !dryRun {
+			resDef.PetSet = nil
+			resDef.StatefulSet = statefulSet
+			if _, err = c.ResourceDefinitions().Update(resDef); err != nil {
+				return changes, err
+			}
+		}
+
+		migrated, err := migrateLivePetSet(clients.get(namespace), name, dryRun)
+		if err != nil {
+			return changes, err
+		}
+		if migrated {
+			change("Object petset/%s deleted keeping its pods, statefulset/%s created", name, name)
+		}
+	}
+
+	depList, err := c.Dependencies().List(api.ListOptions{LabelSelector: sel})
+	if err != nil {
+		return changes, err
+	}
+	for _, dep := range depList.Items {
+		parent := migratedKey(dep.Parent)
+		child := migratedKey(dep.Child)
+		if parent == dep.Parent && child == dep.Child {
+			continue
+		}
+		change("Dependency %s: %s -> %s replaced by %s -> %s", dep.Name, dep.Parent, dep.Child, parent, child)
+		if dryRun {
+			continue
+		}
+		if err = c.Dependencies().Delete(dep.Name, nil); err != nil {
+			return changes, err
+		}
+		migrated := &client.Dependency{
+			ObjectMeta: api.ObjectMeta{Name: dep.Name, Labels: dep.Labels, Annotations: dep.Annotations},
+			Parent:     parent,
+			Child:      child,
+			Meta:       dep.Meta,
+		}
+		if _, err = c.Dependencies().Create(migrated); err != nil {
+			return changes, err
+		}
+	}
+	return changes, nil
+}
+
+// migratedKey returns key of StatefulSet for key of PetSet, other keys are returned unchanged
+func migratedKey(key string) string {
+	if !strings.HasPrefix(key, petSetKeyPrefix) {
+		return key
+	}
+	return statefulSetKeyPrefix + strings.TrimPrefix(key, petSetKeyPrefix)
+}
+
+// migrateLivePetSet replaces PetSet with given name in the cluster by StatefulSet. The PetSet is deleted
+// with its pods orphaned, so that the StatefulSet with the same selector adopts them without restarts.
+// Returns false if there is no PetSet to migrate
+func migrateLivePetSet(c client.Interface, name string, dryRun bool) (bool, error) {
+	ps, err := c.PetSets().Get(name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	statefulSet, err := PetSetToStatefulSet(ps)
+	if err != nil {
+		return false, err
+	}
+	if dryRun {
+		return true, nil
+	}
+
+	orphan := true
+	if err = c.PetSets().Delete(name, &api.DeleteOptions{OrphanDependents: &orphan}); err != nil {
+		return false, err
+	}
+	if _, err = c.StatefulSets().Create(statefulSet); err != nil {
+		return false, fmt.Errorf("PetSet %s was deleted, but StatefulSet could not be created, its pods are left without controller: %v", name, err)
+	}
+	return true, nil
+}
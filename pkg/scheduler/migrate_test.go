@@ -0,0 +1,158 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/labels"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// migrationDefinitions is a resource definitions client keeping definitions in memory
+type migrationDefinitions struct {
+	client.ResourceDefinitionsInterface
+	items map[string]client.ResourceDefinition
+}
+
+func (d *migrationDefinitions) List(opts api.ListOptions) (*client.ResourceDefinitionList, error) {
+	list := &client.ResourceDefinitionList{}
+	for _, name := range []string{"db", "web"} {
+		list.Items = append(list.Items, d.items[name])
+	}
+	return list, nil
+}
+
+func (d *migrationDefinitions) Get(name string) (*client.ResourceDefinition, error) {
+	r := d.items[name]
+	return &r, nil
+}
+
+func (d *migrationDefinitions) Update(r *client.ResourceDefinition) (*client.ResourceDefinition, error) {
+	d.items[r.Name] = *r
+	return r, nil
+}
+
+// migrationDependencies is a dependencies client keeping dependencies in memory
+type migrationDependencies struct {
+	items []client.Dependency
+}
+
+func (d *migrationDependencies) List(opts api.ListOptions) (*client.DependencyList, error) {
+	return &client.DependencyList{Items: append([]client.Dependency{}, d.items...)}, nil
+}
+
+func (d *migrationDependencies) Create(dep *client.Dependency) (*client.Dependency, error) {
+	d.items = append(d.items, *dep)
+	return dep, nil
+}
+
+func (d *migrationDependencies) UpdateStatus(name string, status *client.DependencyStatus) error {
+	return nil
+}
+
+func (d *migrationDependencies) Delete(name string, opts *api.DeleteOptions) error {
+	for i, dep := range d.items {
+		if dep.Name == name {
+			d.items = append(d.items[:i], d.items[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// TestPetSetToStatefulSet checks that spec and volume claim templates of PetSet are kept
+func TestPetSetToStatefulSet(t *testing.T) {
+	ps := mocks.MakePetSet("db")
+	ps.ResourceVersion = "42"
+	ps.Spec.Template.ObjectMeta.Labels["app"] = "db"
+	ps.Spec.ServiceName = "db"
+	claim := v1.PersistentVolumeClaim{}
+	claim.Name = "data"
+	ps.Spec.VolumeClaimTemplates = []v1.PersistentVolumeClaim{claim}
+
+	statefulSet, err := PetSetToStatefulSet(ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statefulSet.Name != "db" || statefulSet.ResourceVersion != "" {
+		t.Errorf("Expected statefulset db without resource version, got %v", statefulSet.ObjectMeta)
+	}
+	if *statefulSet.Spec.Replicas != 3 || statefulSet.Spec.ServiceName != "db" || statefulSet.Spec.Template.Labels["app"] != "db" {
+		t.Errorf("Spec of petset was not kept, got %v", statefulSet.Spec)
+	}
+	if claims := statefulSet.Spec.VolumeClaimTemplates; len(claims) != 1 || claims[0].Name != "data" {
+		t.Errorf("Expected volume claim template data, got %v", claims)
+	}
+}
+
+// TestMigratePetSets checks that definitions and dependencies of PetSets are converted, and nothing is
+// changed in dry run
+func TestMigratePetSets(t *testing.T) {
+	c := mocks.NewClient()
+	defs := &migrationDefinitions{items: map[string]client.ResourceDefinition{
+		"db":  {ObjectMeta: api.ObjectMeta{Name: "db"}, PetSet: mocks.MakePetSet("db")},
+		"web": {ObjectMeta: api.ObjectMeta{Name: "web"}, Pod: mocks.MakePod("web")},
+	}}
+	deps := &migrationDependencies{items: []client.Dependency{
+		{ObjectMeta: api.ObjectMeta{Name: "db-web"}, Parent: "petset/db", Child: "pod/web", Meta: map[string]string{"success_factor": "50"}},
+		{ObjectMeta: api.ObjectMeta{Name: "web-other"}, Parent: "pod/web", Child: "pod/other"},
+	}}
+	c.ResDefs = defs
+	c.Deps = deps
+
+	changes, err := MigratePetSets(c, labels.Everything(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || defs.items["db"].PetSet == nil || deps.items[0].Parent != "petset/db" {
+		t.Errorf("Dry run should only report 2 changes, got %v", changes)
+	}
+
+	if _, err = MigratePetSets(c, labels.Everything(), false); err != nil {
+		t.Fatal(err)
+	}
+	db := defs.items["db"]
+	if db.PetSet != nil || db.StatefulSet == nil || db.StatefulSet.Name != "db" {
+		t.Errorf("Expected petset definition to be replaced by statefulset, got %v", db)
+	}
+	expected := []client.Dependency{
+		{ObjectMeta: api.ObjectMeta{Name: "web-other"}, Parent: "pod/web", Child: "pod/other"},
+		{ObjectMeta: api.ObjectMeta{Name: "db-web"}, Parent: "statefulset/db", Child: "pod/web", Meta: map[string]string{"success_factor": "50"}},
+	}
+	if !reflect.DeepEqual(deps.items, expected) {
+		t.Errorf("Unexpected dependencies after migration:\n%v\nexpected:\n%v", deps.items, expected)
+	}
+}
+
+// TestPetSetsDisabled checks that graph with PetSets is rejected unless PetSets are enabled
+func TestPetSetsDisabled(t *testing.T) {
+	c := mocks.NewClient1_4()
+	c.ResDefs = mocks.NewResourceDefinitionClient("petset/db")
+	if _, err := BuildDependencyGraph(c, nil); err != errPetSetsDisabled {
+		t.Errorf("Expected PetSets to be disabled, got %v", err)
+	}
+
+	c.ResDefs = mocks.NewResourceDefinitionClient()
+	c.Deps = mocks.NewDependencyClient(mocks.Dependency{Parent: "petset/db", Child: "pod/ready-1"})
+	if _, err := BuildDependencyGraph(c, nil); err != errPetSetsDisabled {
+		t.Errorf("Expected dependency on existing PetSet to be rejected, got %v", err)
+	}
+}
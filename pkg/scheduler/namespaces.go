@@ -0,0 +1,115 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"strings"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// NamespaceSeparator separates name of the resource from its namespace in keys of resources outside
+// ac namespace, e.g. pod/db@storage
+const NamespaceSeparator = "@"
+
+// NamespaceKey is a meta key of resource definition with namespace of its object. Objects of definitions
+// without it are created in ac namespace
+const NamespaceKey = "namespace"
+
+// namespaceKind is a kind of graph nodes of namespaces
+const namespaceKind = "namespace"
+
+// namespacedKey returns key of the resource qualified with namespace, if it is not ac namespace
+func namespacedKey(key, namespace string) string {
+	if namespace == "" {
+		return key
+	}
+	return key + NamespaceSeparator + namespace
+}
+
+// splitNamespace returns name and namespace of the resource from name part of its key. Namespace is
+// empty for resources in ac namespace
+func splitNamespace(name string) (string, string) {
+	i := strings.LastIndex(name, NamespaceSeparator)
+	if i < 0 {
+		return name, ""
+	}
+	return name[:i], name[i+len(NamespaceSeparator):]
+}
+
+// definitionNamespace returns namespace of the object described by resource definition, or empty
+// string if the object is created in ac namespace or is cluster-scoped
+func definitionNamespace(r client.ResourceDefinition) string {
+	if clusterScoped(r) {
+		return ""
+	}
+	namespace, _ := r.Meta[NamespaceKey].(string)
+	return namespace
+}
+
+// clusterScoped returns true if resource definition describes object which belongs to no namespace
+func clusterScoped(r client.ResourceDefinition) bool {
+	key, err := definitionObjectKey(r)
+	if err != nil {
+		return false
+	}
+	kind, _, err := keyParts(key)
+	return err == nil && resources.ClusterScopedKinds[kind]
+}
+
+// namespacedClients caches clients of namespaces used by the graph
+type namespacedClients struct {
+	base    client.Interface
+	clients map[string]client.Interface
+}
+
+func newNamespacedClients(c client.Interface) *namespacedClients {
+	return &namespacedClients{base: c, clients: map[string]client.Interface{}}
+}
+
+// get returns client for given namespace, or base client for ac namespace
+func (n *namespacedClients) get(namespace string) client.Interface {
+	if namespace == "" {
+		return n.base
+	}
+	c, ok := n.clients[namespace]
+	if !ok {
+		c = n.base.WithNamespace(namespace)
+		n.clients[namespace] = c
+	}
+	return c
+}
+
+// addNamespaces adds node for every namespace of graph resources other than ac namespace. Resources
+// in the namespace depend on its node, so the namespace is created before them. Added nodes are never
+// deleted, neither on rollback nor before retries
+func (depGraph DependencyGraph) addNamespaces(c client.Interface) {
+	for _, sr := range depGraph {
+		if sr.namespace == "" {
+			continue
+		}
+		key := resources.NamespaceKey(sr.namespace)
+		namespace, ok := depGraph[key]
+		if !ok {
+			namespace = NewScheduledResourceFor(resources.NewNamespace(sr.namespace, c.Namespaces()))
+			namespace.autoNamespace = true
+			depGraph[key] = namespace
+		}
+		sr.Requires = append(sr.Requires, namespace)
+		sr.Meta[key] = nil
+		namespace.RequiredBy = append(namespace.RequiredBy, sr)
+	}
+}
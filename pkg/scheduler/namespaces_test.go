@@ -0,0 +1,152 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestMultiNamespaceGraph checks that resources of other namespaces are keyed by namespace, depend on
+// their namespace and are created in it
+func TestMultiNamespaceGraph(t *testing.T) {
+	c := mocks.NewClient()
+	c.ResDefs = mocks.NewResourceDefinitionClient("pod/ready-1", "pod/ready-2@other")
+	c.Deps = mocks.NewDependencyClient(mocks.Dependency{Parent: "pod/ready-1", Child: "pod/ready-2@other"})
+
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(depGraph) != 3 {
+		t.Errorf("Expected 2 pods and a namespace, got %d resources", len(depGraph))
+	}
+	sr, ok := depGraph["pod/ready-2@other"]
+	if !ok {
+		t.Fatal("Resource pod/ready-2@other not found")
+	}
+	if sr.Existing {
+		t.Error("Resource pod/ready-2@other should be created from its definition")
+	}
+	if sr.Key() != "pod/ready-2@other" {
+		t.Errorf("Unexpected key %s", sr.Key())
+	}
+	requires := map[string]bool{}
+	for _, parent := range sr.Requires {
+		requires[parent.Key()] = true
+	}
+	if !requires["pod/ready-1"] || !requires["namespace/other"] {
+		t.Errorf("Expected pod/ready-2@other to depend on pod/ready-1 and namespace/other, got %v", requires)
+	}
+
+	Create(depGraph, 0)
+
+	if _, err = c.Namespaces().Get("other"); err != nil {
+		t.Errorf("Namespace should be created: %v", err)
+	}
+	if _, err = c.WithNamespace("other").Pods().Get("ready-2"); err != nil {
+		t.Errorf("Pod should be created in namespace other: %v", err)
+	}
+}
+
+// TestNamespacedClients checks that clients are cached by namespace
+func TestNamespacedClients(t *testing.T) {
+	c := mocks.NewClient()
+	clients := newNamespacedClients(c)
+	if clients.get("") != c {
+		t.Error("Base client should be used for ac namespace")
+	}
+	other := clients.get("other")
+	if other != clients.get("other") {
+		t.Error("Client of namespace should be cached")
+	}
+}
+
+// TestNamespacedDefinitionKey checks that keys of definitions with namespace are qualified with it
+func TestNamespacedDefinitionKey(t *testing.T) {
+	r := client.ResourceDefinition{Pod: mocks.MakePod("p"), Meta: map[string]interface{}{NamespaceKey: "other"}}
+	key, err := definitionKey(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "pod/p@other" {
+		t.Errorf("Expected key pod/p@other, got %s", key)
+	}
+
+	name, namespace := splitNamespace("p@other")
+	if name != "p" || namespace != "other" {
+		t.Errorf("Unexpected name %s and namespace %s", name, namespace)
+	}
+}
+
+// TestClusterScopedResources checks that cluster-scoped objects share the graph with namespaced ones,
+// are keyed without namespace and are created with cluster clients
+func TestClusterScopedResources(t *testing.T) {
+	c := mocks.NewClient()
+	c.ResDefs = mocks.NewResourceDefinitionClient("namespace/other", "storageclass/fast", "persistentvolume/data", "pod/ready-1@other")
+	c.Deps = mocks.NewDependencyClient(
+		mocks.Dependency{Parent: "storageclass/fast", Child: "persistentvolume/data"},
+		mocks.Dependency{Parent: "persistentvolume/data", Child: "pod/ready-1@other"},
+	)
+
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"namespace/other", "storageclass/fast", "persistentvolume/data", "pod/ready-1@other"} {
+		sr, ok := depGraph[key]
+		if !ok {
+			t.Fatalf("Resource %s not found in %v", key, depGraph)
+		}
+		if sr.Existing {
+			t.Errorf("Resource %s should be created from its definition", key)
+		}
+	}
+	if len(depGraph) != 4 {
+		t.Errorf("Expected defined namespace to be used as node of the namespace, got %d resources", len(depGraph))
+	}
+
+	Create(depGraph, 0)
+
+	if _, err = c.Namespaces().Get("other"); err != nil {
+		t.Errorf("Namespace should be created: %v", err)
+	}
+	if _, err = c.StorageClasses().Get("fast"); err != nil {
+		t.Errorf("Storage class should be created: %v", err)
+	}
+	if _, err = c.PersistentVolumes().Get("data"); err != nil {
+		t.Errorf("Persistent volume should be created: %v", err)
+	}
+}
+
+// TestClusterScopedDefinitionKey checks that namespace meta of cluster-scoped definitions is ignored
+func TestClusterScopedDefinitionKey(t *testing.T) {
+	r := client.ResourceDefinition{
+		PersistentVolume: mocks.MakePersistentVolume("data"),
+		Meta:             map[string]interface{}{NamespaceKey: "other"},
+	}
+	key, err := definitionKey(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "persistentvolume/data" {
+		t.Errorf("Expected key persistentvolume/data, got %s", key)
+	}
+	if definitionOwnerReference(r) != nil {
+		t.Error("Cluster-scoped objects cannot be owned by definitions")
+	}
+}
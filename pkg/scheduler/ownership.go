@@ -0,0 +1,143 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// DefaultGraphName is a name of the graph put in labels of created objects if the graph has no name
+const DefaultGraphName = "default"
+
+// WithName sets name of the graph which is put in labels of objects created by it
+func (depGraph DependencyGraph) WithName(name string) {
+	for _, sr := range depGraph {
+		sr.graphName = name
+	}
+}
+
+// Name returns name of the graph put in labels of objects created by it
+func (depGraph DependencyGraph) Name() string {
+	for _, sr := range depGraph {
+		if sr.graphName != "" {
+			return sr.graphName
+		}
+	}
+	return DefaultGraphName
+}
+
+// definitionObjectMeta returns metadata of the object described by resource definition, or nil if
+// the resource is not a K8s object
+func definitionObjectMeta(r client.ResourceDefinition) *v1.ObjectMeta {
+	switch {
+	case r.Pod != nil:
+		return &r.Pod.ObjectMeta
+	case r.Job != nil:
+		return &r.Job.ObjectMeta
+	case r.Service != nil:
+		return &r.Service.ObjectMeta
+	case r.ReplicaSet != nil:
+		return &r.ReplicaSet.ObjectMeta
+	case r.StatefulSet != nil:
+		return &r.StatefulSet.ObjectMeta
+	case r.ServiceAccount != nil:
+		return &r.ServiceAccount.ObjectMeta
+	case r.PetSet != nil:
+		return &r.PetSet.ObjectMeta
+	case r.DaemonSet != nil:
+		return &r.DaemonSet.ObjectMeta
+	case r.ConfigMap != nil:
+		return &r.ConfigMap.ObjectMeta
+	case r.Secret != nil:
+		return &r.Secret.ObjectMeta
+	case r.Deployment != nil:
+		return &r.Deployment.ObjectMeta
+	case r.PersistentVolumeClaim != nil:
+		return &r.PersistentVolumeClaim.ObjectMeta
+	case r.NamespaceObject != nil:
+		return &r.NamespaceObject.ObjectMeta
+	case r.PersistentVolume != nil:
+		return &r.PersistentVolume.ObjectMeta
+	case r.StorageClass != nil:
+		return &r.StorageClass.ObjectMeta
+	case r.ClusterRole != nil:
+		return &r.ClusterRole.ObjectMeta
+	}
+	return nil
+}
+
+// definitionOwnerReference returns reference to resource definition for owner references of the
+// object created from it. Owner must be in the same namespace as the object, so objects created in
+// other namespaces, cluster-scoped objects and definitions not stored in the cluster yet have no owner
+func definitionOwnerReference(r client.ResourceDefinition) *v1.OwnerReference {
+	if definitionNamespace(r) != "" || clusterScoped(r) || r.UID == "" {
+		return nil
+	}
+	return &v1.OwnerReference{
+		APIVersion: client.SchemeGroupVersion.String(),
+		Kind:       "Definition",
+		Name:       r.Name,
+		UID:        r.UID,
+	}
+}
+
+// withOwnership makes resources of the graph which have definitions label objects they create and
+// set their definitions as owners of the objects
+func (depGraph DependencyGraph) withOwnership(resDefs []client.ResourceDefinition) {
+	for _, r := range resDefs {
+		key, err := definitionKey(r)
+		if err != nil {
+			continue
+		}
+		if sr, ok := depGraph[key]; ok {
+			sr.object = definitionObjectMeta(r)
+			sr.owner = definitionOwnerReference(r)
+		}
+	}
+}
+
+// labelObjects sets labels with graph name, run ID and node key, and owner references in metadata
+// of objects which are created by the run
+func (depGraph DependencyGraph) labelObjects(runID string) {
+	graphName := depGraph.Name()
+	for key, sr := range depGraph {
+		sr.Lock()
+		if sr.object != nil {
+			if sr.object.Labels == nil {
+				sr.object.Labels = map[string]string{}
+			}
+			sr.object.Labels[resources.GraphLabel] = resources.LabelValue(graphName)
+			sr.object.Labels[resources.RunIDLabel] = resources.LabelValue(runID)
+			sr.object.Labels[resources.NodeLabel] = resources.LabelValue(key)
+			if sr.owner != nil && !hasOwner(sr.object, *sr.owner) {
+				sr.object.OwnerReferences = append(sr.object.OwnerReferences, *sr.owner)
+			}
+		}
+		sr.Unlock()
+	}
+}
+
+// hasOwner returns true if the object already has owner reference to the same owner
+func hasOwner(object *v1.ObjectMeta, owner v1.OwnerReference) bool {
+	for _, ref := range object.OwnerReferences {
+		if ref.UID == owner.UID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// TestLabelObjects checks that objects created from definitions get labels of the graph and the run,
+// and owner references to their definitions in the same namespace
+func TestLabelObjects(t *testing.T) {
+	local := client.ResourceDefinition{
+		ObjectMeta: api.ObjectMeta{Name: "db-definition", UID: "1234"},
+		Pod:        &v1.Pod{ObjectMeta: v1.ObjectMeta{Name: "db", Labels: map[string]string{"app": "db"}}},
+	}
+	remote := client.ResourceDefinition{
+		ObjectMeta: api.ObjectMeta{Name: "web-definition", UID: "5678"},
+		Meta:       map[string]interface{}{NamespaceKey: "frontend"},
+		Service:    &v1.Service{ObjectMeta: v1.ObjectMeta{Name: "web"}},
+	}
+	depGraph := DependencyGraph{
+		"pod/db":               NewScheduledResourceFor(report.SimpleReporter{BaseResource: mocks.NewResource("pod/db", interfaces.ResourceReady)}),
+		"service/web@frontend": NewScheduledResourceFor(report.SimpleReporter{BaseResource: mocks.NewResource("service/web", interfaces.ResourceReady)}),
+		"pod/existing":         NewScheduledResourceFor(report.SimpleReporter{BaseResource: mocks.NewResource("pod/existing", interfaces.ResourceReady)}),
+	}
+	depGraph.withOwnership([]client.ResourceDefinition{local, remote})
+	depGraph.WithName("my app")
+
+	depGraph.labelObjects("run1")
+	depGraph.labelObjects("run2")
+
+	expected := map[string]string{
+		"app":                "db",
+		resources.GraphLabel: "my.app",
+		resources.RunIDLabel: "run2",
+		resources.NodeLabel:  "pod.db",
+	}
+	for label, value := range expected {
+		if local.Pod.Labels[label] != value {
+			t.Errorf("Expected label %s of pod to be %q, got %q", label, value, local.Pod.Labels[label])
+		}
+	}
+	if refs := local.Pod.OwnerReferences; len(refs) != 1 || refs[0].UID != "1234" || refs[0].Kind != "Definition" {
+		t.Errorf("Expected pod to be owned by its definition, got %v", refs)
+	}
+
+	if remote.Service.Labels[resources.NodeLabel] != "service.web.frontend" {
+		t.Errorf("Expected service to be labeled with its node key, got %v", remote.Service.Labels)
+	}
+	if len(remote.Service.OwnerReferences) != 0 {
+		t.Errorf("Object in other namespace should have no owner, got %v", remote.Service.OwnerReferences)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestParallel checks that every index is processed and the number of concurrent calls is bounded
+func TestParallel(t *testing.T) {
+	defer func(workers int) { StatusWorkers = workers }(StatusWorkers)
+	StatusWorkers = 3
+
+	var lock sync.Mutex
+	running, maxRunning := 0, 0
+	done := make([]bool, 20)
+	parallel(len(done), func(i int) {
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+		done[i] = true
+
+		lock.Lock()
+		running--
+		lock.Unlock()
+	})
+
+	for i, ok := range done {
+		if !ok {
+			t.Errorf("Index %d was not processed", i)
+		}
+	}
+	if maxRunning > 3 {
+		t.Errorf("Expected at most 3 concurrent calls, got %d", maxRunning)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/client-go/pkg/api/errors"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// PlannedResource is a resource in the deployment plan
+type PlannedResource struct {
+	Key string `json:"key"`
+	// Existing is true if the object is already present in the cluster
+	Existing bool `json:"existing"`
+	// Defined is true if the resource has a definition, otherwise it is expected to exist
+	Defined bool `json:"defined"`
+}
+
+// Plan is an order in which resources of the graph are created. Resources of each batch depend
+// only on resources of previous batches, so they can be created in parallel
+type Plan [][]PlannedResource
+
+// Plan returns the deployment plan of the graph. Batches are ordered the same way resources are
+// queued for creation: by priority and then by key. Graph is not modified and nothing is created
+func (depGraph DependencyGraph) Plan() (Plan, error) {
+	remaining := map[string]int{}
+	var current resourceQueue
+	for key, sr := range depGraph {
+		remaining[key] = len(sr.Requires)
+		if len(sr.Requires) == 0 {
+			current.push(sr)
+		}
+	}
+
+	var plan Plan
+	planned := 0
+	for len(current) > 0 {
+		batch := make([]PlannedResource, 0, len(current))
+		var next resourceQueue
+		for _, sr := range current {
+			batch = append(batch, PlannedResource{
+				Key:      sr.Key(),
+				Existing: existsInCluster(sr),
+				Defined:  !sr.Existing,
+			})
+			for _, req := range sr.RequiredBy {
+				remaining[req.Key()]--
+				if remaining[req.Key()] == 0 {
+					next.push(req)
+				}
+			}
+		}
+		plan = append(plan, batch)
+		planned += len(batch)
+		current = next
+	}
+
+	if planned != len(depGraph) {
+		return nil, fmt.Errorf("Dependency graph has cycles, it cannot be deployed")
+	}
+	return plan, nil
+}
+
+// existsInCluster checks if the object of the resource is already present in the cluster
+func existsInCluster(sr *ScheduledResource) bool {
+	kind, _, err := keyParts(sr.Key())
+	if err != nil || resources.NonObjectKinds[kind] {
+		return false
+	}
+	var resource interfaces.BaseResource = sr.Resource
+	if deletion, ok := sr.Resource.(resources.Deletion); ok {
+		resource = deletion.Resource
+	}
+	ctx, cancel := newRequestContext(nil, time.Time{})
+	defer cancel()
+	_, err = statusWithContext(ctx, resource, nil)
+	return !errors.IsNotFound(err)
+}
+
+// AsText returns a human-readable representation of the plan as a slice
+func (p Plan) AsText() []string {
+	var ret []string
+	for i, batch := range p {
+		ret = append(ret, fmt.Sprintf("Batch %d:", i+1))
+		keys := make([]string, 0, len(batch))
+		for _, r := range batch {
+			line := r.Key
+			if r.Existing {
+				line += " (exists)"
+			}
+			if !r.Defined {
+				line += " (no definition)"
+			}
+			keys = append(keys, line)
+		}
+		ret = append(ret, report.Indent(report.ReportIndentSize, keys)...)
+	}
+	return ret
+}
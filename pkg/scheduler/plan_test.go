@@ -0,0 +1,71 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestPlan checks that resources are split into batches by their dependencies
+func TestPlan(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePod("ready-1"))
+	c.ResDefs = mocks.NewResourceDefinitionClient("pod/ready-2", "pod/ready-3")
+	c.Deps = mocks.NewDependencyClient(
+		mocks.Dependency{Parent: "pod/ready-1", Child: "pod/ready-2"},
+		mocks.Dependency{Parent: "pod/ready-1", Child: "pod/ready-3"},
+		mocks.Dependency{Parent: "pod/ready-2", Child: "pod/ready-3"},
+	)
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := depGraph.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Plan{
+		{{Key: "pod/ready-1", Existing: true, Defined: false}},
+		{{Key: "pod/ready-2", Existing: false, Defined: true}},
+		{{Key: "pod/ready-3", Existing: false, Defined: true}},
+	}
+	if len(plan) != len(expected) {
+		t.Fatalf("Expected %d batches, got %v", len(expected), plan)
+	}
+	for i := range expected {
+		if len(plan[i]) != 1 || plan[i][0] != expected[i][0] {
+			t.Errorf("Expected batch %d to be %v, got %v", i+1, expected[i], plan[i])
+		}
+	}
+}
+
+// TestPlanCycle checks that graph with cycles has no plan
+func TestPlanCycle(t *testing.T) {
+	c := mocks.NewClient()
+	c.Deps = mocks.NewDependencyClient(
+		mocks.Dependency{Parent: "pod/ready-1", Child: "pod/ready-2"},
+		mocks.Dependency{Parent: "pod/ready-2", Child: "pod/ready-1"},
+	)
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = depGraph.Plan(); err == nil {
+		t.Error("Error expected for graph with cycles")
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// PollIntervalKey is a meta key of resource definition with interval in seconds between checks of
+// the resource status
+const PollIntervalKey = "poll-interval"
+
+// InitialDelayKey is a meta key of resource definition with time in seconds to wait after the object
+// is created before its status is checked for the first time
+const InitialDelayKey = "initial-delay"
+
+// PollingOptions set how often statuses of resources are checked. Intervals and delays of kinds
+// override the defaults, and meta of resource definitions overrides both
+type PollingOptions struct {
+	// Interval is an interval between status checks, CheckInterval if not set
+	Interval     time.Duration
+	InitialDelay time.Duration
+	// KindIntervals and KindInitialDelays are keyed by resource kind, e.g. job
+	KindIntervals     map[string]time.Duration
+	KindInitialDelays map[string]time.Duration
+}
+
+// WithPolling sets intervals of status checks and initial delays of resources of the graph
+func (depGraph DependencyGraph) WithPolling(options PollingOptions) {
+	for _, sr := range depGraph {
+		sr.polling = options
+	}
+}
+
+// ParseKindDurations parses kind=seconds pairs, e.g. job=10, into durations keyed by kind
+func ParseKindDurations(pairs []string) (map[string]time.Duration, error) {
+	durations := make(map[string]time.Duration, len(pairs))
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%q is not in kind=seconds form", pair)
+		}
+		if _, ok := resources.KindToResourceTemplate[parts[0]]; !ok {
+			return nil, fmt.Errorf("unknown resource kind %s", parts[0])
+		}
+		seconds, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("%q is not a valid number of seconds", parts[1])
+		}
+		durations[parts[0]] = secondsDuration(seconds)
+	}
+	return durations, nil
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// durationMeta returns duration in seconds set in resource meta, fractions of seconds are allowed
+func (sr *ScheduledResource) durationMeta(key string) (time.Duration, bool) {
+	value := sr.Resource.Meta(key)
+	if value == nil {
+		return 0, false
+	}
+	seconds, ok := value.(float64)
+	if !ok || seconds < 0 {
+		sr.logger("wait").Warningf("Metadata parameter '%s' for resource '%s' is set to '%v' but it is not a number of seconds, ignoring it", key, sr.Key(), value)
+		return 0, false
+	}
+	return secondsDuration(seconds), true
+}
+
+// pollInterval returns interval between status checks of the resource
+func (sr *ScheduledResource) pollInterval() time.Duration {
+	if interval, ok := sr.durationMeta(PollIntervalKey); ok && interval > 0 {
+		return interval
+	}
+	if interval, ok := sr.polling.KindIntervals[resourceKind(sr.Resource.Key())]; ok && interval > 0 {
+		return interval
+	}
+	if sr.polling.Interval > 0 {
+		return sr.polling.Interval
+	}
+	return CheckInterval
+}
+
+// watchedPollInterval returns interval between status checks of the resource whose object is watched.
+// Interval set by poll-interval meta is kept, other intervals are increased to WatchPollInterval
+func (sr *ScheduledResource) watchedPollInterval(checkInterval time.Duration) time.Duration {
+	if interval, ok := sr.durationMeta(PollIntervalKey); ok && interval > 0 {
+		return checkInterval
+	}
+	if checkInterval < WatchPollInterval {
+		return WatchPollInterval
+	}
+	return checkInterval
+}
+
+// initialDelay returns time to wait after the resource is created before checking its status
+func (sr *ScheduledResource) initialDelay() time.Duration {
+	if delay, ok := sr.durationMeta(InitialDelayKey); ok {
+		return delay
+	}
+	if delay, ok := sr.polling.KindInitialDelays[resourceKind(sr.Resource.Key())]; ok {
+		return delay
+	}
+	return sr.polling.InitialDelay
+}
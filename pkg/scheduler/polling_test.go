@@ -0,0 +1,111 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// TestParseKindDurations checks parsing of kind=seconds pairs
+func TestParseKindDurations(t *testing.T) {
+	durations, err := ParseKindDurations([]string{"job=10", "pod=0.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if durations["job"] != 10*time.Second || durations["pod"] != 500*time.Millisecond {
+		t.Errorf("Unexpected durations %v", durations)
+	}
+	for _, invalid := range []string{"job", "unknown=1", "job=-1", "job=soon"} {
+		if _, err := ParseKindDurations([]string{invalid}); err == nil {
+			t.Errorf("%q should be invalid", invalid)
+		}
+	}
+}
+
+// TestPollInterval checks that meta overrides intervals of kinds, which override the default one
+func TestPollInterval(t *testing.T) {
+	c := mocks.NewClient()
+	polling := PollingOptions{
+		Interval:          2 * time.Second,
+		InitialDelay:      time.Second,
+		KindIntervals:     map[string]time.Duration{"pod": 5 * time.Second},
+		KindInitialDelays: map[string]time.Duration{"pod": 30 * time.Second},
+	}
+
+	plain := NewScheduledResourceFor(resources.NewJob(mocks.MakeJob("ready-1"), c.Jobs(), nil))
+	if plain.pollInterval() != CheckInterval || plain.initialDelay() != 0 {
+		t.Errorf("Expected default interval without polling options, got %v, %v", plain.pollInterval(), plain.initialDelay())
+	}
+	plain.polling = polling
+	if plain.pollInterval() != 2*time.Second || plain.initialDelay() != time.Second {
+		t.Errorf("Expected default interval of the graph, got %v, %v", plain.pollInterval(), plain.initialDelay())
+	}
+
+	pod := NewScheduledResourceFor(resources.NewPod(mocks.MakePod("ready-1"), c.Pods(), nil))
+	pod.polling = polling
+	if pod.pollInterval() != 5*time.Second || pod.initialDelay() != 30*time.Second {
+		t.Errorf("Expected interval of the kind, got %v, %v", pod.pollInterval(), pod.initialDelay())
+	}
+
+	meta := map[string]interface{}{PollIntervalKey: 0.25, InitialDelayKey: 0.0}
+	pod = NewScheduledResourceFor(resources.NewPod(mocks.MakePod("ready-1"), c.Pods(), meta))
+	pod.polling = polling
+	if pod.pollInterval() != 250*time.Millisecond || pod.initialDelay() != 0 {
+		t.Errorf("Expected interval from meta, got %v, %v", pod.pollInterval(), pod.initialDelay())
+	}
+}
+
+// TestWatchedPollInterval checks that resources with watched objects are polled less often, unless their
+// meta sets poll-interval
+func TestWatchedPollInterval(t *testing.T) {
+	c := mocks.NewClient()
+	pod := NewScheduledResourceFor(resources.NewPod(mocks.MakePod("ready-1"), c.Pods(), nil))
+	if interval := pod.watchedPollInterval(CheckInterval); interval != WatchPollInterval {
+		t.Errorf("Expected watch interval, got %v", interval)
+	}
+	if interval := pod.watchedPollInterval(time.Minute); interval != time.Minute {
+		t.Errorf("Expected longer interval to be kept, got %v", interval)
+	}
+
+	meta := map[string]interface{}{PollIntervalKey: 0.25}
+	pod = NewScheduledResourceFor(resources.NewPod(mocks.MakePod("ready-1"), c.Pods(), meta))
+	if interval := pod.watchedPollInterval(pod.pollInterval()); interval != 250*time.Millisecond {
+		t.Errorf("Expected interval from meta, got %v", interval)
+	}
+}
+
+// TestWaitInitialDelay checks that status is not checked before initial delay passes, and that the
+// delay counts towards the timeout
+func TestWaitInitialDelay(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePod("ready-1"))
+	meta := map[string]interface{}{InitialDelayKey: 0.1}
+	sr := NewScheduledResourceFor(resources.NewPod(mocks.MakePod("ready-1"), c.Pods(), meta))
+
+	start := time.Now()
+	if err := sr.Wait(CheckInterval, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Status was checked after %v, before initial delay", elapsed)
+	}
+
+	if err := sr.Wait(CheckInterval, 50*time.Millisecond); err == nil {
+		t.Error("Wait should time out during initial delay")
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+)
+
+// NodeProgress is a progress of a single graph resource
+type NodeProgress struct {
+	Key    string                    `json:"key"`
+	Status interfaces.ResourceStatus `json:"status"`
+	Error  string                    `json:"error,omitempty"`
+	// Percentage is a readiness percentage reported by the resource, e.g. share of ready replicas
+	Percentage int `json:"percentage"`
+	// BlockedBy are keys of dependencies which block creation of the resource
+	BlockedBy []string `json:"blockedBy"`
+	// Skipped is true if the resource was skipped in the last run of the graph
+	Skipped bool `json:"skipped,omitempty"`
+	// Revision is a number of the deployed revision of the resource definition and RevisionChanged is
+	// true if the definition changed since it was deployed. They are known only if revisions are recorded
+	Revision        int  `json:"revision,omitempty"`
+	RevisionChanged bool `json:"revisionChanged,omitempty"`
+	// State and Since are known only if deployment keeps its state in a StateStore
+	State NodeState  `json:"state,omitempty"`
+	Since *time.Time `json:"since,omitempty"`
+}
+
+// Progress returns progress of every resource of the graph sorted by key. States are the ones
+// saved by deployment in a StateStore, they may be nil
+func (depGraph DependencyGraph) Progress(states map[string]NodeRecord) []NodeProgress {
+	keys := make([]string, 0, len(depGraph))
+	for key := range depGraph {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]NodeProgress, len(keys))
+	parallel(len(keys), func(i int) {
+		key := keys[i]
+		sr := depGraph[key]
+		progress := NodeProgress{Key: key, BlockedBy: []string{}}
+
+		status, err := sr.Status(nil)
+		progress.Status = status
+		if err != nil {
+			progress.Status.Phase = interfaces.ResourceError
+			progress.Error = err.Error()
+		}
+		progress.Percentage = sr.GetDependencyReport(nil).Percentage
+		sr.RLock()
+		progress.Skipped = sr.Skipped
+		sr.RUnlock()
+
+		for _, req := range sr.Requires {
+			if req.GetDependencyReport(sr.Meta[req.Key()]).Blocks {
+				progress.BlockedBy = append(progress.BlockedBy, req.Key())
+			}
+		}
+		sort.Strings(progress.BlockedBy)
+		progress.Revision, progress.RevisionChanged = sr.deployedRevision()
+
+		if record, ok := states[key]; ok {
+			since := record.Since
+			progress.State = record.State
+			progress.Since = &since
+		}
+		result[i] = progress
+	})
+	return result
+}
+
+// ProgressAsTable returns a human-readable table of resources progress
+func ProgressAsTable(progress []NodeProgress, now time.Time) []string {
+	rows := [][]string{{"RESOURCE", "STATUS", "PROGRESS", "BLOCKED BY", "REVISION", "STATE", "TIME IN STATE"}}
+	for _, p := range progress {
+		status := p.Status.String()
+		if p.Status.Reason != "" {
+			status += " (" + p.Status.Reason + ")"
+		}
+		if p.Skipped {
+			status = "skipped"
+		}
+		if p.Error != "" {
+			status += ": " + p.Error
+		}
+		blockedBy, revision, state, duration := "-", "-", "-", "-"
+		if len(p.BlockedBy) > 0 {
+			blockedBy = strings.Join(p.BlockedBy, ",")
+		}
+		if p.Revision > 0 {
+			revision = fmt.Sprint(p.Revision)
+			if p.RevisionChanged {
+				revision += " (changed)"
+			}
+		}
+		if p.State != "" {
+			state = string(p.State)
+		}
+		if p.Since != nil {
+			duration = (now.Sub(*p.Since) / time.Second * time.Second).String()
+		}
+		rows = append(rows, []string{p.Key, status, fmt.Sprintf("%d%%", p.Percentage), blockedBy, revision, state, duration})
+	}
+	return formatTable(rows)
+}
+
+// formatTable returns rows with cells aligned in columns
+func formatTable(rows [][]string) []string {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	lines := make([]string, 0, len(rows))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = cell + strings.Repeat(" ", widths[i]-len(cell))
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(cells, "   "), " "))
+	}
+	return lines
+}
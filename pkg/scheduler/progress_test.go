@@ -0,0 +1,64 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestProgress checks progress reported for graph resources
+func TestProgress(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePod("ready-1"), mocks.MakePod("pending-2"), mocks.MakePod("ready-3"))
+	c.Deps = mocks.NewDependencyClient(
+		mocks.Dependency{Parent: "pod/ready-1", Child: "pod/pending-2"},
+		mocks.Dependency{Parent: "pod/pending-2", Child: "pod/ready-3"},
+	)
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	since := time.Now().Add(-time.Minute)
+	progress := depGraph.Progress(map[string]NodeRecord{"pod/ready-1": {State: NodeReady, Since: since}})
+	if len(progress) != 3 {
+		t.Fatalf("Expected progress of 3 resources, got %v", progress)
+	}
+
+	pending := progress[0]
+	if pending.Key != "pod/pending-2" || pending.Status.Phase != interfaces.ResourceNotReady || len(pending.BlockedBy) != 0 {
+		t.Errorf("Unexpected progress of pending pod: %v", pending)
+	}
+	ready := progress[1]
+	if ready.State != NodeReady || ready.Since == nil || !ready.Since.Equal(since) || ready.Percentage != 100 {
+		t.Errorf("Unexpected progress of ready pod: %v", ready)
+	}
+	blocked := progress[2]
+	if len(blocked.BlockedBy) != 1 || blocked.BlockedBy[0] != "pod/pending-2" {
+		t.Errorf("Pod should be blocked by pending pod, got %v", blocked.BlockedBy)
+	}
+
+	table := ProgressAsTable(progress, since.Add(time.Minute))
+	if len(table) != 4 {
+		t.Fatalf("Expected header and 3 rows, got %v", table)
+	}
+	if !strings.HasPrefix(table[2], "pod/ready-1") || !strings.HasSuffix(table[2], "ready   1m0s") {
+		t.Errorf("Unexpected row %q", table[2])
+	}
+}
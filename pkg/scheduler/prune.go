@@ -0,0 +1,180 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/meta"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/runtime"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// objectLister lists objects of one kind matching list options
+type objectLister func(c client.Interface, options v1.ListOptions) (runtime.Object, error)
+
+// prunableKinds are kinds of objects which can be found by labels and pruned. Namespaces are never pruned,
+// as deleting them would delete objects not managed by AppController
+var prunableKinds = map[string]objectLister{
+	"clusterrole": func(c client.Interface, options v1.ListOptions) (runtime.Object, error) {
+		return c.ClusterRoles().List(options)
+	},
+	"configmap": func(c client.Interface, options v1.ListOptions) (runtime.Object, error) {
+		return c.ConfigMaps().List(options)
+	},
+	"daemonset": func(c client.Interface, options v1.ListOptions) (runtime.Object, error) {
+		return c.DaemonSets().List(options)
+	},
+	"deployment": func(c client.Interface, options v1.ListOptions) (runtime.Object, error) {
+		return c.Deployments().List(options)
+	},
+	"job": func(c client.Interface, options v1.ListOptions) (runtime.Object, error) {
+		return c.Jobs().List(options)
+	},
+	"persistentvolume": func(c client.Interface, options v1.ListOptions) (runtime.Object, error) {
+		return c.PersistentVolumes().List(options)
+	},
+	"persistentvolumeclaim": func(c client.Interface, options v1.ListOptions) (runtime.Object, error) {
+		return c.PersistentVolumeClaims().List(options)
+	},
+	"pod": func(c client.Interface, options v1.ListOptions) (runtime.Object, error) {
+		return c.Pods().List(options)
+	},
+	"replicaset": func(c client.Interface, options v1.ListOptions) (runtime.Object, error) {
+		return c.ReplicaSets().List(options)
+	},
+	"secret": func(c client.Interface, options v1.ListOptions) (runtime.Object, error) {
+		return c.Secrets().List(options)
+	},
+	"service": func(c client.Interface, options v1.ListOptions) (runtime.Object, error) {
+		return c.Services().List(options)
+	},
+	"serviceaccount": func(c client.Interface, options v1.ListOptions) (runtime.Object, error) {
+		return c.ServiceAccounts().List(options)
+	},
+	"statefulset": func(c client.Interface, options v1.ListOptions) (runtime.Object, error) {
+		return c.StatefulSets().List(options)
+	},
+	"storageclass": func(c client.Interface, options v1.ListOptions) (runtime.Object, error) {
+		return c.StorageClasses().List(options)
+	},
+}
+
+// PruneOptions define which objects left by removed resource definitions are deleted
+type PruneOptions struct {
+	// Whitelist are kinds of objects which are pruned, e.g. "configmap". All kinds which can be
+	// pruned are used if it is empty
+	Whitelist []string
+	// Timeout is a time to wait for deletion of each object
+	Timeout time.Duration
+	// DryRun makes Prune only return keys of objects which would be deleted
+	DryRun bool
+}
+
+// Prune deletes objects labeled with name of the graph which do not belong to any of its resources,
+// e.g. because their resource definitions were removed. Objects are looked for in AppController
+// namespace and namespaces of graph resources, cluster-scoped objects are listed once. Keys of deleted
+// objects are returned
+func Prune(c client.Interface, depGraph DependencyGraph, options PruneOptions) ([]string, error) {
+	kinds := options.Whitelist
+	if len(kinds) == 0 {
+		graphKinds := map[string]bool{}
+		for key := range depGraph {
+			if kind, _, err := keyParts(key); err == nil {
+				graphKinds[kind] = true
+			}
+		}
+		for kind := range prunableKinds {
+			// listing cluster-scoped objects requires permissions graphs without them may not have
+			if resources.ClusterScopedKinds[kind] && !graphKinds[kind] {
+				continue
+			}
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		if _, ok := prunableKinds[kind]; !ok {
+			return nil, fmt.Errorf("Kind %s cannot be pruned", kind)
+		}
+	}
+
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = WaitTimeout
+	}
+
+	nodes := map[string]bool{}
+	seen := map[string]bool{"": true}
+	namespaces := []string{""}
+	for key, sr := range depGraph {
+		nodes[resources.LabelValue(key)] = true
+		if !seen[sr.namespace] {
+			seen[sr.namespace] = true
+			namespaces = append(namespaces, sr.namespace)
+		}
+	}
+	sort.Strings(namespaces)
+
+	graphLabel := fmt.Sprintf("%s=%s", resources.GraphLabel, resources.LabelValue(depGraph.Name()))
+	clients := newNamespacedClients(c)
+	var pruned []string
+	for _, kind := range kinds {
+		list := prunableKinds[kind]
+		kindNamespaces := namespaces
+		if resources.ClusterScopedKinds[kind] {
+			kindNamespaces = []string{""}
+		}
+		for _, namespace := range kindNamespaces {
+			nc := clients.get(namespace)
+			listed, err := list(nc, v1.ListOptions{LabelSelector: graphLabel})
+			if err != nil {
+				return pruned, err
+			}
+			objects, err := meta.ExtractList(listed)
+			if err != nil {
+				return pruned, err
+			}
+			for _, obj := range objects {
+				object, err := meta.Accessor(obj)
+				if err != nil {
+					return pruned, err
+				}
+				if nodes[object.GetLabels()[resources.NodeLabel]] {
+					continue
+				}
+				key := namespacedKey(kind+"/"+object.GetName(), namespace)
+				if options.DryRun {
+					pruned = append(pruned, key)
+					continue
+				}
+				r := resources.KindToResourceTemplate[kind].NewExisting(object.GetName(), nc)
+				logging.ForResource(key).Infof("Resource %s does not belong to the graph anymore, deleting it", key)
+				if err := resources.DeleteAndWait(context.Background(), r, resources.DeletionDefault, CheckInterval, timeout); err != nil {
+					return pruned, fmt.Errorf("Could not delete %s: %v", key, err)
+				}
+				pruned = append(pruned, key)
+			}
+		}
+	}
+	return pruned, nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// labeledConfigMap returns config map labeled as created by node of given graph
+func labeledConfigMap(name, graph string) *v1.ConfigMap {
+	configMap := mocks.MakeConfigMap(name)
+	configMap.Labels = map[string]string{
+		resources.GraphLabel: graph,
+		resources.NodeLabel:  resources.LabelValue("configmap/" + name),
+	}
+	return configMap
+}
+
+// TestPrune checks that only objects of the graph which do not belong to its resources are deleted
+func TestPrune(t *testing.T) {
+	c := mocks.NewClient(
+		labeledConfigMap("kept", DefaultGraphName),
+		labeledConfigMap("removed", DefaultGraphName),
+		labeledConfigMap("other-graph", "other"),
+		mocks.MakeConfigMap("unlabeled"),
+	)
+	depGraph := DependencyGraph{
+		"configmap/kept": NewScheduledResourceFor(report.SimpleReporter{BaseResource: mocks.NewResource("configmap/kept", interfaces.ResourceReady)}),
+	}
+
+	pruned, err := Prune(c, depGraph, PruneOptions{Whitelist: []string{"configmap"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pruned, []string{"configmap/removed"}) {
+		t.Errorf("Expected only removed config map to be pruned, got %v", pruned)
+	}
+	for _, name := range []string{"kept", "other-graph", "unlabeled"} {
+		if _, err = c.ConfigMaps().Get(name); err != nil {
+			t.Errorf("Config map %s should be kept: %v", name, err)
+		}
+	}
+	if _, err = c.ConfigMaps().Get("removed"); err == nil {
+		t.Error("Config map removed from the graph should be deleted")
+	}
+}
+
+// TestPruneUnknownKind checks that kinds which cannot be pruned are rejected
+func TestPruneUnknownKind(t *testing.T) {
+	if _, err := Prune(mocks.NewClient(), DependencyGraph{}, PruneOptions{Whitelist: []string{"externalcheck"}}); err == nil {
+		t.Error("Error expected for kind which cannot be pruned")
+	}
+}
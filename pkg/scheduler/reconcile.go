@@ -0,0 +1,133 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/labels"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// DefaultReconcileInterval is a default interval between checks of the graph in reconciliation mode
+const DefaultReconcileInterval = time.Second * 30
+
+// graphVersion returns string which changes whenever resource definitions or dependencies matching
+// the selector are created, changed or deleted. Dependencies are compared by their parent, child and
+// meta rather than resource version, which is also changed by updates of their status
+func graphVersion(c client.Interface, sel labels.Selector) (string, error) {
+	resDefList, err := c.ResourceDefinitions().List(api.ListOptions{LabelSelector: sel})
+	if err != nil {
+		return "", err
+	}
+	depList, err := c.Dependencies().List(api.ListOptions{LabelSelector: sel})
+	if err != nil {
+		return "", err
+	}
+	var versions []string
+	for _, r := range resDefList.Items {
+		versions = append(versions, fmt.Sprintf("definition/%s@%s", r.Name, r.ResourceVersion))
+	}
+	for _, d := range depList.Items {
+		versions = append(versions, fmt.Sprintf("dependency/%s@%s->%s%v", d.Name, d.Parent, d.Child, d.Meta))
+	}
+	sort.Strings(versions)
+	return strings.Join(versions, ","), nil
+}
+
+// Drifted returns sorted keys of managed resources which are missing in the cluster, differ from
+// their definitions or are not ready. Resources without definitions are not checked
+func (depGraph DependencyGraph) Drifted() []string {
+	var managed []string
+	for key, sr := range depGraph {
+		if !sr.Existing {
+			managed = append(managed, key)
+		}
+	}
+	drifted := make([]bool, len(managed))
+	parallel(len(managed), func(i int) {
+		status, err := depGraph[managed[i]].Status(nil)
+		drifted[i] = err != nil || status.Phase != interfaces.ResourceReady
+	})
+
+	var keys []string
+	for i, key := range managed {
+		if drifted[i] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// reconcileOnce deploys the graph if its definitions changed since lastVersion or any of its resources
+// drifted. Returns current version of the graph
+func reconcileOnce(c client.Interface, sel labels.Selector, concurrency int, lastVersion string) (string, error) {
+	version, err := graphVersion(c, sel)
+	if err != nil {
+		return lastVersion, err
+	}
+	depGraph, err := BuildDependencyGraph(c, sel)
+	if err != nil {
+		return lastVersion, err
+	}
+	if cycles := DetectCycles(depGraph); len(cycles) > 0 {
+		return lastVersion, fmt.Errorf("graph has %d cycles, not deploying it", len(cycles))
+	}
+
+	if version != lastVersion {
+		logging.Infof("Resource definitions or dependencies changed, deploying the graph")
+	} else if drifted := depGraph.Drifted(); len(drifted) > 0 {
+		logging.Infof("Resources drifted from their definitions: %s, deploying the graph", strings.Join(drifted, ", "))
+	} else {
+		logging.Debugf("Graph is up to date")
+		return version, nil
+	}
+	Create(depGraph, concurrency)
+	return version, nil
+}
+
+// Reconcile keeps the graph matching the selector deployed until stop channel is closed. Every interval
+// it checks resource definitions and dependencies, and the objects they describe, and deploys the
+// graph again if definitions changed or managed objects were changed or deleted. Errors are logged
+// and the check is retried after the interval
+func Reconcile(c client.Interface, sel labels.Selector, concurrency int, interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		interval = DefaultReconcileInterval
+	}
+	logging.Infof("Reconciling the graph every %v", interval)
+
+	var version string
+	for {
+		var err error
+		version, err = reconcileOnce(c, sel, concurrency, version)
+		if err != nil {
+			logging.Errorf("Error reconciling the graph: %v", err)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestReconcileCreatesAndRecreates checks that the graph is deployed on first check, is left alone while
+// it is up to date, and is deployed again when managed object is deleted
+func TestReconcileCreatesAndRecreates(t *testing.T) {
+	c := mocks.NewClient()
+	c.ResDefs = mocks.NewResourceDefinitionClient("pod/ready-1")
+
+	version, err := reconcileOnce(c, nil, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Pods().Get("ready-1"); err != nil {
+		t.Fatalf("Pod should be created: %v", err)
+	}
+
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drifted := depGraph.Drifted(); len(drifted) != 0 {
+		t.Errorf("No drifted resources expected, got %v", drifted)
+	}
+	newVersion, err := reconcileOnce(c, nil, 0, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newVersion != version {
+		t.Errorf("Graph version changed from %q to %q without changes of definitions", version, newVersion)
+	}
+
+	if err = c.Pods().Delete("ready-1", nil); err != nil {
+		t.Fatal(err)
+	}
+	depGraph, err = BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drifted := depGraph.Drifted(); len(drifted) != 1 || drifted[0] != "pod/ready-1" {
+		t.Errorf("Expected pod/ready-1 to drift, got %v", drifted)
+	}
+	if _, err = reconcileOnce(c, nil, 0, version); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Pods().Get("ready-1"); err != nil {
+		t.Errorf("Deleted pod should be created again: %v", err)
+	}
+}
+
+// TestDriftedIgnoresExisting checks that resources without definitions are not reported as drifted
+func TestDriftedIgnoresExisting(t *testing.T) {
+	c := mocks.NewClient()
+	c.Deps = mocks.NewDependencyClient(mocks.Dependency{Parent: "pod/ready-1", Child: "pod/ready-2"})
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drifted := depGraph.Drifted(); len(drifted) != 0 {
+		t.Errorf("No drifted resources expected, got %v", drifted)
+	}
+}
+
+// TestReconcileStops checks that reconciliation loop returns once stop channel is closed
+func TestReconcileStops(t *testing.T) {
+	c := mocks.NewClient()
+	stop := make(chan struct{})
+	close(stop)
+	Reconcile(c, nil, 0, 0, stop)
+}
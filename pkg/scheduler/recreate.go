@@ -0,0 +1,75 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// RecreateOnFailureKey is a meta key of resource definition which makes the object deleted and created
+// again when its status becomes an error, e.g. its Job fails or its pod is in CrashLoopBackOff
+const RecreateOnFailureKey = "recreate-on-failure"
+
+// RecreateLimitKey is a meta key of resource definition which limits how many times the failed object
+// is recreated in a single run
+const RecreateLimitKey = "recreate-limit"
+
+// DefaultRecreateLimit is a number of recreations of failed object used if recreate-limit is not set
+const DefaultRecreateLimit = 3
+
+// EventRecreating is a reason of events recorded when failed object is recreated
+const EventRecreating = "Recreating"
+
+// Recreation is an attempt to recreate the object of the resource after it failed
+type Recreation struct {
+	Time time.Time `json:"time"`
+	// Error is the failure which caused the object to be recreated
+	Error string `json:"error"`
+}
+
+// shouldRecreate returns true if the object failed with given error should be recreated, i.e. the
+// resource has recreate-on-failure set, the status of its object is an error and the limit of
+// recreations is not reached yet
+func (sr *ScheduledResource) shouldRecreate(err error) bool {
+	if _, ok := err.(resources.ResourceError); !ok {
+		return false
+	}
+	if !resources.GetBoolMeta(sr.Resource, RecreateOnFailureKey, false) {
+		return false
+	}
+	limit := resources.GetIntMeta(sr.Resource, RecreateLimitKey, DefaultRecreateLimit)
+	sr.RLock()
+	defer sr.RUnlock()
+	return len(sr.recreations) < limit
+}
+
+// recreate deletes the failed object and waits until it is gone, so that it can be created again.
+// The attempt is recorded in the run history whether deletion succeeds or not
+func (sr *ScheduledResource) recreate(cause error, timeout time.Duration) error {
+	sr.Lock()
+	sr.recreations = append(sr.recreations, Recreation{Time: time.Now(), Error: cause.Error()})
+	attempt := len(sr.recreations)
+	sr.Unlock()
+
+	limit := resources.GetIntMeta(sr.Resource, RecreateLimitKey, DefaultRecreateLimit)
+	sr.logger("recreate").Warningf("Resource %s failed, recreating it, attempt %d of %d: %v", sr.Key(), attempt, limit, cause)
+	sr.recordEvent(v1.EventTypeWarning, EventRecreating, fmt.Sprintf("Resource failed, recreating it, attempt %d of %d: %v", attempt, limit, cause))
+	return resources.DeleteAndWait(sr.run.context(), sr.Resource, resources.DeletionDefault, CheckInterval, timeout)
+}
@@ -0,0 +1,111 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/unversioned"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// failingJob is a resource with recreate-on-failure set, whose object fails after the first
+// failures creations and is ready afterwards
+type failingJob struct {
+	*mocks.Resource
+	failures  int
+	limit     interface{}
+	exists    bool
+	creations int
+}
+
+func (r *failingJob) Create(ctx context.Context) error {
+	r.exists = true
+	r.creations++
+	return nil
+}
+
+func (r *failingJob) Delete(ctx context.Context) error {
+	r.exists = false
+	return nil
+}
+
+func (r *failingJob) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	if !r.exists {
+		return interfaces.NewStatus(interfaces.ResourceError), errors.NewNotFound(unversioned.GroupResource{Resource: "jobs"}, r.Key())
+	}
+	if r.creations <= r.failures {
+		return interfaces.NewStatus(interfaces.ResourceError), resources.NewResourceError(r.Key(), "BackoffLimitExceeded", "job failed")
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+func (r *failingJob) Meta(key string) interface{} {
+	switch key {
+	case RecreateOnFailureKey:
+		return "true"
+	case RecreateLimitKey:
+		return r.limit
+	}
+	return nil
+}
+
+func newFailingJob(failures int, limit interface{}) (*failingJob, *ScheduledResource) {
+	r := &failingJob{Resource: mocks.NewResource("job/failing", interfaces.ResourceReady), failures: failures, limit: limit}
+	return r, NewScheduledResourceFor(report.SimpleReporter{BaseResource: r})
+}
+
+// TestRecreateOnFailure checks that failed object is recreated and the recreation is recorded
+func TestRecreateOnFailure(t *testing.T) {
+	r, sr := newFailingJob(2, nil)
+	depGraph := DependencyGraph{sr.Key(): sr}
+
+	Create(depGraph, 0)
+
+	if !sr.created {
+		t.Fatalf("Resource should be created after recreation, got error %v", sr.Error)
+	}
+	if r.creations != 3 {
+		t.Errorf("Expected object to be created 3 times, got %d", r.creations)
+	}
+	node := depGraph.record("run", sr.creationStart).Nodes[sr.Key()]
+	if len(node.Recreations) != 2 || node.Recreations[0].Error == "" {
+		t.Errorf("Expected two recreations recorded, got %+v", node.Recreations)
+	}
+}
+
+// TestRecreateLimit checks that the object is not recreated more times than recreate-limit allows
+func TestRecreateLimit(t *testing.T) {
+	r, sr := newFailingJob(5, 1.0)
+	depGraph := DependencyGraph{sr.Key(): sr}
+
+	Create(depGraph, 0)
+
+	if !sr.failed {
+		t.Fatal("Resource should fail once recreate limit is reached")
+	}
+	if r.creations != 2 {
+		t.Errorf("Expected object to be created twice, got %d", r.creations)
+	}
+	if len(sr.recreations) != 1 {
+		t.Errorf("Expected one recreation, got %d", len(sr.recreations))
+	}
+}
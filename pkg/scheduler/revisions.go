@@ -0,0 +1,349 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// RevisionLabel marks config maps holding revision histories of resource definitions
+const RevisionLabel = "appcontroller.k8s/revisions"
+
+// revisionPrefix is a prefix of names of config maps holding revision histories, followed by
+// name of the resource definition
+const revisionPrefix = "appcontroller-revisions-"
+
+// revisionDataKey is a key of config map data holding JSON with revision history
+const revisionDataKey = "history"
+
+// DefaultKeepRevisions is a default number of revisions kept for every resource definition. Revision
+// history is opt-in, as it is readable by anyone who can read config maps of AppController namespace
+const DefaultKeepRevisions = 0
+
+// Revision is an immutable snapshot of resource definition content. Revisions are numbered from 1
+// for every definition. Hash covers the whole content, but data of Secrets is not stored, and decrypted
+// definitions are stored encrypted
+type Revision struct {
+	Number     int                       `json:"number"`
+	Hash       string                    `json:"hash"`
+	Time       time.Time                 `json:"time"`
+	Definition client.ResourceDefinition `json:"definition"`
+	// Redacted is true if data of the Secret was removed from the definition, only its keys are kept
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// RevisionHistory is a list of revisions of a resource definition, the oldest first. Deployed is
+// the number of the revision deployed most recently, 0 if none was deployed
+type RevisionHistory struct {
+	Revisions []Revision `json:"revisions"`
+	Deployed  int        `json:"deployed,omitempty"`
+}
+
+// Get returns revision with given number
+func (h RevisionHistory) Get(number int) (Revision, error) {
+	for _, revision := range h.Revisions {
+		if revision.Number == number {
+			return revision, nil
+		}
+	}
+	return Revision{}, fmt.Errorf("Revision %d not found", number)
+}
+
+// RevisionStore keeps revision histories of resource definitions
+type RevisionStore interface {
+	// Deployed marks the content of the definition as deployed, adding a new revision to its history
+	// if no revision has the same content
+	Deployed(r client.ResourceDefinition) (Revision, error)
+	// History returns revision history of the definition with given name
+	History(name string) (RevisionHistory, error)
+}
+
+// DefinitionHash returns hex encoded sha256 digest of the content of resource definition: its meta and
+// object. Metadata of the definition itself is not a part of the content
+func DefinitionHash(r client.ResourceDefinition) (string, error) {
+	r.TypeMeta = unversioned.TypeMeta{}
+	r.ObjectMeta = api.ObjectMeta{}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
+// revisionDefinition returns copy of resource definition stored in revision, without metadata set by
+// API server, and true if secret data was redacted. Definitions decrypted when they were listed are
+// stored encrypted, values of Secret data are dropped from other definitions
+func revisionDefinition(r client.ResourceDefinition) (client.ResourceDefinition, bool) {
+	r.ObjectMeta = api.ObjectMeta{
+		Name:        r.Name,
+		Namespace:   r.Namespace,
+		Labels:      r.Labels,
+		Annotations: r.Annotations,
+	}
+	if r.Decrypted != nil {
+		return client.ResourceDefinition{TypeMeta: r.TypeMeta, ObjectMeta: r.ObjectMeta, Meta: r.Meta, Encrypted: r.Decrypted}, false
+	}
+	if r.Secret == nil || len(r.Secret.Data) == 0 && len(r.Secret.StringData) == 0 {
+		return r, false
+	}
+	secret := *r.Secret
+	secret.Data = map[string][]byte{}
+	for key := range r.Secret.Data {
+		secret.Data[key] = nil
+	}
+	for key := range r.Secret.StringData {
+		secret.Data[key] = nil
+	}
+	secret.StringData = nil
+	r.Secret = &secret
+	return r, true
+}
+
+type configMapRevisionStore struct {
+	client corev1.ConfigMapInterface
+	limit  int
+}
+
+// NewConfigMapRevisionStore returns RevisionStore which keeps revisions of every definition in a config
+// map. Only limit most recent revisions are kept, 0 means no limit
+func NewConfigMapRevisionStore(client corev1.ConfigMapInterface, limit int) RevisionStore {
+	return &configMapRevisionStore{client: client, limit: limit}
+}
+
+// revisionHistoryFromConfigMap decodes revision history stored in config map
+func revisionHistoryFromConfigMap(configMap *v1.ConfigMap) (RevisionHistory, error) {
+	var history RevisionHistory
+	data, ok := configMap.Data[revisionDataKey]
+	if !ok {
+		return history, nil
+	}
+	err := json.Unmarshal([]byte(data), &history)
+	if err != nil {
+		err = fmt.Errorf("Invalid revision history %s: %v", configMap.Name, err)
+	}
+	return history, err
+}
+
+func (s *configMapRevisionStore) Deployed(r client.ResourceDefinition) (Revision, error) {
+	hash, err := DefinitionHash(r)
+	if err != nil {
+		return Revision{}, err
+	}
+	name := revisionPrefix + r.Name
+	configMap, err := s.client.Get(name)
+	exists := err == nil
+	if errors.IsNotFound(err) {
+		configMap = &v1.ConfigMap{ObjectMeta: v1.ObjectMeta{Name: name, Labels: map[string]string{RevisionLabel: "true"}}}
+	} else if err != nil {
+		return Revision{}, err
+	}
+	history, err := revisionHistoryFromConfigMap(configMap)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	var revision Revision
+	found := false
+	for _, existing := range history.Revisions {
+		if existing.Hash == hash {
+			revision, found = existing, true
+		}
+	}
+	if found && history.Deployed == revision.Number {
+		return revision, nil
+	}
+	if !found {
+		number := 1
+		if len(history.Revisions) > 0 {
+			number = history.Revisions[len(history.Revisions)-1].Number + 1
+		}
+		revision = Revision{Number: number, Hash: hash, Time: time.Now()}
+		revision.Definition, revision.Redacted = revisionDefinition(r)
+		history.Revisions = append(history.Revisions, revision)
+		if s.limit > 0 && len(history.Revisions) > s.limit {
+			history.Revisions = history.Revisions[len(history.Revisions)-s.limit:]
+		}
+	}
+	history.Deployed = revision.Number
+
+	data, err := json.Marshal(history)
+	if err != nil {
+		return Revision{}, err
+	}
+	configMap.Data = map[string]string{revisionDataKey: string(data)}
+	if exists {
+		_, err = s.client.Update(configMap)
+	} else {
+		_, err = s.client.Create(configMap)
+	}
+	return revision, err
+}
+
+func (s *configMapRevisionStore) History(name string) (RevisionHistory, error) {
+	configMap, err := s.client.Get(revisionPrefix + name)
+	if errors.IsNotFound(err) {
+		return RevisionHistory{}, nil
+	}
+	if err != nil {
+		return RevisionHistory{}, err
+	}
+	return revisionHistoryFromConfigMap(configMap)
+}
+
+// RevisionsAsTable returns a human-readable table of revisions with the deployed one marked
+func RevisionsAsTable(history RevisionHistory) []string {
+	rows := [][]string{{"REVISION", "HASH", "RECORDED", "DEPLOYED"}}
+	for _, revision := range history.Revisions {
+		deployed := ""
+		if revision.Number == history.Deployed {
+			deployed = "*"
+		}
+		hash := revision.Hash
+		if len(hash) > 12 {
+			hash = hash[:12]
+		}
+		rows = append(rows, []string{fmt.Sprint(revision.Number), hash, revision.Time.Format(time.RFC3339), deployed})
+	}
+	return formatTable(rows)
+}
+
+// WithRevisions makes resources of the graph record revisions of their definitions in the store when
+// they are deployed
+func (depGraph DependencyGraph) WithRevisions(store RevisionStore) {
+	for _, sr := range depGraph {
+		sr.revisions = store
+	}
+}
+
+// withDefinitions keeps resource definitions of the graph resources, so that their revisions can be
+// recorded
+func (depGraph DependencyGraph) withDefinitions(resDefs []client.ResourceDefinition) {
+	for i := range resDefs {
+		key, err := definitionKey(resDefs[i])
+		if err != nil {
+			continue
+		}
+		if sr, ok := depGraph[key]; ok {
+			sr.resDef = &resDefs[i]
+		}
+	}
+}
+
+// recordRevision marks the definition of created resource as deployed. Definitions without names, e.g.
+// loaded from files, have no revisions
+func (sr *ScheduledResource) recordRevision() {
+	if sr.revisions == nil || sr.resDef == nil || sr.resDef.Name == "" {
+		return
+	}
+	revision, err := sr.revisions.Deployed(*sr.resDef)
+	if err != nil {
+		sr.logger("revision").Warningf("Could not record revision of definition %s: %v", sr.resDef.Name, err)
+		return
+	}
+	sr.logger("revision").Debugf("Revision %d of definition %s is deployed", revision.Number, sr.resDef.Name)
+}
+
+// deployedRevision returns number of the revision of the definition which was deployed, and true if
+// the definition changed since then. Number is 0 if no revision is known
+func (sr *ScheduledResource) deployedRevision() (int, bool) {
+	if sr.revisions == nil || sr.resDef == nil || sr.resDef.Name == "" {
+		return 0, false
+	}
+	history, err := sr.revisions.History(sr.resDef.Name)
+	if err != nil || history.Deployed == 0 {
+		return 0, false
+	}
+	revision, err := history.Get(history.Deployed)
+	if err != nil {
+		return history.Deployed, false
+	}
+	hash, err := DefinitionHash(*sr.resDef)
+	return revision.Number, err == nil && hash != revision.Hash
+}
+
+// liveObjectGetter returns resource which can get its object from the cluster, unwrapping reporters
+func liveObjectGetter(r interfaces.BaseResource) (interfaces.LiveObjectGetter, bool) {
+	if reporter, ok := r.(report.SimpleReporter); ok {
+		r = reporter.BaseResource
+	}
+	getter, ok := r.(interfaces.LiveObjectGetter)
+	return getter, ok
+}
+
+// DefinitionDiff returns unified diff between the object in the cluster and the object of resource
+// definition, compared the way the resource compares them. Diff of missing object shows the whole
+// definition as added. Name is shown as the name of the definition side of diff
+func DefinitionDiff(c client.Interface, r client.ResourceDefinition, name string) ([]string, error) {
+	resource, err := newResourceFromDefinition(r, newNamespacedClients(c).get(definitionNamespace(r)))
+	if err != nil {
+		return nil, err
+	}
+	lines, _, err := liveDiff(resource, name)
+	return lines, err
+}
+
+// liveDiff returns unified diff between the object of the resource in the cluster and its definition,
+// and true if the object exists
+func liveDiff(resource interfaces.BaseResource, name string) ([]string, bool, error) {
+	getter, ok := liveObjectGetter(resource)
+	if !ok {
+		return nil, false, fmt.Errorf("Objects of %s cannot be compared with definition", resource.Key())
+	}
+	live, err := getter.Live()
+	if errors.IsNotFound(err) {
+		live = nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	defObj, liveObj, err := resources.ComparableObjects(resource, getter.Definition(), live)
+	if err != nil {
+		return nil, false, err
+	}
+	defLines, err := objectLines(defObj)
+	if err != nil {
+		return nil, false, err
+	}
+	var liveLines []string
+	if live != nil {
+		if liveLines, err = objectLines(liveObj); err != nil {
+			return nil, false, err
+		}
+	}
+	return report.UnifiedDiff("live "+resource.Key(), name, liveLines, defLines), live != nil, nil
+}
+
+// objectLines returns lines of indented JSON of the object
+func objectLines(obj map[string]interface{}) ([]string, error) {
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(string(data), "\n"), nil
+}
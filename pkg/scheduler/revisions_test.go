@@ -0,0 +1,129 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+func podDefinition(name, image string) client.ResourceDefinition {
+	pod := mocks.MakePod(name)
+	pod.Spec.Containers = []v1.Container{{Name: "main", Image: image}}
+	return client.ResourceDefinition{ObjectMeta: api.ObjectMeta{Name: name, ResourceVersion: "1"}, Pod: pod}
+}
+
+// TestRevisionStore checks that revisions are added only for new content and only the most recent
+// ones are kept
+func TestRevisionStore(t *testing.T) {
+	store := NewConfigMapRevisionStore(mocks.NewClient().ConfigMaps(), 2)
+
+	first, err := store.Deployed(podDefinition("web", "nginx:1.10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changedMeta := podDefinition("web", "nginx:1.10")
+	changedMeta.ResourceVersion = "2"
+	same, err := store.Deployed(changedMeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Number != 1 || same.Number != 1 {
+		t.Errorf("Definition with the same content should have the same revision, got %d and %d", first.Number, same.Number)
+	}
+
+	for _, image := range []string{"nginx:1.11", "nginx:1.12"} {
+		if _, err = store.Deployed(podDefinition("web", image)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = store.Deployed(podDefinition("web", "nginx:1.11")); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := store.History("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Revisions) != 2 || history.Revisions[0].Number != 2 || history.Revisions[1].Number != 3 {
+		t.Errorf("Expected revisions 2 and 3 to be kept, got %v", history.Revisions)
+	}
+	if history.Deployed != 2 {
+		t.Errorf("Rolled back revision 2 should be deployed, got %d", history.Deployed)
+	}
+	if revision, _ := history.Get(2); revision.Definition.ResourceVersion != "" {
+		t.Error("Revisions should not keep metadata set by API server")
+	}
+}
+
+// TestDeployedRevision checks that deployed revisions are recorded and changes of definitions detected
+func TestDeployedRevision(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePod("ready-1"))
+	store := NewConfigMapRevisionStore(c.ConfigMaps(), 0)
+	def := podDefinition("ready-1", "nginx:1.10")
+	depGraph := DependencyGraph{"pod/ready-1": newPolicyResource("pod/ready-1", nil, false)}
+	depGraph.withDefinitions([]client.ResourceDefinition{def})
+	depGraph.WithRevisions(store)
+
+	Create(depGraph, 0)
+
+	sr := depGraph["pod/ready-1"]
+	if number, changed := sr.deployedRevision(); number != 1 || changed {
+		t.Errorf("Expected revision 1 to be deployed, got %d (changed %t)", number, changed)
+	}
+	sr.resDef.Pod.Spec.Containers[0].Image = "nginx:1.11"
+	if number, changed := sr.deployedRevision(); number != 1 || !changed {
+		t.Errorf("Changed definition should be detected, got %d (changed %t)", number, changed)
+	}
+}
+
+// TestRevisionRedaction checks that revisions keep neither secret data nor decrypted objects
+func TestRevisionRedaction(t *testing.T) {
+	store := NewConfigMapRevisionStore(mocks.NewClient().ConfigMaps(), 0)
+	secret := mocks.MakeSecret("credentials")
+	secret.Data = map[string][]byte{"password": []byte("topsecret")}
+	secret.StringData = map[string]string{"token": "topsecret"}
+	def := client.ResourceDefinition{ObjectMeta: api.ObjectMeta{Name: "credentials"}, Secret: secret}
+
+	revision, err := store.Deployed(def)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := revision.Definition.Secret
+	if !revision.Redacted || len(stored.StringData) != 0 || len(stored.Data) != 2 || len(stored.Data["password"]) != 0 {
+		t.Errorf("Expected only keys of secret data to be stored, got %+v", stored)
+	}
+	if string(def.Secret.Data["password"]) != "topsecret" {
+		t.Error("Definition must not be changed")
+	}
+	secret.Data["password"] = []byte("changed")
+	if changed, err := store.Deployed(def); err != nil || changed.Number != 2 {
+		t.Errorf("Changed secret data should be a new revision, got %d, %v", changed.Number, err)
+	}
+
+	pod := podDefinition("web", "nginx:1.10")
+	pod.Decrypted = &client.EncryptedObject{Provider: client.LocalKeyProvider, KeyID: "key", Data: []byte("ciphertext")}
+	if revision, err = store.Deployed(pod); err != nil {
+		t.Fatal(err)
+	}
+	if revision.Definition.Pod != nil || revision.Definition.Encrypted == nil || string(revision.Definition.Encrypted.Data) != "ciphertext" {
+		t.Errorf("Expected decrypted definition to be stored encrypted, got %+v", revision.Definition)
+	}
+}
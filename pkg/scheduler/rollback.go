@@ -0,0 +1,109 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// WithRollback makes aborted runs of the graph delete resources which were created during the run,
+// in reverse order of their creation. Resources without definitions and resources which existed
+// before the run are kept
+func (depGraph DependencyGraph) WithRollback(enabled bool) {
+	for _, sr := range depGraph {
+		sr.rollback = enabled
+	}
+}
+
+// own records that the resource was created by the run, so that it is deleted on rollback.
+// Resources created again on retry keep their original position
+func (r *runState) own(sr *ScheduledResource) {
+	if r == nil {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	for _, owned := range r.owned {
+		if owned == sr {
+			return
+		}
+	}
+	r.owned = append(r.owned, sr)
+}
+
+// ownedResources returns resources created by the run in order of their creation
+func (r *runState) ownedResources() []*ScheduledResource {
+	if r == nil {
+		return nil
+	}
+	r.RLock()
+	defer r.RUnlock()
+	return append([]*ScheduledResource(nil), r.owned...)
+}
+
+// missing returns true if the object of the resource does not exist in the cluster, so creating it
+// makes the run its owner. Resources without definitions and namespaces added for resources in them
+// are never owned
+func (sr *ScheduledResource) missing() bool {
+	if sr.Existing || sr.autoNamespace {
+		return false
+	}
+	ctx, cancel := sr.requestContext()
+	defer cancel()
+	_, err := statusWithContext(ctx, sr.Resource, nil)
+	return errors.IsNotFound(err)
+}
+
+// Created returns keys of resources created by the last run of the graph in order of their creation
+func (depGraph DependencyGraph) Created() []string {
+	var keys []string
+	for _, sr := range depGraph.lastRun().ownedResources() {
+		keys = append(keys, sr.Key())
+	}
+	return keys
+}
+
+// rollbackIfAborted deletes resources created by the run if it was aborted and rollback is enabled.
+// Errors are logged and do not stop deletion of other resources
+func (depGraph DependencyGraph) rollbackIfAborted() {
+	abortErr := depGraph.Aborted()
+	if abortErr == nil {
+		return
+	}
+	for _, sr := range depGraph {
+		if !sr.rollback {
+			return
+		}
+		break
+	}
+
+	owned := depGraph.lastRun().ownedResources()
+	logging.Warningf("Rolling back %d resources created by the run: %v", len(owned), abortErr)
+	for i := len(owned) - 1; i >= 0; i-- {
+		sr := owned[i]
+		sr.logger("rollback").Infof("Deleting %s", sr.Key())
+		if err := resources.DeleteAndWait(context.Background(), sr.Resource, resources.DeletionDefault, CheckInterval, WaitTimeout); err != nil {
+			sr.logger("rollback").Errorf("Could not delete %s: %v", sr.Key(), err)
+			continue
+		}
+		sr.recordEvent(v1.EventTypeNormal, EventRolledBack, "Resource deleted after the run was aborted")
+		sr.logger("rollback").Infof("Resource %s deleted", sr.Key())
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"golang.org/x/net/context"
+	apierrors "k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/unversioned"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+// deletionLog records keys of deleted resources in order of deletion
+type deletionLog struct {
+	keys []string
+	sync.Mutex
+}
+
+// clusterResource is a resource which exists in the cluster only after it is created. Creation of
+// failing resource fails and aborts the run
+type clusterResource struct {
+	*mocks.Resource
+	exists  bool
+	failing bool
+	deleted *deletionLog
+	sync.Mutex
+}
+
+func (r *clusterResource) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	r.Lock()
+	defer r.Unlock()
+	if !r.exists {
+		return interfaces.NewStatus(interfaces.ResourceError), apierrors.NewNotFound(unversioned.GroupResource{Resource: "pods"}, r.Key())
+	}
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+func (r *clusterResource) Create(ctx context.Context) error {
+	if r.failing {
+		return errors.New("failed")
+	}
+	r.Lock()
+	defer r.Unlock()
+	r.exists = true
+	return nil
+}
+
+func (r *clusterResource) Delete(ctx context.Context) error {
+	r.Lock()
+	r.exists = false
+	r.Unlock()
+	r.deleted.Lock()
+	defer r.deleted.Unlock()
+	r.deleted.keys = append(r.deleted.keys, r.Key())
+	return nil
+}
+
+func (r *clusterResource) Meta(key string) interface{} {
+	if key == FailurePolicyKey && r.failing {
+		return "abort"
+	}
+	return nil
+}
+
+func newClusterResource(key string, exists, failing bool, deleted *deletionLog) *ScheduledResource {
+	r := &clusterResource{Resource: mocks.NewResource(key, interfaces.ResourceReady), exists: exists, failing: failing, deleted: deleted}
+	return NewScheduledResourceFor(report.SimpleReporter{BaseResource: r})
+}
+
+// TestRollback checks that resources created by aborted run are deleted in reverse order, while
+// pre-existing resources, resources without definitions and added namespaces are kept
+func TestRollback(t *testing.T) {
+	deleted := &deletionLog{}
+	namespace := newClusterResource("namespace/team", false, false, deleted)
+	namespace.autoNamespace = true
+	first := newClusterResource("pod/first", false, false, deleted)
+	second := newClusterResource("pod/second", false, false, deleted)
+	preexisting := newClusterResource("pod/preexisting", true, false, deleted)
+	existing := newClusterResource("pod/existing", false, false, deleted)
+	existing.Existing = true
+	failing := newClusterResource("pod/failing", false, true, deleted)
+	dependOn(first, namespace)
+	dependOn(second, first)
+	dependOn(preexisting, second)
+	dependOn(existing, preexisting)
+	dependOn(failing, existing)
+	depGraph := DependencyGraph{
+		"namespace/team": namespace, "pod/first": first, "pod/second": second, "pod/preexisting": preexisting,
+		"pod/existing": existing, "pod/failing": failing,
+	}
+	depGraph.WithRollback(true)
+
+	Create(depGraph, 0)
+
+	if !IsRunAborted(depGraph.Aborted()) {
+		t.Fatalf("Expected run to be aborted, got %v", depGraph.Aborted())
+	}
+	if created := depGraph.Created(); !reflect.DeepEqual(created, []string{"pod/first", "pod/second"}) {
+		t.Errorf("Expected only resources missing before the run to be owned, got %v", created)
+	}
+	if !reflect.DeepEqual(deleted.keys, []string{"pod/second", "pod/first"}) {
+		t.Errorf("Expected created resources to be deleted in reverse order, got %v", deleted.keys)
+	}
+}
+
+// TestNoRollback checks that created resources are kept unless rollback is enabled
+func TestNoRollback(t *testing.T) {
+	deleted := &deletionLog{}
+	created := newClusterResource("pod/created", false, false, deleted)
+	failing := newClusterResource("pod/failing", false, true, deleted)
+	dependOn(failing, created)
+	depGraph := DependencyGraph{"pod/created": created, "pod/failing": failing}
+
+	Create(depGraph, 0)
+
+	if depGraph.Aborted() == nil {
+		t.Fatal("Expected run to be aborted")
+	}
+	if len(deleted.keys) != 0 {
+		t.Errorf("Expected no resources to be deleted, got %v", deleted.keys)
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// RunRecordLabel marks config maps holding records of graph runs
+const RunRecordLabel = "appcontroller.k8s/run-record"
+
+// runRecordPrefix is a prefix of names of config maps holding run records, followed by run ID
+const runRecordPrefix = "appcontroller-run-"
+
+// runRecordDataKey is a key of config map data holding JSON with the run record
+const runRecordDataKey = "run"
+
+// NodeRun is a result of a resource in a graph run. Resources which were not processed, e.g. because
+// the run was aborted, have empty state
+type NodeRun struct {
+	State NodeState `json:"state,omitempty"`
+	// DurationSeconds is a time the resource took to become ready, it is zero if it was not created
+	// by the run
+	DurationSeconds float64 `json:"durationSeconds,omitempty"`
+	Error           string  `json:"error,omitempty"`
+	// Recreations are attempts to recreate the object after it failed
+	Recreations []Recreation `json:"recreations,omitempty"`
+	// Logs are last lines of container logs captured when the resource failed
+	Logs string `json:"logs,omitempty"`
+}
+
+// RunRecord describes a finished graph run
+type RunRecord struct {
+	ID      string             `json:"id"`
+	Graph   string             `json:"graph"`
+	Start   time.Time          `json:"start"`
+	End     time.Time          `json:"end"`
+	Outcome RunEvent           `json:"outcome"`
+	Nodes   map[string]NodeRun `json:"nodes"`
+}
+
+// RunRecordStore keeps records of graph runs
+type RunRecordStore interface {
+	// Save stores record of finished run
+	Save(record RunRecord) error
+	// List returns records of stored runs, the most recent first
+	List() ([]RunRecord, error)
+	// Get returns record of the run with given ID
+	Get(id string) (RunRecord, error)
+}
+
+type configMapRunRecordStore struct {
+	client corev1.ConfigMapInterface
+	graph  string
+	limit  int
+}
+
+// NewConfigMapRunRecordStore returns RunRecordStore which keeps every run of the graph with given name
+// in a config map. Only limit most recent runs are kept, 0 means no limit. Stores with empty graph name
+// list runs of all graphs
+func NewConfigMapRunRecordStore(client corev1.ConfigMapInterface, graph string, limit int) RunRecordStore {
+	return &configMapRunRecordStore{client: client, graph: graph, limit: limit}
+}
+
+// runRecordFromConfigMap decodes run record stored in config map
+func runRecordFromConfigMap(configMap *v1.ConfigMap) (RunRecord, error) {
+	var record RunRecord
+	err := json.Unmarshal([]byte(configMap.Data[runRecordDataKey]), &record)
+	if err != nil {
+		err = fmt.Errorf("Invalid run record %s: %v", configMap.Name, err)
+	}
+	return record, err
+}
+
+func (s *configMapRunRecordStore) Save(record RunRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	configMap := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name: runRecordPrefix + record.ID,
+			Labels: map[string]string{
+				RunRecordLabel:       "true",
+				resources.GraphLabel: resources.LabelValue(record.Graph),
+			},
+		},
+		Data: map[string]string{runRecordDataKey: string(data)},
+	}
+	if _, err = s.client.Create(configMap); err != nil {
+		return err
+	}
+	if s.limit <= 0 {
+		return nil
+	}
+
+	records, err := s.List()
+	if err != nil {
+		return err
+	}
+	for i := s.limit; i < len(records); i++ {
+		err = s.client.Delete(runRecordPrefix+records[i].ID, nil)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+type recordsByStart []RunRecord
+
+func (r recordsByStart) Len() int           { return len(r) }
+func (r recordsByStart) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r recordsByStart) Less(i, j int) bool { return r[i].Start.After(r[j].Start) }
+
+func (s *configMapRunRecordStore) List() ([]RunRecord, error) {
+	selector := RunRecordLabel + "=true"
+	if s.graph != "" {
+		selector += fmt.Sprintf(",%s=%s", resources.GraphLabel, resources.LabelValue(s.graph))
+	}
+	list, err := s.client.List(v1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	records := []RunRecord{}
+	for i := range list.Items {
+		configMap := &list.Items[i]
+		if configMap.Labels[RunRecordLabel] != "true" {
+			continue
+		}
+		record, err := runRecordFromConfigMap(configMap)
+		if err != nil {
+			return nil, err
+		}
+		if s.graph == "" || record.Graph == s.graph {
+			records = append(records, record)
+		}
+	}
+	sort.Sort(recordsByStart(records))
+	return records, nil
+}
+
+func (s *configMapRunRecordStore) Get(id string) (RunRecord, error) {
+	configMap, err := s.client.Get(runRecordPrefix + id)
+	if err != nil {
+		return RunRecord{}, err
+	}
+	return runRecordFromConfigMap(configMap)
+}
+
+// RunRecordHistory returns DurationHistory which averages readiness durations of resources over
+// recent runs kept in the store. Durations are recorded by saving run records, so Record does nothing
+func RunRecordHistory(store RunRecordStore) DurationHistory {
+	return runRecordHistory{store}
+}
+
+type runRecordHistory struct {
+	store RunRecordStore
+}
+
+func (h runRecordHistory) Load() (report.Durations, error) {
+	records, err := h.store.List()
+	if err != nil {
+		return nil, err
+	}
+	totals := map[string]float64{}
+	samples := map[string]int{}
+	for _, record := range records {
+		for key, node := range record.Nodes {
+			if node.State != NodeReady || node.DurationSeconds == 0 || samples[key] == historySamples {
+				continue
+			}
+			totals[key] += node.DurationSeconds
+			samples[key]++
+		}
+	}
+	durations := make(report.Durations, len(totals))
+	for key, total := range totals {
+		durations[key] = time.Duration(total / float64(samples[key]) * float64(time.Second))
+	}
+	return durations, nil
+}
+
+func (h runRecordHistory) Record(key string, duration time.Duration) error {
+	return nil
+}
+
+// WithRunRecords makes graph runs save their records in the store
+func (depGraph DependencyGraph) WithRunRecords(store RunRecordStore) {
+	for _, sr := range depGraph {
+		sr.runRecords = store
+	}
+}
+
+// record returns record of the finished run of the graph
+func (depGraph DependencyGraph) record(runID string, start time.Time) RunRecord {
+	record := RunRecord{
+		ID:      runID,
+		Graph:   depGraph.Name(),
+		Start:   start,
+		End:     time.Now(),
+		Outcome: depGraph.summarize(RunCompleted, runID).Event,
+		Nodes:   make(map[string]NodeRun, len(depGraph)),
+	}
+	for key, sr := range depGraph {
+		var node NodeRun
+		sr.RLock()
+		switch {
+		case sr.created:
+			node.State = NodeReady
+			node.DurationSeconds = sr.readyDuration.Seconds()
+		case sr.Skipped:
+			node.State = NodeSkipped
+		case sr.failed:
+			node.State = NodeFailed
+		}
+		if sr.Error != nil {
+			node.Error = sr.Error.Error()
+		}
+		node.Recreations = append([]Recreation(nil), sr.recreations...)
+		node.Logs = sr.failureLogs
+		sr.RUnlock()
+		record.Nodes[key] = node
+	}
+	return record
+}
+
+// saveRunRecord saves record of the finished run if the graph is deployed with a run record store
+func (depGraph DependencyGraph) saveRunRecord(runID string, start time.Time) {
+	var store RunRecordStore
+	for _, sr := range depGraph {
+		store = sr.runRecords
+		break
+	}
+	if store == nil {
+		return
+	}
+	if err := store.Save(depGraph.record(runID, start)); err != nil {
+		logging.Warningf("Could not save record of run %s: %v", runID, err)
+	}
+}
+
+// RunsAsTable returns a human-readable table of runs with their outcomes and durations
+func RunsAsTable(records []RunRecord) []string {
+	rows := [][]string{{"RUN", "GRAPH", "STARTED", "DURATION", "OUTCOME", "READY", "SKIPPED", "FAILED"}}
+	for _, r := range records {
+		counts := map[NodeState]int{}
+		for _, node := range r.Nodes {
+			counts[node.State]++
+		}
+		rows = append(rows, []string{
+			r.ID, r.Graph, r.Start.Format(time.RFC3339), (r.End.Sub(r.Start) / time.Second * time.Second).String(), string(r.Outcome),
+			fmt.Sprint(counts[NodeReady]), fmt.Sprint(counts[NodeSkipped]), fmt.Sprint(counts[NodeFailed]),
+		})
+	}
+	return formatTable(rows)
+}
+
+// RunDurationsAsTable returns a human-readable table comparing durations of resources in given runs,
+// with a column per run
+func RunDurationsAsTable(records []RunRecord) []string {
+	header := []string{"RESOURCE"}
+	keys := map[string]bool{}
+	for _, r := range records {
+		header = append(header, r.ID)
+		for key := range r.Nodes {
+			keys[key] = true
+		}
+	}
+	rows := [][]string{header}
+	for _, key := range sortedKeys(keys) {
+		row := []string{key}
+		for _, r := range records {
+			node, ok := r.Nodes[key]
+			cell := "-"
+			switch {
+			case !ok:
+			case node.State == NodeReady:
+				cell = (time.Duration(node.DurationSeconds*float64(time.Second)) / time.Millisecond * time.Millisecond).String()
+			case node.State != "":
+				cell = string(node.State)
+			}
+			row = append(row, cell)
+		}
+		rows = append(rows, row)
+	}
+	return formatTable(rows)
+}
@@ -0,0 +1,86 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// lastRecord returns the most recent run record
+func lastRecord(t *testing.T, store RunRecordStore) RunRecord {
+	records, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) == 0 {
+		t.Fatal("No runs recorded")
+	}
+	return records[0]
+}
+
+// TestRunRecords checks that runs are recorded with results of their resources and only the most
+// recent ones are kept
+func TestRunRecords(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePod("ready-1"), mocks.MakePod("crashloop-1"))
+	c.ResDefs = mocks.NewResourceDefinitionClient("pod/ready-1", "pod/crashloop-1")
+	store := NewConfigMapRunRecordStore(c.ConfigMaps(), "test", 2)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		depGraph, err := BuildDependencyGraph(c, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		depGraph.WithName("test")
+		depGraph.WithRunRecords(store)
+		Create(depGraph, 0)
+		ids = append(ids, lastRecord(t, store).ID)
+		// runs are ordered by start time
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	records, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].ID != ids[2] || records[1].ID != ids[1] {
+		t.Fatalf("Expected two most recent runs %v, got %v", ids[1:], records)
+	}
+
+	record, err := store.Get(ids[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Graph != "test" || record.Outcome != RunFailed {
+		t.Errorf("Unexpected record %+v", record)
+	}
+	if node := record.Nodes["pod/ready-1"]; node.State != NodeReady || node.DurationSeconds == 0 {
+		t.Errorf("Expected pod/ready-1 to be ready with duration, got %+v", node)
+	}
+	if node := record.Nodes["pod/crashloop-1"]; node.State != NodeFailed || node.Error == "" {
+		t.Errorf("Expected pod/crashloop-1 to fail with error, got %+v", node)
+	}
+
+	durations, err := RunRecordHistory(store).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := durations["pod/ready-1"]; !ok || len(durations) != 1 {
+		t.Errorf("Expected duration of pod/ready-1 only, got %v", durations)
+	}
+}
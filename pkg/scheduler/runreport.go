@@ -0,0 +1,130 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"sort"
+
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+// RunReport returns machine-readable report of the last run of the graph. Resources which were not
+// created get reports of their dependencies. The outcome is a timeout if any resource exceeded its
+// deadline, and a partial failure if any other resource failed or was not processed
+func (depGraph DependencyGraph) RunReport() report.RunReport {
+	result := report.RunReport{Graph: depGraph.Name(), Outcome: report.OutcomeSucceeded}
+	if run := depGraph.lastRun(); run != nil {
+		run.RLock()
+		result.RunID, result.Start, result.End = run.id, run.start, run.end
+		run.RUnlock()
+	}
+	if err := depGraph.Aborted(); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	result.Nodes = depGraph.NodeResults()
+	for i := range result.Nodes {
+		node := &result.Nodes[i]
+		switch node.State {
+		case report.NodeCreated:
+			result.Created++
+		case report.NodeSkipped:
+			result.Skipped++
+		case report.NodeFailed:
+			result.Failed++
+		case report.NodeDeferred:
+			result.Deferred++
+		default:
+			// resources still in progress when the run ended were interrupted by abort or cancellation
+			node.State = report.NodePending
+			result.Pending++
+		}
+	}
+
+	parallel(len(result.Nodes), func(i int) {
+		if node := &result.Nodes[i]; node.State == report.NodeFailed || node.State == report.NodePending || node.State == report.NodeDeferred {
+			nodeReport := depGraph[node.Key].GetNodeReport(node.Key)
+			status := nodeReport.Status
+			node.Status = &status
+			node.Dependencies = nodeReport.Dependencies
+		}
+	})
+
+	switch {
+	case depGraph.deadlineExceeded():
+		result.Outcome = report.OutcomeTimeout
+	case result.Failed > 0 || result.Pending > 0 || result.Deferred > 0:
+		result.Outcome = report.OutcomePartialFailure
+	}
+	return result
+}
+
+// NodeResults returns current state, readiness duration and error of every resource of the graph
+// sorted by key. It may be called while the graph is being deployed
+func (depGraph DependencyGraph) NodeResults() []report.NodeResult {
+	keys := make([]string, 0, len(depGraph))
+	for key := range depGraph {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	nodes := make([]report.NodeResult, len(keys))
+	for i, key := range keys {
+		sr := depGraph[key]
+		node := report.NodeResult{Key: key}
+		sr.RLock()
+		switch {
+		case sr.created:
+			node.State = report.NodeCreated
+			node.DurationSeconds = sr.readyDuration.Seconds()
+		case sr.Skipped:
+			node.State = report.NodeSkipped
+		case sr.failed:
+			node.State = report.NodeFailed
+		case !sr.windowOpens.IsZero():
+			node.State = report.NodeDeferred
+			opens := sr.windowOpens
+			node.DeferredUntil = &opens
+		case sr.Started:
+			node.State = report.NodeInProgress
+		default:
+			node.State = report.NodePending
+		}
+		if sr.Error != nil {
+			node.Error = sr.Error.Error()
+		}
+		sr.RUnlock()
+		nodes[i] = node
+	}
+	return nodes
+}
+
+// deadlineExceeded returns true if any resource of the graph failed because of its deadline
+func (depGraph DependencyGraph) deadlineExceeded() bool {
+	for _, sr := range depGraph {
+		sr.RLock()
+		exceeded := sr.Error != nil && IsDeadlineExceeded(sr.Error)
+		sr.RUnlock()
+		if exceeded {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidationReport returns report of the graph which was not run because of validation errors
+func ValidationReport(graphName string, errors []string) report.RunReport {
+	return report.RunReport{Graph: graphName, Outcome: report.OutcomeValidationError, Errors: errors}
+}
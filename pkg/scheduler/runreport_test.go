@@ -0,0 +1,68 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+// TestRunReport checks states of resources and outcome in report of run with failed resource
+func TestRunReport(t *testing.T) {
+	failing := newPolicyResource("pod/failing", nil, true)
+	child := newPolicyResource("pod/child", nil, false)
+	independent := newPolicyResource("pod/independent", nil, false)
+	dependOn(child, failing)
+	depGraph := DependencyGraph{"pod/failing": failing, "pod/child": child, "pod/independent": independent}
+
+	Create(depGraph, 0)
+
+	runReport := depGraph.RunReport()
+	if runReport.Outcome != report.OutcomePartialFailure {
+		t.Errorf("Expected partial failure, got %s", runReport.Outcome)
+	}
+	if runReport.RunID == "" || runReport.End.Before(runReport.Start) {
+		t.Errorf("Run ID and times should be reported, got %+v", runReport)
+	}
+	expected := map[string]string{
+		"pod/child":       report.NodePending,
+		"pod/failing":     report.NodeFailed,
+		"pod/independent": report.NodeCreated,
+	}
+	if len(runReport.Nodes) != len(expected) {
+		t.Fatalf("Expected %d nodes, got %d", len(expected), len(runReport.Nodes))
+	}
+	for _, node := range runReport.Nodes {
+		if node.State != expected[node.Key] {
+			t.Errorf("Expected %s to be %s, got %s", node.Key, expected[node.Key], node.State)
+		}
+	}
+	if runReport.Nodes[1].Error == "" {
+		t.Error("Error of failed resource should be reported")
+	}
+	if len(runReport.Nodes[0].Dependencies) != 1 {
+		t.Errorf("Dependencies of pending resource should be reported, got %v", runReport.Nodes[0].Dependencies)
+	}
+}
+
+// TestRunReportSucceeded checks outcome of successful run
+func TestRunReportSucceeded(t *testing.T) {
+	depGraph := DependencyGraph{"pod/1": newPolicyResource("pod/1", nil, false)}
+	Create(depGraph, 0)
+	if runReport := depGraph.RunReport(); runReport.Outcome != report.OutcomeSucceeded || runReport.Created != 1 {
+		t.Errorf("Expected successful run with one created resource, got %+v", runReport)
+	}
+}
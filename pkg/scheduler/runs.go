@@ -0,0 +1,165 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// ProgressEvent is a change of graph run or of one of its resources. Run events have empty key and
+// no state
+type ProgressEvent struct {
+	RunID string    `json:"runID"`
+	Event RunEvent  `json:"event,omitempty"`
+	Key   string    `json:"key,omitempty"`
+	State NodeState `json:"state,omitempty"`
+	Error string    `json:"error,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+// progressBufferSize is a number of events kept for a subscriber which does not read them. Further
+// events are dropped, so that slow subscribers never block deployment
+const progressBufferSize = 256
+
+var (
+	progressSubscribers = map[chan ProgressEvent]struct{}{}
+	progressLock        sync.Mutex
+)
+
+// SubscribeProgress returns channel receiving progress events of all graph runs and function
+// cancelling the subscription
+func SubscribeProgress() (<-chan ProgressEvent, func()) {
+	ch := make(chan ProgressEvent, progressBufferSize)
+	progressLock.Lock()
+	progressSubscribers[ch] = struct{}{}
+	progressLock.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			progressLock.Lock()
+			delete(progressSubscribers, ch)
+			progressLock.Unlock()
+		})
+	}
+}
+
+// publishProgress sends event to every subscriber which has room for it
+func publishProgress(event ProgressEvent) {
+	runningMux.Lock()
+	event.RunID = lastRunID
+	runningMux.Unlock()
+	event.Time = time.Now()
+	progressLock.Lock()
+	defer progressLock.Unlock()
+	for ch := range progressSubscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// setState saves new state of the resource and publishes it to progress subscribers
+func (sr *ScheduledResource) setState(state NodeState, err error) {
+	sr.saveState(state)
+	event := ProgressEvent{Key: sr.Key(), State: state}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	publishProgress(event)
+}
+
+var (
+	paused     bool
+	pauseLock  sync.Mutex
+	pauseCond  = sync.NewCond(&pauseLock)
+	lastGraph  DependencyGraph
+	lastRunID  string
+	running    bool
+	runningMux sync.Mutex
+)
+
+// Pause stops creation of resources by all graph runs. Resources which are being created are not
+// interrupted, resources which became unblocked wait until deployment is resumed
+func Pause() {
+	pauseLock.Lock()
+	defer pauseLock.Unlock()
+	if !paused {
+		logging.Infof("Deployment paused")
+	}
+	paused = true
+}
+
+// Resume continues creation of resources paused by Pause
+func Resume() {
+	pauseLock.Lock()
+	defer pauseLock.Unlock()
+	if paused {
+		logging.Infof("Deployment resumed")
+	}
+	paused = false
+	pauseCond.Broadcast()
+}
+
+// IsPaused returns true if creation of resources is paused
+func IsPaused() bool {
+	pauseLock.Lock()
+	defer pauseLock.Unlock()
+	return paused
+}
+
+// waitWhilePaused blocks until deployment is not paused
+func waitWhilePaused() {
+	pauseLock.Lock()
+	defer pauseLock.Unlock()
+	for paused {
+		pauseCond.Wait()
+	}
+}
+
+// LastRun returns graph of the current graph run or of the last finished one, and true if the run
+// is in progress. Graph is nil if there were no runs
+func LastRun() (DependencyGraph, bool) {
+	runningMux.Lock()
+	defer runningMux.Unlock()
+	return lastGraph, running
+}
+
+// startRun records start of the graph run and publishes it to progress subscribers
+func startRun(depGraph DependencyGraph, runID string) {
+	runningMux.Lock()
+	lastGraph = depGraph
+	lastRunID = runID
+	running = true
+	runningMux.Unlock()
+	publishProgress(ProgressEvent{Event: RunStarted})
+}
+
+// finishRun records end of the graph run and publishes it to progress subscribers
+func finishRun(depGraph DependencyGraph) {
+	event := RunCompleted
+	if len(depGraph.Failed()) > 0 {
+		event = RunFailed
+	}
+
+	runningMux.Lock()
+	running = false
+	runningMux.Unlock()
+	publishProgress(ProgressEvent{Event: event})
+}
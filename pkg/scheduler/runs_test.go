@@ -0,0 +1,111 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// readyGraph returns graph of two ready pods where the second one depends on the first
+func readyGraph(t *testing.T) DependencyGraph {
+	c := mocks.NewClient()
+	c.ResDefs = mocks.NewResourceDefinitionClient("pod/ready-1", "pod/ready-2")
+	c.Deps = mocks.NewDependencyClient(mocks.Dependency{Parent: "pod/ready-1", Child: "pod/ready-2"})
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return depGraph
+}
+
+// TestProgressEvents checks that subscribers receive events of the run and its resources
+func TestProgressEvents(t *testing.T) {
+	events, cancel := SubscribeProgress()
+	defer cancel()
+
+	depGraph := readyGraph(t)
+	Create(depGraph, 0)
+
+	var received []ProgressEvent
+	for len(events) > 0 {
+		received = append(received, <-events)
+	}
+	if len(received) < 2 {
+		t.Fatalf("Expected run and resource events, got %v", received)
+	}
+	if received[0].Event != RunStarted || received[0].RunID == "" {
+		t.Errorf("Expected first event to be start of the run, got %+v", received[0])
+	}
+	if last := received[len(received)-1]; last.Event != RunCompleted || last.RunID != received[0].RunID {
+		t.Errorf("Expected last event to be completion of the run, got %+v", last)
+	}
+	states := map[string]NodeState{}
+	for _, event := range received {
+		if event.Key != "" {
+			states[event.Key] = event.State
+		}
+	}
+	for _, key := range []string{"pod/ready-1", "pod/ready-2"} {
+		if states[key] != NodeReady {
+			t.Errorf("Expected %s to be reported ready, got %q", key, states[key])
+		}
+	}
+
+	lastGraph, running := LastRun()
+	if running {
+		t.Error("Run should not be in progress")
+	}
+	if len(lastGraph) != len(depGraph) {
+		t.Errorf("Expected last run graph to be the deployed one, got %v", lastGraph)
+	}
+}
+
+// TestPause checks that resources are not created while deployment is paused
+func TestPause(t *testing.T) {
+	Pause()
+	defer Resume()
+	if !IsPaused() {
+		t.Fatal("Deployment should be paused")
+	}
+
+	depGraph := readyGraph(t)
+	done := make(chan struct{})
+	go func() {
+		Create(depGraph, 0)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Graph should not be deployed while paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if _, running := LastRun(); !running {
+		t.Error("Paused run should be in progress")
+	}
+
+	Resume()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Graph was not deployed after resume")
+	}
+	if IsPaused() {
+		t.Error("Deployment should not be paused")
+	}
+}
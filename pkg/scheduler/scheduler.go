@@ -17,19 +17,27 @@ package scheduler
 import (
 	"container/list"
 	"fmt"
-	"log"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/Mirantis/k8s-AppController/pkg/client"
 	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
 	"github.com/Mirantis/k8s-AppController/pkg/report"
 	"github.com/Mirantis/k8s-AppController/pkg/resources"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/api/v1"
 	"k8s.io/client-go/pkg/labels"
 )
 
+// DeleteBeforeCreateKey is a dependency meta key. If it is set to "true", the parent resource is
+// deleted instead of being created, and the child is created only after the parent is gone
+const DeleteBeforeCreateKey = "delete-before-create"
+
 // ScheduledResourceStatus describes possible status of a single resource
 type ScheduledResourceStatus int
 
@@ -80,14 +88,98 @@ type ScheduledResource struct {
 	Requires   []*ScheduledResource
 	RequiredBy []*ScheduledResource
 	Started    bool
-	Error      error
-	status     string
+	// Skipped is true if the resource was not created because it was not needed: either it is an
+	// on-error dependency of created resource, or its dependency timed out with on-timeout set to skip,
+	// or it or its dependency failed with on-failure policy set to skip
+	Skipped bool
+	// Existing is true if there is no definition for the resource. Such resources are not managed
+	// by AppController, so they are never deleted on destroy
+	Existing bool
+	Error    error
+	// failed is true if processing of the resource is over and it was not created successfully
+	failed bool
+	// created is true if processing of the resource is over and it was created
+	created bool
+	// resumed is true if the resource was ready according to saved state, so it is not created again
+	resumed    bool
+	stateStore StateStore
+	// history records readiness durations of the resource, creationStart is a time its creation
+	// started in the current run and readyDuration is a time it took to become ready
+	history       DurationHistory
+	creationStart time.Time
+	readyDuration time.Duration
+	// runRecords keeps records of graph runs
+	runRecords RunRecordStore
+	// revisions keep revisions of resDef, the definition of the resource, when it is deployed
+	revisions RevisionStore
+	resDef    *client.ResourceDefinition
+	// events and definition are used to record events for resource definition of the resource
+	events     corev1.EventInterface
+	definition *v1.ObjectReference
+	// namespace is a namespace of the object if it is not ac namespace
+	namespace string
+	// autoNamespace is true for nodes of namespaces added for resources in them. Deleting such namespace
+	// would delete all objects in it, including ones not managed by AppController, so it is never deleted
+	autoNamespace bool
+	// watcher notifies the resource about changes of its object
+	watcher *Watcher
+	// recreations are attempts to recreate the object after it failed in the current run
+	recreations []Recreation
+	// logsClient is used to capture container logs of the resource when it fails, failureLogs are
+	// the captured logs
+	logsClient  client.Interface
+	failureLogs string
+	// polling sets intervals of status checks of the resource
+	polling PollingOptions
+	// readySince is a time since which every status check of the resource was ready
+	readySince time.Time
+	// probe has to pass, in addition to the status of the object, before the resource is ready
+	probe *resources.ReadinessProbe
+	// status is cached status of the resource retrieved at statusTime, statusResets counts resets of it,
+	// so that checks which were in progress during a reset do not cache outdated status
+	status       interfaces.ResourceStatus
+	statusTime   time.Time
+	statusResets int
+	// runTimeout is a deadline of the whole graph run, deadline is a time by which the resource must
+	// be ready in the current run
+	runTimeout time.Duration
+	deadline   time.Time
+	// windows are deployment windows of the resource, windowOpens is a time when the next one opens
+	// while creation of the resource is deferred
+	windows     DeploymentWindows
+	windowOpens time.Time
+	// run is a state of the current graph run shared by all its resources
+	run *runState
+	// abandoned receives result of the last call to the cluster which timed out. No other call is made
+	// until it returns, so that retried creation does not race with the abandoned one
+	abandoned chan error
+	// rollback is true if resources created by the run are deleted when the run is aborted
+	rollback bool
+	// object is metadata of the object created from definition of the resource, which is labeled
+	// with graphName and ID of the run. owner is a reference to the definition set as its owner
+	object    *v1.ObjectMeta
+	owner     *v1.OwnerReference
+	graphName string
 	interfaces.Resource
+	// dependencies are Dependency objects by parent key, dependencyStatus publishes their status
+	// during graph runs
+	dependencies     map[string]client.Dependency
+	dependencyStatus client.DependenciesInterface
 	// parentKey -> dependencyMetadata
 	Meta map[string]map[string]string
 	sync.RWMutex
 }
 
+// Key returns key of the resource. Keys of resources outside ac namespace are qualified with the namespace
+func (sr *ScheduledResource) Key() string {
+	return namespacedKey(sr.Resource.Key(), sr.namespace)
+}
+
+// logger returns logger which tags lines with key of the resource and given processing phase
+func (sr *ScheduledResource) logger(phase string) logging.Logger {
+	return logging.ForResource(sr.Key()).With(logging.PhaseField, phase)
+}
+
 // RequestCreation does not create a scheduled resource immediately, but updates status
 // and puts the scheduled resource to corresponding channel. Returns true if
 // scheduled resource creation was actually requested, false otherwise.
@@ -113,20 +205,62 @@ func (sr *ScheduledResource) RequestCreation(toCreate chan *ScheduledResource) b
 
 // Wait periodically checks resource status and returns if the resource processing is finished,
 // regardless successfull or not. The actual result of processing could be obtained from returned error.
+// If objects of the resource kind are watched, status is checked whenever the object changes, and
+// polling interval is increased to WatchPollInterval unless it is set by poll-interval meta. The first
+// check is made after initial delay of the resource, which counts towards the timeout
 func (sr *ScheduledResource) Wait(checkInterval time.Duration, timeout time.Duration) error {
+	var changed <-chan struct{}
+	if sr.watcher != nil {
+		if ch, cancel, ok := sr.watcher.Subscribe(sr.Key()); ok {
+			defer cancel()
+			changed = ch
+			checkInterval = sr.watchedPollInterval(checkInterval)
+		}
+	}
+
 	ch := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
 	go func(ch chan error) {
+		if delay := sr.initialDelay(); delay > 0 {
+			sr.logger("wait").Debugf("Waiting %v before checking status of %s", delay, sr.Key())
+			select {
+			case <-done:
+				return
+			case <-time.After(delay):
+			}
+		}
+		var b backoff
 		for {
+			apiBreaker.wait()
 			status, err := sr.Status(nil)
+			if throttlingError(err) {
+				delay := b.next()
+				sr.logger("wait").Warningf("API server error checking status of %s, retrying in %v: %v", sr.Key(), delay, err)
+				if !sleepBackoff(delay, done) {
+					return
+				}
+				sr.ResetStatus()
+				continue
+			}
+			b.reset()
 			if err != nil {
 				ch <- err
+				return
 			}
 
-			if status == "ready" {
+			if status.Phase == interfaces.ResourceReady {
 				ch <- nil
+				return
 			}
 
-			time.Sleep(checkInterval)
+			select {
+			case <-done:
+				return
+			case <-changed:
+				sr.ResetStatus()
+			case <-time.After(checkInterval):
+			}
 		}
 	}(ch)
 
@@ -143,21 +277,95 @@ func (sr *ScheduledResource) Wait(checkInterval time.Duration, timeout time.Dura
 }
 
 // Status either returns cached copy of resource's status or retrieves it via Resource.Status
-// depending on presense of cached copy and resource's settings
-func (sr *ScheduledResource) Status(meta map[string]string) (string, error) {
+// depending on presense of cached copy and resource's settings. The lock is held only to read and
+// update the cached status, not during the check itself
+func (sr *ScheduledResource) Status(meta map[string]string) (interfaces.ResourceStatus, error) {
+	sr.Lock()
+	if sr.failed {
+		status, err := observedStatus(interfaces.NewStatus(interfaces.ResourceError), sr.Error, sr.statusTime), sr.Error
+		sr.Unlock()
+		return status, err
+	}
+	if sr.cachedStatus(meta) {
+		status, err := sr.status, sr.Error
+		sr.Unlock()
+		return status, err
+	}
+	ctx, cancel := newRequestContext(sr.run, sr.deadline)
+	defer cancel()
+	probe, resets := sr.probe, sr.statusResets
+	sr.Unlock()
+
+	start := time.Now()
+	status, err := statusWithContext(ctx, sr.Resource, meta)
+	if err == nil && status.IsReady() && probe != nil {
+		status, err = probe.Status(ctx)
+	}
+	statusPollSeconds.Observe(time.Since(start).Seconds(), resourceKind(sr.Key()))
+	sr.recordAPIResult(err)
+
 	sr.Lock()
 	defer sr.Unlock()
-	if (sr.status == "ready" || sr.Error != nil) && sr.Resource.StatusIsCacheable(meta) {
-		return sr.status, sr.Error
+	if resets != sr.statusResets {
+		return observedStatus(status, err, time.Now()), err
 	}
-	status, err := sr.Resource.Status(meta)
 	sr.Error = err
+	sr.statusTime = time.Now()
+	status = sr.stableStatus(observedStatus(status, err, sr.statusTime))
 	if sr.Resource.StatusIsCacheable(meta) {
 		sr.status = status
 	}
 	return status, err
 }
 
+// Upgrade updates the resource in the cluster if it exists but differs from its definition
+func (sr *ScheduledResource) Upgrade() error {
+	status, err := sr.Status(nil)
+	if err != nil || status.Phase != interfaces.ResourceWaitingForUpgrade {
+		return nil
+	}
+	sr.logger("upgrade").Infof("Resource %s differs from its definition, upgrading", sr.Key())
+	sr.recordEvent(v1.EventTypeNormal, EventUpgrading, "Resource differs from its definition, upgrading")
+	err = sr.Update()
+	sr.ResetStatus()
+	if err != nil {
+		return err
+	}
+	if resources.GetBoolMeta(sr.Resource, resources.RestartDependentsKey, false) {
+		sr.restartDependents()
+	}
+	return nil
+}
+
+// restartDependents restarts pods of resources which depend on this one by setting
+// checksum of its content in their pod template annotation
+func (sr *ScheduledResource) restartDependents() {
+	var resource interface{} = sr.Resource
+	if wrapper, ok := resource.(report.SimpleReporter); ok {
+		resource = wrapper.GetResource()
+	}
+	checksummer, ok := resource.(interfaces.Checksummer)
+	if !ok {
+		sr.logger("upgrade").Warningf("Resource %s does not support restarting dependents", sr.Key())
+		return
+	}
+	checksum, err := checksummer.Checksum()
+	if err != nil {
+		sr.logger("upgrade").Errorf("Could not compute checksum of %s: %v", sr.Key(), err)
+		return
+	}
+	annotation := "checksum.appcontroller.k8s/" + strings.Replace(sr.Key(), "/", "-", -1)
+	for _, dependent := range sr.RequiredBy {
+		restarter, ok := dependent.Resource.(interfaces.PodRestarter)
+		if !ok {
+			continue
+		}
+		if err := restarter.RestartPods(annotation, checksum); err != nil {
+			dependent.logger("upgrade").Errorf("Could not restart pods of %s: %v", dependent.Key(), err)
+		}
+	}
+}
+
 // IsBlocked checks whether a scheduled resource can be created. It checks status of resources
 // it depends on, via API
 func (sr *ScheduledResource) IsBlocked() bool {
@@ -166,40 +374,167 @@ func (sr *ScheduledResource) IsBlocked() bool {
 		_, onErrorSet := meta["on-error"]
 
 		status, err := req.Status(meta)
+		failed := err != nil || status.Phase == interfaces.ResourceError
 
-		if err != nil && !onErrorSet {
+		if failed && !onErrorSet {
 			return true
-		} else if status == "ready" && onErrorSet {
+		} else if !failed && onErrorSet {
 			return true
-		} else if err == nil && status != "ready" {
+		} else if !failed && status.Phase != interfaces.ResourceReady {
 			return true
 		}
 	}
 	return false
 }
 
+// dependencyTimeout parses timeout from dependency meta. Timeout can be either a duration string,
+// such as "10m" or "600s", or an integer number of seconds
+func dependencyTimeout(meta map[string]string) (time.Duration, bool) {
+	value, ok := meta["timeout"]
+	if !ok {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, seconds > 0
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		logging.Warningf("Dependency timeout '%s' is neither a duration nor an integer, ignoring it", value)
+		return 0, false
+	}
+	return timeout, timeout > 0
+}
+
+// timeOut is called when dependency with given key did not become ready within the dependency timeout.
+// Depending on "on-timeout" dependency meta the branch starting at this resource is either marked
+// failed ("fail", the default) or skipped ("skip"). In both cases none of resources in the branch is created
+func (sr *ScheduledResource) timeOut(parentKey string, finished chan string) {
+	skip := sr.Meta[parentKey]["on-timeout"] == "skip"
+	err := fmt.Errorf("timeout waiting for dependency %s", parentKey)
+	sr.logger("dependency").Errorf("Resource %s was not created: %v", sr.Key(), err)
+	sr.abandon(err, skip, finished)
+}
+
+// abandon marks resource and all not started resources depending on it as finished without creating them
+func (sr *ScheduledResource) abandon(err error, skip bool, finished chan string) {
+	sr.Lock()
+	if sr.Started {
+		sr.Unlock()
+		return
+	}
+	sr.Started = true
+	sr.Skipped = skip
+	if !skip {
+		sr.Error = err
+		sr.failed = true
+	}
+	sr.Unlock()
+
+	if skip {
+		sr.setState(NodeSkipped, err)
+		sr.recordEvent(v1.EventTypeNormal, EventSkipped, err.Error())
+	} else {
+		sr.setState(NodeFailed, err)
+		sr.recordEvent(v1.EventTypeWarning, EventFailed, err.Error())
+	}
+
+	finished <- sr.Key()
+
+	for _, req := range sr.RequiredBy {
+		req.abandon(fmt.Errorf("dependency %s was not created", sr.Key()), skip, finished)
+	}
+}
+
+// isOnErrorDependency returns true if the resource should be created only when parent with given key fails
+func (sr *ScheduledResource) isOnErrorDependency(parentKey string) bool {
+	_, ok := sr.Meta[parentKey]["on-error"]
+	return ok
+}
+
+// finish is called when processing of the resource is over, err is the reason of failure or nil if the
+// resource was created. Dependents which can never be created are abandoned: on-error dependents are
+// skipped if the resource was created, other dependents fail if it was not, or are skipped along with the
+// resource if its on-failure policy is skip. With abort policy failure of the resource aborts the whole run
+func (sr *ScheduledResource) finish(err error, finished chan string) {
+	policy := FailureBlock
+	if err != nil && !IsRunAborted(err) {
+		policy = sr.failurePolicy()
+	}
+	var logs string
+	if err != nil && !IsRunAborted(err) {
+		logs = sr.captureFailureLogs()
+	}
+	if err != nil && policy == FailureSkip {
+		sr.logger("create").Infof("Resource %s failed, skipping it according to its %s policy", sr.Key(), FailurePolicyKey)
+		sr.Lock()
+		sr.Error = err
+		sr.Skipped = true
+		sr.Unlock()
+		sr.setState(NodeSkipped, err)
+		sr.recordEvent(v1.EventTypeNormal, EventSkipped, failureMessage(err, logs))
+	} else if err != nil {
+		sr.Lock()
+		sr.Error = err
+		sr.failed = true
+		sr.Unlock()
+		sr.setState(NodeFailed, err)
+		sr.recordEvent(v1.EventTypeWarning, EventFailed, failureMessage(err, logs))
+		if policy == FailureAbort {
+			sr.logger("create").Errorf("Resource %s failed, aborting the run according to its %s policy", sr.Key(), FailurePolicyKey)
+			sr.run.abort(RunAbortedError{Key: sr.Key(), Err: err})
+		}
+	} else {
+		sr.Lock()
+		sr.created = true
+		sr.Unlock()
+		sr.setState(NodeReady, nil)
+		sr.recordEvent(v1.EventTypeNormal, EventReady, "Resource is ready")
+		sr.recordRevision()
+	}
+
+	for _, req := range sr.RequiredBy {
+		if req.isOnErrorDependency(sr.Key()) != (err == nil) {
+			continue
+		}
+		if err == nil {
+			req.logger("dependency").Infof("Resource %s was created, skipping its on-error dependency %s", sr.Key(), req.Key())
+		}
+		req.abandon(fmt.Errorf("dependency %s was not created: %v", sr.Key(), err), err == nil || policy == FailureSkip, finished)
+	}
+
+	finished <- sr.Key()
+}
+
 // ResetStatus resets cached status of scheduled resource
 func (sr *ScheduledResource) ResetStatus() {
 	sr.Lock()
 	defer sr.Unlock()
 	sr.Error = nil
-	sr.status = ""
+	sr.status = interfaces.ResourceStatus{}
+	sr.statusTime = time.Time{}
+	sr.statusResets++
 }
 
 // DependencyGraph is a full deployment graph as a mapping from job keys to
 // ScheduledResource pointers
 type DependencyGraph map[string]*ScheduledResource
 
-func newResource(name string, resDefs []client.ResourceDefinition, c client.Interface, resourceTemplate interfaces.ResourceTemplate) interfaces.Resource {
+// newResource returns resource with given name created from its definition. If there is no definition,
+// the resource is expected to exist already and the second returned value is true
+func newResource(name string, resDefs []client.ResourceDefinition, c client.Interface, resourceTemplate interfaces.ResourceTemplate) (interfaces.Resource, bool, error) {
 	for _, rd := range resDefs {
 		if resourceTemplate.NameMatches(rd, name) {
-			log.Println("Found resource definition for ", name)
-			return resourceTemplate.New(rd, c)
+			logging.Debugf("Found resource definition for %s", name)
+			dc, err := definitionClient(rd, c)
+			if err != nil {
+				return nil, false, err
+			}
+			return resourceTemplate.New(rd, dc), false, nil
 		}
 	}
 
-	log.Printf("Resource definition for '%s' not found, so it is expected to exist already", name)
-	return resourceTemplate.NewExisting(name, c)
+	logging.Infof("Resource definition for '%s' not found, so it is expected to exist already", name)
+	return resourceTemplate.NewExisting(name, c), true, nil
 
 }
 
@@ -207,15 +542,32 @@ func newResource(name string, resDefs []client.ResourceDefinition, c client.Inte
 func NewScheduledResource(kind string, name string,
 	resDefs []client.ResourceDefinition, c client.Interface) (*ScheduledResource, error) {
 
-	var r interfaces.Resource
-
 	resourceTemplate, ok := resources.KindToResourceTemplate[kind]
 	if !ok {
 		return nil, fmt.Errorf("Not a proper resource kind: %s. Expected '%s'", kind, strings.Join(resources.Kinds, "', '"))
 	}
-	r = newResource(name, resDefs, c, resourceTemplate)
+	if kind == "petset" && !resources.PetSetsEnabled {
+		return nil, errPetSetsDisabled
+	}
+	r, existing, err := newResource(name, resDefs, c, resourceTemplate)
+	if err != nil {
+		return nil, err
+	}
+	// existing objects can be referred to by label selector, except for selector nodes themselves
+	if existing && kind != "selector" && resources.IsSelectorName(name) {
+		if r, err = resources.NewNewestSelected(kind, name, c); err != nil {
+			return nil, err
+		}
+	}
+	probe, err := resources.GetReadinessProbe(r, c)
+	if err != nil {
+		return nil, err
+	}
 
-	return NewScheduledResourceFor(r), nil
+	sr := NewScheduledResourceFor(r)
+	sr.Existing = existing
+	sr.probe = probe
+	return sr, nil
 }
 
 // NewScheduledResourceFor returns new scheduled resource for given resource in init state
@@ -229,7 +581,8 @@ func NewScheduledResourceFor(r interfaces.Resource) *ScheduledResource {
 }
 
 func keyParts(key string) (kind string, name string, err error) {
-	parts := strings.Split(key, "/")
+	// label selectors in keys of selector nodes may contain slashes
+	parts := strings.SplitN(key, "/", 2)
 
 	if len(parts) < 2 {
 		return "", "", fmt.Errorf("Not a proper resource key: %s. Expected KIND/NAME", key)
@@ -238,26 +591,79 @@ func keyParts(key string) (kind string, name string, err error) {
 	return parts[0], parts[1], nil
 }
 
+// newResourceFromDefinition creates resource described by resource definition with template of its kind
+func newResourceFromDefinition(r client.ResourceDefinition, c client.Interface) (interfaces.Resource, error) {
+	key, err := definitionObjectKey(r)
+	if err != nil {
+		return nil, err
+	}
+	kind, _, err := keyParts(key)
+	if err != nil {
+		return nil, err
+	}
+	if kind == "petset" && !resources.PetSetsEnabled {
+		return nil, errPetSetsDisabled
+	}
+	template, ok := resources.KindToResourceTemplate[kind]
+	if !ok {
+		return nil, fmt.Errorf("Resource definition %s contains object of unregistered kind %s", r.Name, kind)
+	}
+	if c, err = definitionClient(r, c); err != nil {
+		return nil, err
+	}
+	return template.New(r, c), nil
+}
+
 // BuildDependencyGraph loads dependencies data and creates the DependencyGraph
 func BuildDependencyGraph(c client.Interface, sel labels.Selector) (DependencyGraph, error) {
 
-	log.Println("Getting resource definitions")
+	logging.Infof("Getting resource definitions")
 	resDefList, err := c.ResourceDefinitions().List(api.ListOptions{LabelSelector: sel})
 	if err != nil {
 		return nil, err
 	}
 
-	resDefs := resDefList.Items
-
-	log.Println("Getting dependencies")
+	logging.Infof("Getting dependencies")
 	depList, err := c.Dependencies().List(api.ListOptions{LabelSelector: sel})
 	if err != nil {
 		return nil, err
 	}
 
+	allResDefs, allDeps, err := ExpandCopies(resDefList.Items, depList.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := newConditionEvaluator(c)
+	clients := newNamespacedClients(c)
+	excluded := map[string]bool{}
+	var resDefs []client.ResourceDefinition
+	// resDefsByNamespace are included resource definitions by namespace of their objects
+	resDefsByNamespace := map[string][]client.ResourceDefinition{}
+	for _, r := range allResDefs {
+		matches, err := conditions.matches(r.Meta[ConditionKey])
+		if err != nil {
+			return nil, err
+		}
+		namespace := definitionNamespace(r)
+		if matches {
+			resDefs = append(resDefs, r)
+			resDefsByNamespace[namespace] = append(resDefsByNamespace[namespace], r)
+			continue
+		}
+		// excluded definitions are not turned into resources, so that e.g. PetSets excluded on clusters
+		// with StatefulSets do not need PetSet support
+		key, err := definitionKey(r)
+		if err != nil {
+			return nil, err
+		}
+		logging.ForResource(key).Infof("Condition of resource definition %s is not met, excluding it", key)
+		excluded[key] = true
+	}
+
 	depGraph := DependencyGraph{}
 
-	for _, d := range depList.Items {
+	for _, d := range allDeps {
 		parent := d.Parent
 		child := d.Child
 
@@ -265,149 +671,411 @@ func BuildDependencyGraph(c client.Interface, sel labels.Selector) (DependencyGr
 		// to the form KIND/NAME, so that no extra metainformation
 		// (e.g. success factor for replica set) will not be a part of the key
 
-		log.Println("Found dependency", parent, "->", child)
+		logging.Debugf("Found dependency %s -> %s", parent, child)
+
+		if excluded[parent] || excluded[child] {
+			logging.Infof("Dependency %s -> %s refers to excluded resource, skipping it", parent, child)
+			continue
+		}
+		matches, err := conditions.matches(d.Meta[ConditionKey])
+		if err != nil {
+			return nil, err
+		}
+		if !matches {
+			logging.Infof("Condition of dependency %s -> %s is not met, skipping it", parent, child)
+			continue
+		}
 
 		for _, key := range []string{parent, child} {
 			if _, ok := depGraph[key]; !ok {
-				log.Printf("Resource %s not found in dependecy graph yet, adding.", key)
+				logging.Debugf("Resource %s not found in dependecy graph yet, adding.", key)
 
 				kind, name, err := keyParts(key)
 				if err != nil {
 					return nil, err
 				}
+				namespace := ""
+				if !resources.NonObjectKinds[kind] && !resources.ClusterScopedKinds[kind] {
+					name, namespace = splitNamespace(name)
+				}
 
-				sr, err := NewScheduledResource(kind, name, resDefs, c)
+				sr, err := NewScheduledResource(kind, name, resDefsByNamespace[namespace], clients.get(namespace))
 				if err != nil {
 					return nil, err
 				}
+				sr.namespace = namespace
 
 				depGraph[key] = sr
 			}
 		}
 
+		if d.Meta[DeleteBeforeCreateKey] == "true" {
+			if _, ok := depGraph[parent].Resource.(resources.Deletion); !ok {
+				logging.ForResource(parent).Infof("Resource %s will be deleted before %s is created", parent, child)
+				depGraph[parent].Resource = resources.NewDeletion(depGraph[parent].Resource)
+			}
+		}
+
 		depGraph[child].Requires = append(
 			depGraph[child].Requires, depGraph[parent])
 
 		depGraph[child].Meta[parent] = d.Meta
+		depGraph[child].setDependency(parent, d)
 
 		depGraph[parent].RequiredBy = append(
 			depGraph[parent].RequiredBy, depGraph[child])
 	}
 
-	log.Println("Looking for resource definitions not in dependency list")
-	for _, r := range resDefList.Items {
-		var resource interfaces.Resource
-
-		if r.Pod != nil {
-			resource = resources.NewPod(r.Pod, c.Pods(), r.Meta)
-		} else if r.Job != nil {
-			resource = resources.NewJob(r.Job, c.Jobs(), r.Meta)
-		} else if r.Service != nil {
-			resource = resources.NewService(r.Service, c.Services(), c, r.Meta)
-		} else if r.ReplicaSet != nil {
-			resource = resources.NewReplicaSet(r.ReplicaSet, c.ReplicaSets(), r.Meta)
-		} else if r.StatefulSet != nil {
-			resource = resources.NewStatefulSet(r.StatefulSet, c.StatefulSets(), c, r.Meta)
-		} else if r.PetSet != nil {
-			resource = resources.NewPetSet(r.PetSet, c.PetSets(), c, r.Meta)
-		} else if r.DaemonSet != nil {
-			resource = resources.NewDaemonSet(r.DaemonSet, c.DaemonSets(), r.Meta)
-		} else if r.ConfigMap != nil {
-			resource = resources.NewConfigMap(r.ConfigMap, c.ConfigMaps(), r.Meta)
-		} else if r.Secret != nil {
-			resource = resources.NewSecret(r.Secret, c.Secrets(), r.Meta)
-		} else if r.Deployment != nil {
-			resource = resources.NewDeployment(r.Deployment, c.Deployments(), r.Meta)
-		} else if r.PersistentVolumeClaim != nil {
-			resource = resources.NewPersistentVolumeClaim(r.PersistentVolumeClaim, c.PersistentVolumeClaims(), r.Meta)
-		} else if r.ServiceAccount != nil {
-			resource = resources.NewServiceAccount(r.ServiceAccount, c.ServiceAccounts(), r.Meta)
-		} else {
-			return nil, fmt.Errorf("Found unsupported resource %v", r)
+	logging.Debugf("Looking for resource definitions not in dependency list")
+	for _, r := range resDefs {
+		namespace := definitionNamespace(r)
+		resource, err := newResourceFromDefinition(r, clients.get(namespace))
+		if err != nil {
+			return nil, err
 		}
 
-		if _, ok := depGraph[resource.Key()]; !ok {
-			log.Printf("Resource %s not found in dependecy graph yet, adding.", resource.Key())
-			depGraph[resource.Key()] = NewScheduledResourceFor(resource)
+		key := namespacedKey(resource.Key(), namespace)
+		if _, ok := depGraph[key]; !ok {
+			logging.Debugf("Resource %s not found in dependecy graph yet, adding.", key)
+			probe, err := resources.GetReadinessProbe(resource, clients.get(namespace))
+			if err != nil {
+				return nil, err
+			}
+			sr := NewScheduledResourceFor(resource)
+			sr.namespace = namespace
+			sr.probe = probe
+			depGraph[key] = sr
 		}
 	}
 
+	if err := depGraph.addHooks(clients); err != nil {
+		return nil, err
+	}
+	if err := depGraph.addBlueGreen(clients); err != nil {
+		return nil, err
+	}
+	if err := depGraph.withWindows(); err != nil {
+		return nil, err
+	}
+	depGraph.addNamespaces(c)
+	depGraph.withEvents(c.Events(), resDefs)
+	depGraph.withDefinitions(resDefs)
+	depGraph.withOwnership(resDefs)
+	depGraph.withImageChecks(clients)
+	depGraph.withFailureLogs(clients)
+	watcher := NewWatcher(c)
+	for _, sr := range depGraph {
+		sr.watcher = watcher
+		// objects outside ac namespace are not indexed
+		if sr.namespace == "" {
+			sr.Resource = resources.WithSelectorIndex(sr.Resource, watcher.index)
+		}
+	}
 	return depGraph, nil
 }
 
-func createResources(toCreate chan *ScheduledResource, finished chan string, ccLimiter chan struct{}) {
+// resourceQueue is a queue of resources ready to be created. Resources with higher "priority" meta
+// go first, resources with the same priority are ordered by key
+type resourceQueue []*ScheduledResource
 
-	for r := range toCreate {
-		go func(r *ScheduledResource, finished chan string, ccLimiter chan struct{}) {
-			// Acquire sepmaphor
-			ccLimiter <- struct{}{}
+func (q *resourceQueue) push(r *ScheduledResource) {
+	i := sort.Search(len(*q), func(i int) bool { return createBefore(r, (*q)[i]) })
+	*q = append(*q, nil)
+	copy((*q)[i+1:], (*q)[i:])
+	(*q)[i] = r
+}
 
-			attempts := resources.GetIntMeta(r.Resource, "retry", 1)
-			timeoutInSeconds := resources.GetIntMeta(r.Resource, "timeout", -1)
+func (q *resourceQueue) pop() *ScheduledResource {
+	r := (*q)[0]
+	*q = (*q)[1:]
+	return r
+}
 
-			waitTimeout := WaitTimeout
-			if timeoutInSeconds > 0 {
-				waitTimeout = time.Second * time.Duration(timeoutInSeconds)
-			}
+// createBefore returns true if resource a should be created before resource b
+func createBefore(a, b *ScheduledResource) bool {
+	aPriority := resources.GetIntMeta(a.Resource, "priority", 0)
+	bPriority := resources.GetIntMeta(b.Resource, "priority", 0)
+	if aPriority != bPriority {
+		return aPriority > bPriority
+	}
+	return a.Key() < b.Key()
+}
 
-			for attemptNo := 1; attemptNo <= attempts; attemptNo++ {
-
-				r.ResetStatus()
-
-				var err error
-
-				// NOTE(gluke77): We start goroutines for dependencies
-				// before the resource becomes ready, since dependencies
-				// could have metadata defining their own readiness condition
-				if attemptNo == 1 {
-					for _, req := range r.RequiredBy {
-						go func(req *ScheduledResource, toCreate chan *ScheduledResource) {
-							for {
-								time.Sleep(CheckInterval)
-								if req.RequestCreation(toCreate) {
-									break
-								}
-							}
-						}(req, toCreate)
-					}
+func createResources(toCreate chan *ScheduledResource, finished chan string, ccLimiter chan struct{}) {
+	var pending resourceQueue
+	for {
+		if len(pending) == 0 {
+			r, ok := <-toCreate
+			if !ok {
+				return
+			}
+			pending.push(r)
+		}
+		// take everything that became ready to be created, so that the order is decided by priority
+		// rather than by the order in which the resources were unblocked
+	drain:
+		for {
+			select {
+			case r, ok := <-toCreate:
+				if !ok {
+					return
 				}
+				pending.push(r)
+			default:
+				break drain
+			}
+		}
 
-				if attemptNo > 1 {
-					log.Printf("Trying to delete resource %s after previous unsuccessful attempt", r.Key())
-					err = r.Delete()
-					if err != nil {
-						log.Printf("Error deleting resource %s: %v", r.Key(), err)
-					}
+		select {
+		case r, ok := <-toCreate:
+			if !ok {
+				return
+			}
+			pending.push(r)
+		// Acquire semaphor
+		case ccLimiter <- struct{}{}:
+			go createResource(pending.pop(), toCreate, finished, ccLimiter)
+		}
+	}
+}
 
+// startDependents starts polling resources which depend on this one, requesting their creation once they
+// are not blocked anymore
+func (sr *ScheduledResource) startDependents(toCreate chan *ScheduledResource, finished chan string, ccLimiter chan struct{}) {
+	for _, req := range sr.RequiredBy {
+		go func(req *ScheduledResource, toCreate chan *ScheduledResource) {
+			timeout, hasTimeout := dependencyTimeout(req.Meta[sr.Key()])
+			start := time.Now()
+			for {
+				time.Sleep(sr.pollInterval())
+				apiBreaker.wait()
+				// status checks of dependencies are limited by concurrency as well,
+				// so that large graphs do not flood API server with requests
+				ccLimiter <- struct{}{}
+				requested := req.RequestCreation(toCreate)
+				<-ccLimiter
+				if requested {
+					break
 				}
-
-				log.Printf("Creating resource %s, attempt %d of %d", r.Key(), attemptNo, attempts)
-				err = r.Create()
-				if err != nil {
-					log.Printf("Error creating resource %s: %v", r.Key(), err)
-					continue
+				if err := req.run.aborted(); err != nil {
+					req.abandon(err, false, finished)
+					break
 				}
+				if err := req.deadlineExceeded(); err != nil {
+					req.logger("dependency").Errorf("Resource %s was not created: %v", req.Key(), err)
+					req.abandon(err, false, finished)
+					break
+				}
+				if hasTimeout && time.Since(start) > timeout {
+					req.timeOut(sr.Key(), finished)
+					break
+				}
+			}
+		}(req, toCreate)
+	}
+}
 
-				log.Printf("Checking status for %s", r.Key())
+// createResource creates the resource, waits for it to become ready and starts polling its dependents.
+// Semaphor must be acquired by the caller, it is released once the resource is processed
+func createResource(r *ScheduledResource, toCreate chan *ScheduledResource, finished chan string, ccLimiter chan struct{}) {
+	start := time.Now()
+	attempts := resources.GetIntMeta(r.Resource, "retry", 1)
+	timeoutInSeconds := resources.GetIntMeta(r.Resource, "timeout", -1)
 
-				err = r.Wait(CheckInterval, waitTimeout)
+	waitTimeout := WaitTimeout
+	if timeoutInSeconds > 0 {
+		waitTimeout = time.Second * time.Duration(timeoutInSeconds)
+	}
 
-				if err == nil {
-					log.Printf("Resource %s created", r.Key())
-					break
-				}
+	if r.resumed {
+		r.logger("resume").Infof("Resource %s was created by previous run, skipping it", r.Key())
+		attempts = 0
+		r.startDependents(toCreate, finished, ccLimiter)
+	}
+
+	var err error
+	if attempts > 0 {
+		if err = r.waitForWindow(ccLimiter); err != nil {
+			r.logger("window").Errorf("Resource %s was not created: %v", r.Key(), err)
+			attempts = 0
+		}
+	}
+	if attempts > 0 {
+		waitWhilePaused()
+		r.Lock()
+		r.creationStart = time.Now()
+		r.Unlock()
+	}
+
+	var createBackoff backoff
+	dependentsStarted := false
+	recreating := false
+	policyApplied := false
+	for attemptNo := 1; attemptNo <= attempts; attemptNo++ {
+		if err = r.run.aborted(); err != nil {
+			r.logger("create").Errorf("Resource %s was not created: %v", r.Key(), err)
+			break
+		}
+		if err = r.deadlineExceeded(); err != nil {
+			r.logger("create").Errorf("Resource %s was not created: %v", r.Key(), err)
+			break
+		}
+
+		r.ResetStatus()
+
+		// NOTE(gluke77): We start goroutines for dependencies
+		// before the resource becomes ready, since dependencies
+		// could have metadata defining their own readiness condition
+		if !dependentsStarted {
+			r.startDependents(toCreate, finished, ccLimiter)
+			dependentsStarted = true
+		}
+
+		// nothing was created yet if existence policy could not be applied
+		if attemptNo > 1 && !recreating && policyApplied && !r.autoNamespace {
+			r.logger("create").Infof("Trying to delete resource %s after previous unsuccessful attempt", r.Key())
+			err = r.Delete()
+			if err != nil {
+				r.logger("create").Errorf("Error deleting resource %s: %v", r.Key(), err)
+			}
+
+		}
+		recreating = false
+
+		if !policyApplied {
+			err = r.applyExistencePolicy()
+			if IsObjectExists(err) {
+				r.logger("create").Errorf("Resource %s was not created: %v", r.Key(), err)
+				break
+			}
+			if err != nil {
+				r.logger("create").Errorf("Error applying %s policy of %s: %v", resources.IfExistsKey, r.Key(), err)
+				continue
+			}
+			policyApplied = true
+		}
+
+		r.logger("create").Infof("Creating resource %s, attempt %d of %d", r.Key(), attemptNo, attempts)
+		createAttempts.Inc(resourceKind(r.Key()))
+		missing := r.missing()
+		apiBreaker.wait()
+		err = r.Create()
+		r.recordAPIResult(err)
+		if err != nil {
+			r.logger("create").Errorf("Error creating resource %s: %v", r.Key(), err)
+			// API server is overloaded or unavailable, so the next attempt is delayed
+			if throttlingError(err) && attemptNo < attempts {
+				delay := createBackoff.next()
+				r.logger("create").Warningf("API server error, next attempt in %v", delay)
+				sleepBackoff(delay, nil)
+			}
+			continue
+		}
+		createBackoff.reset()
+		if missing {
+			r.run.own(r)
+		}
+		r.ResetStatus()
+		r.setState(NodeCreated, nil)
+		r.recordEvent(v1.EventTypeNormal, EventCreated, fmt.Sprintf("Resource created, attempt %d of %d", attemptNo, attempts))
+
+		err = r.Upgrade()
+		if err != nil {
+			r.logger("upgrade").Errorf("Error upgrading resource %s: %v", r.Key(), err)
+			continue
+		}
+
+		r.logger("wait").Debugf("Checking status for %s", r.Key())
+
+		err = r.Wait(r.pollInterval(), r.untilDeadline(waitTimeout))
+		if deadlineErr := r.deadlineExceeded(); err != nil && deadlineErr != nil {
+			err = deadlineErr
+		}
+
+		if err == nil {
+			r.logger("wait").Infof("Resource %s created", r.Key())
+			break
+		}
+
+		r.logger("wait").Errorf("Resource %s was not created: %v", r.Key(), err)
 
-				log.Printf("Resource %s was not created: %v", r.Key(), err)
+		// Failed object is recreated without using up retry attempts
+		recreating = r.shouldRecreate(err)
+		if recreating {
+			if deleteErr := r.recreate(err, r.untilDeadline(waitTimeout)); deleteErr != nil {
+				r.logger("recreate").Errorf("Error deleting failed resource %s: %v", r.Key(), deleteErr)
+				break
 			}
-			finished <- r.Key()
-			// Release semaphor
-			<-ccLimiter
-		}(r, finished, ccLimiter)
+			attemptNo--
+		}
+	}
+	if err != nil {
+		createFailures.Inc(resourceKind(r.Key()))
+	} else if attempts > 0 {
+		timeToReadySeconds.Set(time.Since(start).Seconds(), r.Key())
+		r.Lock()
+		r.readyDuration = time.Since(r.creationStart)
+		r.Unlock()
+		r.recordDuration(r.readyDuration)
+	}
+	r.finish(err, finished)
+	// Release semaphor
+	<-ccLimiter
+}
+
+// Concurrency returns concurrency set by "concurrency" meta of graph resources, or 0 if it is not set.
+// If several resources set it, the smallest value is used
+func (depGraph DependencyGraph) Concurrency() int {
+	result := 0
+	for _, sr := range depGraph {
+		concurrency := resources.GetIntMeta(sr.Resource, "concurrency", 0)
+		if concurrency > 0 && (result == 0 || concurrency < result) {
+			result = concurrency
+		}
 	}
+	return result
 }
 
-// Create starts the deployment of a DependencyGraph
+// Create starts the deployment of a DependencyGraph. Concurrency limits number of resources which are
+// created or checked at the same time, 0 means no limit. It is overridden by concurrency set in the graph
 func Create(depGraph DependencyGraph, concurrency int) {
+	createGraph(depGraph, concurrency)
+}
+
+// CreateWithState deploys the graph like Create, but persists state of its resources in the store.
+// Resources which were ready according to the state saved by previous interrupted run are not created
+// again. Saved state is removed once every resource of the graph is created
+func CreateWithState(depGraph DependencyGraph, concurrency int, store StateStore) error {
+	if err := depGraph.resume(store); err != nil {
+		return err
+	}
+	createGraph(depGraph, concurrency)
+
+	for _, sr := range depGraph {
+		if sr.failed {
+			sr.logger("state").Infof("Resource %s failed, keeping deployment state to resume from", sr.Key())
+			return nil
+		}
+	}
+	return store.Reset()
+}
+
+func createGraph(depGraph DependencyGraph, concurrency int) {
+	start := time.Now()
+	runID := logging.NewRunID()
+	logging.SetRunID(runID)
+	logging.Infof("Starting graph run %s", runID)
+	depGraph.setDeadlines(start)
+	depGraph.resetRunState(runID, start)
+	depGraph.labelObjects(runID)
+	depGraph.notifyWebhooks(RunStarted, runID)
+	startRun(depGraph, runID)
+	stopDependencyStatus := depGraph.publishDependencyStatus(runID)
+
+	if graphConcurrency := depGraph.Concurrency(); graphConcurrency > 0 {
+		logging.Infof("Using concurrency %d set in the graph", graphConcurrency)
+		concurrency = graphConcurrency
+	}
 
 	depCount := len(depGraph)
 
@@ -428,12 +1096,22 @@ func Create(depGraph DependencyGraph, concurrency int) {
 		}
 	}
 
-	log.Printf("Wait for %d deps to create\n", depCount)
+	updateGraphMetrics(depGraph)
+	logging.Infof("Wait for %d deps to create", depCount)
 	for i := 0; i < depCount; i++ {
 		<-created
+		updateGraphMetrics(depGraph)
 	}
 	close(toCreate)
 	close(created)
+	depGraph.stopWatchers()
+	depGraph.reportDeadline()
+	depGraph.rollbackIfAborted()
+	stopDependencyStatus()
+	depGraph.notifyWebhooks(RunCompleted, runID)
+	depGraph.saveRunRecord(runID, start)
+	depGraph.lastRun().finish()
+	finishRun(depGraph)
 
 	// TODO Make sure every KO gets created eventually
 }
@@ -519,30 +1197,30 @@ func assignVertex(vertex, root *ScheduledResource, assigned map[string]bool, com
 // GetNodeReport acts as a more verbose version of IsBlocked. It performs the
 // same check as IsBlocked, but returns the DeploymentReport
 func (sr *ScheduledResource) GetNodeReport(name string) report.NodeReport {
-	var ready bool
 	isBlocked := false
 	dependencies := make([]interfaces.DependencyReport, 0, len(sr.Requires))
 	status, err := sr.Status(nil)
-	if err != nil {
-		ready = false
-	} else {
-		ready = status == "ready"
-	}
+	ready := err == nil && status.IsReady()
 	for _, r := range sr.Requires {
 		r.RLock()
 		meta := r.Meta[sr.Key()]
-		depReport := r.GetDependencyReport(meta)
 		r.RUnlock()
+		depReport := r.GetDependencyReport(meta)
 		if depReport.Blocks {
 			isBlocked = true
 		}
 		dependencies = append(dependencies, depReport)
 	}
+	sr.RLock()
+	skipped := sr.Skipped
+	sr.RUnlock()
 	return report.NodeReport{
 		Dependent:    name,
 		Dependencies: dependencies,
 		Blocked:      isBlocked,
 		Ready:        ready,
+		Skipped:      skipped,
+		Status:       status,
 	}
 }
 
@@ -552,10 +1230,15 @@ func (sr *ScheduledResource) GetNodeReport(name string) report.NodeReport {
 func (graph *DependencyGraph) GetStatus() (DeploymentStatus, report.DeploymentReport) {
 	var readyExist, nonReadyExist bool
 	var status DeploymentStatus
-	report := make(report.DeploymentReport, 0, len(*graph))
-	for key, resource := range *graph {
-		depReport := resource.GetNodeReport(key)
-		report = append(report, depReport)
+	keys := make([]string, 0, len(*graph))
+	for key := range *graph {
+		keys = append(keys, key)
+	}
+	report := make(report.DeploymentReport, len(keys))
+	parallel(len(keys), func(i int) {
+		report[i] = (*graph)[keys[i]].GetNodeReport(keys[i])
+	})
+	for _, depReport := range report {
 		if depReport.Ready {
 			readyExist = true
 		} else {
@@ -17,12 +17,15 @@ package scheduler
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/Mirantis/k8s-AppController/pkg/mocks"
 	"github.com/Mirantis/k8s-AppController/pkg/report"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
 )
 
 func TestBuildDependencyGraph(t *testing.T) {
@@ -511,3 +514,269 @@ func TestGraph(t *testing.T) {
 		}
 	}
 }
+
+// TestRestartDependents checks that pod templates of dependent deployments get checksum of config map
+func TestRestartDependents(t *testing.T) {
+	c := mocks.NewClient(mocks.MakeConfigMap("cfg"), mocks.MakeDeployment("ready"))
+	configMap := mocks.MakeConfigMap("cfg")
+	configMap.Data = map[string]string{"key": "value"}
+
+	deployment := &ScheduledResource{
+		Resource: resources.NewExistingDeployment("ready", c.Deployments()),
+	}
+	sr := &ScheduledResource{
+		Resource:   resources.NewConfigMap(configMap, c.ConfigMaps(), map[string]interface{}{resources.RestartDependentsKey: "true"}),
+		RequiredBy: []*ScheduledResource{deployment},
+	}
+
+	sr.restartDependents()
+
+	d, err := c.Deployments().Get("ready")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Spec.Template.Annotations["checksum.appcontroller.k8s/configmap-cfg"] == "" {
+		t.Error("Deployment pod template should have config map checksum annotation")
+	}
+}
+
+// TestDependencyTimeout checks parsing of timeout in dependency meta
+func TestDependencyTimeout(t *testing.T) {
+	cases := map[string]time.Duration{"600": 600 * time.Second, "10m": 10 * time.Minute, "0": 0, "bad": 0}
+	for value, expected := range cases {
+		timeout, ok := dependencyTimeout(map[string]string{"timeout": value})
+		if timeout != expected || ok != (expected > 0) {
+			t.Errorf("Timeout `%s` parsed as %v (%t), expected %v", value, timeout, ok, expected)
+		}
+	}
+	if _, ok := dependencyTimeout(nil); ok {
+		t.Error("Timeout should not be set for empty meta")
+	}
+}
+
+// TestDependencyTimeoutFailsBranch checks that timed out dependency fails the whole branch, but not other resources
+func TestDependencyTimeoutFailsBranch(t *testing.T) {
+	c := mocks.NewClient()
+	c.ResDefs = mocks.NewResourceDefinitionClient("pod/ready-1", "pod/ready-2", "pod/ready-3", "pod/ready-4")
+	c.Deps = mocks.NewDependencyClient(
+		mocks.Dependency{Parent: "pod/ready-1", Child: "pod/ready-2"},
+		mocks.Dependency{Parent: "pod/ready-2", Child: "pod/ready-3"},
+		mocks.Dependency{Parent: "pod/ready-4", Child: "pod/ready-3"},
+	)
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	finished := make(chan string, len(depGraph))
+	depGraph["pod/ready-2"].timeOut("pod/ready-1", finished)
+	close(finished)
+
+	var keys []string
+	for key := range finished {
+		keys = append(keys, key)
+	}
+	if len(keys) != 2 || keys[0] != "pod/ready-2" || keys[1] != "pod/ready-3" {
+		t.Errorf("Expected pod/ready-2 and pod/ready-3 to be finished, got %v", keys)
+	}
+	for _, key := range keys {
+		if depGraph[key].Error == nil || depGraph[key].Skipped {
+			t.Errorf("Resource %s should be failed", key)
+		}
+	}
+	if depGraph["pod/ready-3"].RequestCreation(make(chan *ScheduledResource, 1)) != true {
+		t.Error("Creation of failed resource should be considered already requested")
+	}
+	if depGraph["pod/ready-4"].Started || depGraph["pod/ready-4"].Error != nil {
+		t.Error("Independent resource should not be affected by timeout")
+	}
+}
+
+// TestDependencyTimeoutSkipsBranch checks that with on-timeout set to skip the branch is skipped without errors
+func TestDependencyTimeoutSkipsBranch(t *testing.T) {
+	c := mocks.NewClient()
+	c.ResDefs = mocks.NewResourceDefinitionClient("pod/ready-1", "pod/ready-2")
+	c.Deps = mocks.NewDependencyClient(mocks.Dependency{Parent: "pod/ready-1", Child: "pod/ready-2"})
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	depGraph["pod/ready-2"].Meta["pod/ready-1"] = map[string]string{"timeout": "1", "on-timeout": "skip"}
+
+	finished := make(chan string, len(depGraph))
+	depGraph["pod/ready-2"].timeOut("pod/ready-1", finished)
+
+	if len(finished) != 1 {
+		t.Errorf("Expected 1 finished resource, got %d", len(finished))
+	}
+	if !depGraph["pod/ready-2"].Skipped || depGraph["pod/ready-2"].Error != nil {
+		t.Error("Resource should be skipped without error")
+	}
+}
+
+// TestFinishWithError checks that failed resource unblocks its on-error dependents and fails the others
+func TestFinishWithError(t *testing.T) {
+	parent := NewScheduledResourceFor(report.SimpleReporter{BaseResource: mocks.NewResource("parent", "not ready")})
+	onError := NewScheduledResourceFor(report.SimpleReporter{BaseResource: mocks.NewResource("on-error", "not ready")})
+	regular := NewScheduledResourceFor(report.SimpleReporter{BaseResource: mocks.NewResource("regular", "not ready")})
+	for _, child := range []*ScheduledResource{onError, regular} {
+		child.Requires = []*ScheduledResource{parent}
+		parent.RequiredBy = append(parent.RequiredBy, child)
+	}
+	onError.Meta["parent"] = map[string]string{"on-error": "true"}
+
+	finished := make(chan string, 3)
+	parent.finish(errors.New("timeout"), finished)
+
+	if len(finished) != 2 {
+		t.Errorf("Expected 2 finished resources, got %d", len(finished))
+	}
+	if status, err := parent.Status(nil); status.Phase != "error" || err == nil {
+		t.Errorf("Failed resource should have error status, got `%s` and %v", status, err)
+	}
+	if onError.IsBlocked() || onError.Started {
+		t.Error("On-error dependency of failed resource should be unblocked")
+	}
+	if !regular.Started || regular.Error == nil {
+		t.Error("Regular dependency of failed resource should fail")
+	}
+}
+
+// TestFinishWithoutError checks that created resource skips its on-error dependents
+func TestFinishWithoutError(t *testing.T) {
+	parent := NewScheduledResourceFor(report.SimpleReporter{BaseResource: mocks.NewResource("parent", "ready")})
+	onError := NewScheduledResourceFor(report.SimpleReporter{BaseResource: mocks.NewResource("on-error", "not ready")})
+	onError.Requires = []*ScheduledResource{parent}
+	onError.Meta["parent"] = map[string]string{"on-error": "true"}
+	parent.RequiredBy = []*ScheduledResource{onError}
+
+	finished := make(chan string, 2)
+	parent.finish(nil, finished)
+
+	if len(finished) != 2 {
+		t.Errorf("Expected 2 finished resources, got %d", len(finished))
+	}
+	if !onError.Skipped || onError.Error != nil {
+		t.Error("On-error dependency of created resource should be skipped")
+	}
+}
+
+// TestGraphConcurrency checks that the smallest concurrency set in graph meta is used
+func TestGraphConcurrency(t *testing.T) {
+	c := mocks.NewClient()
+	depGraph := DependencyGraph{}
+	for i, concurrency := range []interface{}{nil, float64(5), float64(2)} {
+		var meta map[string]interface{}
+		if concurrency != nil {
+			meta = map[string]interface{}{"concurrency": concurrency}
+		}
+		sr := NewScheduledResourceFor(resources.NewPod(mocks.MakePod(fmt.Sprintf("ready-%d", i)), c.Pods(), meta))
+		depGraph[sr.Key()] = sr
+	}
+
+	if depGraph.Concurrency() != 2 {
+		t.Errorf("Expected graph concurrency 2, got %d", depGraph.Concurrency())
+	}
+}
+
+// TestResourceQueueOrder checks that resources are ordered by priority and then by key
+func TestResourceQueueOrder(t *testing.T) {
+	c := mocks.NewClient()
+	var queue resourceQueue
+	for name, priority := range map[string]interface{}{"ready-b": nil, "ready-a": nil, "ready-c": float64(10), "ready-d": float64(-1)} {
+		var meta map[string]interface{}
+		if priority != nil {
+			meta = map[string]interface{}{"priority": priority}
+		}
+		queue.push(NewScheduledResourceFor(resources.NewPod(mocks.MakePod(name), c.Pods(), meta)))
+	}
+
+	expected := []string{"pod/ready-c", "pod/ready-a", "pod/ready-b", "pod/ready-d"}
+	for _, key := range expected {
+		if r := queue.pop(); r.Key() != key {
+			t.Errorf("Expected %s, got %s", key, r.Key())
+		}
+	}
+}
+
+// TestDeleteBeforeCreateDependency checks that parent of delete-before-create dependency is deleted instead of created
+func TestDeleteBeforeCreateDependency(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePod("ready-1"))
+	c.ResDefs = mocks.NewResourceDefinitionClient("pod/ready-2")
+	c.Deps = mocks.NewDependencyClient(
+		mocks.Dependency{Parent: "pod/ready-1", Child: "pod/ready-2", Meta: map[string]string{DeleteBeforeCreateKey: "true"}})
+
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := depGraph["pod/ready-1"].Resource.(resources.Deletion); !ok {
+		t.Errorf("Parent of delete-before-create dependency should be a deletion, got %T", depGraph["pod/ready-1"].Resource)
+	}
+	if _, ok := depGraph["pod/ready-2"].Resource.(resources.Deletion); ok {
+		t.Error("Child of delete-before-create dependency should not be a deletion")
+	}
+}
+
+// TestReadinessProbe checks that ready resource is not ready and blocks its dependents until its readiness probe passes
+func TestReadinessProbe(t *testing.T) {
+	migrated := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !migrated {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	c := mocks.NewClient(mocks.MakePod("ready-1"))
+	meta := map[string]interface{}{resources.ReadinessProbeKey: map[string]interface{}{"http": map[string]interface{}{"url": server.URL}}}
+	pod := resources.NewPod(mocks.MakePod("ready-1"), c.Pods(), meta)
+	probe, err := resources.GetReadinessProbe(pod, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sr := NewScheduledResourceFor(pod)
+	sr.probe = probe
+
+	if status, _ := sr.Status(nil); status.IsReady() || status.Reason != "ReadinessProbeFailed" {
+		t.Errorf("Resource should not be ready until its probe passes, got %+v", status)
+	}
+	if depReport := sr.GetDependencyReport(nil); !depReport.Blocks {
+		t.Errorf("Resource should block its dependents until its probe passes, got %+v", depReport)
+	}
+
+	migrated = true
+	sr.ResetStatus()
+	if status, err := sr.Status(nil); err != nil || !status.IsReady() {
+		t.Errorf("Resource should be ready once its probe passes, got %+v, %v", status, err)
+	}
+	if depReport := sr.GetDependencyReport(nil); depReport.Blocks {
+		t.Errorf("Resource should not block its dependents once its probe passes, got %+v", depReport)
+	}
+}
+
+// TestBuildDependencyGraphSelectedParent checks that parent without definition can be referred to by label selector
+func TestBuildDependencyGraphSelectedParent(t *testing.T) {
+	pod := mocks.MakePod("ready-1abc")
+	pod.Labels = map[string]string{"app": "db"}
+	c := mocks.NewClient(pod, mocks.MakePod("ready-2"))
+	c.ResDefs = mocks.NewResourceDefinitionClient("pod/ready-2")
+	c.Deps = mocks.NewDependencyClient(mocks.Dependency{Parent: "pod/app=db", Child: "pod/ready-2"})
+
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := depGraph["pod/app=db"]
+	if parent == nil || !parent.Existing {
+		t.Fatalf("Expected existing node pod/app=db, got %v", depGraph)
+	}
+	if _, ok := parent.Resource.(resources.NewestSelected); !ok {
+		t.Errorf("Parent should be resolved by selector, got %T", parent.Resource)
+	}
+
+	Create(depGraph, 0)
+	if !depGraph["pod/ready-2"].created {
+		t.Errorf("Child should be created once selected pod is ready, got %v", depGraph["pod/ready-2"].Error)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+)
+
+// StableForKey is a meta key of resource definition with time the resource must stay ready, without
+// interruption, before resources depending on it are created. It is a duration string, e.g. 60s, or
+// a number of seconds
+const StableForKey = "stable-for"
+
+// stableFor returns time the resource must stay ready before it is considered ready
+func (sr *ScheduledResource) stableFor() time.Duration {
+	switch value := sr.Resource.Meta(StableForKey).(type) {
+	case nil:
+		return 0
+	case float64:
+		return secondsDuration(value)
+	case string:
+		duration, err := time.ParseDuration(value)
+		if err == nil {
+			return duration
+		}
+	}
+	sr.logger("wait").Warningf("Metadata parameter '%s' for resource '%s' is set to '%v' but it is neither a duration nor a number of seconds, ignoring it",
+		StableForKey, sr.Key(), sr.Resource.Meta(StableForKey))
+	return 0
+}
+
+// stableStatus returns status of the resource which is ready but has not been ready for its stable-for
+// time yet as not ready. Readiness is continuous as long as every status check since the first ready one
+// was ready. Must be called with the lock held
+func (sr *ScheduledResource) stableStatus(status interfaces.ResourceStatus) interfaces.ResourceStatus {
+	stableFor := sr.stableFor()
+	if stableFor <= 0 {
+		return status
+	}
+	if !status.IsReady() {
+		if !sr.readySince.IsZero() {
+			sr.logger("wait").Warningf("Resource %s stopped being ready after %v, waiting until it is stable", sr.Key(), time.Since(sr.readySince))
+		}
+		sr.readySince = time.Time{}
+		return status
+	}
+	if sr.readySince.IsZero() {
+		sr.readySince = time.Now()
+	}
+	if ready := time.Since(sr.readySince); ready < stableFor {
+		return interfaces.NewStatus(interfaces.ResourceNotReady).WithReason("NotStable",
+			fmt.Sprintf("ready for %v, must be ready for %v", ready-ready%time.Second, stableFor))
+	}
+	return status
+}
+
+// isStable returns true if the resource has been ready for its stable-for time, or has none
+func (sr *ScheduledResource) isStable() bool {
+	stableFor := sr.stableFor()
+	if stableFor <= 0 {
+		return true
+	}
+	sr.RLock()
+	defer sr.RUnlock()
+	return !sr.readySince.IsZero() && time.Since(sr.readySince) >= stableFor
+}
+
+// stableReport makes dependency report of resource which is not stable yet block its dependents
+func (sr *ScheduledResource) stableReport(depReport interfaces.DependencyReport) interfaces.DependencyReport {
+	if depReport.Blocks || sr.isStable() {
+		return depReport
+	}
+	depReport.Blocks = true
+	depReport.Percentage = 0
+	depReport.Message = fmt.Sprintf("%s must be ready for %v", sr.Key(), sr.stableFor())
+	return depReport
+}
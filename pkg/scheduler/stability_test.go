@@ -0,0 +1,83 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+func stablePod(stableFor interface{}) *ScheduledResource {
+	c := mocks.NewClient(mocks.MakePod("ready-1"))
+	meta := map[string]interface{}{StableForKey: stableFor}
+	return NewScheduledResourceFor(resources.NewPod(mocks.MakePod("ready-1"), c.Pods(), meta))
+}
+
+// TestStableFor checks parsing of stable-for meta
+func TestStableFor(t *testing.T) {
+	cases := map[interface{}]time.Duration{
+		"1m":    time.Minute,
+		30.0:    30 * time.Second,
+		"later": 0,
+	}
+	for value, expected := range cases {
+		if stableFor := stablePod(value).stableFor(); stableFor != expected {
+			t.Errorf("stable-for %v should be %v, got %v", value, expected, stableFor)
+		}
+	}
+}
+
+// TestStableStatus checks that resource is ready only after it is ready continuously for stable-for time
+func TestStableStatus(t *testing.T) {
+	sr := stablePod("50ms")
+	ready := interfaces.NewStatus(interfaces.ResourceReady)
+	notReady := interfaces.NewStatus(interfaces.ResourceNotReady)
+
+	if status := sr.stableStatus(ready); status.IsReady() || status.Reason != "NotStable" {
+		t.Errorf("Resource should not be stable right after it is ready, got %v", status)
+	}
+	if depReport := sr.stableReport(interfaces.DependencyReport{Dependency: sr.Key()}); !depReport.Blocks {
+		t.Error("Resource which is not stable should block dependents")
+	}
+	time.Sleep(30 * time.Millisecond)
+	sr.stableStatus(notReady)
+	time.Sleep(30 * time.Millisecond)
+	if status := sr.stableStatus(ready); status.IsReady() {
+		t.Errorf("Resource should not be stable after it stopped being ready, got %v", status)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if status := sr.stableStatus(ready); !status.IsReady() {
+		t.Errorf("Resource should be stable, got %v", status)
+	}
+	if depReport := sr.stableReport(interfaces.DependencyReport{Dependency: sr.Key()}); depReport.Blocks {
+		t.Error("Stable resource should not block dependents")
+	}
+}
+
+// TestWaitStable checks that Wait returns only after the resource is stable
+func TestWaitStable(t *testing.T) {
+	sr := stablePod(0.1)
+	start := time.Now()
+	if err := sr.Wait(10*time.Millisecond, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Wait returned after %v, before the resource was stable", elapsed)
+	}
+}
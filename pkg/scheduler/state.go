@@ -0,0 +1,177 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+)
+
+// NodeState is a state of graph resource persisted between AppController runs
+type NodeState string
+
+// Possible values for NodeState
+const (
+	NodeCreated NodeState = "created"
+	NodeReady   NodeState = "ready"
+	NodeFailed  NodeState = "failed"
+	NodeSkipped NodeState = "skipped"
+)
+
+// NodeRecord is a saved state of graph resource with the time it entered the state
+type NodeRecord struct {
+	State NodeState `json:"state"`
+	Since time.Time `json:"since"`
+}
+
+// StateStore persists state of graph resources, so that interrupted deployment can be resumed
+type StateStore interface {
+	// Load returns saved states of resources by their keys
+	Load() (map[string]NodeRecord, error)
+	// Save stores state of resource with given key. Time of the state is kept if it did not change
+	Save(key string, state NodeState) error
+	// Reset removes all saved states
+	Reset() error
+}
+
+// stateDataKey is a key of config map data holding JSON with node states
+const stateDataKey = "state"
+
+type configMapStateStore struct {
+	client corev1.ConfigMapInterface
+	name   string
+	states map[string]NodeRecord
+	sync.Mutex
+}
+
+// NewConfigMapStateStore returns StateStore which keeps states in config map with given name
+func NewConfigMapStateStore(client corev1.ConfigMapInterface, name string) StateStore {
+	return &configMapStateStore{client: client, name: name}
+}
+
+func (s *configMapStateStore) Load() (map[string]NodeRecord, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.states = map[string]NodeRecord{}
+	configMap, err := s.client.Get(s.name)
+	if errors.IsNotFound(err) {
+		return s.copyStates(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if data, ok := configMap.Data[stateDataKey]; ok {
+		if err = json.Unmarshal([]byte(data), &s.states); err != nil {
+			return nil, err
+		}
+	}
+	return s.copyStates(), nil
+}
+
+func (s *configMapStateStore) Save(key string, state NodeState) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.states == nil {
+		s.states = map[string]NodeRecord{}
+	}
+	if s.states[key].State != state {
+		s.states[key] = NodeRecord{State: state, Since: time.Now()}
+	}
+	return s.write()
+}
+
+func (s *configMapStateStore) Reset() error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.states = map[string]NodeRecord{}
+	err := s.client.Delete(s.name, nil)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *configMapStateStore) copyStates() map[string]NodeRecord {
+	result := make(map[string]NodeRecord, len(s.states))
+	for key, state := range s.states {
+		result[key] = state
+	}
+	return result
+}
+
+// write stores all states in the config map, creating it if needed. Caller must hold the lock
+func (s *configMapStateStore) write() error {
+	data, err := json.Marshal(s.states)
+	if err != nil {
+		return err
+	}
+
+	configMap, err := s.client.Get(s.name)
+	if errors.IsNotFound(err) {
+		configMap = &v1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: s.name},
+			Data:       map[string]string{stateDataKey: string(data)},
+		}
+		_, err = s.client.Create(configMap)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[stateDataKey] = string(data)
+	_, err = s.client.Update(configMap)
+	return err
+}
+
+// saveState saves state of the resource if the graph is deployed with a state store
+func (sr *ScheduledResource) saveState(state NodeState) {
+	if sr.stateStore == nil {
+		return
+	}
+	if err := sr.stateStore.Save(sr.Key(), state); err != nil {
+		sr.logger("state").Warningf("Could not save state of %s: %v", sr.Key(), err)
+	}
+}
+
+// resume restores saved states of graph resources. Resources which were ready are not created
+// and checked again, other resources are processed from the beginning
+func (depGraph DependencyGraph) resume(store StateStore) error {
+	states, err := store.Load()
+	if err != nil {
+		return err
+	}
+	for key, sr := range depGraph {
+		sr.stateStore = store
+		if states[key].State == NodeReady {
+			sr.logger("resume").Infof("Resource %s is ready according to saved state", key)
+			sr.resumed = true
+			sr.status = interfaces.NewStatus(interfaces.ResourceReady)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestConfigMapStateStore checks that states saved in config map are loaded back
+func TestConfigMapStateStore(t *testing.T) {
+	c := mocks.NewClient()
+	store := NewConfigMapStateStore(c.ConfigMaps(), "state")
+
+	states, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 0 {
+		t.Errorf("No states expected, got %v", states)
+	}
+
+	if err = store.Save("pod/ready-1", NodeReady); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.Save("pod/ready-2", NodeFailed); err != nil {
+		t.Fatal(err)
+	}
+
+	states, err = NewConfigMapStateStore(c.ConfigMaps(), "state").Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if states["pod/ready-1"].State != NodeReady || states["pod/ready-2"].State != NodeFailed {
+		t.Errorf("Unexpected states %v", states)
+	}
+
+	if err = store.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.ConfigMaps().Get("state"); err == nil {
+		t.Error("State config map should be deleted")
+	}
+}
+
+// TestCreateWithState checks that resources ready according to saved state are not waited for
+func TestCreateWithState(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePod("pending"), mocks.MakePod("ready-1"))
+	c.Deps = mocks.NewDependencyClient(mocks.Dependency{Parent: "pod/pending", Child: "pod/ready-1"})
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewConfigMapStateStore(c.ConfigMaps(), "state")
+	if err = store.Save("pod/pending", NodeReady); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = CreateWithState(depGraph, 0, store); err != nil {
+		t.Fatal(err)
+	}
+	if depGraph["pod/ready-1"].failed {
+		t.Errorf("Resource should be created, failed with %v", depGraph["pod/ready-1"].Error)
+	}
+	if _, err = c.ConfigMaps().Get("state"); err == nil {
+		t.Error("State should be removed after successful deployment")
+	}
+}
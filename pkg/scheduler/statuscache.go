@@ -0,0 +1,95 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+	"k8s.io/client-go/pkg/api/errors"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// StatusCacheTTLKey is a meta key of resource definition overriding time in seconds for which ready
+// status and errors of the resource are cached. 0 disables caching, negative value caches status
+// until it is invalidated
+const StatusCacheTTLKey = "status-cache-ttl"
+
+// CacheForever is a TTL of statuses which are cached until they are invalidated
+const CacheForever time.Duration = -1
+
+// defaultStatusCacheTTLs are status cache TTLs by resource kind. Statuses of objects which can stop
+// being ready on their own are rechecked, other statuses are cached until they are invalidated by
+// creation, update or watch event of the object
+var defaultStatusCacheTTLs = map[string]time.Duration{
+	"pod":         time.Minute,
+	"replicaset":  time.Minute,
+	"statefulset": time.Minute,
+	"petset":      time.Minute,
+	"daemonset":   time.Minute,
+	"deployment":  time.Minute,
+}
+
+// statusCacheTTL returns time for which status of the resource is cached
+func (sr *ScheduledResource) statusCacheTTL() time.Duration {
+	if sr.Resource.Meta(StatusCacheTTLKey) != nil {
+		seconds := resources.GetIntMeta(sr.Resource, StatusCacheTTLKey, -1)
+		if seconds < 0 {
+			return CacheForever
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if ttl, ok := defaultStatusCacheTTLs[resourceKind(sr.Key())]; ok {
+		return ttl
+	}
+	return CacheForever
+}
+
+// cachedStatus returns true if status of the resource for dependency with given meta can be taken
+// from the cache. Only ready status and errors are cached. Resources restored from saved state are
+// trusted to stay ready. Caller must hold the lock
+func (sr *ScheduledResource) cachedStatus(meta map[string]string) bool {
+	if sr.resumed {
+		return true
+	}
+	if sr.status.Phase != interfaces.ResourceReady && sr.Error == nil {
+		return false
+	}
+	if !sr.Resource.StatusIsCacheable(meta) {
+		return false
+	}
+	ttl := sr.statusCacheTTL()
+	return ttl == CacheForever || time.Since(sr.statusTime) < ttl
+}
+
+// observedStatus stamps the status with time of the check. Failed checks which did not explain the status
+// themselves get the error as its message, with reason of API server error if there is one
+func observedStatus(status interfaces.ResourceStatus, err error, observedAt time.Time) interfaces.ResourceStatus {
+	if status.ObservedAt.IsZero() {
+		status.ObservedAt = observedAt
+	}
+	if err == nil || status.Reason != "" || status.Message != "" {
+		return status
+	}
+	reason := "StatusCheckFailed"
+	if err == context.DeadlineExceeded {
+		reason = "RequestTimeout"
+	} else if apiStatus, ok := err.(errors.APIStatus); ok && apiStatus.Status().Reason != "" {
+		reason = string(apiStatus.Status().Reason)
+	}
+	return status.WithReason(reason, err.Error())
+}
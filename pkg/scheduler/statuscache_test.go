@@ -0,0 +1,181 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	apierrors "k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/unversioned"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+// pollCountingResource is a ready resource which counts status checks and has status cache TTL in its meta
+type pollCountingResource struct {
+	*mocks.Resource
+	polls int
+	ttl   interface{}
+}
+
+func (r *pollCountingResource) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	r.polls++
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+func (r *pollCountingResource) Meta(key string) interface{} {
+	if key == StatusCacheTTLKey {
+		return r.ttl
+	}
+	return nil
+}
+
+func newPollCountingResource(key string, ttl interface{}) (*ScheduledResource, *pollCountingResource) {
+	r := &pollCountingResource{Resource: mocks.NewResource(key, interfaces.ResourceReady), ttl: ttl}
+	return NewScheduledResourceFor(report.SimpleReporter{BaseResource: r}), r
+}
+
+// TestStatusCacheForever checks that statuses of kinds without default TTL are cached until reset
+func TestStatusCacheForever(t *testing.T) {
+	sr, r := newPollCountingResource("configmap/cfg", nil)
+	sr.Status(nil)
+	sr.Status(nil)
+	if r.polls != 1 {
+		t.Errorf("Expected status to be checked once, checked %d times", r.polls)
+	}
+
+	sr.ResetStatus()
+	sr.Status(nil)
+	if r.polls != 2 {
+		t.Errorf("Expected status to be checked again after reset, checked %d times", r.polls)
+	}
+}
+
+// TestStatusCacheDisabled checks that zero TTL in meta disables caching
+func TestStatusCacheDisabled(t *testing.T) {
+	sr, r := newPollCountingResource("configmap/cfg", float64(0))
+	sr.Status(nil)
+	sr.Status(nil)
+	if r.polls != 2 {
+		t.Errorf("Expected status to be checked twice, checked %d times", r.polls)
+	}
+}
+
+// TestStatusCacheExpires checks that cached status expires after its TTL
+func TestStatusCacheExpires(t *testing.T) {
+	sr, r := newPollCountingResource("pod/p1", nil)
+	if ttl := sr.statusCacheTTL(); ttl != defaultStatusCacheTTLs["pod"] {
+		t.Errorf("Expected default TTL of pods, got %v", ttl)
+	}
+
+	sr.Status(nil)
+	sr.Status(nil)
+	if r.polls != 1 {
+		t.Errorf("Expected status to be checked once, checked %d times", r.polls)
+	}
+
+	sr.statusTime = time.Now().Add(-2 * time.Minute)
+	sr.Status(nil)
+	if r.polls != 2 {
+		t.Errorf("Expected expired status to be checked again, checked %d times", r.polls)
+	}
+}
+
+// TestStatusCacheTTLOverride checks that TTL in meta overrides default TTL of the kind
+func TestStatusCacheTTLOverride(t *testing.T) {
+	sr, _ := newPollCountingResource("pod/p1", float64(5))
+	if ttl := sr.statusCacheTTL(); ttl != 5*time.Second {
+		t.Errorf("Expected TTL of 5s, got %v", ttl)
+	}
+	sr, _ = newPollCountingResource("pod/p1", float64(-1))
+	if ttl := sr.statusCacheTTL(); ttl != CacheForever {
+		t.Errorf("Expected status to be cached forever, got %v", ttl)
+	}
+}
+
+// blockingResource is a ready resource whose status checks wait until the test lets them finish
+type blockingResource struct {
+	*mocks.Resource
+	started chan struct{}
+	finish  chan struct{}
+}
+
+func (r *blockingResource) Status(ctx context.Context, meta map[string]string) (interfaces.ResourceStatus, error) {
+	r.started <- struct{}{}
+	<-r.finish
+	return interfaces.NewStatus(interfaces.ResourceReady), nil
+}
+
+// TestStatusCheckUnlocked checks that the resource is not locked while its status is checked, and that
+// status checked during a reset is not cached
+func TestStatusCheckUnlocked(t *testing.T) {
+	r := &blockingResource{Resource: mocks.NewResource("configmap/cfg", interfaces.ResourceReady), started: make(chan struct{}), finish: make(chan struct{})}
+	sr := NewScheduledResourceFor(report.SimpleReporter{BaseResource: r})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if status, err := sr.Status(nil); err != nil || !status.IsReady() {
+			t.Errorf("Expected ready status, got %v, %v", status, err)
+		}
+	}()
+
+	<-r.started
+	locked := make(chan struct{})
+	go func() {
+		sr.ResetStatus()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("Resource is locked while its status is checked")
+	}
+	close(r.finish)
+	<-done
+
+	sr.RLock()
+	defer sr.RUnlock()
+	if !sr.statusTime.IsZero() || sr.status.IsReady() {
+		t.Errorf("Status checked during reset should not be cached, got %v at %v", sr.status, sr.statusTime)
+	}
+}
+
+// TestObservedStatus checks that statuses are stamped with time of the check and failed checks are explained
+func TestObservedStatus(t *testing.T) {
+	now := time.Now()
+	notFound := apierrors.NewNotFound(unversioned.GroupResource{Resource: "pods"}, "missing")
+
+	status := observedStatus(interfaces.NewStatus(interfaces.ResourceError), notFound, now)
+	if !status.ObservedAt.Equal(now) {
+		t.Errorf("Expected status observed at %v, got %v", now, status.ObservedAt)
+	}
+	if status.Reason != string(unversioned.StatusReasonNotFound) || status.Message != notFound.Error() {
+		t.Errorf("Expected reason and message of API error, got %+v", status)
+	}
+
+	status = observedStatus(interfaces.NewStatus(interfaces.ResourceError), context.DeadlineExceeded, now)
+	if status.Reason != "RequestTimeout" {
+		t.Errorf("Expected reason `RequestTimeout`, got `%s`", status.Reason)
+	}
+
+	explained := interfaces.NewStatus(interfaces.ResourceError).WithReason("CrashLoopBackOff", "back-off")
+	if status = observedStatus(explained, notFound, now); status.Reason != "CrashLoopBackOff" {
+		t.Errorf("Reason set by the resource should be kept, got `%s`", status.Reason)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/labels"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// SelectTargets returns keys of resources whose definitions match the selector
+func SelectTargets(c client.Interface, sel labels.Selector) ([]string, error) {
+	resDefList, err := c.ResourceDefinitions().List(api.ListOptions{LabelSelector: sel})
+	if err != nil {
+		return nil, err
+	}
+	resDefs, _, err := ExpandCopies(resDefList.Items, nil)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, r := range resDefs {
+		resource, err := newResourceFromDefinition(r, c)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, resource.Key())
+	}
+	return keys, nil
+}
+
+// Subgraph returns part of the graph with target resources and all resources they transitively
+// depend on. Dependencies on resources outside the subgraph are removed from its resources
+func (depGraph DependencyGraph) Subgraph(targets []string) (DependencyGraph, error) {
+	subgraph := DependencyGraph{}
+	var queue []*ScheduledResource
+	for _, key := range targets {
+		sr, ok := depGraph[key]
+		if !ok {
+			return nil, fmt.Errorf("Target %s is not in the dependency graph", key)
+		}
+		queue = append(queue, sr)
+	}
+
+	for len(queue) > 0 {
+		sr := queue[0]
+		queue = queue[1:]
+		if _, ok := subgraph[sr.Key()]; ok {
+			continue
+		}
+		subgraph[sr.Key()] = sr
+		queue = append(queue, sr.Requires...)
+	}
+
+	for _, sr := range subgraph {
+		var requiredBy []*ScheduledResource
+		for _, child := range sr.RequiredBy {
+			if _, ok := subgraph[child.Key()]; ok {
+				requiredBy = append(requiredBy, child)
+			}
+		}
+		sr.RequiredBy = requiredBy
+	}
+
+	logging.Infof("Deploying %d of %d resources of the graph", len(subgraph), len(depGraph))
+	return subgraph, nil
+}
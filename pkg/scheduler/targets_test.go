@@ -0,0 +1,66 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// TestSubgraph checks that subgraph contains targets with their transitive dependencies only
+func TestSubgraph(t *testing.T) {
+	c := mocks.NewClient()
+	c.Deps = mocks.NewDependencyClient(
+		mocks.Dependency{Parent: "pod/ready-1", Child: "pod/ready-2"},
+		mocks.Dependency{Parent: "pod/ready-2", Child: "pod/ready-3"},
+		mocks.Dependency{Parent: "pod/ready-2", Child: "pod/ready-4"},
+		mocks.Dependency{Parent: "pod/ready-5", Child: "pod/ready-4"},
+	)
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subgraph, err := depGraph.Subgraph([]string{"pod/ready-3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subgraph) != 3 {
+		t.Errorf("Subgraph should have 3 resources, has %d", len(subgraph))
+	}
+	for _, key := range []string{"pod/ready-1", "pod/ready-2", "pod/ready-3"} {
+		if _, ok := subgraph[key]; !ok {
+			t.Errorf("%s should be in the subgraph", key)
+		}
+	}
+	if len(subgraph["pod/ready-2"].RequiredBy) != 1 {
+		t.Errorf("Dependency on resource outside the subgraph should be removed")
+	}
+}
+
+// TestSubgraphUnknownTarget checks that targets must be in the graph
+func TestSubgraphUnknownTarget(t *testing.T) {
+	c := mocks.NewClient()
+	c.Deps = mocks.NewDependencyClient(mocks.Dependency{Parent: "pod/ready-1", Child: "pod/ready-2"})
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = depGraph.Subgraph([]string{"pod/ready-3"}); err == nil {
+		t.Error("Error expected for unknown target")
+	}
+}
@@ -0,0 +1,337 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// Severity of validation problem
+type Severity string
+
+// Possible values for Severity
+const (
+	// SeverityError is used for problems which prevent the graph from being deployed
+	SeverityError Severity = "error"
+	// SeverityWarning is used for suspicious places which are allowed, but are likely mistakes
+	SeverityWarning Severity = "warning"
+)
+
+// Types of validation problems
+const (
+	ProblemInvalidKey          = "invalid-key"
+	ProblemInvalidDefinition   = "invalid-definition"
+	ProblemCycle               = "cycle"
+	ProblemUndefinedResource   = "undefined-resource"
+	ProblemDuplicateDefinition = "duplicate-definition"
+	ProblemDuplicateDependency = "duplicate-dependency"
+	ProblemUnreachable         = "unreachable"
+	ProblemInvalidCopies       = "invalid-copies"
+	ProblemInvalidSecretSource = "invalid-secret-source"
+	ProblemInvalidPatch        = "invalid-patch"
+	ProblemInvalidSpread       = "invalid-spread"
+	ProblemUnknownMetaKey      = "unknown-meta-key"
+	ProblemInvalidMetaValue    = "invalid-meta-value"
+	ProblemDeprecatedKind      = "deprecated-kind"
+)
+
+// ValidationProblem is a problem found in the graph
+type ValidationProblem struct {
+	Type      string   `json:"type"`
+	Severity  Severity `json:"severity"`
+	Message   string   `json:"message"`
+	Resources []string `json:"resources"`
+}
+
+// definitionKey returns key of the resource described by resource definition. Keys of objects outside
+// ac namespace are qualified with their namespace
+func definitionKey(r client.ResourceDefinition) (string, error) {
+	key, err := definitionObjectKey(r)
+	if err != nil {
+		return "", err
+	}
+	return namespacedKey(key, definitionNamespace(r)), nil
+}
+
+// definitionObjectKey returns key of the resource described by resource definition without namespace
+func definitionObjectKey(r client.ResourceDefinition) (string, error) {
+	switch {
+	case r.Pod != nil:
+		return "pod/" + r.Pod.Name, nil
+	case r.Job != nil:
+		return "job/" + r.Job.Name, nil
+	case r.Service != nil:
+		return "service/" + r.Service.Name, nil
+	case r.ReplicaSet != nil:
+		return "replicaset/" + r.ReplicaSet.Name, nil
+	case r.StatefulSet != nil:
+		return "statefulset/" + r.StatefulSet.Name, nil
+	case r.ServiceAccount != nil:
+		return "serviceaccount/" + r.ServiceAccount.Name, nil
+	case r.PetSet != nil:
+		return "petset/" + r.PetSet.Name, nil
+	case r.DaemonSet != nil:
+		return "daemonset/" + r.DaemonSet.Name, nil
+	case r.ConfigMap != nil:
+		return "configmap/" + r.ConfigMap.Name, nil
+	case r.Secret != nil:
+		return "secret/" + r.Secret.Name, nil
+	case r.Deployment != nil:
+		return "deployment/" + r.Deployment.Name, nil
+	case r.PersistentVolumeClaim != nil:
+		return "persistentvolumeclaim/" + r.PersistentVolumeClaim.Name, nil
+	case r.ExternalCheck != nil:
+		return "externalcheck/" + r.ExternalCheck.Name, nil
+	case r.ExecCheck != nil:
+		return "execcheck/" + r.ExecCheck.Name, nil
+	case r.Handler != nil:
+		return "handler/" + r.Handler.Name, nil
+	case r.Checkpoint != nil:
+		return "checkpoint/" + r.Checkpoint.Name, nil
+	case r.ImageCheck != nil:
+		return "imagecheck/" + r.ImageCheck.Name, nil
+	case r.Scale != nil:
+		return "scale/" + r.Scale.Name, nil
+	case r.Patch != nil:
+		return "patch/" + r.Patch.Name, nil
+	case r.NamespaceObject != nil:
+		return "namespace/" + r.NamespaceObject.Name, nil
+	case r.PersistentVolume != nil:
+		return "persistentvolume/" + r.PersistentVolume.Name, nil
+	case r.StorageClass != nil:
+		return "storageclass/" + r.StorageClass.Name, nil
+	case r.ClusterRole != nil:
+		return "clusterrole/" + r.ClusterRole.Name, nil
+	case r.Object != nil:
+		return r.Object.Kind + "/" + r.Object.Name, nil
+	case r.Encrypted != nil:
+		return "", fmt.Errorf("Resource definition %s is encrypted, its key must be given with --encryption-key or --encryption-kms-command", r.Name)
+	}
+	return "", fmt.Errorf("Resource definition %s does not contain supported object", r.Name)
+}
+
+// ValidateGraph checks resource definitions and dependencies for problems. Resources which are referenced
+// by dependencies but are not defined are reported as warnings, as they may be created outside of
+// AppController. Problems are sorted by their type
+func ValidateGraph(resDefs []client.ResourceDefinition, deps []client.Dependency) []ValidationProblem {
+	var problems []ValidationProblem
+	add := func(problemType string, severity Severity, message string, keys ...string) {
+		problems = append(problems, ValidationProblem{
+			Type:      problemType,
+			Severity:  severity,
+			Message:   message,
+			Resources: keys,
+		})
+	}
+
+	problems = append(problems, ValidateMeta(resDefs, deps)...)
+
+	resDefs, deps, err := ExpandCopies(resDefs, deps)
+	if err != nil {
+		add(ProblemInvalidCopies, SeverityError, err.Error())
+		sort.Stable(byProblemType(problems))
+		return problems
+	}
+
+	defined := map[string]bool{}
+	for _, r := range resDefs {
+		key, err := definitionKey(r)
+		if err != nil {
+			add(ProblemInvalidDefinition, SeverityError, err.Error())
+			continue
+		}
+		if defined[key] {
+			add(ProblemDuplicateDefinition, SeverityError, fmt.Sprintf("Resource %s is defined more than once", key), key)
+		}
+		defined[key] = true
+		if r.PetSet != nil {
+			add(ProblemDeprecatedKind, SeverityWarning, fmt.Sprintf("Resource %s is a PetSet, which is supported only with --enable-petsets, migrate it with 'kubeac migrate-petsets'", key), key)
+		}
+		if source, ok := r.Meta[resources.SecretSourceKey]; ok {
+			if r.Secret == nil {
+				add(ProblemInvalidSecretSource, SeverityWarning, fmt.Sprintf("Resource %s is not a secret, %s is ignored", key, resources.SecretSourceKey), key)
+			} else if _, err := resources.NewSecretSource(source, nil); err != nil {
+				add(ProblemInvalidSecretSource, SeverityError, fmt.Sprintf("Resource %s: %v", key, err), key)
+			}
+		}
+		if r.Patch != nil {
+			if _, _, err := resources.PatchData(r.Patch); err != nil {
+				add(ProblemInvalidPatch, SeverityError, fmt.Sprintf("Resource %s: %v", key, err), key)
+			}
+		}
+		if spread, ok := r.Meta[resources.SpreadKey]; ok {
+			if r.ReplicaSet == nil && r.StatefulSet == nil {
+				add(ProblemInvalidSpread, SeverityWarning, fmt.Sprintf("Resource %s is neither a replica set nor a stateful set, %s is ignored", key, resources.SpreadKey), key)
+			} else if _, err := resources.ParseSpread(spread); err != nil {
+				add(ProblemInvalidSpread, SeverityError, fmt.Sprintf("Resource %s: %v", key, err), key)
+			}
+		}
+	}
+
+	edges := map[string][]string{}
+	seen := map[string]bool{}
+	referenced := map[string]bool{}
+	for _, d := range deps {
+		valid := true
+		for _, key := range []string{d.Parent, d.Child} {
+			kind, _, err := keyParts(key)
+			if err != nil {
+				add(ProblemInvalidKey, SeverityError, fmt.Sprintf("Dependency %s: %v", d.Name, err), key)
+				valid = false
+				continue
+			}
+			if _, ok := resources.KindToResourceTemplate[kind]; !ok {
+				add(ProblemInvalidKey, SeverityError, fmt.Sprintf("Dependency %s: unknown resource kind %s", d.Name, kind), key)
+				valid = false
+			}
+		}
+		if !valid {
+			continue
+		}
+
+		edge := d.Parent + " -> " + d.Child
+		if seen[edge] {
+			add(ProblemDuplicateDependency, SeverityError, fmt.Sprintf("Dependency %s is declared more than once", edge), d.Parent, d.Child)
+			continue
+		}
+		seen[edge] = true
+		edges[d.Parent] = append(edges[d.Parent], d.Child)
+		referenced[d.Parent] = true
+		referenced[d.Child] = true
+	}
+
+	for _, key := range sortedKeys(referenced) {
+		kind, _, _ := keyParts(key)
+		if !defined[key] && !resources.NonObjectKinds[kind] {
+			add(ProblemUndefinedResource, SeverityWarning,
+				fmt.Sprintf("Resource %s has no definition, it is expected to exist already", key), key)
+		}
+	}
+
+	cycles := findCycles(edges)
+	inCycle := map[string]bool{}
+	for _, cycle := range cycles {
+		add(ProblemCycle, SeverityError, "Cycle: "+strings.Join(cycle, ", "), cycle...)
+		for _, key := range cycle {
+			inCycle[key] = true
+		}
+	}
+
+	// resources depending on cycles can never be created
+	blocked := map[string]bool{}
+	var visit func(key string)
+	visit = func(key string) {
+		for _, child := range edges[key] {
+			if !blocked[child] && !inCycle[child] {
+				blocked[child] = true
+				visit(child)
+			}
+		}
+	}
+	for key := range inCycle {
+		visit(key)
+	}
+	for _, key := range sortedKeys(blocked) {
+		add(ProblemUnreachable, SeverityError,
+			fmt.Sprintf("Resource %s depends on a cycle, so it can never be created", key), key)
+	}
+
+	sort.Stable(byProblemType(problems))
+	return problems
+}
+
+// findCycles returns cycles of the graph given as adjacency lists. Like DetectCycles it finds strongly
+// connected components (using Tarjan's algorithm), every component of more than one vertex and every
+// vertex depending on itself is a cycle
+func findCycles(edges map[string][]string) [][]string {
+	index := map[string]int{}
+	lowLink := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	var result [][]string
+
+	var connect func(key string)
+	connect = func(key string) {
+		index[key] = len(index)
+		lowLink[key] = index[key]
+		stack = append(stack, key)
+		onStack[key] = true
+
+		selfLoop := false
+		for _, child := range edges[key] {
+			if child == key {
+				selfLoop = true
+			}
+			if _, visited := index[child]; !visited {
+				connect(child)
+				if lowLink[child] < lowLink[key] {
+					lowLink[key] = lowLink[child]
+				}
+			} else if onStack[child] && index[child] < lowLink[key] {
+				lowLink[key] = index[child]
+			}
+		}
+
+		if lowLink[key] != index[key] {
+			return
+		}
+		var component []string
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			component = append(component, last)
+			if last == key {
+				break
+			}
+		}
+		if len(component) > 1 || selfLoop {
+			sort.Strings(component)
+			result = append(result, component)
+		}
+	}
+
+	parents := make([]string, 0, len(edges))
+	for key := range edges {
+		parents = append(parents, key)
+	}
+	sort.Strings(parents)
+	for _, key := range parents {
+		if _, visited := index[key]; !visited {
+			connect(key)
+		}
+	}
+	return result
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type byProblemType []ValidationProblem
+
+func (b byProblemType) Len() int           { return len(b) }
+func (b byProblemType) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byProblemType) Less(i, j int) bool { return b[i].Type < b[j].Type }
@@ -0,0 +1,142 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+func dependency(parent, child string) client.Dependency {
+	return client.Dependency{Parent: parent, Child: child}
+}
+
+// TestValidateGraphValid checks that no problems are found in correct graph
+func TestValidateGraphValid(t *testing.T) {
+	resDefs := []client.ResourceDefinition{{Pod: mocks.MakePod("ready-1")}, {Pod: mocks.MakePod("ready-2")}}
+	deps := []client.Dependency{dependency("pod/ready-1", "pod/ready-2")}
+
+	if problems := ValidateGraph(resDefs, deps); len(problems) != 0 {
+		t.Errorf("No problems expected, got %v", problems)
+	}
+}
+
+// TestValidateGraphProblems checks that problems of every type are found
+func TestValidateGraphProblems(t *testing.T) {
+	resDefs := []client.ResourceDefinition{
+		{Pod: mocks.MakePod("ready-1")},
+		{Pod: mocks.MakePod("ready-1")},
+		{Job: mocks.MakeJob("a")},
+		{Job: mocks.MakeJob("b")},
+		{Job: mocks.MakeJob("c")},
+	}
+	deps := []client.Dependency{
+		dependency("pod/ready-1", "pod/undefined"),
+		dependency("pod/ready-1", "pod/undefined"),
+		dependency("job/a", "job/b"),
+		dependency("job/b", "job/a"),
+		dependency("job/b", "job/c"),
+		dependency("pod", "job/c"),
+		dependency("unknown/x", "job/c"),
+	}
+
+	expected := []ValidationProblem{
+		{ProblemCycle, SeverityError, "Cycle: job/a, job/b", []string{"job/a", "job/b"}},
+		{ProblemDuplicateDefinition, SeverityError, "Resource pod/ready-1 is defined more than once", []string{"pod/ready-1"}},
+		{ProblemDuplicateDependency, SeverityError, "Dependency pod/ready-1 -> pod/undefined is declared more than once", []string{"pod/ready-1", "pod/undefined"}},
+		{ProblemInvalidKey, SeverityError, "Dependency : Not a proper resource key: pod. Expected KIND/NAME", []string{"pod"}},
+		{ProblemInvalidKey, SeverityError, "Dependency : unknown resource kind unknown", []string{"unknown/x"}},
+		{ProblemUndefinedResource, SeverityWarning, "Resource pod/undefined has no definition, it is expected to exist already", []string{"pod/undefined"}},
+		{ProblemUnreachable, SeverityError, "Resource job/c depends on a cycle, so it can never be created", []string{"job/c"}},
+	}
+	problems := ValidateGraph(resDefs, deps)
+	if !reflect.DeepEqual(problems, expected) {
+		t.Errorf("Unexpected problems:\n%v\nexpected:\n%v", problems, expected)
+	}
+}
+
+// TestValidateGraphSelfDependency checks that resource depending on itself is a cycle
+func TestValidateGraphSelfDependency(t *testing.T) {
+	resDefs := []client.ResourceDefinition{{Pod: mocks.MakePod("ready-1")}}
+	deps := []client.Dependency{dependency("pod/ready-1", "pod/ready-1")}
+
+	problems := ValidateGraph(resDefs, deps)
+	if len(problems) != 1 || problems[0].Type != ProblemCycle {
+		t.Errorf("Cycle expected, got %v", problems)
+	}
+}
+
+// TestValidateGraphSecretSource checks that invalid secret sources are reported
+func TestValidateGraphSecretSource(t *testing.T) {
+	resDefs := []client.ResourceDefinition{
+		{Secret: mocks.MakeSecret("valid"), Meta: map[string]interface{}{"secret-source": map[string]interface{}{"vault": "secret/db"}}},
+		{Secret: mocks.MakeSecret("invalid"), Meta: map[string]interface{}{"secret-source": map[string]interface{}{"consul": "db"}}},
+		{Pod: mocks.MakePod("ready-1"), Meta: map[string]interface{}{"secret-source": map[string]interface{}{"vault": "secret/db"}}},
+	}
+
+	problems := ValidateGraph(resDefs, nil)
+	if len(problems) != 2 {
+		t.Fatalf("Expected 2 problems, got %v", problems)
+	}
+	for i, expected := range []struct {
+		severity Severity
+		key      string
+	}{{SeverityError, "secret/invalid"}, {SeverityWarning, "pod/ready-1"}} {
+		if problems[i].Type != ProblemInvalidSecretSource || problems[i].Severity != expected.severity || problems[i].Resources[0] != expected.key {
+			t.Errorf("Expected %s of %s, got %v", expected.severity, expected.key, problems[i])
+		}
+	}
+}
+
+// TestValidateGraphPatch checks that invalid patches are reported as errors
+func TestValidateGraphPatch(t *testing.T) {
+	resDefs := []client.ResourceDefinition{
+		{Patch: &client.Patch{Name: "valid", Target: "service/web", Patch: "spec:\n  selector:\n    color: green"}},
+		{Patch: &client.Patch{Name: "invalid", Target: "service/web", Type: "json", Patch: "spec: {}"}},
+	}
+
+	problems := ValidateGraph(resDefs, nil)
+	if len(problems) != 1 {
+		t.Fatalf("Expected 1 problem, got %v", problems)
+	}
+	if problems[0].Type != ProblemInvalidPatch || problems[0].Resources[0] != "patch/invalid" {
+		t.Errorf("Expected invalid patch of patch/invalid, got %v", problems[0])
+	}
+}
+
+// TestValidateGraphSpread checks that invalid spread requirements are reported
+func TestValidateGraphSpread(t *testing.T) {
+	resDefs := []client.ResourceDefinition{
+		{ReplicaSet: mocks.MakeReplicaSet("valid"), Meta: map[string]interface{}{"spread": map[string]interface{}{"min": 2.0}}},
+		{ReplicaSet: mocks.MakeReplicaSet("invalid"), Meta: map[string]interface{}{"spread": map[string]interface{}{"min": 0.0}}},
+		{Pod: mocks.MakePod("ready-1"), Meta: map[string]interface{}{"spread": map[string]interface{}{"min": 2.0}}},
+	}
+
+	problems := ValidateGraph(resDefs, nil)
+	if len(problems) != 2 {
+		t.Fatalf("Expected 2 problems, got %v", problems)
+	}
+	for i, expected := range []struct {
+		severity Severity
+		key      string
+	}{{SeverityError, "replicaset/invalid"}, {SeverityWarning, "pod/ready-1"}} {
+		if problems[i].Type != ProblemInvalidSpread || problems[i].Severity != expected.severity || problems[i].Resources[0] != expected.key {
+			t.Errorf("Expected %s of %s, got %v", expected.severity, expected.key, problems[i])
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/api/meta"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/watch"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// WatchPollInterval is an interval between status checks of resources whose kind is watched. Their
+// status is also checked whenever the object changes, so polling is only a safety net for missed events
+const WatchPollInterval = time.Second * 30
+
+// watchRetryInterval is a time to wait before restarting failed watch
+const watchRetryInterval = time.Second * 5
+
+// watchRestartInterval is a time to wait before restarting watch closed by the server
+const watchRestartInterval = time.Second
+
+type watchFunc func(client.Interface) (watch.Interface, error)
+
+// watchFuncs start watches of objects of given kind. Objects of other kinds (e.g. services, whose
+// readiness depends on other objects) are polled
+var watchFuncs = map[string]watchFunc{
+	"pod": func(c client.Interface) (watch.Interface, error) {
+		return c.Pods().Watch(v1.ListOptions{})
+	},
+	"job": func(c client.Interface) (watch.Interface, error) {
+		return c.Jobs().Watch(v1.ListOptions{})
+	},
+	"replicaset": func(c client.Interface) (watch.Interface, error) {
+		return c.ReplicaSets().Watch(v1.ListOptions{})
+	},
+	"statefulset": func(c client.Interface) (watch.Interface, error) {
+		return c.StatefulSets().Watch(v1.ListOptions{})
+	},
+	"petset": func(c client.Interface) (watch.Interface, error) {
+		return c.PetSets().Watch(api.ListOptions{})
+	},
+	"daemonset": func(c client.Interface) (watch.Interface, error) {
+		return c.DaemonSets().Watch(v1.ListOptions{})
+	},
+	"deployment": func(c client.Interface) (watch.Interface, error) {
+		return c.Deployments().Watch(v1.ListOptions{})
+	},
+	"persistentvolumeclaim": func(c client.Interface) (watch.Interface, error) {
+		return c.PersistentVolumeClaims().Watch(v1.ListOptions{})
+	},
+	"configmap": func(c client.Interface) (watch.Interface, error) {
+		return c.ConfigMaps().Watch(v1.ListOptions{})
+	},
+	"secret": func(c client.Interface) (watch.Interface, error) {
+		return c.Secrets().Watch(v1.ListOptions{})
+	},
+	"serviceaccount": func(c client.Interface) (watch.Interface, error) {
+		return c.ServiceAccounts().Watch(v1.ListOptions{})
+	},
+}
+
+// Watcher shares one watch per kind between all resources of the graph and notifies subscribed
+// resources when their objects change
+type Watcher struct {
+	client client.Interface
+	funcs  map[string]watchFunc
+	// watched is a set of kinds whose watch is started
+	watched map[string]bool
+	// subscribers are notification channels by resource key
+	subscribers map[string][]chan struct{}
+	stop        chan struct{}
+	// index serves objects selected by services of the graph. It is stopped along with the watches
+	index *resources.SelectorIndex
+	sync.Mutex
+}
+
+// NewWatcher returns watcher of objects accessible by the client
+func NewWatcher(c client.Interface) *Watcher {
+	return &Watcher{
+		client:      c,
+		funcs:       watchFuncs,
+		watched:     map[string]bool{},
+		subscribers: map[string][]chan struct{}{},
+		stop:        make(chan struct{}),
+		index:       resources.NewSelectorIndex(c),
+	}
+}
+
+// Subscribe returns channel receiving a value whenever object with given resource key changes and
+// function cancelling the subscription. Returns false if objects of this kind are not watched
+func (w *Watcher) Subscribe(key string) (<-chan struct{}, func(), bool) {
+	kind, name, err := keyParts(key)
+	// objects outside ac namespace and objects resolved by label selector are polled
+	if err != nil || strings.Contains(name, NamespaceSeparator) || resources.IsSelectorName(name) {
+		return nil, nil, false
+	}
+	watchFunc, ok := w.funcs[kind]
+	if !ok {
+		return nil, nil, false
+	}
+
+	ch := make(chan struct{}, 1)
+	w.Lock()
+	defer w.Unlock()
+	w.subscribers[key] = append(w.subscribers[key], ch)
+	if !w.watched[kind] {
+		w.watched[kind] = true
+		go w.watchKind(kind, watchFunc, w.stop)
+	}
+
+	cancel := func() {
+		w.Lock()
+		defer w.Unlock()
+		subscribers := w.subscribers[key]
+		for i, subscriber := range subscribers {
+			if subscriber == ch {
+				w.subscribers[key] = append(subscribers[:i], subscribers[i+1:]...)
+				break
+			}
+		}
+		if len(w.subscribers[key]) == 0 {
+			delete(w.subscribers, key)
+		}
+	}
+	return ch, cancel, true
+}
+
+// Stop stops all watches. Watches are started again by following subscriptions
+func (w *Watcher) Stop() {
+	w.Lock()
+	defer w.Unlock()
+	close(w.stop)
+	w.stop = make(chan struct{})
+	w.watched = map[string]bool{}
+	w.index.Stop()
+}
+
+// stopWatchers stops watches started for resources of the graph
+func (depGraph DependencyGraph) stopWatchers() {
+	stopped := map[*Watcher]bool{}
+	for _, sr := range depGraph {
+		if sr.watcher != nil && !stopped[sr.watcher] {
+			sr.watcher.Stop()
+			stopped[sr.watcher] = true
+		}
+	}
+}
+
+// notify wakes up subscribers of the resource. Notifications are not queued: if subscriber has
+// pending notification, it will check the status anyway
+func (w *Watcher) notify(key string) {
+	w.Lock()
+	defer w.Unlock()
+	for _, ch := range w.subscribers[key] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// watchKind watches objects of given kind until stop channel is closed. Watch is restarted when
+// the server closes it or fails
+func (w *Watcher) watchKind(kind string, watchFunc watchFunc, stop chan struct{}) {
+	for {
+		retryInterval := watchRestartInterval
+		wi, err := watchFunc(w.client)
+		if err != nil {
+			logging.Errorf("Error watching %s objects, retrying in %v: %v", kind, watchRetryInterval, err)
+			retryInterval = watchRetryInterval
+		} else {
+			logging.Debugf("Watching %s objects", kind)
+			if !w.dispatch(kind, wi, stop) {
+				return
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// dispatch notifies subscribers about events of the watch. Returns false if stop channel was closed and
+// true if the watch was closed
+func (w *Watcher) dispatch(kind string, wi watch.Interface, stop chan struct{}) bool {
+	defer wi.Stop()
+	for {
+		select {
+		case <-stop:
+			return false
+		case event, ok := <-wi.ResultChan():
+			if !ok {
+				return true
+			}
+			if event.Type == watch.Error {
+				continue
+			}
+			accessor, err := meta.Accessor(event.Object)
+			if err != nil {
+				continue
+			}
+			w.notify(kind + "/" + accessor.GetName())
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/client-go/pkg/watch"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// fakeWatcher returns watcher of pods whose events are sent by returned fake watch
+func fakeWatcher() (*Watcher, *watch.FakeWatcher) {
+	fake := watch.NewFake()
+	w := NewWatcher(mocks.NewClient())
+	w.funcs = map[string]watchFunc{
+		"pod": func(client.Interface) (watch.Interface, error) {
+			return fake, nil
+		},
+	}
+	return w, fake
+}
+
+// TestWatcherNotifies checks that subscribers are notified about changes of their objects only
+func TestWatcherNotifies(t *testing.T) {
+	w, fake := fakeWatcher()
+	defer w.Stop()
+
+	changed, cancel, ok := w.Subscribe("pod/ready-1")
+	if !ok {
+		t.Fatal("Pods should be watched")
+	}
+	defer cancel()
+
+	fake.Modify(mocks.MakePod("ready-2"))
+	fake.Modify(mocks.MakePod("ready-1"))
+
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("Subscriber was not notified")
+	}
+	select {
+	case <-changed:
+		t.Error("Subscriber should be notified about its object only")
+	default:
+	}
+}
+
+// TestWatcherUnwatchedKind checks that kinds without watch are not subscribed to
+func TestWatcherUnwatchedKind(t *testing.T) {
+	w, _ := fakeWatcher()
+	defer w.Stop()
+
+	if _, _, ok := w.Subscribe("service/s1"); ok {
+		t.Error("Services should not be watched")
+	}
+	if _, _, ok := w.Subscribe("not-a-key"); ok {
+		t.Error("Invalid key should not be watched")
+	}
+}
+
+// TestWatcherCancel checks that cancelled subscriptions are removed
+func TestWatcherCancel(t *testing.T) {
+	w, _ := fakeWatcher()
+	defer w.Stop()
+
+	_, cancel, _ := w.Subscribe("pod/ready-1")
+	cancel()
+
+	w.Lock()
+	defer w.Unlock()
+	if len(w.subscribers) != 0 {
+		t.Errorf("No subscribers expected, got %v", w.subscribers)
+	}
+}
+
+// TestWaitWithWatcher checks that ready resource is reported without waiting for poll interval
+func TestWaitWithWatcher(t *testing.T) {
+	c := mocks.NewClient(mocks.MakePod("ready-1"))
+	c.ResDefs = mocks.NewResourceDefinitionClient("pod/ready-1")
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sr := depGraph["pod/ready-1"]
+	if sr.watcher == nil {
+		t.Fatal("Watcher should be set")
+	}
+	defer depGraph.stopWatchers()
+
+	if err := sr.Wait(time.Hour, time.Second); err != nil {
+		t.Error(err)
+	}
+}
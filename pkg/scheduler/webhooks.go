@@ -0,0 +1,189 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+// RunEvent is a stage of graph run webhooks are notified about
+type RunEvent string
+
+// Possible values for RunEvent
+const (
+	RunStarted   RunEvent = "started"
+	RunCompleted RunEvent = "completed"
+	RunFailed    RunEvent = "failed"
+)
+
+// Possible formats of webhook payload
+const (
+	WebhookJSON  = "json"
+	WebhookSlack = "slack"
+)
+
+// WebhookTimeout is a time to wait for webhook response
+const WebhookTimeout = time.Second * 10
+
+// FailedNode describes resource which was not created during the run
+type FailedNode struct {
+	Key          string                        `json:"key"`
+	Error        string                        `json:"error,omitempty"`
+	Dependencies []interfaces.DependencyReport `json:"dependencies,omitempty"`
+}
+
+// RunSummary is a payload of generic webhook
+type RunSummary struct {
+	Event   RunEvent     `json:"event"`
+	RunID   string       `json:"run"`
+	Total   int          `json:"total"`
+	Created int          `json:"created"`
+	Skipped int          `json:"skipped"`
+	Failed  []FailedNode `json:"failed,omitempty"`
+}
+
+// Webhook is an HTTP endpoint which receives POST request with run summary when graph run
+// starts, completes or fails
+type Webhook struct {
+	URL string
+	// Format is either WebhookJSON for RunSummary payload or WebhookSlack for Slack incoming webhook message
+	Format string
+}
+
+var (
+	webhooksLock sync.Mutex
+	webhooks     []Webhook
+)
+
+// AddWebhook registers webhook notified about following graph runs
+func AddWebhook(w Webhook) error {
+	if w.Format != WebhookJSON && w.Format != WebhookSlack {
+		return fmt.Errorf("Unknown webhook format %s. Expected one of: %s, %s", w.Format, WebhookJSON, WebhookSlack)
+	}
+	webhooksLock.Lock()
+	defer webhooksLock.Unlock()
+	webhooks = append(webhooks, w)
+	return nil
+}
+
+// summarize returns summary of the graph run. Failed nodes are sorted by keys and include reports
+// of their dependencies
+func (depGraph DependencyGraph) summarize(event RunEvent, runID string) RunSummary {
+	summary := RunSummary{Event: event, RunID: runID, Total: len(depGraph)}
+	for key, sr := range depGraph {
+		sr.RLock()
+		created, skipped, failed, err := sr.created, sr.Skipped, sr.failed, sr.Error
+		sr.RUnlock()
+		switch {
+		case created:
+			summary.Created++
+		case skipped:
+			summary.Skipped++
+		case failed:
+			node := FailedNode{Key: key, Dependencies: sr.GetNodeReport(key).Dependencies}
+			if err != nil {
+				node.Error = err.Error()
+			}
+			summary.Failed = append(summary.Failed, node)
+		}
+	}
+	sort.Sort(failedByKey(summary.Failed))
+	if event != RunStarted && len(summary.Failed) > 0 {
+		summary.Event = RunFailed
+	}
+	return summary
+}
+
+type failedByKey []FailedNode
+
+func (f failedByKey) Len() int           { return len(f) }
+func (f failedByKey) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+func (f failedByKey) Less(i, j int) bool { return f[i].Key < f[j].Key }
+
+// slackMessage returns Slack incoming webhook message describing the summary
+func (s RunSummary) slackMessage() map[string]string {
+	var lines []string
+	switch s.Event {
+	case RunStarted:
+		lines = append(lines, fmt.Sprintf("AppController run %s started, %d resources to create", s.RunID, s.Total))
+	case RunCompleted:
+		lines = append(lines, fmt.Sprintf("AppController run %s completed: %d resources created, %d skipped", s.RunID, s.Created, s.Skipped))
+	case RunFailed:
+		lines = append(lines, fmt.Sprintf("AppController run %s failed: %d of %d resources created, %d skipped, %d failed",
+			s.RunID, s.Created, s.Total, s.Skipped, len(s.Failed)))
+	}
+	for _, node := range s.Failed {
+		lines = append(lines, fmt.Sprintf("*%s*: %s", node.Key, node.Error))
+		for _, dependency := range node.Dependencies {
+			line := fmt.Sprintf("Dependency %s: %d%% of %d%% ready", dependency.Dependency, dependency.Percentage, dependency.Needed)
+			if dependency.Message != "" {
+				line += ", " + dependency.Message
+			}
+			lines = append(lines, strings.Repeat(" ", report.ReportIndentSize)+line)
+		}
+	}
+	return map[string]string{"text": strings.Join(lines, "\n")}
+}
+
+// send posts the summary to the webhook
+func (w Webhook) send(summary RunSummary) error {
+	var payload interface{} = summary
+	if w.Format == WebhookSlack {
+		payload = summary.slackMessage()
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	httpClient := &http.Client{Timeout: WebhookTimeout}
+	resp, err := httpClient.Post(w.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responded with %s", w.URL, resp.Status)
+	}
+	return nil
+}
+
+// notifyWebhooks sends summary of the graph run to all registered webhooks. Failures are logged,
+// but they do not affect the deployment
+func (depGraph DependencyGraph) notifyWebhooks(event RunEvent, runID string) {
+	webhooksLock.Lock()
+	hooks := append([]Webhook(nil), webhooks...)
+	webhooksLock.Unlock()
+	if len(hooks) == 0 {
+		return
+	}
+
+	summary := depGraph.summarize(event, runID)
+	for _, w := range hooks {
+		if err := w.send(summary); err != nil {
+			logging.Errorf("Error notifying webhook %s about %s run: %v", w.URL, summary.Event, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+)
+
+// failedGraph returns graph of two pods where the second one failed
+func failedGraph(t *testing.T) DependencyGraph {
+	c := mocks.NewClient(mocks.MakePod("ready-1"), mocks.MakePod("ready-2"))
+	c.Deps = mocks.NewDependencyClient(mocks.Dependency{Parent: "pod/ready-1", Child: "pod/ready-2"})
+	depGraph, err := BuildDependencyGraph(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	depGraph["pod/ready-1"].created = true
+	depGraph["pod/ready-2"].failed = true
+	depGraph["pod/ready-2"].Error = errors.New("timeout")
+	return depGraph
+}
+
+// TestSummarize checks that failed run is summarized with failed resources and their dependencies
+func TestSummarize(t *testing.T) {
+	summary := failedGraph(t).summarize(RunCompleted, "abc")
+
+	if summary.Event != RunFailed {
+		t.Errorf("Expected run to fail, got %s", summary.Event)
+	}
+	if summary.Total != 2 || summary.Created != 1 || summary.Skipped != 0 {
+		t.Errorf("Unexpected counts in %+v", summary)
+	}
+	if len(summary.Failed) != 1 {
+		t.Fatalf("Expected 1 failed resource, got %v", summary.Failed)
+	}
+	failed := summary.Failed[0]
+	if failed.Key != "pod/ready-2" || failed.Error != "timeout" {
+		t.Errorf("Unexpected failed resource %+v", failed)
+	}
+	if len(failed.Dependencies) != 1 || failed.Dependencies[0].Dependency != "pod/ready-1" {
+		t.Errorf("Expected report of dependency pod/ready-1, got %v", failed.Dependencies)
+	}
+}
+
+// TestNotifyWebhooks checks payloads of generic and Slack webhooks
+func TestNotifyWebhooks(t *testing.T) {
+	bodies := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies[r.URL.Path] = body
+	}))
+	defer server.Close()
+	defer func() { webhooks = nil }()
+
+	if err := AddWebhook(Webhook{URL: server.URL + "/json", Format: WebhookJSON}); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddWebhook(Webhook{URL: server.URL + "/slack", Format: WebhookSlack}); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddWebhook(Webhook{URL: server.URL, Format: "xml"}); err == nil {
+		t.Error("Webhook with unknown format should not be added")
+	}
+
+	failedGraph(t).notifyWebhooks(RunCompleted, "abc")
+
+	var summary RunSummary
+	if err := json.Unmarshal(bodies["/json"], &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Event != RunFailed || summary.RunID != "abc" || len(summary.Failed) != 1 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+
+	var message map[string]string
+	if err := json.Unmarshal(bodies["/slack"], &message); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"run abc failed", "*pod/ready-2*: timeout", "Dependency pod/ready-1"} {
+		if !strings.Contains(message["text"], expected) {
+			t.Errorf("Slack message %q does not contain %q", message["text"], expected)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+)
+
+// WindowKey is a meta key of resource definition with deployment windows of the resource, separated by
+// semicolons. Each window is a cron schedule of its start followed by its duration, e.g. "0 22 * * 1-5 4h"
+// for 22:00-02:00 starting on working days. Creation of the resource is deferred until a window is open
+const WindowKey = "window"
+
+// windowCheckInterval is the longest time between checks of deployment windows of deferred resources,
+// so that aborted runs and deadlines are noticed
+var windowCheckInterval = time.Minute
+
+// windowSearchDays limits how far ahead the next opening of a window is searched for
+const windowSearchDays = 5 * 366
+
+// cronField is a set of allowed values of a field of cron schedule
+type cronField map[int]bool
+
+// cronFieldRanges are minimal and maximal values of minute, hour, day of month, month and day of week
+var cronFieldRanges = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// parseCronField parses comma-separated list of *, values and ranges, each with optional /step
+func parseCronField(field string, min, max int) (cronField, error) {
+	result := cronField{}
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:i]
+		}
+		from, to := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			to = from
+			if len(bounds) == 2 {
+				if to, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("invalid range %q", part)
+				}
+			} else if step > 1 {
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return nil, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+		for v := from; v <= to; v += step {
+			result[v] = true
+		}
+	}
+	return result, nil
+}
+
+// DeploymentWindow is a period of time when resources may be created, which starts according to cron
+// schedule and lasts for duration
+type DeploymentWindow struct {
+	spec                   string
+	minutes, hours         cronField
+	days, months, weekdays cronField
+	// anyDay and anyWeekday are true if day of month and day of week fields are *, as days match
+	// if either of restricted fields matches
+	anyDay, anyWeekday bool
+	duration           time.Duration
+}
+
+// ParseWindow parses deployment window given by 5 fields of cron schedule and duration
+func ParseWindow(spec string) (DeploymentWindow, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 6 {
+		return DeploymentWindow{}, fmt.Errorf("Invalid deployment window %q: expected 5 fields of cron schedule and duration", spec)
+	}
+	w := DeploymentWindow{spec: spec, anyDay: fields[2] == "*", anyWeekday: fields[4] == "*"}
+	parsed := make([]cronField, 5)
+	for i := range parsed {
+		var err error
+		if parsed[i], err = parseCronField(fields[i], cronFieldRanges[i][0], cronFieldRanges[i][1]); err != nil {
+			return DeploymentWindow{}, fmt.Errorf("Invalid deployment window %q: %v", spec, err)
+		}
+	}
+	w.minutes, w.hours, w.days, w.months, w.weekdays = parsed[0], parsed[1], parsed[2], parsed[3], parsed[4]
+	// Sunday is either 0 or 7
+	if w.weekdays[7] {
+		w.weekdays[0] = true
+	}
+	duration, err := time.ParseDuration(fields[5])
+	if err != nil || duration <= 0 {
+		return DeploymentWindow{}, fmt.Errorf("Invalid duration of deployment window %q", spec)
+	}
+	w.duration = duration
+	if w.nextStart(time.Now()).IsZero() {
+		return DeploymentWindow{}, fmt.Errorf("Deployment window %q never opens", spec)
+	}
+	return w, nil
+}
+
+func (w DeploymentWindow) String() string {
+	return w.spec
+}
+
+// dayMatches returns true if the window may start on the day
+func (w DeploymentWindow) dayMatches(t time.Time) bool {
+	if !w.months[int(t.Month())] {
+		return false
+	}
+	day, weekday := w.days[t.Day()], w.weekdays[int(t.Weekday())]
+	switch {
+	case w.anyDay && w.anyWeekday:
+		return true
+	case w.anyDay:
+		return weekday
+	case w.anyWeekday:
+		return day
+	}
+	return day || weekday
+}
+
+// nextStart returns the first start of the window at or after t, or zero time if it does not start
+// within windowSearchDays
+func (w DeploymentWindow) nextStart(t time.Time) time.Time {
+	if t.Truncate(time.Minute) != t {
+		t = t.Truncate(time.Minute).Add(time.Minute)
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	for i := 0; i < windowSearchDays; i, day = i+1, day.AddDate(0, 0, 1) {
+		if !w.dayMatches(day) {
+			continue
+		}
+		for hour := 0; hour < 24; hour++ {
+			if !w.hours[hour] {
+				continue
+			}
+			for minute := 0; minute < 60; minute++ {
+				start := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+				if w.minutes[minute] && !start.Before(t) {
+					return start
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// Open returns true if the window is open at t
+func (w DeploymentWindow) Open(t time.Time) bool {
+	start := w.nextStart(t.Add(-w.duration).Add(time.Nanosecond))
+	return !start.IsZero() && !start.After(t)
+}
+
+// DeploymentWindows are windows of which any one allows resources to be created
+type DeploymentWindows []DeploymentWindow
+
+// ParseWindows parses deployment windows separated by semicolons
+func ParseWindows(spec string) (DeploymentWindows, error) {
+	var windows DeploymentWindows
+	for _, part := range strings.Split(spec, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		w, err := ParseWindow(part)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// Open returns true if there are no windows or any of them is open at t
+func (ws DeploymentWindows) Open(t time.Time) bool {
+	for _, w := range ws {
+		if w.Open(t) {
+			return true
+		}
+	}
+	return len(ws) == 0
+}
+
+// NextOpen returns the earliest time after t when any of the windows opens
+func (ws DeploymentWindows) NextOpen(t time.Time) time.Time {
+	var result time.Time
+	for _, w := range ws {
+		if start := w.nextStart(t); !start.IsZero() && (result.IsZero() || start.Before(result)) {
+			result = start
+		}
+	}
+	return result
+}
+
+// WithWindows sets deployment windows of graph resources which do not set their own windows in meta
+func (depGraph DependencyGraph) WithWindows(windows DeploymentWindows) {
+	for _, sr := range depGraph {
+		if _, ok := sr.windowsMeta(); !ok {
+			sr.windows = windows
+		}
+	}
+}
+
+// windowsMeta returns value of WindowKey meta of the resource and whether it is set
+func (sr *ScheduledResource) windowsMeta() (string, bool) {
+	value := resources.GetStringMeta(sr.Resource, WindowKey, "")
+	return value, value != ""
+}
+
+// withWindows parses deployment windows set in meta of graph resources
+func (depGraph DependencyGraph) withWindows() error {
+	for key, sr := range depGraph {
+		spec, ok := sr.windowsMeta()
+		if !ok {
+			continue
+		}
+		windows, err := ParseWindows(spec)
+		if err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+		sr.windows = windows
+	}
+	return nil
+}
+
+// deferredUntil returns time when deployment window of deferred resource opens, zero time otherwise
+func (sr *ScheduledResource) deferredUntil() time.Time {
+	sr.RLock()
+	defer sr.RUnlock()
+	return sr.windowOpens
+}
+
+// waitForWindow defers creation of the resource until any of its deployment windows is open. Semaphore
+// is released while waiting, so that other branches of the graph are created in the meantime. Objects
+// which exist already are not deferred, as they are not created by AppController
+func (sr *ScheduledResource) waitForWindow(ccLimiter chan struct{}) error {
+	if sr.Existing || sr.windows.Open(time.Now()) {
+		return nil
+	}
+	<-ccLimiter
+	defer func() {
+		ccLimiter <- struct{}{}
+	}()
+
+	for {
+		now := time.Now()
+		if sr.windows.Open(now) {
+			sr.setWindowOpens(time.Time{})
+			sr.logger("window").Infof("Deployment window of %s is open", sr.Key())
+			return nil
+		}
+		opens := sr.windows.NextOpen(now)
+		if opens.IsZero() {
+			sr.setWindowOpens(time.Time{})
+			return fmt.Errorf("no deployment window of %s opens", sr.Key())
+		}
+		if sr.deferredUntil() != opens {
+			sr.logger("window").Infof("Creation of %s is deferred until its deployment window opens at %s", sr.Key(), opens.Format(time.RFC3339))
+			sr.setWindowOpens(opens)
+		}
+		if err := sr.run.aborted(); err != nil {
+			sr.setWindowOpens(time.Time{})
+			return err
+		}
+		if err := sr.deadlineExceeded(); err != nil {
+			sr.setWindowOpens(time.Time{})
+			return err
+		}
+		wait := opens.Sub(now)
+		if wait > windowCheckInterval {
+			wait = windowCheckInterval
+		}
+		time.Sleep(wait)
+	}
+}
+
+func (sr *ScheduledResource) setWindowOpens(opens time.Time) {
+	sr.Lock()
+	defer sr.Unlock()
+	sr.windowOpens = opens
+}
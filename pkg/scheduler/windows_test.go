@@ -0,0 +1,116 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+)
+
+// TestDeploymentWindowOpen checks that windows are open from their start for their duration, also across days
+func TestDeploymentWindowOpen(t *testing.T) {
+	windows, err := ParseWindows("0 22 * * 1-5 4h; 30 12 1 * * 30m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2017, time.March, day, hour, minute, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		t    time.Time
+		open bool
+	}{
+		// 2017-03-06 is Monday
+		{at(6, 21, 59), false},
+		{at(6, 22, 0), true},
+		{at(7, 1, 59), true},
+		{at(7, 2, 0), false},
+		// window started on Friday lasts until Saturday morning
+		{at(11, 1, 0), true},
+		{at(11, 22, 0), false},
+		{at(1, 12, 45), true},
+	}
+	for _, tc := range cases {
+		if open := windows.Open(tc.t); open != tc.open {
+			t.Errorf("Expected windows open at %v to be %t", tc.t, tc.open)
+		}
+	}
+	if next := windows.NextOpen(at(11, 3, 0)); next != at(13, 22, 0) {
+		t.Errorf("Expected windows to open on Monday, got %v", next)
+	}
+}
+
+// TestParseWindowErrors checks that invalid windows and windows which never open are rejected
+func TestParseWindowErrors(t *testing.T) {
+	for _, spec := range []string{"0 22 * * 4h", "61 * * * * 1h", "0 22 * * * -1h", "0 22 * * 1-9 1h", "*/0 * * * * 1h", "0 0 30 2 * 1h"} {
+		if _, err := ParseWindow(spec); err == nil {
+			t.Errorf("Window %q should be invalid", spec)
+		}
+	}
+	if windows, err := ParseWindows(""); err != nil || !windows.Open(time.Now()) {
+		t.Errorf("No windows should always be open, got %v, %v", windows, err)
+	}
+}
+
+// TestDeferredResource checks that resource outside of its deployment window is reported as deferred while
+// other branches are created, and fails once its deadline passes
+func TestDeferredResource(t *testing.T) {
+	defer func(interval time.Duration) { windowCheckInterval = interval }(windowCheckInterval)
+	windowCheckInterval = 10 * time.Millisecond
+
+	opens := time.Now().Add(30 * time.Minute)
+	windows, err := ParseWindows(fmt.Sprintf("%d %d * * * 1m", opens.Minute(), opens.Hour()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deferred := NewScheduledResourceFor(report.SimpleReporter{BaseResource: mocks.NewResource("pod/deferred", interfaces.ResourceReady)})
+	deferred.windows = windows
+	deferred.runTimeout = time.Second
+	depGraph := DependencyGraph{
+		"pod/deferred": deferred,
+		"pod/ready":    NewScheduledResourceFor(report.SimpleReporter{BaseResource: mocks.NewResource("pod/ready", interfaces.ResourceReady)}),
+	}
+
+	done := make(chan struct{})
+	go func() {
+		Create(depGraph, 1)
+		close(done)
+	}()
+	var states map[string]string
+	for i := 0; i < 50; i++ {
+		states = map[string]string{}
+		for _, node := range depGraph.NodeResults() {
+			states[node.Key] = node.State
+		}
+		if states["pod/deferred"] == report.NodeDeferred {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if states["pod/deferred"] != report.NodeDeferred {
+		t.Errorf("Expected resource to be deferred, got states %v", states)
+	}
+	<-done
+
+	runReport := depGraph.RunReport()
+	if runReport.Created != 1 || runReport.Failed != 1 || runReport.Outcome != report.OutcomeTimeout {
+		t.Errorf("Expected ready resource to be created with concurrency 1 and deferred one to time out, got %+v", runReport)
+	}
+}
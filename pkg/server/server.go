@@ -0,0 +1,449 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package server implements HTTP API of AppController. It exposes state of the graph, allows to
+// trigger and pause deployment, approve checkpoints and streams progress of graph runs as server-sent
+// events
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/labels"
+
+	"github.com/Mirantis/k8s-AppController/pkg/client"
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+	"github.com/Mirantis/k8s-AppController/pkg/resources"
+	"github.com/Mirantis/k8s-AppController/pkg/scheduler"
+)
+
+// APIPrefix is a path prefix of all API endpoints
+const APIPrefix = "/api/v1"
+
+// Status is a state of AppController returned by status endpoint
+type Status struct {
+	Running bool `json:"running"`
+	Paused  bool `json:"paused"`
+	// Status is a human-readable status of the deployment
+	Status string `json:"status"`
+}
+
+// Server serves HTTP API for the graph of resources matching the selector
+type Server struct {
+	client   client.Interface
+	selector labels.Selector
+	// run deploys the graph once
+	run func() error
+	// token must be given by requests to endpoints changing state of AppController, if it is set
+	token string
+
+	// triggered is true while the run triggered through the API is in progress, including the time
+	// before its graph is built
+	triggered bool
+	sync.Mutex
+}
+
+// New returns server for the graph of resources matching the selector. run is called to deploy
+// the graph when the run is triggered through the API
+func New(c client.Interface, sel labels.Selector, run func() error) *Server {
+	return &Server{client: c, selector: sel, run: run}
+}
+
+// WithToken makes endpoints which trigger, cancel or pause runs and approve checkpoints require the token
+// in Authorization header as bearer token. Without token such requests are only accepted from loopback
+// addresses
+func (s *Server) WithToken(token string) *Server {
+	s.token = token
+	return s
+}
+
+// graph returns graph of the current or last run, or the graph built from the cluster if there were no runs
+func (s *Server) graph() (scheduler.DependencyGraph, error) {
+	if depGraph, _ := scheduler.LastRun(); depGraph != nil {
+		return depGraph, nil
+	}
+	return scheduler.BuildDependencyGraph(s.client, s.selector)
+}
+
+// Trigger starts graph run in background. Returns false if the graph is being deployed already
+func (s *Server) Trigger() bool {
+	s.Lock()
+	defer s.Unlock()
+	if _, running := scheduler.LastRun(); running || s.triggered {
+		return false
+	}
+	s.triggered = true
+	go func() {
+		defer func() {
+			s.Lock()
+			s.triggered = false
+			s.Unlock()
+		}()
+		if err := s.run(); err != nil {
+			logging.Errorf("Graph run triggered through API failed: %v", err)
+		}
+	}()
+	return true
+}
+
+// Handler returns HTTP handler serving the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(APIPrefix+"/status", s.handleStatus)
+	mux.HandleFunc(APIPrefix+"/nodes", s.handleNodes)
+	mux.HandleFunc(APIPrefix+"/nodes/", s.handleNode)
+	mux.HandleFunc(APIPrefix+"/reports", s.handleReports)
+	mux.HandleFunc(APIPrefix+"/progress", s.handleProgress)
+	mux.HandleFunc(APIPrefix+"/runs", s.handleRuns)
+	mux.HandleFunc(APIPrefix+"/runs/", s.handleRunRecord)
+	mux.HandleFunc(APIPrefix+"/run", s.authorized(s.handleRun))
+	mux.HandleFunc(APIPrefix+"/cancel", s.authorized(s.handleCancel))
+	mux.HandleFunc(APIPrefix+"/pause", s.authorized(s.handlePause))
+	mux.HandleFunc(APIPrefix+"/resume", s.authorized(s.handleResume))
+	mux.HandleFunc(APIPrefix+"/events", s.handleEvents)
+	mux.HandleFunc(APIPrefix+"/checkpoints", s.handleCheckpoints)
+	mux.HandleFunc(APIPrefix+"/checkpoints/", s.authorized(s.handleCheckpoint))
+	return mux
+}
+
+// Serve starts HTTP server exposing the API on given address in background
+func (s *Server) Serve(address string) {
+	go func() {
+		logging.Infof("Serving API on %s%s", address, APIPrefix)
+		if err := http.ListenAndServe(address, s.Handler()); err != nil {
+			logging.Errorf("API server failed: %v", err)
+		}
+	}()
+}
+
+// authorized returns handler serving requests with the token of the server, or requests from loopback
+// addresses if the server has no token
+func (s *Server) authorized(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.token == "" {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if ip := net.ParseIP(host); err != nil || ip == nil || !ip.IsLoopback() {
+				writeError(w, http.StatusForbidden, fmt.Errorf("API token is not set, only local requests are allowed"))
+				return
+			}
+		} else {
+			header := r.Header.Get("Authorization")
+			token := strings.TrimPrefix(header, "Bearer ")
+			if token == header || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid or missing bearer token"))
+				return
+			}
+		}
+		handler(w, r)
+	}
+}
+
+// writeJSON writes value as JSON response
+func writeJSON(w http.ResponseWriter, code int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		logging.Warningf("Error writing API response: %v", err)
+	}
+}
+
+// writeError writes error as JSON response
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+// allowMethod writes error response and returns false if request method is not the expected one
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+	return false
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+	depGraph, err := s.graph()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	_, running := scheduler.LastRun()
+	status, _ := depGraph.GetStatus()
+	writeJSON(w, http.StatusOK, Status{
+		Running: running,
+		Paused:  scheduler.IsPaused(),
+		Status:  status.String(),
+	})
+}
+
+func (s *Server) handleNodes(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+	depGraph, err := s.graph()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, depGraph.Progress(nil))
+}
+
+// handleNode serves progress of a single node on nodes/<key> and its dependency reports on
+// nodes/<key>/report. Keys contain slashes, e.g. nodes/pod/db/report
+func (s *Server) handleNode(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, APIPrefix+"/nodes/")
+	report := false
+	blocking := false
+	if strings.Count(key, "/") > 1 && strings.HasSuffix(key, "/report") {
+		key = strings.TrimSuffix(key, "/report")
+		report = true
+	} else if strings.Count(key, "/") > 1 && strings.HasSuffix(key, "/blocking") {
+		key = strings.TrimSuffix(key, "/blocking")
+		blocking = true
+	}
+
+	depGraph, err := s.graph()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	sr, ok := depGraph[key]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("resource %s is not in the graph", key))
+		return
+	}
+	if report {
+		writeJSON(w, http.StatusOK, sr.GetNodeReport(key))
+		return
+	}
+	if blocking {
+		_, deploymentReport := depGraph.GetStatus()
+		analysis, err := deploymentReport.AnalyzeBlocking(key)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, analysis)
+		return
+	}
+	for _, progress := range depGraph.Progress(nil) {
+		if progress.Key == key {
+			writeJSON(w, http.StatusOK, progress)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("progress of resource %s is not known", key))
+}
+
+func (s *Server) handleReports(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+	depGraph, err := s.graph()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	_, report := depGraph.GetStatus()
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleProgress serves overall progress of the graph with estimated time remaining
+func (s *Server) handleProgress(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+	depGraph, err := s.graph()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	progress, err := depGraph.EstimateProgress(nil, time.Now())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, progress)
+}
+
+// handleRuns lists recorded runs of all graphs, the most recent first
+func (s *Server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+	records, err := scheduler.NewConfigMapRunRecordStore(s.client.ConfigMaps(), "", 0).List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+// handleRunRecord serves record of a single run on runs/<id>
+func (s *Server) handleRunRecord(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, APIPrefix+"/runs/")
+	record, err := scheduler.NewConfigMapRunRecordStore(s.client.ConfigMaps(), "", 0).Get(id)
+	if errors.IsNotFound(err) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("run %s is not recorded", id))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, record)
+}
+
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "POST") {
+		return
+	}
+	if !s.Trigger() {
+		writeError(w, http.StatusConflict, fmt.Errorf("graph is being deployed already"))
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]bool{"started": true})
+}
+
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "POST") {
+		return
+	}
+	graph, running := scheduler.LastRun()
+	if !running {
+		writeError(w, http.StatusConflict, fmt.Errorf("graph is not being deployed"))
+		return
+	}
+	graph.Cancel()
+	writeJSON(w, http.StatusOK, map[string]bool{"cancelled": true})
+}
+
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "POST") {
+		return
+	}
+	scheduler.Pause()
+	writeJSON(w, http.StatusOK, map[string]bool{"paused": true})
+}
+
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "POST") {
+		return
+	}
+	scheduler.Resume()
+	writeJSON(w, http.StatusOK, map[string]bool{"paused": false})
+}
+
+// handleEvents streams progress events as server-sent events until the client disconnects
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+	var closed <-chan bool
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		closed = notifier.CloseNotify()
+	}
+
+	events, cancel := scheduler.SubscribeProgress()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				logging.Warningf("Error encoding progress event: %v", err)
+				continue
+			}
+			name := "node"
+			if event.Key == "" {
+				name = "run"
+			}
+			if _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// handleCheckpoints lists names of checkpoints waiting for approval
+func (s *Server) handleCheckpoints(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+	names, err := resources.PendingCheckpoints(s.client.ConfigMaps())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, names)
+}
+
+// checkpointActions are approvals set by checkpoints/<name>/<action> endpoints
+var checkpointActions = map[string]string{
+	"approve": resources.CheckpointApproved,
+	"reject":  resources.CheckpointRejected,
+}
+
+// handleCheckpoint approves or rejects checkpoint on checkpoints/<name>/approve and
+// checkpoints/<name>/reject
+func (s *Server) handleCheckpoint(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "POST") {
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, APIPrefix+"/checkpoints/"), "/")
+	if len(parts) != 2 || parts[0] == "" || checkpointActions[parts[1]] == "" {
+		writeError(w, http.StatusNotFound, fmt.Errorf("expected checkpoints/<name>/approve or checkpoints/<name>/reject"))
+		return
+	}
+	name, approval := parts[0], checkpointActions[parts[1]]
+	if err := resources.SetCheckpointApproval(s.client.ConfigMaps(), name, approval); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	logging.Infof("Checkpoint %s was %s through API", name, approval)
+	writeJSON(w, http.StatusOK, map[string]string{"checkpoint": name, "approval": approval})
+}
@@ -0,0 +1,317 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/interfaces"
+	"github.com/Mirantis/k8s-AppController/pkg/mocks"
+	"github.com/Mirantis/k8s-AppController/pkg/report"
+	"github.com/Mirantis/k8s-AppController/pkg/scheduler"
+)
+
+// newTestServer returns API server of graph of two pods where the second one depends on the first.
+// Both pods exist, so the graph is ready. Runs triggered through the API are sent to returned channel
+// once they finish
+func newTestServer(t *testing.T) (*httptest.Server, chan struct{}) {
+	c := mocks.NewClient(mocks.MakePod("ready-1"), mocks.MakePod("ready-2"))
+	c.ResDefs = mocks.NewResourceDefinitionClient("pod/ready-1", "pod/ready-2")
+	c.Deps = mocks.NewDependencyClient(mocks.Dependency{Parent: "pod/ready-1", Child: "pod/ready-2"})
+
+	runs := make(chan struct{}, 1)
+	s := New(c, nil, func() error {
+		depGraph, err := scheduler.BuildDependencyGraph(c, nil)
+		if err != nil {
+			return err
+		}
+		scheduler.Create(depGraph, 0)
+		runs <- struct{}{}
+		return nil
+	})
+	return httptest.NewServer(s.Handler()), runs
+}
+
+// getJSON decodes JSON response of GET request to the path
+func getJSON(t *testing.T, server *httptest.Server, path string, expectedCode int, result interface{}) {
+	resp, err := http.Get(server.URL + APIPrefix + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != expectedCode {
+		t.Fatalf("Expected status %d of %s, got %d", expectedCode, path, resp.StatusCode)
+	}
+	if result != nil {
+		if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// post sends POST request to the path and returns status code of the response
+func post(t *testing.T, server *httptest.Server, path string) int {
+	resp, err := http.Post(server.URL+APIPrefix+path, "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// TestNodes checks that nodes are listed with their statuses and reports
+func TestNodes(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	var nodes []scheduler.NodeProgress
+	getJSON(t, server, "/nodes", http.StatusOK, &nodes)
+	if len(nodes) != 2 || nodes[0].Key != "pod/ready-1" || nodes[1].Key != "pod/ready-2" {
+		t.Fatalf("Expected both pods to be listed, got %v", nodes)
+	}
+	if nodes[1].Status.Phase != interfaces.ResourceReady {
+		t.Errorf("Expected pod/ready-2 to be ready, got %s", nodes[1].Status)
+	}
+
+	var node scheduler.NodeProgress
+	getJSON(t, server, "/nodes/pod/ready-2", http.StatusOK, &node)
+	if node.Key != "pod/ready-2" {
+		t.Errorf("Expected progress of pod/ready-2, got %v", node)
+	}
+
+	var nodeReport report.NodeReport
+	getJSON(t, server, "/nodes/pod/ready-2/report", http.StatusOK, &nodeReport)
+	if len(nodeReport.Dependencies) != 1 || nodeReport.Dependencies[0].Dependency != "pod/ready-1" {
+		t.Errorf("Expected report of dependency pod/ready-1, got %v", nodeReport)
+	}
+
+	var analysis report.BlockingAnalysis
+	getJSON(t, server, "/nodes/pod/ready-2/blocking", http.StatusOK, &analysis)
+	if analysis.Key != "pod/ready-2" || !analysis.Ready || len(analysis.Causes) != 0 {
+		t.Errorf("Expected ready pod/ready-2 not to be blocked, got %v", analysis)
+	}
+
+	var deploymentReport report.DeploymentReport
+	getJSON(t, server, "/reports", http.StatusOK, &deploymentReport)
+	if len(deploymentReport) != 2 {
+		t.Errorf("Expected reports of both pods, got %v", deploymentReport)
+	}
+
+	var progress report.GraphProgress
+	getJSON(t, server, "/progress", http.StatusOK, &progress)
+	if progress.Total != 2 || len(progress.Branches) != 1 {
+		t.Errorf("Expected progress of both pods in one branch, got %+v", progress)
+	}
+
+	getJSON(t, server, "/nodes/pod/missing", http.StatusNotFound, nil)
+}
+
+// TestRunAndPause checks that the run can be triggered, paused and resumed, and that its progress
+// is streamed to event subscribers
+func TestRunAndPause(t *testing.T) {
+	server, runs := newTestServer(t)
+	defer server.Close()
+
+	if code := post(t, server, "/pause"); code != http.StatusOK {
+		t.Fatalf("Expected pause to succeed, got %d", code)
+	}
+	defer scheduler.Resume()
+
+	resp, err := http.Get(server.URL + APIPrefix + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Expected event stream, got %s", contentType)
+	}
+
+	if code := post(t, server, "/run"); code != http.StatusAccepted {
+		t.Fatalf("Expected run to be started, got %d", code)
+	}
+	if code := post(t, server, "/run"); code != http.StatusConflict {
+		t.Errorf("Expected second run to be rejected, got %d", code)
+	}
+
+	var status Status
+	getJSON(t, server, "/status", http.StatusOK, &status)
+	if !status.Paused {
+		t.Error("Expected deployment to be paused")
+	}
+
+	if code := post(t, server, "/resume"); code != http.StatusOK {
+		t.Fatalf("Expected resume to succeed, got %d", code)
+	}
+	select {
+	case <-runs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run was not finished after resume")
+	}
+
+	getJSON(t, server, "/status", http.StatusOK, &status)
+	if status.Running || status.Paused {
+		t.Errorf("Expected finished run, got %+v", status)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "event: run\n" {
+		t.Errorf("Expected run event first, got %q", line)
+	}
+	line, err = reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event scheduler.ProgressEvent
+	if err = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Event != scheduler.RunStarted {
+		t.Errorf("Expected start of the run, got %+v", event)
+	}
+}
+
+// TestCancel checks that the run in progress can be cancelled
+func TestCancel(t *testing.T) {
+	server, runs := newTestServer(t)
+	defer server.Close()
+
+	if code := post(t, server, "/cancel"); code != http.StatusConflict {
+		t.Errorf("Expected cancel without run to be rejected, got %d", code)
+	}
+
+	if code := post(t, server, "/pause"); code != http.StatusOK {
+		t.Fatalf("Expected pause to succeed, got %d", code)
+	}
+	defer scheduler.Resume()
+	if code := post(t, server, "/run"); code != http.StatusAccepted {
+		t.Fatalf("Expected run to be started, got %d", code)
+	}
+	// the run is started in background
+	var status Status
+	for i := 0; !status.Running; i++ {
+		if i == 100 {
+			t.Fatal("Run was not started")
+		}
+		time.Sleep(10 * time.Millisecond)
+		getJSON(t, server, "/status", http.StatusOK, &status)
+	}
+	if code := post(t, server, "/cancel"); code != http.StatusOK {
+		t.Fatalf("Expected cancel to succeed, got %d", code)
+	}
+	scheduler.Resume()
+	select {
+	case <-runs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Cancelled run was not finished")
+	}
+
+	graph, _ := scheduler.LastRun()
+	if err := graph.Aborted(); err != scheduler.ErrRunCancelled {
+		t.Errorf("Expected run to be cancelled, got %v", err)
+	}
+}
+
+// TestMethodNotAllowed checks that endpoints reject unexpected methods
+func TestMethodNotAllowed(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	getJSON(t, server, "/run", http.StatusMethodNotAllowed, nil)
+	if code := post(t, server, "/nodes"); code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected POST of nodes to be rejected, got %d", code)
+	}
+}
+
+// TestCheckpoints checks that checkpoints waiting for approval are listed and can be approved
+func TestCheckpoints(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	if code := post(t, server, "/checkpoints/canary/approve"); code != http.StatusOK {
+		t.Fatalf("Expected status 200 of approval, got %d", code)
+	}
+	var pending []string
+	getJSON(t, server, "/checkpoints", http.StatusOK, &pending)
+	if len(pending) != 0 {
+		t.Errorf("Expected no pending checkpoints, got %v", pending)
+	}
+
+	if code := post(t, server, "/checkpoints/canary/unknown"); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 of unknown action, got %d", code)
+	}
+}
+
+// TestAuthorization checks that endpoints changing state require the token, or local requests if the
+// server has no token, while other endpoints are open
+func TestAuthorization(t *testing.T) {
+	s := New(mocks.NewClient(), nil, func() error { return nil }).WithToken("secret")
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	for _, token := range []string{"", "wrong"} {
+		req, err := http.NewRequest("POST", server.URL+APIPrefix+"/pause", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected request with token %q to be unauthorized, got %d", token, resp.StatusCode)
+		}
+	}
+	if scheduler.IsPaused() {
+		t.Fatal("Unauthorized request should not pause deployment")
+	}
+
+	defer scheduler.Resume()
+	req, err := http.NewRequest("POST", server.URL+APIPrefix+"/pause", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !scheduler.IsPaused() {
+		t.Errorf("Expected request with the token to pause deployment, got %d", resp.StatusCode)
+	}
+	getJSON(t, server, "/checkpoints", http.StatusOK, nil)
+
+	remote := httptest.NewRequest("POST", APIPrefix+"/run", nil)
+	remote.RemoteAddr = "10.0.0.1:40000"
+	rec := httptest.NewRecorder()
+	New(mocks.NewClient(), nil, func() error { return nil }).Handler().ServeHTTP(rec, remote)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected remote request to server without token to be forbidden, got %d", rec.Code)
+	}
+}
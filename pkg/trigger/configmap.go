@@ -0,0 +1,90 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// TriggerAnnotation marks config maps whose changes trigger the graph, if its value is "true"
+const TriggerAnnotation = "appcontroller.k8s/trigger"
+
+// DefaultPollInterval is a default interval between checks of annotated config maps
+const DefaultPollInterval = time.Second * 30
+
+// ConfigMaps triggers the graph when config maps with TriggerAnnotation are created, changed or deleted
+type ConfigMaps struct {
+	// Client is a client of config maps in namespace of AppController
+	Client corev1.ConfigMapInterface
+	// Interval is an interval between checks of config maps
+	Interval time.Duration
+}
+
+// version returns string which changes whenever annotated config maps change
+func (c ConfigMaps) version() (string, error) {
+	list, err := c.Client.List(v1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	var versions []string
+	for _, configMap := range list.Items {
+		if configMap.Annotations[TriggerAnnotation] == "true" {
+			versions = append(versions, fmt.Sprintf("%s@%s", configMap.Name, configMap.ResourceVersion))
+		}
+	}
+	sort.Strings(versions)
+	return strings.Join(versions, ","), nil
+}
+
+// Start checks config maps every interval and fires the trigger when they change since the previous
+// check. Config maps existing when the trigger starts do not fire it
+func (c ConfigMaps) Start(fire chan<- string, stop <-chan struct{}) {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	last, err := c.version()
+	if err != nil {
+		logging.Errorf("Error checking config maps of triggers: %v", err)
+	}
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+		version, err := c.version()
+		if err != nil {
+			logging.Errorf("Error checking config maps of triggers: %v", err)
+			continue
+		}
+		if version != last {
+			last = version
+			send(fire, "change of annotated config maps", stop)
+		}
+	}
+}
+
+func (c ConfigMaps) String() string {
+	return fmt.Sprintf("changes of config maps annotated with %s=true", TriggerAnnotation)
+}
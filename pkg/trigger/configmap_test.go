@@ -0,0 +1,59 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// TestConfigMapsVersion checks that only annotated config maps are tracked
+func TestConfigMapsVersion(t *testing.T) {
+	annotated := &v1.ConfigMap{ObjectMeta: v1.ObjectMeta{
+		Name:            "release",
+		Namespace:       "testing",
+		ResourceVersion: "1",
+		Annotations:     map[string]string{TriggerAnnotation: "true"},
+	}}
+	plain := &v1.ConfigMap{ObjectMeta: v1.ObjectMeta{Name: "plain", Namespace: "testing", ResourceVersion: "1"}}
+	configMaps := fake.NewSimpleClientset(annotated, plain).Core().ConfigMaps("testing")
+	trigger := ConfigMaps{Client: configMaps}
+
+	before, err := trigger.version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before != "release@1" {
+		t.Errorf("Expected version of annotated config map, got %q", before)
+	}
+
+	plain.ResourceVersion = "2"
+	if _, err = configMaps.Update(plain); err != nil {
+		t.Fatal(err)
+	}
+	if version, _ := trigger.version(); version != before {
+		t.Errorf("Change of config map without annotation should not change version, got %q", version)
+	}
+
+	annotated.ResourceVersion = "2"
+	if _, err = configMaps.Update(annotated); err != nil {
+		t.Fatal(err)
+	}
+	if version, _ := trigger.version(); version == before {
+		t.Error("Change of annotated config map should change version")
+	}
+}
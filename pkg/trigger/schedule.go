@@ -0,0 +1,187 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scheduleDescriptors are shortcuts for common schedules
+var scheduleDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// scheduleField describes range of a cron field
+type scheduleField struct {
+	name     string
+	min, max int
+}
+
+var scheduleFields = []scheduleField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Schedule is a cron schedule of graph runs. It triggers the graph at every minute matching the schedule
+type Schedule struct {
+	spec string
+	// minutes, hours, days, months and weekdays are bit sets of matching values
+	minutes, hours, days, months, weekdays uint64
+	// anyDay is true if day of month or day of week is *. Otherwise the day matches if any of them matches
+	anyDay bool
+}
+
+// ParseSchedule parses schedule in cron format of five fields: minute, hour, day of month, month and
+// day of week. Fields may be *, numbers, ranges (1-5), lists of them (1,3-5) and steps (*/15, 0-30/10).
+// Descriptors @hourly, @daily, @weekly, @monthly and @yearly are accepted as well
+func ParseSchedule(spec string) (*Schedule, error) {
+	expanded := spec
+	if descriptor, ok := scheduleDescriptors[strings.TrimSpace(spec)]; ok {
+		expanded = descriptor
+	}
+	fields := strings.Fields(expanded)
+	if len(fields) != len(scheduleFields) {
+		return nil, fmt.Errorf("Schedule %q must have %d fields", spec, len(scheduleFields))
+	}
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseScheduleField(field, scheduleFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("Schedule %q: %v", spec, err)
+		}
+		sets[i] = set
+	}
+	// both 0 and 7 mean Sunday
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	return &Schedule{
+		spec:     spec,
+		minutes:  sets[0],
+		hours:    sets[1],
+		days:     sets[2],
+		months:   sets[3],
+		weekdays: sets[4],
+		anyDay:   strings.HasPrefix(fields[2], "*") || strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// parseScheduleField returns bit set of values matching the cron field
+func parseScheduleField(field string, f scheduleField) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %s %q", f.name, part)
+			}
+			part = part[:i]
+		}
+		low, high := f.min, f.max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid %s %q", f.name, part)
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid %s %q", f.name, part)
+				}
+			} else if step > 1 {
+				// a/n means from a to the end of the range
+				high = f.max
+			}
+		}
+		if low < f.min || high > f.max || low > high {
+			return 0, fmt.Errorf("%s %q is out of range %d-%d", f.name, part, f.min, f.max)
+		}
+		for value := low; value <= high; value += step {
+			set |= 1 << uint(value)
+		}
+	}
+	return set, nil
+}
+
+// has returns true if the value is in the bit set
+func has(set uint64, value int) bool {
+	return set&(1<<uint(value)) != 0
+}
+
+// matchesDay returns true if the day of the time matches the schedule
+func (s *Schedule) matchesDay(t time.Time) bool {
+	day := has(s.days, t.Day())
+	weekday := has(s.weekdays, int(t.Weekday()))
+	if s.anyDay {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+// Next returns the first minute matching the schedule after the time, or zero time if there is none
+// within five years, e.g. for February 30
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !has(s.months, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !has(s.hours, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !has(s.minutes, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Start fires the trigger at every minute matching the schedule until stop channel is closed
+func (s *Schedule) Start(fire chan<- string, stop <-chan struct{}) {
+	for {
+		next := s.Next(time.Now())
+		if next.IsZero() {
+			return
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(next.Sub(time.Now())):
+			send(fire, s.String(), stop)
+		}
+	}
+}
+
+func (s *Schedule) String() string {
+	return "schedule " + s.spec
+}
@@ -0,0 +1,66 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"testing"
+	"time"
+)
+
+// TestScheduleNext checks that next run time is the first minute matching the schedule
+func TestScheduleNext(t *testing.T) {
+	// 2017-03-15 is Wednesday
+	now := time.Date(2017, time.March, 15, 10, 20, 30, 0, time.UTC)
+	cases := map[string]time.Time{
+		"* * * * *":       time.Date(2017, time.March, 15, 10, 21, 0, 0, time.UTC),
+		"*/15 * * * *":    time.Date(2017, time.March, 15, 10, 30, 0, 0, time.UTC),
+		"0 9-17 * * 1-5":  time.Date(2017, time.March, 15, 11, 0, 0, 0, time.UTC),
+		"30 2 * * 0":      time.Date(2017, time.March, 19, 2, 30, 0, 0, time.UTC),
+		"30 2 * * 7":      time.Date(2017, time.March, 19, 2, 30, 0, 0, time.UTC),
+		"0 0 1,15 * *":    time.Date(2017, time.April, 1, 0, 0, 0, 0, time.UTC),
+		"@yearly":         time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 13 * 5":      time.Date(2017, time.March, 17, 0, 0, 0, 0, time.UTC),
+		"5/20 10 15 3 *":  time.Date(2017, time.March, 15, 10, 25, 0, 0, time.UTC),
+		"0 12 29 2 *":     time.Date(2020, time.February, 29, 12, 0, 0, 0, time.UTC),
+		"0,45 10 * * 3,4": time.Date(2017, time.March, 15, 10, 45, 0, 0, time.UTC),
+	}
+	for spec, expected := range cases {
+		schedule, err := ParseSchedule(spec)
+		if err != nil {
+			t.Errorf("Error parsing %q: %v", spec, err)
+			continue
+		}
+		if next := schedule.Next(now); !next.Equal(expected) {
+			t.Errorf("Expected next run of %q at %v, got %v", spec, expected, next)
+		}
+	}
+
+	schedule, err := ParseSchedule("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := schedule.Next(now); !next.IsZero() {
+		t.Errorf("Expected no runs on February 30, got %v", next)
+	}
+}
+
+// TestParseScheduleErrors checks that invalid schedules are rejected
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *", "@often"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("Error expected for schedule %q", spec)
+		}
+	}
+}
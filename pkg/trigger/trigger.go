@@ -0,0 +1,84 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package trigger starts graph runs on a cron schedule, on changes of annotated config maps and on
+// webhook requests. Triggers fired while the graph is being deployed are coalesced into a single run
+// after the current one
+package trigger
+
+import (
+	"fmt"
+
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// Trigger decides when the graph should be deployed
+type Trigger interface {
+	fmt.Stringer
+	// Start sends reason of the run to fire channel whenever the graph should be deployed, until stop
+	// channel is closed
+	Start(fire chan<- string, stop <-chan struct{})
+}
+
+// send sends reason of the run to fire channel unless stop channel is closed
+func send(fire chan<- string, reason string, stop <-chan struct{}) {
+	select {
+	case fire <- reason:
+	case <-stop:
+	}
+}
+
+// Run starts the triggers and calls run whenever any of them fires, until stop channel is closed.
+// Runs do not overlap: triggers fired during a run cause one more run after it. Errors of runs are
+// logged
+func Run(triggers []Trigger, run func(reason string) error, stop <-chan struct{}) {
+	fire := make(chan string)
+	for _, t := range triggers {
+		logging.Infof("Waiting for %s", t)
+		go t.Start(fire, stop)
+	}
+
+	done := make(chan struct{})
+	running := false
+	pending := ""
+	start := func(reason string) {
+		running = true
+		logging.Infof("Deploying the graph triggered by %s", reason)
+		go func() {
+			if err := run(reason); err != nil {
+				logging.Errorf("Graph run triggered by %s failed: %v", reason, err)
+			}
+			done <- struct{}{}
+		}()
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case reason := <-fire:
+			if running {
+				pending = reason
+				continue
+			}
+			start(reason)
+		case <-done:
+			running = false
+			if pending != "" {
+				start(pending)
+				pending = ""
+			}
+		}
+	}
+}
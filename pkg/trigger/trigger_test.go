@@ -0,0 +1,121 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// manualTrigger fires whenever a reason is sent to its channel, and reports the reason back once it
+// is received by the runner
+type manualTrigger struct {
+	reasons   chan string
+	delivered chan string
+}
+
+func (m manualTrigger) Start(fire chan<- string, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case reason := <-m.reasons:
+			send(fire, reason, stop)
+			m.delivered <- reason
+		}
+	}
+}
+
+func (m manualTrigger) String() string {
+	return "manual trigger"
+}
+
+// fire fires the trigger and waits until the runner receives it
+func (m manualTrigger) fire(reason string) {
+	m.reasons <- reason
+	<-m.delivered
+}
+
+// TestRunCoalescesTriggers checks that triggers fired during a run cause a single run after it
+func TestRunCoalescesTriggers(t *testing.T) {
+	trigger := manualTrigger{reasons: make(chan string), delivered: make(chan string)}
+	stop := make(chan struct{})
+	defer close(stop)
+
+	started := make(chan string, 10)
+	release := make(chan struct{})
+	go Run([]Trigger{trigger}, func(reason string) error {
+		started <- reason
+		<-release
+		return nil
+	}, stop)
+
+	trigger.fire("first")
+	if reason := <-started; reason != "first" {
+		t.Fatalf("Expected run triggered by first, got %s", reason)
+	}
+	trigger.fire("second")
+	trigger.fire("third")
+	release <- struct{}{}
+
+	select {
+	case reason := <-started:
+		if reason != "third" {
+			t.Errorf("Expected run triggered by the last trigger, got %s", reason)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Pending run was not started")
+	}
+	release <- struct{}{}
+
+	select {
+	case reason := <-started:
+		t.Errorf("Unexpected run triggered by %s", reason)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestWebhookHandler checks that only authorized POST requests fire the trigger
+func TestWebhookHandler(t *testing.T) {
+	fire := make(chan string, 1)
+	handler := Webhook{Token: "secret"}.Handler(fire)
+
+	cases := []struct {
+		method, url, token string
+		code               int
+	}{
+		{"GET", WebhookPath, "secret", http.StatusMethodNotAllowed},
+		{"POST", WebhookPath, "", http.StatusUnauthorized},
+		{"POST", WebhookPath, "wrong", http.StatusUnauthorized},
+		{"POST", WebhookPath, "secret", http.StatusAccepted},
+		{"POST", WebhookPath + "?token=secret", "", http.StatusAccepted},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(c.method, c.url, nil)
+		if c.token != "" {
+			r.Header.Set(WebhookTokenHeader, c.token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != c.code {
+			t.Errorf("Expected code %d for %s %s with token %q, got %d", c.code, c.method, c.url, c.token, w.Code)
+		}
+		if c.code == http.StatusAccepted {
+			<-fire
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2016 Mirantis
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Mirantis/k8s-AppController/pkg/logging"
+)
+
+// WebhookPath is a path on which webhook requests are accepted
+const WebhookPath = "/trigger"
+
+// WebhookTokenHeader is a header of webhook requests with the token. The token may be given in token
+// query parameter as well
+const WebhookTokenHeader = "X-AppController-Token"
+
+// webhookTimeout is a time after which webhook requests are rejected if the trigger is stopped
+const webhookTimeout = time.Second * 5
+
+// Webhook triggers the graph on POST requests to WebhookPath on the address
+type Webhook struct {
+	Address string
+	// Token must be given by requests, if it is set
+	Token string
+}
+
+// Handler returns HTTP handler sending to fire channel on authorized POST requests
+func (w Webhook) Handler(fire chan<- string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(WebhookPath, func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		if r.Method != "POST" {
+			rw.Header().Set("Allow", "POST")
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		token := r.Header.Get(WebhookTokenHeader)
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+		if w.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(w.Token)) != 1 {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		select {
+		case fire <- "webhook from " + r.RemoteAddr:
+		case <-time.After(webhookTimeout):
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		rw.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(rw).Encode(map[string]bool{"triggered": true})
+	})
+	return mux
+}
+
+// Start serves webhook requests. The server keeps running after stop channel is closed, but
+// requests are not accepted anymore
+func (w Webhook) Start(fire chan<- string, stop <-chan struct{}) {
+	requests := make(chan string)
+	go func() {
+		if err := http.ListenAndServe(w.Address, w.Handler(requests)); err != nil {
+			logging.Errorf("Webhook trigger server failed: %v", err)
+		}
+	}()
+	for {
+		select {
+		case <-stop:
+			return
+		case reason := <-requests:
+			send(fire, reason, stop)
+		}
+	}
+}
+
+func (w Webhook) String() string {
+	return fmt.Sprintf("webhooks on %s%s", w.Address, WebhookPath)
+}